@@ -0,0 +1,50 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientutil
+
+import "time"
+
+// CacheOptions configure caching. The zero value of each field selects its
+// default.
+type CacheOptions struct {
+	// TTL is the longest time a response is cached. The default is one
+	// hour.
+	TTL time.Duration
+	// MaxEntries is the number of responses kept; the least recently
+	// used are evicted to make room. The default is 10000.
+	MaxEntries int
+	// Lookup, if set, is called for each cacheable request, reporting
+	// whether it was answered from the cache without a call to the API,
+	// so that the hit rate can be measured. Its name is the gRPC method,
+	// or the host of the HTTP request.
+	Lookup func(name string, hit bool)
+}
+
+func cacheDefaults(opts *CacheOptions) CacheOptions {
+	var o CacheOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.TTL <= 0 {
+		o.TTL = time.Hour
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = 10000
+	}
+	if o.Lookup == nil {
+		o.Lookup = func(string, bool) {}
+	}
+	return o
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientutil
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestUnaryCacheInterceptor(t *testing.T) {
+	calls := 0
+	// The invoker echoes the request, or fails for "fail".
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		in := req.(*wrapperspb.StringValue)
+		if in.GetValue() == "fail" {
+			return status.Error(codes.Unavailable, "unavailable")
+		}
+		proto.Merge(reply.(proto.Message), in)
+		return nil
+	}
+	hits, misses := 0, 0
+	lookup := func(name string, hit bool) {
+		if hit {
+			hits++
+		} else {
+			misses++
+		}
+	}
+	intercept := UnaryCacheInterceptor(&CacheOptions{TTL: 20 * time.Millisecond, Lookup: lookup})
+	call := func(method, v string) (string, error) {
+		out := new(wrapperspb.StringValue)
+		err := intercept(context.Background(), method, wrapperspb.String(v), out, nil, invoker)
+		return out.GetValue(), err
+	}
+	for _, c := range []struct {
+		method, value string
+		wantCalls     int
+	}{
+		{"/m", "a", 1},
+		{"/m", "a", 1},
+		{"/m", "b", 2},
+		{"/n", "a", 3},
+		{"/m", "fail", 4},
+		{"/m", "fail", 5},
+		{"/m", "a", 5},
+	} {
+		got, err := call(c.method, c.value)
+		if c.value == "fail" {
+			if err == nil {
+				t.Errorf("call(%q, %q) succeeded", c.method, c.value)
+			}
+		} else if err != nil || got != c.value {
+			t.Errorf("call(%q, %q) = %q, %v", c.method, c.value, got, err)
+		}
+		if calls != c.wantCalls {
+			t.Errorf("after call(%q, %q): got %d calls, want %d", c.method, c.value, calls, c.wantCalls)
+		}
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := call("/m", "a"); err != nil || calls != 6 {
+		t.Errorf("after expiry: got %v after %d calls, want success after 6", err, calls)
+	}
+	if hits != 2 || misses != 6 {
+		t.Errorf("Lookup: got %d hits and %d misses, want 2 and 6", hits, misses)
+	}
+}
+
+func TestCachingTransport(t *testing.T) {
+	requests := 0
+	revalidated := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "public, max-age=3600")
+		case "/nostore":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				revalidated++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("body of " + r.URL.Path))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: CachingTransport(nil, nil)}
+	get := func(path string) string {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", path, err)
+		}
+		return string(body)
+	}
+	for _, c := range []struct {
+		path         string
+		wantRequests int
+	}{
+		{"/fresh", 1},
+		{"/fresh", 1},
+		{"/nostore", 2},
+		{"/nostore", 3},
+		{"/missing", 4},
+		{"/missing", 5},
+		{"/etag", 6},
+		{"/etag", 7},
+		{"/etag", 8},
+	} {
+		body := get(c.path)
+		if c.path != "/missing" && body != "body of "+c.path {
+			t.Errorf("Get(%s): got body %q", c.path, body)
+		}
+		if requests != c.wantRequests {
+			t.Errorf("after Get(%s): got %d requests, want %d", c.path, requests, c.wantRequests)
+		}
+	}
+	if revalidated != 2 {
+		t.Errorf("got %d revalidations, want 2", revalidated)
+	}
+}
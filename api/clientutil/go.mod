@@ -0,0 +1,18 @@
+module deps.dev/api/clientutil
+
+go 1.23.4
+
+replace deps.dev/util/cache => ../../util/cache
+
+require (
+	deps.dev/util/cache v0.0.0-00010101000000-000000000000
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1
+	google.golang.org/grpc v1.69.4
+	google.golang.org/protobuf v1.36.2
+)
+
+require (
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+)
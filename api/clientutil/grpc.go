@@ -0,0 +1,105 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientutil
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"deps.dev/util/cache"
+)
+
+// UnaryRetryInterceptor returns a gRPC client interceptor that retries calls
+// failing with UNAVAILABLE or RESOURCE_EXHAUSTED. If the error carries a
+// RetryInfo detail, its delay is used before the retry. If opts is nil, the
+// defaults are used.
+func UnaryRetryInterceptor(opts *RetryOptions) grpc.UnaryClientInterceptor {
+	o := withDefaults(opts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, callOpts...)
+			if err == nil || attempt == o.MaxAttempts {
+				return err
+			}
+			s := status.Convert(err)
+			switch s.Code() {
+			case codes.Unavailable, codes.ResourceExhausted:
+			default:
+				return err
+			}
+			d := o.delay(attempt)
+			for _, det := range s.Details() {
+				if ri, ok := det.(*errdetails.RetryInfo); ok && ri.GetRetryDelay() != nil {
+					d = ri.GetRetryDelay().AsDuration()
+				}
+			}
+			if d > o.MaxRetryAfter {
+				return err
+			}
+			if sleep(ctx, d) != nil {
+				return err
+			}
+		}
+	}
+}
+
+// cachedReply is a reply cached by UnaryCacheInterceptor.
+type cachedReply struct {
+	data    []byte // The marshaled reply.
+	expires time.Time
+}
+
+// UnaryCacheInterceptor returns a gRPC client interceptor that memoizes
+// successful calls, keyed by method and request message, so that repeated
+// requests for the same data are answered without calling the API. Replies
+// are kept for the TTL of the options. If opts is nil, the defaults are
+// used.
+func UnaryCacheInterceptor(opts *CacheOptions) grpc.UnaryClientInterceptor {
+	o := cacheDefaults(opts)
+	replies := cache.New(&cache.Options[string, cachedReply]{Size: o.MaxEntries})
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		in, inOK := req.(proto.Message)
+		out, outOK := reply.(proto.Message)
+		if !inOK || !outOK {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(in)
+		if err != nil {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		key := method + "\x00" + string(data)
+		if r, ok := replies.Get(key); ok {
+			if time.Now().Before(r.expires) {
+				o.Lookup(method, true)
+				return proto.Unmarshal(r.data, out)
+			}
+			replies.Remove(key)
+		}
+		o.Lookup(method, false)
+		if err := invoker(ctx, method, req, reply, cc, callOpts...); err != nil {
+			return err
+		}
+		if data, err := proto.Marshal(out); err == nil {
+			replies.Put(key, cachedReply{data: data, expires: time.Now().Add(o.TTL)})
+		}
+		return nil
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientutil
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"deps.dev/util/cache"
+)
+
+// retryTransport is an http.RoundTripper that retries requests.
+type retryTransport struct {
+	base http.RoundTripper
+	opts RetryOptions
+}
+
+// RetryTransport returns an http.RoundTripper that makes requests with base,
+// retrying those whose responses have the status 429 Too Many Requests, 502
+// Bad Gateway, 503 Service Unavailable or 504 Gateway Timeout, or that fail
+// without a response. The Retry-After header of a response, if present, gives
+// the delay before the retry. Requests with a body are only retried if the
+// body can be obtained again with GetBody, as is the case for requests made
+// by http.NewRequest with common body types. If base is nil,
+// http.DefaultTransport is used; if opts is nil, the defaults are used.
+func RetryTransport(base http.RoundTripper, opts *RetryOptions) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{base: base, opts: withDefaults(opts)}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt == t.opts.MaxAttempts || req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+		d := t.opts.delay(attempt)
+		if err == nil {
+			switch resp.StatusCode {
+			case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			default:
+				return resp, nil
+			}
+			if ra, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				d = ra
+			}
+			if d > t.opts.MaxRetryAfter {
+				return resp, nil
+			}
+		}
+		if ctx.Err() != nil {
+			return resp, err
+		}
+		if err := sleep(ctx, d); err != nil {
+			if resp != nil {
+				return resp, nil
+			}
+			return nil, err
+		}
+		if resp != nil {
+			// Drain the body so the connection may be reused.
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// retryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or a date, returning the delay it asks for.
+func retryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// cachedResponse is a response cached by CachingTransport.
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// response returns the cached response as the response to req.
+func (c *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(c.status) + " " + http.StatusText(c.status),
+		StatusCode:    c.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
+
+// cachingTransport is an http.RoundTripper that caches responses.
+type cachingTransport struct {
+	base  http.RoundTripper
+	opts  CacheOptions
+	cache *cache.Cache[string, *cachedResponse]
+}
+
+// CachingTransport returns an http.RoundTripper that makes requests with
+// base, caching the successful responses to GET requests. A response is
+// cached for as long as its Cache-Control header allows, up to the TTL of
+// the options, and not at all if it says no-store. Once a cached response
+// has expired, if it has an ETag or Last-Modified header it is revalidated
+// with a conditional request, and reused if the server replies that it is
+// unchanged. If base is nil, http.DefaultTransport is used; if opts is nil,
+// the defaults are used.
+func CachingTransport(base http.RoundTripper, opts *CacheOptions) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	o := cacheDefaults(opts)
+	return &cachingTransport{base: base, opts: o, cache: cache.New(&cache.Options[string, *cachedResponse]{Size: o.MaxEntries})}
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+	key := req.URL.String() + "\x00" + req.Header.Get("Accept")
+	cached, ok := t.cache.Get(key)
+	if ok && time.Now().Before(cached.expires) {
+		t.opts.Lookup(req.URL.Host, true)
+		return cached.response(req), nil
+	}
+	t.opts.Lookup(req.URL.Host, false)
+	r := req
+	if ok {
+		etag, modified := cached.header.Get("ETag"), cached.header.Get("Last-Modified")
+		if etag == "" && modified == "" {
+			t.cache.Remove(key)
+			ok = false
+		} else {
+			r = req.Clone(req.Context())
+			if etag != "" {
+				r.Header.Set("If-None-Match", etag)
+			}
+			if modified != "" {
+				r.Header.Set("If-Modified-Since", modified)
+			}
+		}
+	}
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	if ok && resp.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		resp.Body.Close()
+		ttl, _ := t.lifetime(resp.Header)
+		refreshed := *cached
+		refreshed.expires = time.Now().Add(ttl)
+		t.cache.Put(key, &refreshed)
+		return refreshed.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	ttl, cacheable := t.lifetime(resp.Header)
+	if !cacheable || ttl == 0 && resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "" {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.cache.Put(key, &cachedResponse{
+		status:  resp.StatusCode,
+		header:  resp.Header.Clone(),
+		body:    body,
+		expires: time.Now().Add(ttl),
+	})
+	return resp, nil
+}
+
+// lifetime returns how long a response with the given header may be used
+// without revalidation, and whether it may be cached at all.
+func (t *cachingTransport) lifetime(h http.Header) (time.Duration, bool) {
+	ttl := t.opts.TTL
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		name, val, _ := strings.Cut(strings.TrimSpace(d), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			return 0, false
+		case "no-cache":
+			ttl = 0
+		case "max-age":
+			if s, err := strconv.Atoi(strings.Trim(val, `"`)); err == nil && s >= 0 {
+				ttl = min(ttl, time.Duration(s)*time.Second)
+			}
+		}
+	}
+	return ttl, true
+}
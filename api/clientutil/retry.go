@@ -0,0 +1,109 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package clientutil helps clients of the deps.dev API cope with transient
+errors, such as those caused by exceeding the API's rate limits, and avoid
+repeating requests.
+
+UnaryRetryInterceptor returns a gRPC client interceptor, and RetryTransport
+an HTTP transport, that retry requests failing with a transient error:
+UNAVAILABLE or RESOURCE_EXHAUSTED for gRPC, and the equivalent status codes
+for HTTP. Retries wait for an exponentially increasing, randomly jittered
+delay, or for as long as the server asks if it says how long to wait.
+
+	conn, err := grpc.NewClient("api.deps.dev:443",
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUnaryInterceptor(clientutil.UnaryRetryInterceptor(nil)),
+	)
+
+	client := &http.Client{Transport: clientutil.RetryTransport(nil, nil)}
+
+UnaryCacheInterceptor and CachingTransport similarly cache responses, so
+that programs requesting the same data repeatedly, such as CI pipelines, do
+not use up their quota.
+
+A Throttle spaces requests to stay within the quota of the server, starting
+at a given rate, cutting it when the quota is exhausted and ramping it back
+up while requests succeed, so that clients neither leave their allowance
+unused nor keep exceeding it.
+*/
+package clientutil
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryOptions configure retries. The zero value of each field selects its
+// default, which suits the public deps.dev API.
+type RetryOptions struct {
+	// MaxAttempts is the number of times a request is attempted before
+	// its error is returned. The default is 5.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. Each following
+	// delay is twice as long, up to MaxBackoff. The actual delays are
+	// jittered to between half and all of these values. The defaults are
+	// 1s and 30s.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// MaxRetryAfter limits how long the server may ask a request to wait
+	// before it is retried. If the server asks for longer, the error is
+	// returned. The default is one minute.
+	MaxRetryAfter time.Duration
+}
+
+func withDefaults(opts *RetryOptions) RetryOptions {
+	var o RetryOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 30 * time.Second
+	}
+	if o.MaxRetryAfter <= 0 {
+		o.MaxRetryAfter = time.Minute
+	}
+	return o
+}
+
+// delay returns how long to wait before the given retry, counting from 1,
+// if the server did not say.
+func (o *RetryOptions) delay(retry int) time.Duration {
+	d := o.InitialBackoff
+	for i := 1; i < retry && d < o.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, o.MaxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
+
+// sleep waits for d or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
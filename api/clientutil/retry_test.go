@@ -0,0 +1,179 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientutil
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var fast = &RetryOptions{InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+
+func TestDelay(t *testing.T) {
+	o := withDefaults(nil)
+	for _, c := range []struct {
+		retry int
+		want  time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 30 * time.Second},
+		{20, 30 * time.Second},
+	} {
+		if d := o.delay(c.retry); d < c.want/2 || d > c.want {
+			t.Errorf("delay(%d) = %v, want between %v and %v", c.retry, d, c.want/2, c.want)
+		}
+	}
+}
+
+// invoker returns a grpc.UnaryInvoker failing with the given errors in turn,
+// then succeeding.
+func invoker(calls *int, errs ...error) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		*calls++
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+func TestUnaryRetryInterceptor(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	exhausted := status.Error(codes.ResourceExhausted, "slow down")
+	notFound := status.Error(codes.NotFound, "not found")
+	for _, c := range []struct {
+		name      string
+		errs      []error
+		wantCode  codes.Code
+		wantCalls int
+	}{
+		{"success", nil, codes.OK, 1},
+		{"transient", []error{unavailable, exhausted}, codes.OK, 3},
+		{"permanent", []error{notFound}, codes.NotFound, 1},
+		{"exhausted", []error{unavailable, unavailable, unavailable, unavailable, unavailable}, codes.Unavailable, 5},
+	} {
+		calls := 0
+		intercept := UnaryRetryInterceptor(fast)
+		err := intercept(context.Background(), "/m", nil, nil, nil, invoker(&calls, c.errs...))
+		if status.Code(err) != c.wantCode || calls != c.wantCalls {
+			t.Errorf("%s: got %v after %d calls, want %v after %d", c.name, err, calls, c.wantCode, c.wantCalls)
+		}
+	}
+}
+
+func TestUnaryRetryInterceptorRetryInfo(t *testing.T) {
+	s, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(20 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	start := time.Now()
+	err = UnaryRetryInterceptor(fast)(context.Background(), "/m", nil, nil, nil, invoker(&calls, s.Err()))
+	if err != nil || calls != 2 {
+		t.Errorf("got %v after %d calls, want success after 2", err, calls)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("retried after %v, want at least 20ms", d)
+	}
+
+	// A delay longer than MaxRetryAfter is not waited for.
+	calls = 0
+	o := *fast
+	o.MaxRetryAfter = 10 * time.Millisecond
+	err = UnaryRetryInterceptor(&o)(context.Background(), "/m", nil, nil, nil, invoker(&calls, s.Err()))
+	if status.Code(err) != codes.ResourceExhausted || calls != 1 {
+		t.Errorf("got %v after %d calls, want ResourceExhausted after 1", err, calls)
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		switch len(bodies) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: RetryTransport(nil, fast)}
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %s, want 200 OK", resp.Status)
+	}
+	if len(bodies) != 3 || bodies[0] != "body" || bodies[2] != "body" {
+		t.Errorf("got request bodies %q, want three of \"body\"", bodies)
+	}
+
+	// Other errors are not retried.
+	bodies = nil
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodies = append(bodies, "")
+		http.NotFound(w, r)
+	})
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || len(bodies) != 1 {
+		t.Errorf("got status %s after %d requests, want 404 after 1", resp.Status, len(bodies))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		v    string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"Mon, 01 Jan 2024 00:00:30 GMT", 30 * time.Second, true},
+		{"Sun, 31 Dec 2023 00:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	} {
+		got, ok := retryAfter(c.v, now)
+		if got != c.want || ok != c.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", c.v, got, ok, c.want, c.ok)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientutil
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ThrottleOptions configure a Throttle. The zero value of each field
+// selects its default.
+type ThrottleOptions struct {
+	// RPS is the initial rate of requests per second. The default is 100.
+	RPS float64
+	// MinRPS and MaxRPS bound the rate. The defaults are 1 and RPS, so
+	// that the rate ramps back up to where it started; a higher MaxRPS
+	// lets it probe for a larger quota.
+	MinRPS, MaxRPS float64
+	// Backoff is the factor the rate is multiplied by when the server
+	// reports that the quota is exhausted. The default is 0.5.
+	Backoff float64
+	// RampUp is the rate added for each second without quota errors. The
+	// default is a tenth of RPS.
+	RampUp float64
+}
+
+// decreaseInterval is the shortest time between two decreases of the rate:
+// concurrent requests tend to exhaust the quota together, and their errors
+// are one signal rather than several.
+const decreaseInterval = time.Second
+
+// Throttle spaces requests evenly at a rate that adapts to the quota of the
+// server: the rate is cut when requests fail with RESOURCE_EXHAUSTED, or
+// HTTP status 429, and ramps back up linearly while they succeed. It is
+// safe for concurrent use, and may be shared by several clients of the
+// same quota.
+type Throttle struct {
+	opts ThrottleOptions
+
+	mu       sync.Mutex
+	rate     float64   // The rate at since.
+	since    time.Time // When the rate was last set.
+	decrease time.Time // When the rate was last decreased.
+	next     time.Time // The earliest time of the next request.
+}
+
+// NewThrottle returns a Throttle. If opts is nil, the defaults are used.
+func NewThrottle(opts *ThrottleOptions) *Throttle {
+	var o ThrottleOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.RPS <= 0 {
+		o.RPS = 100
+	}
+	if o.MinRPS <= 0 {
+		o.MinRPS = min(1, o.RPS)
+	}
+	if o.MaxRPS < o.RPS {
+		o.MaxRPS = o.RPS
+	}
+	if o.Backoff <= 0 || o.Backoff >= 1 {
+		o.Backoff = 0.5
+	}
+	if o.RampUp <= 0 {
+		o.RampUp = o.RPS / 10
+	}
+	return &Throttle{opts: o, rate: o.RPS, since: time.Now()}
+}
+
+// current returns the rate at the given time. The mutex must be held.
+func (t *Throttle) current(now time.Time) float64 {
+	r := t.rate + t.opts.RampUp*now.Sub(t.since).Seconds()
+	return min(r, t.opts.MaxRPS)
+}
+
+// Rate returns the current rate of requests per second.
+func (t *Throttle) Rate() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current(time.Now())
+}
+
+// Wait blocks until the next request may be made or the context is done.
+func (t *Throttle) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	at := t.next
+	if at.Before(now) {
+		at = now
+	}
+	t.next = at.Add(time.Duration(float64(time.Second) / t.current(now)))
+	t.mu.Unlock()
+	d := time.Until(at)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	return sleep(ctx, d)
+}
+
+// Exhausted reports that a request failed because the quota is exhausted,
+// decreasing the rate.
+func (t *Throttle) Exhausted() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if now.Sub(t.decrease) < decreaseInterval {
+		return
+	}
+	t.rate = max(t.current(now)*t.opts.Backoff, t.opts.MinRPS)
+	t.since, t.decrease = now, now
+	// Requests already scheduled keep their slots; the following ones
+	// are spaced at the new rate.
+}
+
+// UnaryClientInterceptor returns a gRPC client interceptor that waits for
+// the throttle before each call. To throttle each attempt of retried calls,
+// chain it after UnaryRetryInterceptor:
+//
+//	grpc.WithChainUnaryInterceptor(
+//		clientutil.UnaryRetryInterceptor(nil),
+//		throttle.UnaryClientInterceptor(),
+//	)
+func (t *Throttle) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := t.Wait(ctx); err != nil {
+			return err
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if status.Code(err) == codes.ResourceExhausted {
+			t.Exhausted()
+		}
+		return err
+	}
+}
+
+// Transport returns an http.RoundTripper that makes requests with base,
+// waiting for the throttle before each. If base is nil,
+// http.DefaultTransport is used. To throttle each attempt of retried
+// requests, use it as the base of RetryTransport.
+func (t *Throttle) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &throttleTransport{base: base, throttle: t}
+}
+
+type throttleTransport struct {
+	base     http.RoundTripper
+	throttle *Throttle
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.throttle.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		t.throttle.Exhausted()
+	}
+	return resp, err
+}
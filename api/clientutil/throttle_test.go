@@ -0,0 +1,114 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestThrottleAdapts(t *testing.T) {
+	th := NewThrottle(&ThrottleOptions{RPS: 100, MinRPS: 10, RampUp: 1000})
+	if got := th.Rate(); got != 100 {
+		t.Errorf("initial rate: got %v, want 100", got)
+	}
+	th.Exhausted()
+	if got := th.Rate(); got < 50 || got > 60 {
+		t.Errorf("rate after exhaustion: got %v, want about 50", got)
+	}
+	// Further errors within a second are not counted.
+	th.Exhausted()
+	if got := th.Rate(); got < 50 {
+		t.Errorf("rate after a second error: got %v, want about 50", got)
+	}
+	// The rate ramps up at 1000 RPS per second, back to at most RPS.
+	time.Sleep(60 * time.Millisecond)
+	if got := th.Rate(); got != 100 {
+		t.Errorf("rate after ramping up: got %v, want 100", got)
+	}
+
+	// The rate does not fall below MinRPS.
+	th = NewThrottle(&ThrottleOptions{RPS: 12, MinRPS: 10, RampUp: 1e-9})
+	th.Exhausted()
+	if got := th.Rate(); got < 10 || got > 10.01 {
+		t.Errorf("rate after exhaustion: got %v, want 10", got)
+	}
+}
+
+func TestThrottleWait(t *testing.T) {
+	th := NewThrottle(&ThrottleOptions{RPS: 100})
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := th.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first request is immediate and the others 10ms apart.
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("5 requests at 100 RPS took %v, want at least 40ms", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	th = NewThrottle(&ThrottleOptions{RPS: 1})
+	th.Wait(ctx)
+	if err := th.Wait(ctx); err == nil {
+		t.Errorf("Wait with cancelled context succeeded")
+	}
+}
+
+func TestThrottleInterceptor(t *testing.T) {
+	th := NewThrottle(&ThrottleOptions{RPS: 1000, RampUp: 1e-9})
+	intercept := th.UnaryClientInterceptor()
+	var calls, exhaustedCalls int
+	if err := intercept(context.Background(), "/m", nil, nil, nil, invoker(&calls, status.Error(codes.NotFound, "not found"))); status.Code(err) != codes.NotFound {
+		t.Errorf("got error %v, want NotFound", err)
+	}
+	if got := th.Rate(); got < 999 {
+		t.Errorf("rate after NotFound: got %v, want 1000", got)
+	}
+	if err := intercept(context.Background(), "/m", nil, nil, nil, invoker(&exhaustedCalls, status.Error(codes.ResourceExhausted, "quota"))); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("got error %v, want ResourceExhausted", err)
+	}
+	if got := th.Rate(); got > 501 {
+		t.Errorf("rate after ResourceExhausted: got %v, want 500", got)
+	}
+	if calls != 1 || exhaustedCalls != 1 {
+		t.Errorf("got %d and %d calls, want 1 each", calls, exhaustedCalls)
+	}
+}
+
+func TestThrottleTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	th := NewThrottle(&ThrottleOptions{RPS: 1000, RampUp: 1e-9})
+	client := &http.Client{Transport: th.Transport(nil)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if got := th.Rate(); got > 501 {
+		t.Errorf("rate after status 429: got %v, want 500", got)
+	}
+}
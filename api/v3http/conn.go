@@ -0,0 +1,334 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package v3http lets the generated clients of the deps.dev API make their
+calls over the HTTP API rather than gRPC, for users who can only make HTTP
+requests. Requests and responses are the generated protocol buffer messages
+of the deps.dev/api/v3 and deps.dev/api/v3alpha packages, encoded as the
+JSON the HTTP API uses, so no fields are lost to partial hand-written types.
+
+The URL of each call is built from the google.api.http annotation of its
+method in the service definition, so every method of both versions of the
+API is supported:
+
+	client := pb.NewInsightsClient(v3http.NewConn("", nil))
+	pkg, err := client.GetPackage(ctx, &pb.GetPackageRequest{
+		PackageKey: &pb.PackageKey{System: pb.System_NPM, Name: "@colors/colors"},
+	})
+
+Errors returned by the HTTP API are converted to gRPC status errors, so
+they can be inspected with the status package as for the gRPC API.
+*/
+package v3http
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// DefaultURL is the base URL of the deps.dev HTTP API.
+const DefaultURL = "https://api.deps.dev"
+
+// Conn is a grpc.ClientConnInterface that makes unary calls using the
+// deps.dev HTTP API. Streaming calls are not supported. It is safe for
+// concurrent use.
+type Conn struct {
+	base   string
+	client *http.Client
+
+	// rules caches the HTTP rule of each method, keyed by the full method
+	// name used by gRPC.
+	rules sync.Map
+}
+
+// NewConn returns a Conn that sends requests to the API at the given base
+// URL using the given HTTP client. An empty URL selects DefaultURL and a nil
+// client http.DefaultClient.
+func NewConn(baseURL string, client *http.Client) *Conn {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Conn{base: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+// Invoke implements grpc.ClientConnInterface. The call options are ignored.
+func (c *Conn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	in, ok := args.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "request %T is not a protocol buffer message", args)
+	}
+	out, ok := reply.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "response %T is not a protocol buffer message", reply)
+	}
+	rule, err := c.rule(method)
+	if err != nil {
+		return err
+	}
+	req, err := newRequest(ctx, c.base, rule, in)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "reading response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, data)
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, out); err != nil {
+		return status.Errorf(codes.Internal, "parsing response: %v", err)
+	}
+	return nil
+}
+
+// NewStream implements grpc.ClientConnInterface. As the deps.dev API has no
+// streaming methods it always fails.
+func (c *Conn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Errorf(codes.Unimplemented, "streaming method %s is not supported over HTTP", method)
+}
+
+// httpRule is the HTTP mapping of a method.
+type httpRule struct {
+	method string // The HTTP method.
+	path   string // The path template.
+	body   string // The request field sent as the body; "*" for all.
+}
+
+// rule returns the HTTP mapping of the named method, as given by its
+// google.api.http annotation. The method name is of the form used by gRPC,
+// /package.Service/Method.
+func (c *Conn) rule(method string) (httpRule, error) {
+	if r, ok := c.rules.Load(method); ok {
+		return r.(httpRule), nil
+	}
+	name := strings.ReplaceAll(strings.TrimPrefix(method, "/"), "/", ".")
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return httpRule{}, status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+	md, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return httpRule{}, status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+	hr, _ := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+	var r httpRule
+	switch p := hr.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		r = httpRule{method: http.MethodGet, path: p.Get}
+	case *annotations.HttpRule_Post:
+		r = httpRule{method: http.MethodPost, path: p.Post}
+	default:
+		return httpRule{}, status.Errorf(codes.Unimplemented, "method %s has no HTTP mapping", method)
+	}
+	r.body = hr.GetBody()
+	if r.body != "" && r.body != "*" {
+		return httpRule{}, status.Errorf(codes.Unimplemented, "method %s has an unsupported HTTP body mapping", method)
+	}
+	c.rules.Store(method, r)
+	return r, nil
+}
+
+// newRequest builds the HTTP request for a call with the given rule. Fields
+// named in the path template are substituted into the path; if there is no
+// body, the remaining fields are sent as query parameters.
+func newRequest(ctx context.Context, base string, rule httpRule, in proto.Message) (*http.Request, error) {
+	var path strings.Builder
+	used := make(map[string]bool)
+	rest := rule.path
+	for {
+		i := strings.IndexByte(rest, '{')
+		if i < 0 {
+			path.WriteString(rest)
+			break
+		}
+		j := strings.IndexByte(rest[i:], '}')
+		if j < 0 {
+			return nil, status.Errorf(codes.Internal, "invalid path template %q", rule.path)
+		}
+		field := rest[i+1 : i+j]
+		v, ok := fieldValue(in.ProtoReflect(), field)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "missing %s", field)
+		}
+		path.WriteString(rest[:i])
+		path.WriteString(url.PathEscape(v))
+		used[field] = true
+		rest = rest[i+j+1:]
+	}
+	u := base + path.String()
+	var body io.Reader
+	switch rule.body {
+	case "*":
+		data, err := protojson.Marshal(in)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "encoding request: %v", err)
+		}
+		body = bytes.NewReader(data)
+	case "":
+		q := make(url.Values)
+		addQuery(q, "", in.ProtoReflect(), used)
+		if len(q) > 0 {
+			u += "?" + q.Encode()
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, rule.method, u, body)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "building request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// fieldValue returns the value of the field of m with the given dotted
+// path, formatted for a URL, and whether it is set.
+func fieldValue(m protoreflect.Message, path string) (string, bool) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil || fd.IsList() || fd.IsMap() || !m.Has(fd) {
+			return "", false
+		}
+		if i < len(names)-1 {
+			if fd.Message() == nil {
+				return "", false
+			}
+			m = m.Get(fd).Message()
+			continue
+		}
+		return formatValue(fd, m.Get(fd)), true
+	}
+	return "", false
+}
+
+// addQuery adds the set fields of m that are not used in the path to q,
+// named by their dotted paths from the request with the given prefix.
+func addQuery(q url.Values, prefix string, m protoreflect.Message, used map[string]bool) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := prefix + string(fd.Name())
+		if used[name] {
+			return true
+		}
+		switch {
+		case fd.IsMap():
+			// The API has no map fields in its requests.
+		case fd.IsList():
+			l := v.List()
+			for i := 0; i < l.Len(); i++ {
+				if fd.Message() != nil {
+					addQuery(q, name+".", l.Get(i).Message(), used)
+					continue
+				}
+				q.Add(name, formatValue(fd, l.Get(i)))
+			}
+		case fd.Message() != nil:
+			addQuery(q, name+".", v.Message(), used)
+		default:
+			q.Add(name, formatValue(fd, v))
+		}
+		return true
+	})
+}
+
+// formatValue formats a scalar field value as the HTTP API expects: enums
+// by name and bytes in standard base64.
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	}
+	return v.String()
+}
+
+// responseError converts an error response from the HTTP API into a gRPC
+// status error. The body of the response is expected to hold the status as
+// JSON; if it does not, the code is derived from the HTTP status.
+func responseError(resp *http.Response, data []byte) error {
+	var s struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &s); err == nil && s.Code != 0 {
+		return status.Error(codes.Code(s.Code), s.Message)
+	}
+	msg := strings.TrimSpace(string(data))
+	if msg == "" {
+		msg = resp.Status
+	}
+	return status.Error(httpCode(resp.StatusCode), fmt.Sprintf("HTTP %s: %s", resp.Status, msg))
+}
+
+// httpCode returns the gRPC code corresponding to an HTTP status code.
+func httpCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusInternalServerError:
+		return codes.Internal
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "deps.dev/api/v3"
+)
+
+// testServer records the requests it receives and answers them with the
+// given response, or with a NotFound status if it is nil.
+func testServer(t *testing.T, resp proto.Message) (*httptest.Server, *[]*http.Request, *[]string) {
+	t.Helper()
+	var reqs []*http.Request
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs = append(reqs, r)
+		bodies = append(bodies, string(body))
+		if resp == nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":5,"message":"package not found"}`))
+			return
+		}
+		data, err := protojson.Marshal(resp)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs, &bodies
+}
+
+func TestGetVersion(t *testing.T) {
+	want := &pb.Version{
+		VersionKey:   &pb.VersionKey{System: pb.System_NPM, Name: "@colors/colors", Version: "1.5.0"},
+		IsDefault:    true,
+		Licenses:     []string{"MIT"},
+		AdvisoryKeys: []*pb.AdvisoryKey{{Id: "GHSA-1"}},
+	}
+	srv, reqs, _ := testServer(t, want)
+	client := pb.NewInsightsClient(NewConn(srv.URL, srv.Client()))
+	got, err := client.GetVersion(context.Background(), &pb.GetVersionRequest{
+		VersionKey: &pb.VersionKey{System: pb.System_NPM, Name: "@colors/colors", Version: "1.5.0"},
+	})
+	if err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("GetVersion: got %v, want %v", got, want)
+	}
+	r := (*reqs)[0]
+	if r.Method != http.MethodGet || r.URL.EscapedPath() != "/v3/systems/NPM/packages/@colors%2Fcolors/versions/1.5.0" || r.URL.RawQuery != "" {
+		t.Errorf("GetVersion: got request %s %s?%s", r.Method, r.URL.EscapedPath(), r.URL.RawQuery)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	srv, reqs, _ := testServer(t, &pb.QueryResult{})
+	client := pb.NewInsightsClient(NewConn(srv.URL, srv.Client()))
+	_, err := client.Query(context.Background(), &pb.QueryRequest{
+		Hash:       &pb.Hash{Type: pb.HashType_SHA1, Value: []byte{0xfb, 0xff}},
+		VersionKey: &pb.VersionKey{System: pb.System_MAVEN},
+	})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	r := (*reqs)[0]
+	q := r.URL.Query()
+	if r.URL.Path != "/v3/query" || q.Get("hash.type") != "SHA1" || q.Get("hash.value") != "+/8=" || q.Get("version_key.system") != "MAVEN" || len(q) != 3 {
+		t.Errorf("Query: got request %s?%s", r.URL.Path, r.URL.RawQuery)
+	}
+}
+
+func TestError(t *testing.T) {
+	srv, _, _ := testServer(t, nil)
+	client := pb.NewInsightsClient(NewConn(srv.URL, srv.Client()))
+	_, err := client.GetPackage(context.Background(), &pb.GetPackageRequest{
+		PackageKey: &pb.PackageKey{System: pb.System_NPM, Name: "nonexistent"},
+	})
+	if s, _ := status.FromError(err); s.Code() != codes.NotFound || s.Message() != "package not found" {
+		t.Errorf("GetPackage: got error %v, want NotFound", err)
+	}
+	// A path field must be set.
+	_, err = client.GetPackage(context.Background(), &pb.GetPackageRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetPackage with no key: got error %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v3alphatest tests the use of v3http with the v3alpha API. It is
+// separate from the tests of v3http, which use the v3 API, as both APIs
+// register a file named api.proto, so cannot be linked into one binary.
+package v3alphatest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/api/v3http"
+)
+
+func TestBatch(t *testing.T) {
+	var req *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	client := pb.NewInsightsClient(v3http.NewConn(srv.URL, srv.Client()))
+	in := &pb.GetVersionBatchRequest{
+		Requests: []*pb.GetVersionRequest{{
+			VersionKey: &pb.VersionKey{System: pb.System_GO, Name: "golang.org/x/net", Version: "v0.30.0"},
+		}},
+	}
+	if _, err := client.GetVersionBatch(context.Background(), in); err != nil {
+		t.Fatalf("GetVersionBatch: %v", err)
+	}
+	if req.Method != http.MethodPost || req.URL.Path != "/v3alpha/versionbatch" {
+		t.Errorf("GetVersionBatch: got request %s %s", req.Method, req.URL.Path)
+	}
+	got := new(pb.GetVersionBatchRequest)
+	if err := protojson.Unmarshal(body, got); err != nil || !proto.Equal(got, in) {
+		t.Errorf("GetVersionBatch: got body %s (%v), want %v", body, err, in)
+	}
+}
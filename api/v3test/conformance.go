@@ -0,0 +1,299 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "deps.dev/api/v3"
+)
+
+// Fixture describes data that a backend under test is known to hold, for
+// use by RunConformance.
+type Fixture struct {
+	// Version is a package version having a resolved dependency graph.
+	// Its advisory keys must include Advisory and its related projects
+	// Project.
+	Version *pb.VersionKey
+	// Project is the ID of a project, such as github.com/google/go-cmp.
+	Project string
+	// Advisory is the ID of an advisory, such as GHSA-2qrg-x229-3v8q.
+	Advisory string
+	// Hash is the hash of an artifact of Version.
+	Hash *pb.Hash
+	// Keys are further package versions whose names or versions contain
+	// characters that need escaping in URLs or package URLs, such as
+	// scoped npm packages, Maven packages and versions with build
+	// metadata.
+	Keys []*pb.VersionKey
+}
+
+// Seed adds to s the data described by the returned Fixture.
+func Seed(s *Server) Fixture {
+	vk := &pb.VersionKey{System: pb.System_NPM, Name: "@colors/colors", Version: "1.5.0"}
+	f := Fixture{
+		Version:  vk,
+		Project:  "github.com/dabh/colors.js",
+		Advisory: "GHSA-5rqg-jm4f-cqx7",
+		Hash:     &pb.Hash{Type: pb.HashType_SHA1, Value: []byte("\x9b\x0f\x3a\x71\x3c\x02\x5a\xe3\x1e\x06\x31\x1a\x51\x4d\xf9\x65\x3f\x40\xd1\x9e")},
+		Keys: []*pb.VersionKey{
+			{System: pb.System_MAVEN, Name: "org.apache.logging.log4j:log4j-core", Version: "2.17.1"},
+			{System: pb.System_GO, Name: "golang.org/x/net", Version: "v0.0.0-20220722155237-a158d28d115b"},
+			{System: pb.System_NUGET, Name: "Newtonsoft.Json", Version: "13.0.3"},
+			{System: pb.System_CARGO, Name: "semver", Version: "1.0.0+build.1"},
+			{System: pb.System_PYPI, Name: "zope.interface", Version: "6.0"},
+		},
+	}
+	s.AddVersion(&pb.Version{VersionKey: &pb.VersionKey{System: vk.System, Name: vk.Name, Version: "1.4.0"}})
+	s.AddVersion(&pb.Version{
+		VersionKey:   vk,
+		IsDefault:    true,
+		Licenses:     []string{"MIT"},
+		AdvisoryKeys: []*pb.AdvisoryKey{{Id: f.Advisory}},
+		RelatedProjects: []*pb.Version_Project{{
+			ProjectKey:   &pb.ProjectKey{Id: f.Project},
+			RelationType: pb.ProjectRelationType_SOURCE_REPO,
+		}},
+	})
+	s.AddDependencies(vk, &pb.Dependencies{
+		Nodes: []*pb.Dependencies_Node{{VersionKey: vk, Relation: pb.DependencyRelation_SELF}},
+	})
+	s.AddRequirements(vk, &pb.Requirements{Npm: &pb.Requirements_NPM{}})
+	s.AddProject(&pb.Project{ProjectKey: &pb.ProjectKey{Id: f.Project}})
+	s.AddAdvisory(&pb.Advisory{AdvisoryKey: &pb.AdvisoryKey{Id: f.Advisory}})
+	s.AddArtifact(f.Hash, vk)
+	for _, k := range f.Keys {
+		s.AddVersion(&pb.Version{VersionKey: k})
+	}
+	return f
+}
+
+// RunConformance checks that c, a client for an implementation of the
+// Insights service holding the data described by f, behaves as
+// api.deps.dev does: each method returns the requested data, identified by
+// the requested key, requests for missing data fail with the NotFound code
+// and malformed requests with InvalidArgument. Each method is checked in
+// its own subtest.
+func RunConformance(t *testing.T, c pb.InsightsClient, f Fixture) {
+	ctx := context.Background()
+	vk := f.Version
+	missing := &pb.VersionKey{System: vk.GetSystem(), Name: vk.GetName(), Version: "0.0.0-does-not-exist"}
+
+	t.Run("GetPackage", func(t *testing.T) {
+		pk := &pb.PackageKey{System: vk.GetSystem(), Name: vk.GetName()}
+		p, err := c.GetPackage(ctx, &pb.GetPackageRequest{PackageKey: pk})
+		if err != nil {
+			t.Fatalf("GetPackage(%v): %v", pk, err)
+		}
+		if !proto.Equal(p.GetPackageKey(), pk) {
+			t.Errorf("GetPackage(%v): got key %v", pk, p.GetPackageKey())
+		}
+		seen := make(map[string]bool)
+		defaults := 0
+		for _, v := range p.GetVersions() {
+			k := v.GetVersionKey()
+			if k.GetSystem() != pk.System || k.GetName() != pk.Name {
+				t.Errorf("GetPackage(%v): version %v of another package", pk, k)
+			}
+			if seen[k.GetVersion()] {
+				t.Errorf("GetPackage(%v): version %s listed twice", pk, k.GetVersion())
+			}
+			seen[k.GetVersion()] = true
+			if v.GetIsDefault() {
+				defaults++
+			}
+		}
+		if !seen[vk.GetVersion()] {
+			t.Errorf("GetPackage(%v): version %s not listed", pk, vk.GetVersion())
+		}
+		if defaults > 1 {
+			t.Errorf("GetPackage(%v): %d default versions", pk, defaults)
+		}
+		checkCode(t, "GetPackage of unknown package", codes.NotFound, func() error {
+			_, err := c.GetPackage(ctx, &pb.GetPackageRequest{PackageKey: &pb.PackageKey{System: vk.GetSystem(), Name: "does-not-exist-" + vk.GetName()}})
+			return err
+		})
+		checkCode(t, "GetPackage without name", codes.InvalidArgument, func() error {
+			_, err := c.GetPackage(ctx, &pb.GetPackageRequest{PackageKey: &pb.PackageKey{System: vk.GetSystem()}})
+			return err
+		})
+	})
+
+	t.Run("GetVersion", func(t *testing.T) {
+		for _, k := range append([]*pb.VersionKey{vk}, f.Keys...) {
+			v, err := c.GetVersion(ctx, &pb.GetVersionRequest{VersionKey: k})
+			if err != nil {
+				t.Errorf("GetVersion(%v): %v", k, err)
+				continue
+			}
+			if !proto.Equal(v.GetVersionKey(), k) {
+				t.Errorf("GetVersion(%v): got key %v", k, v.GetVersionKey())
+			}
+		}
+		checkCode(t, "GetVersion of unknown version", codes.NotFound, func() error {
+			_, err := c.GetVersion(ctx, &pb.GetVersionRequest{VersionKey: missing})
+			return err
+		})
+		checkCode(t, "GetVersion without version", codes.InvalidArgument, func() error {
+			_, err := c.GetVersion(ctx, &pb.GetVersionRequest{VersionKey: &pb.VersionKey{System: vk.GetSystem(), Name: vk.GetName()}})
+			return err
+		})
+	})
+
+	t.Run("GetRequirements", func(t *testing.T) {
+		// Not every system has requirements, so only their absence is
+		// allowed as an error.
+		if _, err := c.GetRequirements(ctx, &pb.GetRequirementsRequest{VersionKey: vk}); err != nil && status.Code(err) != codes.NotFound {
+			t.Errorf("GetRequirements(%v): %v", vk, err)
+		}
+		checkCode(t, "GetRequirements of unknown version", codes.NotFound, func() error {
+			_, err := c.GetRequirements(ctx, &pb.GetRequirementsRequest{VersionKey: missing})
+			return err
+		})
+	})
+
+	t.Run("GetDependencies", func(t *testing.T) {
+		d, err := c.GetDependencies(ctx, &pb.GetDependenciesRequest{VersionKey: vk})
+		if err != nil {
+			t.Fatalf("GetDependencies(%v): %v", vk, err)
+		}
+		nodes := d.GetNodes()
+		if len(nodes) == 0 || nodes[0].GetRelation() != pb.DependencyRelation_SELF || !proto.Equal(nodes[0].GetVersionKey(), vk) {
+			t.Errorf("GetDependencies(%v): first node is not the requested version", vk)
+		}
+		for _, e := range d.GetEdges() {
+			if int(e.GetFromNode()) >= len(nodes) || int(e.GetToNode()) >= len(nodes) {
+				t.Errorf("GetDependencies(%v): edge %v refers to a missing node", vk, e)
+			}
+		}
+		checkCode(t, "GetDependencies of unknown version", codes.NotFound, func() error {
+			_, err := c.GetDependencies(ctx, &pb.GetDependenciesRequest{VersionKey: missing})
+			return err
+		})
+	})
+
+	t.Run("GetProject", func(t *testing.T) {
+		p, err := c.GetProject(ctx, &pb.GetProjectRequest{ProjectKey: &pb.ProjectKey{Id: f.Project}})
+		if err != nil {
+			t.Fatalf("GetProject(%s): %v", f.Project, err)
+		}
+		if p.GetProjectKey().GetId() != f.Project {
+			t.Errorf("GetProject(%s): got key %v", f.Project, p.GetProjectKey())
+		}
+		checkCode(t, "GetProject of unknown project", codes.NotFound, func() error {
+			_, err := c.GetProject(ctx, &pb.GetProjectRequest{ProjectKey: &pb.ProjectKey{Id: f.Project + "-does-not-exist"}})
+			return err
+		})
+	})
+
+	t.Run("GetProjectPackageVersions", func(t *testing.T) {
+		ppv, err := c.GetProjectPackageVersions(ctx, &pb.GetProjectPackageVersionsRequest{ProjectKey: &pb.ProjectKey{Id: f.Project}})
+		if err != nil {
+			t.Fatalf("GetProjectPackageVersions(%s): %v", f.Project, err)
+		}
+		found := false
+		for _, v := range ppv.GetVersions() {
+			found = found || proto.Equal(v.GetVersionKey(), vk)
+		}
+		if !found {
+			t.Errorf("GetProjectPackageVersions(%s): %v not listed", f.Project, vk)
+		}
+		checkCode(t, "GetProjectPackageVersions of unknown project", codes.NotFound, func() error {
+			_, err := c.GetProjectPackageVersions(ctx, &pb.GetProjectPackageVersionsRequest{ProjectKey: &pb.ProjectKey{Id: f.Project + "-does-not-exist"}})
+			return err
+		})
+	})
+
+	t.Run("GetAdvisory", func(t *testing.T) {
+		a, err := c.GetAdvisory(ctx, &pb.GetAdvisoryRequest{AdvisoryKey: &pb.AdvisoryKey{Id: f.Advisory}})
+		if err != nil {
+			t.Fatalf("GetAdvisory(%s): %v", f.Advisory, err)
+		}
+		if a.GetAdvisoryKey().GetId() != f.Advisory {
+			t.Errorf("GetAdvisory(%s): got key %v", f.Advisory, a.GetAdvisoryKey())
+		}
+		v, err := c.GetVersion(ctx, &pb.GetVersionRequest{VersionKey: vk})
+		if err != nil {
+			t.Fatalf("GetVersion(%v): %v", vk, err)
+		}
+		found := false
+		for _, ak := range v.GetAdvisoryKeys() {
+			found = found || ak.GetId() == f.Advisory
+		}
+		if !found {
+			t.Errorf("GetVersion(%v): advisory %s not listed", vk, f.Advisory)
+		}
+		checkCode(t, "GetAdvisory of unknown advisory", codes.NotFound, func() error {
+			_, err := c.GetAdvisory(ctx, &pb.GetAdvisoryRequest{AdvisoryKey: &pb.AdvisoryKey{Id: "GHSA-0000-0000-0000"}})
+			return err
+		})
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		q, err := c.Query(ctx, &pb.QueryRequest{Hash: f.Hash})
+		if err != nil {
+			t.Fatalf("Query(%v): %v", f.Hash, err)
+		}
+		if !hasVersion(q, vk) {
+			t.Errorf("Query(%v): %v not found", f.Hash, vk)
+		}
+		q, err = c.Query(ctx, &pb.QueryRequest{VersionKey: vk})
+		if err != nil {
+			t.Fatalf("Query(%v): %v", vk, err)
+		}
+		if !hasVersion(q, vk) {
+			t.Errorf("Query(%v): not found", vk)
+		}
+		for _, r := range q.GetResults() {
+			if !proto.Equal(r.GetVersion().GetVersionKey(), vk) {
+				t.Errorf("Query(%v): got other version %v", vk, r.GetVersion().GetVersionKey())
+			}
+		}
+		// A hash matching nothing gives an empty result, not an error.
+		unknown := &pb.Hash{Type: f.Hash.GetType(), Value: make([]byte, len(f.Hash.GetValue()))}
+		q, err = c.Query(ctx, &pb.QueryRequest{Hash: unknown})
+		if err != nil {
+			t.Errorf("Query of unknown hash: %v", err)
+		} else if len(q.GetResults()) != 0 {
+			t.Errorf("Query of unknown hash: got %d results, want none", len(q.GetResults()))
+		}
+		checkCode(t, "empty Query", codes.InvalidArgument, func() error {
+			_, err := c.Query(ctx, &pb.QueryRequest{})
+			return err
+		})
+	})
+}
+
+// checkCode reports an error if f does not fail with the given code.
+func checkCode(t *testing.T, what string, want codes.Code, f func() error) {
+	t.Helper()
+	if got := status.Code(f()); got != want {
+		t.Errorf("%s: got code %v, want %v", what, got, want)
+	}
+}
+
+func hasVersion(q *pb.QueryResult, vk *pb.VersionKey) bool {
+	for _, r := range q.GetResults() {
+		if proto.Equal(r.GetVersion().GetVersionKey(), vk) {
+			return true
+		}
+	}
+	return false
+}
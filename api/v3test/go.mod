@@ -0,0 +1,18 @@
+module deps.dev/api/v3test
+
+go 1.23.4
+
+replace deps.dev/api/v3 => ../v3
+
+require (
+	deps.dev/api/v3 v3.0.0-20240311054650-e1e6a3d70fb7
+	google.golang.org/grpc v1.69.4
+	google.golang.org/protobuf v1.36.2
+)
+
+require (
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
+)
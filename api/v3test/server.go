@@ -0,0 +1,328 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package v3test provides an in-memory implementation of the deps.dev v3
+Insights service, for testing programs that use the API.
+
+A Server is seeded with package versions, requirements, dependency graphs,
+projects, advisories and artifact hashes, and answers the methods of the
+service from that data. NewClient serves it over an in-memory connection
+and returns a client for it:
+
+	s := v3test.NewServer()
+	s.AddVersion(&pb.Version{
+		VersionKey: &pb.VersionKey{System: pb.System_NPM, Name: "left-pad", Version: "1.3.0"},
+		Licenses:   []string{"WTFPL"},
+	})
+	client := v3test.NewClient(t, s)
+
+Requests for data that has not been added fail with the NotFound code, as
+they do with the real API.
+
+RunConformance checks that any implementation of the service, such as an
+alternative backend or a mock, behaves like api.deps.dev. The data it
+expects is described by a Fixture; Seed adds such data to a Server.
+*/
+package v3test
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "deps.dev/api/v3"
+)
+
+// versionKey is a comparable form of a pb.VersionKey. The version is empty
+// for package keys.
+type versionKey struct {
+	system  pb.System
+	name    string
+	version string
+}
+
+func keyOf(vk *pb.VersionKey) versionKey {
+	return versionKey{vk.GetSystem(), vk.GetName(), vk.GetVersion()}
+}
+
+// compareKeys orders version keys by system, name and version, so that
+// results drawn from maps are returned in a stable order.
+func compareKeys(a, b versionKey) int {
+	return cmp.Or(
+		cmp.Compare(a.system, b.system),
+		cmp.Compare(a.name, b.name),
+		cmp.Compare(a.version, b.version),
+	)
+}
+
+// hashKey is a comparable form of a pb.Hash.
+type hashKey struct {
+	typ   pb.HashType
+	value string
+}
+
+// Server is an in-memory implementation of the Insights service. Its
+// methods for adding data may be called while it is serving. It is safe for
+// concurrent use.
+type Server struct {
+	pb.UnimplementedInsightsServer
+
+	mu           sync.Mutex
+	packages     map[versionKey][]*pb.Version // Keyed by system and name.
+	versions     map[versionKey]*pb.Version
+	requirements map[versionKey]*pb.Requirements
+	dependencies map[versionKey]*pb.Dependencies
+	projects     map[string]*pb.Project
+	advisories   map[string]*pb.Advisory
+	artifacts    map[hashKey][]versionKey
+}
+
+// NewServer returns a Server holding no data.
+func NewServer() *Server {
+	return &Server{
+		packages:     make(map[versionKey][]*pb.Version),
+		versions:     make(map[versionKey]*pb.Version),
+		requirements: make(map[versionKey]*pb.Requirements),
+		dependencies: make(map[versionKey]*pb.Dependencies),
+		projects:     make(map[string]*pb.Project),
+		advisories:   make(map[string]*pb.Advisory),
+		artifacts:    make(map[hashKey][]versionKey),
+	}
+}
+
+// AddVersion adds a package version, identified by its VersionKey. The
+// package is listed by GetPackage with its versions in the order they were
+// added. The version is listed by GetProjectPackageVersions for each of its
+// related projects. Adding a version again replaces it.
+func (s *Server) AddVersion(v *pb.Version) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v = proto.Clone(v).(*pb.Version)
+	k := keyOf(v.GetVersionKey())
+	pk := versionKey{system: k.system, name: k.name}
+	if _, ok := s.versions[k]; ok {
+		for i, old := range s.packages[pk] {
+			if keyOf(old.GetVersionKey()) == k {
+				s.packages[pk][i] = v
+			}
+		}
+	} else {
+		s.packages[pk] = append(s.packages[pk], v)
+	}
+	s.versions[k] = v
+}
+
+// AddRequirements adds the requirements of the given package version.
+func (s *Server) AddRequirements(vk *pb.VersionKey, r *pb.Requirements) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requirements[keyOf(vk)] = proto.Clone(r).(*pb.Requirements)
+}
+
+// AddDependencies adds the resolved dependency graph of the given package
+// version.
+func (s *Server) AddDependencies(vk *pb.VersionKey, d *pb.Dependencies) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dependencies[keyOf(vk)] = proto.Clone(d).(*pb.Dependencies)
+}
+
+// AddProject adds a project, identified by its ProjectKey.
+func (s *Server) AddProject(p *pb.Project) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.projects[p.GetProjectKey().GetId()] = proto.Clone(p).(*pb.Project)
+}
+
+// AddAdvisory adds an advisory, identified by its AdvisoryKey.
+func (s *Server) AddAdvisory(a *pb.Advisory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advisories[a.GetAdvisoryKey().GetId()] = proto.Clone(a).(*pb.Advisory)
+}
+
+// AddArtifact records that an artifact with the given hash belongs to the
+// given package version, so that Query finds the version. The version must
+// be added with AddVersion too.
+func (s *Server) AddArtifact(h *pb.Hash, vk *pb.VersionKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hk := hashKey{h.GetType(), string(h.GetValue())}
+	s.artifacts[hk] = append(s.artifacts[hk], keyOf(vk))
+}
+
+func notFound(format string, args ...any) error {
+	return status.Error(codes.NotFound, fmt.Sprintf(format, args...))
+}
+
+func (s *Server) GetPackage(ctx context.Context, req *pb.GetPackageRequest) (*pb.Package, error) {
+	pk := req.GetPackageKey()
+	if pk.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "package name is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vs, ok := s.packages[versionKey{system: pk.GetSystem(), name: pk.GetName()}]
+	if !ok {
+		return nil, notFound("package %v %s not found", pk.GetSystem(), pk.GetName())
+	}
+	p := &pb.Package{PackageKey: proto.Clone(pk).(*pb.PackageKey)}
+	for _, v := range vs {
+		p.Versions = append(p.Versions, &pb.Package_Version{
+			VersionKey:  proto.Clone(v.GetVersionKey()).(*pb.VersionKey),
+			PublishedAt: v.GetPublishedAt(),
+			IsDefault:   v.GetIsDefault(),
+		})
+	}
+	return p, nil
+}
+
+// version returns the version with the given key. It must be called with
+// s.mu held.
+func (s *Server) version(vk *pb.VersionKey) (*pb.Version, error) {
+	if vk.GetName() == "" || vk.GetVersion() == "" {
+		return nil, status.Error(codes.InvalidArgument, "package name and version are required")
+	}
+	v, ok := s.versions[keyOf(vk)]
+	if !ok {
+		return nil, notFound("version %v %s@%s not found", vk.GetSystem(), vk.GetName(), vk.GetVersion())
+	}
+	return v, nil
+}
+
+func (s *Server) GetVersion(ctx context.Context, req *pb.GetVersionRequest) (*pb.Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, err := s.version(req.GetVersionKey())
+	if err != nil {
+		return nil, err
+	}
+	return proto.Clone(v).(*pb.Version), nil
+}
+
+func (s *Server) GetRequirements(ctx context.Context, req *pb.GetRequirementsRequest) (*pb.Requirements, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.version(req.GetVersionKey()); err != nil {
+		return nil, err
+	}
+	r, ok := s.requirements[keyOf(req.GetVersionKey())]
+	if !ok {
+		return nil, notFound("requirements not found")
+	}
+	return proto.Clone(r).(*pb.Requirements), nil
+}
+
+func (s *Server) GetDependencies(ctx context.Context, req *pb.GetDependenciesRequest) (*pb.Dependencies, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.version(req.GetVersionKey()); err != nil {
+		return nil, err
+	}
+	d, ok := s.dependencies[keyOf(req.GetVersionKey())]
+	if !ok {
+		return nil, notFound("dependencies not found")
+	}
+	return proto.Clone(d).(*pb.Dependencies), nil
+}
+
+func (s *Server) GetProject(ctx context.Context, req *pb.GetProjectRequest) (*pb.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.projects[req.GetProjectKey().GetId()]
+	if !ok {
+		return nil, notFound("project %s not found", req.GetProjectKey().GetId())
+	}
+	return proto.Clone(p).(*pb.Project), nil
+}
+
+func (s *Server) GetProjectPackageVersions(ctx context.Context, req *pb.GetProjectPackageVersionsRequest) (*pb.ProjectPackageVersions, error) {
+	id := req.GetProjectKey().GetId()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &pb.ProjectPackageVersions{}
+	for _, vs := range s.packages {
+		for _, v := range vs {
+			for _, rp := range v.GetRelatedProjects() {
+				if rp.GetProjectKey().GetId() != id {
+					continue
+				}
+				resp.Versions = append(resp.Versions, &pb.ProjectPackageVersions_Version{
+					VersionKey:         proto.Clone(v.GetVersionKey()).(*pb.VersionKey),
+					RelationType:       rp.GetRelationType(),
+					RelationProvenance: rp.GetRelationProvenance(),
+				})
+			}
+		}
+	}
+	if len(resp.Versions) == 0 {
+		return nil, notFound("project %s not found", id)
+	}
+	slices.SortFunc(resp.Versions, func(a, b *pb.ProjectPackageVersions_Version) int {
+		return compareKeys(keyOf(a.GetVersionKey()), keyOf(b.GetVersionKey()))
+	})
+	return resp, nil
+}
+
+func (s *Server) GetAdvisory(ctx context.Context, req *pb.GetAdvisoryRequest) (*pb.Advisory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.advisories[req.GetAdvisoryKey().GetId()]
+	if !ok {
+		return nil, notFound("advisory %s not found", req.GetAdvisoryKey().GetId())
+	}
+	return proto.Clone(a).(*pb.Advisory), nil
+}
+
+// Query finds the versions having an artifact with the requested hash, or
+// if no hash is given all versions, that match the requested version key.
+// Fields of the version key that are not set match any version.
+func (s *Server) Query(ctx context.Context, req *pb.QueryRequest) (*pb.QueryResult, error) {
+	h, vk := req.GetHash(), req.GetVersionKey()
+	if h == nil && vk.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "a hash or package name is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []versionKey
+	if h != nil {
+		keys = s.artifacts[hashKey{h.GetType(), string(h.GetValue())}]
+	} else {
+		for _, vs := range s.packages {
+			for _, v := range vs {
+				keys = append(keys, keyOf(v.GetVersionKey()))
+			}
+		}
+		slices.SortFunc(keys, compareKeys)
+	}
+	resp := &pb.QueryResult{}
+	for _, k := range keys {
+		if vk.GetSystem() != pb.System_SYSTEM_UNSPECIFIED && k.system != vk.GetSystem() ||
+			vk.GetName() != "" && k.name != vk.GetName() ||
+			vk.GetVersion() != "" && k.version != vk.GetVersion() {
+			continue
+		}
+		if v, ok := s.versions[k]; ok {
+			resp.Results = append(resp.Results, &pb.QueryResult_Result{Version: proto.Clone(v).(*pb.Version)})
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "deps.dev/api/v3"
+)
+
+func vk(name, version string) *pb.VersionKey {
+	return &pb.VersionKey{System: pb.System_NPM, Name: name, Version: version}
+}
+
+func seed() *Server {
+	s := NewServer()
+	s.AddVersion(&pb.Version{VersionKey: vk("left-pad", "1.0.0")})
+	s.AddVersion(&pb.Version{
+		VersionKey:   vk("left-pad", "1.3.0"),
+		IsDefault:    true,
+		Licenses:     []string{"WTFPL"},
+		AdvisoryKeys: []*pb.AdvisoryKey{{Id: "GHSA-1"}},
+		RelatedProjects: []*pb.Version_Project{{
+			ProjectKey:   &pb.ProjectKey{Id: "github.com/left-pad/left-pad"},
+			RelationType: pb.ProjectRelationType_SOURCE_REPO,
+		}},
+	})
+	s.AddDependencies(vk("left-pad", "1.3.0"), &pb.Dependencies{
+		Nodes: []*pb.Dependencies_Node{{VersionKey: vk("left-pad", "1.3.0"), Relation: pb.DependencyRelation_SELF}},
+	})
+	s.AddProject(&pb.Project{ProjectKey: &pb.ProjectKey{Id: "github.com/left-pad/left-pad"}, StarsCount: 10})
+	s.AddAdvisory(&pb.Advisory{AdvisoryKey: &pb.AdvisoryKey{Id: "GHSA-1"}, Title: "Padding"})
+	s.AddArtifact(&pb.Hash{Type: pb.HashType_SHA1, Value: []byte{1, 2, 3}}, vk("left-pad", "1.3.0"))
+	return s
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient(t, seed())
+
+	pkg, err := c.GetPackage(ctx, &pb.GetPackageRequest{PackageKey: &pb.PackageKey{System: pb.System_NPM, Name: "left-pad"}})
+	if err != nil {
+		t.Fatalf("GetPackage: %v", err)
+	}
+	if len(pkg.Versions) != 2 || pkg.Versions[0].GetVersionKey().GetVersion() != "1.0.0" || !pkg.Versions[1].GetIsDefault() {
+		t.Errorf("GetPackage: got %v", pkg)
+	}
+
+	v, err := c.GetVersion(ctx, &pb.GetVersionRequest{VersionKey: vk("left-pad", "1.3.0")})
+	if err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if len(v.Licenses) != 1 || v.Licenses[0] != "WTFPL" {
+		t.Errorf("GetVersion: got %v", v)
+	}
+
+	d, err := c.GetDependencies(ctx, &pb.GetDependenciesRequest{VersionKey: vk("left-pad", "1.3.0")})
+	if err != nil || len(d.Nodes) != 1 {
+		t.Errorf("GetDependencies: got %v, %v", d, err)
+	}
+
+	p, err := c.GetProject(ctx, &pb.GetProjectRequest{ProjectKey: &pb.ProjectKey{Id: "github.com/left-pad/left-pad"}})
+	if err != nil || p.StarsCount != 10 {
+		t.Errorf("GetProject: got %v, %v", p, err)
+	}
+
+	ppv, err := c.GetProjectPackageVersions(ctx, &pb.GetProjectPackageVersionsRequest{ProjectKey: &pb.ProjectKey{Id: "github.com/left-pad/left-pad"}})
+	want := &pb.ProjectPackageVersions{Versions: []*pb.ProjectPackageVersions_Version{{
+		VersionKey:   vk("left-pad", "1.3.0"),
+		RelationType: pb.ProjectRelationType_SOURCE_REPO,
+	}}}
+	if err != nil || !proto.Equal(ppv, want) {
+		t.Errorf("GetProjectPackageVersions: got %v, %v, want %v", ppv, err, want)
+	}
+
+	a, err := c.GetAdvisory(ctx, &pb.GetAdvisoryRequest{AdvisoryKey: &pb.AdvisoryKey{Id: "GHSA-1"}})
+	if err != nil || a.Title != "Padding" {
+		t.Errorf("GetAdvisory: got %v, %v", a, err)
+	}
+
+	q, err := c.Query(ctx, &pb.QueryRequest{Hash: &pb.Hash{Type: pb.HashType_SHA1, Value: []byte{1, 2, 3}}})
+	if err != nil || len(q.Results) != 1 || q.Results[0].GetVersion().GetVersionKey().GetVersion() != "1.3.0" {
+		t.Errorf("Query by hash: got %v, %v", q, err)
+	}
+	q, err = c.Query(ctx, &pb.QueryRequest{VersionKey: &pb.VersionKey{Name: "left-pad"}})
+	if err != nil || len(q.Results) != 2 {
+		t.Errorf("Query by name: got %v, %v", q, err)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient(t, seed())
+	for _, tc := range []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"unknown version", func() error {
+			_, err := c.GetVersion(ctx, &pb.GetVersionRequest{VersionKey: vk("left-pad", "9.9.9")})
+			return err
+		}, codes.NotFound},
+		{"missing version", func() error {
+			_, err := c.GetVersion(ctx, &pb.GetVersionRequest{VersionKey: vk("left-pad", "")})
+			return err
+		}, codes.InvalidArgument},
+		{"no requirements", func() error {
+			_, err := c.GetRequirements(ctx, &pb.GetRequirementsRequest{VersionKey: vk("left-pad", "1.0.0")})
+			return err
+		}, codes.NotFound},
+		{"unknown advisory", func() error {
+			_, err := c.GetAdvisory(ctx, &pb.GetAdvisoryRequest{AdvisoryKey: &pb.AdvisoryKey{Id: "GHSA-2"}})
+			return err
+		}, codes.NotFound},
+		{"empty query", func() error {
+			_, err := c.Query(ctx, &pb.QueryRequest{})
+			return err
+		}, codes.InvalidArgument},
+	} {
+		if got := status.Code(tc.call()); got != tc.want {
+			t.Errorf("%s: got code %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
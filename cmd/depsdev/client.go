@@ -0,0 +1,107 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"deps.dev/api/clientutil"
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/api/v3http"
+)
+
+// errUsage is returned by a command whose arguments are malformed.
+var errUsage = errors.New("usage")
+
+// env is the environment in which a command runs.
+type env struct {
+	client pb.InsightsClient
+	close  func() error
+}
+
+// connect returns an env whose client calls the API at the given endpoint,
+// which is either a gRPC address or the URL of the HTTP API, sending the
+// given bearer token if it is not empty. Requests failing with transient
+// errors are retried, and if qps is positive, requests are throttled to at
+// most qps per second, less while the API reports that the quota is
+// exhausted.
+func connect(endpoint, token string, qps float64) (*env, error) {
+	var throttle *clientutil.Throttle
+	if qps > 0 {
+		throttle = clientutil.NewThrottle(&clientutil.ThrottleOptions{RPS: qps})
+	}
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		var rt http.RoundTripper = http.DefaultTransport
+		if token != "" {
+			rt = bearerTransport{base: rt, token: token}
+		}
+		if throttle != nil {
+			rt = throttle.Transport(rt)
+		}
+		conn := v3http.NewConn(endpoint, &http.Client{
+			Transport: clientutil.RetryTransport(rt, nil),
+		})
+		return &env{client: pb.NewInsightsClient(conn), close: func() error { return nil }}, nil
+	}
+	certPool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("getting system cert pool: %w", err)
+	}
+	interceptors := []grpc.UnaryClientInterceptor{clientutil.UnaryRetryInterceptor(nil)}
+	if throttle != nil {
+		interceptors = append(interceptors, throttle.UnaryClientInterceptor())
+	}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(certPool, "")),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerCredentials(token)))
+	}
+	conn, err := grpc.NewClient(endpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &env{client: pb.NewInsightsClient(conn), close: conn.Close}, nil
+}
+
+// bearerTransport adds a bearer token to each HTTP request.
+type bearerTransport struct {
+	base  http.RoundTripper
+	token string
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
+
+// bearerCredentials adds a bearer token to each gRPC request.
+type bearerCredentials string
+
+func (c bearerCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(c)}, nil
+}
+
+func (c bearerCredentials) RequireTransportSecurity() bool { return true }
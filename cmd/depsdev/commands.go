@@ -0,0 +1,345 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/batch"
+	"deps.dev/util/oci"
+	"deps.dev/util/typosquat"
+)
+
+// versionKeyArgs parses the system, name and version arguments of a
+// command.
+func versionKeyArgs(args []string) (*pb.VersionKey, error) {
+	if len(args) != 3 {
+		return nil, errUsage
+	}
+	sys, err := parseSystem(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &pb.VersionKey{System: sys, Name: args[1], Version: args[2]}, nil
+}
+
+func runVersion(ctx context.Context, e *env, args []string) (*table, error) {
+	vk, err := versionKeyArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	v, err := e.client.GetVersion(ctx, &pb.GetVersionRequest{VersionKey: vk})
+	if err != nil {
+		return nil, err
+	}
+	t := newTable("system", "name", "version", "published", "default", "licenses", "advisories", "links")
+	var published string
+	if v.GetPublishedAt() != nil {
+		published = v.GetPublishedAt().AsTime().Format(time.RFC3339)
+	}
+	var advisories, links []string
+	for _, ak := range v.GetAdvisoryKeys() {
+		advisories = append(advisories, ak.GetId())
+	}
+	for _, l := range v.GetLinks() {
+		links = append(links, l.GetUrl())
+	}
+	k := v.GetVersionKey()
+	t.add(k.GetSystem().String(), k.GetName(), k.GetVersion(), published,
+		strconv.FormatBool(v.GetIsDefault()),
+		strings.Join(v.GetLicenses(), " "),
+		strings.Join(advisories, " "),
+		strings.Join(links, " "))
+	return t, nil
+}
+
+func runResolve(ctx context.Context, e *env, args []string) (*table, error) {
+	vk, err := versionKeyArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	d, err := e.client.GetDependencies(ctx, &pb.GetDependenciesRequest{VersionKey: vk})
+	if err != nil {
+		return nil, err
+	}
+	// Each node is listed with the requirements through which it was
+	// reached, as "name@version:requirement".
+	reached := make([][]string, len(d.GetNodes()))
+	for _, edge := range d.GetEdges() {
+		from := d.GetNodes()[edge.GetFromNode()].GetVersionKey()
+		reached[edge.GetToNode()] = append(reached[edge.GetToNode()],
+			fmt.Sprintf("%s@%s:%s", from.GetName(), from.GetVersion(), edge.GetRequirement()))
+	}
+	t := newTable("system", "name", "version", "relation", "required_by", "errors")
+	for i, n := range d.GetNodes() {
+		k := n.GetVersionKey()
+		t.add(k.GetSystem().String(), k.GetName(), k.GetVersion(),
+			n.GetRelation().String(),
+			strings.Join(reached[i], " "),
+			strings.Join(n.GetErrors(), "; "))
+	}
+	return t, nil
+}
+
+func runPurl(ctx context.Context, e *env, args []string) (*table, error) {
+	if len(args) == 0 {
+		return nil, errUsage
+	}
+	t := newTable("purl", "system", "name", "version", "licenses")
+	for _, purl := range args {
+		r, err := e.client.PurlLookup(ctx, &pb.PurlLookupRequest{Purl: purl})
+		if status.Code(err) == codes.NotFound {
+			t.add(purl, "", "", "", "")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("looking up %s: %w", purl, err)
+		}
+		if v := r.GetVersion(); v != nil {
+			k := v.GetVersionKey()
+			t.add(purl, k.GetSystem().String(), k.GetName(), k.GetVersion(), strings.Join(v.GetLicenses(), " "))
+			continue
+		}
+		// A purl without a version names a package; list its versions.
+		for _, v := range r.GetPackage().GetVersions() {
+			k := v.GetVersionKey()
+			t.add(purl, k.GetSystem().String(), k.GetName(), k.GetVersion(), "")
+		}
+	}
+	return t, nil
+}
+
+// lockfileArgs parses the flags and lockfile argument of the licenses and
+// advisories commands and reads the lockfile.
+func lockfileArgs(name string, args []string) ([]nameVersion, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var opts lockOptions
+	fs.BoolVar(&opts.dev, "dev", false, "include development dependencies")
+	fs.BoolVar(&opts.optional, "optional", false, "include optional dependencies")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return nil, errUsage
+	}
+	return readNPMLock(fs.Arg(0), opts)
+}
+
+// lookupVersions fetches the given npm package versions with
+// GetVersionBatch. Versions that are not found are missing from the result.
+func lookupVersions(ctx context.Context, c pb.InsightsClient, vs []nameVersion) (map[batch.VersionKey]*pb.Version, error) {
+	vks := make([]*pb.VersionKey, len(vs))
+	for i, v := range vs {
+		vks[i] = &pb.VersionKey{System: pb.System_NPM, Name: v.name, Version: v.version}
+	}
+	return batch.NewRunner(c, nil).LookupVersions(ctx, vks)
+}
+
+// key returns the key of v in the result of lookupVersions.
+func (v nameVersion) key() batch.VersionKey {
+	return batch.VersionKey{System: pb.System_NPM, Name: v.name, Version: v.version}
+}
+
+func runLicenses(ctx context.Context, e *env, args []string) (*table, error) {
+	vs, err := lockfileArgs("licenses", args)
+	if err != nil {
+		return nil, err
+	}
+	found, err := lookupVersions(ctx, e.client, vs)
+	if err != nil {
+		return nil, err
+	}
+	t := newTable("name", "version", "licenses", "error")
+	for _, nv := range vs {
+		v, ok := found[nv.key()]
+		if !ok {
+			t.add(nv.name, nv.version, "", "version not found")
+			continue
+		}
+		t.add(nv.name, nv.version, strings.Join(v.GetLicenses(), " "), "")
+	}
+	return t, nil
+}
+
+func runAdvisories(ctx context.Context, e *env, args []string) (*table, error) {
+	vs, err := lockfileArgs("advisories", args)
+	if err != nil {
+		return nil, err
+	}
+	found, err := lookupVersions(ctx, e.client, vs)
+	if err != nil {
+		return nil, err
+	}
+	advisories := make(map[string]*pb.Advisory)
+	t := newTable("name", "version", "advisory", "cvss3_score", "title", "url")
+	for _, nv := range vs {
+		for _, ak := range found[nv.key()].GetAdvisoryKeys() {
+			id := ak.GetId()
+			a, ok := advisories[id]
+			if !ok {
+				a, err = e.client.GetAdvisory(ctx, &pb.GetAdvisoryRequest{AdvisoryKey: ak})
+				if err != nil {
+					return nil, fmt.Errorf("fetching advisory %s: %w", id, err)
+				}
+				advisories[id] = a
+			}
+			t.add(nv.name, nv.version, id,
+				strconv.FormatFloat(float64(a.GetCvss3Score()), 'f', 1, 32),
+				a.GetTitle(), a.GetUrl())
+		}
+	}
+	return t, nil
+}
+
+// imageArg reads the images named by an argument that is either a local
+// image archive or OCI layout directory, or else a reference to an image in
+// a registry.
+func imageArg(ctx context.Context, arg string) ([]*oci.Image, error) {
+	if _, err := os.Stat(arg); err == nil {
+		imgs, err := oci.Open(arg)
+		if err != nil {
+			return nil, fmt.Errorf("reading image: %w", err)
+		}
+		return imgs, nil
+	}
+	var r oci.Registry
+	imgs, err := r.Fetch(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("fetching image: %w", err)
+	}
+	return imgs, nil
+}
+
+func runBaseImage(ctx context.Context, e *env, args []string) (*table, error) {
+	if len(args) != 1 {
+		return nil, errUsage
+	}
+	imgs, err := imageArg(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	t := newTable("image", "platform", "layer", "chain_id", "repositories")
+	for _, img := range imgs {
+		repos, err := oci.BaseImages(ctx, e.client, img)
+		if err != nil {
+			return nil, err
+		}
+		for i, l := range img.Layers {
+			t.add(img.Name, img.Platform, strconv.Itoa(i), l.ChainID, strings.Join(repos[i], " "))
+		}
+	}
+	return t, nil
+}
+
+func runImageDiff(ctx context.Context, e *env, args []string) (*table, error) {
+	if len(args) != 2 {
+		return nil, errUsage
+	}
+	olds, err := imageArg(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	news, err := imageArg(ctx, args[1])
+	if err != nil {
+		return nil, err
+	}
+	t := newTable("platform", "change", "layer", "path", "packages")
+	for _, img := range news {
+		var old *oci.Image
+		for _, o := range olds {
+			if o.Platform == img.Platform || len(olds) == 1 && len(news) == 1 {
+				old = o
+				break
+			}
+		}
+		if old == nil {
+			return nil, fmt.Errorf("no old image for platform %q", img.Platform)
+		}
+		d, err := oci.DiffImages(ctx, e.client, old, img)
+		if err != nil {
+			return nil, err
+		}
+		if d.BaseChanged() {
+			t.add(img.Platform, "-base", d.OldBase.ChainID, "", strings.Join(d.OldBase.Repositories, " "))
+			t.add(img.Platform, "+base", d.NewBase.ChainID, "", strings.Join(d.NewBase.Repositories, " "))
+		}
+		for _, l := range d.RemovedLayers {
+			t.add(img.Platform, "-layer", l.DiffID, "", "")
+		}
+		for _, l := range d.AddedLayers {
+			t.add(img.Platform, "+layer", l.DiffID, "", "")
+		}
+		for _, as := range []struct {
+			change    string
+			artifacts []oci.Artifact
+		}{
+			{"-artifact", d.RemovedArtifacts},
+			{"+artifact", d.AddedArtifacts},
+		} {
+			for _, a := range as.artifacts {
+				var pkgs []string
+				for _, vk := range a.Versions {
+					pkgs = append(pkgs, fmt.Sprintf("%s:%s@%s", vk.GetSystem(), vk.GetName(), vk.GetVersion()))
+				}
+				t.add(img.Platform, as.change, a.Layer, a.Path, strings.Join(pkgs, " "))
+			}
+		}
+	}
+	return t, nil
+}
+
+func runTyposquat(ctx context.Context, e *env, args []string) (*table, error) {
+	fs := flag.NewFlagSet("typosquat", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var (
+		lockOpts lockOptions
+		opts     typosquat.Options
+	)
+	fs.BoolVar(&lockOpts.dev, "dev", false, "include development dependencies")
+	fs.BoolVar(&lockOpts.optional, "optional", false, "include optional dependencies")
+	fs.Float64Var(&opts.Ratio, "ratio", 100, "how many times more dependents a similarly named package must have")
+	fs.IntVar(&opts.MinDependents, "min-dependents", 1000, "the dependents a similarly named package must have")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return nil, errUsage
+	}
+	names, err := readNPMDirect(fs.Arg(0), lockOpts)
+	if err != nil {
+		return nil, err
+	}
+	var deps []*pb.PackageKey
+	for _, name := range names {
+		deps = append(deps, &pb.PackageKey{System: pb.System_NPM, Name: name})
+	}
+	findings, err := typosquat.Check(ctx, e.client, deps, &opts)
+	if err != nil {
+		return nil, err
+	}
+	t := newTable("name", "dependents", "similar", "similar_dependents")
+	for _, f := range findings {
+		for _, s := range f.Similar {
+			t.add(f.Package.GetName(), strconv.Itoa(f.Dependents), s.Package.GetName(), strconv.Itoa(s.Dependents))
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// fakeClient answers the methods used by the commands from fixed data.
+// Version batches are returned one response per page, to exercise paging.
+type fakeClient struct {
+	pb.InsightsClient
+	versions   map[nameVersion]*pb.Version
+	advisories map[string]*pb.Advisory
+}
+
+func (c *fakeClient) GetVersionBatch(ctx context.Context, req *pb.GetVersionBatchRequest, opts ...grpc.CallOption) (*pb.VersionBatch, error) {
+	i := 0
+	if req.PageToken != "" {
+		i = int(req.PageToken[0] - '0')
+	}
+	r := req.Requests[i]
+	k := r.GetVersionKey()
+	resp := &pb.VersionBatch{Responses: []*pb.VersionBatch_Response{{
+		Request: r,
+		Version: c.versions[nameVersion{k.GetName(), k.GetVersion()}],
+	}}}
+	if i+1 < len(req.Requests) {
+		resp.NextPageToken = string(rune('0' + i + 1))
+	}
+	return resp, nil
+}
+
+func (c *fakeClient) GetAdvisory(ctx context.Context, req *pb.GetAdvisoryRequest, opts ...grpc.CallOption) (*pb.Advisory, error) {
+	a, ok := c.advisories[req.GetAdvisoryKey().GetId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "advisory not found")
+	}
+	return a, nil
+}
+
+func (c *fakeClient) PurlLookup(ctx context.Context, req *pb.PurlLookupRequest, opts ...grpc.CallOption) (*pb.PurlLookupResult, error) {
+	if req.GetPurl() != "pkg:npm/a@1.0.0" {
+		return nil, status.Error(codes.NotFound, "purl not found")
+	}
+	return &pb.PurlLookupResult{Version: c.versions[nameVersion{"a", "1.0.0"}]}, nil
+}
+
+func newFakeEnv() *env {
+	return &env{client: &fakeClient{
+		versions: map[nameVersion]*pb.Version{
+			{"a", "1.0.0"}: {
+				VersionKey:   &pb.VersionKey{System: pb.System_NPM, Name: "a", Version: "1.0.0"},
+				Licenses:     []string{"MIT"},
+				AdvisoryKeys: []*pb.AdvisoryKey{{Id: "GHSA-1"}},
+			},
+			{"b", "2.0.0"}: {
+				VersionKey: &pb.VersionKey{System: pb.System_NPM, Name: "b", Version: "2.0.0"},
+				Licenses:   []string{"ISC", "MIT"},
+			},
+		},
+		advisories: map[string]*pb.Advisory{
+			"GHSA-1": {AdvisoryKey: &pb.AdvisoryKey{Id: "GHSA-1"}, Title: "Bad", Cvss3Score: 7.5},
+		},
+	}}
+}
+
+const testLock = `{
+	"name": "root", "version": "1.0.0", "lockfileVersion": 3,
+	"packages": {
+		"": {"name": "root", "version": "1.0.0"},
+		"node_modules/a": {"version": "1.0.0"},
+		"node_modules/b": {"version": "2.0.0"},
+		"node_modules/c": {"version": "3.0.0"}
+	}
+}`
+
+func TestLicenses(t *testing.T) {
+	got, err := runLicenses(context.Background(), newFakeEnv(), []string{writeFile(t, testLock)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"a", "1.0.0", "MIT", ""},
+		{"b", "2.0.0", "ISC MIT", ""},
+		{"c", "3.0.0", "", "version not found"},
+	}
+	if !reflect.DeepEqual(got.rows, want) {
+		t.Errorf("got %v, want %v", got.rows, want)
+	}
+}
+
+func TestAdvisories(t *testing.T) {
+	got, err := runAdvisories(context.Background(), newFakeEnv(), []string{writeFile(t, testLock)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"a", "1.0.0", "GHSA-1", "7.5", "Bad", ""}}
+	if !reflect.DeepEqual(got.rows, want) {
+		t.Errorf("got %v, want %v", got.rows, want)
+	}
+}
+
+func TestPurl(t *testing.T) {
+	got, err := runPurl(context.Background(), newFakeEnv(), []string{"pkg:npm/a@1.0.0", "pkg:npm/z@1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"pkg:npm/a@1.0.0", "NPM", "a", "1.0.0", "MIT"},
+		{"pkg:npm/z@1.0.0", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(got.rows, want) {
+		t.Errorf("got %v, want %v", got.rows, want)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range commands {
+		if _, err := c.run(ctx, newFakeEnv(), nil); err != errUsage {
+			t.Errorf("%s with no arguments: got %v, want errUsage", c.name, err)
+		}
+	}
+	if _, err := runVersion(ctx, newFakeEnv(), []string{"cobol", "a", "1"}); err == nil || err == errUsage {
+		t.Errorf("version with unknown system: got %v, want error", err)
+	}
+}
@@ -0,0 +1,33 @@
+module deps.dev/cmd/depsdev
+
+go 1.23.4
+
+replace (
+	deps.dev/api/clientutil => ../../api/clientutil
+	deps.dev/api/v3 => ../../api/v3
+	deps.dev/api/v3alpha => ../../api/v3alpha
+	deps.dev/api/v3http => ../../api/v3http
+	deps.dev/util/batch => ../../util/batch
+	deps.dev/util/cache => ../../util/cache
+	deps.dev/util/oci => ../../util/oci
+	deps.dev/util/typosquat => ../../util/typosquat
+)
+
+require (
+	deps.dev/api/clientutil v0.0.0-00010101000000-000000000000
+	deps.dev/api/v3alpha v0.0.0-20240701033337-efe6530670b9
+	deps.dev/api/v3http v0.0.0-00010101000000-000000000000
+	deps.dev/util/batch v0.0.0-00010101000000-000000000000
+	deps.dev/util/oci v0.0.0-00010101000000-000000000000
+	deps.dev/util/typosquat v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.69.4
+)
+
+require (
+	deps.dev/util/cache v0.0.0-00010101000000-000000000000 // indirect
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
+	google.golang.org/protobuf v1.36.2 // indirect
+)
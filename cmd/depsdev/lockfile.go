@@ -0,0 +1,206 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// npmLock is a package-lock.json file. Version 1 files list the installed
+// packages in a tree of dependencies; later versions list them in packages,
+// keyed by their paths in node_modules.
+// https://docs.npmjs.com/cli/configuring-npm/package-lock-json
+type npmLock struct {
+	Name         string                    `json:"name"`
+	Version      string                    `json:"version"`
+	Dependencies map[string]npmLockDep     `json:"dependencies"`
+	Packages     map[string]npmLockPackage `json:"packages"`
+}
+
+// npmLockFlags are the flags of an installed package in a package-lock.json
+// file.
+type npmLockFlags struct {
+	Bundled     bool `json:"bundled"`
+	InBundle    bool `json:"inBundle"`
+	Dev         bool `json:"dev"`
+	Optional    bool `json:"optional"`
+	DevOptional bool `json:"devOptional"`
+	Link        bool `json:"link"`
+}
+
+// npmLockDep is an installed package in the dependencies of a version 1
+// package-lock.json file.
+type npmLockDep struct {
+	npmLockFlags
+	Version      string                `json:"version"`
+	Dependencies map[string]npmLockDep `json:"dependencies"`
+}
+
+// npmLockPackage is an installed package in the packages of a version 2 or
+// 3 package-lock.json file. Its requirements are as in its package.json.
+type npmLockPackage struct {
+	npmLockFlags
+	npmManifest
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// npmManifest holds the requirements of a package.json file, keyed by
+// package name.
+type npmManifest struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// lockOptions select the packages read from a lockfile.
+type lockOptions struct {
+	dev      bool // Include development dependencies.
+	optional bool // Include optional dependencies.
+}
+
+// wanted reports whether a dependency is selected by the options. Bundled
+// dependencies are never selected, as they are part of the package that
+// bundles them.
+func (o lockOptions) wanted(d npmLockFlags) bool {
+	switch {
+	case d.Bundled, d.InBundle, d.Link:
+		return false
+	case d.DevOptional:
+		return o.dev || o.optional
+	case d.Dev && !o.dev, d.Optional && !o.optional:
+		return false
+	}
+	return true
+}
+
+// nameVersion is an npm package version.
+type nameVersion struct {
+	name, version string
+}
+
+// readNPMLock returns the distinct package versions installed by the given
+// package-lock.json file, excluding the root package, sorted by name and
+// version.
+func readNPMLock(filename string, opts lockOptions) ([]nameVersion, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var l npmLock
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+	seen := make(map[nameVersion]bool)
+	if l.Packages != nil {
+		for path, d := range l.Packages {
+			i := strings.LastIndex(path, "node_modules/")
+			if i < 0 || !opts.wanted(d.npmLockFlags) {
+				// The root package, or a workspace.
+				continue
+			}
+			name := d.Name
+			if name == "" {
+				name = path[i+len("node_modules/"):]
+			}
+			seen[nameVersion{name, d.Version}] = true
+		}
+	} else {
+		toVisit := []map[string]npmLockDep{l.Dependencies}
+		for len(toVisit) > 0 {
+			deps := toVisit[0]
+			toVisit = toVisit[1:]
+			for name, d := range deps {
+				if !opts.wanted(d.npmLockFlags) {
+					continue
+				}
+				seen[nameVersion{name, d.Version}] = true
+				toVisit = append(toVisit, d.Dependencies)
+			}
+		}
+	}
+	vs := make([]nameVersion, 0, len(seen))
+	for v := range seen {
+		vs = append(vs, v)
+	}
+	slices.SortFunc(vs, func(a, b nameVersion) int {
+		return cmp.Or(cmp.Compare(a.name, b.name), cmp.Compare(a.version, b.version))
+	})
+	return vs, nil
+}
+
+// readNPMDirect returns the names of the direct dependencies of the root
+// package of the given package.json file or version 2 or 3
+// package-lock.json file, sorted. Version 1 lockfiles do not record which
+// dependencies are direct.
+func readNPMDirect(filename string, opts lockOptions) ([]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	// The dependencies of a version 1 lockfile and of a package.json file
+	// differ, so are not parsed until the kind of file is known.
+	var l struct {
+		LockfileVersion int                       `json:"lockfileVersion"`
+		Packages        map[string]npmLockPackage `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+	var m npmManifest
+	switch root, ok := l.Packages[""]; {
+	case ok:
+		m = root.npmManifest
+	case l.LockfileVersion != 0:
+		return nil, fmt.Errorf("%s: lockfile version %d does not record direct dependencies; use package.json", filename, l.LockfileVersion)
+	default:
+		// A package.json file.
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", filename, err)
+		}
+	}
+	seen := make(map[string]bool)
+	add := func(reqs map[string]string) {
+		for name, req := range reqs {
+			// An alias, such as "npm:name@^1.0.0", names the package
+			// installed under another name.
+			if spec, ok := strings.CutPrefix(req, "npm:"); ok {
+				if i := strings.LastIndex(spec, "@"); i > 0 {
+					spec = spec[:i]
+				}
+				name = spec
+			}
+			seen[name] = true
+		}
+	}
+	add(m.Dependencies)
+	if opts.dev {
+		add(m.DevDependencies)
+	}
+	if opts.optional {
+		add(m.OptionalDependencies)
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, data string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "package-lock.json")
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+const (
+	testLockV1 = `{
+		"name": "root", "version": "1.0.0", "lockfileVersion": 1,
+		"dependencies": {
+			"a": {"version": "1.0.0", "dependencies": {
+				"b": {"version": "2.0.0"},
+				"bundled": {"version": "1.0.0", "bundled": true}
+			}},
+			"b": {"version": "1.0.0"},
+			"dev": {"version": "1.0.0", "dev": true},
+			"opt": {"version": "1.0.0", "optional": true}
+		}
+	}`
+	testLockV3 = `{
+		"name": "root", "version": "1.0.0", "lockfileVersion": 3,
+		"packages": {
+			"": {
+				"name": "root", "version": "1.0.0",
+				"dependencies": {"a": "^1.0.0", "b": "^1.0.0", "alias": "npm:b@^1.0.0"},
+				"devDependencies": {"dev": "^1.0.0"},
+				"optionalDependencies": {"opt": "^1.0.0"}
+			},
+			"node_modules/a": {"version": "1.0.0", "dependencies": {"b": "^2.0.0"}},
+			"node_modules/a/node_modules/b": {"version": "2.0.0"},
+			"node_modules/a/node_modules/bundled": {"version": "1.0.0", "inBundle": true},
+			"node_modules/b": {"version": "1.0.0"},
+			"node_modules/dev": {"version": "1.0.0", "dev": true},
+			"node_modules/opt": {"version": "1.0.0", "optional": true},
+			"node_modules/alias": {"name": "b", "version": "1.0.0"},
+			"node_modules/ws": {"resolved": "packages/ws", "link": true},
+			"packages/ws": {"name": "ws", "version": "0.1.0"}
+		}
+	}`
+)
+
+func TestReadNPMLock(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+		opts lockOptions
+		want []nameVersion
+	}{
+		{"v1", testLockV1, lockOptions{}, []nameVersion{{"a", "1.0.0"}, {"b", "1.0.0"}, {"b", "2.0.0"}}},
+		{"v1 dev", testLockV1, lockOptions{dev: true}, []nameVersion{{"a", "1.0.0"}, {"b", "1.0.0"}, {"b", "2.0.0"}, {"dev", "1.0.0"}}},
+		{"v3", testLockV3, lockOptions{}, []nameVersion{{"a", "1.0.0"}, {"b", "1.0.0"}, {"b", "2.0.0"}}},
+		{"v3 optional", testLockV3, lockOptions{optional: true}, []nameVersion{{"a", "1.0.0"}, {"b", "1.0.0"}, {"b", "2.0.0"}, {"opt", "1.0.0"}}},
+	} {
+		got, err := readNPMLock(writeFile(t, tc.data), tc.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestReadNPMDirect(t *testing.T) {
+	const manifest = `{
+		"name": "root", "version": "1.0.0",
+		"dependencies": {"a": "^1.0.0", "@scope/b": "npm:@scope/c@^1.0.0"},
+		"devDependencies": {"dev": "^1.0.0"},
+		"optionalDependencies": {"opt": "^1.0.0"}
+	}`
+	for _, tc := range []struct {
+		name string
+		data string
+		opts lockOptions
+		want []string
+	}{
+		{"package.json", manifest, lockOptions{}, []string{"@scope/c", "a"}},
+		{"package.json dev", manifest, lockOptions{dev: true}, []string{"@scope/c", "a", "dev"}},
+		{"v3", testLockV3, lockOptions{}, []string{"a", "b"}},
+		{"v3 optional", testLockV3, lockOptions{optional: true}, []string{"a", "b", "opt"}},
+	} {
+		got, err := readNPMDirect(writeFile(t, tc.data), tc.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if _, err := readNPMDirect(writeFile(t, testLockV1), lockOptions{}); err == nil {
+		t.Errorf("v1: got no error")
+	}
+}
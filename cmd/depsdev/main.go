@@ -0,0 +1,164 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+depsdev is a command-line client for the deps.dev API.
+
+Usage:
+
+	depsdev [flags] <command> [arguments]
+
+The commands are:
+
+	version <system> <name> <version>
+		print a package version's licenses, advisories and links
+	resolve <system> <name> <version>
+		print the resolved dependency graph of a package version
+	licenses [-dev] [-optional] <package-lock.json>
+		print the licenses of the packages in an npm lockfile
+	advisories [-dev] [-optional] <package-lock.json>
+		print the security advisories affecting the packages in an npm lockfile
+	typosquat [-dev] [-optional] [-ratio n] [-min-dependents n] <package.json or package-lock.json>
+		print the direct npm dependencies whose names resemble those of much
+		more popular packages
+	base-image <image.tar, OCI layout directory or image reference>
+		print the base images of a container image
+	image-diff <old image> <new image>
+		print the changes in base image, layers and vendored package
+		archives between two container images, each given as for
+		base-image
+	purl <purl>...
+		print the package versions named by package URLs
+
+Systems are named as in the API, such as npm, maven or pypi, in any case.
+
+The flags shared by all commands select the API endpoint, the credentials
+sent to it, the rate of requests and the output format:
+
+	-endpoint  the gRPC address of the API, or an http:// or https:// URL to
+	           use the HTTP API (default api.deps.dev:443)
+	-token     a bearer token sent with each request, for deployments behind
+	           an authenticating proxy; the default is $DEPSDEV_TOKEN
+	-qps       the maximum number of requests per second (default 10)
+	-format    the output format: text, json or csv (default text)
+
+Requests that fail because of rate limits or transient errors are retried.
+All of the API methods used are those of the v3alpha API.
+*/
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+var (
+	endpoint = flag.String("endpoint", "api.deps.dev:443", "gRPC address of the API, or an http(s):// URL to use the HTTP API")
+	token    = flag.String("token", "", "bearer token to send with each request (default $DEPSDEV_TOKEN)")
+	qps      = flag.Float64("qps", 10, "maximum number of requests per second")
+	format   = flag.String("format", "text", "output format: text, json or csv")
+)
+
+// command is a subcommand of depsdev.
+type command struct {
+	name  string
+	args  string // A synopsis of the arguments.
+	about string
+	// run runs the command with the given arguments, which may include
+	// flags of its own, and returns its output. It returns errUsage if the
+	// arguments are malformed.
+	run func(ctx context.Context, e *env, args []string) (*table, error)
+}
+
+var commands = []*command{
+	{"version", "<system> <name> <version>", "print a package version's licenses, advisories and links", runVersion},
+	{"resolve", "<system> <name> <version>", "print the resolved dependency graph of a package version", runResolve},
+	{"licenses", "[-dev] [-optional] <package-lock.json>", "print the licenses of the packages in an npm lockfile", runLicenses},
+	{"advisories", "[-dev] [-optional] <package-lock.json>", "print the security advisories affecting the packages in an npm lockfile", runAdvisories},
+	{"typosquat", "[-dev] [-optional] [-ratio n] [-min-dependents n] <package.json or package-lock.json>", "print the direct npm dependencies whose names resemble those of much more popular packages", runTyposquat},
+	{"base-image", "<image.tar, OCI layout directory or image reference>", "print the base images of a container image", runBaseImage},
+	{"image-diff", "<old image> <new image>", "print the base image, layer and vendored package changes between two container images", runImageDiff},
+	{"purl", "<purl>...", "print the package versions named by package URLs", runPurl},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: depsdev [flags] <command> [arguments]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s %s\n    \t%s\n", c.name, c.args, c.about)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	var cmd *command
+	for _, c := range commands {
+		if c.name == flag.Arg(0) {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		log.Printf("Unknown command %q", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	w, err := newWriter(*format, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *token == "" {
+		*token = os.Getenv("DEPSDEV_TOKEN")
+	}
+	e, err := connect(*endpoint, *token, *qps)
+	if err != nil {
+		log.Fatalf("Connecting to %s: %v", *endpoint, err)
+	}
+	defer e.close()
+
+	t, err := cmd.run(context.Background(), e, flag.Args()[1:])
+	if err == errUsage {
+		fmt.Fprintf(os.Stderr, "Usage: depsdev %s %s\n", cmd.name, cmd.args)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", cmd.name, err)
+	}
+	if err := w.write(t); err != nil {
+		log.Fatalf("Writing output: %v", err)
+	}
+}
+
+// parseSystem parses the name of a package management system, such as npm
+// or MAVEN.
+func parseSystem(s string) (pb.System, error) {
+	v, ok := pb.System_value[strings.ToUpper(s)]
+	if !ok || v == 0 {
+		return 0, fmt.Errorf("unknown system %q", s)
+	}
+	return pb.System(v), nil
+}
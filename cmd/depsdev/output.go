@@ -0,0 +1,89 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// table is the output of a command: rows of values under named columns.
+// Values holding several items, such as a list of licenses, are joined
+// with spaces.
+type table struct {
+	columns []string
+	rows    [][]string
+}
+
+func newTable(columns ...string) *table {
+	return &table{columns: columns}
+}
+
+// add adds a row. It must have a value for each column.
+func (t *table) add(values ...string) {
+	if len(values) != len(t.columns) {
+		panic(fmt.Sprintf("row has %d values for %d columns", len(values), len(t.columns)))
+	}
+	t.rows = append(t.rows, values)
+}
+
+// writer writes tables in an output format.
+type writer struct {
+	w      io.Writer
+	format string
+}
+
+func newWriter(format string, w io.Writer) (*writer, error) {
+	switch format {
+	case "text", "json", "csv":
+		return &writer{w: w, format: format}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// write writes the table. Text is aligned in columns under a header. JSON
+// is an array holding an object for each row, keyed by column name. CSV
+// has a header record followed by a record for each row.
+func (w *writer) write(t *table) error {
+	switch w.format {
+	case "json":
+		objs := make([]map[string]string, 0, len(t.rows))
+		for _, r := range t.rows {
+			obj := make(map[string]string, len(r))
+			for i, v := range r {
+				obj[t.columns[i]] = v
+			}
+			objs = append(objs, obj)
+		}
+		enc := json.NewEncoder(w.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(objs)
+	case "csv":
+		cw := csv.NewWriter(w.w)
+		cw.Write(t.columns)
+		cw.WriteAll(t.rows)
+		return cw.Error()
+	}
+	tw := tabwriter.NewWriter(w.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(t.columns, "\t")))
+	for _, r := range t.rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	tb := newTable("name", "licenses")
+	tb.add("left-pad", "WTFPL")
+	tb.add("a,b", "MIT Apache-2.0")
+	for _, tc := range []struct {
+		format, want string
+	}{
+		{"text", "NAME      LICENSES\nleft-pad  WTFPL\na,b       MIT Apache-2.0\n"},
+		{"csv", "name,licenses\nleft-pad,WTFPL\n\"a,b\",MIT Apache-2.0\n"},
+		{"json", `[
+  {
+    "licenses": "WTFPL",
+    "name": "left-pad"
+  },
+  {
+    "licenses": "MIT Apache-2.0",
+    "name": "a,b"
+  }
+]
+`},
+	} {
+		var b strings.Builder
+		w, err := newWriter(tc.format, &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.write(tb); err != nil {
+			t.Errorf("%s: %v", tc.format, err)
+		}
+		if got := b.String(); got != tc.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tc.format, got, tc.want)
+		}
+	}
+	if _, err := newWriter("xml", nil); err == nil {
+		t.Errorf("newWriter(xml): succeeded, want error")
+	}
+}
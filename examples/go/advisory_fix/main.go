@@ -0,0 +1,87 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+advisory_fix is an example application that finds the versions to upgrade to
+in order to fix an advisory affecting a package version.
+
+Given a package version and the version ranges affected by an advisory, in the
+constraint syntax of the package's system, it uses the Fixes method of the
+deps.dev/util/depsdev client to find, for each major version from the current
+one, the nearest later version outside the affected ranges, along with the
+advisories still affecting those versions.
+
+For example, to find the fixes for the prototype pollution advisory
+GHSA-35jh-r3h4-6jhm in lodash 4.17.15:
+
+	advisory_fix npm lodash 4.17.15 '<4.17.21'
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/depsdev"
+)
+
+const usage = "Usage: advisory_fix <system> <package-name> <package-version> <affected-range>..."
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 5 {
+		log.Fatal(usage)
+	}
+	system, ok := pb.System_value[strings.ToUpper(os.Args[1])]
+	if !ok {
+		log.Fatalf("Unknown system %q", os.Args[1])
+	}
+	name, version := os.Args[2], os.Args[3]
+
+	client, err := depsdev.New(nil)
+	if err != nil {
+		log.Fatalf("Creating client: %v", err)
+	}
+	defer client.Close()
+
+	fixes, err := client.Fixes(context.Background(), pb.System(system), name, version, os.Args[4:])
+	if errors.Is(err, depsdev.ErrNotAffected) {
+		fmt.Printf("%s@%s is not affected\n", name, version)
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(fixes) == 0 {
+		fmt.Printf("%s@%s: no fixed version available\n", name, version)
+		return
+	}
+
+	fmt.Printf("%s@%s is affected, upgrade to:\n", name, version)
+	for i, f := range fixes {
+		fmt.Printf("  %s", f.Version)
+		if i == 0 {
+			fmt.Printf(" (minimal upgrade)")
+		}
+		if len(f.Advisories) > 0 {
+			fmt.Printf(", still affected by %s", strings.Join(f.Advisories, ", "))
+		}
+		fmt.Printf("\n")
+	}
+}
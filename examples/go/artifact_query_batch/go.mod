@@ -0,0 +1,28 @@
+module github.com/google/deps.dev/examples/go/artifact_query_batch
+
+go 1.23.4
+
+replace (
+	deps.dev/api/clientutil => ../../../api/clientutil
+	deps.dev/api/v3 => ../../../api/v3
+	deps.dev/util/cache => ../../../util/cache
+	deps.dev/util/depsdev => ../../../util/depsdev
+	deps.dev/util/semver => ../../../util/semver
+)
+
+require (
+	deps.dev/api/v3 v3.0.0-20240311054650-e1e6a3d70fb7
+	deps.dev/util/depsdev v0.0.0-00010101000000-000000000000
+)
+
+require (
+	deps.dev/api/clientutil v0.0.0-00010101000000-000000000000 // indirect
+	deps.dev/util/cache v0.0.0-00010101000000-000000000000 // indirect
+	deps.dev/util/semver v0.0.0-20240109040450-1e316b822bc4 // indirect
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
+	google.golang.org/grpc v1.69.4 // indirect
+	google.golang.org/protobuf v1.36.2 // indirect
+)
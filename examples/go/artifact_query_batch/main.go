@@ -0,0 +1,137 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+artifact_query_batch is an example application that queries the deps.dev API
+by file content hash for many files at once, such as a directory of JAR files.
+
+Whereas examples/go/artifact_query makes a single request to the HTTP API,
+this application hashes every file found under its arguments and uses the
+QueryBatch method of the deps.dev/util/depsdev client to make concurrent, rate
+limited calls to the Query endpoint of the gRPC API, one per distinct hash.
+The results are merged by hash, so that identical files are only queried
+once.
+*/
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/depsdev"
+)
+
+var (
+	ext         = flag.String("ext", "", "only query the files with this extension, such as .jar")
+	concurrency = flag.Int("concurrency", 10, "maximum number of concurrent requests")
+	qps         = flag.Float64("qps", 50, "maximum number of requests per second")
+)
+
+// hashFiles returns the SHA-1 hashes of the regular files found under the
+// given paths, along with the names of the files having each hash.
+func hashFiles(paths []string) (map[[sha1.Size]byte][]string, error) {
+	files := make(map[[sha1.Size]byte][]string)
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() || !strings.HasSuffix(path, *ext) {
+				return nil
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			h := sha1.New()
+			if _, err := io.Copy(h, f); err != nil {
+				return fmt.Errorf("reading %s: %w", path, err)
+			}
+			var sum [sha1.Size]byte
+			h.Sum(sum[:0])
+			files[sum] = append(files[sum], path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: artifact_query_batch [flags] <file-or-directory>...\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || *concurrency < 1 || *qps <= 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	files, err := hashFiles(flag.Args())
+	if err != nil {
+		log.Fatalf("Hashing files: %v", err)
+	}
+	hashes := make([][]byte, 0, len(files))
+	for h := range files {
+		hashes = append(hashes, h[:])
+	}
+
+	client, err := depsdev.New(&depsdev.Options{QPS: *qps})
+	if err != nil {
+		log.Fatalf("Creating client: %v", err)
+	}
+	defer client.Close()
+	results := client.QueryBatch(context.Background(), pb.HashType_SHA1, hashes, *concurrency)
+
+	// Print the matching package versions of each file, in file order.
+	type fileResult struct {
+		name string
+		*depsdev.HashResult
+	}
+	var out []fileResult
+	for h, names := range files {
+		for _, name := range names {
+			out = append(out, fileResult{name, results[string(h[:])]})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	for _, f := range out {
+		switch {
+		case f.Err != nil && !errors.Is(f.Err, depsdev.ErrNotFound):
+			fmt.Printf("%s: error: %v\n", f.name, f.Err)
+		case len(f.Results) == 0:
+			fmt.Printf("%s: no match\n", f.name)
+		}
+		for _, r := range f.Results {
+			vk := r.GetVersion().GetVersionKey()
+			fmt.Printf("%s: %s: %s@%s\n", f.name, vk.GetSystem(), vk.GetName(), vk.GetVersion())
+		}
+	}
+}
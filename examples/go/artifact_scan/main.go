@@ -0,0 +1,397 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+artifact_scan is an example application that identifies the package versions
+found in a directory tree, such as the vendored dependencies of a project.
+
+It walks the directories given as arguments, looking inside any archives it
+finds (JARs, wheels, zip files and tarballs, including ones nested within
+other archives), and computes the SHA-1 and SHA-256 hashes of every file. It
+then queries the gRPC API's Query endpoint for each distinct file, first by
+SHA-1 and, failing a match, by SHA-256, and prints a report mapping the files
+to the package versions they belong to. Files inside archives are named by
+the path to the archive, "!/", and the path within it, as in
+lib/app.war!/WEB-INF/lib/guava.jar.
+*/
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"deps.dev/api/clientutil"
+	pb "deps.dev/api/v3"
+)
+
+var (
+	concurrency = flag.Int("concurrency", 10, "maximum number of concurrent requests")
+	qps         = flag.Float64("qps", 50, "maximum number of requests per second")
+	jsonOut     = flag.Bool("json", false, "print the report as JSON")
+	all         = flag.Bool("all", false, "also report the files that match no package version")
+	maxSize     = flag.Int64("max_archive_size", 1<<30, "maximum size in bytes of a nested archive to look inside")
+)
+
+// file is a file found by the scan.
+type file struct {
+	name   string
+	sha1   [sha1.Size]byte
+	sha256 [sha256.Size]byte
+}
+
+// archiveKind returns the kind of archive a file is, judging by its name,
+// or the empty string if it is not one.
+func archiveKind(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"), strings.HasSuffix(name, ".crate"):
+		return "tgz"
+	case strings.HasSuffix(name, ".tar"), strings.HasSuffix(name, ".gem"):
+		return "tar"
+	}
+	switch filepath.Ext(name) {
+	case ".jar", ".war", ".ear", ".aar", ".zip", ".whl", ".egg", ".nupkg":
+		return "zip"
+	}
+	return ""
+}
+
+// scanner hashes files and the contents of archives.
+type scanner struct {
+	files []file
+}
+
+// scanFile hashes the contents of the named file, read from r, and if it is
+// an archive scans its contents too.
+func (s *scanner) scanFile(name string, r io.Reader) error {
+	kind := archiveKind(name)
+	if kind == "" {
+		return s.hash(name, r)
+	}
+	// Archives are read into memory, as the zip package needs random
+	// access and the contents need hashing as well as unpacking.
+	data, err := io.ReadAll(io.LimitReader(r, *maxSize+1))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	if int64(len(data)) > *maxSize {
+		log.Printf("Not looking inside %s: larger than %d bytes", name, *maxSize)
+		return s.hash(name, io.MultiReader(bytes.NewReader(data), r))
+	}
+	if err := s.hash(name, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	if err := s.scanArchive(name, kind, data); err != nil {
+		// A file with an archive's name may not be one; it has
+		// still been hashed, so carry on.
+		log.Printf("Not looking inside %s: %v", name, err)
+	}
+	return nil
+}
+
+// scanArchive scans each regular file in the given archive.
+func (s *scanner) scanArchive(name, kind string, data []byte) error {
+	switch kind {
+	case "zip":
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return err
+		}
+		for _, f := range zr.File {
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = s.scanFile(name+"!/"+f.Name, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case "tgz":
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		return s.scanTar(name, gr)
+	case "tar":
+		return s.scanTar(name, bytes.NewReader(data))
+	}
+	return fmt.Errorf("unknown archive kind %q", kind)
+}
+
+// scanTar scans each regular file in a tar stream.
+func (s *scanner) scanTar(name string, r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := s.scanFile(name+"!/"+h.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// hash records the SHA-1 and SHA-256 hashes of the named file.
+func (s *scanner) hash(name string, r io.Reader) error {
+	h1, h256 := sha1.New(), sha256.New()
+	if _, err := io.Copy(io.MultiWriter(h1, h256), r); err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	f := file{name: name}
+	h1.Sum(f.sha1[:0])
+	h256.Sum(f.sha256[:0])
+	s.files = append(s.files, f)
+	return nil
+}
+
+// scan walks the given paths, scanning every regular file.
+func (s *scanner) scan(paths []string) error {
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return s.scanFile(path, f)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match is a package version matching a file.
+type match struct {
+	System  string `json:"system"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Hash is the type of hash that matched.
+	Hash string `json:"hash"`
+}
+
+// queryResult holds the package versions matching a file's contents.
+type queryResult struct {
+	matches []match
+	err     error
+}
+
+// query finds the package versions matching a file, first by its SHA-1
+// hash and then, if there is no match, by its SHA-256 hash.
+func query(ctx context.Context, client pb.InsightsClient, f file) queryResult {
+	hashes := []struct {
+		typ   pb.HashType
+		value []byte
+	}{
+		{pb.HashType_SHA1, f.sha1[:]},
+		{pb.HashType_SHA256, f.sha256[:]},
+	}
+	var r queryResult
+	for _, h := range hashes {
+		resp, err := client.Query(ctx, &pb.QueryRequest{
+			Hash: &pb.Hash{
+				Type:  h.typ,
+				Value: h.value,
+			},
+		})
+		if err != nil {
+			return queryResult{err: err}
+		}
+		for _, res := range resp.GetResults() {
+			vk := res.GetVersion().GetVersionKey()
+			r.matches = append(r.matches, match{
+				System:  vk.GetSystem().String(),
+				Name:    vk.GetName(),
+				Version: vk.GetVersion(),
+				Hash:    h.typ.String(),
+			})
+		}
+		if len(r.matches) > 0 {
+			break
+		}
+	}
+	return r
+}
+
+// queryAll queries the package versions matching each of the given files,
+// making up to concurrency calls to query at once and starting at most qps
+// per second. Files with the same contents are queried only once. The
+// results are keyed by SHA-256 hash.
+func queryAll(ctx context.Context, client pb.InsightsClient, files []file, concurrency int, qps float64) map[[sha256.Size]byte]queryResult {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[[sha256.Size]byte]queryResult)
+		work    = make(chan file)
+		tick    = time.NewTicker(time.Duration(float64(time.Second) / qps))
+	)
+	defer tick.Stop()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range work {
+				var r queryResult
+				select {
+				case <-tick.C:
+					r = query(ctx, client, f)
+				case <-ctx.Done():
+					r = queryResult{err: ctx.Err()}
+				}
+				mu.Lock()
+				results[f.sha256] = r
+				mu.Unlock()
+			}
+		}()
+	}
+	seen := make(map[[sha256.Size]byte]bool)
+	for _, f := range files {
+		if seen[f.sha256] {
+			continue
+		}
+		seen[f.sha256] = true
+		work <- f
+	}
+	close(work)
+	wg.Wait()
+	return results
+}
+
+// reportEntry is a line of the report.
+type reportEntry struct {
+	File    string  `json:"file"`
+	SHA1    string  `json:"sha1"`
+	SHA256  string  `json:"sha256"`
+	Matches []match `json:"matches,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: artifact_scan [flags] <file-or-directory>...\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || *concurrency < 1 || *qps <= 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var s scanner
+	if err := s.scan(flag.Args()); err != nil {
+		log.Fatalf("Scanning: %v", err)
+	}
+
+	// Set up gRPC API client.
+	certPool, err := x509.SystemCertPool()
+	if err != nil {
+		log.Fatalf("Getting system cert pool: %v", err)
+	}
+	creds := credentials.NewClientTLSFromCert(certPool, "")
+	conn, err := grpc.Dial("api.deps.dev:443",
+		grpc.WithTransportCredentials(creds),
+		// Retry requests that fail because of the API's rate limits.
+		grpc.WithUnaryInterceptor(clientutil.UnaryRetryInterceptor(nil)),
+	)
+	if err != nil {
+		log.Fatalf("Dialing: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewInsightsClient(conn)
+
+	results := queryAll(context.Background(), client, s.files, *concurrency, *qps)
+
+	// Build the report, in file order.
+	var report []reportEntry
+	for _, f := range s.files {
+		r := results[f.sha256]
+		if r.err == nil && len(r.matches) == 0 && !*all {
+			continue
+		}
+		e := reportEntry{
+			File:    f.name,
+			SHA1:    fmt.Sprintf("%x", f.sha1),
+			SHA256:  fmt.Sprintf("%x", f.sha256),
+			Matches: r.matches,
+		}
+		if r.err != nil {
+			e.Error = r.err.Error()
+		}
+		report = append(report, e)
+	}
+	sort.SliceStable(report, func(i, j int) bool { return report[i].File < report[j].File })
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("Encoding report: %v", err)
+		}
+		return
+	}
+	for _, e := range report {
+		switch {
+		case e.Error != "":
+			fmt.Printf("%s: error: %s\n", e.File, e.Error)
+		case len(e.Matches) == 0:
+			fmt.Printf("%s: no match\n", e.File)
+		}
+		for _, m := range e.Matches {
+			fmt.Printf("%s: %s: %s@%s\n", e.File, m.System, m.Name, m.Version)
+		}
+	}
+}
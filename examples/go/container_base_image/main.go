@@ -0,0 +1,97 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+container_base_image is an example application that finds the base images of
+a container image using the deps.dev API.
+
+It reads the image from a tarball written by docker save or ctr export, or
+from an OCI image layout directory. Both the OCI layout written by docker 25
+and later and the docker-archive layout written by earlier versions are
+understood. If the argument is not a file or directory it is taken to be an
+image reference, such as gcr.io/foo/bar:tag, and the image's manifest and
+config are fetched from the registry, using the credentials recorded by docker
+login if the registry requires them. For each layer it prints the layer's OCI Chain ID and the Docker
+Hub image repositories whose images share the layers up to and including it,
+as reported by the QueryContainerImages method of the v3alpha gRPC API.
+*/
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/oci"
+)
+
+func main() {
+	log.SetFlags(0)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: container_base_image <image.tar, OCI layout directory or image reference>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	var imgs []*oci.Image
+	if _, err := os.Stat(flag.Arg(0)); err == nil {
+		imgs, err = oci.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatalf("Reading image: %v", err)
+		}
+	} else {
+		var r oci.Registry
+		imgs, err = r.Fetch(ctx, flag.Arg(0))
+		if err != nil {
+			log.Fatalf("Fetching image: %v", err)
+		}
+	}
+
+	// Set up gRPC API client.
+	certPool, err := x509.SystemCertPool()
+	if err != nil {
+		log.Fatalf("Getting system cert pool: %v", err)
+	}
+	creds := credentials.NewClientTLSFromCert(certPool, "")
+	conn, err := grpc.Dial("api.deps.dev:443", grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Fatalf("Dialing: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewInsightsClient(conn)
+
+	for _, img := range imgs {
+		fmt.Printf("Image %s (%s)\n", img.Name, img.Platform)
+		repos, err := oci.BaseImages(ctx, client, img)
+		if err != nil {
+			log.Fatalf("Querying base images: %v", err)
+		}
+		for i, l := range img.Layers {
+			fmt.Printf("  layer %d %s: %s\n", i, l.ChainID, strings.Join(repos[i], ", "))
+		}
+	}
+}
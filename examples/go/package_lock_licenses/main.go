@@ -36,12 +36,12 @@ import (
 	"os"
 
 	"golang.org/x/sync/errgroup"
-	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 
+	"deps.dev/api/clientutil"
 	pb "deps.dev/api/v3alpha"
 )
 
@@ -78,6 +78,7 @@ type VersionResponse struct {
 var (
 	includeDevDeps      = flag.Bool("dev", false, "whether to include dev dependencies")
 	includeOptionalDeps = flag.Bool("optional", false, "whether to include optional dependencies")
+	rps                 = flag.Float64("rps", 500, "initial number of requests per second, reduced if the API's quota is exceeded")
 )
 
 func main() {
@@ -132,7 +133,16 @@ func main() {
 		log.Fatalf("Getting system cert pool: %v", err)
 	}
 	creds := credentials.NewClientTLSFromCert(certPool, "")
-	conn, err := grpc.Dial("api.deps.dev:443", grpc.WithTransportCredentials(creds))
+	// Start at the given rate of requests, slowing down and retrying when
+	// the API's quota is exceeded. The throttle waits before each attempt.
+	throttle := clientutil.NewThrottle(&clientutil.ThrottleOptions{RPS: *rps})
+	conn, err := grpc.Dial("api.deps.dev:443",
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(
+			clientutil.UnaryRetryInterceptor(nil),
+			throttle.UnaryClientInterceptor(),
+		),
+	)
 	if err != nil {
 		log.Fatalf("Dialing: %v", err)
 	}
@@ -140,11 +150,10 @@ func main() {
 
 	// Fetch license details from the deps.dev API.
 	// To speed things up, use an error group to make many requests
-	// concurrently, but limit the rate to 500 requests/second.
+	// concurrently, at the rate allowed by the throttle.
 	// Note that gRPC will multiplex multiple requests over a single HTTP/2
 	// connection.
 	g, ctx := errgroup.WithContext(context.Background())
-	limiter := rate.NewLimiter(500, 1)
 	for v := range versions {
 		r := versions[v]
 		req := pb.GetVersionRequest{
@@ -155,9 +164,6 @@ func main() {
 			},
 		}
 		g.Go(func() error {
-			if err := limiter.Wait(ctx); err != nil {
-				return err
-			}
 			resp, err := client.GetVersion(ctx, &req)
 			switch status.Code(err) {
 			case codes.OK:
@@ -93,9 +93,11 @@ type GetVersionBatchRequest struct {
 }
 
 // VersionResponse corresponds to the v3alpha API definition of VersionBatch.Response.
+// Version is nil if the requested version was not found, as the field is
+// then left out of the response.
 type VersionResponse struct {
 	Request GetVersionRequest `json:"request"`
-	Version struct {
+	Version *struct {
 		VersionKey     VersionKey `json:"versionKey"`
 		LicenseDetails []License  `json:"licenseDetails"`
 	} `json:"version"`
@@ -203,8 +205,8 @@ func main() {
 				Name:    response.Request.VersionKey.Name,
 				Version: response.Request.VersionKey.Version,
 			}
-			if (response.Version.VersionKey == VersionKey{}) {
-				// An empty Version field means that the requested
+			if response.Version == nil {
+				// A missing Version field means that the requested
 				// version was not found.
 				versions[v] = nil
 			} else {
@@ -10,7 +10,7 @@ replace (
 
 require (
 	deps.dev/api/v3 v3.0.0-20240311054650-e1e6a3d70fb7
-	deps.dev/util/resolve v0.0.0-20240312000934-38ffc8dd1d92
+	deps.dev/util/resolve v0.0.0-20240611045547-af20eef0f1eb
 	google.golang.org/grpc v1.69.4
 )
 
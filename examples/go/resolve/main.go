@@ -14,46 +14,69 @@
 
 /*
 resolve is an example program that performs dependency resolution for
-a single version of a published npm package, using the example resolver
-implementation in deps.dev/resolve. It then compares the resulting graph
-to the result from the GetDependencies endpoint.
+versions of published npm and Maven packages, using the example resolver
+implementations in deps.dev/util/resolve. It then compares the resulting
+graphs to the results from the GetDependencies endpoint, using the harness
+in deps.dev/util/resolve/difftest, and exits with status 1 if any differ.
+
+Each argument names a package, such as npm:react or
+maven:org.apache.logging.log4j:log4j-core, optionally with a version, such
+as npm:react@18.2.0. Up to -n versions per system are sampled from the
+packages given without a version.
 */
 package main
 
 import (
 	"context"
 	"crypto/x509"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
-	"text/tabwriter"
-	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
 	pb "deps.dev/api/v3"
 	"deps.dev/util/resolve"
-	"deps.dev/util/resolve/dep"
-	"deps.dev/util/resolve/npm"
+	"deps.dev/util/resolve/difftest"
 )
 
-const usage = "Usage: resolve <package-name> <package-version>"
+const usage = "Usage: resolve [-n samples] [-seed seed] [-json] <system>:<package-name>[@<version>]..."
+
+var (
+	samples = flag.Int("n", 10, "number of versions to sample per system")
+	seed    = flag.Int64("seed", 1, "seed for sampling versions")
+	asJSON  = flag.Bool("json", false, "write the report as JSON")
+)
 
 func main() {
 	log.SetFlags(0)
-	if len(os.Args) != 3 {
-		log.Fatal(usage)
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	root := resolve.VersionKey{
-		PackageKey: resolve.PackageKey{
-			System: resolve.NPM,
-			Name:   os.Args[1],
-		},
-		VersionType: resolve.Concrete,
-		Version:     os.Args[2],
+	var (
+		pkgs []resolve.PackageKey
+		vks  []resolve.VersionKey
+	)
+	for _, arg := range flag.Args() {
+		vk, err := parseArg(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if vk.Version == "" {
+			pkgs = append(pkgs, vk.PackageKey)
+		} else {
+			vks = append(vks, vk)
+		}
 	}
 
 	// Set up gRPC API client.
@@ -68,75 +91,52 @@ func main() {
 	}
 	client := pb.NewInsightsClient(conn)
 
-	resolver := npm.NewResolver(resolve.NewAPIClient(client))
+	h := difftest.New(client, &difftest.Options{Seed: *seed})
 	ctx := context.Background()
-
-	start := time.Now()
-	log.Printf("Resolving: %v", root)
-	g, err := resolver.Resolve(ctx, root)
-	if err != nil {
-		log.Fatal(err)
-	}
-	// Strip the dependency types for comparison.
-	for i, e := range g.Edges {
-		e.Type = dep.Type{}
-		g.Edges[i] = e
+	if len(pkgs) > 0 {
+		sampled, err := h.Sample(ctx, pkgs, *samples)
+		if err != nil {
+			log.Fatalf("Sampling versions: %v", err)
+		}
+		vks = append(vks, sampled...)
 	}
-	if err := g.Canon(); err != nil {
-		log.Fatal(err)
-	}
-	log.Printf("Resolved in %v", time.Since(start))
 
-	start = time.Now()
-	log.Printf("GetDependencies(%v)", root)
-	resp, err := client.GetDependencies(ctx, &pb.GetDependenciesRequest{
-		VersionKey: &pb.VersionKey{
-			System:  pb.System_NPM,
-			Name:    root.Name,
-			Version: root.Version,
-		},
-	})
+	log.Printf("Comparing %d versions", len(vks))
+	r := h.Run(ctx, vks)
+	if *asJSON {
+		err = r.WriteJSON(os.Stdout)
+	} else {
+		err = r.WriteText(os.Stdout)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
-	var g2 resolve.Graph
-	for _, n := range resp.Nodes {
-		g2.AddNode(resolve.VersionKey{
-			PackageKey: resolve.PackageKey{
-				System: resolve.NPM,
-				Name:   n.VersionKey.Name,
-			},
-			VersionType: resolve.Concrete,
-			Version:     n.VersionKey.Version,
-		})
-	}
-	for _, e := range resp.Edges {
-		g2.AddEdge(resolve.NodeID(e.FromNode), resolve.NodeID(e.ToNode), e.Requirement, dep.Type{})
+	if !r.OK() {
+		os.Exit(1)
 	}
-	if err := g2.Canon(); err != nil {
-		log.Fatal(err)
-	}
-	log.Printf("GetDependencies in %v", time.Since(start))
-	printGraphs(g, &g2)
 }
 
-// printGraphs prints the two resolved graphs side by side.
-func printGraphs(local, remote *resolve.Graph) {
-	s1 := strings.Split(local.String(), "\n")
-	s2 := strings.Split(remote.String(), "\n")
-
-	w := tabwriter.NewWriter(os.Stdout, 10, 2, 2, ' ', 0)
-	fmt.Fprintf(w, "Local\tGetDependencies\n")
-	for len(s1) > 0 && len(s2) > 0 {
-		fmt.Fprintf(w, "%s\t%s\n", s1[0], s2[0])
-		s1, s2 = s1[1:], s2[1:]
+// parseArg parses a package, and optional version, given as
+// <system>:<name>[@<version>].
+func parseArg(arg string) (resolve.VersionKey, error) {
+	var vk resolve.VersionKey
+	sys, name, ok := strings.Cut(arg, ":")
+	if !ok || name == "" {
+		return vk, fmt.Errorf("invalid package %q: %s", arg, usage)
 	}
-	for _, l := range s1 {
-		fmt.Fprintf(w, "%s\t\n", l)
+	switch strings.ToLower(sys) {
+	case "npm":
+		vk.System = resolve.NPM
+	case "maven":
+		vk.System = resolve.Maven
+	default:
+		return vk, fmt.Errorf("invalid package %q: unsupported system %q", arg, sys)
 	}
-	for _, l := range s2 {
-		fmt.Fprintf(w, "\t%s\t\n", l)
+	// An npm name may begin with @, as in @scope/name.
+	if i := strings.LastIndex(name, "@"); i > 0 {
+		name, vk.Version = name[:i], name[i+1:]
+		vk.VersionType = resolve.Concrete
 	}
-	w.Flush()
-	return
+	vk.Name = name
+	return vk, nil
 }
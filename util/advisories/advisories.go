@@ -0,0 +1,195 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package advisories gathers the security advisories affecting a set of
+package versions, such as the dependencies of a project, from the deps.dev
+API, summarizes their severity, and checks them against a policy so that a
+build may be failed when they are too severe.
+
+Fetch looks up the versions with GetVersionBatch and their advisories with
+GetAdvisory, and returns a Report giving the highest CVSS v3 score of the
+advisories affecting each version and of all of them. A Policy holds rules
+such as "fail on HIGH in direct dependencies", written as HIGH:direct, and
+Evaluate lists the advisories that break them:
+
+	r, err := advisories.Fetch(ctx, client, deps)
+	if err != nil {
+		// ...
+	}
+	p, err := advisories.ParsePolicy("CRITICAL,HIGH:direct")
+	if err != nil {
+		// ...
+	}
+	if vs := p.Evaluate(r); len(vs) > 0 {
+		// Fail the build.
+	}
+
+The osv subpackage fetches the full OSV records of advisories, giving the
+version ranges they affect, and the vex subpackage records how advisories
+have been triaged as OpenVEX documents.
+*/
+package advisories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/batch"
+)
+
+// Severity is the qualitative severity of an advisory, derived from its
+// CVSS v3 score as set out by the CVSS v3.1 specification.
+type Severity int
+
+const (
+	// None is the severity of an advisory with no CVSS v3 score, or a
+	// score of zero.
+	None Severity = iota
+	Low
+	Medium
+	High
+	Critical
+)
+
+var severityNames = [...]string{"NONE", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+func (s Severity) String() string {
+	if s < None || s > Critical {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler, so that severities are
+// encoded by name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSeverity parses a severity name, such as HIGH, in any case.
+func ParseSeverity(s string) (Severity, error) {
+	for i, n := range severityNames {
+		if strings.EqualFold(s, n) {
+			return Severity(i), nil
+		}
+	}
+	return None, fmt.Errorf("unknown severity %q", s)
+}
+
+// SeverityOf returns the severity of a CVSS v3 score.
+func SeverityOf(score float32) Severity {
+	switch {
+	case score >= 9:
+		return Critical
+	case score >= 7:
+		return High
+	case score >= 4:
+		return Medium
+	case score > 0:
+		return Low
+	}
+	return None
+}
+
+// Dependency is a package version to check.
+type Dependency struct {
+	VersionKey *pb.VersionKey
+	// Direct reports whether the version is a direct dependency.
+	Direct bool
+}
+
+// FromGraph returns the dependencies in a resolved dependency graph, as
+// returned by GetDependencies, excluding the package version the graph was
+// resolved for.
+func FromGraph(g *pb.Dependencies) []Dependency {
+	var deps []Dependency
+	for _, n := range g.GetNodes() {
+		if n.GetRelation() == pb.DependencyRelation_SELF {
+			continue
+		}
+		deps = append(deps, Dependency{
+			VersionKey: n.GetVersionKey(),
+			Direct:     n.GetRelation() == pb.DependencyRelation_DIRECT,
+		})
+	}
+	return deps
+}
+
+// Result holds the advisories affecting a dependency.
+type Result struct {
+	Dependency
+	// Found reports whether the package version is known to deps.dev. If
+	// not, it has no advisories.
+	Found      bool
+	Advisories []*pb.Advisory
+	// MaxScore is the highest CVSS v3 score of the advisories.
+	MaxScore float32
+}
+
+// Severity returns the severity of the result's highest score.
+func (r *Result) Severity() Severity { return SeverityOf(r.MaxScore) }
+
+// Report holds the advisories affecting a set of dependencies.
+type Report struct {
+	// Results holds a result for each dependency, in the order given to
+	// Fetch.
+	Results []*Result
+	// MaxScore is the highest CVSS v3 score of any advisory.
+	MaxScore float32
+}
+
+// Severity returns the severity of the report's highest score.
+func (r *Report) Severity() Severity { return SeverityOf(r.MaxScore) }
+
+// Fetch fetches the advisories affecting the given dependencies. Each
+// advisory is fetched once, however many dependencies it affects.
+func Fetch(ctx context.Context, c pb.InsightsClient, deps []Dependency) (*Report, error) {
+	vks := make([]*pb.VersionKey, len(deps))
+	for i, d := range deps {
+		vks[i] = d.VersionKey
+	}
+	versions, err := batch.NewRunner(c, nil).LookupVersions(ctx, vks)
+	if err != nil {
+		return nil, err
+	}
+	advisories := make(map[string]*pb.Advisory)
+	r := &Report{Results: make([]*Result, len(deps))}
+	for i, d := range deps {
+		res := &Result{Dependency: d}
+		r.Results[i] = res
+		v, ok := versions[batch.KeyOf(d.VersionKey)]
+		if !ok {
+			continue
+		}
+		res.Found = true
+		for _, ak := range v.GetAdvisoryKeys() {
+			id := ak.GetId()
+			a, ok := advisories[id]
+			if !ok {
+				a, err = c.GetAdvisory(ctx, &pb.GetAdvisoryRequest{AdvisoryKey: ak})
+				if err != nil {
+					return nil, fmt.Errorf("fetching advisory %s: %w", id, err)
+				}
+				advisories[id] = a
+			}
+			res.Advisories = append(res.Advisories, a)
+			res.MaxScore = max(res.MaxScore, a.GetCvss3Score())
+		}
+		r.MaxScore = max(r.MaxScore, res.MaxScore)
+	}
+	return r, nil
+}
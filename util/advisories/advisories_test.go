@@ -0,0 +1,216 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisories
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/batch"
+)
+
+// fakeClient serves versions and advisories from fixed data. Version
+// batches are returned one response per page, to exercise paging.
+type fakeClient struct {
+	pb.InsightsClient
+	versions      map[batch.VersionKey]*pb.Version
+	advisories    map[string]*pb.Advisory
+	advisoryCalls int
+}
+
+func (c *fakeClient) GetVersionBatch(ctx context.Context, req *pb.GetVersionBatchRequest, opts ...grpc.CallOption) (*pb.VersionBatch, error) {
+	i := 0
+	if req.PageToken != "" {
+		i, _ = strconv.Atoi(req.PageToken)
+	}
+	r := req.Requests[i]
+	resp := &pb.VersionBatch{Responses: []*pb.VersionBatch_Response{{
+		Request: r,
+		Version: c.versions[batch.KeyOf(r.GetVersionKey())],
+	}}}
+	if i+1 < len(req.Requests) {
+		resp.NextPageToken = strconv.Itoa(i + 1)
+	}
+	return resp, nil
+}
+
+func (c *fakeClient) GetAdvisory(ctx context.Context, req *pb.GetAdvisoryRequest, opts ...grpc.CallOption) (*pb.Advisory, error) {
+	c.advisoryCalls++
+	a, ok := c.advisories[req.GetAdvisoryKey().GetId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "advisory not found")
+	}
+	return a, nil
+}
+
+func vk(name, version string) *pb.VersionKey {
+	return &pb.VersionKey{System: pb.System_NPM, Name: name, Version: version}
+}
+
+func advisory(id string, score float32) *pb.Advisory {
+	return &pb.Advisory{AdvisoryKey: &pb.AdvisoryKey{Id: id}, Title: id, Cvss3Score: score}
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		versions: map[batch.VersionKey]*pb.Version{
+			batch.KeyOf(vk("direct", "1.0.0")): {
+				VersionKey:   vk("direct", "1.0.0"),
+				AdvisoryKeys: []*pb.AdvisoryKey{{Id: "GHSA-high"}, {Id: "GHSA-low"}},
+			},
+			batch.KeyOf(vk("indirect", "1.0.0")): {
+				VersionKey:   vk("indirect", "1.0.0"),
+				AdvisoryKeys: []*pb.AdvisoryKey{{Id: "GHSA-high"}, {Id: "GHSA-critical"}},
+			},
+			batch.KeyOf(vk("clean", "1.0.0")): {VersionKey: vk("clean", "1.0.0")},
+		},
+		advisories: map[string]*pb.Advisory{
+			"GHSA-low":      advisory("GHSA-low", 3.1),
+			"GHSA-high":     advisory("GHSA-high", 7.5),
+			"GHSA-critical": advisory("GHSA-critical", 9.8),
+		},
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	for _, tc := range []struct {
+		score float32
+		want  Severity
+	}{
+		{0, None}, {0.1, Low}, {3.9, Low}, {4, Medium}, {6.9, Medium},
+		{7, High}, {8.9, High}, {9, Critical}, {10, Critical},
+	} {
+		if got := SeverityOf(tc.score); got != tc.want {
+			t.Errorf("SeverityOf(%v): got %v, want %v", tc.score, got, tc.want)
+		}
+	}
+	if s, err := ParseSeverity("high"); err != nil || s != High {
+		t.Errorf("ParseSeverity(high): got %v, %v", s, err)
+	}
+	if _, err := ParseSeverity("severe"); err == nil {
+		t.Errorf("ParseSeverity(severe): succeeded, want error")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	c := newFakeClient()
+	deps := []Dependency{
+		{VersionKey: vk("direct", "1.0.0"), Direct: true},
+		{VersionKey: vk("indirect", "1.0.0")},
+		{VersionKey: vk("clean", "1.0.0"), Direct: true},
+		{VersionKey: vk("unknown", "1.0.0")},
+	}
+	r, err := Fetch(context.Background(), c, deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.MaxScore, float32(9.8); got != want || r.Severity() != Critical {
+		t.Errorf("report max score: got %v (%v), want %v", got, r.Severity(), want)
+	}
+	type summary struct {
+		found      bool
+		advisories int
+		severity   Severity
+	}
+	var got []summary
+	for _, res := range r.Results {
+		got = append(got, summary{res.Found, len(res.Advisories), res.Severity()})
+	}
+	want := []summary{{true, 2, High}, {true, 2, Critical}, {true, 0, None}, {false, 0, None}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results: got %v, want %v", got, want)
+	}
+	if c.advisoryCalls != 3 {
+		t.Errorf("GetAdvisory called %d times, want 3", c.advisoryCalls)
+	}
+}
+
+func TestFromGraph(t *testing.T) {
+	g := &pb.Dependencies{Nodes: []*pb.Dependencies_Node{
+		{VersionKey: vk("root", "1.0.0"), Relation: pb.DependencyRelation_SELF},
+		{VersionKey: vk("direct", "1.0.0"), Relation: pb.DependencyRelation_DIRECT},
+		{VersionKey: vk("indirect", "1.0.0"), Relation: pb.DependencyRelation_INDIRECT},
+	}}
+	got := FromGraph(g)
+	if len(got) != 2 || got[0].VersionKey.GetName() != "direct" || !got[0].Direct || got[1].Direct {
+		t.Errorf("FromGraph: got %v", got)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	r, err := Fetch(context.Background(), newFakeClient(), []Dependency{
+		{VersionKey: vk("direct", "1.0.0"), Direct: true},
+		{VersionKey: vk("indirect", "1.0.0")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type violation struct{ name, advisory, rule string }
+	for _, tc := range []struct {
+		policy string
+		ignore []string
+		want   []violation
+	}{
+		{"", nil, nil},
+		{"HIGH:direct", nil, []violation{{"direct", "GHSA-high", "HIGH:direct"}}},
+		{"critical,high:direct", nil, []violation{
+			{"direct", "GHSA-high", "HIGH:direct"},
+			{"indirect", "GHSA-critical", "CRITICAL"},
+		}},
+		{"low", []string{"GHSA-high"}, []violation{
+			{"direct", "GHSA-low", "LOW"},
+			{"indirect", "GHSA-critical", "LOW"},
+		}},
+	} {
+		p, err := ParsePolicy(tc.policy)
+		if err != nil {
+			t.Errorf("ParsePolicy(%q): %v", tc.policy, err)
+			continue
+		}
+		p.Ignore = tc.ignore
+		var got []violation
+		for _, v := range p.Evaluate(r) {
+			got = append(got, violation{v.Name, v.Advisory, v.Rule})
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("policy %q ignoring %v: got %v, want %v", tc.policy, tc.ignore, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"severe", "HIGH:transitive", "NONE"} {
+		if _, err := ParsePolicy(bad); err == nil {
+			t.Errorf("ParsePolicy(%q): succeeded, want error", bad)
+		}
+	}
+}
+
+func TestViolationJSON(t *testing.T) {
+	v := Violation{System: "NPM", Name: "a", Version: "1.0.0", Advisory: "GHSA-1", Score: 9.8, Severity: Critical, Rule: "CRITICAL"}
+	got, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"system":"NPM","name":"a","version":"1.0.0","direct":false,"advisory":"GHSA-1","title":"","cvss3Score":9.8,"severity":"CRITICAL","rule":"CRITICAL"}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
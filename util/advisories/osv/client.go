@@ -0,0 +1,129 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package osv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/cache"
+)
+
+// DefaultURL is the base URL of the OSV API.
+const DefaultURL = "https://api.osv.dev/v1"
+
+// ErrNotFound is returned, wrapped, when OSV has no record with the
+// requested ID.
+var ErrNotFound = errors.New("not found")
+
+// maxRecordSize is the largest OSV record read.
+const maxRecordSize = 16 << 20
+
+// Client fetches OSV records. Records are cached, so each is fetched at
+// most once, however often it is requested. The zero value is ready to use
+// and is safe for concurrent use.
+type Client struct {
+	// BaseURL is the base URL of the OSV API. If empty, DefaultURL is
+	// used.
+	BaseURL string
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+
+	once  sync.Once
+	cache *cache.Cache[string, *Vulnerability]
+}
+
+// Get returns the OSV record with the given ID, such as
+// GHSA-2qrg-x229-3v8q.
+func (c *Client) Get(ctx context.Context, id string) (*Vulnerability, error) {
+	c.once.Do(func() { c.cache = cache.New[string, *Vulnerability](nil) })
+	return c.cache.GetOrLoad(id, func() (*Vulnerability, error) {
+		return c.fetch(ctx, id)
+	})
+}
+
+func (c *Client) fetch(ctx context.Context, id string) (*Vulnerability, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultURL
+	}
+	u := strings.TrimSuffix(base, "/") + "/vulns/" + url.PathEscape(id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching OSV record %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("OSV record %s: %w", id, ErrNotFound)
+	default:
+		return nil, fmt.Errorf("fetching OSV record %s: %s", id, resp.Status)
+	}
+	var v Vulnerability
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRecordSize)).Decode(&v); err != nil {
+		return nil, fmt.Errorf("parsing OSV record %s: %w", id, err)
+	}
+	return &v, nil
+}
+
+// Record is an advisory as described by both deps.dev and OSV.
+type Record struct {
+	// Advisory is deps.dev's summary of the advisory.
+	Advisory *pb.Advisory
+	// OSV is the full OSV record. It is nil if OSV has no record of the
+	// advisory.
+	OSV *Vulnerability
+}
+
+// Advisory fetches the advisory with the given key from the deps.dev API,
+// using the given client, and its record from OSV. If OSV has no record
+// with the advisory's ID, the records of its aliases are tried in turn.
+func (c *Client) Advisory(ctx context.Context, insights pb.InsightsClient, key *pb.AdvisoryKey) (*Record, error) {
+	a, err := insights.GetAdvisory(ctx, &pb.GetAdvisoryRequest{AdvisoryKey: key})
+	if err != nil {
+		return nil, fmt.Errorf("fetching advisory %s: %w", key.GetId(), err)
+	}
+	r := &Record{Advisory: a}
+	for _, id := range append([]string{key.GetId()}, a.GetAliases()...) {
+		v, err := c.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.OSV = v
+		break
+	}
+	return r, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package osv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+const record = `{
+	"schema_version": "1.4.0",
+	"id": "GHSA-2qrg-x229-3v8q",
+	"modified": "2024-01-02T03:04:05Z",
+	"aliases": ["CVE-2021-3918"],
+	"summary": "Prototype Pollution in json-schema",
+	"severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}],
+	"affected": [{
+		"package": {"ecosystem": "npm", "name": "json-schema", "purl": "pkg:npm/json-schema"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.4.0"}]}],
+		"database_specific": {"source": "test"}
+	}],
+	"references": [{"type": "ADVISORY", "url": "https://nvd.nist.gov/vuln/detail/CVE-2021-3918"}]
+}`
+
+// newServer returns an OSV API serving record under the given ID, and a
+// count of the requests it has received.
+func newServer(t *testing.T, id string) (*httptest.Server, *int) {
+	n := new(int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*n++
+		if r.URL.Path != "/v1/vulns/"+id {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(record))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, n
+}
+
+func TestGet(t *testing.T) {
+	srv, n := newServer(t, "GHSA-2qrg-x229-3v8q")
+	c := &Client{BaseURL: srv.URL + "/v1"}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		v, err := c.Get(ctx, "GHSA-2qrg-x229-3v8q")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(v.Affected) != 1 || len(v.Affected[0].Ranges) != 1 || v.Affected[0].Ranges[0].Events[1].Fixed != "0.4.0" {
+			t.Errorf("got affected %+v", v.Affected)
+		}
+		if string(v.Affected[0].DatabaseSpecific) != `{"source": "test"}` {
+			t.Errorf("got database_specific %s", v.Affected[0].DatabaseSpecific)
+		}
+	}
+	if *n != 1 {
+		t.Errorf("server received %d requests, want 1", *n)
+	}
+	if _, err := c.Get(ctx, "GHSA-xxxx-xxxx-xxxx"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of unknown ID: got %v, want ErrNotFound", err)
+	}
+}
+
+type fakeInsights struct {
+	pb.InsightsClient
+	advisory *pb.Advisory
+}
+
+func (c fakeInsights) GetAdvisory(ctx context.Context, req *pb.GetAdvisoryRequest, opts ...grpc.CallOption) (*pb.Advisory, error) {
+	return c.advisory, nil
+}
+
+func TestAdvisory(t *testing.T) {
+	// deps.dev knows the advisory by an ID that OSV does not, so OSV's
+	// record is found by its alias.
+	srv, _ := newServer(t, "GHSA-2qrg-x229-3v8q")
+	c := &Client{BaseURL: srv.URL + "/v1"}
+	a := &pb.Advisory{
+		AdvisoryKey: &pb.AdvisoryKey{Id: "OTHER-1"},
+		Aliases:     []string{"CVE-2021-3918", "GHSA-2qrg-x229-3v8q"},
+		Cvss3Score:  9.8,
+	}
+	r, err := c.Advisory(context.Background(), fakeInsights{advisory: a}, a.AdvisoryKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Advisory != a || r.OSV == nil || r.OSV.ID != "GHSA-2qrg-x229-3v8q" {
+		t.Errorf("got %+v", r)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package osv fetches the full records of security advisories from the OSV
+API, https://osv.dev.
+
+The advisories returned by the deps.dev API summarize the records held by
+OSV: they give an advisory's title, aliases and CVSS v3 score, but not the
+version ranges it affects or the fields specific to each ecosystem. Client
+fetches the OSV record of an advisory by the ID in its deps.dev
+AdvisoryKey, and Client.Advisory returns both views of an advisory as one
+Record:
+
+	var c osv.Client
+	r, err := c.Advisory(ctx, insights, &pb.AdvisoryKey{Id: "GHSA-2qrg-x229-3v8q"})
+	if err != nil {
+		// ...
+	}
+	for _, a := range r.OSV.Affected {
+		// ...
+	}
+
+The types of this package follow the OSV schema, described at
+https://ossf.github.io/osv-schema/.
+*/
+package osv
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Vulnerability is an OSV record.
+type Vulnerability struct {
+	SchemaVersion    string          `json:"schema_version,omitempty"`
+	ID               string          `json:"id"`
+	Modified         time.Time       `json:"modified"`
+	Published        time.Time       `json:"published"` // Zero if not given.
+	Withdrawn        time.Time       `json:"withdrawn"` // Zero if not withdrawn.
+	Aliases          []string        `json:"aliases,omitempty"`
+	Related          []string        `json:"related,omitempty"`
+	Summary          string          `json:"summary,omitempty"`
+	Details          string          `json:"details,omitempty"`
+	Severity         []Severity      `json:"severity,omitempty"`
+	Affected         []Affected      `json:"affected,omitempty"`
+	References       []Reference     `json:"references,omitempty"`
+	Credits          []Credit        `json:"credits,omitempty"`
+	DatabaseSpecific json.RawMessage `json:"database_specific,omitempty"`
+}
+
+// Severity is a severity score of a vulnerability, such as a CVSS vector.
+type Severity struct {
+	// Type is the scoring system, such as CVSS_V3.
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected describes the versions of a package affected by a
+// vulnerability.
+type Affected struct {
+	Package  Package    `json:"package"`
+	Severity []Severity `json:"severity,omitempty"`
+	Ranges   []Range    `json:"ranges,omitempty"`
+	// Versions lists affected versions individually.
+	Versions          []string        `json:"versions,omitempty"`
+	EcosystemSpecific json.RawMessage `json:"ecosystem_specific,omitempty"`
+	DatabaseSpecific  json.RawMessage `json:"database_specific,omitempty"`
+}
+
+// Package identifies a package in an ecosystem, such as npm or Maven.
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Purl      string `json:"purl,omitempty"`
+}
+
+// Range is a range of affected versions, given by a sequence of events.
+type Range struct {
+	// Type is the kind of version the events hold: SEMVER, ECOSYSTEM or
+	// GIT.
+	Type             string          `json:"type"`
+	Repo             string          `json:"repo,omitempty"`
+	Events           []Event         `json:"events"`
+	DatabaseSpecific json.RawMessage `json:"database_specific,omitempty"`
+}
+
+// Event is a point in a Range at which versions start or stop being
+// affected. Only one of its fields is set.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// Reference is a link to more information about a vulnerability.
+type Reference struct {
+	// Type is the kind of reference, such as ADVISORY, FIX or WEB.
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// Credit names someone credited with finding or fixing a vulnerability.
+type Credit struct {
+	Name    string   `json:"name"`
+	Contact []string `json:"contact,omitempty"`
+	Type    string   `json:"type,omitempty"`
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisories
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule is a rule of a Policy. It is broken by an advisory of at least its
+// severity affecting a dependency it applies to.
+type Rule struct {
+	Severity Severity
+	// DirectOnly restricts the rule to direct dependencies.
+	DirectOnly bool
+}
+
+// String returns the rule in the form understood by ParseRule.
+func (r Rule) String() string {
+	if r.DirectOnly {
+		return r.Severity.String() + ":direct"
+	}
+	return r.Severity.String()
+}
+
+// ParseRule parses a rule written as a severity, such as HIGH, optionally
+// followed by ":direct" to apply it only to direct dependencies.
+func ParseRule(s string) (Rule, error) {
+	sev, scope, hasScope := strings.Cut(strings.TrimSpace(s), ":")
+	var r Rule
+	if hasScope {
+		if !strings.EqualFold(scope, "direct") {
+			return Rule{}, fmt.Errorf("rule %q: unknown scope %q", s, scope)
+		}
+		r.DirectOnly = true
+	}
+	var err error
+	if r.Severity, err = ParseSeverity(sev); err != nil {
+		return Rule{}, fmt.Errorf("rule %q: %w", s, err)
+	}
+	if r.Severity == None {
+		return Rule{}, fmt.Errorf("rule %q: severity must be at least LOW", s)
+	}
+	return r, nil
+}
+
+// Policy is a set of rules that the advisories affecting a set of
+// dependencies must not break.
+type Policy struct {
+	Rules []Rule
+	// Ignore holds the IDs of advisories that break no rules, such as
+	// ones that have been assessed as not applying.
+	Ignore []string
+}
+
+// ParsePolicy parses a comma-separated list of rules, as understood by
+// ParseRule, such as "CRITICAL,HIGH:direct".
+func ParsePolicy(s string) (*Policy, error) {
+	p := &Policy{}
+	for _, f := range strings.Split(s, ",") {
+		if strings.TrimSpace(f) == "" {
+			continue
+		}
+		r, err := ParseRule(f)
+		if err != nil {
+			return nil, err
+		}
+		p.Rules = append(p.Rules, r)
+	}
+	return p, nil
+}
+
+// Violation is an advisory affecting a dependency that breaks a rule of a
+// policy. Its fields are plain values, so that it may be reported as JSON.
+type Violation struct {
+	System   string   `json:"system"`
+	Name     string   `json:"name"`
+	Version  string   `json:"version"`
+	Direct   bool     `json:"direct"`
+	Advisory string   `json:"advisory"`
+	Title    string   `json:"title"`
+	Score    float32  `json:"cvss3Score"`
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+}
+
+// Evaluate returns the violations of the policy in the report, one for
+// each advisory affecting each dependency that breaks any of its rules,
+// ordered as the dependencies and their advisories are in the report. The
+// rule given is the first broken.
+func (p *Policy) Evaluate(r *Report) []Violation {
+	ignore := make(map[string]bool)
+	for _, id := range p.Ignore {
+		ignore[id] = true
+	}
+	var vs []Violation
+	for _, res := range r.Results {
+		for _, a := range res.Advisories {
+			id := a.GetAdvisoryKey().GetId()
+			if ignore[id] {
+				continue
+			}
+			sev := SeverityOf(a.GetCvss3Score())
+			for _, rule := range p.Rules {
+				if sev < rule.Severity || rule.DirectOnly && !res.Direct {
+					continue
+				}
+				vk := res.VersionKey
+				vs = append(vs, Violation{
+					System:   vk.GetSystem().String(),
+					Name:     vk.GetName(),
+					Version:  vk.GetVersion(),
+					Direct:   res.Direct,
+					Advisory: id,
+					Title:    a.GetTitle(),
+					Score:    a.GetCvss3Score(),
+					Severity: sev,
+					Rule:     rule.String(),
+				})
+				break
+			}
+		}
+	}
+	return vs
+}
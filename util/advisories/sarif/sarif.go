@@ -0,0 +1,329 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package sarif writes the violations of an advisory policy as a SARIF 2.1.0
+log, so that they can be uploaded to GitHub code scanning from CI.
+
+New makes a result for each violation, located at the line of the
+project's manifest that declares the direct dependency through which the
+affected version is used: the affected version itself if it is a direct
+dependency, or the direct dependency that brings it in, as found by Via,
+if not. Each advisory becomes a rule of the log, with its CVSS v3 score
+given as the security-severity property used by GitHub to rank alerts.
+
+The format is specified at
+https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+*/
+package sarif
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/advisories"
+)
+
+const (
+	// Schema is the JSON schema of SARIF 2.1.0 logs.
+	Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+	// Version is the version of SARIF written.
+	Version = "2.1.0"
+	// ToolName names this package as the tool that wrote a log.
+	ToolName = "deps.dev/util/advisories/sarif"
+)
+
+// Log is a SARIF log.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is a run of an analysis tool.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the analysis tool.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the component of the tool holding its rules.
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule is a rule of the tool; here, an advisory.
+type Rule struct {
+	ID               string         `json:"id"`
+	ShortDescription Message        `json:"shortDescription"`
+	HelpURI          string         `json:"helpUri,omitempty"`
+	Properties       RuleProperties `json:"properties"`
+}
+
+// RuleProperties are the properties of a rule read by GitHub code scanning.
+type RuleProperties struct {
+	// SecuritySeverity is the CVSS v3 score of the advisory, formatted
+	// as a decimal number.
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+}
+
+// Message is a plain text message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is a finding: a violation of the policy.
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations"`
+	// PartialFingerprints identify the result across runs, so that the
+	// alert it raises is kept while the violation persists.
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+// Location is the location of a result.
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a location in a file.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation identifies a file by its path relative to the root of
+// the repository.
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Region is a range of lines in a file, numbered from 1.
+type Region struct {
+	StartLine int `json:"startLine"`
+}
+
+// Options configure New.
+type Options struct {
+	// Manifest is the path of the project's manifest, such as
+	// package.json, relative to the root of the repository, and
+	// ManifestData its contents, searched for the line declaring each
+	// direct dependency. If the line is not found, the result is located
+	// at the file as a whole.
+	Manifest     string
+	ManifestData []byte
+	// Via maps the indirect dependencies to the direct dependencies that
+	// bring them in, as returned by Via.
+	Via map[string]string
+}
+
+// New returns a SARIF log holding a result for each of the given
+// violations, as returned by Policy.Evaluate.
+func New(vs []advisories.Violation, opts *Options) *Log {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	run := Run{
+		Tool: Tool{Driver: Driver{
+			Name:           ToolName,
+			InformationURI: "https://deps.dev",
+			Rules:          []Rule{},
+		}},
+		Results: []Result{},
+	}
+	rules := make(map[string]bool)
+	for _, v := range vs {
+		if !rules[v.Advisory] {
+			rules[v.Advisory] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, Rule{
+				ID:               v.Advisory,
+				ShortDescription: Message{Text: cmp.Or(v.Title, v.Advisory)},
+				HelpURI:          "https://osv.dev/vulnerability/" + v.Advisory,
+				Properties: RuleProperties{
+					SecuritySeverity: fmt.Sprintf("%.1f", v.Score),
+					Tags:             []string{"security"},
+				},
+			})
+		}
+
+		direct := v.Name
+		msg := fmt.Sprintf("%s@%s is affected by %s", v.Name, v.Version, v.Advisory)
+		if v.Title != "" {
+			msg += ": " + v.Title
+		}
+		if !v.Direct {
+			if d, ok := o.Via[ViaKey(v.Name, v.Version)]; ok {
+				direct = d
+				msg += fmt.Sprintf(". It is an indirect dependency, brought in by %s", d)
+			} else {
+				direct = ""
+				msg += ". It is an indirect dependency"
+			}
+		}
+		msg += fmt.Sprintf(". Severity %v (CVSS v3 %.1f) breaks the policy rule %s.", v.Severity, v.Score, v.Rule)
+
+		loc := PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: o.Manifest}}
+		if direct != "" {
+			if line := FindLine(o.ManifestData, direct); line > 0 {
+				loc.Region = &Region{StartLine: line}
+			}
+		}
+		run.Results = append(run.Results, Result{
+			RuleID:    v.Advisory,
+			Level:     level(v.Severity),
+			Message:   Message{Text: msg},
+			Locations: []Location{{PhysicalLocation: loc}},
+			PartialFingerprints: map[string]string{
+				"dependency": fmt.Sprintf("%s/%s@%s", v.System, v.Name, v.Version),
+			},
+		})
+	}
+	return &Log{Schema: Schema, Version: Version, Runs: []Run{run}}
+}
+
+// level returns the SARIF level of a result of the given severity.
+func level(s advisories.Severity) string {
+	switch {
+	case s >= advisories.High:
+		return "error"
+	case s == advisories.Medium:
+		return "warning"
+	}
+	return "note"
+}
+
+// Write writes the log as indented JSON.
+func (l *Log) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
+
+// ViaKey returns the key of a dependency in the map returned by Via.
+func ViaKey(name, version string) string { return name + "@" + version }
+
+// Via returns, for each indirect dependency in a resolved dependency
+// graph, as returned by GetDependencies, the name of a direct dependency
+// through which it is used: the first on a shortest path from the root.
+// The map is keyed by ViaKey.
+func Via(g *pb.Dependencies) map[string]string {
+	nodes := g.GetNodes()
+	out := make([][]int, len(nodes))
+	for _, e := range g.GetEdges() {
+		if int(e.GetFromNode()) < len(nodes) && int(e.GetToNode()) < len(nodes) {
+			out[e.GetFromNode()] = append(out[e.GetFromNode()], int(e.GetToNode()))
+		}
+	}
+	via := make(map[string]string)
+	if len(nodes) == 0 {
+		return via
+	}
+	// direct holds, for each node reached, the index of the direct
+	// dependency through which it was first reached.
+	direct := make([]int, len(nodes))
+	for i := range direct {
+		direct[i] = -1
+	}
+	direct[0] = 0
+	var queue []int
+	for _, m := range out[0] {
+		if direct[m] < 0 {
+			direct[m] = m
+			queue = append(queue, m)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range out[n] {
+			if direct[m] >= 0 {
+				continue
+			}
+			direct[m] = direct[n]
+			queue = append(queue, m)
+			vk := nodes[m].GetVersionKey()
+			k := ViaKey(vk.GetName(), vk.GetVersion())
+			if _, ok := via[k]; !ok {
+				via[k] = nodes[direct[n]].GetVersionKey().GetName()
+			}
+		}
+	}
+	return via
+}
+
+// FindLine returns the number, from 1, of the first line of a manifest
+// that mentions the given package name as a whole word, or 0 if there is
+// none. This finds the declaration of a dependency in the common manifest
+// formats, such as package.json, go.mod, Cargo.toml, requirements.txt and
+// .csproj files. A Maven name, group:artifact, is also searched for by its
+// artifact ID, as a pom.xml declares the two on separate lines.
+func FindLine(data []byte, name string) int {
+	if name == "" {
+		return 0
+	}
+	if line := findWord(data, name); line > 0 {
+		return line
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return findWord(data, name[i+1:])
+	}
+	return 0
+}
+
+// findWord returns the number of the first line holding word, not
+// preceded or followed by a character that may be part of a package name.
+func findWord(data []byte, word string) int {
+	if word == "" {
+		return 0
+	}
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		s := string(line)
+		for off := 0; ; {
+			j := strings.Index(s[off:], word)
+			if j < 0 {
+				break
+			}
+			start, end := off+j, off+j+len(word)
+			if (start == 0 || !isNameChar(s[start-1])) && (end == len(s) || !isNameChar(s[end])) {
+				return i + 1
+			}
+			off = start + 1
+		}
+	}
+	return 0
+}
+
+// isNameChar reports whether c may be part of a package name.
+func isNameChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-_./@", c) >= 0
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/advisories"
+)
+
+const packageJSON = `{
+  "name": "app",
+  "dependencies": {
+    "express": "^4.18.0",
+    "lodash": "^4.17.0"
+  },
+  "devDependencies": {
+    "lodash.merge": "^4.6.0"
+  }
+}
+`
+
+func vk(name, version string) *pb.VersionKey {
+	return &pb.VersionKey{System: pb.System_NPM, Name: name, Version: version}
+}
+
+func TestNew(t *testing.T) {
+	g := &pb.Dependencies{
+		Nodes: []*pb.Dependencies_Node{
+			{VersionKey: vk("app", "1.0.0"), Relation: pb.DependencyRelation_SELF},
+			{VersionKey: vk("express", "4.18.2"), Relation: pb.DependencyRelation_DIRECT},
+			{VersionKey: vk("lodash", "4.17.20"), Relation: pb.DependencyRelation_DIRECT},
+			{VersionKey: vk("body-parser", "1.20.1"), Relation: pb.DependencyRelation_INDIRECT},
+			{VersionKey: vk("qs", "6.11.0"), Relation: pb.DependencyRelation_INDIRECT},
+		},
+		Edges: []*pb.Dependencies_Edge{
+			{FromNode: 0, ToNode: 1},
+			{FromNode: 0, ToNode: 2},
+			{FromNode: 1, ToNode: 3},
+			{FromNode: 3, ToNode: 4},
+			{FromNode: 2, ToNode: 4},
+		},
+	}
+	via := Via(g)
+	if want := map[string]string{"body-parser@1.20.1": "express", "qs@6.11.0": "lodash"}; !reflect.DeepEqual(via, want) {
+		t.Errorf("Via: got %v, want %v", via, want)
+	}
+
+	vs := []advisories.Violation{
+		{System: "NPM", Name: "lodash", Version: "4.17.20", Direct: true, Advisory: "GHSA-35jh-r3h4-6jhm", Title: "Command Injection in lodash", Score: 7.2, Severity: advisories.High, Rule: "HIGH"},
+		{System: "NPM", Name: "qs", Version: "6.11.0", Advisory: "GHSA-hrpp-h998-j3pp", Title: "qs vulnerable to Prototype Pollution", Score: 5.3, Severity: advisories.Medium, Rule: "MEDIUM"},
+		{System: "NPM", Name: "lodash", Version: "4.17.20", Direct: true, Advisory: "GHSA-29mw-wpgm-hmr9", Score: 3.7, Severity: advisories.Low, Rule: "LOW"},
+		{System: "NPM", Name: "unknown", Version: "1.0.0", Advisory: "GHSA-35jh-r3h4-6jhm", Title: "Command Injection in lodash", Score: 7.2, Severity: advisories.High, Rule: "HIGH"},
+	}
+	log := New(vs, &Options{Manifest: "web/package.json", ManifestData: []byte(packageJSON), Via: via})
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("got version %q and %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	var rules []string
+	for _, r := range run.Tool.Driver.Rules {
+		rules = append(rules, r.ID+" "+r.Properties.SecuritySeverity)
+	}
+	if want := []string{"GHSA-35jh-r3h4-6jhm 7.2", "GHSA-hrpp-h998-j3pp 5.3", "GHSA-29mw-wpgm-hmr9 3.7"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules: got %q, want %q", rules, want)
+	}
+
+	type summary struct {
+		Rule, Level string
+		Line        int
+	}
+	var got []summary
+	for _, r := range run.Results {
+		s := summary{Rule: r.RuleID, Level: r.Level}
+		loc := r.Locations[0].PhysicalLocation
+		if loc.ArtifactLocation.URI != "web/package.json" {
+			t.Errorf("%s: got uri %q", r.RuleID, loc.ArtifactLocation.URI)
+		}
+		if loc.Region != nil {
+			s.Line = loc.Region.StartLine
+		}
+		got = append(got, s)
+	}
+	want := []summary{
+		// lodash is declared on line 5, not to be confused with
+		// lodash.merge.
+		{"GHSA-35jh-r3h4-6jhm", "error", 5},
+		// qs is brought in most directly by lodash, rather than
+		// through express and body-parser.
+		{"GHSA-hrpp-h998-j3pp", "warning", 5},
+		{"GHSA-29mw-wpgm-hmr9", "note", 5},
+		// No direct dependency is known to bring in unknown.
+		{"GHSA-35jh-r3h4-6jhm", "error", 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results:\ngot  %v\nwant %v", got, want)
+	}
+	if msg := run.Results[1].Message.Text; !strings.Contains(msg, "brought in by lodash") {
+		t.Errorf("message of indirect result: %q", msg)
+	}
+
+	var buf bytes.Buffer
+	if err := log.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["$schema"] != Schema {
+		t.Errorf("$schema: got %v", decoded["$schema"])
+	}
+}
+
+func TestFindLine(t *testing.T) {
+	for _, tc := range []struct {
+		data, name string
+		want       int
+	}{
+		{"module a\n\nrequire (\n\tgolang.org/x/mod v0.22.0\n\tgolang.org/x/net v0.30.0\n)\n", "golang.org/x/net", 5},
+		{"[dependencies]\nserde_json = \"1\"\nserde = \"1\"\n", "serde", 3},
+		{"flask==2.0\nrequests[socks]==2.31.0\n", "requests", 2},
+		{"<dependency>\n  <groupId>org.example</groupId>\n  <artifactId>lib</artifactId>\n</dependency>\n", "org.example:lib", 3},
+		{`<PackageReference Include="Newtonsoft.Json" Version="13.0.1" />`, "Newtonsoft.Json", 1},
+		{`{"dependencies": {"@scope/pkg": "1"}}`, "pkg", 0},
+		{"", "anything", 0},
+	} {
+		if got := FindLine([]byte(tc.data), tc.name); got != tc.want {
+			t.Errorf("FindLine(%q, %q) = %d, want %d", tc.data, tc.name, got, tc.want)
+		}
+	}
+}
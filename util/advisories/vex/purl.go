@@ -0,0 +1,27 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vex
+
+import (
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/resolve/purl"
+)
+
+// PackageURL returns the package URL of the given version, or an empty
+// string if its system has no package URL type, as formatted by
+// purl.Format.
+func PackageURL(vk *pb.VersionKey) string {
+	return purl.Format(vk.GetSystem().String(), vk.GetName(), vk.GetVersion())
+}
@@ -0,0 +1,260 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package vex writes OpenVEX documents recording how the advisories affecting
+the dependencies of a package version have been triaged, so that a team
+that has assessed an advisory as not affecting its software can publish
+that assessment in a machine-readable form.
+
+Generate fetches the advisories affecting the nodes of a resolved
+dependency graph from the deps.dev API, and writes a statement for each
+advisory that a Config triages. The product of each statement is the root
+of the graph, and its subcomponents are the package URLs of the affected
+nodes. The document format is specified at
+https://github.com/openvex/spec/blob/main/OPENVEX-SPEC.md
+*/
+package vex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/advisories"
+)
+
+const (
+	// Context is the JSON-LD context of OpenVEX v0.2.0 documents.
+	Context = "https://openvex.dev/ns/v0.2.0"
+	// Tooling names this package as the tool that wrote a document.
+	Tooling = "deps.dev/util/advisories/vex"
+)
+
+// Status is the status of a product with respect to a vulnerability.
+type Status string
+
+const (
+	NotAffected        Status = "not_affected"
+	Affected           Status = "affected"
+	Fixed              Status = "fixed"
+	UnderInvestigation Status = "under_investigation"
+)
+
+// Justification is the reason a product is not affected by a
+// vulnerability.
+type Justification string
+
+const (
+	ComponentNotPresent                         Justification = "component_not_present"
+	VulnerableCodeNotPresent                    Justification = "vulnerable_code_not_present"
+	VulnerableCodeNotInExecutePath              Justification = "vulnerable_code_not_in_execute_path"
+	VulnerableCodeCannotBeControlledByAdversary Justification = "vulnerable_code_cannot_be_controlled_by_adversary"
+	InlineMitigationsAlreadyExist               Justification = "inline_mitigations_already_exist"
+)
+
+// Document is an OpenVEX document.
+type Document struct {
+	Context    string      `json:"@context"`
+	ID         string      `json:"@id"`
+	Author     string      `json:"author"`
+	Timestamp  time.Time   `json:"timestamp"`
+	Version    int         `json:"version"`
+	Tooling    string      `json:"tooling,omitempty"`
+	Statements []Statement `json:"statements"`
+}
+
+// Statement records the status of products with respect to a
+// vulnerability.
+type Statement struct {
+	Vulnerability   Vulnerability `json:"vulnerability"`
+	Products        []Product     `json:"products"`
+	Status          Status        `json:"status"`
+	Justification   Justification `json:"justification,omitempty"`
+	ImpactStatement string        `json:"impact_statement,omitempty"`
+	ActionStatement string        `json:"action_statement,omitempty"`
+}
+
+// Vulnerability identifies a vulnerability.
+type Vulnerability struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Product identifies a product or component by its package URL.
+type Product struct {
+	ID            string    `json:"@id"`
+	Subcomponents []Product `json:"subcomponents,omitempty"`
+}
+
+// Triage is the assessment of an advisory.
+type Triage struct {
+	// Advisory is the ID of the advisory, as in its deps.dev AdvisoryKey.
+	Advisory string `json:"advisory"`
+	// Status is the status of the product with respect to the advisory.
+	// If empty, it is NotAffected.
+	Status Status `json:"status,omitempty"`
+	// Justification is required, unless ImpactStatement is given, if the
+	// status is NotAffected.
+	Justification   Justification `json:"justification,omitempty"`
+	ImpactStatement string        `json:"impact_statement,omitempty"`
+	// ActionStatement is required if the status is Affected.
+	ActionStatement string `json:"action_statement,omitempty"`
+}
+
+// Config configures the generation of a Document. It may be read from JSON.
+type Config struct {
+	// Author is the person or organization responsible for the document.
+	// It is required.
+	Author string `json:"author"`
+	// Triage holds the assessment of each triaged advisory.
+	Triage []Triage `json:"triage"`
+	// IncludeUntriaged includes the advisories that are not triaged, with
+	// the status UnderInvestigation. Otherwise they are left out of the
+	// document.
+	IncludeUntriaged bool `json:"include_untriaged,omitempty"`
+	// Timestamp is the time the document is issued. If zero, the current
+	// time is used.
+	Timestamp time.Time `json:"timestamp,omitempty"`
+}
+
+// check reports whether a triage has the fields its status requires.
+func (t *Triage) check() error {
+	switch t.Status {
+	case "", NotAffected:
+		if t.Justification == "" && t.ImpactStatement == "" {
+			return fmt.Errorf("advisory %s: not_affected needs a justification or impact statement", t.Advisory)
+		}
+	case Affected:
+		if t.ActionStatement == "" {
+			return fmt.Errorf("advisory %s: affected needs an action statement", t.Advisory)
+		}
+	case Fixed, UnderInvestigation:
+	default:
+		return fmt.Errorf("advisory %s: unknown status %q", t.Advisory, t.Status)
+	}
+	return nil
+}
+
+// Generate fetches the advisories affecting the dependencies in the given
+// resolved graph, as returned by GetDependencies, and returns a document
+// holding a statement for each of them that cfg triages.
+func Generate(ctx context.Context, c pb.InsightsClient, g *pb.Dependencies, cfg *Config) (*Document, error) {
+	var root *pb.VersionKey
+	for _, n := range g.GetNodes() {
+		if n.GetRelation() == pb.DependencyRelation_SELF {
+			root = n.GetVersionKey()
+			break
+		}
+	}
+	if root == nil {
+		return nil, errors.New("graph has no root node")
+	}
+	r, err := advisories.Fetch(ctx, c, advisories.FromGraph(g))
+	if err != nil {
+		return nil, err
+	}
+	return New(root, r, cfg)
+}
+
+// New returns a document holding a statement for each advisory in the
+// report that cfg triages, in the order the advisories are first found in
+// the report. The product of each statement is root, with the dependencies
+// the advisory affects as its subcomponents.
+func New(root *pb.VersionKey, r *advisories.Report, cfg *Config) (*Document, error) {
+	if cfg.Author == "" {
+		return nil, errors.New("missing author")
+	}
+	rootURL := PackageURL(root)
+	if rootURL == "" {
+		return nil, fmt.Errorf("no package URL for %v", root)
+	}
+	triage := make(map[string]*Triage)
+	for i := range cfg.Triage {
+		t := &cfg.Triage[i]
+		if err := t.check(); err != nil {
+			return nil, err
+		}
+		triage[t.Advisory] = t
+	}
+
+	// Gather the dependencies affected by each advisory.
+	var (
+		order    []*pb.Advisory
+		affected = make(map[string][]Product)
+		seen     = make(map[string]map[string]bool)
+	)
+	for _, res := range r.Results {
+		purl := PackageURL(res.VersionKey)
+		if purl == "" {
+			continue
+		}
+		for _, a := range res.Advisories {
+			id := a.GetAdvisoryKey().GetId()
+			if seen[id] == nil {
+				seen[id] = make(map[string]bool)
+				order = append(order, a)
+			}
+			if !seen[id][purl] {
+				seen[id][purl] = true
+				affected[id] = append(affected[id], Product{ID: purl})
+			}
+		}
+	}
+
+	doc := &Document{
+		Context:    Context,
+		Author:     cfg.Author,
+		Timestamp:  cfg.Timestamp,
+		Version:    1,
+		Tooling:    Tooling,
+		Statements: []Statement{},
+	}
+	if doc.Timestamp.IsZero() {
+		doc.Timestamp = time.Now().UTC().Truncate(time.Second)
+	}
+	for _, a := range order {
+		id := a.GetAdvisoryKey().GetId()
+		s := Statement{
+			Vulnerability: Vulnerability{Name: id, Aliases: a.GetAliases()},
+			Products:      []Product{{ID: rootURL, Subcomponents: affected[id]}},
+		}
+		if t, ok := triage[id]; ok {
+			s.Status = t.Status
+			if s.Status == "" {
+				s.Status = NotAffected
+			}
+			s.Justification = t.Justification
+			s.ImpactStatement = t.ImpactStatement
+			s.ActionStatement = t.ActionStatement
+		} else if cfg.IncludeUntriaged {
+			s.Status = UnderInvestigation
+		} else {
+			continue
+		}
+		doc.Statements = append(doc.Statements, s)
+	}
+
+	// The document is identified by the hash of its contents.
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	doc.ID = fmt.Sprintf("https://openvex.dev/docs/public/vex-%x", sha256.Sum256(data))
+	return doc, nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vex
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/advisories"
+)
+
+func TestPackageURL(t *testing.T) {
+	for _, tc := range []struct {
+		system        pb.System
+		name, version string
+		want          string
+	}{
+		{pb.System_NPM, "left-pad", "1.3.0", "pkg:npm/left-pad@1.3.0"},
+		{pb.System_NPM, "@colors/colors", "1.5.0", "pkg:npm/%40colors/colors@1.5.0"},
+		{pb.System_MAVEN, "org.apache.logging.log4j:log4j-core", "2.17.1", "pkg:maven/org.apache.logging.log4j/log4j-core@2.17.1"},
+		{pb.System_MAVEN, "no-group", "1.0", ""},
+		{pb.System_GO, "golang.org/x/net", "v0.17.0", "pkg:golang/golang.org/x/net@v0.17.0"},
+		{pb.System_PYPI, "Django_Rest", "3.0", "pkg:pypi/django-rest@3.0"},
+		{pb.System_CARGO, "semver", "1.0.0+build", "pkg:cargo/semver@1.0.0+build"},
+		{pb.System_NUGET, "Newtonsoft.Json", "13.0.3", "pkg:nuget/Newtonsoft.Json@13.0.3"},
+		{pb.System_SYSTEM_UNSPECIFIED, "x", "1", ""},
+	} {
+		vk := &pb.VersionKey{System: tc.system, Name: tc.name, Version: tc.version}
+		if got := PackageURL(vk); got != tc.want {
+			t.Errorf("PackageURL(%v): got %q, want %q", vk, got, tc.want)
+		}
+	}
+}
+
+func vk(name, version string) *pb.VersionKey {
+	return &pb.VersionKey{System: pb.System_NPM, Name: name, Version: version}
+}
+
+func advisory(id string, aliases ...string) *pb.Advisory {
+	return &pb.Advisory{AdvisoryKey: &pb.AdvisoryKey{Id: id}, Aliases: aliases}
+}
+
+func TestNew(t *testing.T) {
+	a1, a2 := advisory("GHSA-1", "CVE-1"), advisory("GHSA-2")
+	r := &advisories.Report{Results: []*advisories.Result{
+		{Dependency: advisories.Dependency{VersionKey: vk("a", "1.0.0"), Direct: true}, Found: true, Advisories: []*pb.Advisory{a1, a2}},
+		{Dependency: advisories.Dependency{VersionKey: vk("b", "2.0.0")}, Found: true, Advisories: []*pb.Advisory{a1}},
+	}}
+	cfg := &Config{
+		Author: "Example Security Team",
+		Triage: []Triage{{
+			Advisory:      "GHSA-1",
+			Justification: VulnerableCodeNotInExecutePath,
+		}},
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	doc, err := New(vk("root", "1.0.0"), r, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.ID, "https://openvex.dev/docs/public/vex-") {
+		t.Errorf("got ID %q", doc.ID)
+	}
+	doc.ID = "ID"
+	got, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "@id": "ID",
+  "author": "Example Security Team",
+  "timestamp": "2024-01-02T03:04:05Z",
+  "version": 1,
+  "tooling": "deps.dev/util/advisories/vex",
+  "statements": [
+    {
+      "vulnerability": {
+        "name": "GHSA-1",
+        "aliases": [
+          "CVE-1"
+        ]
+      },
+      "products": [
+        {
+          "@id": "pkg:npm/root@1.0.0",
+          "subcomponents": [
+            {
+              "@id": "pkg:npm/a@1.0.0"
+            },
+            {
+              "@id": "pkg:npm/b@2.0.0"
+            }
+          ]
+        }
+      ],
+      "status": "not_affected",
+      "justification": "vulnerable_code_not_in_execute_path"
+    }
+  ]
+}`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	cfg.IncludeUntriaged = true
+	doc, err = New(vk("root", "1.0.0"), r, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Statements) != 2 || doc.Statements[1].Status != UnderInvestigation {
+		t.Errorf("with untriaged advisories: got statements %+v", doc.Statements)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	r := &advisories.Report{}
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+	}{
+		{"no author", Config{}},
+		{"no justification", Config{Author: "a", Triage: []Triage{{Advisory: "GHSA-1"}}}},
+		{"no action", Config{Author: "a", Triage: []Triage{{Advisory: "GHSA-1", Status: Affected}}}},
+		{"bad status", Config{Author: "a", Triage: []Triage{{Advisory: "GHSA-1", Status: "ignored"}}}},
+	} {
+		if _, err := New(vk("root", "1.0.0"), r, &tc.cfg); err == nil {
+			t.Errorf("%s: succeeded, want error", tc.name)
+		}
+	}
+}
+
+type fakeClient struct {
+	pb.InsightsClient
+}
+
+func (fakeClient) GetVersionBatch(ctx context.Context, req *pb.GetVersionBatchRequest, opts ...grpc.CallOption) (*pb.VersionBatch, error) {
+	resp := &pb.VersionBatch{}
+	for _, r := range req.Requests {
+		v := &pb.Version{VersionKey: r.GetVersionKey()}
+		if r.GetVersionKey().GetName() == "a" {
+			v.AdvisoryKeys = []*pb.AdvisoryKey{{Id: "GHSA-1"}}
+		}
+		resp.Responses = append(resp.Responses, &pb.VersionBatch_Response{Request: r, Version: v})
+	}
+	return resp, nil
+}
+
+func (fakeClient) GetAdvisory(ctx context.Context, req *pb.GetAdvisoryRequest, opts ...grpc.CallOption) (*pb.Advisory, error) {
+	return &pb.Advisory{AdvisoryKey: req.GetAdvisoryKey()}, nil
+}
+
+func TestGenerate(t *testing.T) {
+	g := &pb.Dependencies{Nodes: []*pb.Dependencies_Node{
+		{VersionKey: vk("root", "1.0.0"), Relation: pb.DependencyRelation_SELF},
+		{VersionKey: vk("a", "1.0.0"), Relation: pb.DependencyRelation_DIRECT},
+		{VersionKey: vk("b", "1.0.0"), Relation: pb.DependencyRelation_INDIRECT},
+	}}
+	cfg := &Config{
+		Author: "a",
+		Triage: []Triage{{Advisory: "GHSA-1", ImpactStatement: "Only used in tests."}},
+	}
+	doc, err := Generate(context.Background(), fakeClient{}, g, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Statements) != 1 {
+		t.Fatalf("got %d statements, want 1", len(doc.Statements))
+	}
+	s := doc.Statements[0]
+	if s.Products[0].ID != "pkg:npm/root@1.0.0" || len(s.Products[0].Subcomponents) != 1 || s.Products[0].Subcomponents[0].ID != "pkg:npm/a@1.0.0" {
+		t.Errorf("got products %+v", s.Products)
+	}
+	if s.ImpactStatement != "Only used in tests." || s.Status != NotAffected {
+		t.Errorf("got statement %+v", s)
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package batch fetches large numbers of package versions from the deps.dev
+API with GetVersionBatch, in a job that can be interrupted and resumed.
+
+A Runner splits the versions into batches and follows the page tokens of
+each batch. After each page it records its progress in a checkpoint file,
+so that a job interrupted by a failure, a signal or a crash resumes where it
+stopped when run again with the same versions. Limiting the rate of
+requests and retrying those that fail with transient errors are left to
+the connection of the client, which may use the interceptors of
+deps.dev/api/clientutil:
+
+	throttle := clientutil.NewThrottle(&clientutil.ThrottleOptions{RPS: 10})
+	conn, err := grpc.NewClient("api.deps.dev:443",
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(
+			clientutil.UnaryRetryInterceptor(nil),
+			throttle.UnaryClientInterceptor(),
+		),
+	)
+	// ...
+	r := batch.NewRunner(pb.NewInsightsClient(conn), &batch.Options{Checkpoint: "job.json"})
+	err := r.Run(ctx, versionKeys, func(resp *pb.VersionBatch_Response) error {
+		// Store resp.GetVersion(), which is nil if the version was not found.
+		return nil
+	})
+
+The checkpoint records the versions it applies to, and Run fails with
+ErrCheckpointMismatch if given different ones. Once a job is complete,
+running it again does nothing; remove the checkpoint file to start over.
+
+LookupVersions runs such a job and returns the versions found, for
+callers that would rather have them all at once.
+
+For callers that would rather pull results than be called with them, the
+Versions, Projects and Purls methods return iterators over the results of
+GetVersionBatch, GetProjectBatch and PurlLookupBatch, one per request:
+
+	it := r.Purls(purls)
+	for {
+		res, err := it.Next(ctx)
+		if errors.Is(err, batch.Done) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch res.Status {
+		case batch.Found:
+			// Use res.Value.
+		case batch.NotFound:
+			// The purl names no known package or version.
+		case batch.Failed:
+			// The purl's batch could not be fetched: see res.Err.
+		}
+	}
+
+LookupPurls does the same for a list of package URLs of any size, checking
+and deduplicating them first and returning the results in the order given.
+*/
+package batch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// MaxBatch is the maximum number of requests in a GetVersionBatch call.
+const MaxBatch = 5000
+
+// ErrCheckpointMismatch is returned, wrapped, by Run when the checkpoint
+// file records the progress of a job with other versions or batch size.
+var ErrCheckpointMismatch = errors.New("checkpoint does not match the job")
+
+// Options configure a Runner. The zero value of each field selects its
+// default.
+type Options struct {
+	// Checkpoint is the name of the file recording the progress of the
+	// job. The default is to keep no record, so that an interrupted job
+	// starts over.
+	Checkpoint string
+	// BatchSize is the number of versions requested in each batch, at
+	// most MaxBatch. The default is MaxBatch.
+	BatchSize int
+	// OnProgress, if set, is called after each page with the progress of
+	// the job.
+	OnProgress func(Progress)
+}
+
+// Progress holds the metrics of a job.
+type Progress struct {
+	// Total is the number of versions of the job, and Done the number
+	// whose responses have been handled, including in earlier runs.
+	Total, Done int
+	// Resumed is the number of versions done by earlier runs.
+	Resumed int
+	// Pages is the number of pages fetched by this run.
+	Pages int
+	// Elapsed is the duration of this run so far.
+	Elapsed time.Duration
+}
+
+// Runner runs batch jobs. It is safe for concurrent use, but jobs sharing a
+// checkpoint file must not run at the same time.
+type Runner struct {
+	c    pb.InsightsClient
+	opts Options
+
+	mu       sync.Mutex
+	progress Progress
+}
+
+// NewRunner returns a Runner making its calls with the given client, whose
+// connection should retry and rate limit them as needed. If opts is nil, the
+// defaults are used.
+func NewRunner(c pb.InsightsClient, opts *Options) *Runner {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 || o.BatchSize > MaxBatch {
+		o.BatchSize = MaxBatch
+	}
+	return &Runner{c: c, opts: o}
+}
+
+// Progress returns the progress of the current or last job.
+func (r *Runner) Progress() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.progress
+}
+
+// checkpoint is the content of a checkpoint file.
+type checkpoint struct {
+	// Job identifies the versions and batch size of the job.
+	Job string `json:"job"`
+	// Done is the number of versions whose responses have been handled,
+	// up to the batch being fetched.
+	Done int `json:"done"`
+	// PageToken is the token of the next page of the batch starting at
+	// Done, if some of its pages have been handled.
+	PageToken string `json:"pageToken,omitempty"`
+}
+
+// Run fetches the given versions, calling handle with each response. A
+// response with no version means the version was not found. If handle
+// returns an error, Run stops and returns it.
+//
+// Progress is recorded after all the responses of a page are handled, so
+// if the job is interrupted during a page, the responses of that page are
+// handled again when the job resumes.
+func (r *Runner) Run(ctx context.Context, vks []*pb.VersionKey, handle func(*pb.VersionBatch_Response) error) error {
+	start := time.Now()
+	cp := checkpoint{Job: r.jobID(vks)}
+	if r.opts.Checkpoint != "" {
+		saved, err := readCheckpoint(r.opts.Checkpoint)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return err
+		case saved.Job != cp.Job || saved.Done > len(vks):
+			return fmt.Errorf("%s: %w", r.opts.Checkpoint, ErrCheckpointMismatch)
+		default:
+			cp = saved
+		}
+	}
+	r.update(func(p *Progress) {
+		*p = Progress{Total: len(vks), Done: cp.Done, Resumed: cp.Done}
+	})
+
+	for cp.Done < len(vks) {
+		n := min(len(vks)-cp.Done, r.opts.BatchSize)
+		req := &pb.GetVersionBatchRequest{PageToken: cp.PageToken}
+		for _, vk := range vks[cp.Done : cp.Done+n] {
+			req.Requests = append(req.Requests, &pb.GetVersionRequest{VersionKey: vk})
+		}
+		for {
+			b, err := r.call(ctx, req)
+			if err != nil {
+				return err
+			}
+			for _, resp := range b.GetResponses() {
+				if err := handle(resp); err != nil {
+					return err
+				}
+			}
+			if b.GetNextPageToken() == "" {
+				cp.Done, cp.PageToken = cp.Done+n, ""
+			} else {
+				cp.PageToken = b.GetNextPageToken()
+			}
+			if err := r.save(cp); err != nil {
+				return err
+			}
+			done := cp.Done
+			if cp.PageToken != "" {
+				// The batch is partly done; count the responses so far.
+				done = r.Progress().Done + len(b.GetResponses())
+			}
+			r.update(func(p *Progress) {
+				p.Done = done
+				p.Pages++
+				p.Elapsed = time.Since(start)
+			})
+			if r.opts.OnProgress != nil {
+				r.opts.OnProgress(r.Progress())
+			}
+			if cp.PageToken == "" {
+				break
+			}
+			req.PageToken = cp.PageToken
+		}
+	}
+	return nil
+}
+
+// VersionKey is a comparable form of a pb.VersionKey, for use as a map key.
+type VersionKey struct {
+	System        pb.System
+	Name, Version string
+}
+
+// KeyOf returns the comparable form of vk.
+func KeyOf(vk *pb.VersionKey) VersionKey {
+	return VersionKey{vk.GetSystem(), vk.GetName(), vk.GetVersion()}
+}
+
+// LookupVersions fetches the given versions as Run does, returning those
+// that are found keyed by KeyOf their version key.
+func (r *Runner) LookupVersions(ctx context.Context, vks []*pb.VersionKey) (map[VersionKey]*pb.Version, error) {
+	found := make(map[VersionKey]*pb.Version)
+	err := r.Run(ctx, vks, func(resp *pb.VersionBatch_Response) error {
+		if v := resp.GetVersion(); v != nil {
+			found[KeyOf(resp.GetRequest().GetVersionKey())] = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// jobID returns a digest of the versions of a job and its batch size, to
+// which page tokens and the progress of a checkpoint are tied.
+func (r *Runner) jobID(vks []*pb.VersionKey) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n", r.opts.BatchSize)
+	for _, vk := range vks {
+		fmt.Fprintf(h, "%d %q %q\n", vk.GetSystem(), vk.GetName(), vk.GetVersion())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (r *Runner) update(f func(*Progress)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f(&r.progress)
+}
+
+// call makes a GetVersionBatch request.
+func (r *Runner) call(ctx context.Context, req *pb.GetVersionBatchRequest) (*pb.VersionBatch, error) {
+	return call(ctx, "fetching versions", func(ctx context.Context) (*pb.VersionBatch, error) {
+		return r.c.GetVersionBatch(ctx, req)
+	})
+}
+
+// call makes a request with f, unless the context is done, wrapping its
+// error with the given description of the request.
+func call[T any](ctx context.Context, what string, f func(context.Context) (T, error)) (T, error) {
+	if err := ctx.Err(); err != nil {
+		var zero T
+		return zero, err
+	}
+	resp, err := f(ctx)
+	if err != nil {
+		return resp, fmt.Errorf("%s: %w", what, err)
+	}
+	return resp, nil
+}
+
+func readCheckpoint(name string) (checkpoint, error) {
+	var cp checkpoint
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return cp, err
+	}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return cp, fmt.Errorf("parsing checkpoint %s: %w", name, err)
+	}
+	return cp, nil
+}
+
+// save records the progress of the job, if it has a checkpoint file. The
+// file is replaced atomically, so that an interruption leaves either the
+// old or the new checkpoint.
+func (r *Runner) save(cp checkpoint) error {
+	if r.opts.Checkpoint == "" {
+		return nil
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(r.opts.Checkpoint), filepath.Base(r.opts.Checkpoint)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("saving checkpoint: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("saving checkpoint: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("saving checkpoint: %w", err)
+	}
+	if err := os.Rename(f.Name(), r.opts.Checkpoint); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("saving checkpoint: %w", err)
+	}
+	return nil
+}
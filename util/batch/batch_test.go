@@ -0,0 +1,232 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"deps.dev/api/clientutil"
+	pb "deps.dev/api/v3alpha"
+)
+
+// fakeClient returns batches two responses per page, finding the versions
+// whose name does not start with "missing". Calls listed in errs fail with
+// the given error.
+type fakeClient struct {
+	pb.InsightsClient
+	calls int
+	errs  map[int]error
+}
+
+func (c *fakeClient) GetVersionBatch(ctx context.Context, req *pb.GetVersionBatchRequest, opts ...grpc.CallOption) (*pb.VersionBatch, error) {
+	c.calls++
+	if err := c.errs[c.calls]; err != nil {
+		return nil, err
+	}
+	i := 0
+	if req.PageToken != "" {
+		i, _ = strconv.Atoi(req.PageToken)
+	}
+	b := &pb.VersionBatch{}
+	for _, r := range req.Requests[i:min(i+2, len(req.Requests))] {
+		resp := &pb.VersionBatch_Response{Request: r}
+		if name := r.GetVersionKey().GetName(); len(name) < 7 || name[:7] != "missing" {
+			resp.Version = &pb.Version{VersionKey: r.GetVersionKey()}
+		}
+		b.Responses = append(b.Responses, resp)
+	}
+	if i+2 < len(req.Requests) {
+		b.NextPageToken = strconv.Itoa(i + 2)
+	}
+	return b, nil
+}
+
+func versionKeys(n int) []*pb.VersionKey {
+	var vks []*pb.VersionKey
+	for i := 0; i < n; i++ {
+		vks = append(vks, &pb.VersionKey{System: pb.System_NPM, Name: fmt.Sprintf("pkg%d", i), Version: "1.0.0"})
+	}
+	return vks
+}
+
+// names returns a handler recording the names of the versions found.
+func names(got *[]string) func(*pb.VersionBatch_Response) error {
+	return func(resp *pb.VersionBatch_Response) error {
+		if resp.GetVersion() != nil {
+			*got = append(*got, resp.GetVersion().GetVersionKey().GetName())
+		}
+		return nil
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	vks := versionKeys(7)
+	cp := filepath.Join(t.TempDir(), "job.json")
+	errBoom := status.Error(codes.Internal, "boom")
+	// Batches of 3 give pages [0 1] [2] [3 4] [5] [6]; the fourth call
+	// fails with a permanent error.
+	c := &fakeClient{errs: map[int]error{4: errBoom}}
+	opts := &Options{Checkpoint: cp, BatchSize: 3}
+
+	var got []string
+	r := NewRunner(c, opts)
+	if err := r.Run(context.Background(), vks, names(&got)); !errors.Is(err, errBoom) {
+		t.Fatalf("Run: got error %v, want %v", err, errBoom)
+	}
+	if want := []string{"pkg0", "pkg1", "pkg2", "pkg3", "pkg4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first run: got %v, want %v", got, want)
+	}
+
+	// The second run resumes at the second page of the second batch.
+	var progress []int
+	opts.OnProgress = func(p Progress) { progress = append(progress, p.Done) }
+	got = nil
+	r = NewRunner(c, opts)
+	if err := r.Run(context.Background(), vks, names(&got)); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if want := []string{"pkg5", "pkg6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second run: got %v, want %v", got, want)
+	}
+	if want := []int{6, 7}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("second run progress: got %v, want %v", progress, want)
+	}
+	p := r.Progress()
+	if p.Total != 7 || p.Done != 7 || p.Resumed != 3 || p.Pages != 2 {
+		t.Errorf("Progress: got %+v", p)
+	}
+
+	// A complete job does nothing.
+	got = nil
+	calls := c.calls
+	if err := NewRunner(c, opts).Run(context.Background(), vks, names(&got)); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(got) != 0 || c.calls != calls {
+		t.Errorf("complete job: handled %v in %d calls", got, c.calls-calls)
+	}
+
+	// Other versions do not match the checkpoint.
+	if err := NewRunner(c, opts).Run(context.Background(), vks[1:], names(&got)); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("Run with other versions: got error %v, want %v", err, ErrCheckpointMismatch)
+	}
+}
+
+// fakeServer serves the batches of a fakeClient.
+type fakeServer struct {
+	pb.UnimplementedInsightsServer
+	c *fakeClient
+}
+
+func (s fakeServer) GetVersionBatch(ctx context.Context, req *pb.GetVersionBatchRequest) (*pb.VersionBatch, error) {
+	return s.c.GetVersionBatch(ctx, req)
+}
+
+// serve serves c on an in-memory connection for the duration of the test,
+// and returns a client for it whose connection uses the given interceptors.
+func serve(t *testing.T, c *fakeClient, interceptors ...grpc.UnaryClientInterceptor) pb.InsightsClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterInsightsServer(srv, fakeServer{c: c})
+	go srv.Serve(lis)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+	)
+	if err != nil {
+		srv.Stop()
+		t.Fatalf("connecting to test server: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		srv.Stop()
+	})
+	return pb.NewInsightsClient(conn)
+}
+
+func TestRunRetry(t *testing.T) {
+	c := &fakeClient{errs: map[int]error{
+		1: status.Error(codes.Unavailable, "unavailable"),
+		2: status.Error(codes.ResourceExhausted, "quota"),
+	}}
+	vks := append(versionKeys(3), &pb.VersionKey{System: pb.System_NPM, Name: "missing", Version: "1.0.0"})
+	retry := clientutil.UnaryRetryInterceptor(&clientutil.RetryOptions{InitialBackoff: time.Millisecond})
+	r := NewRunner(serve(t, c, retry), nil)
+	var got []string
+	handled := 0
+	err := r.Run(context.Background(), vks, func(resp *pb.VersionBatch_Response) error {
+		handled++
+		return names(&got)(resp)
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if want := []string{"pkg0", "pkg1", "pkg2"}; !reflect.DeepEqual(got, want) || handled != 4 {
+		t.Errorf("Run: found %v in %d responses, want %v in 4", got, handled, want)
+	}
+	if p := r.Progress(); c.calls != 4 || p.Pages != 2 || p.Done != 4 {
+		t.Errorf("Progress: got %+v in %d calls, want 2 pages in 4 calls", p, c.calls)
+	}
+
+	// The Runner itself does not retry.
+	c = &fakeClient{errs: map[int]error{1: status.Error(codes.Unavailable, "unavailable")}}
+	r = NewRunner(c, nil)
+	if err := r.Run(context.Background(), vks, names(&got)); status.Code(errors.Unwrap(err)) != codes.Unavailable {
+		t.Errorf("Run without retries: got error %v, want Unavailable", err)
+	}
+}
+
+func TestLookupVersions(t *testing.T) {
+	vks := append(versionKeys(3), &pb.VersionKey{System: pb.System_NPM, Name: "missing", Version: "1.0.0"})
+	found, err := NewRunner(&fakeClient{}, &Options{BatchSize: 3}).LookupVersions(context.Background(), vks)
+	if err != nil {
+		t.Fatalf("LookupVersions: %v", err)
+	}
+	if len(found) != 3 {
+		t.Errorf("LookupVersions: found %d versions, want 3", len(found))
+	}
+	for _, vk := range vks[:3] {
+		if v := found[KeyOf(vk)]; v.GetVersionKey().GetName() != vk.GetName() {
+			t.Errorf("LookupVersions: got %v for %v", v, vk)
+		}
+	}
+}
+
+func TestRunHandlerError(t *testing.T) {
+	errStop := errors.New("stop")
+	r := NewRunner(&fakeClient{}, nil)
+	err := r.Run(context.Background(), versionKeys(3), func(*pb.VersionBatch_Response) error { return errStop })
+	if !errors.Is(err, errStop) {
+		t.Errorf("Run: got error %v, want %v", err, errStop)
+	}
+}
@@ -0,0 +1,24 @@
+module deps.dev/util/batch
+
+go 1.23.4
+
+replace (
+	deps.dev/api/clientutil => ../../api/clientutil
+	deps.dev/api/v3alpha => ../../api/v3alpha
+	deps.dev/util/cache => ../cache
+)
+
+require (
+	deps.dev/api/clientutil v0.0.0-00010101000000-000000000000
+	deps.dev/api/v3alpha v0.0.0-20240701033337-efe6530670b9
+	google.golang.org/grpc v1.69.4
+)
+
+require (
+	deps.dev/util/cache v0.0.0-00010101000000-000000000000 // indirect
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
+	google.golang.org/protobuf v1.36.2 // indirect
+)
@@ -0,0 +1,158 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// ErrInvalidPurl is the error, wrapped in a RequestError, of a result whose
+// package URL could not be parsed.
+var ErrInvalidPurl = errors.New("invalid package URL")
+
+// LookupPurls looks up any number of package URLs with PurlLookupBatch,
+// returning a result for each, in the order given. The package URLs are
+// first brought to a canonical form, with a lowercase type and consistently
+// percent-encoded components, and those naming the same package or version
+// are looked up once. The batches are split and paged as by Purls. A package
+// URL that cannot be parsed is given a Failed result with the code
+// InvalidArgument, wrapping ErrInvalidPurl. The error is not nil only if ctx
+// is done.
+func (r *Runner) LookupPurls(ctx context.Context, purls []string) ([]Result[string, *pb.PurlLookupResult], error) {
+	results := make([]Result[string, *pb.PurlLookupResult], len(purls))
+	var unique []string
+	index := make(map[string]int) // Index in unique of each canonical purl.
+	of := make([]int, len(purls)) // Index in unique of each purl, or -1.
+	for i, p := range purls {
+		c, err := canonicalPurl(p)
+		if err != nil {
+			of[i] = -1
+			results[i] = Result[string, *pb.PurlLookupResult]{
+				Index:  i,
+				Key:    p,
+				Status: Failed,
+				Err: &RequestError{
+					Index:   i,
+					Request: p,
+					Code:    codes.InvalidArgument,
+					Err:     fmt.Errorf("%w: %v", ErrInvalidPurl, err),
+				},
+			}
+			continue
+		}
+		j, ok := index[c]
+		if !ok {
+			j = len(unique)
+			index[c] = j
+			unique = append(unique, c)
+		}
+		of[i] = j
+	}
+
+	found := make([]Result[string, *pb.PurlLookupResult], len(unique))
+	it := r.Purls(unique)
+	for {
+		res, err := it.Next(ctx)
+		if errors.Is(err, Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		found[res.Index] = res
+	}
+	for i, j := range of {
+		if j < 0 {
+			continue
+		}
+		res := found[j]
+		res.Index, res.Key = i, purls[i]
+		var reqErr *RequestError
+		if errors.As(res.Err, &reqErr) {
+			res.Err = &RequestError{Index: i, Request: purls[i], Code: reqErr.Code, Err: reqErr.Err}
+		}
+		results[i] = res
+	}
+	return results, nil
+}
+
+// canonicalPurl returns the canonical form of a package URL: its type in
+// lowercase and its namespace, name and version percent-encoded, including
+// the @ of an npm scope. Qualifiers and subpaths are kept as given.
+// See https://github.com/package-url/purl-spec.
+func canonicalPurl(purl string) (string, error) {
+	rest, ok := cutPrefixFold(strings.TrimSpace(purl), "pkg:")
+	if !ok {
+		return "", errors.New(`no "pkg:" scheme`)
+	}
+	rest = strings.TrimLeft(rest, "/")
+	var suffix string
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest, suffix = rest[:i], rest[i:]
+	}
+	typ, rest, ok := strings.Cut(rest, "/")
+	if !ok || typ == "" {
+		return "", errors.New("no type")
+	}
+	typ = strings.ToLower(typ)
+	for _, c := range typ {
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '.' || c == '+' || c == '-') {
+			return "", fmt.Errorf("invalid type %q", typ)
+		}
+	}
+	// The version follows the last @ that does not start a segment.
+	var version string
+	hasVersion := false
+	if i := strings.LastIndex(rest, "@"); i > 0 && rest[i-1] != '/' {
+		rest, version, hasVersion = rest[:i], rest[i+1:], true
+	}
+	segs := strings.Split(strings.Trim(rest, "/"), "/")
+	for i, s := range segs {
+		dec, err := url.PathUnescape(s)
+		if err != nil || dec == "" {
+			return "", fmt.Errorf("invalid component %q", s)
+		}
+		segs[i] = escape(dec)
+	}
+	out := "pkg:" + typ + "/" + strings.Join(segs, "/")
+	if hasVersion {
+		dec, err := url.PathUnescape(version)
+		if err != nil || dec == "" {
+			return "", fmt.Errorf("invalid version %q", version)
+		}
+		out += "@" + escape(dec)
+	}
+	return out + suffix, nil
+}
+
+// escape percent-encodes a component of a package URL.
+func escape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "@", "%40")
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCanonicalPurl(t *testing.T) {
+	for _, c := range []struct {
+		in, want string
+	}{
+		{"pkg:npm/left-pad@1.3.0", "pkg:npm/left-pad@1.3.0"},
+		{"PKG:NPM/@babel/core@7.0.0", "pkg:npm/%40babel/core@7.0.0"},
+		{"pkg:npm/%40babel/core@7.0.0", "pkg:npm/%40babel/core@7.0.0"},
+		{"pkg:npm/%40babel/core", "pkg:npm/%40babel/core"},
+		{"pkg://maven/org.example/lib@1.0?type=jar", "pkg:maven/org.example/lib@1.0?type=jar"},
+		{"pkg:golang/golang.org/x/text@v0.3.0", "pkg:golang/golang.org/x/text@v0.3.0"},
+		{"pkg:pypi/a%20b@1", "pkg:pypi/a%20b@1"},
+	} {
+		got, err := canonicalPurl(c.in)
+		if err != nil {
+			t.Errorf("canonicalPurl(%q): %v", c.in, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("canonicalPurl(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	for _, in := range []string{"npm/left-pad", "pkg:npm", "pkg:/left-pad@1", "pkg:n_p/x", "pkg:npm/%zz@1", "pkg:npm/x@"} {
+		if got, err := canonicalPurl(in); err == nil {
+			t.Errorf("canonicalPurl(%q) = %q, want error", in, got)
+		}
+	}
+}
+
+func TestLookupPurls(t *testing.T) {
+	purls := []string{
+		"pkg:npm/a@1.0.0",
+		"not a purl",
+		"pkg:npm/%40s/b@1.0.0",
+		"pkg:NPM/a@1.0.0",
+		"pkg:npm/@s/b@1.0.0",
+		"pkg:npm/c@1.0.0",
+		"pkg:npm/d@1.0.0",
+	}
+	// The fake gives no response to the last request of each batch, so
+	// of the batches [a @s/b] and [c d] those of @s/b and d are missing.
+	c := &fakeClient{}
+	r := NewRunner(c, &Options{BatchSize: 2})
+	results, err := r.LookupPurls(context.Background(), purls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.calls != 2 {
+		t.Errorf("made %d calls, want 2", c.calls)
+	}
+	type summary struct {
+		Index  int
+		Key    string
+		Status Status
+		Purl   string
+		Code   codes.Code
+	}
+	var got []summary
+	for _, res := range results {
+		var reqErr *RequestError
+		if res.Err != nil && (!errors.As(res.Err, &reqErr) || reqErr.Index != res.Index || reqErr.Request != res.Key) {
+			t.Errorf("result %d: error %v is not attributed to %q", res.Index, res.Err, res.Key)
+		}
+		got = append(got, summary{res.Index, res.Key, res.Status, res.Value.GetVersion().GetPurl(), status.Code(res.Err)})
+	}
+	want := []summary{
+		{0, purls[0], Found, "pkg:npm/a@1.0.0", codes.OK},
+		{1, purls[1], Failed, "", codes.InvalidArgument},
+		{2, purls[2], NotFound, "", codes.NotFound},
+		{3, purls[3], Found, "pkg:npm/a@1.0.0", codes.OK},
+		{4, purls[4], NotFound, "", codes.NotFound},
+		{5, purls[5], Found, "pkg:npm/c@1.0.0", codes.OK},
+		{6, purls[6], NotFound, "", codes.NotFound},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LookupPurls:\n got %+v\nwant %+v", got, want)
+	}
+	if !errors.Is(results[1].Err, ErrInvalidPurl) {
+		t.Errorf("invalid purl: got error %v, want ErrInvalidPurl", results[1].Err)
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// Done is returned by Results.Next when there are no more results.
+var Done = errors.New("no more results")
+
+// ErrNotFound is the error, wrapped in a RequestError, of a result whose
+// request found nothing.
+var ErrNotFound = errors.New("not found")
+
+// Status is the outcome of a single request of a batch.
+type Status int
+
+const (
+	// Found is the status of a request whose value was fetched.
+	Found Status = iota
+	// NotFound is the status of a request that found nothing.
+	NotFound
+	// Failed is the status of a request whose batch could not be
+	// fetched.
+	Failed
+)
+
+var statusNames = [...]string{"FOUND", "NOT_FOUND", "FAILED"}
+
+func (s Status) String() string {
+	if s < 0 || int(s) >= len(statusNames) {
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+	return statusNames[s]
+}
+
+// RequestError is the error of a single request of a batch.
+type RequestError struct {
+	// Index is the index of the request among those of the iterator.
+	Index int
+	// Request describes the request, such as "NPM react@18.2.0".
+	Request string
+	// Code is the gRPC code of the error: NotFound if the request found
+	// nothing, or else that of the call fetching the request's batch,
+	// Unknown if it has none.
+	Code codes.Code
+	// Err is ErrNotFound, or the error of the call fetching the request's
+	// batch.
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Request, e.Err)
+}
+
+func (e *RequestError) Unwrap() error { return e.Err }
+
+// GRPCStatus returns the error as a gRPC status with its Code, so that
+// status.Code reports it.
+func (e *RequestError) GRPCStatus() *status.Status {
+	return status.New(e.Code, e.Error())
+}
+
+// Result is the result of a single request of a batch.
+type Result[K, V any] struct {
+	// Index is the index of the request among those of the iterator, and
+	// Key the request itself.
+	Index int
+	Key   K
+	// Status is the outcome of the request. Callers should test it,
+	// rather than whether Value is empty, to tell missing values from
+	// failures.
+	Status Status
+	// Value is the value fetched, or nil unless Status is Found.
+	Value V
+	// Err is a *RequestError unless Status is Found.
+	Err error
+}
+
+// page fetches a page of the batch of the given keys, returning the values
+// of the responses in the order of the requests, nil for those not found,
+// and the token of the next page.
+type page[K, V any] func(ctx context.Context, keys []K, token string) (values []V, next string, err error)
+
+// Results iterates over the results of the requests of a batch job, one
+// per request and in the order of the requests. The requests are split
+// into batches and pages are fetched as needed, with the rate limit and
+// retries of the Runner; checkpoints are not used. A failure to fetch a
+// batch does not end the iteration: its remaining requests are given
+// results with the error, and the iteration continues with the next batch.
+type Results[K, V any] struct {
+	r        *Runner
+	keys     []K
+	fetch    page[K, V]
+	describe func(K) string
+
+	start int    // The index of the first request of the current batch.
+	off   int    // The number of results of the current batch fetched.
+	token string // The token of the next page of the current batch.
+	buf   []Result[K, V]
+}
+
+// Next returns the next result. It returns Done when there are no more
+// results, and an error only if ctx is done; the errors of individual
+// requests are reported in the Err of their results.
+func (it *Results[K, V]) Next(ctx context.Context) (Result[K, V], error) {
+	for len(it.buf) == 0 {
+		if it.start >= len(it.keys) {
+			return Result[K, V]{}, Done
+		}
+		if err := it.nextPage(ctx); err != nil {
+			return Result[K, V]{}, err
+		}
+	}
+	res := it.buf[0]
+	it.buf = it.buf[1:]
+	return res, nil
+}
+
+// nextPage fetches the next page of the current batch, moving to the next
+// batch when it is complete.
+func (it *Results[K, V]) nextPage(ctx context.Context) error {
+	n := min(len(it.keys)-it.start, it.r.opts.BatchSize)
+	keys := it.keys[it.start : it.start+n]
+	values, next, err := it.fetch(ctx, keys, it.token)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		for i := it.off; i < n; i++ {
+			it.add(i, *new(V), err)
+		}
+		it.endBatch(n)
+		return nil
+	}
+	values = values[:min(len(values), n-it.off)]
+	for _, v := range values {
+		it.add(it.off, v, nil)
+		it.off++
+	}
+	it.r.update(func(p *Progress) {
+		p.Pages++
+		p.Done += len(values)
+	})
+	if next != "" && len(values) > 0 {
+		it.token = next
+		return nil
+	}
+	// Requests without responses were not found.
+	for i := it.off; i < n; i++ {
+		it.add(i, *new(V), nil)
+	}
+	it.endBatch(n)
+	return nil
+}
+
+// add adds the result of the i'th request of the current batch. A nil
+// value with no error is not found.
+func (it *Results[K, V]) add(i int, v V, err error) {
+	idx := it.start + i
+	res := Result[K, V]{Index: idx, Key: it.keys[idx], Value: v}
+	code := codes.OK
+	switch {
+	case err != nil:
+		res.Status, code = Failed, status.Code(err)
+		if code == codes.OK {
+			code = codes.Unknown
+		}
+	case isNil(v):
+		res.Status, code, err = NotFound, codes.NotFound, ErrNotFound
+	}
+	if err != nil {
+		res.Err = &RequestError{Index: idx, Request: it.describe(it.keys[idx]), Code: code, Err: err}
+	}
+	it.buf = append(it.buf, res)
+}
+
+func (it *Results[K, V]) endBatch(n int) {
+	it.start += n
+	it.off, it.token = 0, ""
+}
+
+// isNil reports whether v, a pointer to a message, is nil.
+func isNil[V any](v V) bool {
+	return any(v) == any(*new(V))
+}
+
+// Versions returns an iterator over the versions with the given keys,
+// fetched with GetVersionBatch.
+func (r *Runner) Versions(vks []*pb.VersionKey) *Results[*pb.VersionKey, *pb.Version] {
+	r.update(func(p *Progress) { *p = Progress{Total: len(vks)} })
+	return &Results[*pb.VersionKey, *pb.Version]{
+		r:    r,
+		keys: vks,
+		fetch: func(ctx context.Context, vks []*pb.VersionKey, token string) ([]*pb.Version, string, error) {
+			req := &pb.GetVersionBatchRequest{PageToken: token}
+			for _, vk := range vks {
+				req.Requests = append(req.Requests, &pb.GetVersionRequest{VersionKey: vk})
+			}
+			b, err := r.call(ctx, req)
+			if err != nil {
+				return nil, "", err
+			}
+			var vs []*pb.Version
+			for _, resp := range b.GetResponses() {
+				vs = append(vs, resp.GetVersion())
+			}
+			return vs, b.GetNextPageToken(), nil
+		},
+		describe: func(vk *pb.VersionKey) string {
+			return fmt.Sprintf("%v %s@%s", vk.GetSystem(), vk.GetName(), vk.GetVersion())
+		},
+	}
+}
+
+// Projects returns an iterator over the projects with the given IDs, such
+// as github.com/google/go-cmp, fetched with GetProjectBatch.
+func (r *Runner) Projects(ids []string) *Results[string, *pb.Project] {
+	r.update(func(p *Progress) { *p = Progress{Total: len(ids)} })
+	return &Results[string, *pb.Project]{
+		r:    r,
+		keys: ids,
+		fetch: func(ctx context.Context, ids []string, token string) ([]*pb.Project, string, error) {
+			req := &pb.GetProjectBatchRequest{PageToken: token}
+			for _, id := range ids {
+				req.Requests = append(req.Requests, &pb.GetProjectRequest{ProjectKey: &pb.ProjectKey{Id: id}})
+			}
+			b, err := call(ctx, "fetching projects", func(ctx context.Context) (*pb.ProjectBatch, error) {
+				return r.c.GetProjectBatch(ctx, req)
+			})
+			if err != nil {
+				return nil, "", err
+			}
+			var ps []*pb.Project
+			for _, resp := range b.GetResponses() {
+				ps = append(ps, resp.GetProject())
+			}
+			return ps, b.GetNextPageToken(), nil
+		},
+		describe: func(id string) string { return "project " + id },
+	}
+}
+
+// Purls returns an iterator over the results of looking up the given
+// package URLs, fetched with PurlLookupBatch.
+func (r *Runner) Purls(purls []string) *Results[string, *pb.PurlLookupResult] {
+	r.update(func(p *Progress) { *p = Progress{Total: len(purls)} })
+	return &Results[string, *pb.PurlLookupResult]{
+		r:    r,
+		keys: purls,
+		fetch: func(ctx context.Context, purls []string, token string) ([]*pb.PurlLookupResult, string, error) {
+			req := &pb.PurlLookupBatchRequest{PageToken: token}
+			for _, purl := range purls {
+				req.Requests = append(req.Requests, &pb.PurlLookupRequest{Purl: purl})
+			}
+			b, err := call(ctx, "looking up purls", func(ctx context.Context) (*pb.PurlLookupBatchResult, error) {
+				return r.c.PurlLookupBatch(ctx, req)
+			})
+			if err != nil {
+				return nil, "", err
+			}
+			var rs []*pb.PurlLookupResult
+			for _, resp := range b.GetResponses() {
+				rs = append(rs, resp.GetResult())
+			}
+			return rs, b.GetNextPageToken(), nil
+		},
+		describe: func(purl string) string { return purl },
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+func (c *fakeClient) GetProjectBatch(ctx context.Context, req *pb.GetProjectBatchRequest, opts ...grpc.CallOption) (*pb.ProjectBatch, error) {
+	c.calls++
+	if err := c.errs[c.calls]; err != nil {
+		return nil, err
+	}
+	b := &pb.ProjectBatch{}
+	for _, r := range req.GetRequests() {
+		resp := &pb.ProjectBatch_Response{Request: r}
+		if id := r.GetProjectKey().GetId(); id != "missing" {
+			resp.Project = &pb.Project{ProjectKey: r.GetProjectKey()}
+		}
+		b.Responses = append(b.Responses, resp)
+	}
+	return b, nil
+}
+
+func (c *fakeClient) PurlLookupBatch(ctx context.Context, req *pb.PurlLookupBatchRequest, opts ...grpc.CallOption) (*pb.PurlLookupBatchResult, error) {
+	c.calls++
+	b := &pb.PurlLookupBatchResult{}
+	// The last request gets no response at all.
+	for _, r := range req.GetRequests()[:len(req.GetRequests())-1] {
+		b.Responses = append(b.Responses, &pb.PurlLookupBatchResult_Response{
+			Request: r,
+			Result:  &pb.PurlLookupResult{Version: &pb.Version{Purl: r.GetPurl()}},
+		})
+	}
+	return b, nil
+}
+
+// summarize returns the results of an iterator as strings, the key of each
+// found and the error of the others.
+func summarize[K, V any](t *testing.T, it *Results[K, V], key func(K) string) []string {
+	t.Helper()
+	var got []string
+	for i := 0; ; i++ {
+		res, err := it.Next(context.Background())
+		if errors.Is(err, Done) {
+			return got
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if res.Index != i {
+			t.Errorf("result %d has index %d", i, res.Index)
+		}
+		if (res.Status == Found) != (res.Err == nil) {
+			t.Errorf("result %d: status %v with error %v", i, res.Status, res.Err)
+		}
+		if res.Err != nil {
+			got = append(got, res.Status.String()+" "+res.Err.Error())
+			continue
+		}
+		got = append(got, key(res.Key))
+	}
+}
+
+func TestVersions(t *testing.T) {
+	vks := versionKeys(5)
+	vks[1] = &pb.VersionKey{System: pb.System_NPM, Name: "missing", Version: "1.0.0"}
+	// Batches of 3 give pages [0 1] [2] [3 4]; the third call fails, so
+	// the second batch fails as a whole.
+	c := &fakeClient{errs: map[int]error{3: status.Error(codes.InvalidArgument, "bad request")}}
+	r := NewRunner(c, &Options{BatchSize: 3})
+	got := summarize(t, r.Versions(vks), (*pb.VersionKey).GetName)
+	want := []string{
+		"pkg0",
+		"NOT_FOUND NPM missing@1.0.0: not found",
+		"pkg2",
+		"FAILED NPM pkg3@1.0.0: fetching versions: rpc error: code = InvalidArgument desc = bad request",
+		"FAILED NPM pkg4@1.0.0: fetching versions: rpc error: code = InvalidArgument desc = bad request",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Versions:\n got %q\nwant %q", got, want)
+	}
+	if p := r.Progress(); p.Total != 5 || p.Done != 3 || p.Pages != 2 {
+		t.Errorf("Progress: got %+v", p)
+	}
+}
+
+func TestVersionsErrors(t *testing.T) {
+	vks := versionKeys(2)
+	vks[0].Name = "missing"
+	it := NewRunner(&fakeClient{}, nil).Versions(vks)
+	res, err := it.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reqErr *RequestError
+	if !errors.As(res.Err, &reqErr) || !errors.Is(res.Err, ErrNotFound) || reqErr.Index != 0 {
+		t.Errorf("missing version: got error %#v, want a RequestError for ErrNotFound", res.Err)
+	}
+	if res.Status != NotFound || status.Code(res.Err) != codes.NotFound {
+		t.Errorf("missing version: got status %v, code %v; want NOT_FOUND, NotFound", res.Status, status.Code(res.Err))
+	}
+
+	c := &fakeClient{errs: map[int]error{1: status.Error(codes.PermissionDenied, "denied")}}
+	res, err = NewRunner(c, nil).Versions(vks).Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != Failed || status.Code(res.Err) != codes.PermissionDenied {
+		t.Errorf("failed batch: got status %v, code %v; want FAILED, PermissionDenied", res.Status, status.Code(res.Err))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewRunner(&fakeClient{}, nil).Versions(vks).Next(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Next with canceled context: got %v, want context.Canceled", err)
+	}
+}
+
+func TestProjects(t *testing.T) {
+	r := NewRunner(&fakeClient{}, nil)
+	got := summarize(t, r.Projects([]string{"github.com/a/a", "missing", "github.com/b/b"}), func(id string) string { return id })
+	want := []string{"github.com/a/a", "NOT_FOUND project missing: not found", "github.com/b/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Projects:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestPurls(t *testing.T) {
+	var purls []string
+	for i := 0; i < 3; i++ {
+		purls = append(purls, "pkg:npm/pkg"+strconv.Itoa(i)+"@1.0.0")
+	}
+	got := summarize(t, NewRunner(&fakeClient{}, nil).Purls(purls), func(p string) string { return p })
+	want := []string{"pkg:npm/pkg0@1.0.0", "pkg:npm/pkg1@1.0.0", "NOT_FOUND pkg:npm/pkg2@1.0.0: not found"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Purls:\n got %q\nwant %q", got, want)
+	}
+}
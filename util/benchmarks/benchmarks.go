@@ -0,0 +1,152 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package benchmarks tracks the performance of the resolvers on recorded
+real-world resolutions, so that regressions in their running time, their
+allocations or the number of calls they make to the deps.dev API are
+visible.
+
+Each Fixture names a package version representative of a kind of
+dependency graph, such as the large trees of popular npm packages or the
+deep hierarchies of Maven artifacts. Its recording, made by the record
+command with the replay package, holds the answers of the deps.dev API to
+the calls made by its resolution, and is kept in the testdata directory:
+
+	go run ./cmd/record            # records all the fixtures
+	go test -bench . -benchmem     # resolves them
+
+The benchmarks report, along with the time and allocations of each
+resolution, the number of calls it makes to its Client as calls/op.
+Fixtures that have not been recorded are skipped.
+
+PyPI packages, such as apache-airflow, are not covered, as there is no
+resolver for them in deps.dev/util/resolve yet.
+*/
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/maven"
+	"deps.dev/util/resolve/npm"
+	"deps.dev/util/resolve/replay"
+)
+
+// Fixture is a package version whose resolution is benchmarked.
+type Fixture struct {
+	// Name names the fixture in benchmarks, and its recording, held in
+	// the file Name.json.
+	Name string
+	// Root is the version resolved.
+	Root resolve.VersionKey
+}
+
+func concrete(sys resolve.System, name, version string) resolve.VersionKey {
+	return resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: sys, Name: name},
+		VersionType: resolve.Concrete,
+		Version:     version,
+	}
+}
+
+// Fixtures holds the fixtures of the suite.
+var Fixtures = []Fixture{
+	// A popular package with few dependencies.
+	{"npm-react", concrete(resolve.NPM, "react", "18.2.0")},
+	// A large tree, with many duplicated and bundled packages.
+	{"npm-react-scripts", concrete(resolve.NPM, "react-scripts", "5.0.1")},
+	// A tree with many aliases and peer dependencies.
+	{"npm-next", concrete(resolve.NPM, "next", "14.2.3")},
+	// Maven artifacts with native classifiers and a parent hierarchy.
+	{"maven-tensorflow-core-platform", concrete(resolve.Maven, "org.tensorflow:tensorflow-core-platform", "0.5.0")},
+	{"maven-tensorflow", concrete(resolve.Maven, "org.tensorflow:tensorflow", "1.15.0")},
+	// A deep graph with many dependency management imports.
+	{"maven-spring-boot-starter-web", concrete(resolve.Maven, "org.springframework.boot:spring-boot-starter-web", "3.2.5")},
+}
+
+// NewResolver returns the resolver of the fixture's system, using c.
+func (f Fixture) NewResolver(c resolve.Client) (resolve.Resolver, error) {
+	switch f.Root.System {
+	case resolve.NPM:
+		return npm.NewResolver(c), nil
+	case resolve.Maven:
+		return maven.NewResolver(c), nil
+	}
+	return nil, fmt.Errorf("%s: no resolver for %v", f.Name, f.Root.System)
+}
+
+// Path returns the path of the fixture's recording in the given directory.
+func (f Fixture) Path(dir string) string {
+	return filepath.Join(dir, f.Name+".json")
+}
+
+// Load returns a Client serving the fixture's recording, read from the
+// given directory. The error wraps fs.ErrNotExist if the fixture has not
+// been recorded.
+func (f Fixture) Load(dir string) (resolve.Client, error) {
+	file, err := os.Open(f.Path(dir))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	rec, err := replay.Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return replay.NewClient(rec)
+}
+
+// CountingClient is a resolve.Client counting the calls made to another.
+// It is safe for concurrent use if the wrapped client is.
+type CountingClient struct {
+	resolve.Client
+	calls atomic.Int64
+}
+
+// NewCountingClient returns a CountingClient of the calls made to c.
+func NewCountingClient(c resolve.Client) *CountingClient {
+	return &CountingClient{Client: c}
+}
+
+// Calls returns the number of calls made so far.
+func (c *CountingClient) Calls() int64 { return c.calls.Load() }
+
+// Reset sets the number of calls to zero.
+func (c *CountingClient) Reset() { c.calls.Store(0) }
+
+func (c *CountingClient) Version(ctx context.Context, vk resolve.VersionKey) (resolve.Version, error) {
+	c.calls.Add(1)
+	return c.Client.Version(ctx, vk)
+}
+
+func (c *CountingClient) Versions(ctx context.Context, pk resolve.PackageKey) ([]resolve.Version, error) {
+	c.calls.Add(1)
+	return c.Client.Versions(ctx, pk)
+}
+
+func (c *CountingClient) Requirements(ctx context.Context, vk resolve.VersionKey) ([]resolve.RequirementVersion, error) {
+	c.calls.Add(1)
+	return c.Client.Requirements(ctx, vk)
+}
+
+func (c *CountingClient) MatchingVersions(ctx context.Context, vk resolve.VersionKey) ([]resolve.Version, error) {
+	c.calls.Add(1)
+	return c.Client.MatchingVersions(ctx, vk)
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmarks
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/replay"
+	"deps.dev/util/resolve/schema"
+)
+
+func BenchmarkResolve(b *testing.B) {
+	for _, f := range Fixtures {
+		b.Run(f.Name, func(b *testing.B) {
+			c, err := f.Load("testdata")
+			if errors.Is(err, fs.ErrNotExist) {
+				b.Skipf("%s is not recorded; run go run ./cmd/record %s", f.Name, f.Name)
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+			cc := NewCountingClient(c)
+			r, err := f.NewResolver(cc)
+			if err != nil {
+				b.Fatal(err)
+			}
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				if _, err := r.Resolve(ctx, f.Root); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(cc.Calls())/float64(b.N), "calls/op")
+		})
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, f := range Fixtures {
+		if seen[f.Name] {
+			t.Errorf("duplicate fixture %s", f.Name)
+		}
+		seen[f.Name] = true
+		if _, err := f.NewResolver(resolve.NewLocalClient()); err != nil {
+			t.Error(err)
+		}
+		if f.Root.VersionType != resolve.Concrete {
+			t.Errorf("%s: root %v is not concrete", f.Name, f.Root)
+		}
+	}
+}
+
+func TestRecordAndLoad(t *testing.T) {
+	s, err := schema.New(`
+alice
+	1.0.0
+		bob@^1.0.0
+		chuck@^2.0.0
+bob
+	1.0.0
+		chuck@^2.0.0
+chuck
+	2.0.0
+	2.1.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Fixture{Name: "alice", Root: concrete(resolve.NPM, "alice", "1.0.0")}
+	dir := t.TempDir()
+	if _, err := f.Load(dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Load before recording: got %v, want fs.ErrNotExist", err)
+	}
+
+	rec := replay.NewRecorder(s.NewClient())
+	r, err := f.NewResolver(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := r.Resolve(context.Background(), f.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.Create(f.Path(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Recording().Write(out); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	c, err := f.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := NewCountingClient(c)
+	r, err = f.NewResolver(cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.Resolve(context.Background(), f.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Duration, got.Duration = 0, 0
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("replayed resolution (-want +got):\n%s", diff)
+	}
+	if cc.Calls() == 0 {
+		t.Errorf("got no counted call")
+	}
+	cc.Reset()
+	if cc.Calls() != 0 {
+		t.Errorf("Calls after Reset: got %d", cc.Calls())
+	}
+}
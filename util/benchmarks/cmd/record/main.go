@@ -0,0 +1,109 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+The record command records the fixtures of the benchmarks from the deps.dev
+API, resolving each of them and writing the calls made by its resolution to
+its file in the testdata directory, replacing any previous recording.
+
+	go run ./cmd/record [-dir testdata] [fixture ...]
+
+With no arguments, all the fixtures are recorded.
+*/
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/benchmarks"
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/replay"
+)
+
+func main() {
+	dir := flag.String("dir", "testdata", "directory of the recordings")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: record [-dir testdata] [fixture ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	fixtures := benchmarks.Fixtures
+	if flag.NArg() > 0 {
+		fixtures = nil
+		for _, name := range flag.Args() {
+			i := slices.IndexFunc(benchmarks.Fixtures, func(f benchmarks.Fixture) bool { return f.Name == name })
+			if i < 0 {
+				log.Fatalf("Unknown fixture %q", name)
+			}
+			fixtures = append(fixtures, benchmarks.Fixtures[i])
+		}
+	}
+
+	certPool, err := x509.SystemCertPool()
+	if err != nil {
+		log.Fatalf("Getting system cert pool: %v", err)
+	}
+	creds := credentials.NewClientTLSFromCert(certPool, "")
+	conn, err := grpc.NewClient("api.deps.dev:443", grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Fatalf("Dialing: %v", err)
+	}
+	defer conn.Close()
+	api := resolve.NewAPIClient(pb.NewInsightsClient(conn))
+
+	ctx := context.Background()
+	for _, f := range fixtures {
+		if err := record(ctx, api, f, *dir); err != nil {
+			log.Fatalf("Recording %s: %v", f.Name, err)
+		}
+	}
+}
+
+// record resolves the fixture with the given client and writes the calls
+// made to its recording.
+func record(ctx context.Context, c resolve.Client, f benchmarks.Fixture, dir string) error {
+	rec := replay.NewRecorder(c)
+	r, err := f.NewResolver(rec)
+	if err != nil {
+		return err
+	}
+	g, err := r.Resolve(ctx, f.Root)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(f.Path(dir))
+	if err != nil {
+		return err
+	}
+	if err := rec.Recording().Write(out); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	log.Printf("Recorded %s: %d nodes, %d calls", f.Name, len(g.Nodes), len(rec.Recording().Calls))
+	return nil
+}
@@ -0,0 +1,233 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package cache provides a generic least recently used cache that is safe for
+concurrent use. Entries may expire after a fixed time, the cache may be split
+into independently locked shards to reduce contention, and hooks report hits,
+misses and evictions so that the cache can be monitored.
+*/
+package cache
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// Options configure a Cache. The zero value of each field selects its
+// default.
+type Options[K comparable, V any] struct {
+	// Size is the most entries kept; the least recently used are evicted
+	// to make room. The default is no limit.
+	Size int
+	// TTL is the longest time an entry is kept after it is stored. The
+	// default is no limit.
+	TTL time.Duration
+	// Shards is the number of independently locked parts the cache is
+	// split into, each holding an equal share of Size. The default is
+	// one. Sharding reduces contention between goroutines using the
+	// cache, at the cost of evictions being less strictly ordered.
+	Shards int
+	// Hash assigns keys to shards. Keys of type string are hashed
+	// without it; for other types of key it must be set for the cache
+	// to be sharded.
+	Hash func(K) uint64
+
+	// Name is passed to Lookup, identifying the cache.
+	Name string
+	// Lookup, if set, is called for each call to Get, reporting whether
+	// the key was found. Its signature matches that of
+	// clientutil.CacheOptions.Lookup, so the same metrics can be used.
+	Lookup func(name string, hit bool)
+	// Evict, if set, is called for each entry removed to make room or
+	// because it has expired. It is not called for entries that are
+	// replaced or removed by Remove. It is called without any lock
+	// held, so it may use the cache.
+	Evict func(key K, value V)
+}
+
+// Cache is a least recently used cache of values of type V keyed by K. It
+// is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	opts   Options[K, V]
+	shards []shard[K, V]
+	seed   maphash.Seed
+}
+
+type shard[K comparable, V any] struct {
+	max int
+
+	mu      sync.Mutex
+	order   *list.List // Of *entry[K, V], most recently used first.
+	entries map[K]*list.Element
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // Zero if the entry does not expire.
+}
+
+// New returns an empty Cache. If opts is nil, the defaults are used.
+func New[K comparable, V any](opts *Options[K, V]) *Cache[K, V] {
+	c := &Cache[K, V]{seed: maphash.MakeSeed()}
+	if opts != nil {
+		c.opts = *opts
+	}
+	n := c.opts.Shards
+	if n < 1 || c.opts.Hash == nil && !isString[K]() {
+		n = 1
+	}
+	if c.opts.Size > 0 && n > c.opts.Size {
+		n = c.opts.Size
+	}
+	c.shards = make([]shard[K, V], n)
+	for i := range c.shards {
+		s := &c.shards[i]
+		if c.opts.Size > 0 {
+			// Spread the remainder so that the shards hold Size in
+			// total.
+			s.max = c.opts.Size / n
+			if i < c.opts.Size%n {
+				s.max++
+			}
+		}
+		s.order = list.New()
+		s.entries = make(map[K]*list.Element)
+	}
+	return c
+}
+
+func isString[K comparable]() bool {
+	var k K
+	_, ok := any(k).(string)
+	return ok
+}
+
+func (c *Cache[K, V]) shard(key K) *shard[K, V] {
+	if len(c.shards) == 1 {
+		return &c.shards[0]
+	}
+	var h uint64
+	if c.opts.Hash != nil {
+		h = c.opts.Hash(key)
+	} else {
+		h = maphash.String(c.seed, any(key).(string))
+	}
+	return &c.shards[h%uint64(len(c.shards))]
+}
+
+// Get returns the value stored for key, marking it as recently used.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	e, ok := s.entries[key]
+	var (
+		ent     *entry[K, V]
+		expired bool
+	)
+	if ok {
+		ent = e.Value.(*entry[K, V])
+		if !ent.expires.IsZero() && !time.Now().Before(ent.expires) {
+			s.order.Remove(e)
+			delete(s.entries, key)
+			ok, expired = false, true
+		} else {
+			s.order.MoveToFront(e)
+		}
+	}
+	s.mu.Unlock()
+	if c.opts.Lookup != nil {
+		c.opts.Lookup(c.opts.Name, ok)
+	}
+	if expired && c.opts.Evict != nil {
+		c.opts.Evict(ent.key, ent.value)
+	}
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return ent.value, true
+}
+
+// Put stores the value for key, evicting the least recently used value if
+// the cache is full.
+func (c *Cache[K, V]) Put(key K, value V) {
+	var expires time.Time
+	if c.opts.TTL > 0 {
+		expires = time.Now().Add(c.opts.TTL)
+	}
+	s := c.shard(key)
+	s.mu.Lock()
+	if e, ok := s.entries[key]; ok {
+		ent := e.Value.(*entry[K, V])
+		ent.value, ent.expires = value, expires
+		s.order.MoveToFront(e)
+		s.mu.Unlock()
+		return
+	}
+	s.entries[key] = s.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	var evicted *entry[K, V]
+	if s.max > 0 && s.order.Len() > s.max {
+		last := s.order.Back()
+		s.order.Remove(last)
+		evicted = last.Value.(*entry[K, V])
+		delete(s.entries, evicted.key)
+	}
+	s.mu.Unlock()
+	if evicted != nil && c.opts.Evict != nil {
+		c.opts.Evict(evicted.key, evicted.value)
+	}
+}
+
+// Remove removes the value stored for key, if any.
+func (c *Cache[K, V]) Remove(key K) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		s.order.Remove(e)
+		delete(s.entries, key)
+	}
+}
+
+// Len returns the number of entries in the cache, including any that have
+// expired but not yet been removed.
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		n += s.order.Len()
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// GetOrLoad returns the value stored for key, or else calls load and stores
+// the value it returns, unless it returns an error. Concurrent calls for a
+// missing key may each call load.
+func (c *Cache[K, V]) GetOrLoad(key K, load func() (V, error)) (V, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+	c.Put(key, v)
+	return v, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	var evicted []string
+	c := New(&Options[string, int]{
+		Size:  2,
+		Evict: func(k string, v int) { evicted = append(evicted, k) },
+	})
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Get("a")
+	c.Put("c", 3) // Evicts b.
+	for _, k := range []string{"a", "b", "c"} {
+		v, ok := c.Get(k)
+		if want := k != "b"; ok != want {
+			t.Errorf("Get(%q) = %d, %v, want present %v", k, v, ok, want)
+		}
+	}
+	c.Remove("a")
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("Get(\"a\") after Remove succeeded")
+	}
+	if got := c.Len(); got != 1 {
+		t.Errorf("Len() = %d, want 1", got)
+	}
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Errorf("evicted %v, want [b]", evicted)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	hits, misses, evictions := 0, 0, 0
+	c := New(&Options[string, int]{
+		TTL:  20 * time.Millisecond,
+		Name: "test",
+		Lookup: func(name string, hit bool) {
+			if name != "test" {
+				t.Errorf("Lookup called with name %q", name)
+			}
+			if hit {
+				hits++
+			} else {
+				misses++
+			}
+		},
+		Evict: func(string, int) { evictions++ },
+	})
+	c.Put("a", 1)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(\"a\") = %d, %v, want 1, true", v, ok)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("Get(\"a\") after expiry succeeded")
+	}
+	if hits != 1 || misses != 1 || evictions != 1 {
+		t.Errorf("got %d hits, %d misses and %d evictions, want 1 of each", hits, misses, evictions)
+	}
+	if got := c.Len(); got != 0 {
+		t.Errorf("Len() = %d, want 0", got)
+	}
+}
+
+func TestShards(t *testing.T) {
+	c := New(&Options[string, int]{Size: 100, Shards: 8})
+	if got := len(c.shards); got != 8 {
+		t.Fatalf("got %d shards, want 8", got)
+	}
+	total := 0
+	for i := range c.shards {
+		total += c.shards[i].max
+	}
+	if total != 100 {
+		t.Errorf("shards hold %d entries in total, want 100", total)
+	}
+	for i := 0; i < 1000; i++ {
+		c.Put(fmt.Sprint(i), i)
+	}
+	if got := c.Len(); got > 100 {
+		t.Errorf("Len() = %d, want at most 100", got)
+	}
+
+	// Keys other than strings need a Hash to be sharded.
+	if got := len(New(&Options[int, int]{Shards: 8}).shards); got != 1 {
+		t.Errorf("int keys without Hash: got %d shards, want 1", got)
+	}
+	ints := New(&Options[int, int]{Shards: 8, Hash: func(k int) uint64 { return uint64(k) }})
+	if got := len(ints.shards); got != 8 {
+		t.Errorf("int keys with Hash: got %d shards, want 8", got)
+	}
+	ints.Put(3, 9)
+	if v, ok := ints.Get(3); !ok || v != 9 {
+		t.Errorf("Get(3) = %d, %v, want 9, true", v, ok)
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	c := New[string, int](nil)
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return loads, nil
+	}
+	for i := 0; i < 2; i++ {
+		if v, err := c.GetOrLoad("a", load); err != nil || v != 1 {
+			t.Errorf("GetOrLoad(\"a\") = %d, %v, want 1, nil", v, err)
+		}
+	}
+	errLoad := errors.New("load failed")
+	if _, err := c.GetOrLoad("b", func() (int, error) { return 0, errLoad }); err != errLoad {
+		t.Errorf("GetOrLoad(\"b\"): got error %v, want %v", err, errLoad)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("failed load was cached")
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	c := New(&Options[string, int]{Size: 64, Shards: 4, TTL: time.Minute})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := fmt.Sprint((g * i) % 200)
+				if _, ok := c.Get(k); !ok {
+					c.Put(k, i)
+				}
+				if i%10 == 0 {
+					c.Remove(k)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.Len(); got > 64 {
+		t.Errorf("Len() = %d, want at most 64", got)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	for _, shards := range []int{1, 16} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			c := New(&Options[string, int]{Size: 1024, Shards: shards})
+			keys := make([]string, 1024)
+			for i := range keys {
+				keys[i] = fmt.Sprint(i)
+				c.Put(keys[i], i)
+			}
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					c.Get(keys[i%len(keys)])
+					i++
+				}
+			})
+		})
+	}
+}
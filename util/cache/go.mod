@@ -0,0 +1,3 @@
+module deps.dev/util/cache
+
+go 1.23.4
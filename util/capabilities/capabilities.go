@@ -0,0 +1,192 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package capabilities compares the Capslock capabilities of two versions of a
+Go package, as reported by the GetCapabilities method of the deps.dev API,
+so that an upgrade that gives a package a new capability, such as network
+access or running other programs, can be flagged for review:
+
+	d, err := capabilities.Compare(ctx, client, "golang.org/x/net", "v0.17.0", "v0.18.0")
+	if err != nil {
+		// ...
+	}
+	if gained := d.Gained("NETWORK", "EXEC"); len(gained) > 0 {
+		// Flag the upgrade.
+	}
+
+See https://github.com/google/capslock for a description of the
+capabilities.
+*/
+package capabilities
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// ErrNotFound is returned, wrapped, by Compare if deps.dev has no
+// capabilities for a version.
+var ErrNotFound = errors.New("not found")
+
+// prefix is the prefix of the names of Capslock capabilities.
+const prefix = "CAPABILITY_"
+
+// Capability is a capability of a package version, with the number of
+// call paths reaching it.
+type Capability struct {
+	// Name is the name of the capability, such as CAPABILITY_NETWORK.
+	Name string
+	// Direct and Indirect are the numbers of calls to the capability made
+	// by the package directly and through other packages.
+	Direct, Indirect int
+}
+
+// Change is a capability held by both versions whose number of call paths
+// differs.
+type Change struct {
+	Name     string
+	Old, New Capability
+}
+
+// Diff is the difference between the capabilities of two versions. Each of
+// its lists is ordered by capability name.
+type Diff struct {
+	// Added holds the capabilities of the new version that the old one
+	// did not have.
+	Added []Capability
+	// Removed holds the capabilities of the old version that the new one
+	// does not have.
+	Removed []Capability
+	// Changed holds the capabilities of both versions whose call counts
+	// differ.
+	Changed []Change
+}
+
+// Empty reports whether the versions have the same capabilities, with the
+// same call counts.
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Gained returns the names of the given capabilities that were added, in
+// the order given. Names may be given with or without the CAPABILITY_
+// prefix, as NETWORK or CAPABILITY_NETWORK, in any case. With no arguments
+// it returns the names of all added capabilities.
+func (d *Diff) Gained(names ...string) []string {
+	if len(names) == 0 {
+		var all []string
+		for _, c := range d.Added {
+			all = append(all, c.Name)
+		}
+		return all
+	}
+	var gained []string
+	for _, n := range names {
+		n = Canonical(n)
+		if slices.ContainsFunc(d.Added, func(c Capability) bool { return c.Name == n }) {
+			gained = append(gained, n)
+		}
+	}
+	return gained
+}
+
+// Canonical returns the full name of a capability, as used by the API,
+// given its name with or without the CAPABILITY_ prefix.
+func Canonical(name string) string {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, prefix) {
+		name = prefix + name
+	}
+	return name
+}
+
+// Compare fetches the capabilities of two versions of the Go package with
+// the given name and returns their difference.
+func Compare(ctx context.Context, c pb.InsightsClient, name, oldVersion, newVersion string) (*Diff, error) {
+	old, err := get(ctx, c, name, oldVersion)
+	if err != nil {
+		return nil, err
+	}
+	new, err := get(ctx, c, name, newVersion)
+	if err != nil {
+		return nil, err
+	}
+	return DiffCapabilities(old, new), nil
+}
+
+func get(ctx context.Context, c pb.InsightsClient, name, version string) (*pb.Capabilities, error) {
+	caps, err := c.GetCapabilities(ctx, &pb.GetCapabilitiesRequest{
+		VersionKey: &pb.VersionKey{System: pb.System_GO, Name: name, Version: version},
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, fmt.Errorf("capabilities of %s@%s: %w", name, version, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("capabilities of %s@%s: %w", name, version, err)
+	}
+	return caps, nil
+}
+
+// DiffCapabilities returns the difference between two sets of
+// capabilities, as returned by GetCapabilities.
+func DiffCapabilities(old, new *pb.Capabilities) *Diff {
+	oldCaps, newCaps := index(old), index(new)
+	d := &Diff{}
+	for name, n := range newCaps {
+		o, ok := oldCaps[name]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, n)
+		case o != n:
+			d.Changed = append(d.Changed, Change{Name: name, Old: o, New: n})
+		}
+	}
+	for name, o := range oldCaps {
+		if _, ok := newCaps[name]; !ok {
+			d.Removed = append(d.Removed, o)
+		}
+	}
+	byName := func(a, b Capability) int { return cmp.Compare(a.Name, b.Name) }
+	slices.SortFunc(d.Added, byName)
+	slices.SortFunc(d.Removed, byName)
+	slices.SortFunc(d.Changed, func(a, b Change) int { return cmp.Compare(a.Name, b.Name) })
+	return d
+}
+
+// index returns the capabilities keyed by name. Capabilities with no call
+// paths are left out.
+func index(caps *pb.Capabilities) map[string]Capability {
+	m := make(map[string]Capability)
+	for _, c := range caps.GetCapabilities() {
+		if c.GetDirectCount() == 0 && c.GetIndirectCount() == 0 {
+			continue
+		}
+		m[c.GetCapability()] = Capability{
+			Name:     c.GetCapability(),
+			Direct:   int(c.GetDirectCount()),
+			Indirect: int(c.GetIndirectCount()),
+		}
+	}
+	return m
+}
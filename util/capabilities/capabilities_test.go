@@ -0,0 +1,98 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capabilities
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+type fakeClient struct {
+	pb.InsightsClient
+	caps map[string]*pb.Capabilities // Keyed by version.
+}
+
+func (c fakeClient) GetCapabilities(ctx context.Context, req *pb.GetCapabilitiesRequest, opts ...grpc.CallOption) (*pb.Capabilities, error) {
+	caps, ok := c.caps[req.GetVersionKey().GetVersion()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no capabilities")
+	}
+	return caps, nil
+}
+
+func capability(name string, direct, indirect uint32) *pb.Capabilities_Capability {
+	return &pb.Capabilities_Capability{Capability: name, DirectCount: direct, IndirectCount: indirect}
+}
+
+func TestCompare(t *testing.T) {
+	c := fakeClient{caps: map[string]*pb.Capabilities{
+		"v1.0.0": {Capabilities: []*pb.Capabilities_Capability{
+			capability("CAPABILITY_FILES", 2, 1),
+			capability("CAPABILITY_REFLECT", 1, 0),
+			capability("CAPABILITY_UNSAFE_POINTER", 0, 3),
+			capability("CAPABILITY_CGO", 0, 0),
+		}},
+		"v1.1.0": {Capabilities: []*pb.Capabilities_Capability{
+			capability("CAPABILITY_FILES", 2, 1),
+			capability("CAPABILITY_UNSAFE_POINTER", 0, 5),
+			capability("CAPABILITY_NETWORK", 1, 0),
+			capability("CAPABILITY_EXEC", 0, 2),
+		}},
+	}}
+	d, err := Compare(context.Background(), c, "example.com/m", "v1.0.0", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Diff{
+		Added: []Capability{
+			{Name: "CAPABILITY_EXEC", Indirect: 2},
+			{Name: "CAPABILITY_NETWORK", Direct: 1},
+		},
+		Removed: []Capability{{Name: "CAPABILITY_REFLECT", Direct: 1}},
+		Changed: []Change{{
+			Name: "CAPABILITY_UNSAFE_POINTER",
+			Old:  Capability{Name: "CAPABILITY_UNSAFE_POINTER", Indirect: 3},
+			New:  Capability{Name: "CAPABILITY_UNSAFE_POINTER", Indirect: 5},
+		}},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("got %+v, want %+v", d, want)
+	}
+	if got := d.Gained("network", "CAPABILITY_FILES", "Exec"); !reflect.DeepEqual(got, []string{"CAPABILITY_NETWORK", "CAPABILITY_EXEC"}) {
+		t.Errorf("Gained: got %v", got)
+	}
+	if got := d.Gained(); len(got) != 2 {
+		t.Errorf("Gained(): got %v", got)
+	}
+	if d.Empty() {
+		t.Errorf("Empty: got true")
+	}
+
+	d, err = Compare(context.Background(), c, "example.com/m", "v1.1.0", "v1.1.0")
+	if err != nil || !d.Empty() {
+		t.Errorf("comparing a version with itself: got %+v, %v", d, err)
+	}
+	if _, err := Compare(context.Background(), c, "example.com/m", "v1.0.0", "v2.0.0"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("comparing with an unknown version: got %v, want ErrNotFound", err)
+	}
+}
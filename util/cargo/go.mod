@@ -0,0 +1,27 @@
+module deps.dev/util/cargo
+
+go 1.23.4
+
+replace (
+	deps.dev/util/maven => ../maven
+	deps.dev/util/resolve => ../resolve
+	deps.dev/util/semver => ../semver
+)
+
+require (
+	deps.dev/util/resolve v0.0.0-20240611045547-af20eef0f1eb
+	github.com/BurntSushi/toml v1.4.0
+	github.com/google/go-cmp v0.6.0
+)
+
+require (
+	deps.dev/api/v3 v3.0.0-20240311054650-e1e6a3d70fb7 // indirect
+	deps.dev/util/maven v0.0.0-20240322043601-ff53416fec6a // indirect
+	deps.dev/util/semver v0.0.0-20240109040450-1e316b822bc4 // indirect
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
+	google.golang.org/grpc v1.69.4 // indirect
+	google.golang.org/protobuf v1.35.1 // indirect
+)
@@ -0,0 +1,272 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package cargo parses Cargo.toml manifests, resolving the fields inherited
+from their workspace, and converts their dependencies to resolve
+requirements.
+https://doc.rust-lang.org/cargo/reference/manifest.html
+*/
+package cargo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Manifest holds the contents of a Cargo.toml.
+type Manifest struct {
+	Package Package `toml:"package"`
+
+	Dependencies      map[string]Dependency `toml:"dependencies"`
+	DevDependencies   map[string]Dependency `toml:"dev-dependencies"`
+	BuildDependencies map[string]Dependency `toml:"build-dependencies"`
+	// Target holds the platform-specific dependencies, keyed by target
+	// triple or cfg expression, such as `cfg(unix)`.
+	Target map[string]Platform `toml:"target"`
+
+	// Features maps the features of the package to the features and
+	// optional dependencies they enable.
+	Features map[string][]string `toml:"features"`
+
+	// Workspace is set if the manifest is the root of a workspace.
+	Workspace *Workspace `toml:"workspace"`
+}
+
+// Package holds the metadata of the package. The fields of type Field may
+// be inherited from the workspace.
+type Package struct {
+	Name        string `toml:"name"`
+	Version     Field  `toml:"version"`
+	Edition     Field  `toml:"edition"`
+	RustVersion Field  `toml:"rust-version"`
+	Description Field  `toml:"description"`
+	License     Field  `toml:"license"`
+	Repository  Field  `toml:"repository"`
+	Homepage    Field  `toml:"homepage"`
+}
+
+// Platform holds the dependencies specific to a target platform.
+type Platform struct {
+	Dependencies      map[string]Dependency `toml:"dependencies"`
+	DevDependencies   map[string]Dependency `toml:"dev-dependencies"`
+	BuildDependencies map[string]Dependency `toml:"build-dependencies"`
+}
+
+// Workspace holds the members of a workspace and the values its members
+// may inherit.
+type Workspace struct {
+	Members      []string              `toml:"members"`
+	Exclude      []string              `toml:"exclude"`
+	Package      WorkspacePackage      `toml:"package"`
+	Dependencies map[string]Dependency `toml:"dependencies"`
+}
+
+// WorkspacePackage holds the package metadata inherited by the members of
+// a workspace.
+type WorkspacePackage struct {
+	Version     string `toml:"version"`
+	Edition     string `toml:"edition"`
+	RustVersion string `toml:"rust-version"`
+	Description string `toml:"description"`
+	License     string `toml:"license"`
+	Repository  string `toml:"repository"`
+	Homepage    string `toml:"homepage"`
+}
+
+// Field is a package field that is either set or inherited from the
+// workspace, written as `field.workspace = true`.
+type Field struct {
+	Value     string
+	Workspace bool
+}
+
+func (f *Field) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		*f = Field{Value: v}
+	case map[string]any:
+		ws, ok := v["workspace"].(bool)
+		if !ok || !ws || len(v) != 1 {
+			return errors.New("invalid field: expected a string or workspace = true")
+		}
+		*f = Field{Workspace: true}
+	default:
+		return fmt.Errorf("invalid field of type %T", data)
+	}
+	return nil
+}
+
+// Dependency is the specification of a dependency, written either as a
+// version requirement or as a table.
+// https://doc.rust-lang.org/cargo/reference/specifying-dependencies.html
+type Dependency struct {
+	Version string
+	// Package, if set, is the name of the package depended on, the
+	// dependency being renamed to its key.
+	Package  string
+	Registry string
+	Path     string
+	Git      string
+	Branch   string
+	Tag      string
+	Rev      string
+	Features []string
+	Optional bool
+	// DefaultFeatures reports whether the default features of the
+	// dependency are enabled, nil meaning unspecified, that is enabled.
+	DefaultFeatures *bool
+	// Workspace reports whether the dependency is inherited from the
+	// workspace.
+	Workspace bool
+}
+
+func (d *Dependency) UnmarshalTOML(data any) error {
+	if v, ok := data.(string); ok {
+		*d = Dependency{Version: v}
+		return nil
+	}
+	table, ok := data.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid dependency of type %T", data)
+	}
+	*d = Dependency{}
+	for k, v := range table {
+		var err error
+		switch k {
+		case "version":
+			err = set(&d.Version, k, v)
+		case "package":
+			err = set(&d.Package, k, v)
+		case "registry":
+			err = set(&d.Registry, k, v)
+		case "path":
+			err = set(&d.Path, k, v)
+		case "git":
+			err = set(&d.Git, k, v)
+		case "branch":
+			err = set(&d.Branch, k, v)
+		case "tag":
+			err = set(&d.Tag, k, v)
+		case "rev":
+			err = set(&d.Rev, k, v)
+		case "optional":
+			err = set(&d.Optional, k, v)
+		case "workspace":
+			err = set(&d.Workspace, k, v)
+		case "default-features", "default_features":
+			var b bool
+			err = set(&b, k, v)
+			d.DefaultFeatures = &b
+		case "features":
+			fs, ok := v.([]any)
+			if !ok {
+				return fmt.Errorf("invalid features of type %T", v)
+			}
+			for _, f := range fs {
+				var s string
+				if err := set(&s, k, f); err != nil {
+					return err
+				}
+				d.Features = append(d.Features, s)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// set sets *dst to v, if it is of the expected type.
+func set[T any](dst *T, key string, v any) error {
+	t, ok := v.(T)
+	if !ok {
+		return fmt.Errorf("invalid %s of type %T", key, v)
+	}
+	*dst = t
+	return nil
+}
+
+// Parse parses the given Cargo.toml. If the manifest is the root of a
+// workspace, the fields it inherits from the workspace are resolved.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if _, err := toml.Decode(string(data), &m); err != nil {
+		return nil, fmt.Errorf("failed to parse Cargo.toml: %w", err)
+	}
+	if m.Workspace != nil {
+		if err := m.Inherit(m.Workspace); err != nil {
+			return nil, err
+		}
+	}
+	return &m, nil
+}
+
+// Inherit resolves the fields and dependencies inherited from the given
+// workspace. As in Cargo, the features of an inherited dependency add to
+// those of the workspace, and its optional flag is that of the member.
+// https://doc.rust-lang.org/cargo/reference/workspaces.html
+func (m *Manifest) Inherit(ws *Workspace) error {
+	p, wp := &m.Package, ws.Package
+	for _, f := range []struct {
+		name  string
+		field *Field
+		value string
+	}{
+		{"version", &p.Version, wp.Version},
+		{"edition", &p.Edition, wp.Edition},
+		{"rust-version", &p.RustVersion, wp.RustVersion},
+		{"description", &p.Description, wp.Description},
+		{"license", &p.License, wp.License},
+		{"repository", &p.Repository, wp.Repository},
+		{"homepage", &p.Homepage, wp.Homepage},
+	} {
+		if !f.field.Workspace {
+			continue
+		}
+		if f.value == "" {
+			return fmt.Errorf("package.%s is not set in the workspace", f.name)
+		}
+		*f.field = Field{Value: f.value}
+	}
+
+	inherit := func(deps map[string]Dependency) error {
+		for name, d := range deps {
+			if !d.Workspace {
+				continue
+			}
+			wd, ok := ws.Dependencies[name]
+			if !ok {
+				return fmt.Errorf("dependency %s is not set in the workspace", name)
+			}
+			wd.Features = append(wd.Features[:len(wd.Features):len(wd.Features)], d.Features...)
+			wd.Optional = d.Optional
+			deps[name] = wd
+		}
+		return nil
+	}
+	sections := []map[string]Dependency{m.Dependencies, m.DevDependencies, m.BuildDependencies}
+	for _, p := range m.Target {
+		sections = append(sections, p.Dependencies, p.DevDependencies, p.BuildDependencies)
+	}
+	for _, deps := range sections {
+		if err := inherit(deps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cargo
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+const workspaceToml = `
+[workspace]
+members = ["crates/*"]
+
+[workspace.package]
+version = "0.3.0"
+edition = "2021"
+license = "MIT OR Apache-2.0"
+
+[workspace.dependencies]
+serde = { version = "1.0", default-features = false, features = ["derive"] }
+log = "0.4"
+`
+
+const memberToml = `
+[package]
+name = "alice"
+version.workspace = true
+edition = { workspace = true }
+license.workspace = true
+description = "A crate"
+
+[dependencies]
+serde = { workspace = true, features = ["std"] }
+log = { workspace = true, optional = true }
+rand = "0.8"
+bob = { version = "1.2", package = "bob-core", default_features = false }
+local = { path = "../local" }
+chuck = { git = "https://example.com/chuck", version = "2" }
+
+[dev-dependencies]
+proptest = "1"
+
+[build-dependencies]
+cc = "1.0"
+
+[target.'cfg(unix)'.dependencies]
+libc = "0.2"
+
+[features]
+default = ["std"]
+std = ["serde/std"]
+logging = ["dep:log"]
+`
+
+func TestManifest(t *testing.T) {
+	ws, err := Parse([]byte(workspaceToml))
+	if err != nil {
+		t.Fatalf("Parse workspace: %v", err)
+	}
+	m, err := Parse([]byte(memberToml))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := m.Requirements(); err == nil {
+		t.Errorf("Requirements before Inherit: got no error")
+	}
+	if err := m.Inherit(ws.Workspace); err != nil {
+		t.Fatalf("Inherit: %v", err)
+	}
+
+	wantPackage := Package{
+		Name:        "alice",
+		Version:     Field{Value: "0.3.0"},
+		Edition:     Field{Value: "2021"},
+		License:     Field{Value: "MIT OR Apache-2.0"},
+		Description: Field{Value: "A crate"},
+	}
+	if diff := cmp.Diff(wantPackage, m.Package); diff != "" {
+		t.Errorf("package (-want +got):\n%s", diff)
+	}
+	wantFeatures := map[string][]string{
+		"default": {"std"},
+		"std":     {"serde/std"},
+		"logging": {"dep:log"},
+	}
+	if diff := cmp.Diff(wantFeatures, m.Features); diff != "" {
+		t.Errorf("features (-want +got):\n%s", diff)
+	}
+	if got, want := m.VersionKey().Version, "0.3.0"; got != want {
+		t.Errorf("VersionKey: got version %s, want %s", got, want)
+	}
+
+	req := func(name, version string, b *dep.Builder) resolve.RequirementVersion {
+		return resolve.RequirementVersion{
+			VersionKey: resolve.VersionKey{
+				PackageKey:  resolve.PackageKey{System: resolve.Cargo, Name: name},
+				VersionType: resolve.Requirement,
+				Version:     version,
+			},
+			Type: b.Type(),
+		}
+	}
+	want := []resolve.RequirementVersion{
+		req("bob-core", "1.2", dep.NewBuilder().KnownAs("bob")),
+		req("chuck", "2", dep.NewBuilder().EnabledDependencies("default")),
+		req("log", "0.4", dep.NewBuilder().Opt().EnabledDependencies("default")),
+		req("rand", "0.8", dep.NewBuilder().EnabledDependencies("default")),
+		req("serde", "1.0", dep.NewBuilder().EnabledDependencies("derive", "std")),
+		req("proptest", "1", dep.NewBuilder().Dev().EnabledDependencies("default")),
+		req("cc", "1.0", dep.NewBuilder().Scope(dep.ScopeBuild).EnabledDependencies("default")),
+		req("libc", "0.2", dep.NewBuilder().Environment("cfg(unix)").EnabledDependencies("default")),
+	}
+	got, err := m.Requirements()
+	if err != nil {
+		t.Fatalf("Requirements: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Requirements (-want +got):\n%s", diff)
+	}
+	// The workspace is left unchanged.
+	if got := ws.Workspace.Dependencies["serde"].Features; len(got) != 1 {
+		t.Errorf("workspace serde features: got %v, want [derive]", got)
+	}
+}
+
+func TestManifestErrors(t *testing.T) {
+	for _, test := range []struct {
+		name, toml string
+	}{
+		{"invalid toml", `[package`},
+		{"invalid field", `package.version = 1`},
+		{"invalid features", `dependencies.a = { version = "1", features = "x" }`},
+		{"missing workspace field", "[workspace]\n[package]\nlicense.workspace = true"},
+		{"missing workspace dependency", "[workspace]\n[dependencies]\na.workspace = true"},
+	} {
+		if _, err := Parse([]byte(test.toml)); err == nil {
+			t.Errorf("%s: got no error", test.name)
+		}
+	}
+}
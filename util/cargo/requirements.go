@@ -0,0 +1,140 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cargo
+
+import (
+	"fmt"
+	"sort"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+// defaultFeature is the feature enabled unless default-features is false.
+const defaultFeature = "default"
+
+// VersionKey returns the version key of the package.
+func (m *Manifest) VersionKey() resolve.VersionKey {
+	return resolve.VersionKey{
+		PackageKey: resolve.PackageKey{
+			System: resolve.Cargo,
+			Name:   m.Package.Name,
+		},
+		VersionType: resolve.Concrete,
+		Version:     m.Package.Version.Value,
+	}
+}
+
+// Requirements returns the requirements on the dependencies of the
+// manifest, in the order of their sections and then of their names. Their
+// types hold:
+//   - Dev for dev-dependencies, and the build scope for build-dependencies;
+//   - Opt for optional dependencies;
+//   - Environment for platform-specific dependencies, set to their target;
+//   - KnownAs for renamed dependencies, set to the name used by the package;
+//   - EnabledDependencies for the features enabled in the dependency,
+//     including "default" unless default-features is false.
+//
+// Dependencies on a path or a git repository with no version are left out,
+// as they are not resolved from a registry. Dependencies inherited from a
+// workspace must have been resolved with Inherit.
+func (m *Manifest) Requirements() ([]resolve.RequirementVersion, error) {
+	var reqs []resolve.RequirementVersion
+	add := func(deps map[string]Dependency, target string, t func() *dep.Builder) error {
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			d := deps[name]
+			if d.Workspace {
+				return fmt.Errorf("dependency %s is inherited from the workspace", name)
+			}
+			if d.Version == "" && (d.Path != "" || d.Git != "") {
+				continue
+			}
+			b := t()
+			if d.Optional {
+				b.Opt()
+			}
+			if target != "" {
+				b.Environment(target)
+			}
+			pkg := name
+			if d.Package != "" {
+				pkg = d.Package
+				b.KnownAs(name)
+			}
+			var features []string
+			if d.DefaultFeatures == nil || *d.DefaultFeatures {
+				features = append(features, defaultFeature)
+			}
+			features = append(features, d.Features...)
+			if len(features) > 0 {
+				b.EnabledDependencies(features...)
+			}
+			req := d.Version
+			if req == "" {
+				req = "*"
+			}
+			reqs = append(reqs, resolve.RequirementVersion{
+				VersionKey: resolve.VersionKey{
+					PackageKey: resolve.PackageKey{
+						System: resolve.Cargo,
+						Name:   pkg,
+					},
+					VersionType: resolve.Requirement,
+					Version:     req,
+				},
+				Type: b.Type(),
+			})
+		}
+		return nil
+	}
+	regular := func() *dep.Builder { return dep.NewBuilder() }
+	dev := func() *dep.Builder { return dep.NewBuilder().Dev() }
+	build := func() *dep.Builder { return dep.NewBuilder().Scope(dep.ScopeBuild) }
+
+	type section struct {
+		deps   map[string]Dependency
+		target string
+		t      func() *dep.Builder
+	}
+	sections := []section{
+		{m.Dependencies, "", regular},
+		{m.DevDependencies, "", dev},
+		{m.BuildDependencies, "", build},
+	}
+	targets := make([]string, 0, len(m.Target))
+	for target := range m.Target {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		p := m.Target[target]
+		sections = append(sections,
+			section{p.Dependencies, target, regular},
+			section{p.DevDependencies, target, dev},
+			section{p.BuildDependencies, target, build},
+		)
+	}
+	for _, s := range sections {
+		if err := add(s.deps, s.target, s.t); err != nil {
+			return nil, err
+		}
+	}
+	return reqs, nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package cpe parses Common Platform Enumeration names, as used by the NVD to
+identify the software affected by vulnerabilities, and maps them to the
+packages known to deps.dev.
+
+Parse accepts CPE 2.3 formatted strings, such as
+
+	cpe:2.3:a:fasterxml:jackson-databind:2.9.10:*:*:*:*:*:*:*
+
+and the older CPE 2.2 URIs, such as cpe:/a:fasterxml:jackson-databind:2.9.10.
+
+A CPE names software by vendor and product, which rarely match the name of
+a package, so the mapping is best-effort. A Mapper first looks the vendor
+and product up in a table of aliases, seeded with DefaultAliases and
+extended with Mapper.Add; failing that, it guesses a package from the
+product name when the target software of the CPE identifies an ecosystem,
+such as node.js. The candidates it returns give package URLs usable with
+the PurlLookup method of the deps.dev API.
+
+See NISTIR 7695 for the CPE 2.3 naming specification.
+*/
+package cpe
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// The logical values of attributes.
+const (
+	// Any matches any value.
+	Any = "*"
+	// NA means the attribute does not apply.
+	NA = "-"
+)
+
+// CPE is a CPE name. Its attributes are held unescaped, with the logical
+// values Any and NA.
+type CPE struct {
+	// Part is "a" for applications, "o" for operating systems and "h"
+	// for hardware.
+	Part      string
+	Vendor    string
+	Product   string
+	Version   string
+	Update    string
+	Edition   string
+	Language  string
+	SWEdition string
+	TargetSW  string
+	TargetHW  string
+	Other     string
+}
+
+// attrs returns pointers to the attributes of c, in the order of a CPE 2.3
+// formatted string.
+func (c *CPE) attrs() []*string {
+	return []*string{
+		&c.Part, &c.Vendor, &c.Product, &c.Version, &c.Update, &c.Edition,
+		&c.Language, &c.SWEdition, &c.TargetSW, &c.TargetHW, &c.Other,
+	}
+}
+
+// Parse parses a CPE 2.3 formatted string or a CPE 2.2 URI.
+func Parse(s string) (*CPE, error) {
+	switch {
+	case strings.HasPrefix(s, "cpe:2.3:"):
+		return parseFormatted(s)
+	case strings.HasPrefix(s, "cpe:/"):
+		return parseURI(s)
+	}
+	return nil, fmt.Errorf("%q: not a CPE name", s)
+}
+
+func parseFormatted(s string) (*CPE, error) {
+	comps := split(s[len("cpe:2.3:"):])
+	c := &CPE{}
+	attrs := c.attrs()
+	if len(comps) != len(attrs) {
+		return nil, fmt.Errorf("%q: got %d attributes, want %d", s, len(comps), len(attrs))
+	}
+	for i, comp := range comps {
+		v, err := unescape(comp)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", s, err)
+		}
+		*attrs[i] = v
+	}
+	if err := c.check(); err != nil {
+		return nil, fmt.Errorf("%q: %w", s, err)
+	}
+	return c, nil
+}
+
+// split splits a formatted string at the colons that are not escaped.
+func split(s string) []string {
+	var comps []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ':':
+			comps = append(comps, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(comps, s[start:])
+}
+
+// unescape returns the value of an attribute of a formatted string.
+func unescape(comp string) (string, error) {
+	switch comp {
+	case "":
+		return "", errors.New("empty attribute")
+	case Any, NA:
+		return comp, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(comp); i++ {
+		if comp[i] == '\\' {
+			if i+1 == len(comp) {
+				return "", fmt.Errorf("attribute %q ends with an escape", comp)
+			}
+			i++
+		}
+		b.WriteByte(comp[i])
+	}
+	return b.String(), nil
+}
+
+func parseURI(s string) (*CPE, error) {
+	comps := strings.Split(s[len("cpe:/"):], ":")
+	c := &CPE{}
+	attrs := c.attrs()
+	// A URI holds the first seven attributes; the others are Any.
+	if len(comps) > 7 {
+		return nil, fmt.Errorf("%q: got %d attributes, want at most 7", s, len(comps))
+	}
+	for i, a := range attrs {
+		*a = Any
+		if i >= len(comps) || comps[i] == "" {
+			continue
+		}
+		v, err := url.PathUnescape(comps[i])
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", s, err)
+		}
+		*a = v
+	}
+	if err := c.check(); err != nil {
+		return nil, fmt.Errorf("%q: %w", s, err)
+	}
+	return c, nil
+}
+
+func (c *CPE) check() error {
+	switch c.Part {
+	case "a", "o", "h", Any:
+		return nil
+	}
+	return fmt.Errorf("invalid part %q", c.Part)
+}
+
+// String returns c as a CPE 2.3 formatted string.
+func (c *CPE) String() string {
+	var b strings.Builder
+	b.WriteString("cpe:2.3")
+	for _, a := range c.attrs() {
+		b.WriteByte(':')
+		switch v := *a; v {
+		case "", Any:
+			b.WriteString(Any)
+		case NA:
+			b.WriteString(NA)
+		default:
+			for i := 0; i < len(v); i++ {
+				ch := v[i]
+				// Periods, hyphens and underscores go unquoted in
+				// formatted strings.
+				if !isWordChar(ch) && ch != '.' && ch != '-' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(ch)
+			}
+		}
+	}
+	return b.String()
+}
+
+func isWordChar(ch byte) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' || ch == '_'
+}
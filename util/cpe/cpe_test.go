@@ -0,0 +1,130 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cpe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		in   string
+		want CPE
+		str  string
+	}{
+		{
+			in: `cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*`,
+			want: CPE{Part: "a", Vendor: "apache", Product: "log4j", Version: "2.14.1",
+				Update: Any, Edition: Any, Language: Any, SWEdition: Any, TargetSW: Any, TargetHW: Any, Other: Any},
+			str: `cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*`,
+		},
+		{
+			in: `cpe:2.3:a:minimist_project:minimist:1.2.5:*:*:*:*:node.js:*:*`,
+			want: CPE{Part: "a", Vendor: "minimist_project", Product: "minimist", Version: "1.2.5",
+				Update: Any, Edition: Any, Language: Any, SWEdition: Any, TargetSW: "node.js", TargetHW: Any, Other: Any},
+			str: `cpe:2.3:a:minimist_project:minimist:1.2.5:*:*:*:*:node.js:*:*`,
+		},
+		{
+			in: `cpe:/a:djangoproject:django:4.2`,
+			want: CPE{Part: "a", Vendor: "djangoproject", Product: "django", Version: "4.2",
+				Update: Any, Edition: Any, Language: Any, SWEdition: Any, TargetSW: Any, TargetHW: Any, Other: Any},
+			str: `cpe:2.3:a:djangoproject:django:4.2:*:*:*:*:*:*:*`,
+		},
+	} {
+		got, err := Parse(c.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(*got, c.want) {
+			t.Errorf("Parse(%q):\n got %+v\nwant %+v", c.in, *got, c.want)
+		}
+		if s := got.String(); s != c.str {
+			t.Errorf("Parse(%q).String() = %q, want %q", c.in, s, c.str)
+		}
+	}
+	for _, in := range []string{
+		"",
+		"cpe:2.3:a:apache",
+		"cpe:2.3:x:apache:log4j:*:*:*:*:*:*:*:*",
+		"cpe:2.3:a:apache:log4j:1:*:*:*:*:*:*:*:*",
+		`cpe:2.3:a:apache:log4j:1\:*:*:*:*:*:*:*`,
+		"pkg:npm/lodash",
+	} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q): got no error", in)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	m := NewMapper()
+	m.Add("acme", "widget", Package{"NPM", "@acme/widget"})
+	m.Add(Any, "gadget", Package{"PYPI", "acme_gadget"})
+	for _, c := range []struct {
+		in   string
+		want []Candidate
+		purl []string
+	}{
+		{
+			in:   "cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*",
+			want: []Candidate{{Package{"MAVEN", "org.apache.logging.log4j:log4j-core"}, "2.14.1", Alias}},
+			purl: []string{"pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"},
+		},
+		{
+			in:   "cpe:2.3:a:golang:text:*:*:*:*:*:*:*:*",
+			want: []Candidate{{Package{"GO", "golang.org/x/text"}, "", Alias}},
+			purl: []string{"pkg:golang/golang.org/x/text"},
+		},
+		{
+			in:   "cpe:2.3:a:acme:widget:1.0:*:*:*:*:*:*:*",
+			want: []Candidate{{Package{"NPM", "@acme/widget"}, "1.0", Alias}},
+			purl: []string{"pkg:npm/%40acme/widget@1.0"},
+		},
+		{
+			in:   "cpe:2.3:a:someone:gadget:2:*:*:*:*:*:*:*",
+			want: []Candidate{{Package{"PYPI", "acme_gadget"}, "2", Alias}},
+			purl: []string{"pkg:pypi/acme-gadget@2"},
+		},
+		{
+			in:   "cpe:2.3:a:example:left_pad:1.3.0:*:*:*:*:node.js:*:*",
+			want: []Candidate{{Package{"NPM", "left-pad"}, "1.3.0", Guess}},
+			purl: []string{"pkg:npm/left-pad@1.3.0"},
+		},
+		{in: "cpe:2.3:a:example:thing:1:*:*:*:*:*:*:*"},
+		{in: "cpe:2.3:o:apache:log4j:1:*:*:*:*:*:*:*"},
+	} {
+		p, err := Parse(c.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := m.Map(p)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Map(%s):\n got %v\nwant %v", c.in, got, c.want)
+			continue
+		}
+		for i, cand := range got {
+			if purl := cand.Purl(); purl != c.purl[i] {
+				t.Errorf("Map(%s)[%d].Purl() = %q, want %q", c.in, i, purl, c.purl[i])
+			}
+		}
+	}
+	// Adding to a mapper must not change the defaults.
+	m.Add("apache", "log4j", Package{"MAVEN", "org.apache.logging.log4j:log4j-api"})
+	if n := len(DefaultAliases["apache:log4j"]); n != 1 {
+		t.Errorf("DefaultAliases changed: %d packages for apache:log4j", n)
+	}
+}
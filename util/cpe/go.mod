@@ -0,0 +1,11 @@
+module deps.dev/util/cpe
+
+go 1.23.4
+
+replace (
+	deps.dev/util/maven => ../maven
+	deps.dev/util/resolve => ../resolve
+	deps.dev/util/semver => ../semver
+)
+
+require deps.dev/util/resolve v0.0.0-20240611045547-af20eef0f1eb
@@ -0,0 +1,165 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cpe
+
+import (
+	"strings"
+
+	"deps.dev/util/resolve/purl"
+)
+
+// Package is a package of a deps.dev packaging system.
+type Package struct {
+	// System names the package's system as in the deps.dev API, such as
+	// NPM or MAVEN.
+	System string
+	// Name is the name of the package, as in the deps.dev API: Maven
+	// packages are named groupId:artifactId.
+	Name string
+}
+
+// Source is how a candidate was found.
+type Source int
+
+const (
+	// Alias is the source of candidates found in the alias table.
+	Alias Source = iota
+	// Guess is the source of candidates guessed from the product name and
+	// target software of a CPE.
+	Guess
+)
+
+func (s Source) String() string {
+	if s == Alias {
+		return "alias"
+	}
+	return "guess"
+}
+
+// Candidate is a package version that a CPE may name.
+type Candidate struct {
+	Package
+	// Version is the version named by the CPE, or empty if it names any
+	// version.
+	Version string
+	Source  Source
+}
+
+// Purl returns the package URL of the candidate, which names a package if
+// the candidate has no version, or an empty string if its system has no
+// package URL type.
+func (c Candidate) Purl() string {
+	return purl.Format(c.System, c.Name, c.Version)
+}
+
+// DefaultAliases holds the packages of some commonly seen vendor and product
+// pairs, keyed by vendor:product as they appear in the NVD's CPEs.
+var DefaultAliases = map[string][]Package{
+	"apache:commons_text":               {{"MAVEN", "org.apache.commons:commons-text"}},
+	"apache:log4j":                      {{"MAVEN", "org.apache.logging.log4j:log4j-core"}},
+	"apache:struts":                     {{"MAVEN", "org.apache.struts:struts2-core"}},
+	"djangoproject:django":              {{"PYPI", "django"}},
+	"expressjs:express":                 {{"NPM", "express"}},
+	"fasterxml:jackson-databind":        {{"MAVEN", "com.fasterxml.jackson.core:jackson-databind"}},
+	"golang:text":                       {{"GO", "golang.org/x/text"}},
+	"google:guava":                      {{"MAVEN", "com.google.guava:guava"}},
+	"jquery:jquery":                     {{"NPM", "jquery"}},
+	"lodash:lodash":                     {{"NPM", "lodash"}},
+	"minimist_project:minimist":         {{"NPM", "minimist"}},
+	"newtonsoft:json.net":               {{"NUGET", "Newtonsoft.Json"}},
+	"palletsprojects:flask":             {{"PYPI", "flask"}},
+	"palletsprojects:jinja":             {{"PYPI", "jinja2"}},
+	"python:requests":                   {{"PYPI", "requests"}},
+	"python:urllib3":                    {{"PYPI", "urllib3"}},
+	"rust-lang:regex":                   {{"CARGO", "regex"}},
+	"vmware:spring_framework":           {{"MAVEN", "org.springframework:spring-core"}},
+	"pivotal_software:spring_framework": {{"MAVEN", "org.springframework:spring-core"}},
+}
+
+// targetSystems maps the target software of CPEs to the systems whose
+// packages are named after the product.
+var targetSystems = map[string]string{
+	"node.js": "NPM",
+	"nodejs":  "NPM",
+	"npm":     "NPM",
+	"python":  "PYPI",
+	"pypi":    "PYPI",
+	"rust":    "CARGO",
+	"cargo":   "CARGO",
+	".net":    "NUGET",
+	"nuget":   "NUGET",
+}
+
+// Mapper maps CPEs to candidate packages.
+type Mapper struct {
+	aliases map[string][]Package
+}
+
+// NewMapper returns a Mapper using DefaultAliases.
+func NewMapper() *Mapper {
+	m := &Mapper{aliases: make(map[string][]Package)}
+	for k, pkgs := range DefaultAliases {
+		m.aliases[k] = append([]Package(nil), pkgs...)
+	}
+	return m
+}
+
+// Add records that the CPEs of the given vendor and product name the given
+// packages, in addition to any already recorded. The vendor may be Any, to
+// match the product of any vendor that has no alias of its own.
+func (m *Mapper) Add(vendor, product string, pkgs ...Package) {
+	k := aliasKey(vendor, product)
+	m.aliases[k] = append(m.aliases[k], pkgs...)
+}
+
+func aliasKey(vendor, product string) string {
+	return strings.ToLower(vendor) + ":" + strings.ToLower(product)
+}
+
+// Map returns the package versions the given CPE may name: those of the
+// alias table, or if there are none a package named after the product in
+// the ecosystem of its target software, if it has one. Only applications
+// are mapped. The version of the candidates is the version of the CPE, if
+// it names one; its update, such as a release candidate, is ignored.
+func (m *Mapper) Map(c *CPE) []Candidate {
+	if c.Part != "a" && c.Part != Any {
+		return nil
+	}
+	version := c.Version
+	if version == Any || version == NA {
+		version = ""
+	}
+	pkgs, ok := m.aliases[aliasKey(c.Vendor, c.Product)]
+	if !ok {
+		pkgs = m.aliases[aliasKey(Any, c.Product)]
+	}
+	var cands []Candidate
+	for _, p := range pkgs {
+		cands = append(cands, Candidate{Package: p, Version: version, Source: Alias})
+	}
+	if len(cands) > 0 {
+		return cands
+	}
+	sys, ok := targetSystems[strings.ToLower(c.TargetSW)]
+	if !ok || c.Product == Any || c.Product == NA {
+		return nil
+	}
+	name := c.Product
+	if sys != "NUGET" {
+		// CPE product names replace spaces and hyphens with underscores.
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	}
+	return []Candidate{{Package: Package{sys, name}, Version: version, Source: Guess}}
+}
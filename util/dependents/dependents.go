@@ -0,0 +1,254 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package dependents estimates the "blast radius" of a package version: how
+many packages depend on it, and which notable ones.
+
+The deps.dev API counts the dependents of a package version with its
+GetDependents method, but does not list them. Analyze reports the counts
+and, given a sample of candidate packages, such as the most popular
+packages of the system or the packages of an organization, finds which of
+them depend on the package by fetching their resolved dependency graphs.
+The dependents found are ranked by how many dependents they have in turn,
+so the ones whose own users are most exposed come first.
+*/
+package dependents
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// Report is the blast radius of a package version.
+type Report struct {
+	Version *pb.VersionKey
+	// Dependents, DirectDependents and IndirectDependents are the counts
+	// of packages depending on the version, as returned by GetDependents.
+	// They are derived from public packages only, so are indicative rather
+	// than exact.
+	Dependents, DirectDependents, IndirectDependents int
+	// Notable holds the candidates found to depend on the package, most
+	// exposed first: those depending on the exact version before those
+	// depending on another version, then by decreasing Dependents.
+	Notable []*Dependent
+	// Errors holds the candidates that could not be checked, with the
+	// reason. Candidates unknown to deps.dev are not included.
+	Errors []error
+}
+
+// Dependent is a candidate package version found to depend on the package.
+type Dependent struct {
+	Version *pb.VersionKey
+	// Resolved is the version of the package in the candidate's resolved
+	// dependency graph.
+	Resolved string
+	// Exact reports whether Resolved is the version being analyzed.
+	Exact bool
+	// Direct reports whether the candidate depends directly on the
+	// package.
+	Direct bool
+	// Path holds the names of the packages through which the candidate
+	// depends on the package, from the candidate down to the package.
+	Path []string
+	// Dependents is the number of packages depending on the candidate, as
+	// returned by GetDependents.
+	Dependents int
+}
+
+// Options configure Analyze.
+type Options struct {
+	// Concurrency is the maximum number of candidates checked at once.
+	// The default is 10.
+	Concurrency int
+}
+
+// Analyze reports the dependents of the given package version. Each
+// candidate is checked at its given version or, if it has none, at its
+// default version. If opts is nil, the defaults are used.
+func Analyze(ctx context.Context, c pb.InsightsClient, vk *pb.VersionKey, candidates []*pb.VersionKey, opts *Options) (*Report, error) {
+	d, err := c.GetDependents(ctx, &pb.GetDependentsRequest{VersionKey: vk})
+	if err != nil {
+		return nil, fmt.Errorf("dependents of %s: %w", describe(vk), err)
+	}
+	r := &Report{
+		Version:            vk,
+		Dependents:         int(d.GetDependentCount()),
+		DirectDependents:   int(d.GetDirectDependentCount()),
+		IndirectDependents: int(d.GetIndirectDependentCount()),
+	}
+
+	concurrency := 10
+	if opts != nil && opts.Concurrency > 0 {
+		concurrency = opts.Concurrency
+	}
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for _, cand := range candidates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			dep, err := check(ctx, c, vk, cand)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				r.Errors = append(r.Errors, err)
+			case dep != nil:
+				r.Notable = append(r.Notable, dep)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(r.Notable, func(a, b *Dependent) int {
+		if a.Exact != b.Exact {
+			if a.Exact {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(
+			cmp.Compare(b.Dependents, a.Dependents),
+			cmp.Compare(a.Version.GetName(), b.Version.GetName()),
+			cmp.Compare(a.Version.GetVersion(), b.Version.GetVersion()),
+		)
+	})
+	return r, nil
+}
+
+// check reports whether the candidate depends on the package of vk,
+// returning nil if it does not or is unknown.
+func check(ctx context.Context, c pb.InsightsClient, vk, cand *pb.VersionKey) (*Dependent, error) {
+	if cand.GetVersion() == "" {
+		v, err := defaultVersion(ctx, c, cand)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		cand = v
+	}
+	g, err := c.GetDependencies(ctx, &pb.GetDependenciesRequest{VersionKey: cand})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dependencies of %s: %w", describe(cand), err)
+	}
+	path, target := findPath(g, vk.GetSystem(), vk.GetName())
+	if path == nil {
+		return nil, nil
+	}
+	dep := &Dependent{
+		Version:  cand,
+		Resolved: target.GetVersion(),
+		Exact:    target.GetVersion() == vk.GetVersion(),
+		Direct:   len(path) == 2,
+		Path:     path,
+	}
+	d, err := c.GetDependents(ctx, &pb.GetDependentsRequest{VersionKey: cand})
+	switch {
+	case status.Code(err) == codes.NotFound:
+	case err != nil:
+		return nil, fmt.Errorf("dependents of %s: %w", describe(cand), err)
+	default:
+		dep.Dependents = int(d.GetDependentCount())
+	}
+	return dep, nil
+}
+
+// defaultVersion returns the key of the default version of the package of
+// pk, or nil if the package is unknown or has no default version.
+func defaultVersion(ctx context.Context, c pb.InsightsClient, pk *pb.VersionKey) (*pb.VersionKey, error) {
+	p, err := c.GetPackage(ctx, &pb.GetPackageRequest{
+		PackageKey: &pb.PackageKey{System: pk.GetSystem(), Name: pk.GetName()},
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("package %v %s: %w", pk.GetSystem(), pk.GetName(), err)
+	}
+	for _, v := range p.GetVersions() {
+		if v.GetIsDefault() {
+			return v.GetVersionKey(), nil
+		}
+	}
+	return nil, nil
+}
+
+// findPath returns the names of the packages on a shortest path from the
+// root of g to a node of the given package, and the key of that node, or
+// nil if the package is not in g. The root itself is not a match.
+func findPath(g *pb.Dependencies, system pb.System, name string) ([]string, *pb.VersionKey) {
+	nodes := g.GetNodes()
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	out := make([][]int, len(nodes))
+	for _, e := range g.GetEdges() {
+		if int(e.GetFromNode()) < len(nodes) && int(e.GetToNode()) < len(nodes) {
+			out[e.GetFromNode()] = append(out[e.GetFromNode()], int(e.GetToNode()))
+		}
+	}
+	prev := make([]int, len(nodes))
+	for i := range prev {
+		prev[i] = -1
+	}
+	prev[0] = 0
+	queue := []int{0}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if k := nodes[n].GetVersionKey(); n != 0 && k.GetSystem() == system && k.GetName() == name {
+			var path []string
+			for ; n != 0; n = prev[n] {
+				path = append(path, nodes[n].GetVersionKey().GetName())
+			}
+			path = append(path, nodes[0].GetVersionKey().GetName())
+			slices.Reverse(path)
+			return path, k
+		}
+		for _, m := range out[n] {
+			if prev[m] < 0 {
+				prev[m] = n
+				queue = append(queue, m)
+			}
+		}
+	}
+	return nil, nil
+}
+
+func describe(vk *pb.VersionKey) string {
+	return fmt.Sprintf("%v %s@%s", vk.GetSystem(), vk.GetName(), vk.GetVersion())
+}
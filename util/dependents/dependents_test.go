@@ -0,0 +1,142 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependents
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+func vk(name, version string) *pb.VersionKey {
+	return &pb.VersionKey{System: pb.System_NPM, Name: name, Version: version}
+}
+
+// graph returns a dependency graph with the given nodes, the first being
+// the root, and edges between the nodes with the given indexes.
+func graph(nodes []*pb.VersionKey, edges ...[2]uint32) *pb.Dependencies {
+	g := &pb.Dependencies{}
+	for _, n := range nodes {
+		g.Nodes = append(g.Nodes, &pb.Dependencies_Node{VersionKey: n})
+	}
+	for _, e := range edges {
+		g.Edges = append(g.Edges, &pb.Dependencies_Edge{FromNode: e[0], ToNode: e[1]})
+	}
+	return g
+}
+
+type fakeClient struct {
+	pb.InsightsClient
+	packages   map[string]*pb.Package
+	graphs     map[string]*pb.Dependencies
+	dependents map[string]uint32
+}
+
+func (c *fakeClient) GetPackage(ctx context.Context, req *pb.GetPackageRequest, opts ...grpc.CallOption) (*pb.Package, error) {
+	p, ok := c.packages[req.GetPackageKey().GetName()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such package")
+	}
+	return p, nil
+}
+
+func (c *fakeClient) GetDependencies(ctx context.Context, req *pb.GetDependenciesRequest, opts ...grpc.CallOption) (*pb.Dependencies, error) {
+	k := req.GetVersionKey()
+	if k.GetName() == "broken" {
+		return nil, status.Error(codes.Internal, "broken")
+	}
+	g, ok := c.graphs[k.GetName()+"@"+k.GetVersion()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such version")
+	}
+	return g, nil
+}
+
+func (c *fakeClient) GetDependents(ctx context.Context, req *pb.GetDependentsRequest, opts ...grpc.CallOption) (*pb.Dependents, error) {
+	k := req.GetVersionKey()
+	n := c.dependents[k.GetName()+"@"+k.GetVersion()]
+	return &pb.Dependents{DependentCount: n, DirectDependentCount: n / 2, IndirectDependentCount: n - n/2}, nil
+}
+
+func TestAnalyze(t *testing.T) {
+	c := &fakeClient{
+		packages: map[string]*pb.Package{
+			"app": {Versions: []*pb.Package_Version{
+				{VersionKey: vk("app", "1.0.0")},
+				{VersionKey: vk("app", "2.0.0"), IsDefault: true},
+			}},
+		},
+		graphs: map[string]*pb.Dependencies{
+			// app depends on lib indirectly, through mid.
+			"app@2.0.0": graph([]*pb.VersionKey{vk("app", "2.0.0"), vk("mid", "1.0.0"), vk("lib", "1.0.0")}, [2]uint32{0, 1}, [2]uint32{1, 2}),
+			// tool depends on another version of lib directly.
+			"tool@3.0.0": graph([]*pb.VersionKey{vk("tool", "3.0.0"), vk("lib", "0.9.0")}, [2]uint32{0, 1}),
+			// cli depends on lib directly.
+			"cli@1.0.0": graph([]*pb.VersionKey{vk("cli", "1.0.0"), vk("lib", "1.0.0")}, [2]uint32{0, 1}),
+			// other does not depend on lib.
+			"other@1.0.0": graph([]*pb.VersionKey{vk("other", "1.0.0"), vk("mid", "1.0.0")}, [2]uint32{0, 1}),
+		},
+		dependents: map[string]uint32{
+			"lib@1.0.0":  100,
+			"app@2.0.0":  5,
+			"tool@3.0.0": 50,
+			"cli@1.0.0":  10,
+		},
+	}
+	candidates := []*pb.VersionKey{
+		vk("app", ""),
+		vk("tool", "3.0.0"),
+		vk("cli", "1.0.0"),
+		vk("other", "1.0.0"),
+		vk("unknown", ""),
+		vk("broken", "1.0.0"),
+	}
+	r, err := Analyze(context.Background(), c, vk("lib", "1.0.0"), candidates, &Options{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Dependents != 100 || r.DirectDependents != 50 || r.IndirectDependents != 50 {
+		t.Errorf("got counts %d, %d, %d", r.Dependents, r.DirectDependents, r.IndirectDependents)
+	}
+	type summary struct {
+		Name       string
+		Resolved   string
+		Exact      bool
+		Direct     bool
+		Path       []string
+		Dependents int
+	}
+	var got []summary
+	for _, d := range r.Notable {
+		got = append(got, summary{d.Version.GetName(), d.Resolved, d.Exact, d.Direct, d.Path, d.Dependents})
+	}
+	want := []summary{
+		{"cli", "1.0.0", true, true, []string{"cli", "lib"}, 10},
+		{"app", "1.0.0", true, false, []string{"app", "mid", "lib"}, 5},
+		{"tool", "0.9.0", false, true, []string{"tool", "lib"}, 50},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("notable dependents:\ngot  %+v\nwant %+v", got, want)
+	}
+	if len(r.Errors) != 1 || status.Code(r.Errors[0]) != codes.Internal {
+		t.Errorf("got errors %v, want one for broken", r.Errors)
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package depsdev provides a convenient client for the deps.dev API.
+
+The Client wraps the generated gRPC client of the deps.dev/api/v3 package,
+taking care of the details that every user of the API would otherwise need
+to handle: it dials the API with TLS, limits the rate of requests and
+retries those that fail with transient errors, using the interceptors of
+deps.dev/api/clientutil, and reports missing data with ErrNotFound.
+Results are the generated protocol buffer messages.
+
+	c, err := depsdev.New(nil)
+	if err != nil {
+		// ...
+	}
+	defer c.Close()
+	v, err := c.Version(ctx, pb.System_NPM, "react", "18.2.0")
+*/
+package depsdev
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"deps.dev/api/clientutil"
+	pb "deps.dev/api/v3"
+)
+
+// ErrNotFound is returned, wrapped, by the methods of a Client when the
+// requested data does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Options configure a Client. The zero value of each field selects its
+// default.
+type Options struct {
+	// Address is the address of the API. The default is api.deps.dev:443.
+	Address string
+	// QPS is the maximum rate of requests per second. It is lowered while
+	// the API reports that the quota is exhausted, as by a
+	// clientutil.Throttle. The default is no limit.
+	QPS float64
+	// Retry configures the retrying of requests that fail with transient
+	// errors. If nil, the defaults of clientutil are used.
+	Retry *clientutil.RetryOptions
+}
+
+// Client is a client for the deps.dev API. It is safe for concurrent use.
+type Client struct {
+	c    pb.InsightsClient
+	conn *grpc.ClientConn // Nil if the gRPC client was provided.
+}
+
+// New returns a Client connected to the deps.dev API using TLS with the
+// system's root certificates. If opts is nil, the defaults are used. The
+// Client should be closed when no longer needed.
+func New(opts *Options) (*Client, error) {
+	certPool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("getting system cert pool: %w", err)
+	}
+	creds := credentials.NewClientTLSFromCert(certPool, "")
+	return dial(opts, grpc.WithTransportCredentials(creds))
+}
+
+// dial returns a Client connected to the API with the given dial options,
+// retrying and throttling its requests with the interceptors of clientutil.
+func dial(opts *Options, dialOpts ...grpc.DialOption) (*Client, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Address == "" {
+		o.Address = "api.deps.dev:443"
+	}
+	interceptors := []grpc.UnaryClientInterceptor{clientutil.UnaryRetryInterceptor(o.Retry)}
+	if o.QPS > 0 {
+		t := clientutil.NewThrottle(&clientutil.ThrottleOptions{RPS: o.QPS})
+		interceptors = append(interceptors, t.UnaryClientInterceptor())
+	}
+	dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(interceptors...))
+	conn, err := grpc.NewClient(o.Address, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", o.Address, err)
+	}
+	return &Client{c: pb.NewInsightsClient(conn), conn: conn}, nil
+}
+
+// NewFromClient returns a Client that makes its calls with the given gRPC
+// client. Retrying and limiting the rate of requests are left to the
+// client's connection, which may use the interceptors of clientutil.
+func NewFromClient(c pb.InsightsClient) *Client {
+	return &Client{c: c}
+}
+
+// Close closes the connection made by New. It does nothing for a Client
+// made by NewFromClient.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// call makes a request with f, wrapping its error with the given
+// description of what was requested. A NotFound error is replaced by
+// ErrNotFound.
+func call[T any](ctx context.Context, c *Client, what string, f func(context.Context) (T, error)) (T, error) {
+	resp, err := f(ctx)
+	switch {
+	case status.Code(err) == codes.NotFound:
+		return resp, fmt.Errorf("%s: %w", what, ErrNotFound)
+	case err != nil:
+		return resp, fmt.Errorf("%s: %w", what, err)
+	}
+	return resp, nil
+}
+
+// Package returns the package with the given name, including the list of
+// its versions.
+func (c *Client) Package(ctx context.Context, system pb.System, name string) (*pb.Package, error) {
+	return call(ctx, c, fmt.Sprintf("package %v %s", system, name), func(ctx context.Context) (*pb.Package, error) {
+		return c.c.GetPackage(ctx, &pb.GetPackageRequest{
+			PackageKey: &pb.PackageKey{System: system, Name: name},
+		})
+	})
+}
+
+func versionKey(system pb.System, name, version string) *pb.VersionKey {
+	return &pb.VersionKey{System: system, Name: name, Version: version}
+}
+
+func describe(vk *pb.VersionKey) string {
+	return fmt.Sprintf("%v %s@%s", vk.GetSystem(), vk.GetName(), vk.GetVersion())
+}
+
+// Version returns the given package version.
+func (c *Client) Version(ctx context.Context, system pb.System, name, version string) (*pb.Version, error) {
+	vk := versionKey(system, name, version)
+	return call(ctx, c, "version "+describe(vk), func(ctx context.Context) (*pb.Version, error) {
+		return c.c.GetVersion(ctx, &pb.GetVersionRequest{VersionKey: vk})
+	})
+}
+
+// Requirements returns the requirements of the given package version, as
+// declared by its manifest.
+func (c *Client) Requirements(ctx context.Context, system pb.System, name, version string) (*pb.Requirements, error) {
+	vk := versionKey(system, name, version)
+	return call(ctx, c, "requirements of "+describe(vk), func(ctx context.Context) (*pb.Requirements, error) {
+		return c.c.GetRequirements(ctx, &pb.GetRequirementsRequest{VersionKey: vk})
+	})
+}
+
+// Dependencies returns the resolved dependency graph of the given package
+// version.
+func (c *Client) Dependencies(ctx context.Context, system pb.System, name, version string) (*pb.Dependencies, error) {
+	vk := versionKey(system, name, version)
+	return call(ctx, c, "dependencies of "+describe(vk), func(ctx context.Context) (*pb.Dependencies, error) {
+		return c.c.GetDependencies(ctx, &pb.GetDependenciesRequest{VersionKey: vk})
+	})
+}
+
+// Advisory returns the security advisory with the given ID, such as
+// GHSA-2qrg-x229-3v8q.
+func (c *Client) Advisory(ctx context.Context, id string) (*pb.Advisory, error) {
+	return call(ctx, c, "advisory "+id, func(ctx context.Context) (*pb.Advisory, error) {
+		return c.c.GetAdvisory(ctx, &pb.GetAdvisoryRequest{AdvisoryKey: &pb.AdvisoryKey{Id: id}})
+	})
+}
+
+// Advisories returns the security advisories affecting the given package
+// version.
+func (c *Client) Advisories(ctx context.Context, vk *pb.VersionKey) ([]*pb.Advisory, error) {
+	v, err := c.Version(ctx, vk.GetSystem(), vk.GetName(), vk.GetVersion())
+	if err != nil {
+		return nil, err
+	}
+	advs := make([]*pb.Advisory, 0, len(v.GetAdvisoryKeys()))
+	for _, ak := range v.GetAdvisoryKeys() {
+		a, err := c.Advisory(ctx, ak.GetId())
+		if err != nil {
+			return nil, err
+		}
+		advs = append(advs, a)
+	}
+	return advs, nil
+}
+
+// projectID returns the project key of the project at the given URL, which
+// may include a scheme and a .git suffix, as in
+// https://github.com/google/go-cmp.git.
+func projectID(u string) string {
+	if p, err := url.Parse(u); err == nil && p.Host != "" {
+		u = p.Host + p.Path
+	}
+	u = strings.TrimSuffix(u, "/")
+	u = strings.TrimSuffix(u, ".git")
+	return strings.ToLower(u)
+}
+
+// Project returns the source code project at the given URL, such as
+// github.com/google/go-cmp.
+func (c *Client) Project(ctx context.Context, url string) (*pb.Project, error) {
+	id := projectID(url)
+	return call(ctx, c, "project "+id, func(ctx context.Context) (*pb.Project, error) {
+		return c.c.GetProject(ctx, &pb.GetProjectRequest{ProjectKey: &pb.ProjectKey{Id: id}})
+	})
+}
+
+// ProjectPackageVersions returns the package versions built from the
+// source code project at the given URL.
+func (c *Client) ProjectPackageVersions(ctx context.Context, url string) ([]*pb.ProjectPackageVersions_Version, error) {
+	id := projectID(url)
+	resp, err := call(ctx, c, "package versions of project "+id, func(ctx context.Context) (*pb.ProjectPackageVersions, error) {
+		return c.c.GetProjectPackageVersions(ctx, &pb.GetProjectPackageVersionsRequest{ProjectKey: &pb.ProjectKey{Id: id}})
+	})
+	return resp.GetVersions(), err
+}
+
+// Query returns the package versions having an artifact with the given
+// hash, such as the SHA-1 hash of a JAR file.
+func (c *Client) Query(ctx context.Context, hashType pb.HashType, hash []byte) ([]*pb.QueryResult_Result, error) {
+	resp, err := call(ctx, c, fmt.Sprintf("query %v %x", hashType, hash), func(ctx context.Context) (*pb.QueryResult, error) {
+		return c.c.Query(ctx, &pb.QueryRequest{Hash: &pb.Hash{Type: hashType, Value: hash}})
+	})
+	return resp.GetResults(), err
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsdev
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"deps.dev/api/clientutil"
+	pb "deps.dev/api/v3"
+)
+
+// fakeClient serves versions and advisories from maps, counting the calls.
+type fakeClient struct {
+	pb.InsightsClient
+	calls      int
+	versions   map[string]*pb.Version
+	advisories map[string]*pb.Advisory
+	projects   map[string]*pb.Project
+}
+
+func (f *fakeClient) GetVersion(ctx context.Context, req *pb.GetVersionRequest, opts ...grpc.CallOption) (*pb.Version, error) {
+	f.calls++
+	v, ok := f.versions[req.GetVersionKey().GetName()+"@"+req.GetVersionKey().GetVersion()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such version")
+	}
+	return v, nil
+}
+
+func (f *fakeClient) GetAdvisory(ctx context.Context, req *pb.GetAdvisoryRequest, opts ...grpc.CallOption) (*pb.Advisory, error) {
+	f.calls++
+	a, ok := f.advisories[req.GetAdvisoryKey().GetId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such advisory")
+	}
+	return a, nil
+}
+
+func (f *fakeClient) GetProject(ctx context.Context, req *pb.GetProjectRequest, opts ...grpc.CallOption) (*pb.Project, error) {
+	f.calls++
+	p, ok := f.projects[req.GetProjectKey().GetId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such project")
+	}
+	return p, nil
+}
+
+func newFake() *fakeClient {
+	return &fakeClient{
+		versions: map[string]*pb.Version{
+			"left-pad@1.0.0": {
+				VersionKey:   versionKey(pb.System_NPM, "left-pad", "1.0.0"),
+				AdvisoryKeys: []*pb.AdvisoryKey{{Id: "GHSA-1"}, {Id: "GHSA-2"}},
+			},
+		},
+		advisories: map[string]*pb.Advisory{
+			"GHSA-1": {AdvisoryKey: &pb.AdvisoryKey{Id: "GHSA-1"}, Title: "one"},
+			"GHSA-2": {AdvisoryKey: &pb.AdvisoryKey{Id: "GHSA-2"}, Title: "two"},
+		},
+		projects: map[string]*pb.Project{
+			"github.com/google/go-cmp": {ProjectKey: &pb.ProjectKey{Id: "github.com/google/go-cmp"}},
+		},
+	}
+}
+
+// serve serves s on an in-memory connection for the duration of the test,
+// and returns a Client for it, dialed as New dials the API.
+func serve(t *testing.T, s pb.InsightsServer, opts *Options) *Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterInsightsServer(srv, s)
+	go srv.Serve(lis)
+	o := *opts
+	o.Address = "passthrough:///bufconn"
+	c, err := dial(&o,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		srv.Stop()
+		t.Fatalf("connecting to test server: %v", err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		srv.Stop()
+	})
+	return c
+}
+
+// flakyServer serves any version, failing the first failures calls with
+// Unavailable.
+type flakyServer struct {
+	pb.UnimplementedInsightsServer
+	failures int
+	calls    atomic.Int32
+}
+
+func (s *flakyServer) GetVersion(ctx context.Context, req *pb.GetVersionRequest) (*pb.Version, error) {
+	if int(s.calls.Add(1)) <= s.failures {
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	return &pb.Version{VersionKey: req.GetVersionKey()}, nil
+}
+
+var fastRetries = &Options{
+	Retry: &clientutil.RetryOptions{MaxAttempts: 4, InitialBackoff: time.Millisecond},
+}
+
+func TestRetry(t *testing.T) {
+	s := &flakyServer{failures: 3}
+	c := serve(t, s, fastRetries)
+	v, err := c.Version(context.Background(), pb.System_NPM, "left-pad", "1.0.0")
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if got := v.GetVersionKey().GetName(); got != "left-pad" {
+		t.Errorf("Version: got %s, want left-pad", got)
+	}
+	if n := s.calls.Load(); n != 4 {
+		t.Errorf("got %d calls, want 4", n)
+	}
+
+	s = &flakyServer{failures: 4}
+	c = serve(t, s, fastRetries)
+	_, err = c.Version(context.Background(), pb.System_NPM, "left-pad", "1.0.0")
+	if status.Code(errors.Unwrap(err)) != codes.Unavailable {
+		t.Errorf("Version: got error %v, want Unavailable", err)
+	}
+	if n := s.calls.Load(); n != 4 {
+		t.Errorf("got %d calls, want 4", n)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	f := newFake()
+	c := NewFromClient(f)
+	_, err := c.Version(context.Background(), pb.System_NPM, "left-pad", "9.9.9")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Version: got error %v, want ErrNotFound", err)
+	}
+	if f.calls != 1 {
+		t.Errorf("got %d calls, want 1", f.calls)
+	}
+}
+
+func TestAdvisories(t *testing.T) {
+	c := NewFromClient(newFake())
+	advs, err := c.Advisories(context.Background(), versionKey(pb.System_NPM, "left-pad", "1.0.0"))
+	if err != nil {
+		t.Fatalf("Advisories: %v", err)
+	}
+	var got []string
+	for _, a := range advs {
+		got = append(got, a.GetTitle())
+	}
+	if len(got) != 2 || got[0] != "one" || got[1] != "two" {
+		t.Errorf("Advisories: got %q, want [one two]", got)
+	}
+}
+
+func TestProject(t *testing.T) {
+	c := NewFromClient(newFake())
+	for _, url := range []string{
+		"github.com/google/go-cmp",
+		"https://github.com/google/go-cmp",
+		"https://github.com/Google/go-cmp.git",
+		"github.com/google/go-cmp/",
+	} {
+		if _, err := c.Project(context.Background(), url); err != nil {
+			t.Errorf("Project(%q): %v", url, err)
+		}
+	}
+}
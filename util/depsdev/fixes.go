@@ -0,0 +1,147 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsdev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/semver"
+)
+
+// ErrNotAffected is returned by NearestFixes, and wrapped by Client.Fixes,
+// when the given version is outside the affected ranges of the advisory.
+var ErrNotAffected = errors.New("not affected")
+
+// NearestFixes returns the versions to upgrade to from the given version of
+// a package to fix an advisory, given the ranges of versions the advisory
+// affects and the versions of the package, all in the syntax of the
+// package's system. For each major version from that of the given version,
+// it returns the lowest stable version later than the given one that is
+// outside the affected ranges; the first is thus the minimal upgrade. As
+// the constraints of Go are single versions, v1.2.3 meaning >=1.2.3 <2.0.0,
+// the ranges of a Go advisory can only be whole major versions.
+// Versions that cannot be parsed are ignored. The versions are returned as
+// given, from lowest to highest.
+func NearestFixes(sys semver.System, version string, affected, versions []string) ([]string, error) {
+	current, err := sys.Parse(version)
+	if err != nil {
+		return nil, err
+	}
+	// The affected versions are the union of the affected ranges.
+	var set semver.Set
+	for i, r := range affected {
+		c, err := sys.ParseConstraint(r)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			set = c.Set()
+			continue
+		}
+		if err := set.Union(c.Set()); err != nil {
+			return nil, err
+		}
+	}
+	if len(affected) == 0 || !set.MatchVersion(current) {
+		return nil, ErrNotAffected
+	}
+
+	type candidate struct {
+		str string
+		v   *semver.Version
+	}
+	var candidates []candidate
+	for _, s := range versions {
+		v, err := sys.Parse(s)
+		if err != nil || v.IsPrerelease() || v.Compare(current) <= 0 || set.MatchVersion(v) {
+			continue
+		}
+		candidates = append(candidates, candidate{s, v})
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return a.v.Compare(b.v)
+	})
+
+	// Keep the nearest fix of each major version. Versions whose major
+	// version cannot be told apart from the last fix's, as with Maven
+	// versions that do not start with a number, are taken to share it.
+	var (
+		fixes []string
+		last  *semver.Version
+	)
+	for _, c := range candidates {
+		if last != nil {
+			if _, d := c.v.Difference(last); d != semver.DiffMajor {
+				continue
+			}
+		}
+		last = c.v
+		fixes = append(fixes, c.str)
+	}
+	return fixes, nil
+}
+
+// Fix is a version of a package that fixes an advisory.
+type Fix struct {
+	*PackageVersion
+	// Advisories holds the IDs of the other advisories that still affect
+	// the version.
+	Advisories []string
+}
+
+// Fixes returns the versions to upgrade to from the given package version
+// to fix an advisory affecting the given ranges of versions, in the syntax
+// of the package's system, as computed by NearestFixes, along with the
+// advisories still affecting each of them. It returns an empty list if
+// there is no fixed version.
+func (c *Client) Fixes(ctx context.Context, system pb.System, name, version string, affected []string) ([]*Fix, error) {
+	sys, ok := semverSystems[system]
+	if !ok {
+		return nil, fmt.Errorf("fixes of %v %s@%s: unsupported system", system, name, version)
+	}
+	pvs, err := c.PackageVersions(ctx, system, name, &VersionsOptions{StableOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(pvs))
+	byVersion := make(map[string]*PackageVersion, len(pvs))
+	for i, pv := range pvs {
+		strs[i] = pv.VersionKey.GetVersion()
+		byVersion[strs[i]] = pv
+	}
+	nearest, err := NearestFixes(sys, version, affected, strs)
+	if err != nil {
+		return nil, fmt.Errorf("fixes of %v %s@%s: %w", system, name, version, err)
+	}
+
+	fixes := make([]*Fix, 0, len(nearest))
+	for _, s := range nearest {
+		pv := byVersion[s]
+		v, err := c.Version(ctx, system, pv.VersionKey.GetName(), s)
+		if err != nil {
+			return nil, err
+		}
+		f := &Fix{PackageVersion: pv}
+		for _, ak := range v.GetAdvisoryKeys() {
+			f.Advisories = append(f.Advisories, ak.GetId())
+		}
+		fixes = append(fixes, f)
+	}
+	return fixes, nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsdev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/semver"
+)
+
+func TestNearestFixes(t *testing.T) {
+	for _, tc := range []struct {
+		sys      semver.System
+		version  string
+		affected []string
+		versions []string
+		want     string
+	}{
+		{
+			sys:      semver.NPM,
+			version:  "4.17.15",
+			affected: []string{"<4.17.21"},
+			versions: []string{"5.0.0", "4.17.21", "4.17.20", "4.17.15", "4.18.0", "5.0.0-rc.1", "6.1.0", "6.0.0"},
+			want:     "[4.17.21 5.0.0 6.0.0]",
+		},
+		{
+			sys:      semver.NPM,
+			version:  "1.2.0",
+			affected: []string{">=1.0.0 <1.2.3", ">=2.0.0 <2.1.0"},
+			versions: []string{"1.2.2", "1.2.3", "2.0.5", "2.1.0", "not-a-version"},
+			want:     "[1.2.3 2.1.0]",
+		},
+		{
+			sys:      semver.Go,
+			version:  "v1.2.0",
+			affected: []string{"v1.0.0"},
+			versions: []string{"v1.2.1", "v0.9.0", "v2.1.0", "v2.0.0", "v3.0.0-pre"},
+			want:     "[v2.0.0]",
+		},
+		{
+			sys:      semver.Maven,
+			version:  "2.9.10",
+			affected: []string{"[2.9.0,2.9.10.8)"},
+			versions: []string{"2.9.10.8", "2.9.10.1", "2.10.0", "2.10.0.pr1", "3.0.0-SNAPSHOT", "3.0.0"},
+			want:     "[2.9.10.8 3.0.0]",
+		},
+		{
+			sys:      semver.PyPI,
+			version:  "2.0",
+			affected: []string{"<2.2.28", ">=3.0,<3.2.13"},
+			versions: []string{"2.2.28", "3.2.12", "3.2.13", "4.0a1", "4.0"},
+			want:     "[2.2.28 3.2.13 4.0]",
+		},
+		{
+			sys:      semver.Cargo,
+			version:  "0.5.0",
+			affected: []string{"<0.5.3"},
+			versions: []string{"0.5.2", "0.5.3", "0.6.0", "1.0.0"},
+			want:     "[0.5.3 1.0.0]",
+		},
+		{
+			sys:      semver.NuGet,
+			version:  "12.0.1",
+			affected: []string{"[12.0.0, 13.0.1)"},
+			versions: []string{"12.0.3", "13.0.1", "13.0.2"},
+			want:     "[13.0.1]",
+		},
+		{
+			sys:      semver.NPM,
+			version:  "1.0.0",
+			affected: []string{"*"},
+			versions: []string{"1.0.0", "2.0.0"},
+			want:     "[]",
+		},
+	} {
+		got, err := NearestFixes(tc.sys, tc.version, tc.affected, tc.versions)
+		if err != nil {
+			t.Errorf("%v %s %q: %v", tc.sys, tc.version, tc.affected, err)
+			continue
+		}
+		if fmt.Sprint(got) != tc.want {
+			t.Errorf("%v %s %q: got %v, want %s", tc.sys, tc.version, tc.affected, got, tc.want)
+		}
+	}
+
+	if _, err := NearestFixes(semver.NPM, "1.0.0", []string{">=2.0.0"}, nil); !errors.Is(err, ErrNotAffected) {
+		t.Errorf("unaffected version: got %v, want ErrNotAffected", err)
+	}
+	if _, err := NearestFixes(semver.NPM, "1.0.0", nil, nil); !errors.Is(err, ErrNotAffected) {
+		t.Errorf("no ranges: got %v, want ErrNotAffected", err)
+	}
+	if _, err := NearestFixes(semver.NPM, "1.0.0", []string{"^^1"}, nil); err == nil {
+		t.Errorf("invalid range: got no error")
+	}
+}
+
+// fixesFake serves the versions of an npm package and their advisories.
+type fixesFake struct {
+	*fakeClient
+}
+
+func (f *fixesFake) GetPackage(ctx context.Context, req *pb.GetPackageRequest, opts ...grpc.CallOption) (*pb.Package, error) {
+	if req.GetPackageKey().GetName() != "pkg" {
+		return nil, status.Error(codes.NotFound, "no such package")
+	}
+	pkg := &pb.Package{PackageKey: req.GetPackageKey()}
+	for _, v := range []string{"1.0.0", "1.0.1", "1.1.0", "2.0.0-rc.1", "2.0.0"} {
+		pkg.Versions = append(pkg.Versions, &pb.Package_Version{
+			VersionKey: versionKey(pb.System_NPM, "pkg", v),
+		})
+	}
+	return pkg, nil
+}
+
+func TestFixes(t *testing.T) {
+	ctx := context.Background()
+	f := &fixesFake{&fakeClient{versions: map[string]*pb.Version{
+		"pkg@1.0.1": {AdvisoryKeys: []*pb.AdvisoryKey{{Id: "GHSA-2"}}},
+		"pkg@2.0.0": {},
+	}}}
+	c := NewFromClient(f)
+	got, err := c.Fixes(ctx, pb.System_NPM, "pkg", "1.0.0", []string{"<1.0.1"})
+	if err != nil {
+		t.Fatalf("Fixes: %v", err)
+	}
+	var fixes []string
+	for _, fix := range got {
+		fixes = append(fixes, fmt.Sprintf("%s%v", fix.Version, fix.Advisories))
+	}
+	if want := "[1.0.1[GHSA-2] 2.0.0[]]"; fmt.Sprint(fixes) != want {
+		t.Errorf("Fixes: got %v, want %s", fixes, want)
+	}
+
+	if _, err := c.Fixes(ctx, pb.System_NPM, "pkg", "1.1.0", []string{"<1.0.1"}); !errors.Is(err, ErrNotAffected) {
+		t.Errorf("unaffected version: got %v, want ErrNotAffected", err)
+	}
+	if _, err := c.Fixes(ctx, pb.System_NPM, "missing", "1.0.0", []string{"<1.0.1"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing package: got %v, want ErrNotFound", err)
+	}
+	if _, err := c.Fixes(ctx, pb.System_SYSTEM_UNSPECIFIED, "pkg", "1.0.0", []string{"<1.0.1"}); err == nil {
+		t.Errorf("unspecified system: got no error")
+	}
+}
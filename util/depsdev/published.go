@@ -0,0 +1,188 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsdev
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/semver"
+)
+
+// ProjectPackageVersionsLimit is the maximum number of package versions
+// returned by the GetProjectPackageVersions method of the API.
+const ProjectPackageVersionsLimit = 1500
+
+// PublishedOptions configure PublishedPackages. The zero value selects
+// every mapping returned by GetProjectPackageVersions.
+type PublishedOptions struct {
+	// AttestedOnly includes only the versions whose link to the project
+	// is backed by an attestation, such as SLSA provenance or a PyPI
+	// publish attestation, rather than by unverified metadata.
+	AttestedOnly bool
+	// Complete looks beyond the limit on the number of versions returned
+	// by GetProjectPackageVersions. If the limit is reached, every version
+	// of each package found is fetched with GetPackage and GetVersion to
+	// find the rest of the versions linked to the project. This makes a
+	// call for every version of those packages. Packages none of whose
+	// versions are among those returned cannot be found.
+	Complete bool
+}
+
+// PublishedPackage is a package with versions built from a project.
+type PublishedPackage struct {
+	System pb.System
+	Name   string
+	// Versions holds the versions linked to the project, ordered from
+	// lowest to highest by the version ordering of the package's system.
+	// Versions that cannot be parsed come last, ordered by version
+	// string, as do all the versions of systems the deps.dev/util/semver
+	// package does not support.
+	Versions []*pb.ProjectPackageVersions_Version
+}
+
+// PublishedPackages returns the packages published from the source code
+// project at the given URL, such as github.com/google/go-cmp, keyed by
+// system and ordered by name. If opts is nil, the defaults are used.
+func (c *Client) PublishedPackages(ctx context.Context, url string, opts *PublishedOptions) (map[pb.System][]*PublishedPackage, error) {
+	var o PublishedOptions
+	if opts != nil {
+		o = *opts
+	}
+	id := projectID(url)
+	versions, err := c.ProjectPackageVersions(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	type pkgKey struct {
+		system pb.System
+		name   string
+	}
+	pkgs := make(map[pkgKey]*PublishedPackage)
+	seen := make(map[string]bool) // Versions, as system/name@version.
+	add := func(v *pb.ProjectPackageVersions_Version) {
+		vk := v.GetVersionKey()
+		k := pkgKey{vk.GetSystem(), vk.GetName()}
+		p, ok := pkgs[k]
+		if !ok {
+			p = &PublishedPackage{System: k.system, Name: k.name}
+			pkgs[k] = p
+		}
+		seen[describe(vk)] = true
+		if o.AttestedOnly && !attested(v) {
+			return
+		}
+		p.Versions = append(p.Versions, v)
+	}
+	for _, v := range versions {
+		add(v)
+	}
+
+	if o.Complete && len(versions) >= ProjectPackageVersionsLimit {
+		// Visit the packages in order, so that the calls made do not
+		// depend on the order of map iteration.
+		keys := make([]pkgKey, 0, len(pkgs))
+		for k := range pkgs {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, func(a, b pkgKey) int {
+			return cmp.Or(cmp.Compare(a.system, b.system), cmp.Compare(a.name, b.name))
+		})
+		for _, k := range keys {
+			pkg, err := c.Package(ctx, k.system, k.name)
+			if err != nil {
+				return nil, err
+			}
+			for _, pv := range pkg.GetVersions() {
+				vk := pv.GetVersionKey()
+				if seen[describe(vk)] {
+					continue
+				}
+				v, err := c.Version(ctx, vk.GetSystem(), vk.GetName(), vk.GetVersion())
+				if errors.Is(err, ErrNotFound) {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				for _, rp := range v.GetRelatedProjects() {
+					if projectID(rp.GetProjectKey().GetId()) != id {
+						continue
+					}
+					add(&pb.ProjectPackageVersions_Version{
+						VersionKey:         vk,
+						RelationType:       rp.GetRelationType(),
+						RelationProvenance: rp.GetRelationProvenance(),
+					})
+					break
+				}
+			}
+		}
+	}
+
+	bySystem := make(map[pb.System][]*PublishedPackage)
+	for _, p := range pkgs {
+		if len(p.Versions) == 0 {
+			continue
+		}
+		sortVersions(p.System, p.Versions)
+		bySystem[p.System] = append(bySystem[p.System], p)
+	}
+	for _, ps := range bySystem {
+		slices.SortFunc(ps, func(a, b *PublishedPackage) int { return cmp.Compare(a.Name, b.Name) })
+	}
+	return bySystem, nil
+}
+
+// sortVersions sorts the versions of a package of the given system as
+// described by PublishedPackage.Versions.
+func sortVersions(system pb.System, versions []*pb.ProjectPackageVersions_Version) {
+	parsed := make(map[*pb.ProjectPackageVersions_Version]*semver.Version, len(versions))
+	if sys, ok := semverSystems[system]; ok {
+		for _, v := range versions {
+			if sv, err := sys.Parse(v.GetVersionKey().GetVersion()); err == nil {
+				parsed[v] = sv
+			}
+		}
+	}
+	slices.SortFunc(versions, func(a, b *pb.ProjectPackageVersions_Version) int {
+		va, vb := parsed[a], parsed[b]
+		switch {
+		case va != nil && vb != nil:
+			if c := va.Compare(vb); c != 0 {
+				return c
+			}
+		case va != nil:
+			return -1
+		case vb != nil:
+			return 1
+		}
+		return cmp.Compare(a.GetVersionKey().GetVersion(), b.GetVersionKey().GetVersion())
+	})
+}
+
+// attested reports whether a version's link to its project is backed by an
+// attestation.
+func attested(v *pb.ProjectPackageVersions_Version) bool {
+	switch v.GetRelationProvenance() {
+	case pb.ProjectRelationProvenance_SLSA_ATTESTATION, pb.ProjectRelationProvenance_PYPI_PUBLISH_ATTESTATION:
+		return true
+	}
+	return len(v.GetAttestations()) > 0 || len(v.GetSlsaProvenances()) > 0
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsdev
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	pb "deps.dev/api/v3"
+)
+
+// publishedFake serves the package versions of a project, returning at
+// most ProjectPackageVersionsLimit of them as the API does.
+type publishedFake struct {
+	*fakeClient
+	mappings []*pb.ProjectPackageVersions_Version
+	packages map[string]*pb.Package
+}
+
+func (f *publishedFake) GetProjectPackageVersions(ctx context.Context, req *pb.GetProjectPackageVersionsRequest, opts ...grpc.CallOption) (*pb.ProjectPackageVersions, error) {
+	return &pb.ProjectPackageVersions{Versions: f.mappings[:min(len(f.mappings), ProjectPackageVersionsLimit)]}, nil
+}
+
+func (f *publishedFake) GetPackage(ctx context.Context, req *pb.GetPackageRequest, opts ...grpc.CallOption) (*pb.Package, error) {
+	return f.packages[req.GetPackageKey().GetName()], nil
+}
+
+// newPublishedFake returns a fake for the project github.com/a/a, which
+// publishes n versions of the npm package big with SLSA provenance and one
+// version of the npm package small with unverified metadata. The versions
+// of big are all listed by GetPackage, and each has a related project.
+func newPublishedFake(n int) *publishedFake {
+	f := &publishedFake{
+		fakeClient: &fakeClient{versions: make(map[string]*pb.Version)},
+		packages: map[string]*pb.Package{
+			"big": {PackageKey: &pb.PackageKey{System: pb.System_NPM, Name: "big"}},
+		},
+	}
+	repo := &pb.ProjectKey{Id: "github.com/a/a"}
+	for i := 0; i < n; i++ {
+		vk := versionKey(pb.System_NPM, "big", fmt.Sprintf("1.0.%04d", i))
+		f.mappings = append(f.mappings, &pb.ProjectPackageVersions_Version{
+			VersionKey:         vk,
+			RelationType:       pb.ProjectRelationType_SOURCE_REPO,
+			RelationProvenance: pb.ProjectRelationProvenance_SLSA_ATTESTATION,
+		})
+		f.packages["big"].Versions = append(f.packages["big"].Versions, &pb.Package_Version{VersionKey: vk})
+		f.versions["big@"+vk.Version] = &pb.Version{
+			VersionKey: vk,
+			RelatedProjects: []*pb.Version_Project{{
+				ProjectKey:         repo,
+				RelationType:       pb.ProjectRelationType_SOURCE_REPO,
+				RelationProvenance: pb.ProjectRelationProvenance_SLSA_ATTESTATION,
+			}},
+		}
+	}
+	// Put small first, as the API would not, so that it is among the
+	// versions returned even when the limit is reached.
+	f.mappings = append([]*pb.ProjectPackageVersions_Version{{
+		VersionKey:         versionKey(pb.System_NPM, "small", "0.1.0"),
+		RelationType:       pb.ProjectRelationType_SOURCE_REPO,
+		RelationProvenance: pb.ProjectRelationProvenance_UNVERIFIED_METADATA,
+	}}, f.mappings...)
+	return f
+}
+
+func count(pkgs map[pb.System][]*PublishedPackage) map[string]int {
+	n := make(map[string]int)
+	for sys, ps := range pkgs {
+		for _, p := range ps {
+			n[fmt.Sprintf("%v/%s", sys, p.Name)] = len(p.Versions)
+		}
+	}
+	return n
+}
+
+func TestPublishedPackages(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		n    int
+		opts *PublishedOptions
+		want map[string]int
+	}{
+		{"all", 3, nil, map[string]int{"NPM/big": 3, "NPM/small": 1}},
+		{"attested", 3, &PublishedOptions{AttestedOnly: true}, map[string]int{"NPM/big": 3}},
+		{"truncated", 1600, nil, map[string]int{"NPM/big": 1499, "NPM/small": 1}},
+		{"complete", 1600, &PublishedOptions{Complete: true}, map[string]int{"NPM/big": 1600, "NPM/small": 1}},
+	} {
+		c := NewFromClient(newPublishedFake(tc.n))
+		got, err := c.PublishedPackages(ctx, "https://github.com/a/a", tc.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if g := count(got); fmt.Sprint(g) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, g, tc.want)
+		}
+	}
+
+	// Versions are in version order, unparseable ones last.
+	f := newPublishedFake(3)
+	for _, v := range []string{"0.10.0", "not-a-version", "0.9.0"} {
+		f.mappings = append(f.mappings, &pb.ProjectPackageVersions_Version{
+			VersionKey:         versionKey(pb.System_NPM, "small", v),
+			RelationType:       pb.ProjectRelationType_SOURCE_REPO,
+			RelationProvenance: pb.ProjectRelationProvenance_UNVERIFIED_METADATA,
+		})
+	}
+	c := NewFromClient(f)
+	got, err := c.PublishedPackages(ctx, "github.com/a/a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"[1.0.0000 1.0.0001 1.0.0002]", "[0.1.0 0.9.0 0.10.0 not-a-version]"} {
+		p := got[pb.System_NPM][i]
+		var versions []string
+		for _, v := range p.Versions {
+			versions = append(versions, v.GetVersionKey().GetVersion())
+		}
+		if fmt.Sprint(versions) != want {
+			t.Errorf("versions of %s: got %v, want %s", p.Name, versions, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsdev
+
+import (
+	"context"
+	"sync"
+
+	pb "deps.dev/api/v3"
+)
+
+// HashResult holds the package versions having an artifact with a given
+// hash, or the error of the query for them.
+type HashResult struct {
+	Results []*pb.QueryResult_Result
+	Err     error
+}
+
+// QueryBatch queries the package versions having an artifact with each of
+// the given hashes, as Query does, making up to concurrency requests at
+// once; their rate is limited by the QPS option of a Client made by New.
+// Repeated hashes are only queried once. The results are keyed by hash,
+// converted to a string, and hold the error of each query, if any.
+func (c *Client) QueryBatch(ctx context.Context, hashType pb.HashType, hashes [][]byte, concurrency int) map[string]*HashResult {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]*HashResult, len(hashes))
+		work    = make(chan string)
+	)
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for h := range work {
+				res, err := c.Query(ctx, hashType, []byte(h))
+				mu.Lock()
+				results[h] = &HashResult{Results: res, Err: err}
+				mu.Unlock()
+			}
+		}()
+	}
+	seen := make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		if seen[string(h)] {
+			continue
+		}
+		seen[string(h)] = true
+		work <- string(h)
+	}
+	close(work)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsdev
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3"
+)
+
+// queryFake serves the package versions having an artifact with the hash
+// "jar", and counts the queries for each hash and the queries in flight.
+type queryFake struct {
+	pb.UnimplementedInsightsServer
+
+	mu          sync.Mutex
+	calls       map[string]int
+	inFlight    int
+	maxInFlight int
+}
+
+func (f *queryFake) Query(ctx context.Context, req *pb.QueryRequest) (*pb.QueryResult, error) {
+	h := string(req.GetHash().GetValue())
+	f.mu.Lock()
+	f.calls[h]++
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	f.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	f.mu.Lock()
+	f.inFlight--
+	f.mu.Unlock()
+	if h != "jar" {
+		return nil, status.Error(codes.NotFound, "no such artifact")
+	}
+	return &pb.QueryResult{Results: []*pb.QueryResult_Result{{
+		Version: &pb.Version{VersionKey: versionKey(pb.System_MAVEN, "g:a", "1.0")},
+	}}}, nil
+}
+
+func TestQueryBatch(t *testing.T) {
+	f := &queryFake{calls: make(map[string]int)}
+	c := serve(t, f, &Options{QPS: 100})
+	hashes := [][]byte{[]byte("jar"), []byte("a"), []byte("jar"), []byte("b"), []byte("c"), []byte("d")}
+	start := time.Now()
+	results := c.QueryBatch(context.Background(), pb.HashType_SHA1, hashes, 2)
+	// The first query is immediate and the 4 others 10ms apart.
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("5 queries at 100 QPS took %v, want at least 40ms", d)
+	}
+	if f.maxInFlight > 2 {
+		t.Errorf("got %d concurrent queries, want at most 2", f.maxInFlight)
+	}
+
+	// Each distinct hash is queried once, and has its own result.
+	if len(results) != 5 {
+		t.Errorf("got %d results, want 5", len(results))
+	}
+	for h, n := range f.calls {
+		if n != 1 {
+			t.Errorf("hash %q: queried %d times, want once", h, n)
+		}
+	}
+	r := results["jar"]
+	if r == nil || r.Err != nil || len(r.Results) != 1 {
+		t.Fatalf(`results["jar"] = %+v, want one result`, r)
+	}
+	if got := r.Results[0].GetVersion().GetVersionKey().GetName(); got != "g:a" {
+		t.Errorf(`results["jar"]: got package %s, want g:a`, got)
+	}
+	for _, h := range []string{"a", "b", "c", "d"} {
+		if r := results[h]; r == nil || !errors.Is(r.Err, ErrNotFound) {
+			t.Errorf("results[%q] = %+v, want ErrNotFound", h, r)
+		}
+	}
+}
+
+func TestQueryBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := serve(t, &queryFake{calls: make(map[string]int)}, &Options{QPS: 1})
+	results := c.QueryBatch(ctx, pb.HashType_SHA1, [][]byte{[]byte("jar"), []byte("a")}, 0)
+	for _, h := range []string{"jar", "a"} {
+		if r := results[h]; r == nil || !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("results[%q] = %+v, want context.Canceled", h, r)
+		}
+	}
+}
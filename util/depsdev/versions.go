@@ -0,0 +1,120 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsdev
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/semver"
+)
+
+// VersionsOptions select the versions returned by PackageVersions. The zero
+// value selects every version.
+type VersionsOptions struct {
+	// StableOnly excludes prerelease versions, as defined by the
+	// package's system.
+	StableOnly bool
+	// Since excludes versions published before the given time, and
+	// versions whose publication time is not known. It is ignored if
+	// zero.
+	Since time.Time
+	// Constraint excludes versions not matched by the given version
+	// constraint, in the syntax of the package's system, such as ^1.2.0
+	// for npm or [1.0,2.0) for Maven. It is ignored if empty.
+	Constraint string
+}
+
+// PackageVersion is a version of a package, parsed according to the rules
+// of its system.
+type PackageVersion struct {
+	VersionKey *pb.VersionKey
+	Version    *semver.Version
+	// PublishedAt is the time the version was published, or zero if it
+	// is not known.
+	PublishedAt time.Time
+	IsDefault   bool
+}
+
+// semverSystems maps the systems of the API to those of the semver package.
+var semverSystems = map[pb.System]semver.System{
+	pb.System_GO:    semver.Go,
+	pb.System_NPM:   semver.NPM,
+	pb.System_CARGO: semver.Cargo,
+	pb.System_MAVEN: semver.Maven,
+	pb.System_PYPI:  semver.PyPI,
+	pb.System_NUGET: semver.NuGet,
+}
+
+// PackageVersions returns the versions of the given package selected by
+// opts, ordered from lowest to highest by the version ordering of the
+// package's system rather than by version string or publication time.
+// Versions that cannot be parsed are omitted. If opts is nil, every version
+// is returned.
+func (c *Client) PackageVersions(ctx context.Context, system pb.System, name string, opts *VersionsOptions) ([]*PackageVersion, error) {
+	var o VersionsOptions
+	if opts != nil {
+		o = *opts
+	}
+	sys, ok := semverSystems[system]
+	if !ok {
+		return nil, fmt.Errorf("versions of %v %s: unsupported system", system, name)
+	}
+	var constraint *semver.Constraint
+	if o.Constraint != "" {
+		var err error
+		constraint, err = sys.ParseConstraint(o.Constraint)
+		if err != nil {
+			return nil, fmt.Errorf("versions of %v %s: %w", system, name, err)
+		}
+	}
+	pkg, err := c.Package(ctx, system, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []*PackageVersion
+	for _, pv := range pkg.GetVersions() {
+		v, err := sys.Parse(pv.GetVersionKey().GetVersion())
+		if err != nil {
+			continue
+		}
+		var published time.Time
+		if pv.GetPublishedAt() != nil {
+			published = pv.GetPublishedAt().AsTime()
+		}
+		switch {
+		case o.StableOnly && v.IsPrerelease():
+			continue
+		case !o.Since.IsZero() && (published.IsZero() || published.Before(o.Since)):
+			continue
+		case constraint != nil && !constraint.MatchVersion(v):
+			continue
+		}
+		versions = append(versions, &PackageVersion{
+			VersionKey:  pv.GetVersionKey(),
+			Version:     v,
+			PublishedAt: published,
+			IsDefault:   pv.GetIsDefault(),
+		})
+	}
+	slices.SortStableFunc(versions, func(a, b *PackageVersion) int {
+		return a.Version.Compare(b.Version)
+	})
+	return versions, nil
+}
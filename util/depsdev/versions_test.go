@@ -0,0 +1,108 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package depsdev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "deps.dev/api/v3"
+)
+
+// versionsFake serves a single npm package whose versions are listed out
+// of order, as neither string nor publication order matches version order.
+type versionsFake struct {
+	*fakeClient
+}
+
+func (f *versionsFake) GetPackage(ctx context.Context, req *pb.GetPackageRequest, opts ...grpc.CallOption) (*pb.Package, error) {
+	if req.GetPackageKey().GetName() != "pkg" {
+		return nil, status.Error(codes.NotFound, "no such package")
+	}
+	day := func(d int) *timestamppb.Timestamp {
+		return timestamppb.New(time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC))
+	}
+	pkg := &pb.Package{PackageKey: req.GetPackageKey()}
+	for _, v := range []struct {
+		version   string
+		published *timestamppb.Timestamp
+		isDefault bool
+	}{
+		{"1.10.0", day(2), false},
+		{"1.9.0", day(5), false}, // A backport, published later.
+		{"2.0.0-rc.1", day(6), false},
+		{"1.2.0", nil, false},
+		{"not-a-version", day(7), false},
+		{"2.0.0", day(8), true},
+	} {
+		pkg.Versions = append(pkg.Versions, &pb.Package_Version{
+			VersionKey:  versionKey(pb.System_NPM, "pkg", v.version),
+			PublishedAt: v.published,
+			IsDefault:   v.isDefault,
+		})
+	}
+	return pkg, nil
+}
+
+func TestPackageVersions(t *testing.T) {
+	ctx := context.Background()
+	c := NewFromClient(&versionsFake{&fakeClient{}})
+	for _, tc := range []struct {
+		name string
+		opts *VersionsOptions
+		want string
+	}{
+		{"all", nil, "[1.2.0 1.9.0 1.10.0 2.0.0-rc.1 2.0.0*]"},
+		{"stable", &VersionsOptions{StableOnly: true}, "[1.2.0 1.9.0 1.10.0 2.0.0*]"},
+		{"since", &VersionsOptions{Since: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)}, "[1.9.0 2.0.0-rc.1 2.0.0*]"},
+		{"constraint", &VersionsOptions{Constraint: "^1.5.0"}, "[1.9.0 1.10.0]"},
+		{"combined", &VersionsOptions{StableOnly: true, Constraint: ">=1.9.0"}, "[1.9.0 1.10.0 2.0.0*]"},
+	} {
+		got, err := c.PackageVersions(ctx, pb.System_NPM, "pkg", tc.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var versions []string
+		for _, v := range got {
+			s := v.Version.String()
+			if v.IsDefault {
+				s += "*"
+			}
+			versions = append(versions, s)
+		}
+		if fmt.Sprint(versions) != tc.want {
+			t.Errorf("%s: got %v, want %s", tc.name, versions, tc.want)
+		}
+	}
+
+	if _, err := c.PackageVersions(ctx, pb.System_NPM, "pkg", &VersionsOptions{Constraint: "^^1"}); err == nil {
+		t.Errorf("invalid constraint: got no error")
+	}
+	if _, err := c.PackageVersions(ctx, pb.System_SYSTEM_UNSPECIFIED, "pkg", nil); err == nil {
+		t.Errorf("unspecified system: got no error")
+	}
+	if _, err := c.PackageVersions(ctx, pb.System_NPM, "missing", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing package: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package gomod converts the contents of go.mod and go.sum files to resolve
+requirements and version keys, named as in deps.dev: modules by their
+unescaped path, keeping its case, and versions in their canonical form,
+keeping the +incompatible suffix.
+
+ImportResolver finds the modules providing packages, resolving vanity
+import paths such as k8s.io/api/core/v1 through their go-import meta tags.
+*/
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+// VersionKey returns the key of the given module version. The path and
+// version may be escaped, as in module proxy URLs and the module cache,
+// and the version is canonicalized, such as "v1.2" to "v1.2.0". An error
+// is returned if the path or version is invalid, or if the version does
+// not match the major version suffix of the path.
+func VersionKey(path, version string) (resolve.VersionKey, error) {
+	p, v := path, version
+	// Escaped paths and versions mark upper case letters with "!", and
+	// are otherwise lower case.
+	if strings.Contains(p, "!") {
+		var err error
+		if p, err = module.UnescapePath(p); err != nil {
+			return resolve.VersionKey{}, err
+		}
+	}
+	if strings.Contains(v, "!") {
+		var err error
+		if v, err = module.UnescapeVersion(v); err != nil {
+			return resolve.VersionKey{}, err
+		}
+	}
+	canonical := module.CanonicalVersion(v)
+	if canonical == "" {
+		return resolve.VersionKey{}, fmt.Errorf("%s: invalid version %q", p, v)
+	}
+	if err := module.Check(p, canonical); err != nil {
+		return resolve.VersionKey{}, err
+	}
+	return resolve.VersionKey{
+		PackageKey: resolve.PackageKey{
+			System: resolve.Go,
+			Name:   p,
+		},
+		VersionType: resolve.Concrete,
+		Version:     canonical,
+	}, nil
+}
+
+// ParseMod parses the given go.mod and returns the requirements of the
+// module. The replace and exclude directives of the file are applied:
+// requirements replaced by another module version are returned on that
+// module version, keeping the original path in the KnownAs attribute, and
+// those replaced by a directory are left out. Indirect requirements are
+// included, as they take part in the selection of versions.
+func ParseMod(filename string, data []byte) ([]resolve.RequirementVersion, error) {
+	return parseMod(filename, data, nil)
+}
+
+// ParseModPositions is like ParseMod, and also returns the positions of
+// the require directives declaring the requirements, keyed by the
+// VersionKeys of the requirements returned.
+func ParseModPositions(filename string, data []byte) ([]resolve.RequirementVersion, resolve.Positions, error) {
+	ps := make(resolve.Positions)
+	reqs, err := parseMod(filename, data, ps)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reqs, ps, nil
+}
+
+// parseMod implements ParseMod, recording the positions of the
+// requirements in ps if it is not nil.
+func parseMod(filename string, data []byte, ps resolve.Positions) ([]resolve.RequirementVersion, error) {
+	f, err := modfile.Parse(filename, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[module.Version]bool)
+	for _, e := range f.Exclude {
+		excluded[e.Mod] = true
+	}
+	var reqs []resolve.RequirementVersion
+	for _, r := range f.Require {
+		if excluded[r.Mod] {
+			continue
+		}
+		mod, t := r.Mod, dep.NewType()
+		if rep, ok := replacement(f.Replace, r.Mod); ok {
+			if rep.Version == "" {
+				// Replaced by a directory.
+				continue
+			}
+			mod = rep
+			t.AddAttr(dep.KnownAs, r.Mod.Path)
+		}
+		vk, err := VersionKey(mod.Path, mod.Version)
+		if err != nil {
+			return nil, err
+		}
+		vk.VersionType = resolve.Requirement
+		if ps != nil && r.Syntax != nil {
+			ps.Add(vk, resolve.Position{
+				File:   filename,
+				Line:   r.Syntax.Start.Line,
+				Column: r.Syntax.Start.LineRune,
+			})
+		}
+		reqs = append(reqs, resolve.RequirementVersion{VersionKey: vk, Type: t})
+	}
+	return reqs, nil
+}
+
+// replacement returns the replacement of the given module version, if
+// any. A replacement of the specific version takes precedence over that
+// of all the versions of the module.
+func replacement(reps []*modfile.Replace, mod module.Version) (module.Version, bool) {
+	var (
+		rep   module.Version
+		found bool
+	)
+	for _, r := range reps {
+		if r.Old.Path != mod.Path {
+			continue
+		}
+		if r.Old.Version == mod.Version {
+			return r.New, true
+		}
+		if r.Old.Version == "" {
+			rep, found = r.New, true
+		}
+	}
+	return rep, found
+}
+
+// ParseSum parses the given go.sum and returns the module versions it
+// holds the content of, sorted and without duplicates. The versions whose
+// go.mod only is checked are left out, as they are only used to select
+// the versions of the build.
+func ParseSum(data []byte) ([]resolve.VersionKey, error) {
+	return parseSum("", data, nil)
+}
+
+// ParseSumPositions is like ParseSum, and also returns the positions of
+// the lines of the given go.sum holding the content of each version.
+func ParseSumPositions(filename string, data []byte) ([]resolve.VersionKey, resolve.Positions, error) {
+	ps := make(resolve.Positions)
+	vks, err := parseSum(filename, data, ps)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vks, ps, nil
+}
+
+// parseSum implements ParseSum, recording the positions of the versions
+// in ps if it is not nil.
+func parseSum(filename string, data []byte, ps resolve.Positions) ([]resolve.VersionKey, error) {
+	seen := make(map[resolve.VersionKey]bool)
+	var vks []resolve.VersionKey
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("go.sum:%d: malformed line", n)
+		}
+		if strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		vk, err := VersionKey(fields[0], fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("go.sum:%d: %w", n, err)
+		}
+		if !seen[vk] {
+			seen[vk] = true
+			vks = append(vks, vk)
+			if ps != nil {
+				ps.Add(vk, resolve.Position{File: filename, Line: n, Column: 1})
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(vks, func(i, j int) bool { return vks[i].Less(vks[j]) })
+	return vks, nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+func TestVersionKey(t *testing.T) {
+	for _, test := range []struct {
+		path, version string
+		wantName      string
+		wantVersion   string
+		wantErr       bool
+	}{
+		{"github.com/google/go-cmp", "v0.6.0", "github.com/google/go-cmp", "v0.6.0", false},
+		{"github.com/!azure/azure-sdk-for-go", "v68.0.0+incompatible", "github.com/Azure/azure-sdk-for-go", "v68.0.0+incompatible", false},
+		{"github.com/Azure/go-autorest", "v14.2.0+incompatible", "github.com/Azure/go-autorest", "v14.2.0+incompatible", false},
+		{"golang.org/x/mod", "v0.22", "golang.org/x/mod", "v0.22.0", false},
+		{"golang.org/x/mod", "v0.0.0-20240101000000-abcdefabcdef", "golang.org/x/mod", "v0.0.0-20240101000000-abcdefabcdef", false},
+		{"example.com/m/v2", "v2.1.0", "example.com/m/v2", "v2.1.0", false},
+		{"gopkg.in/yaml.v3", "v3.0.1", "gopkg.in/yaml.v3", "v3.0.1", false},
+		// The major version does not match the path.
+		{"example.com/m/v2", "v3.0.0", "", "", true},
+		{"example.com/m", "v2.0.0", "", "", true},
+		{"example.com/m", "latest", "", "", true},
+		{"Example.com/m", "v1.0.0", "", "", true},
+	} {
+		got, err := VersionKey(test.path, test.version)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("VersionKey(%s, %s): got %v, want error", test.path, test.version, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("VersionKey(%s, %s): %v", test.path, test.version, err)
+			continue
+		}
+		want := resolve.VersionKey{
+			PackageKey:  resolve.PackageKey{System: resolve.Go, Name: test.wantName},
+			VersionType: resolve.Concrete,
+			Version:     test.wantVersion,
+		}
+		if got != want {
+			t.Errorf("VersionKey(%s, %s): got %v, want %v", test.path, test.version, got, want)
+		}
+	}
+}
+
+func TestParseMod(t *testing.T) {
+	got, err := ParseMod("go.mod", []byte(`
+module example.com/app
+
+go 1.22
+
+require (
+	github.com/Azure/go-autorest v14.2.0+incompatible
+	github.com/google/go-cmp v0.6.0
+	golang.org/x/mod v0.22.0 // indirect
+	example.com/local v1.0.0
+	example.com/old v1.5.0
+	example.com/excluded v1.0.0
+)
+
+replace example.com/local => ../local
+
+replace example.com/old v1.5.0 => example.com/fork v1.5.1
+
+exclude example.com/excluded v1.0.0
+`))
+	if err != nil {
+		t.Fatalf("ParseMod: %v", err)
+	}
+	req := func(name, version string, t dep.Type) resolve.RequirementVersion {
+		return resolve.RequirementVersion{
+			VersionKey: resolve.VersionKey{
+				PackageKey:  resolve.PackageKey{System: resolve.Go, Name: name},
+				VersionType: resolve.Requirement,
+				Version:     version,
+			},
+			Type: t,
+		}
+	}
+	want := []resolve.RequirementVersion{
+		req("github.com/Azure/go-autorest", "v14.2.0+incompatible", dep.NewType()),
+		req("github.com/google/go-cmp", "v0.6.0", dep.NewType()),
+		req("golang.org/x/mod", "v0.22.0", dep.NewType()),
+		req("example.com/fork", "v1.5.1", dep.NewBuilder().KnownAs("example.com/old").Type()),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseMod (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseMod("go.mod", []byte("require example.com/m v2.0.0\n")); err == nil {
+		t.Errorf("ParseMod with an invalid version: got no error")
+	}
+}
+
+func TestParseSum(t *testing.T) {
+	got, err := ParseSum([]byte(`
+github.com/!burnt!sushi/toml v1.4.0 h1:kuoIxZQy2WRRk1pttg9asf+WVv6tWQuBNVmK8+nqPr0=
+github.com/!burnt!sushi/toml v1.4.0/go.mod h1:ukJfTF/6rtPPRCnwkur4qwRxa8vTRFBF0uk2lLoLwho=
+github.com/google/go-cmp v0.5.9/go.mod h1:17dUlkBOakJ0+DkrSSNjCkIjxS6bF9zb3elmeNGIjoY=
+github.com/google/go-cmp v0.6.0 h1:ofyhxvXcZhMsU5ulbFiLKl/XBFqE1GSq7atu8tAmTRI=
+github.com/google/go-cmp v0.6.0/go.mod h1:17dUlkBOakJ0+DkrSSNjCkIjxS6bF9zb3elmeNGIjoY=
+`))
+	if err != nil {
+		t.Fatalf("ParseSum: %v", err)
+	}
+	vk := func(name, version string) resolve.VersionKey {
+		return resolve.VersionKey{
+			PackageKey:  resolve.PackageKey{System: resolve.Go, Name: name},
+			VersionType: resolve.Concrete,
+			Version:     version,
+		}
+	}
+	want := []resolve.VersionKey{
+		vk("github.com/BurntSushi/toml", "v1.4.0"),
+		vk("github.com/google/go-cmp", "v0.6.0"),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseSum (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseSum([]byte("github.com/google/go-cmp v0.6.0\n")); err == nil {
+		t.Errorf("ParseSum of a malformed line: got no error")
+	}
+}
+
+func TestPositions(t *testing.T) {
+	mod := "module example.com/app\n\nrequire github.com/google/go-cmp v0.6.0\n\nrequire (\n\tgolang.org/x/mod v0.22.0\n\texample.com/old v1.5.0\n)\n\nreplace example.com/old => example.com/fork v1.5.1\n"
+	reqs, ps, err := ParseModPositions("app/go.mod", []byte(mod))
+	if err != nil {
+		t.Fatalf("ParseModPositions: %v", err)
+	}
+	var got []string
+	for _, r := range reqs {
+		p, _ := ps.Of(r.VersionKey)
+		got = append(got, r.Name+" "+p.String())
+	}
+	want := []string{
+		"github.com/google/go-cmp app/go.mod:3:1",
+		"golang.org/x/mod app/go.mod:6:2",
+		// The position of a replaced requirement is that of the
+		// require directive, not of the replace directive.
+		"example.com/fork app/go.mod:7:2",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseModPositions (-want +got):\n%s", diff)
+	}
+
+	sum := "golang.org/x/mod v0.22.0/go.mod h1:x=\ngolang.org/x/mod v0.22.0 h1:y=\n"
+	vks, ps, err := ParseSumPositions("go.sum", []byte(sum))
+	if err != nil {
+		t.Fatalf("ParseSumPositions: %v", err)
+	}
+	if p, ok := ps.Of(vks[0]); !ok || p.String() != "go.sum:2:1" {
+		t.Errorf("ParseSumPositions: got position %v, want go.sum:2:1", p)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/module"
+
+	"deps.dev/util/cache"
+)
+
+// maxMetaPage is the largest part of a go-get=1 page read.
+const maxMetaPage = 1 << 20
+
+// ImportResolver resolves Go import paths, such as those of packages, to
+// the paths of the modules providing them, as named in deps.dev. Import
+// paths on well-known code hosts are resolved without network access;
+// others are resolved by fetching their go-get=1 pages and reading the
+// go-import meta tags, as the go command does.
+// https://go.dev/ref/mod#vcs-find
+//
+// Pages are cached, so each is fetched at most once. The zero value is
+// ready to use and is safe for concurrent use.
+type ImportResolver struct {
+	// HTTPClient is used to fetch go-get=1 pages. If nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+	// Known, if set, reports whether a module with the given path exists,
+	// for instance by looking it up in the deps.dev API. It is used to
+	// find modules nested in a repository, such as
+	// github.com/Azure/azure-sdk-for-go/sdk/azcore: the longest prefix
+	// of the import path that Known reports is returned. Without it,
+	// the module at the root of the repository is assumed.
+	Known func(ctx context.Context, path string) (bool, error)
+
+	once  sync.Once
+	cache *cache.Cache[string, []metaImport]
+}
+
+// Module returns the path of the module providing the package with the
+// given import path. The import path may be case-encoded, as in module
+// proxy URLs.
+func (r *ImportResolver) Module(ctx context.Context, path string) (string, error) {
+	if strings.Contains(path, "!") {
+		var err error
+		if path, err = module.UnescapePath(path); err != nil {
+			return "", err
+		}
+	}
+	path = strings.TrimSuffix(path, "/")
+	if err := module.CheckImportPath(path); err != nil {
+		return "", err
+	}
+	root, ok := knownRoot(path)
+	if !ok {
+		var err error
+		if root, err = r.metaRoot(ctx, path); err != nil {
+			return "", err
+		}
+	}
+	if r.Known != nil {
+		for p := path; len(p) > len(root); p = p[:strings.LastIndex(p, "/")] {
+			ok, err := r.Known(ctx, p)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				return p, nil
+			}
+		}
+		return root, nil
+	}
+	// A major version subdirectory holds the module of that major
+	// version.
+	if rest, ok := strings.CutPrefix(path, root+"/"); ok {
+		elem, _, _ := strings.Cut(rest, "/")
+		if isMajor(elem) {
+			return root + "/" + elem, nil
+		}
+	}
+	return root, nil
+}
+
+// knownRoot returns the repository root of the given import path if it
+// is on a code host whose layout is known.
+func knownRoot(path string) (string, bool) {
+	elems := strings.Split(path, "/")
+	switch elems[0] {
+	case "github.com", "bitbucket.org":
+		if len(elems) < 3 {
+			return "", false
+		}
+		return strings.Join(elems[:3], "/"), true
+	case "gopkg.in":
+		// gopkg.in/pkg.v1 and gopkg.in/user/pkg.v1.
+		for i, e := range elems[1:min(len(elems), 3)] {
+			if strings.Contains(e, ".v") {
+				return strings.Join(elems[:i+2], "/"), true
+			}
+		}
+	}
+	return "", false
+}
+
+// isMajor reports whether the given path element is a major version
+// suffix, such as v2.
+func isMajor(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' || elem[1] == '0' || elem == "v1" {
+		return false
+	}
+	for _, c := range elem[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// metaImport is a go-import meta tag.
+type metaImport struct {
+	Prefix, VCS, RepoRoot string
+}
+
+// metaRoot returns the import prefix declared for the given import path
+// by the go-import meta tags of its go-get=1 page.
+func (r *ImportResolver) metaRoot(ctx context.Context, path string) (string, error) {
+	r.once.Do(func() { r.cache = cache.New[string, []metaImport](nil) })
+	imports, err := r.cache.GetOrLoad(path, func() ([]metaImport, error) {
+		return r.fetch(ctx, path)
+	})
+	if err != nil {
+		return "", err
+	}
+	var match *metaImport
+	for i, m := range imports {
+		if m.Prefix != path && !strings.HasPrefix(path, m.Prefix+"/") {
+			continue
+		}
+		// A proxy declaration may accompany that of the repository.
+		if match != nil && match.Prefix == m.Prefix && (m.VCS == "mod" || match.VCS == "mod") {
+			continue
+		}
+		if match != nil {
+			return "", fmt.Errorf("%s: multiple go-import meta tags match: %s and %s", path, match.Prefix, m.Prefix)
+		}
+		match = &imports[i]
+	}
+	if match == nil {
+		return "", fmt.Errorf("%s: no go-import meta tag", path)
+	}
+	return match.Prefix, nil
+}
+
+func (r *ImportResolver) fetch(ctx context.Context, path string) ([]metaImport, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+path+"?go-get=1", nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := r.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching go-get page of %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	// Servers may serve the meta tags with an error status, as the go
+	// command accepts.
+	imports, err := parseMetaImports(io.LimitReader(resp.Body, maxMetaPage))
+	if err != nil {
+		return nil, fmt.Errorf("parsing go-get page of %s: %w", path, err)
+	}
+	return imports, nil
+}
+
+// parseMetaImports returns the go-import meta tags of the head of an HTML
+// page.
+func parseMetaImports(r io.Reader) ([]metaImport, error) {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "utf-8", "ascii":
+			return input, nil
+		}
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	var imports []metaImport
+	for {
+		t, err := d.RawToken()
+		if err == io.EOF {
+			return imports, nil
+		}
+		if err != nil {
+			// Meta tags come first; a page broken later on is
+			// of no concern.
+			if len(imports) > 0 {
+				return imports, nil
+			}
+			return nil, err
+		}
+		if e, ok := t.(xml.StartElement); ok && strings.EqualFold(e.Name.Local, "body") {
+			return imports, nil
+		}
+		if e, ok := t.(xml.EndElement); ok && strings.EqualFold(e.Name.Local, "head") {
+			return imports, nil
+		}
+		e, ok := t.(xml.StartElement)
+		if !ok || !strings.EqualFold(e.Name.Local, "meta") || attr(e, "name") != "go-import" {
+			continue
+		}
+		// The optional fourth field names a subdirectory of the
+		// repository.
+		if f := strings.Fields(attr(e, "content")); len(f) == 3 || len(f) == 4 {
+			imports = append(imports, metaImport{Prefix: f[0], VCS: f[1], RepoRoot: f[2]})
+		}
+	}
+}
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
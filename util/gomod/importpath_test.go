@@ -0,0 +1,130 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// pages serves go-get=1 pages from a map keyed by host and path.
+type pages struct {
+	pages   map[string]string
+	fetches atomic.Int32
+}
+
+func (p *pages) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.fetches.Add(1)
+	if req.URL.Query().Get("go-get") != "1" {
+		return nil, fmt.Errorf("missing go-get=1 in %s", req.URL)
+	}
+	w := httptest.NewRecorder()
+	page, ok := p.pages[req.URL.Host+req.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	fmt.Fprint(w, page)
+	return w.Result(), nil
+}
+
+func metaPage(content ...string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html><head>\n")
+	for _, c := range content {
+		fmt.Fprintf(&b, "<meta name=\"go-import\" content=\"%s\">\n", c)
+	}
+	b.WriteString("</head><body><a href=x>unclosed <br></body></html>")
+	return b.String()
+}
+
+func TestImportResolver(t *testing.T) {
+	p := &pages{pages: map[string]string{
+		"k8s.io/api/core/v1": metaPage("k8s.io/api git https://github.com/kubernetes/api"),
+		"k8s.io/api/apps/v1": metaPage("k8s.io/api git https://github.com/kubernetes/api"),
+		"golang.org/x/text/unicode/norm": metaPage(
+			"golang.org/x/text git https://go.googlesource.com/text",
+			"golang.org/x/text mod https://proxy.example.com",
+		),
+		"example.com/a/b": metaPage(
+			"example.com/a git https://example.com/a",
+			"example.com/a/b git https://example.com/b",
+		),
+		"example.com/other": metaPage("example.org/other git https://example.org/other"),
+	}}
+	r := &ImportResolver{HTTPClient: &http.Client{Transport: p}}
+	ctx := context.Background()
+	for _, test := range []struct {
+		path, want string
+		wantErr    bool
+	}{
+		{"k8s.io/api/core/v1", "k8s.io/api", false},
+		{"golang.org/x/text/unicode/norm", "golang.org/x/text", false},
+		{"github.com/!azure/go-autorest/autorest", "github.com/Azure/go-autorest", false},
+		{"github.com/google/go-cmp/cmp", "github.com/google/go-cmp", false},
+		{"github.com/go-yaml/yaml/v3", "github.com/go-yaml/yaml/v3", false},
+		{"gopkg.in/yaml.v3", "gopkg.in/yaml.v3", false},
+		{"gopkg.in/src-d/go-git.v4/plumbing", "gopkg.in/src-d/go-git.v4", false},
+		{"example.com/a/b", "", true},
+		{"example.com/other", "", true},
+		{"example.com/missing", "", true},
+		{"github.com/!Azure/x", "", true},
+	} {
+		got, err := r.Module(ctx, test.path)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Module(%s): got %s, want error", test.path, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Module(%s): %v", test.path, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Module(%s): got %s, want %s", test.path, got, test.want)
+		}
+	}
+	// Pages are fetched once.
+	n := p.fetches.Load()
+	if _, err := r.Module(ctx, "k8s.io/api/core/v1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.fetches.Load(); got != n {
+		t.Errorf("fetched %d pages again", got-n)
+	}
+
+	// Modules nested in a repository are found with Known.
+	r = &ImportResolver{
+		HTTPClient: &http.Client{Transport: p},
+		Known: func(_ context.Context, path string) (bool, error) {
+			return path == "github.com/Azure/azure-sdk-for-go/sdk/azcore", nil
+		},
+	}
+	for path, want := range map[string]string{
+		"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy": "github.com/Azure/azure-sdk-for-go/sdk/azcore",
+		"github.com/Azure/azure-sdk-for-go/services/storage":  "github.com/Azure/azure-sdk-for-go",
+		"k8s.io/api/apps/v1": "k8s.io/api",
+	} {
+		got, err := r.Module(ctx, path)
+		if err != nil || got != want {
+			t.Errorf("Module(%s): got %s, %v, want %s", path, got, err, want)
+		}
+	}
+}
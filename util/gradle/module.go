@@ -0,0 +1,262 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package gradle parses Gradle Module Metadata, the variant-aware JSON
+documents (.module files) that Gradle publishes alongside, or instead of,
+Maven POMs.
+
+The format is specified at
+https://github.com/gradle/gradle/blob/master/platforms/documentation/docs/src/docs/design/gradle-module-metadata-latest-specification.md
+
+Requirements converts the dependencies of a module to those of the
+deps.dev/util/resolve package, so that Gradle modules can be resolved as
+Maven packages.
+*/
+package gradle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Well known attribute names and values.
+const (
+	AttrCategory        = "org.gradle.category"
+	AttrUsage           = "org.gradle.usage"
+	AttrBundling        = "org.gradle.dependency.bundling"
+	AttrStatus          = "org.gradle.status"
+	AttrJVMVersion      = "org.gradle.jvm.version"
+	AttrLibraryElements = "org.gradle.libraryelements"
+
+	CategoryLibrary          = "library"
+	CategoryPlatform         = "platform"
+	CategoryEnforcedPlatform = "enforced-platform"
+	CategoryDocumentation    = "documentation"
+
+	UsageJavaAPI     = "java-api"
+	UsageJavaRuntime = "java-runtime"
+)
+
+// Module holds the content of a Gradle Module Metadata file.
+type Module struct {
+	FormatVersion string    `json:"formatVersion"`
+	Component     Component `json:"component"`
+	CreatedBy     CreatedBy `json:"createdBy"`
+	Variants      []Variant `json:"variants,omitempty"`
+}
+
+// Component identifies the published component.
+type Component struct {
+	Group      string     `json:"group"`
+	Module     string     `json:"module"`
+	Version    string     `json:"version"`
+	URL        string     `json:"url,omitempty"`
+	Attributes Attributes `json:"attributes,omitempty"`
+}
+
+// Name returns the name of the component in the Maven form group:module.
+func (c Component) Name() string {
+	return c.Group + ":" + c.Module
+}
+
+// CreatedBy describes the tool that produced the metadata.
+type CreatedBy struct {
+	Gradle struct {
+		Version string `json:"version"`
+		BuildID string `json:"buildId,omitempty"`
+	} `json:"gradle"`
+}
+
+// Variant is a published variant of a component, for example its API or its
+// runtime elements.
+type Variant struct {
+	Name                  string       `json:"name"`
+	Attributes            Attributes   `json:"attributes,omitempty"`
+	AvailableAt           *AvailableAt `json:"available-at,omitempty"`
+	Dependencies          []Dependency `json:"dependencies,omitempty"`
+	DependencyConstraints []Dependency `json:"dependencyConstraints,omitempty"`
+	Files                 []File       `json:"files,omitempty"`
+	Capabilities          []Capability `json:"capabilities,omitempty"`
+}
+
+// AvailableAt indicates that a variant is published in another module.
+type AvailableAt struct {
+	URL     string `json:"url"`
+	Group   string `json:"group"`
+	Module  string `json:"module"`
+	Version string `json:"version"`
+}
+
+// Dependency is a dependency, or a dependency constraint, of a variant.
+type Dependency struct {
+	Group                   string                   `json:"group"`
+	Module                  string                   `json:"module"`
+	Version                 VersionConstraint        `json:"version,omitempty"`
+	Excludes                []Exclude                `json:"excludes,omitempty"`
+	Reason                  string                   `json:"reason,omitempty"`
+	Attributes              Attributes               `json:"attributes,omitempty"`
+	RequestedCapabilities   []Capability             `json:"requestedCapabilities,omitempty"`
+	EndorseStrictVersions   bool                     `json:"endorseStrictVersions,omitempty"`
+	ThirdPartyCompatibility *ThirdPartyCompatibility `json:"thirdPartyCompatibility,omitempty"`
+}
+
+// Name returns the name of the dependency in the Maven form group:module.
+func (d Dependency) Name() string {
+	return d.Group + ":" + d.Module
+}
+
+// VersionConstraint holds the rich version declaration of a dependency.
+type VersionConstraint struct {
+	Requires string   `json:"requires,omitempty"`
+	Prefers  string   `json:"prefers,omitempty"`
+	Strictly string   `json:"strictly,omitempty"`
+	Rejects  []string `json:"rejects,omitempty"`
+}
+
+// Requirement returns the version requirement in Maven syntax. A strict
+// version takes precedence over the required one, which takes precedence
+// over the preferred one.
+func (vc VersionConstraint) Requirement() string {
+	switch {
+	case vc.Strictly != "":
+		return vc.Strictly
+	case vc.Requires != "":
+		return vc.Requires
+	}
+	return vc.Prefers
+}
+
+// Exclude is a transitive dependency exclusion. Either field may be "*".
+type Exclude struct {
+	Group  string `json:"group"`
+	Module string `json:"module"`
+}
+
+// ThirdPartyCompatibility holds information needed to consume modules that
+// do not publish Gradle Module Metadata.
+type ThirdPartyCompatibility struct {
+	ArtifactSelector *ArtifactSelector `json:"artifactSelector,omitempty"`
+}
+
+// ArtifactSelector selects a specific artifact of a dependency, in the same
+// way as a Maven classifier and type.
+type ArtifactSelector struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Extension  string `json:"extension,omitempty"`
+	Classifier string `json:"classifier,omitempty"`
+}
+
+// File is a file that is part of a variant.
+type File struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Size   int64  `json:"size,omitempty"`
+	SHA1   string `json:"sha1,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	SHA512 string `json:"sha512,omitempty"`
+	MD5    string `json:"md5,omitempty"`
+}
+
+// Capability is a feature provided by a variant, used to detect conflicts
+// between modules providing the same feature.
+type Capability struct {
+	Group   string `json:"group"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Attributes holds the attributes of a component, variant or dependency.
+// Gradle attribute values may be strings, booleans or integers; they are all
+// represented as strings here.
+type Attributes map[string]string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Attributes) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	attrs := make(Attributes, len(raw))
+	for k, v := range raw {
+		var val any
+		if err := json.Unmarshal(v, &val); err != nil {
+			return err
+		}
+		switch val := val.(type) {
+		case string:
+			attrs[k] = val
+		case bool:
+			attrs[k] = strconv.FormatBool(val)
+		case float64:
+			// Keep the integer representation as written.
+			attrs[k] = strings.TrimSpace(string(v))
+		default:
+			return fmt.Errorf("attribute %s: unsupported value %s", k, v)
+		}
+	}
+	*a = attrs
+	return nil
+}
+
+// Parse parses the content of a Gradle Module Metadata file.
+func Parse(b []byte) (*Module, error) {
+	var m Module
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	if m.FormatVersion == "" {
+		return nil, errors.New("missing format version")
+	}
+	if major, _, _ := strings.Cut(m.FormatVersion, "."); major != "1" {
+		return nil, fmt.Errorf("unsupported format version %q", m.FormatVersion)
+	}
+	if m.Component.Group == "" || m.Component.Module == "" || m.Component.Version == "" {
+		return nil, fmt.Errorf("incomplete component %s:%s:%s", m.Component.Group, m.Component.Module, m.Component.Version)
+	}
+	return &m, nil
+}
+
+// Variant returns the variant with the given name, or nil if there is none.
+func (m *Module) Variant(name string) *Variant {
+	for i := range m.Variants {
+		if m.Variants[i].Name == name {
+			return &m.Variants[i]
+		}
+	}
+	return nil
+}
+
+// VariantsWith returns the variants having the given attribute value, in the
+// order they are declared.
+func (m *Module) VariantsWith(attr, value string) []*Variant {
+	var vs []*Variant
+	for i := range m.Variants {
+		if m.Variants[i].Attributes[attr] == value {
+			vs = append(vs, &m.Variants[i])
+		}
+	}
+	return vs
+}
+
+// IsPlatform reports whether the attributes describe a platform, the Gradle
+// equivalent of a Maven BOM.
+func (a Attributes) IsPlatform() bool {
+	c := a[AttrCategory]
+	return c == CategoryPlatform || c == CategoryEnforcedPlatform
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gradle
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	input, err := os.ReadFile("testdata/library-1.2.3.module")
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	got, err := Parse(input)
+	if err != nil {
+		t.Fatalf("failed to parse module: %v", err)
+	}
+
+	api := Dependency{
+		Group:  "com.example",
+		Module: "api",
+		Version: VersionConstraint{
+			Strictly: "[1.0,2.0)",
+			Prefers:  "1.5",
+		},
+	}
+	want := &Module{
+		FormatVersion: "1.1",
+		Component: Component{
+			Group:      "com.example",
+			Module:     "library",
+			Version:    "1.2.3",
+			Attributes: Attributes{AttrStatus: "release"},
+		},
+		Variants: []Variant{{
+			Name: "apiElements",
+			Attributes: Attributes{
+				AttrCategory:        CategoryLibrary,
+				AttrBundling:        "external",
+				AttrJVMVersion:      "8",
+				AttrLibraryElements: "jar",
+				AttrUsage:           UsageJavaAPI,
+			},
+			Dependencies: []Dependency{{
+				Group:                 "com.example",
+				Module:                "bom",
+				Version:               VersionConstraint{Requires: "2.0.0"},
+				Attributes:            Attributes{AttrCategory: CategoryPlatform},
+				EndorseStrictVersions: true,
+			}, {
+				Group:    api.Group,
+				Module:   api.Module,
+				Version:  api.Version,
+				Excludes: []Exclude{{Group: "org.unwanted", Module: "*"}},
+			}},
+			Files: []File{{
+				Name: "library-1.2.3.jar",
+				URL:  "library-1.2.3.jar",
+				Size: 1234,
+				SHA1: "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+			}},
+		}, {
+			Name: "runtimeElements",
+			Attributes: Attributes{
+				AttrCategory:        CategoryLibrary,
+				AttrBundling:        "external",
+				AttrJVMVersion:      "8",
+				AttrLibraryElements: "jar",
+				AttrUsage:           UsageJavaRuntime,
+			},
+			Dependencies: []Dependency{api, {
+				Group:   "com.example",
+				Module:  "impl",
+				Version: VersionConstraint{Requires: "3.1"},
+				ThirdPartyCompatibility: &ThirdPartyCompatibility{
+					ArtifactSelector: &ArtifactSelector{
+						Name:       "impl",
+						Type:       "jar",
+						Extension:  "jar",
+						Classifier: "linux",
+					},
+				},
+			}},
+			DependencyConstraints: []Dependency{{
+				Group:   "com.example",
+				Module:  "transitive",
+				Version: VersionConstraint{Requires: "4.0"},
+				Reason:  "security fix",
+			}},
+		}, {
+			Name: "sourcesElements",
+			Attributes: Attributes{
+				AttrCategory:          CategoryDocumentation,
+				AttrBundling:          "external",
+				"org.gradle.docstype": "sources",
+				AttrUsage:             UsageJavaRuntime,
+			},
+			AvailableAt: &AvailableAt{
+				URL:     "../../other/1.0/other-1.0.module",
+				Group:   "com.example",
+				Module:  "other",
+				Version: "1.0",
+			},
+		}},
+	}
+	want.CreatedBy.Gradle.Version = "8.5"
+	want.CreatedBy.Gradle.BuildID = "abcdef"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Parse:\n(- want, + got):\n%s", diff)
+	}
+
+	if v := got.Variant("runtimeElements"); v == nil || v.Name != "runtimeElements" {
+		t.Errorf("Variant(runtimeElements): got %v", v)
+	}
+	if v := got.Variant("missing"); v != nil {
+		t.Errorf("Variant(missing): got %v, want nil", v)
+	}
+	var names []string
+	for _, v := range got.VariantsWith(AttrUsage, UsageJavaRuntime) {
+		names = append(names, v.Name)
+	}
+	if diff := cmp.Diff([]string{"runtimeElements", "sourcesElements"}, names); diff != "" {
+		t.Errorf("VariantsWith:\n(- want, + got):\n%s", diff)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, input := range []string{
+		`{`,
+		`{"component": {"group": "g", "module": "m", "version": "1"}}`,
+		`{"formatVersion": "2.0", "component": {"group": "g", "module": "m", "version": "1"}}`,
+		`{"formatVersion": "1.1", "component": {"group": "g", "module": "m"}}`,
+		`{"formatVersion": "1.1", "component": {"group": "g", "module": "m", "version": "1", "attributes": {"a": [1]}}}`,
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%s): got no error", input)
+		}
+	}
+}
+
+func TestRequirement(t *testing.T) {
+	for _, c := range []struct {
+		vc   VersionConstraint
+		want string
+	}{
+		{VersionConstraint{}, ""},
+		{VersionConstraint{Prefers: "1.0"}, "1.0"},
+		{VersionConstraint{Requires: "1.1", Prefers: "1.0"}, "1.1"},
+		{VersionConstraint{Strictly: "[1.0,2.0)", Requires: "1.1"}, "[1.0,2.0)"},
+	} {
+		if got := c.vc.Requirement(); got != c.want {
+			t.Errorf("%+v.Requirement() = %q, want %q", c.vc, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gradle
+
+import (
+	"strings"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+// DepType returns the dep.Type of a dependency declared by the given
+// variant of a Gradle module. The attributes are the same as the ones
+// produced by resolve.MavenDepType for the equivalent Maven dependency, so
+// Gradle modules can be resolved as Maven packages.
+func DepType(v Variant, d Dependency) dep.Type {
+	var dt dep.Type
+	if d.Attributes.IsPlatform() {
+		// A platform is the Gradle equivalent of an imported BOM.
+		dt.AddAttr(dep.Scope, dep.ScopeImport)
+		dt.AddAttr(dep.MavenArtifactType, "pom")
+	} else if v.Attributes[AttrUsage] == UsageJavaRuntime {
+		dt.AddAttr(dep.Scope, dep.ScopeRuntime)
+	}
+	if tpc := d.ThirdPartyCompatibility; tpc != nil && tpc.ArtifactSelector != nil {
+		if t := tpc.ArtifactSelector.Type; t != "" && t != "jar" {
+			dt.AddAttr(dep.MavenArtifactType, t)
+		}
+		if c := tpc.ArtifactSelector.Classifier; c != "" {
+			dt.AddAttr(dep.MavenClassifier, c)
+		}
+	}
+	if len(d.Excludes) > 0 {
+		exs := make([]string, len(d.Excludes))
+		for i, ex := range d.Excludes {
+			exs[i] = ex.Group + ":" + ex.Module
+		}
+		dt.AddAttr(dep.MavenExclusions, strings.Join(exs, "|"))
+	}
+	return dt
+}
+
+// Requirements returns the requirements of a Gradle module as consumed
+// by a Java build. Dependencies of the library API variants are regular
+// dependencies, those only found in the runtime variants have the runtime
+// scope. Dependency constraints are returned in the same way as Maven
+// dependency management. Variants published in another module are ignored.
+func Requirements(m *Module) []resolve.RequirementVersion {
+	// key identifies a dependency in the same way as the Maven resolver
+	// does, so that a dependency of both the API and runtime variants is
+	// only reported once, as a regular dependency.
+	type key struct {
+		name, typ, classifier string
+		mgt                   bool
+	}
+	var (
+		reqs []resolve.RequirementVersion
+		seen = make(map[key]bool)
+	)
+	add := func(v Variant, d Dependency, mgt bool) {
+		dt := DepType(v, d)
+		if mgt {
+			dt.AddAttr(dep.MavenDependencyOrigin, dep.OriginManagement)
+		}
+		k := key{name: d.Name(), mgt: mgt}
+		k.typ, _ = dt.GetAttr(dep.MavenArtifactType)
+		k.classifier, _ = dt.GetAttr(dep.MavenClassifier)
+		if seen[k] {
+			return
+		}
+		seen[k] = true
+		reqs = append(reqs, resolve.RequirementVersion{
+			VersionKey: resolve.VersionKey{
+				PackageKey: resolve.PackageKey{
+					System: resolve.Maven,
+					Name:   d.Name(),
+				},
+				VersionType: resolve.Requirement,
+				Version:     d.Version.Requirement(),
+			},
+			Type: dt,
+		})
+	}
+	for _, usage := range []string{UsageJavaAPI, UsageJavaRuntime} {
+		for _, v := range m.VariantsWith(AttrUsage, usage) {
+			if v.AvailableAt != nil {
+				continue
+			}
+			if c, ok := v.Attributes[AttrCategory]; ok && c != CategoryLibrary {
+				continue
+			}
+			for _, d := range v.Dependencies {
+				add(*v, d, false)
+			}
+			for _, d := range v.DependencyConstraints {
+				add(*v, d, true)
+			}
+		}
+	}
+	return reqs
+}
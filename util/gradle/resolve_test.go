@@ -0,0 +1,104 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gradle
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+func TestRequirements(t *testing.T) {
+	library := func(usage string) Attributes {
+		return Attributes{
+			AttrCategory: CategoryLibrary,
+			AttrUsage:    usage,
+		}
+	}
+	api := Dependency{
+		Group:    "com.example",
+		Module:   "api",
+		Version:  VersionConstraint{Strictly: "[1.0,2.0)", Prefers: "1.5"},
+		Excludes: []Exclude{{Group: "org.unwanted", Module: "*"}},
+	}
+	m := &Module{
+		Variants: []Variant{{
+			Name:       "apiElements",
+			Attributes: library(UsageJavaAPI),
+			Dependencies: []Dependency{{
+				Group:      "com.example",
+				Module:     "bom",
+				Version:    VersionConstraint{Requires: "2.0.0"},
+				Attributes: Attributes{AttrCategory: CategoryPlatform},
+			}, api},
+		}, {
+			Name:       "runtimeElements",
+			Attributes: library(UsageJavaRuntime),
+			Dependencies: []Dependency{api, {
+				Group:   "com.example",
+				Module:  "impl",
+				Version: VersionConstraint{Requires: "3.1"},
+				ThirdPartyCompatibility: &ThirdPartyCompatibility{
+					ArtifactSelector: &ArtifactSelector{Name: "impl", Type: "jar", Classifier: "linux"},
+				},
+			}},
+			DependencyConstraints: []Dependency{{
+				Group:   "com.example",
+				Module:  "transitive",
+				Version: VersionConstraint{Requires: "4.0"},
+			}},
+		}, {
+			Name: "sourcesElements",
+			Attributes: Attributes{
+				AttrCategory: CategoryDocumentation,
+				AttrUsage:    UsageJavaRuntime,
+			},
+			Dependencies: []Dependency{{Group: "com.example", Module: "docs", Version: VersionConstraint{Requires: "1.0"}}},
+		}, {
+			Name:        "jdk11RuntimeElements",
+			Attributes:  library(UsageJavaRuntime),
+			AvailableAt: &AvailableAt{Group: "com.example", Module: "library-jdk11", Version: "1.2.3"},
+		}},
+	}
+
+	req := func(name, version string, attrs ...any) resolve.RequirementVersion {
+		var dt dep.Type
+		for len(attrs) > 0 {
+			dt.AddAttr(attrs[0].(dep.AttrKey), attrs[1].(string))
+			attrs = attrs[2:]
+		}
+		return resolve.RequirementVersion{
+			VersionKey: resolve.VersionKey{
+				PackageKey:  resolve.PackageKey{System: resolve.Maven, Name: name},
+				VersionType: resolve.Requirement,
+				Version:     version,
+			},
+			Type: dt,
+		}
+	}
+	want := []resolve.RequirementVersion{
+		req("com.example:bom", "2.0.0", dep.Scope, "import", dep.MavenArtifactType, "pom"),
+		req("com.example:api", "[1.0,2.0)", dep.MavenExclusions, "org.unwanted:*"),
+		req("com.example:impl", "3.1", dep.Scope, "runtime", dep.MavenClassifier, "linux"),
+		req("com.example:transitive", "4.0", dep.Scope, "runtime", dep.MavenDependencyOrigin, "management"),
+	}
+	got := Requirements(m)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Requirements:\n(- want, + got):\n%s", diff)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package instrument reports the activity of deps.dev API clients and of
+resolvers as Prometheus metrics and OpenTelemetry spans, so that services
+embedding them can monitor them.
+
+An Instrumentation registers its metrics when made by New, and provides the
+wrappers that record them:
+
+  - UnaryClientInterceptor, for the gRPC clients of both the v3 and v3alpha
+    APIs, counts requests by method and status code and times them;
+  - CacheLookup, for the Lookup option of the api/clientutil caches, counts
+    cache hits and misses;
+  - Client wraps a resolve.Client, counting and timing its calls;
+  - Resolver wraps a resolve.Resolver, counting and timing resolutions;
+  - Tracer, for the WithTracer option of the resolvers, counts the steps of
+    resolutions, such as backtracks.
+
+Each call and resolution is also recorded as a span.
+
+	inst, err := instrument.New(nil)
+	if err != nil {
+		// ...
+	}
+	conn, err := grpc.NewClient("api.deps.dev:443",
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUnaryInterceptor(inst.UnaryClientInterceptor()),
+	)
+	// ...
+	client := inst.Client(resolve.NewAPIClient(pb.NewInsightsClient(conn)))
+	r := inst.Resolver(npm.NewResolver(client, resolve.WithTracer(inst.Tracer())))
+*/
+package instrument
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"deps.dev/util/resolve"
+)
+
+// Options configure an Instrumentation. The zero value of each field
+// selects its default.
+type Options struct {
+	// Registerer registers the metrics. The default is
+	// prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+	// TracerProvider provides the tracer of the spans. The default is the
+	// global provider, as returned by otel.GetTracerProvider.
+	TracerProvider trace.TracerProvider
+	// Namespace is the prefix of the metric names. The default is
+	// "depsdev".
+	Namespace string
+}
+
+// Instrumentation records metrics and spans. It is safe for concurrent use.
+type Instrumentation struct {
+	tracer trace.Tracer
+
+	rpcs               *prometheus.CounterVec
+	rpcDuration        *prometheus.HistogramVec
+	cacheLookups       *prometheus.CounterVec
+	clientCalls        *prometheus.CounterVec
+	clientDuration     *prometheus.HistogramVec
+	resolutions        *prometheus.CounterVec
+	resolutionDuration *prometheus.HistogramVec
+	events             *prometheus.CounterVec
+}
+
+// New returns an Instrumentation, registering its metrics. An error is
+// returned if they are already registered, such as by another
+// Instrumentation with the same Registerer and Namespace. If opts is nil,
+// the defaults are used.
+func New(opts *Options) (*Instrumentation, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Registerer == nil {
+		o.Registerer = prometheus.DefaultRegisterer
+	}
+	if o.TracerProvider == nil {
+		o.TracerProvider = otel.GetTracerProvider()
+	}
+	if o.Namespace == "" {
+		o.Namespace = "depsdev"
+	}
+	counter := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: o.Namespace, Name: name, Help: help}, labels)
+	}
+	histogram := func(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: o.Namespace, Name: name, Help: help, Buckets: buckets}, labels)
+	}
+	i := &Instrumentation{
+		tracer: o.TracerProvider.Tracer("deps.dev/util/instrument"),
+
+		rpcs: counter("rpc_requests_total",
+			"Calls to the deps.dev API, by gRPC method and status code.", "method", "code"),
+		rpcDuration: histogram("rpc_duration_seconds",
+			"Duration of calls to the deps.dev API, by gRPC method.", prometheus.DefBuckets, "method"),
+		cacheLookups: counter("cache_lookups_total",
+			"Lookups of cached API responses, by gRPC method or HTTP host and result (hit or miss).", "name", "result"),
+		clientCalls: counter("resolve_client_calls_total",
+			"Calls to resolve clients, by method, system and result (ok, not_found or error).", "method", "system", "result"),
+		clientDuration: histogram("resolve_client_call_duration_seconds",
+			"Duration of calls to resolve clients, by method and system.", prometheus.DefBuckets, "method", "system"),
+		resolutions: counter("resolve_resolutions_total",
+			"Resolutions, by system and result (ok, incomplete or error).", "system", "result"),
+		resolutionDuration: histogram("resolve_resolution_duration_seconds",
+			"Duration of resolutions, by system.", prometheus.ExponentialBuckets(0.01, 2, 14), "system"),
+		events: counter("resolve_events_total",
+			"Steps of resolutions, by system and kind (pin, backtrack or conflict).", "system", "kind"),
+	}
+	for _, c := range []prometheus.Collector{
+		i.rpcs, i.rpcDuration, i.cacheLookups,
+		i.clientCalls, i.clientDuration,
+		i.resolutions, i.resolutionDuration, i.events,
+	} {
+		if err := o.Registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return i, nil
+}
+
+// UnaryClientInterceptor returns a gRPC client interceptor recording each
+// call.
+func (i *Instrumentation) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := i.tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("rpc.system", "grpc"),
+				attribute.String("rpc.method", method),
+			))
+		defer span.End()
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		code := status.Code(err)
+		i.rpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		i.rpcs.WithLabelValues(method, code.String()).Inc()
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+// CacheLookup records a lookup of a cached response. It has the signature
+// of the Lookup option of the api/clientutil caches.
+func (i *Instrumentation) CacheLookup(name string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	i.cacheLookups.WithLabelValues(name, result).Inc()
+}
+
+// Client returns a resolve.Client recording the calls to c.
+func (i *Instrumentation) Client(c resolve.Client) resolve.Client {
+	return &client{inst: i, client: c}
+}
+
+type client struct {
+	inst   *Instrumentation
+	client resolve.Client
+}
+
+// call records a call to a method of the client, made by f.
+func call[T any](ctx context.Context, c *client, method string, vk resolve.VersionKey, f func(context.Context) (T, error)) (T, error) {
+	system := vk.System.String()
+	attrs := []attribute.KeyValue{
+		attribute.String("package.system", system),
+		attribute.String("package.name", vk.Name),
+	}
+	if vk.Version != "" {
+		attrs = append(attrs, attribute.String("package.version", vk.Version))
+	}
+	ctx, span := c.inst.tracer.Start(ctx, "resolve.Client/"+method, trace.WithAttributes(attrs...))
+	defer span.End()
+	start := time.Now()
+	v, err := f(ctx)
+	c.inst.clientDuration.WithLabelValues(method, system).Observe(time.Since(start).Seconds())
+	result := "ok"
+	switch {
+	case errors.Is(err, resolve.ErrNotFound):
+		// Not found is an answer, not a failure.
+		result = "not_found"
+	case err != nil:
+		result = "error"
+		span.SetStatus(codes.Error, err.Error())
+	}
+	c.inst.clientCalls.WithLabelValues(method, system, result).Inc()
+	return v, err
+}
+
+func (c *client) Version(ctx context.Context, vk resolve.VersionKey) (resolve.Version, error) {
+	return call(ctx, c, "Version", vk, func(ctx context.Context) (resolve.Version, error) {
+		return c.client.Version(ctx, vk)
+	})
+}
+
+func (c *client) Versions(ctx context.Context, pk resolve.PackageKey) ([]resolve.Version, error) {
+	return call(ctx, c, "Versions", resolve.VersionKey{PackageKey: pk}, func(ctx context.Context) ([]resolve.Version, error) {
+		return c.client.Versions(ctx, pk)
+	})
+}
+
+func (c *client) Requirements(ctx context.Context, vk resolve.VersionKey) ([]resolve.RequirementVersion, error) {
+	return call(ctx, c, "Requirements", vk, func(ctx context.Context) ([]resolve.RequirementVersion, error) {
+		return c.client.Requirements(ctx, vk)
+	})
+}
+
+func (c *client) MatchingVersions(ctx context.Context, vk resolve.VersionKey) ([]resolve.Version, error) {
+	return call(ctx, c, "MatchingVersions", vk, func(ctx context.Context) ([]resolve.Version, error) {
+		return c.client.MatchingVersions(ctx, vk)
+	})
+}
+
+// Resolver returns a resolve.Resolver recording the resolutions of r. A
+// resolution is incomplete if it returns a graph holding errors.
+func (i *Instrumentation) Resolver(r resolve.Resolver) resolve.Resolver {
+	return &resolver{inst: i, resolver: r}
+}
+
+type resolver struct {
+	inst     *Instrumentation
+	resolver resolve.Resolver
+}
+
+func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve.Graph, error) {
+	system := vk.System.String()
+	ctx, span := r.inst.tracer.Start(ctx, "resolve.Resolve", trace.WithAttributes(
+		attribute.String("package.system", system),
+		attribute.String("package.name", vk.Name),
+		attribute.String("package.version", vk.Version),
+	))
+	defer span.End()
+	start := time.Now()
+	g, err := r.resolver.Resolve(ctx, vk)
+	r.inst.resolutionDuration.WithLabelValues(system).Observe(time.Since(start).Seconds())
+	result := "ok"
+	switch {
+	case err != nil:
+		result = "error"
+		span.SetStatus(codes.Error, err.Error())
+	case incomplete(g):
+		result = "incomplete"
+		span.SetAttributes(attribute.Bool("graph.incomplete", true))
+	}
+	if g != nil {
+		span.SetAttributes(
+			attribute.Int("graph.nodes", len(g.Nodes)),
+			attribute.Int("graph.edges", len(g.Edges)),
+		)
+	}
+	r.inst.resolutions.WithLabelValues(system, result).Inc()
+	return g, err
+}
+
+// incomplete reports whether the graph holds errors.
+func incomplete(g *resolve.Graph) bool {
+	if g == nil || g.Error != "" {
+		return true
+	}
+	for _, n := range g.Nodes {
+		if len(n.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Tracer returns a resolve.Tracer counting the steps of resolutions. Calls
+// to the client are not counted, as Client records them.
+func (i *Instrumentation) Tracer() resolve.Tracer {
+	return resolve.TracerFunc(func(e resolve.Event) {
+		if e.Kind == resolve.ClientCallEvent {
+			return
+		}
+		system := e.Requirement.System
+		if system == resolve.UnknownSystem {
+			system = e.Version.System
+		}
+		i.events.WithLabelValues(system.String(), e.Kind.String()).Inc()
+	})
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrument
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"deps.dev/util/resolve"
+)
+
+// newTest returns an Instrumentation using a new registry, and the
+// recorder of its spans.
+func newTest(t *testing.T) (*Instrumentation, *tracetest.SpanRecorder) {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	i, err := New(&Options{
+		Registerer:     prometheus.NewRegistry(),
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)),
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return i, rec
+}
+
+func spanNames(rec *tracetest.SpanRecorder) []string {
+	var names []string
+	for _, s := range rec.Ended() {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+func TestNewRegistersOnce(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if _, err := New(&Options{Registerer: reg}); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := New(&Options{Registerer: reg}); err == nil {
+		t.Errorf("second New with the same registry: got no error")
+	}
+	if _, err := New(&Options{Registerer: reg, Namespace: "other"}); err != nil {
+		t.Errorf("New with another namespace: %v", err)
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	i, rec := newTest(t)
+	intercept := i.UnaryClientInterceptor()
+	invoker := func(err error) grpc.UnaryInvoker {
+		return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return err
+		}
+	}
+	const method = "/deps_dev.v3.Insights/GetVersion"
+	intercept(context.Background(), method, nil, nil, nil, invoker(nil))
+	intercept(context.Background(), method, nil, nil, nil, invoker(nil))
+	intercept(context.Background(), method, nil, nil, nil, invoker(status.Error(grpccodes.NotFound, "not found")))
+
+	if got := testutil.ToFloat64(i.rpcs.WithLabelValues(method, "OK")); got != 2 {
+		t.Errorf("OK calls: got %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(i.rpcs.WithLabelValues(method, "NotFound")); got != 1 {
+		t.Errorf("NotFound calls: got %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(i.rpcDuration); got != 1 {
+		t.Errorf("duration series: got %d, want 1", got)
+	}
+	spans := rec.Ended()
+	if len(spans) != 3 || spans[0].Name() != method || spans[2].Status().Code.String() != "Error" {
+		t.Errorf("spans: got %v", spanNames(rec))
+	}
+}
+
+func TestCacheLookup(t *testing.T) {
+	i, _ := newTest(t)
+	i.CacheLookup("/m", true)
+	i.CacheLookup("/m", false)
+	i.CacheLookup("/m", true)
+	if got := testutil.ToFloat64(i.cacheLookups.WithLabelValues("/m", "hit")); got != 2 {
+		t.Errorf("hits: got %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(i.cacheLookups.WithLabelValues("/m", "miss")); got != 1 {
+		t.Errorf("misses: got %v, want 1", got)
+	}
+}
+
+func TestClientAndResolver(t *testing.T) {
+	i, rec := newTest(t)
+	vk := func(name string) resolve.VersionKey {
+		return resolve.VersionKey{
+			PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: name},
+			VersionType: resolve.Concrete,
+			Version:     "1.0.0",
+		}
+	}
+	lc := resolve.NewLocalClient()
+	lc.AddVersion(resolve.Version{VersionKey: vk("alice")}, nil)
+	c := i.Client(lc)
+
+	ctx := context.Background()
+	if _, err := c.Version(ctx, vk("alice")); err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if _, err := c.Version(ctx, vk("bob")); !errors.Is(err, resolve.ErrNotFound) {
+		t.Fatalf("Version(bob): got %v, want not found", err)
+	}
+	if _, err := c.Versions(ctx, vk("alice").PackageKey); err != nil {
+		t.Fatalf("Versions: %v", err)
+	}
+	if got := testutil.ToFloat64(i.clientCalls.WithLabelValues("Version", "NPM", "ok")); got != 1 {
+		t.Errorf("Version ok calls: got %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(i.clientCalls.WithLabelValues("Version", "NPM", "not_found")); got != 1 {
+		t.Errorf("Version not found calls: got %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(i.clientCalls.WithLabelValues("Versions", "NPM", "ok")); got != 1 {
+		t.Errorf("Versions calls: got %v, want 1", got)
+	}
+
+	graphs := map[string]*resolve.Graph{
+		"alice": {Nodes: []resolve.Node{{Version: vk("alice")}}},
+		"bob":   {Nodes: []resolve.Node{{Version: vk("bob")}}, Error: "no versions"},
+	}
+	r := i.Resolver(resolverFunc(func(ctx context.Context, v resolve.VersionKey) (*resolve.Graph, error) {
+		if g, ok := graphs[v.Name]; ok {
+			return g, nil
+		}
+		return nil, errors.New("failed")
+	}))
+	for _, name := range []string{"alice", "bob", "chuck"} {
+		r.Resolve(ctx, vk(name))
+	}
+	for _, result := range []string{"ok", "incomplete", "error"} {
+		if got := testutil.ToFloat64(i.resolutions.WithLabelValues("NPM", result)); got != 1 {
+			t.Errorf("%s resolutions: got %v, want 1", result, got)
+		}
+	}
+
+	tr := i.Tracer()
+	tr.Trace(resolve.Event{Kind: resolve.ClientCallEvent, Requirement: vk("alice")})
+	tr.Trace(resolve.Event{Kind: resolve.BacktrackEvent, Version: vk("alice")})
+	tr.Trace(resolve.Event{Kind: resolve.BacktrackEvent, Requirement: vk("bob")})
+	if got := testutil.ToFloat64(i.events.WithLabelValues("NPM", "backtrack")); got != 2 {
+		t.Errorf("backtracks: got %v, want 2", got)
+	}
+	if got := testutil.CollectAndCount(i.events); got != 1 {
+		t.Errorf("event series: got %d, want 1", got)
+	}
+
+	want := []string{
+		"resolve.Client/Version", "resolve.Client/Version", "resolve.Client/Versions",
+		"resolve.Resolve", "resolve.Resolve", "resolve.Resolve",
+	}
+	got := spanNames(rec)
+	if len(got) != len(want) {
+		t.Fatalf("spans: got %v, want %v", got, want)
+	}
+	for j := range want {
+		if got[j] != want[j] {
+			t.Errorf("span %d: got %s, want %s", j, got[j], want[j])
+		}
+	}
+}
+
+type resolverFunc func(context.Context, resolve.VersionKey) (*resolve.Graph, error)
+
+func (f resolverFunc) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve.Graph, error) {
+	return f(ctx, vk)
+}
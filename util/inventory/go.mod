@@ -0,0 +1,33 @@
+module deps.dev/util/inventory
+
+go 1.23.4
+
+replace (
+	deps.dev/api/clientutil => ../../api/clientutil
+	deps.dev/api/v3alpha => ../../api/v3alpha
+	deps.dev/util/batch => ../batch
+	deps.dev/util/cache => ../cache
+	deps.dev/util/licenses => ../licenses
+	deps.dev/util/names => ../names
+	deps.dev/util/osvscanner => ../osvscanner
+)
+
+require (
+	deps.dev/api/v3alpha v0.0.0-20240701033337-efe6530670b9
+	deps.dev/util/licenses v0.0.0-00010101000000-000000000000
+	deps.dev/util/names v0.0.0-00010101000000-000000000000
+	deps.dev/util/osvscanner v0.0.0-00010101000000-000000000000
+	github.com/BurntSushi/toml v1.4.0
+	github.com/google/go-cmp v0.6.0
+	golang.org/x/mod v0.22.0
+)
+
+require (
+	deps.dev/util/batch v0.0.0-00010101000000-000000000000 // indirect
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
+	google.golang.org/grpc v1.69.4 // indirect
+	google.golang.org/protobuf v1.36.2 // indirect
+)
@@ -0,0 +1,405 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package inventory builds a single inventory of the package versions used
+by all the projects of a repository, across packaging systems.
+
+Scan walks a directory tree, such as a monorepo, and reads the lockfiles
+and manifests it recognizes:
+
+  - package-lock.json and npm-shrinkwrap.json, for npm;
+  - packages.lock.json, for NuGet;
+  - go.mod, for Go;
+  - Cargo.lock, for Cargo;
+  - requirements.txt, for PyPI, of which only the versions pinned with ==
+    are taken.
+
+The package versions found are merged, so that a dependency shared by
+several projects appears once, with the files that use it. Packages that
+belong to the repository itself, such as the members of a Cargo or npm
+workspace, or Go modules defined by another go.mod of the tree, are left
+out, as the deps.dev API knows nothing about them. VersionKeys returns the
+inventory in the form expected by the batch package, so that it can be
+enriched with a single run of GetVersionBatch.
+*/
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/licenses"
+	"deps.dev/util/names"
+	"deps.dev/util/osvscanner"
+)
+
+// Package is a package version used by the projects of a repository.
+type Package struct {
+	System  pb.System
+	Name    string
+	Version string
+	// Dev reports whether the package is only used for development by
+	// every file that uses it, as far as the files record it.
+	Dev bool
+	// Files holds the paths of the files using the package, relative to
+	// the root of the scan, sorted.
+	Files []string
+}
+
+// Inventory is the merged inventory of a repository.
+type Inventory struct {
+	// Packages holds the distinct package versions found, sorted by
+	// system, name and version.
+	Packages []*Package
+	// Files holds the paths of the files read, relative to the root of
+	// the scan, sorted.
+	Files []string
+	// Errors holds the files that could not be read or parsed, with the
+	// reason. The rest of the files are still scanned.
+	Errors []error
+}
+
+// Options configure Scan.
+type Options struct {
+	// SkipDirs holds the names of the directories not walked into. The
+	// default is .git, node_modules, vendor and target, which hold
+	// version control data, installed dependencies and build outputs.
+	SkipDirs []string
+	// NoDev leaves out the packages used only for development.
+	NoDev bool
+}
+
+var defaultSkipDirs = []string{".git", "node_modules", "vendor", "target"}
+
+// parsers holds the parsers of the recognized files, keyed by base name.
+var parsers = map[string]func(filename string, data []byte) (*file, error){
+	"package-lock.json":   parseLockfile,
+	"npm-shrinkwrap.json": parseLockfile,
+	"packages.lock.json":  parseLockfile,
+	"go.mod":              parseGoMod,
+	"Cargo.lock":          parseCargoLock,
+	"requirements.txt":    parseRequirements,
+}
+
+// file is the content of a manifest or lockfile.
+type file struct {
+	system pb.System
+	pkgs   []licenses.Package
+	// local holds the names of the packages defined by the file itself,
+	// such as the module of a go.mod file.
+	local []string
+}
+
+// Scan walks the directory tree at the root of fsys, as returned by
+// os.DirFS, and returns the inventory of the files it recognizes. Only
+// errors walking the tree are returned; errors reading or parsing a file
+// are recorded in the inventory. If opts is nil, the defaults are used.
+func Scan(fsys fs.FS, opts *Options) (*Inventory, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.SkipDirs == nil {
+		o.SkipDirs = defaultSkipDirs
+	}
+
+	inv := &Inventory{}
+	files := make(map[string]*file)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != "." && slices.Contains(o.SkipDirs, d.Name()) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		parse, ok := parsers[d.Name()]
+		if !ok || !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			inv.Errors = append(inv.Errors, err)
+			return nil
+		}
+		f, err := parse(p, data)
+		if err != nil {
+			inv.Errors = append(inv.Errors, fmt.Errorf("parsing %s: %w", p, err))
+			return nil
+		}
+		files[p] = f
+		inv.Files = append(inv.Files, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The packages defined in the repository, keyed by system.
+	local := make(map[pb.System]map[string]bool)
+	for _, f := range files {
+		for _, name := range f.local {
+			if local[f.system] == nil {
+				local[f.system] = make(map[string]bool)
+			}
+			local[f.system][key(f.system, name)] = true
+		}
+	}
+
+	type pkgKey struct {
+		system        pb.System
+		name, version string
+	}
+	merged := make(map[pkgKey]*Package)
+	for _, p := range inv.Files {
+		f := files[p]
+		for _, lp := range f.pkgs {
+			name := lp.Name
+			if n, err := names.Normalize(f.system.String(), name); err == nil {
+				name = n
+			}
+			if local[f.system][key(f.system, name)] || (o.NoDev && lp.Dev) {
+				continue
+			}
+			k := pkgKey{f.system, key(f.system, name), lp.Version}
+			pkg, ok := merged[k]
+			if !ok {
+				pkg = &Package{System: f.system, Name: name, Version: lp.Version, Dev: lp.Dev}
+				merged[k] = pkg
+			}
+			pkg.Dev = pkg.Dev && lp.Dev
+			if !slices.Contains(pkg.Files, p) {
+				pkg.Files = append(pkg.Files, p)
+			}
+		}
+	}
+	for _, pkg := range merged {
+		inv.Packages = append(inv.Packages, pkg)
+	}
+	slices.SortFunc(inv.Packages, func(a, b *Package) int {
+		return cmp.Or(
+			cmp.Compare(a.System, b.System),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Version, b.Version),
+		)
+	})
+	return inv, nil
+}
+
+// key returns the form of a normalized package name under which versions
+// of the same package are merged. NuGet and Cargo names are
+// case-insensitive.
+func key(system pb.System, name string) string {
+	switch system {
+	case pb.System_NUGET, pb.System_CARGO:
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// Systems returns the packages of the inventory grouped by system, in the
+// order of Packages.
+func (inv *Inventory) Systems() map[pb.System][]*Package {
+	m := make(map[pb.System][]*Package)
+	for _, p := range inv.Packages {
+		m[p.System] = append(m[p.System], p)
+	}
+	return m
+}
+
+// VersionKeys returns the keys of the package versions of the inventory,
+// in the order of Packages, as taken by batch.Runner.Run.
+func (inv *Inventory) VersionKeys() []*pb.VersionKey {
+	vks := make([]*pb.VersionKey, len(inv.Packages))
+	for i, p := range inv.Packages {
+		vks[i] = &pb.VersionKey{System: p.System, Name: p.Name, Version: p.Version}
+	}
+	return vks
+}
+
+// OSVScannerInput returns the inventory in the input format of
+// OSV-Scanner, with a result for each file listing the package versions it
+// uses, so that the repository can be scanned without OSV-Scanner parsing
+// the files again. See package osvscanner.
+func (inv *Inventory) OSVScannerInput() (*osvscanner.Input, error) {
+	byFile := make(map[string][]osvscanner.Version)
+	for _, p := range inv.Packages {
+		for _, f := range p.Files {
+			byFile[f] = append(byFile[f], osvscanner.Version{System: p.System.String(), Name: p.Name, Version: p.Version})
+		}
+	}
+	in := &osvscanner.Input{Results: []osvscanner.Result{}}
+	for _, f := range inv.Files {
+		if len(byFile[f]) == 0 {
+			continue
+		}
+		r, err := osvscanner.NewResult(f, byFile[f])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		in.Results = append(in.Results, r)
+	}
+	return in, nil
+}
+
+// parseLockfile parses an npm or NuGet lockfile. The root of the lockfile
+// and the projects and workspaces it links to are left out by
+// licenses.ParseLockfile.
+func parseLockfile(filename string, data []byte) (*file, error) {
+	pkgs, err := licenses.ParseLockfile(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	system := pb.System_NPM
+	if path.Base(filename) == "packages.lock.json" {
+		system = pb.System_NUGET
+	}
+	return &file{system: system, pkgs: pkgs}, nil
+}
+
+// parseGoMod parses a go.mod file. Its requirements are the versions of
+// the modules providing packages to the build. The replace and exclude
+// directives are applied as by gomod.ParseMod, which is not used as it
+// links deps.dev/api/v3, whose messages conflict with those of v3alpha.
+func parseGoMod(filename string, data []byte) (*file, error) {
+	mf, err := modfile.Parse(filename, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	f := &file{system: pb.System_GO}
+	if mf.Module != nil {
+		f.local = append(f.local, mf.Module.Mod.Path)
+	}
+	excluded := make(map[module.Version]bool)
+	for _, e := range mf.Exclude {
+		excluded[e.Mod] = true
+	}
+	for _, r := range mf.Require {
+		if excluded[r.Mod] {
+			continue
+		}
+		mod := r.Mod
+		if rep, ok := replacement(mf.Replace, mod); ok {
+			if rep.Version == "" {
+				// Replaced by a directory.
+				continue
+			}
+			mod = rep
+		}
+		f.pkgs = append(f.pkgs, licenses.Package{System: pb.System_GO, Name: mod.Path, Version: module.CanonicalVersion(mod.Version)})
+	}
+	return f, nil
+}
+
+// replacement returns the replacement of the given module version, if
+// any. A replacement of the specific version takes precedence over that
+// of all the versions of the module.
+func replacement(reps []*modfile.Replace, mod module.Version) (module.Version, bool) {
+	var (
+		rep   module.Version
+		found bool
+	)
+	for _, r := range reps {
+		if r.Old.Path != mod.Path {
+			continue
+		}
+		if r.Old.Version == mod.Version {
+			return r.New, true
+		}
+		if r.Old.Version == "" {
+			rep, found = r.New, true
+		}
+	}
+	return rep, found
+}
+
+// cargoLock is a Cargo.lock file.
+type cargoLock struct {
+	Package []struct {
+		Name    string `toml:"name"`
+		Version string `toml:"version"`
+		// Source is empty for the packages of the workspace and those
+		// depended on by path.
+		Source string `toml:"source"`
+	} `toml:"package"`
+}
+
+// parseCargoLock parses a Cargo.lock file. Packages from git repositories
+// are left out, as they are not in the registry.
+func parseCargoLock(filename string, data []byte) (*file, error) {
+	var l cargoLock
+	if err := toml.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	f := &file{system: pb.System_CARGO}
+	for _, p := range l.Package {
+		switch {
+		case p.Source == "":
+			f.local = append(f.local, p.Name)
+		case strings.HasPrefix(p.Source, "registry+"), strings.HasPrefix(p.Source, "sparse+"):
+			f.pkgs = append(f.pkgs, licenses.Package{System: pb.System_CARGO, Name: p.Name, Version: p.Version})
+		}
+	}
+	return f, nil
+}
+
+// parseRequirements parses a pip requirements file, taking the versions
+// pinned with == or ===. Options, such as -r, and requirements with other
+// specifiers or with environment markers are ignored, as they do not name
+// a single version.
+func parseRequirements(filename string, data []byte) (*file, error) {
+	f := &file{system: pb.System_PYPI}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		// A trailing backslash continues the line, as with the --hash
+		// options written by pip-compile.
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "\\"))
+		if line == "" || strings.HasPrefix(line, "-") || strings.Contains(line, ";") {
+			continue
+		}
+		name, version, ok := strings.Cut(line, "==")
+		if !ok {
+			continue
+		}
+		version = strings.TrimPrefix(version, "=")
+		// Drop the extras, as in "requests[socks]".
+		if i := strings.Index(name, "["); i >= 0 {
+			name = name[:i]
+		}
+		name, version = strings.TrimSpace(name), strings.TrimSpace(version)
+		if name == "" || version == "" || strings.ContainsAny(version, "*,<>!~ ") {
+			continue
+		}
+		f.pkgs = append(f.pkgs, licenses.Package{System: pb.System_PYPI, Name: name, Version: version})
+	}
+	return f, s.Err()
+}
@@ -0,0 +1,204 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// monorepo holds the projects of several systems, sharing dependencies.
+var monorepo = fstest.MapFS{
+	"web/package-lock.json": {Data: []byte(`{
+  "name": "web",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "web", "workspaces": ["packages/ui"], "dependencies": {"react": "^18.2.0"}, "devDependencies": {"jest": "^29.0.0"}},
+    "node_modules/react": {"version": "18.2.0", "dependencies": {"loose-envify": "^1.1.0"}},
+    "node_modules/loose-envify": {"version": "1.4.0"},
+    "node_modules/jest": {"version": "29.7.0", "dev": true},
+    "node_modules/ui": {"resolved": "packages/ui", "link": true},
+    "packages/ui": {"name": "ui", "version": "1.0.0", "dependencies": {"react": "^18.2.0"}}
+  }
+}`)},
+	"admin/package-lock.json": {Data: []byte(`{
+  "name": "admin",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "admin", "dependencies": {"react": "^18.2.0", "jest": "^29.0.0"}},
+    "node_modules/react": {"version": "18.2.0"},
+    "node_modules/jest": {"version": "29.7.0"}
+  }
+}`)},
+	// Installed dependencies are not scanned.
+	"admin/node_modules/react/package-lock.json": {Data: []byte(`{"lockfileVersion": 3, "packages": {"node_modules/x": {"version": "1.0.0"}}}`)},
+	"services/api/go.mod": {Data: []byte(`module example.com/repo/api
+
+go 1.22
+
+require (
+	example.com/repo/lib v0.0.0-00010101000000-000000000000
+	github.com/google/go-cmp v0.6.0
+	golang.org/x/mod v0.22.0 // indirect
+)
+
+replace example.com/repo/lib => ../lib
+`)},
+	"services/lib/go.mod": {Data: []byte(`module example.com/repo/lib
+
+go 1.22
+
+require github.com/google/go-cmp v0.6.0
+`)},
+	"services/tool/go.mod": {Data: []byte(`module example.com/repo/tool
+
+go 1.22
+
+require (
+	example.com/repo/lib v0.1.0
+	github.com/google/go-cmp v0.5.9
+)
+`)},
+	"engine/Cargo.lock": {Data: []byte(`version = 3
+
+[[package]]
+name = "engine"
+version = "0.1.0"
+dependencies = ["serde", "engine-macros"]
+
+[[package]]
+name = "engine-macros"
+version = "0.1.0"
+
+[[package]]
+name = "serde"
+version = "1.0.197"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "forked"
+version = "0.2.0"
+source = "git+https://github.com/example/forked#abc123"
+`)},
+	"scripts/requirements.txt": {Data: []byte(`# Pinned by pip-compile.
+Requests[socks]==2.31.0 \
+    --hash=sha256:0123
+urllib3===2.2.1  # via requests
+flask>=2.0
+-r other.txt
+pywin32==306 ; sys_platform == "win32"
+`)},
+	"broken/Cargo.lock": {Data: []byte(`[[package`)},
+	"README.md":         {Data: []byte(`# Monorepo`)},
+}
+
+func TestScan(t *testing.T) {
+	inv, err := Scan(monorepo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range inv.Packages {
+		s := fmt.Sprintf("%v %s@%s %s", p.System, p.Name, p.Version, strings.Join(p.Files, ","))
+		if p.Dev {
+			s += " dev"
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		"GO github.com/google/go-cmp@v0.5.9 services/tool/go.mod",
+		"GO github.com/google/go-cmp@v0.6.0 services/api/go.mod,services/lib/go.mod",
+		"GO golang.org/x/mod@v0.22.0 services/api/go.mod",
+		"NPM jest@29.7.0 admin/package-lock.json,web/package-lock.json",
+		"NPM loose-envify@1.4.0 web/package-lock.json",
+		"NPM react@18.2.0 admin/package-lock.json,web/package-lock.json",
+		"CARGO serde@1.0.197 engine/Cargo.lock",
+		"PYPI requests@2.31.0 scripts/requirements.txt",
+		"PYPI urllib3@2.2.1 scripts/requirements.txt",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("packages (-want +got):\n%s", diff)
+	}
+	wantFiles := []string{
+		"admin/package-lock.json",
+		"engine/Cargo.lock",
+		"scripts/requirements.txt",
+		"services/api/go.mod",
+		"services/lib/go.mod",
+		"services/tool/go.mod",
+		"web/package-lock.json",
+	}
+	if diff := cmp.Diff(wantFiles, inv.Files); diff != "" {
+		t.Errorf("files (-want +got):\n%s", diff)
+	}
+	if len(inv.Errors) != 1 || !strings.Contains(inv.Errors[0].Error(), "broken/Cargo.lock") {
+		t.Errorf("errors: got %v, want one for broken/Cargo.lock", inv.Errors)
+	}
+
+	systems := inv.Systems()
+	if n := len(systems[pb.System_NPM]); n != 3 {
+		t.Errorf("NPM packages: got %d, want 3", n)
+	}
+	vks := inv.VersionKeys()
+	if len(vks) != len(inv.Packages) || vks[0].GetName() != "github.com/google/go-cmp" || vks[0].GetSystem() != pb.System_GO {
+		t.Errorf("VersionKeys: got %v", vks)
+	}
+
+	in, err := inv.OSVScannerInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []string
+	for _, r := range in.Results {
+		var pkgs []string
+		for _, p := range r.Packages {
+			pkgs = append(pkgs, p.Package.Ecosystem+" "+p.Package.Name+"@"+p.Package.Version)
+		}
+		results = append(results, r.Source.Path+": "+strings.Join(pkgs, ", "))
+	}
+	wantResults := []string{
+		"admin/package-lock.json: npm jest@29.7.0, npm react@18.2.0",
+		"engine/Cargo.lock: crates.io serde@1.0.197",
+		"scripts/requirements.txt: PyPI requests@2.31.0, PyPI urllib3@2.2.1",
+		"services/api/go.mod: Go github.com/google/go-cmp@v0.6.0, Go golang.org/x/mod@v0.22.0",
+		"services/lib/go.mod: Go github.com/google/go-cmp@v0.6.0",
+		"services/tool/go.mod: Go github.com/google/go-cmp@v0.5.9",
+		"web/package-lock.json: npm jest@29.7.0, npm loose-envify@1.4.0, npm react@18.2.0",
+	}
+	if diff := cmp.Diff(wantResults, results); diff != "" {
+		t.Errorf("OSVScannerInput (-want +got):\n%s", diff)
+	}
+}
+
+func TestScanNoDev(t *testing.T) {
+	fsys := fstest.MapFS{"package-lock.json": monorepo["web/package-lock.json"]}
+	inv, err := Scan(fsys, &Options{NoDev: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range inv.Packages {
+		got = append(got, p.Name)
+	}
+	if diff := cmp.Diff([]string{"loose-envify", "react"}, got); diff != "" {
+		t.Errorf("packages (-want +got):\n%s", diff)
+	}
+}
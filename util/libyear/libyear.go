@@ -0,0 +1,278 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package libyear measures how out of date the dependencies of a package
+version are, in "libyears".
+
+The libyear lag of a dependency is the time between the publication of the
+version in use and the publication of the latest stable version of its
+package, or zero if the version in use is the latest. The lag of a
+dependency graph is the sum of the lags of its dependencies, so a single
+number summarizes its staleness and can be tracked over time or checked
+against a threshold in CI.
+
+Publication times and the list of versions come from the GetPackage method
+of the deps.dev API. The latest stable version is the greatest version,
+according to the versioning rules of the package's system, that is not a
+prerelease; it is not necessarily the most recently published one.
+*/
+package libyear
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/semver"
+)
+
+// Year is the length of a libyear.
+const Year = time.Duration(365.25 * 24 * float64(time.Hour))
+
+// Report is the libyear lag of a dependency graph.
+type Report struct {
+	// Root is the version whose dependencies were measured.
+	Root *pb.VersionKey
+	// Dependencies holds the measured dependencies, ordered by decreasing
+	// lag, then by name and version. Each version appears once, even if
+	// it has several nodes in the graph.
+	Dependencies []*Dependency
+	// Lag is the sum of the lags of the dependencies.
+	Lag time.Duration
+	// Errors holds the dependencies that could not be measured, with the
+	// reason, such as an unknown publication time.
+	Errors []error
+}
+
+// Libyears returns the lag of the graph in libyears.
+func (r *Report) Libyears() float64 { return years(r.Lag) }
+
+// Dependency is the libyear lag of a dependency.
+type Dependency struct {
+	Version *pb.VersionKey
+	// Direct reports whether the root depends directly on the version.
+	Direct bool
+	// Published is the time the version in use was published.
+	Published time.Time
+	// Latest is the latest stable version of the package, and
+	// LatestPublished the time it was published.
+	Latest          string
+	LatestPublished time.Time
+	// Lag is the time between Published and LatestPublished, or zero if
+	// the version in use is not older than Latest.
+	Lag time.Duration
+}
+
+// Libyears returns the lag of the dependency in libyears.
+func (d *Dependency) Libyears() float64 { return years(d.Lag) }
+
+func years(d time.Duration) float64 { return float64(d) / float64(Year) }
+
+// Options configure Analyze and AnalyzeGraph.
+type Options struct {
+	// Concurrency is the maximum number of packages fetched at once. The
+	// default is 10.
+	Concurrency int
+	// DirectOnly measures only the direct dependencies of the root.
+	DirectOnly bool
+}
+
+// semverSystems maps the systems of the API to those of the semver package.
+var semverSystems = map[pb.System]semver.System{
+	pb.System_GO:    semver.Go,
+	pb.System_NPM:   semver.NPM,
+	pb.System_CARGO: semver.Cargo,
+	pb.System_MAVEN: semver.Maven,
+	pb.System_PYPI:  semver.PyPI,
+	pb.System_NUGET: semver.NuGet,
+}
+
+// Analyze reports the libyear lag of the resolved dependency graph of the
+// given package version, as returned by GetDependencies. If opts is nil,
+// the defaults are used.
+func Analyze(ctx context.Context, c pb.InsightsClient, vk *pb.VersionKey, opts *Options) (*Report, error) {
+	g, err := c.GetDependencies(ctx, &pb.GetDependenciesRequest{VersionKey: vk})
+	if err != nil {
+		return nil, fmt.Errorf("dependencies of %s: %w", describe(vk), err)
+	}
+	return AnalyzeGraph(ctx, c, g, opts)
+}
+
+// AnalyzeGraph reports the libyear lag of a resolved dependency graph,
+// whose first node is the root, such as one returned by GetDependencies.
+// The root itself is not measured. If opts is nil, the defaults are used.
+func AnalyzeGraph(ctx context.Context, c pb.InsightsClient, g *pb.Dependencies, opts *Options) (*Report, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 10
+	}
+	nodes := g.GetNodes()
+	r := &Report{}
+	if len(nodes) == 0 {
+		return r, nil
+	}
+	r.Root = nodes[0].GetVersionKey()
+
+	// Collect the distinct versions in the graph, and the packages whose
+	// versions must be fetched.
+	type pkgKey struct {
+		system pb.System
+		name   string
+	}
+	deps := make(map[string]*Dependency)
+	var pkgs []pkgKey
+	seenPkgs := make(map[pkgKey]bool)
+	for _, n := range nodes[1:] {
+		if n.GetBundled() {
+			continue
+		}
+		direct := n.GetRelation() == pb.DependencyRelation_DIRECT
+		if o.DirectOnly && !direct {
+			continue
+		}
+		vk := n.GetVersionKey()
+		if d, ok := deps[describe(vk)]; ok {
+			d.Direct = d.Direct || direct
+			continue
+		}
+		deps[describe(vk)] = &Dependency{Version: vk, Direct: direct}
+		if k := (pkgKey{vk.GetSystem(), vk.GetName()}); !seenPkgs[k] {
+			seenPkgs[k] = true
+			pkgs = append(pkgs, k)
+		}
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, o.Concurrency)
+	)
+	packages := make(map[pkgKey]*pb.Package)
+	for _, k := range pkgs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			p, err := c.GetPackage(ctx, &pb.GetPackageRequest{
+				PackageKey: &pb.PackageKey{System: k.system, Name: k.name},
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case status.Code(err) == codes.NotFound:
+			case err != nil:
+				r.Errors = append(r.Errors, fmt.Errorf("package %v %s: %w", k.system, k.name, err))
+			default:
+				packages[k] = p
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, d := range deps {
+		vk := d.Version
+		p, ok := packages[pkgKey{vk.GetSystem(), vk.GetName()}]
+		if !ok {
+			continue
+		}
+		if err := measure(d, p); err != nil {
+			r.Errors = append(r.Errors, fmt.Errorf("%s: %w", describe(vk), err))
+			continue
+		}
+		r.Dependencies = append(r.Dependencies, d)
+		r.Lag += d.Lag
+	}
+	slices.SortFunc(r.Dependencies, func(a, b *Dependency) int {
+		return cmp.Or(
+			cmp.Compare(b.Lag, a.Lag),
+			cmp.Compare(a.Version.GetName(), b.Version.GetName()),
+			cmp.Compare(a.Version.GetVersion(), b.Version.GetVersion()),
+		)
+	})
+	slices.SortFunc(r.Errors, func(a, b error) int { return cmp.Compare(a.Error(), b.Error()) })
+	return r, nil
+}
+
+// measure fills in the lag of d from the versions of its package.
+func measure(d *Dependency, p *pb.Package) error {
+	sys, ok := semverSystems[d.Version.GetSystem()]
+	if !ok {
+		return errors.New("unsupported system")
+	}
+	used, err := sys.Parse(d.Version.GetVersion())
+	if err != nil {
+		return err
+	}
+	var (
+		latest    *semver.Version
+		latestKey *pb.Package_Version
+		found     bool
+	)
+	for _, pv := range p.GetVersions() {
+		v, err := sys.Parse(pv.GetVersionKey().GetVersion())
+		if err != nil {
+			continue
+		}
+		if v.Compare(used) == 0 && pv.GetPublishedAt() != nil {
+			found = true
+			d.Published = pv.GetPublishedAt().AsTime()
+		}
+		if v.IsPrerelease() {
+			continue
+		}
+		if latest == nil || v.Compare(latest) > 0 {
+			latest, latestKey = v, pv
+		}
+	}
+	if !found {
+		return errors.New("unknown publication time")
+	}
+	if latest == nil || latest.Compare(used) <= 0 {
+		// The version in use is the latest, or newer than every stable
+		// version.
+		d.Latest, d.LatestPublished = d.Version.GetVersion(), d.Published
+		return nil
+	}
+	if latestKey.GetPublishedAt() == nil {
+		return fmt.Errorf("unknown publication time of latest version %s", latestKey.GetVersionKey().GetVersion())
+	}
+	d.Latest = latestKey.GetVersionKey().GetVersion()
+	d.LatestPublished = latestKey.GetPublishedAt().AsTime()
+	d.Lag = max(d.LatestPublished.Sub(d.Published), 0)
+	return nil
+}
+
+func describe(vk *pb.VersionKey) string {
+	return fmt.Sprintf("%v %s@%s", vk.GetSystem(), vk.GetName(), vk.GetVersion())
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libyear
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "deps.dev/api/v3"
+)
+
+func vk(name, version string) *pb.VersionKey {
+	return &pb.VersionKey{System: pb.System_NPM, Name: name, Version: version}
+}
+
+func date(y, m, d int) time.Time { return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC) }
+
+// pkg returns a package with the given versions, each followed by its
+// publication date or by the zero time if it is not known.
+func pkg(name string, versions ...any) *pb.Package {
+	p := &pb.Package{PackageKey: &pb.PackageKey{System: pb.System_NPM, Name: name}}
+	for i := 0; i < len(versions); i += 2 {
+		pv := &pb.Package_Version{VersionKey: vk(name, versions[i].(string))}
+		if t := versions[i+1].(time.Time); !t.IsZero() {
+			pv.PublishedAt = timestamppb.New(t)
+		}
+		p.Versions = append(p.Versions, pv)
+	}
+	return p
+}
+
+type fakeClient struct {
+	pb.InsightsClient
+	packages map[string]*pb.Package
+	graph    *pb.Dependencies
+}
+
+func (c *fakeClient) GetPackage(ctx context.Context, req *pb.GetPackageRequest, opts ...grpc.CallOption) (*pb.Package, error) {
+	name := req.GetPackageKey().GetName()
+	if name == "broken" {
+		return nil, status.Error(codes.Internal, "broken")
+	}
+	p, ok := c.packages[name]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such package")
+	}
+	return p, nil
+}
+
+func (c *fakeClient) GetDependencies(ctx context.Context, req *pb.GetDependenciesRequest, opts ...grpc.CallOption) (*pb.Dependencies, error) {
+	return c.graph, nil
+}
+
+func TestAnalyze(t *testing.T) {
+	var zero time.Time
+	c := &fakeClient{
+		packages: map[string]*pb.Package{
+			// old is two years behind 2.0.0; the later 1.x backport and
+			// the 3.0.0 prerelease do not count as latest.
+			"old": pkg("old",
+				"1.0.0", date(2020, 1, 1),
+				"2.0.0", date(2022, 1, 1),
+				"1.1.0", date(2023, 1, 1),
+				"3.0.0-beta.1", date(2024, 1, 1)),
+			// fresh is up to date.
+			"fresh": pkg("fresh",
+				"1.0.0", date(2020, 1, 1),
+				"1.1.0", date(2021, 1, 1)),
+			// mid is half a year behind, and is also in the graph at
+			// its latest version.
+			"mid": pkg("mid",
+				"1.0.0", date(2021, 1, 1),
+				"1.2.0", date(2021, 7, 2),
+				"1.10.0", date(2021, 12, 31)),
+			// undated has no publication time for the version in use.
+			"undated": pkg("undated",
+				"1.0.0", zero,
+				"2.0.0", date(2021, 1, 1)),
+		},
+	}
+	nodes := []struct {
+		key      *pb.VersionKey
+		relation pb.DependencyRelation
+		bundled  bool
+	}{
+		{vk("app", "1.0.0"), pb.DependencyRelation_SELF, false},
+		{vk("old", "1.0.0"), pb.DependencyRelation_DIRECT, false},
+		{vk("fresh", "1.1.0"), pb.DependencyRelation_DIRECT, false},
+		{vk("mid", "1.2.0"), pb.DependencyRelation_INDIRECT, false},
+		{vk("mid", "1.2.0"), pb.DependencyRelation_INDIRECT, false},
+		{vk("mid", "1.10.0"), pb.DependencyRelation_DIRECT, false},
+		{vk("undated", "1.0.0"), pb.DependencyRelation_INDIRECT, false},
+		{vk("unknown", "1.0.0"), pb.DependencyRelation_INDIRECT, false},
+		{vk("broken", "1.0.0"), pb.DependencyRelation_INDIRECT, false},
+		{vk("old/node_modules/x", "1.0.0"), pb.DependencyRelation_INDIRECT, true},
+	}
+	c.graph = &pb.Dependencies{}
+	for _, n := range nodes {
+		c.graph.Nodes = append(c.graph.Nodes, &pb.Dependencies_Node{
+			VersionKey: n.key,
+			Relation:   n.relation,
+			Bundled:    n.bundled,
+		})
+	}
+
+	r, err := Analyze(context.Background(), c, vk("app", "1.0.0"), &Options{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Root.GetName() != "app" {
+		t.Errorf("root: got %v", r.Root)
+	}
+	type summary struct {
+		Version string
+		Direct  bool
+		Latest  string
+		Lag     time.Duration
+	}
+	var got []summary
+	for _, d := range r.Dependencies {
+		got = append(got, summary{d.Version.GetName() + "@" + d.Version.GetVersion(), d.Direct, d.Latest, d.Lag})
+	}
+	day := 24 * time.Hour
+	want := []summary{
+		{"old@1.0.0", true, "2.0.0", 731 * day},
+		{"mid@1.2.0", false, "1.10.0", 182 * day},
+		{"fresh@1.1.0", true, "1.1.0", 0},
+		{"mid@1.10.0", true, "1.10.0", 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dependencies:\ngot  %v\nwant %v", got, want)
+	}
+	if r.Lag != 913*day {
+		t.Errorf("lag: got %v, want %v", r.Lag, 913*day)
+	}
+	if got, want := fmt.Sprintf("%.2f", r.Libyears()), "2.50"; got != want {
+		t.Errorf("libyears: got %s, want %s", got, want)
+	}
+	if len(r.Errors) != 2 {
+		t.Errorf("errors: got %v, want 2", r.Errors)
+	}
+
+	r, err = AnalyzeGraph(context.Background(), c, c.graph, &Options{DirectOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Dependencies) != 3 || r.Lag != 731*day || len(r.Errors) != 0 {
+		t.Errorf("direct only: got %d dependencies, lag %v, errors %v", len(r.Dependencies), r.Lag, r.Errors)
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// Package is a package version installed by a lockfile.
+type Package struct {
+	System  pb.System
+	Name    string
+	Version string
+	// Dev reports whether the package is only installed for development.
+	Dev bool
+	// Path holds the names of the packages through which the root of the
+	// lockfile depends on the package: a direct dependency first, the
+	// package itself last. It is one of the shortest such paths, and is
+	// empty if the lockfile does not connect the package to the root.
+	Path []string
+}
+
+// ParseLockfile returns the distinct package versions installed by the
+// given lockfile, sorted by name and version. The kind of lockfile is
+// determined by its base name: package-lock.json and npm-shrinkwrap.json
+// for npm, and packages.lock.json for NuGet.
+func ParseLockfile(filename string, data []byte) ([]Package, error) {
+	var (
+		g   *graph
+		err error
+	)
+	switch base := filepath.Base(filename); base {
+	case "package-lock.json", "npm-shrinkwrap.json":
+		g, err = parseNPMLock(data)
+	case "packages.lock.json":
+		g, err = parseNuGetLock(data)
+	default:
+		return nil, fmt.Errorf("%s: unsupported lockfile", filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+	return g.packages(), nil
+}
+
+// graph is the dependency graph of a lockfile. Its nodes are keyed by an
+// identifier that is unique within the lockfile, such as an install path.
+type graph struct {
+	system pb.System
+	nodes  map[string]*node
+	roots  []string // The direct dependencies of the root.
+}
+
+// node is an installed package.
+type node struct {
+	name, version string
+	dev           bool
+	hidden        bool     // Traversed, but not reported, such as a project.
+	deps          []string // The identifiers of the dependencies.
+}
+
+// packages returns the distinct package versions of the graph, each with
+// one of the shortest paths from the root, found by a breadth-first
+// traversal.
+func (g *graph) packages() []Package {
+	paths := make(map[string][]string)
+	var queue []string
+	for _, id := range g.roots {
+		if n, ok := g.nodes[id]; ok && paths[id] == nil {
+			paths[id] = []string{n.name}
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, d := range g.nodes[id].deps {
+			n, ok := g.nodes[d]
+			if !ok || paths[d] != nil {
+				continue
+			}
+			paths[d] = append(slices.Clip(paths[id]), n.name)
+			queue = append(queue, d)
+		}
+	}
+
+	type nameVersion struct{ name, version string }
+	found := make(map[nameVersion]*Package)
+	for id, n := range g.nodes {
+		if n.hidden {
+			continue
+		}
+		nv := nameVersion{n.name, n.version}
+		p, ok := found[nv]
+		if !ok {
+			found[nv] = &Package{System: g.system, Name: n.name, Version: n.version, Dev: n.dev, Path: paths[id]}
+			continue
+		}
+		// A version installed in several places is needed for
+		// production if any of its copies is.
+		p.Dev = p.Dev && n.dev
+		if shorterPath(paths[id], p.Path) {
+			p.Path = paths[id]
+		}
+	}
+	pkgs := make([]Package, 0, len(found))
+	for _, p := range found {
+		pkgs = append(pkgs, *p)
+	}
+	slices.SortFunc(pkgs, func(a, b Package) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Version, b.Version))
+	})
+	return pkgs
+}
+
+// shorterPath reports whether path a is preferred over path b: an empty
+// path is never preferred, and ties are broken by comparing the names so
+// that the result does not depend on map order.
+func shorterPath(a, b []string) bool {
+	switch {
+	case len(a) == 0:
+		return false
+	case len(b) == 0:
+		return true
+	case len(a) != len(b):
+		return len(a) < len(b)
+	}
+	return slices.Compare(a, b) < 0
+}
+
+// npmLock is a package-lock.json file. Version 1 files list the installed
+// packages in a tree of dependencies; later versions list them in packages,
+// keyed by their paths in node_modules.
+// https://docs.npmjs.com/cli/configuring-npm/package-lock-json
+type npmLock struct {
+	Dependencies map[string]npmLockDep     `json:"dependencies"`
+	Packages     map[string]npmLockPackage `json:"packages"`
+}
+
+// npmLockDep is an installed package in the dependencies of a version 1
+// package-lock.json file.
+type npmLockDep struct {
+	Version      string                `json:"version"`
+	Dev          bool                  `json:"dev"`
+	Requires     map[string]string     `json:"requires"`
+	Dependencies map[string]npmLockDep `json:"dependencies"`
+}
+
+// npmLockPackage is an installed package in the packages of a version 2 or
+// 3 package-lock.json file.
+type npmLockPackage struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Dev                  bool              `json:"dev"`
+	DevOptional          bool              `json:"devOptional"`
+	Link                 bool              `json:"link"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// parseNPMLock returns the graph of a package-lock.json file. Links, such
+// as those to workspaces, are not followed.
+func parseNPMLock(data []byte) (*graph, error) {
+	var l npmLock
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	g := &graph{system: pb.System_NPM, nodes: make(map[string]*node)}
+	requires := make(map[string]map[string]string)
+	if l.Packages != nil {
+		for path, p := range l.Packages {
+			requires[path] = p.requires(path == "")
+			i := strings.LastIndex(path, "node_modules/")
+			if i < 0 || p.Link {
+				// The root package, a workspace or a link.
+				continue
+			}
+			name := p.Name
+			if name == "" {
+				name = path[i+len("node_modules/"):]
+			}
+			g.nodes[path] = &node{name: name, version: p.Version, dev: p.Dev || p.DevOptional}
+		}
+	} else {
+		addNPMDeps(g, requires, "", l.Dependencies)
+	}
+
+	if root, ok := requires[""]; ok {
+		for _, name := range sortedKeys(root) {
+			if id, ok := resolveNPM(g, "", name); ok {
+				g.roots = append(g.roots, id)
+			}
+		}
+	} else {
+		// Version 1 lockfiles do not record the dependencies of the
+		// root: take those top-level packages that no other package
+		// requires.
+		required := make(map[string]bool)
+		for from, reqs := range requires {
+			for name := range reqs {
+				if id, ok := resolveNPM(g, from, name); ok {
+					required[id] = true
+				}
+			}
+		}
+		for _, name := range sortedKeys(l.Dependencies) {
+			if id := "node_modules/" + name; !required[id] {
+				g.roots = append(g.roots, id)
+			}
+		}
+	}
+	for id, n := range g.nodes {
+		for _, name := range sortedKeys(requires[id]) {
+			if d, ok := resolveNPM(g, id, name); ok {
+				n.deps = append(n.deps, d)
+			}
+		}
+	}
+	return g, nil
+}
+
+// requires returns the packages required by an installed package. Only the
+// root package has its development dependencies installed.
+func (p npmLockPackage) requires(root bool) map[string]string {
+	reqs := make(map[string]string)
+	for _, m := range []map[string]string{p.Dependencies, p.OptionalDependencies, p.PeerDependencies} {
+		for name, req := range m {
+			reqs[name] = req
+		}
+	}
+	if root {
+		for name, req := range p.DevDependencies {
+			reqs[name] = req
+		}
+	}
+	return reqs
+}
+
+// addNPMDeps adds the packages of the dependencies of a version 1
+// package-lock.json file to the graph, keyed by their install paths as in
+// later versions.
+func addNPMDeps(g *graph, requires map[string]map[string]string, parent string, deps map[string]npmLockDep) {
+	for name, d := range deps {
+		path := "node_modules/" + name
+		if parent != "" {
+			path = parent + "/" + path
+		}
+		g.nodes[path] = &node{name: name, version: d.Version, dev: d.Dev}
+		requires[path] = d.Requires
+		addNPMDeps(g, requires, path, d.Dependencies)
+	}
+}
+
+// resolveNPM returns the install path of the package of the given name
+// required by the package installed at from, following the resolution of
+// Node.js: the nearest node_modules directory holding the package.
+func resolveNPM(g *graph, from, name string) (string, bool) {
+	dir := from
+	for {
+		path := "node_modules/" + name
+		if dir != "" {
+			path = dir + "/" + path
+		}
+		if _, ok := g.nodes[path]; ok {
+			return path, true
+		}
+		if dir == "" {
+			return "", false
+		}
+		i := strings.LastIndex(dir, "node_modules/")
+		if i < 0 {
+			// A workspace, whose parent is the root.
+			dir = ""
+			continue
+		}
+		dir = strings.TrimSuffix(dir[:i], "/")
+	}
+}
+
+// nugetLock is a packages.lock.json file, holding the packages installed
+// for each target framework.
+type nugetLock struct {
+	Version      int                                  `json:"version"`
+	Dependencies map[string]map[string]nugetLockEntry `json:"dependencies"`
+}
+
+// nugetLockEntry is a package or project in a packages.lock.json file.
+type nugetLockEntry struct {
+	Type         string            `json:"type"`
+	Resolved     string            `json:"resolved"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// parseNuGetLock returns the graph of a packages.lock.json file, joining
+// the graphs of its target frameworks. Projects are traversed but not
+// reported, and the runtime-specific sections are left out as they repeat
+// packages of their framework.
+func parseNuGetLock(data []byte) (*graph, error) {
+	var l nugetLock
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	if l.Version != 1 && l.Version != 2 {
+		return nil, fmt.Errorf("unsupported lockfile version %d", l.Version)
+	}
+	g := &graph{system: pb.System_NUGET, nodes: make(map[string]*node)}
+	for _, fw := range sortedKeys(l.Dependencies) {
+		if strings.Contains(fw, "/") {
+			continue
+		}
+		entries := l.Dependencies[fw]
+		// Package names are case-insensitive.
+		id := func(name string) string { return fw + "/" + strings.ToLower(name) }
+		for _, name := range sortedKeys(entries) {
+			e := entries[name]
+			n := &node{name: name, version: e.Resolved, hidden: e.Type == "Project"}
+			for _, d := range sortedKeys(e.Dependencies) {
+				n.deps = append(n.deps, id(d))
+			}
+			g.nodes[id(name)] = n
+			// Projects listed in a framework are referenced by the
+			// root project.
+			if e.Type == "Direct" || e.Type == "Project" {
+				g.roots = append(g.roots, id(name))
+			}
+		}
+	}
+	return g, nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+func TestParseLockfile(t *testing.T) {
+	npm := func(name, version string, dev bool, path ...string) Package {
+		return Package{System: pb.System_NPM, Name: name, Version: version, Dev: dev, Path: path}
+	}
+	nuget := func(name, version string, path ...string) Package {
+		return Package{System: pb.System_NUGET, Name: name, Version: version, Path: path}
+	}
+	for _, test := range []struct {
+		filename, data string
+		want           []Package
+	}{{
+		filename: "package-lock.json",
+		data: `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app", "dependencies": {"alice": "^1.0.0", "bob": "^1.0.0"}, "devDependencies": {"dave": "^1.0.0"}},
+    "node_modules/alice": {"version": "1.0.0", "dependencies": {"chuck": "^1.0.0"}},
+    "node_modules/bob": {"version": "1.0.0", "dependencies": {"chuck": "^2.0.0", "@scope/eve": "^1.0.0"}},
+    "node_modules/bob/node_modules/chuck": {"version": "2.0.0"},
+    "node_modules/chuck": {"version": "1.0.0"},
+    "node_modules/@scope/eve": {"version": "1.0.0", "dependencies": {"chuck": "^1.0.0"}},
+    "node_modules/dave": {"version": "1.0.0", "dev": true},
+    "node_modules/workspace": {"resolved": "packages/workspace", "link": true},
+    "packages/workspace": {"version": "1.0.0"},
+    "node_modules/frank": {"version": "1.0.0", "extraneous": true}
+  }
+}`,
+		want: []Package{
+			npm("@scope/eve", "1.0.0", false, "bob", "@scope/eve"),
+			npm("alice", "1.0.0", false, "alice"),
+			npm("bob", "1.0.0", false, "bob"),
+			npm("chuck", "1.0.0", false, "alice", "chuck"),
+			npm("chuck", "2.0.0", false, "bob", "chuck"),
+			npm("dave", "1.0.0", true, "dave"),
+			npm("frank", "1.0.0", false),
+		},
+	}, {
+		filename: "/src/app/npm-shrinkwrap.json",
+		data: `{
+  "lockfileVersion": 1,
+  "requires": true,
+  "dependencies": {
+    "alice": {"version": "1.0.0", "requires": {"chuck": "^1.0.0"}},
+    "bob": {
+      "version": "1.0.0",
+      "requires": {"chuck": "^2.0.0"},
+      "dependencies": {"chuck": {"version": "2.0.0"}}
+    },
+    "chuck": {"version": "1.0.0"}
+  }
+}`,
+		want: []Package{
+			npm("alice", "1.0.0", false, "alice"),
+			npm("bob", "1.0.0", false, "bob"),
+			npm("chuck", "1.0.0", false, "alice", "chuck"),
+			npm("chuck", "2.0.0", false, "bob", "chuck"),
+		},
+	}, {
+		filename: "packages.lock.json",
+		data: `{
+  "version": 1,
+  "dependencies": {
+    "net6.0": {
+      "Serilog": {"type": "Direct", "requested": "[3.0.0, )", "resolved": "3.0.0", "dependencies": {"System.Memory": "4.5.0"}},
+      "System.Memory": {"type": "Transitive", "resolved": "4.5.0"},
+      "Lib": {"type": "Project", "dependencies": {"newtonsoft.json": "[13.0.1, )"}},
+      "Newtonsoft.Json": {"type": "Transitive", "resolved": "13.0.1"}
+    },
+    "net6.0/win-x64": {
+      "runtime.win": {"type": "Transitive", "resolved": "1.0.0"}
+    },
+    "net8.0": {
+      "Serilog": {"type": "Direct", "requested": "[3.0.0, )", "resolved": "3.0.0"},
+      "System.Memory": {"type": "Transitive", "resolved": "4.5.5"},
+      "Other": {"type": "Direct", "requested": "[1.0.0, )", "resolved": "1.0.0", "dependencies": {"System.Memory": "4.5.5"}}
+    }
+  }
+}`,
+		want: []Package{
+			nuget("Newtonsoft.Json", "13.0.1", "Lib", "Newtonsoft.Json"),
+			nuget("Other", "1.0.0", "Other"),
+			nuget("Serilog", "3.0.0", "Serilog"),
+			nuget("System.Memory", "4.5.0", "Serilog", "System.Memory"),
+			nuget("System.Memory", "4.5.5", "Other", "System.Memory"),
+		},
+	}} {
+		got, err := ParseLockfile(test.filename, []byte(test.data))
+		if err != nil {
+			t.Errorf("ParseLockfile(%s): %v", test.filename, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("ParseLockfile(%s) (-want +got):\n%s", test.filename, diff)
+		}
+	}
+
+	for _, test := range []struct {
+		filename, data string
+	}{
+		{"yarn.lock", ""},
+		{"package-lock.json", "{"},
+		{"packages.lock.json", `{"version": 3}`},
+	} {
+		if _, err := ParseLockfile(test.filename, []byte(test.data)); err == nil {
+			t.Errorf("ParseLockfile(%s, %q): got no error", test.filename, test.data)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// pathSeparator separates the packages of a dependency path in the CSV
+// and HTML reports.
+const pathSeparator = " > "
+
+// WriteCSV writes the report as CSV, one row per package version, with a
+// header row. Several licenses are separated by semicolons.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"system", "name", "version", "licenses", "declared", "dev", "path", "error"})
+	for _, e := range r.Entries {
+		cw.Write([]string{
+			e.System.String(),
+			e.Name,
+			e.Version,
+			strings.Join(e.Licenses, "; "),
+			strings.Join(e.Declared, "; "),
+			strconv.FormatBool(e.Dev),
+			strings.Join(e.Path, pathSeparator),
+			e.Error,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// jsonGroup and jsonEntry are the JSON form of a Group and an Entry.
+type jsonGroup struct {
+	License  string      `json:"license"`
+	Packages []jsonEntry `json:"packages"`
+}
+
+type jsonEntry struct {
+	System   string   `json:"system"`
+	Name     string   `json:"name"`
+	Version  string   `json:"version"`
+	Dev      bool     `json:"dev,omitempty"`
+	Licenses []string `json:"licenses,omitempty"`
+	Declared []string `json:"declared,omitempty"`
+	Path     []string `json:"path,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// WriteJSON writes the report as a JSON object holding the groups of
+// packages by license.
+func (r *Report) WriteJSON(w io.Writer) error {
+	out := struct {
+		Groups []jsonGroup `json:"groups"`
+	}{Groups: []jsonGroup{}}
+	for _, g := range r.Groups() {
+		jg := jsonGroup{License: g.License}
+		for _, e := range g.Entries {
+			jg.Packages = append(jg.Packages, jsonEntry{
+				System:   e.System.String(),
+				Name:     e.Name,
+				Version:  e.Version,
+				Dev:      e.Dev,
+				Licenses: e.Licenses,
+				Declared: e.Declared,
+				Path:     e.Path,
+				Error:    e.Error,
+			})
+		}
+		out.Groups = append(out.Groups, jg)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"path": func(p []string) string { return strings.Join(p, pathSeparator) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>License report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>License report</h1>
+<table>
+<tr><th>License</th><th>Packages</th></tr>
+{{- range $i, $g := .}}
+<tr><td><a href="#license-{{$i}}">{{$g.License}}</a></td><td>{{len $g.Entries}}</td></tr>
+{{- end}}
+</table>
+{{- range $i, $g := .}}
+<h2 id="license-{{$i}}">{{$g.License}}</h2>
+<table>
+<tr><th>System</th><th>Name</th><th>Version</th><th>Declared</th><th>Dev</th><th>Path</th><th>Error</th></tr>
+{{- range $g.Entries}}
+<tr><td>{{.System}}</td><td>{{.Name}}</td><td>{{.Version}}</td><td>{{join .Declared "; "}}</td><td>{{if .Dev}}yes{{end}}</td><td>{{path .Path}}</td><td>{{.Error}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as a static HTML page: a summary of the
+// number of packages under each license, followed by a table of the
+// packages of each.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlReport.Execute(w, r.Groups())
+}
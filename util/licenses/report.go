@@ -0,0 +1,145 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package licenses reports the licenses of the packages installed by a
+lockfile.
+
+ParseLockfile reads the package versions of an npm package-lock.json or a
+NuGet packages.lock.json file, along with the dependency path through which
+each is installed. Build looks up their licenses with the GetVersionBatch
+method of the deps.dev API, and the resulting Report is written as CSV,
+JSON or a static HTML page, grouping the packages by license.
+
+Licenses are SPDX expressions, as mapped by deps.dev from the metadata of
+the packages; a package without a known license is grouped under Unknown.
+*/
+package licenses
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/batch"
+)
+
+// Unknown is the group of the packages with no known license.
+const Unknown = "unknown"
+
+// Entry is the license information of a package version.
+type Entry struct {
+	Package
+	// Licenses are the SPDX expressions of the licenses of the package,
+	// or "non-standard" for a license that could not be mapped to SPDX.
+	Licenses []string
+	// Declared are the licenses as declared in the package metadata.
+	Declared []string
+	// Error is set if the package version could not be found.
+	Error string
+}
+
+// Report holds the license information of a set of packages.
+type Report struct {
+	Entries []Entry // Sorted by system, name and version.
+}
+
+// Group is the set of packages under a license.
+type Group struct {
+	License string
+	Entries []Entry
+}
+
+// Build looks up the licenses of the given packages. Packages appearing
+// more than once are reported once, with the first path given. An error
+// is returned only if the API calls fail; packages that are not found are
+// reported with an error.
+func Build(ctx context.Context, c pb.InsightsClient, pkgs []Package) (*Report, error) {
+	key := func(p Package) batch.VersionKey {
+		return batch.VersionKey{System: p.System, Name: p.Name, Version: p.Version}
+	}
+	var (
+		unique []Package
+		vks    []*pb.VersionKey
+	)
+	seen := make(map[batch.VersionKey]bool)
+	for _, p := range pkgs {
+		if !seen[key(p)] {
+			seen[key(p)] = true
+			unique = append(unique, p)
+			vks = append(vks, &pb.VersionKey{System: p.System, Name: p.Name, Version: p.Version})
+		}
+	}
+	found, err := batch.NewRunner(c, nil).LookupVersions(ctx, vks)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{}
+	for _, p := range unique {
+		e := Entry{Package: p}
+		v, ok := found[key(p)]
+		if !ok {
+			e.Error = "version not found"
+			r.Entries = append(r.Entries, e)
+			continue
+		}
+		e.Licenses = v.GetLicenses()
+		for _, l := range v.GetLicenseDetails() {
+			if l.GetLicense() != "" {
+				e.Declared = append(e.Declared, l.GetLicense())
+			}
+		}
+		r.Entries = append(r.Entries, e)
+	}
+	slices.SortStableFunc(r.Entries, func(a, b Entry) int {
+		return cmp.Or(
+			cmp.Compare(a.System, b.System),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Version, b.Version),
+		)
+	})
+	return r, nil
+}
+
+// Groups returns the entries of the report grouped by license, sorted by
+// license with the Unknown group last. An entry with several licenses is
+// in the group of each.
+func (r *Report) Groups() []Group {
+	byLicense := make(map[string][]Entry)
+	for _, e := range r.Entries {
+		if len(e.Licenses) == 0 {
+			byLicense[Unknown] = append(byLicense[Unknown], e)
+			continue
+		}
+		for _, l := range e.Licenses {
+			byLicense[l] = append(byLicense[l], e)
+		}
+	}
+	var groups []Group
+	for l, es := range byLicense {
+		groups = append(groups, Group{License: l, Entries: es})
+	}
+	slices.SortFunc(groups, func(a, b Group) int {
+		if (a.License == Unknown) != (b.License == Unknown) {
+			if a.License == Unknown {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.License, b.License)
+	})
+	return groups
+}
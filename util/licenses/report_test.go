@@ -0,0 +1,169 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// fakeClient serves the licenses of npm package versions, keyed by name
+// and version, one response per page.
+type fakeClient struct {
+	pb.InsightsClient
+	licenses map[string][]string
+	calls    int
+}
+
+func (c *fakeClient) GetVersionBatch(ctx context.Context, req *pb.GetVersionBatchRequest, opts ...grpc.CallOption) (*pb.VersionBatch, error) {
+	c.calls++
+	i := 0
+	if req.GetPageToken() != "" {
+		i = int(req.GetPageToken()[0] - '0')
+	}
+	r := req.GetRequests()[i]
+	resp := &pb.VersionBatch_Response{Request: r}
+	k := r.GetVersionKey()
+	if ls, ok := c.licenses[k.GetName()+"@"+k.GetVersion()]; ok {
+		v := &pb.Version{VersionKey: k, Licenses: ls}
+		for _, l := range ls {
+			v.LicenseDetails = append(v.LicenseDetails, &pb.Version_License{License: l + " license", Spdx: l})
+		}
+		resp.Version = v
+	}
+	b := &pb.VersionBatch{Responses: []*pb.VersionBatch_Response{resp}}
+	if i+1 < len(req.GetRequests()) {
+		b.NextPageToken = string(rune('0' + i + 1))
+	}
+	return b, nil
+}
+
+func testReport(t *testing.T) *Report {
+	t.Helper()
+	c := &fakeClient{licenses: map[string][]string{
+		"alice@1.0.0": {"MIT"},
+		"bob@1.0.0":   {"Apache-2.0", "MIT"},
+		"chuck@1.0.0": nil,
+	}}
+	pkgs := []Package{
+		{System: pb.System_NPM, Name: "chuck", Version: "1.0.0", Path: []string{"alice", "chuck"}},
+		{System: pb.System_NPM, Name: "alice", Version: "1.0.0", Path: []string{"alice"}},
+		{System: pb.System_NPM, Name: "bob", Version: "1.0.0", Dev: true, Path: []string{"bob"}},
+		{System: pb.System_NPM, Name: "dave", Version: "1.0.0", Path: []string{"bob", "dave"}},
+		{System: pb.System_NPM, Name: "alice", Version: "1.0.0", Path: []string{"bob", "alice"}},
+	}
+	r, err := Build(context.Background(), c, pkgs)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if c.calls != 4 {
+		t.Errorf("Build: got %d GetVersionBatch calls, want 4", c.calls)
+	}
+	return r
+}
+
+func TestBuild(t *testing.T) {
+	r := testReport(t)
+	want := []Entry{{
+		Package:  Package{System: pb.System_NPM, Name: "alice", Version: "1.0.0", Path: []string{"alice"}},
+		Licenses: []string{"MIT"},
+		Declared: []string{"MIT license"},
+	}, {
+		Package:  Package{System: pb.System_NPM, Name: "bob", Version: "1.0.0", Dev: true, Path: []string{"bob"}},
+		Licenses: []string{"Apache-2.0", "MIT"},
+		Declared: []string{"Apache-2.0 license", "MIT license"},
+	}, {
+		Package: Package{System: pb.System_NPM, Name: "chuck", Version: "1.0.0", Path: []string{"alice", "chuck"}},
+	}, {
+		Package: Package{System: pb.System_NPM, Name: "dave", Version: "1.0.0", Path: []string{"bob", "dave"}},
+		Error:   "version not found",
+	}}
+	if diff := cmp.Diff(want, r.Entries); diff != "" {
+		t.Errorf("Build (-want +got):\n%s", diff)
+	}
+
+	var groups []string
+	for _, g := range r.Groups() {
+		var names []string
+		for _, e := range g.Entries {
+			names = append(names, e.Name)
+		}
+		groups = append(groups, g.License+": "+strings.Join(names, ","))
+	}
+	wantGroups := []string{"Apache-2.0: bob", "MIT: alice,bob", "unknown: chuck,dave"}
+	if diff := cmp.Diff(wantGroups, groups); diff != "" {
+		t.Errorf("Groups (-want +got):\n%s", diff)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := testReport(t)
+
+	var buf bytes.Buffer
+	if err := r.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	wantCSV := `system,name,version,licenses,declared,dev,path,error
+NPM,alice,1.0.0,MIT,MIT license,false,alice,
+NPM,bob,1.0.0,Apache-2.0; MIT,Apache-2.0 license; MIT license,true,bob,
+NPM,chuck,1.0.0,,,false,alice > chuck,
+NPM,dave,1.0.0,,,false,bob > dave,version not found
+`
+	if diff := cmp.Diff(wantCSV, buf.String()); diff != "" {
+		t.Errorf("WriteCSV (-want +got):\n%s", diff)
+	}
+
+	buf.Reset()
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var out struct {
+		Groups []struct {
+			License  string
+			Packages []struct{ Name string }
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("WriteJSON wrote invalid JSON: %v", err)
+	}
+	if len(out.Groups) != 3 || out.Groups[1].License != "MIT" || len(out.Groups[1].Packages) != 2 {
+		t.Errorf("WriteJSON: got %s", buf.String())
+	}
+
+	buf.Reset()
+	r.Entries[0].Path = []string{"<script>"}
+	if err := r.WriteHTML(&buf); err != nil {
+		t.Fatalf("WriteHTML: %v", err)
+	}
+	html := buf.String()
+	for _, want := range []string{
+		`<a href="#license-1">MIT</a></td><td>2</td>`,
+		`<h2 id="license-2">unknown</h2>`,
+		`<td>bob &gt; dave</td><td>version not found</td>`,
+		`&lt;script&gt;`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("WriteHTML: missing %q in:\n%s", want, html)
+		}
+	}
+}
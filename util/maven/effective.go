@@ -0,0 +1,257 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// MaxParents defines the maximum number of parents of a project.
+const MaxParents = 100
+
+// MaxRelocations defines the maximum number of relocations followed from a
+// project.
+const MaxRelocations = 10
+
+// Fetcher fetches the POMs of projects.
+type Fetcher interface {
+	FetchProject(ctx context.Context, pk ProjectKey) (Project, error)
+}
+
+// FetcherFunc is a function implementing Fetcher.
+type FetcherFunc func(ctx context.Context, pk ProjectKey) (Project, error)
+
+func (f FetcherFunc) FetchProject(ctx context.Context, pk ProjectKey) (Project, error) {
+	return f(ctx, pk)
+}
+
+// MavenCentral is the URL of the Maven Central repository.
+const MavenCentral = "https://repo.maven.apache.org/maven2"
+
+// HTTPFetcher fetches POMs from a Maven repository over HTTP.
+type HTTPFetcher struct {
+	// Repository is the base URL of the repository. If empty, Maven
+	// Central is used.
+	Repository string
+	// Client is the HTTP client used. If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// FetchProject fetches and decodes the POM of the given project.
+func (f *HTTPFetcher) FetchProject(ctx context.Context, pk ProjectKey) (Project, error) {
+	repo := f.Repository
+	if repo == "" {
+		repo = MavenCentral
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	g, a, v := string(pk.GroupID), string(pk.ArtifactID), string(pk.Version)
+	u, err := url.JoinPath(repo, strings.ReplaceAll(g, ".", "/"), a, v, fmt.Sprintf("%s-%s.pom", a, v))
+	if err != nil {
+		return Project{}, fmt.Errorf("failed to join path: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Project{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Project{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Project{}, fmt.Errorf("fetching %s: %s", u, resp.Status)
+	}
+	var proj Project
+	if err := xml.NewDecoder(resp.Body).Decode(&proj); err != nil {
+		return Project{}, fmt.Errorf("failed to decode Maven project %s: %w", pk.Name(), err)
+	}
+	return proj, nil
+}
+
+// EffectiveOptions configure EffectiveProject.
+type EffectiveOptions struct {
+	// JDK and OS are used to activate profiles. If empty, they default to
+	// JDKProfileActivation and OSProfileActivation.
+	JDK string
+	OS  ActivationOS
+	// FollowRelocations makes EffectiveProject follow the relocations
+	// declared in the distribution management of projects, returning the
+	// effective project they lead to.
+	FollowRelocations bool
+}
+
+// EffectiveProject returns the effective project of p, equivalent to the
+// output of `mvn help:effective-pom`. It fetches the parents of p with f,
+// merging each with its active profiles into p, interpolates the result,
+// and processes its dependencies, fetching the imported BOMs and their own
+// parents with f. Failures to fetch BOMs are ignored, as they are by
+// ProcessDependencies. If opts is nil, the defaults are used.
+//
+// If relocations are followed, the project returned is the one the
+// relocations of p lead to, and its RelocatedFrom field holds the keys of
+// p and of the projects relocated on the way.
+func EffectiveProject(ctx context.Context, f Fetcher, p Project, opts *EffectiveOptions) (Project, error) {
+	e := &effective{
+		fetcher:  f,
+		jdk:      JDKProfileActivation,
+		os:       OSProfileActivation,
+		projects: make(map[ProjectKey]Project),
+	}
+	if opts != nil && opts.JDK != "" {
+		e.jdk = opts.JDK
+	}
+	if opts != nil && !opts.OS.blank() {
+		e.os = opts.OS
+	}
+	if err := e.merge(ctx, &p); err != nil {
+		return Project{}, err
+	}
+	if opts != nil && opts.FollowRelocations {
+		var err error
+		if p, err = e.relocate(ctx, p); err != nil {
+			return Project{}, err
+		}
+	}
+	var bomErr error
+	p.ProcessDependencies(func(groupID, artifactID, version String) (DependencyManagement, error) {
+		bom, err := e.fetch(ctx, ProjectKey{GroupID: groupID, ArtifactID: artifactID, Version: version})
+		if err == nil {
+			err = e.merge(ctx, &bom)
+		}
+		if err != nil {
+			// Stop at the cancellation of the context rather than
+			// failing each remaining import.
+			if ctx.Err() != nil {
+				bomErr = ctx.Err()
+			}
+			return DependencyManagement{}, err
+		}
+		return bom.DependencyManagement, nil
+	})
+	if bomErr != nil {
+		return Project{}, bomErr
+	}
+	return p, nil
+}
+
+type effective struct {
+	fetcher Fetcher
+	jdk     string
+	os      ActivationOS
+	// projects holds the projects already fetched.
+	projects map[ProjectKey]Project
+}
+
+// fetch returns the given project, fetching it if needed. The slices of
+// the project returned are clipped, so that appending to them does not
+// modify the projects shared by other calls.
+func (e *effective) fetch(ctx context.Context, pk ProjectKey) (Project, error) {
+	if p, ok := e.projects[pk]; ok {
+		return p, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return Project{}, err
+	}
+	p, err := e.fetcher.FetchProject(ctx, pk)
+	if err != nil {
+		return Project{}, fmt.Errorf("fetching %s:%s: %w", pk.Name(), pk.Version, err)
+	}
+	p.Properties.Properties = slices.Clip(p.Properties.Properties)
+	p.Licenses = slices.Clip(p.Licenses)
+	p.Developers = slices.Clip(p.Developers)
+	p.DependencyManagement.Dependencies = slices.Clip(p.DependencyManagement.Dependencies)
+	p.Dependencies = slices.Clip(p.Dependencies)
+	p.Repositories = slices.Clip(p.Repositories)
+	p.Build.PluginManagement.Plugins = slices.Clip(p.Build.PluginManagement.Plugins)
+	e.projects[pk] = p
+	return p, nil
+}
+
+// relocate follows the relocations of the merged project p, and returns
+// the merged project they lead to.
+func (e *effective) relocate(ctx context.Context, p Project) (Project, error) {
+	var from []ProjectKey
+	visited := map[ProjectKey]bool{p.ProjectKey: true}
+	for {
+		to, ok := p.RelocatedTo()
+		if !ok {
+			break
+		}
+		if visited[to] {
+			return Project{}, errors.New("cycle of relocated projects")
+		}
+		if len(from) == MaxRelocations {
+			return Project{}, errors.New("too many relocated projects")
+		}
+		visited[to] = true
+		from = append(from, p.ProjectKey)
+		next, err := e.fetch(ctx, to)
+		if err != nil {
+			return Project{}, err
+		}
+		if err := e.merge(ctx, &next); err != nil {
+			return Project{}, err
+		}
+		p = next
+	}
+	p.RelocatedFrom = from
+	return p, nil
+}
+
+// merge merges the active profiles of p and its parents, and the parents
+// themselves, into p, and then interpolates it.
+func (e *effective) merge(ctx context.Context, p *Project) error {
+	if err := p.MergeProfiles(e.jdk, e.os); err != nil {
+		return err
+	}
+	visited := make(map[ProjectKey]bool)
+	current := p.Parent.ProjectKey
+	for n := 0; ; n++ {
+		if current.GroupID == "" || current.ArtifactID == "" || current.Version == "" {
+			break
+		}
+		if n == MaxParents {
+			return errors.New("too many parent projects")
+		}
+		if visited[current] {
+			return errors.New("cycle of parent projects")
+		}
+		visited[current] = true
+
+		parent, err := e.fetch(ctx, current)
+		if err != nil {
+			return err
+		}
+		if parent.Packaging != "pom" {
+			return fmt.Errorf("invalid packaging for parent project %s", parent.Packaging)
+		}
+		if err := parent.MergeProfiles(e.jdk, e.os); err != nil {
+			return err
+		}
+		p.MergeParent(parent)
+		current = parent.Parent.ProjectKey
+	}
+	return p.Interpolate()
+}
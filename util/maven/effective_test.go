@@ -0,0 +1,267 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeFetcher serves projects decoded from the given POMs, counting the
+// fetches.
+func fakeFetcher(t *testing.T, calls map[ProjectKey]int, poms ...string) Fetcher {
+	t.Helper()
+	projects := make(map[ProjectKey]Project)
+	for _, pom := range poms {
+		var p Project
+		if err := xml.Unmarshal([]byte(pom), &p); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		projects[p.ProjectKey] = p
+	}
+	return FetcherFunc(func(ctx context.Context, pk ProjectKey) (Project, error) {
+		calls[pk]++
+		p, ok := projects[pk]
+		if !ok {
+			return Project{}, errors.New("not found")
+		}
+		return p, nil
+	})
+}
+
+func TestEffectiveProject(t *testing.T) {
+	calls := make(map[ProjectKey]int)
+	f := fakeFetcher(t, calls, `
+<project>
+  <groupId>org.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1.0</version>
+  <packaging>pom</packaging>
+  <properties>
+    <junit.version>4.13.2</junit.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>junit</groupId>
+        <artifactId>junit</artifactId>
+        <version>${junit.version}</version>
+        <scope>test</scope>
+      </dependency>
+      <dependency>
+        <groupId>org.example</groupId>
+        <artifactId>bom</artifactId>
+        <version>2.0</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`, `
+<project>
+  <parent>
+    <groupId>org.example</groupId>
+    <artifactId>bom-parent</artifactId>
+    <version>2.0</version>
+  </parent>
+  <groupId>org.example</groupId>
+  <artifactId>bom</artifactId>
+  <version>2.0</version>
+  <packaging>pom</packaging>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>org.example</groupId>
+        <artifactId>lib</artifactId>
+        <version>${lib.version}</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`, `
+<project>
+  <groupId>org.example</groupId>
+  <artifactId>bom-parent</artifactId>
+  <version>2.0</version>
+  <packaging>pom</packaging>
+  <properties>
+    <lib.version>2.1</lib.version>
+  </properties>
+</project>`)
+
+	var p Project
+	if err := xml.Unmarshal([]byte(`
+<project>
+  <parent>
+    <groupId>org.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>1.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>lib</artifactId>
+    </dependency>
+  </dependencies>
+</project>`), &p); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	got, err := EffectiveProject(context.Background(), f, p, nil)
+	if err != nil {
+		t.Fatalf("EffectiveProject: %v", err)
+	}
+	if got.GroupID != "org.example" || got.Version != "1.0" {
+		t.Errorf("got project key %+v, want group and version of the parent", got.ProjectKey)
+	}
+	want := []Dependency{
+		{GroupID: "junit", ArtifactID: "junit", Version: "4.13.2", Scope: "test", Type: "jar"},
+		{GroupID: "org.example", ArtifactID: "lib", Version: "2.1", Type: "jar"},
+	}
+	if diff := cmp.Diff(want, got.Dependencies); diff != "" {
+		t.Errorf("dependencies (-want +got):\n%s", diff)
+	}
+	for pk, n := range calls {
+		if n != 1 {
+			t.Errorf("%v fetched %d times, want once", pk, n)
+		}
+	}
+	if len(calls) != 3 {
+		t.Errorf("got %d projects fetched, want 3", len(calls))
+	}
+
+	// A missing parent is an error.
+	p.Parent.Version = "0.1"
+	if _, err := EffectiveProject(context.Background(), f, p, nil); err == nil {
+		t.Errorf("EffectiveProject with missing parent: got no error")
+	}
+}
+
+func TestEffectiveProjectRelocation(t *testing.T) {
+	calls := make(map[ProjectKey]int)
+	f := fakeFetcher(t, calls, `
+<project>
+  <groupId>org.example</groupId>
+  <artifactId>mid</artifactId>
+  <version>1.0</version>
+  <distributionManagement>
+    <relocation>
+      <groupId>org.example.new</groupId>
+      <artifactId>${new.artifact}</artifactId>
+    </relocation>
+  </distributionManagement>
+  <properties>
+    <new.artifact>lib</new.artifact>
+  </properties>
+</project>`, `
+<project>
+  <groupId>org.example.new</groupId>
+  <artifactId>lib</artifactId>
+  <version>1.0</version>
+  <dependencies>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>dep</artifactId>
+      <version>2.0</version>
+    </dependency>
+  </dependencies>
+</project>`, `
+<project>
+  <groupId>org.example</groupId>
+  <artifactId>loop</artifactId>
+  <version>1.0</version>
+  <distributionManagement>
+    <relocation>
+      <artifactId>old</artifactId>
+    </relocation>
+  </distributionManagement>
+</project>`)
+
+	old := Project{
+		ProjectKey: ProjectKey{GroupID: "org.example", ArtifactID: "old", Version: "1.0"},
+		DistributionManagement: DistributionManagement{
+			Relocation: Relocation{ArtifactID: "mid"},
+		},
+	}
+	opts := &EffectiveOptions{FollowRelocations: true}
+	got, err := EffectiveProject(context.Background(), f, old, opts)
+	if err != nil {
+		t.Fatalf("EffectiveProject: %v", err)
+	}
+	want := ProjectKey{GroupID: "org.example.new", ArtifactID: "lib", Version: "1.0"}
+	if got.ProjectKey != want {
+		t.Errorf("got project %+v, want %+v", got.ProjectKey, want)
+	}
+	wantFrom := []ProjectKey{
+		old.ProjectKey,
+		{GroupID: "org.example", ArtifactID: "mid", Version: "1.0"},
+	}
+	if diff := cmp.Diff(wantFrom, got.RelocatedFrom); diff != "" {
+		t.Errorf("relocated from (-want +got):\n%s", diff)
+	}
+	if len(got.Dependencies) != 1 || got.Dependencies[0].ArtifactID != "dep" {
+		t.Errorf("got dependencies %+v, want those of the relocated project", got.Dependencies)
+	}
+
+	// Relocations are only followed on demand.
+	got, err = EffectiveProject(context.Background(), f, old, nil)
+	if err != nil {
+		t.Fatalf("EffectiveProject: %v", err)
+	}
+	if got.ProjectKey != old.ProjectKey || got.RelocatedFrom != nil {
+		t.Errorf("got project %+v relocated from %v, want %+v", got.ProjectKey, got.RelocatedFrom, old.ProjectKey)
+	}
+
+	// A cycle of relocations is an error.
+	old.DistributionManagement.Relocation.ArtifactID = "loop"
+	if _, err := EffectiveProject(context.Background(), f, old, opts); err == nil {
+		t.Errorf("EffectiveProject with a cycle of relocations: got no error")
+	}
+}
+
+func TestHTTPFetcher(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/maven2/org/example/lib/1.0/lib-1.0.pom" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<project><groupId>org.example</groupId><artifactId>lib</artifactId><version>1.0</version></project>`))
+	}))
+	defer srv.Close()
+
+	f := &HTTPFetcher{Repository: srv.URL + "/maven2", Client: srv.Client()}
+	pk := ProjectKey{GroupID: "org.example", ArtifactID: "lib", Version: "1.0"}
+	p, err := f.FetchProject(context.Background(), pk)
+	if err != nil {
+		t.Fatalf("FetchProject: %v", err)
+	}
+	if p.ProjectKey != pk {
+		t.Errorf("got %+v, want %+v", p.ProjectKey, pk)
+	}
+	pk.Version = "2.0"
+	if _, err := f.FetchProject(context.Background(), pk); err == nil {
+		t.Errorf("FetchProject of missing project: got no error")
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+)
+
+const (
+	pomNamespace      = "http://maven.apache.org/POM/4.0.0"
+	xsiNamespace      = "http://www.w3.org/2001/XMLSchema-instance"
+	pomSchemaLocation = "http://maven.apache.org/POM/4.0.0 https://maven.apache.org/xsd/maven-4.0.0.xsd"
+)
+
+// Marshal returns the pom.xml document of the project. The output is
+// deterministic: elements are written in the order recommended by the
+// Maven POM code convention, empty elements are left out, and the document
+// is indented with two spaces.
+// https://maven.apache.org/developers/conventions/code.html#pom-code-convention
+func Marshal(p *Project) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(p); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// pom holds the elements of a Project in canonical order.
+type pom struct {
+	XMLName        xml.Name `xml:"project"`
+	Namespace      string   `xml:"xmlns,attr"`
+	XSI            string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	ModelVersion   string   `xml:"modelVersion"`
+
+	Parent      Parent `xml:"parent"`
+	GroupID     String `xml:"groupId,omitempty"`
+	ArtifactID  String `xml:"artifactId,omitempty"`
+	Version     String `xml:"version,omitempty"`
+	Packaging   String `xml:"packaging,omitempty"`
+	Name        String `xml:"name,omitempty"`
+	Description String `xml:"description,omitempty"`
+	URL         String `xml:"url,omitempty"`
+
+	Licenses               []License              `xml:"licenses>license,omitempty"`
+	Developers             []Developer            `xml:"developers>developer,omitempty"`
+	SCM                    SCM                    `xml:"scm"`
+	IssueManagement        IssueManagement        `xml:"issueManagement"`
+	DistributionManagement DistributionManagement `xml:"distributionManagement"`
+	Properties             Properties             `xml:"properties"`
+	DependencyManagement   DependencyManagement   `xml:"dependencyManagement"`
+	Dependencies           []Dependency           `xml:"dependencies>dependency,omitempty"`
+	Repositories           []Repository           `xml:"repositories>repository,omitempty"`
+	Build                  Build                  `xml:"build"`
+	Profiles               []Profile              `xml:"profiles>profile,omitempty"`
+}
+
+// MarshalXML encodes the project as a pom.xml project element, with the
+// POM namespace and model version 4.0.0. Empty elements are left out.
+func (p Project) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	data, err := xml.Marshal(pom{
+		Namespace:      pomNamespace,
+		XSI:            xsiNamespace,
+		SchemaLocation: pomSchemaLocation,
+		ModelVersion:   "4.0.0",
+
+		Parent:      p.Parent,
+		GroupID:     p.GroupID,
+		ArtifactID:  p.ArtifactID,
+		Version:     p.Version,
+		Packaging:   p.Packaging,
+		Name:        p.Name,
+		Description: p.Description,
+		URL:         p.URL,
+
+		Licenses:               p.Licenses,
+		Developers:             p.Developers,
+		SCM:                    p.SCM,
+		IssueManagement:        p.IssueManagement,
+		DistributionManagement: p.DistributionManagement,
+		Properties:             p.Properties,
+		DependencyManagement:   p.DependencyManagement,
+		Dependencies:           p.Dependencies,
+		Repositories:           p.Repositories,
+		Build:                  p.Build,
+		Profiles:               p.Profiles,
+	})
+	if err != nil {
+		return err
+	}
+	return copyNonEmpty(e, xml.NewDecoder(bytes.NewReader(data)))
+}
+
+// copyNonEmpty copies the elements read from d to e, leaving out those
+// holding neither text nor other elements, other than properties, whose
+// value may be empty. The omitempty option does not apply to structs, nor
+// to the parents of nested elements such as "licenses>license", so
+// encoding/xml writes such empty elements.
+func copyNonEmpty(e *xml.Encoder, d *xml.Decoder) error {
+	var (
+		// open holds the names of the elements started and not ended.
+		open []string
+		// pending holds the last elements started but not written yet,
+		// as they have no content so far.
+		pending []xml.StartElement
+	)
+	flush := func() error {
+		for _, se := range pending {
+			if err := e.EncodeToken(se); err != nil {
+				return err
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
+	for {
+		// Raw tokens keep the names as written, rather than translating
+		// the namespace prefixes.
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			return e.Flush()
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			se := xml.StartElement{Name: rawName(t.Name)}
+			for _, a := range t.Attr {
+				se.Attr = append(se.Attr, xml.Attr{Name: rawName(a.Name), Value: a.Value})
+			}
+			inProperties := len(open) > 0 && open[len(open)-1] == "properties"
+			open = append(open, se.Name.Local)
+			pending = append(pending, se)
+			if inProperties {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			open = open[:len(open)-1]
+			if len(pending) > 0 {
+				pending = pending[:len(pending)-1]
+				continue
+			}
+			if err := e.EncodeToken(xml.EndElement{Name: rawName(t.Name)}); err != nil {
+				return err
+			}
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+			if err := e.EncodeToken(t.Copy()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// rawName returns the name with its prefix, if any, as part of the local
+// name, so that it is written as is.
+func rawName(n xml.Name) xml.Name {
+	if n.Space == "" {
+		return n
+	}
+	return xml.Name{Local: n.Space + ":" + n.Local}
+}
+
+// MarshalXML encodes the properties as elements named after them, in
+// order. Nothing is written if there are no properties.
+func (p Properties) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if len(p.Properties) == 0 {
+		return nil
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, prop := range p.Properties {
+		if err := e.EncodeElement(prop.Value, xml.StartElement{Name: xml.Name{Local: prop.Name}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"encoding/xml"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMarshal(t *testing.T) {
+	p := &Project{
+		ProjectKey: ProjectKey{
+			GroupID:    "com.example",
+			ArtifactID: "app",
+			Version:    "1.0.0",
+		},
+		Parent: Parent{
+			ProjectKey: ProjectKey{
+				GroupID:    "com.example",
+				ArtifactID: "parent",
+				Version:    "2.0.0",
+			},
+		},
+		Properties: Properties{
+			Properties: []Property{
+				{Name: "guava.version", Value: "33.0.0-jre"},
+				{Name: "project.build.sourceEncoding", Value: "UTF-8"},
+				{Name: "empty", Value: ""},
+			},
+		},
+		Dependencies: []Dependency{{
+			GroupID:    "com.google.guava",
+			ArtifactID: "guava",
+			Version:    "${guava.version}",
+			Exclusions: []Exclusion{{GroupID: "*", ArtifactID: "*"}},
+		}, {
+			GroupID:    "junit",
+			ArtifactID: "junit",
+			Version:    "4.13.2",
+			Scope:      "test",
+			Optional:   "true",
+		}},
+		Repositories: []Repository{{
+			ID:        "example",
+			URL:       "https://repo.example.com/maven2",
+			Snapshots: RepositoryPolicy{Enabled: "false"},
+		}},
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 https://maven.apache.org/xsd/maven-4.0.0.xsd">
+  <modelVersion>4.0.0</modelVersion>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>2.0.0</version>
+  </parent>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0.0</version>
+  <properties>
+    <guava.version>33.0.0-jre</guava.version>
+    <project.build.sourceEncoding>UTF-8</project.build.sourceEncoding>
+    <empty></empty>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+      <version>${guava.version}</version>
+      <exclusions>
+        <exclusion>
+          <groupId>*</groupId>
+          <artifactId>*</artifactId>
+        </exclusion>
+      </exclusions>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.13.2</version>
+      <scope>test</scope>
+      <optional>true</optional>
+    </dependency>
+  </dependencies>
+  <repositories>
+    <repository>
+      <id>example</id>
+      <url>https://repo.example.com/maven2</url>
+      <snapshots>
+        <enabled>false</enabled>
+      </snapshots>
+    </repository>
+  </repositories>
+</project>
+`
+	got, err := Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Marshal (-want +got):\n%s", diff)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	for _, file := range []string{"testdata/basic-1.2.3.xml", "testdata/profiles.xml", "testdata/properties.xml"} {
+		input, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+		var want Project
+		if err := xml.Unmarshal(input, &want); err != nil {
+			t.Fatalf("failed to unmarshal %s: %v", file, err)
+		}
+		data, err := Marshal(&want)
+		if err != nil {
+			t.Fatalf("Marshal(%s): %v", file, err)
+		}
+		var got Project
+		if err := xml.Unmarshal(data, &got); err != nil {
+			t.Fatalf("failed to unmarshal marshaled %s: %v\n%s", file, err, data)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: round trip (-want +got):\n%s", file, diff)
+		}
+		again, err := Marshal(&got)
+		if err != nil {
+			t.Fatalf("Marshal(%s) again: %v", file, err)
+		}
+		if diff := cmp.Diff(string(data), string(again)); diff != "" {
+			t.Errorf("%s: output not deterministic (-first +second):\n%s", file, diff)
+		}
+	}
+}
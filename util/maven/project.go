@@ -70,6 +70,11 @@ type Project struct {
 	Repositories           []Repository           `xml:"repositories>repository,omitempty"`
 	Profiles               []Profile              `xml:"profiles>profile,omitempty"`
 	Build                  Build                  `xml:"build,omitempty"`
+
+	// RelocatedFrom holds the projects relocated to this one, in the
+	// order their relocations were followed by EffectiveProject. It is
+	// not part of the POM.
+	RelocatedFrom []ProjectKey `xml:"-"`
 }
 
 type Build struct {
@@ -188,6 +193,27 @@ type Relocation struct {
 	Version    String `xml:"version,omitempty"`
 }
 
+// RelocatedTo returns the key of the project p is relocated to, if it
+// declares a relocation. The parts of the key the relocation leaves out
+// are those of p.
+func (p *Project) RelocatedTo() (ProjectKey, bool) {
+	r := p.DistributionManagement.Relocation
+	if r.GroupID == "" && r.ArtifactID == "" && r.Version == "" {
+		return ProjectKey{}, false
+	}
+	pk := p.ProjectKey
+	if r.GroupID != "" {
+		pk.GroupID = r.GroupID
+	}
+	if r.ArtifactID != "" {
+		pk.ArtifactID = r.ArtifactID
+	}
+	if r.Version != "" {
+		pk.Version = r.Version
+	}
+	return pk, pk != p.ProjectKey
+}
+
 func (r *Relocation) interpolate(properties map[string]string) bool {
 	ok1 := r.GroupID.interpolate(properties)
 	ok2 := r.ArtifactID.interpolate(properties)
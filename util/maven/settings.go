@@ -0,0 +1,139 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// CentralID is the repository ID of Maven Central.
+const CentralID = "central"
+
+// Settings contains the configuration of a Maven installation, as found
+// in ~/.m2/settings.xml.
+// https://maven.apache.org/settings.html
+type Settings struct {
+	LocalRepository String    `xml:"localRepository,omitempty"`
+	Offline         FalsyBool `xml:"offline,omitempty"`
+	Servers         []Server  `xml:"servers>server,omitempty"`
+	Mirrors         []Mirror  `xml:"mirrors>mirror,omitempty"`
+	Profiles        []Profile `xml:"profiles>profile,omitempty"`
+	ActiveProfiles  []String  `xml:"activeProfiles>activeProfile,omitempty"`
+}
+
+// Server contains the credentials for a repository or mirror, identified
+// by its ID.
+type Server struct {
+	ID       String `xml:"id,omitempty"`
+	Username String `xml:"username,omitempty"`
+	Password String `xml:"password,omitempty"`
+}
+
+// Mirror is a repository serving the artifacts of the repositories matched
+// by MirrorOf.
+// https://maven.apache.org/guides/mini/guide-mirror-settings.html
+type Mirror struct {
+	ID       String `xml:"id,omitempty"`
+	Name     String `xml:"name,omitempty"`
+	URL      String `xml:"url,omitempty"`
+	MirrorOf String `xml:"mirrorOf,omitempty"`
+}
+
+// Active returns the active profiles: those listed in ActiveProfiles and
+// those whose activation matches the given JDK and OS or, if there are
+// none, those active by default, in the order they are defined. Errors in
+// activations are returned along with the profiles found active.
+func (s *Settings) Active(jdk string, os ActivationOS) (active []Profile, err error) {
+	var defaults []Profile
+	for _, prof := range s.Profiles {
+		if slices.Contains(s.ActiveProfiles, prof.ID) {
+			active = append(active, prof)
+			continue
+		}
+		act, actErr := prof.activated(jdk, os)
+		if actErr != nil {
+			err = appendError(err, actErr)
+		}
+		if act {
+			active = append(active, prof)
+		} else if prof.Activation.ActiveByDefault.Boolean() {
+			defaults = append(defaults, prof)
+		}
+	}
+	if len(active) == 0 {
+		active = defaults
+	}
+	return active, err
+}
+
+// Mirror returns the mirror of the given repository, if any. As in Maven,
+// a mirror of the repository ID takes precedence over the other matching
+// mirrors, which are considered in order.
+func (s *Settings) Mirror(repo Repository) (Mirror, bool) {
+	for _, m := range s.Mirrors {
+		if m.MirrorOf == repo.ID {
+			return m, true
+		}
+	}
+	for _, m := range s.Mirrors {
+		if mirrorOf(string(m.MirrorOf), repo) {
+			return m, true
+		}
+	}
+	return Mirror{}, false
+}
+
+// mirrorOf reports whether the repository matches the given mirrorOf
+// pattern: a comma separated list of repository IDs, "*", "external:*",
+// "external:http:*", and IDs prefixed with "!" to exclude them.
+func mirrorOf(pattern string, repo Repository) bool {
+	matched := false
+	for _, p := range strings.Split(pattern, ",") {
+		p = strings.TrimSpace(p)
+		if id, ok := strings.CutPrefix(p, "!"); ok {
+			if id == string(repo.ID) {
+				return false
+			}
+			continue
+		}
+		switch p {
+		case "*":
+			matched = true
+		case "external:*":
+			matched = matched || isExternal(repo)
+		case "external:http:*":
+			matched = matched || (isExternal(repo) && strings.HasPrefix(string(repo.URL), "http:"))
+		default:
+			matched = matched || p == string(repo.ID)
+		}
+	}
+	return matched
+}
+
+// isExternal reports whether the repository is neither on the local host
+// nor a file repository.
+func isExternal(repo Repository) bool {
+	u, err := url.Parse(string(repo.URL))
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "file" {
+		return false
+	}
+	host := u.Hostname()
+	return host != "localhost" && host != "127.0.0.1"
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const settingsXML = `
+<settings>
+  <localRepository>/home/user/.m2/repository</localRepository>
+  <offline>false</offline>
+  <servers>
+    <server>
+      <id>corp</id>
+      <username>user</username>
+      <password>secret</password>
+    </server>
+  </servers>
+  <mirrors>
+    <mirror>
+      <id>corp</id>
+      <name>Corporate mirror</name>
+      <url>https://maven.corp.example.com/all</url>
+      <mirrorOf>external:*,!internal</mirrorOf>
+    </mirror>
+    <mirror>
+      <id>corp-snapshots</id>
+      <url>https://maven.corp.example.com/snapshots</url>
+      <mirrorOf>snapshots</mirrorOf>
+    </mirror>
+  </mirrors>
+  <profiles>
+    <profile>
+      <id>corp</id>
+      <repositories>
+        <repository>
+          <id>internal</id>
+          <url>https://internal.corp.example.com/maven</url>
+        </repository>
+      </repositories>
+    </profile>
+    <profile>
+      <id>jdk11</id>
+      <activation>
+        <jdk>[11,12)</jdk>
+      </activation>
+      <properties>
+        <java.version>11</java.version>
+      </properties>
+    </profile>
+    <profile>
+      <id>default</id>
+      <activation>
+        <activeByDefault>true</activeByDefault>
+      </activation>
+    </profile>
+  </profiles>
+  <activeProfiles>
+    <activeProfile>corp</activeProfile>
+  </activeProfiles>
+</settings>
+`
+
+func TestSettings(t *testing.T) {
+	var s Settings
+	if err := xml.Unmarshal([]byte(settingsXML), &s); err != nil {
+		t.Fatalf("failed to unmarshal settings: %v", err)
+	}
+	if s.LocalRepository != "/home/user/.m2/repository" {
+		t.Errorf("local repository: got %s", s.LocalRepository)
+	}
+	if s.Offline.Boolean() {
+		t.Errorf("offline: got true, want false")
+	}
+	wantServers := []Server{{ID: "corp", Username: "user", Password: "secret"}}
+	if diff := cmp.Diff(wantServers, s.Servers); diff != "" {
+		t.Errorf("servers (-want +got):\n%s", diff)
+	}
+
+	ids := func(profs []Profile) []String {
+		var ids []String
+		for _, p := range profs {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+	for _, test := range []struct {
+		jdk  string
+		want []String
+	}{
+		{"11.0.2", []String{"corp", "jdk11"}},
+		{"17", []String{"corp"}},
+	} {
+		active, err := s.Active(test.jdk, ActivationOS{})
+		if err != nil {
+			t.Fatalf("Active(%s): %v", test.jdk, err)
+		}
+		if diff := cmp.Diff(test.want, ids(active)); diff != "" {
+			t.Errorf("Active(%s) (-want +got):\n%s", test.jdk, diff)
+		}
+	}
+
+	s.ActiveProfiles = nil
+	active, err := s.Active("17", ActivationOS{})
+	if err != nil {
+		t.Fatalf("Active: %v", err)
+	}
+	if diff := cmp.Diff([]String{"default"}, ids(active)); diff != "" {
+		t.Errorf("Active without active profiles (-want +got):\n%s", diff)
+	}
+}
+
+func TestSettingsMirror(t *testing.T) {
+	var s Settings
+	if err := xml.Unmarshal([]byte(settingsXML), &s); err != nil {
+		t.Fatalf("failed to unmarshal settings: %v", err)
+	}
+	for _, test := range []struct {
+		repo Repository
+		want String
+		ok   bool
+	}{
+		{Repository{ID: CentralID, URL: MavenCentral}, "corp", true},
+		{Repository{ID: "snapshots", URL: "https://snapshots.example.com"}, "corp-snapshots", true},
+		{Repository{ID: "internal", URL: "https://internal.corp.example.com/maven"}, "", false},
+		{Repository{ID: "local", URL: "http://localhost:8080/maven"}, "", false},
+		{Repository{ID: "file", URL: "file:///home/user/repo"}, "", false},
+	} {
+		m, ok := s.Mirror(test.repo)
+		if ok != test.ok || m.ID != test.want {
+			t.Errorf("Mirror(%s): got %s, %t, want %s, %t", test.repo.ID, m.ID, ok, test.want, test.ok)
+		}
+	}
+
+	for _, test := range []struct {
+		pattern string
+		repo    Repository
+		want    bool
+	}{
+		{"*", Repository{ID: "a", URL: "file:///repo"}, true},
+		{"*,!a", Repository{ID: "a", URL: "https://a.example.com"}, false},
+		{"a,b", Repository{ID: "b", URL: "https://b.example.com"}, true},
+		{"a,b", Repository{ID: "c", URL: "https://c.example.com"}, false},
+		{"external:http:*", Repository{ID: "a", URL: "http://a.example.com"}, true},
+		{"external:http:*", Repository{ID: "a", URL: "https://a.example.com"}, false},
+		{"external:*", Repository{ID: "a", URL: "http://127.0.0.1/repo"}, false},
+	} {
+		if got := mirrorOf(test.pattern, test.repo); got != test.want {
+			t.Errorf("mirrorOf(%q, %s): got %t, want %t", test.pattern, test.repo.URL, got, test.want)
+		}
+	}
+}
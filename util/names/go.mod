@@ -0,0 +1,3 @@
+module deps.dev/util/names
+
+go 1.23.4
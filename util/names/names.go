@@ -0,0 +1,193 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package names normalizes package names to the form used by deps.dev, and
+encodes them for the paths of the deps.dev API.
+
+Each packaging system has its own rules: Python package names are
+normalized as specified by PEP 503, the scopes of npm packages are lower
+case, Maven packages are named by their group and artifact IDs joined by a
+colon, Go module paths may be case-encoded as in module proxies, and NuGet
+package names are case-insensitive.
+
+Systems are named as in the deps.dev API, such as "NPM" or "PYPI".
+*/
+package names
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Normalize returns the name of the given package as known to deps.dev.
+// The name may be encoded as in API paths, such as "%40types%2Fnode". An
+// error is returned if the name is invalid for the system.
+func Normalize(system, name string) (string, error) {
+	name, err := FromAPIKey(name)
+	if err != nil {
+		return "", err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("empty package name")
+	}
+	switch strings.ToUpper(system) {
+	case "NPM":
+		return NPM(name)
+	case "PYPI":
+		return PyPI(name), nil
+	case "MAVEN":
+		group, artifact, err := SplitMaven(name)
+		if err != nil {
+			return "", err
+		}
+		return Maven(group, artifact), nil
+	case "GO":
+		return GoModule(name)
+	case "CARGO", "NUGET":
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown system %q", system)
+}
+
+// Equal reports whether the two names denote the same package of the given
+// system, once normalized. NuGet and Cargo package names are compared
+// case-insensitively.
+func Equal(system, a, b string) bool {
+	na, err := Normalize(system, a)
+	if err != nil {
+		return false
+	}
+	nb, err := Normalize(system, b)
+	if err != nil {
+		return false
+	}
+	switch strings.ToUpper(system) {
+	case "NUGET", "CARGO":
+		return strings.EqualFold(na, nb)
+	}
+	return na == nb
+}
+
+// ToAPIKey encodes the name for use as a path segment of the deps.dev API.
+// In addition to the characters escaped in URL paths, the "@" of npm scopes
+// and the ":" of Maven names are escaped.
+func ToAPIKey(name string) string {
+	escaped := url.PathEscape(name)
+	escaped = strings.ReplaceAll(escaped, "@", "%40")
+	return strings.ReplaceAll(escaped, ":", "%3A")
+}
+
+// FromAPIKey decodes a name encoded as a path segment of the deps.dev API.
+func FromAPIKey(key string) (string, error) {
+	name, err := url.PathUnescape(key)
+	if err != nil {
+		return "", fmt.Errorf("invalid package name %q: %w", key, err)
+	}
+	return name, nil
+}
+
+// pypiSeparators matches the runs of separators replaced by PEP 503.
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// PyPI returns the normalized form of a Python package name, as specified by
+// PEP 503: lower case, with runs of "-", "_" and "." replaced by a single
+// "-".
+// https://peps.python.org/pep-0503/#normalized-names
+func PyPI(name string) string {
+	return pypiSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// NPM returns the normalized form of an npm package name. The scope of a
+// scoped package is lower case, as npm requires, while the case of the
+// rest of the name is kept as some legacy packages have upper case names.
+func NPM(name string) (string, error) {
+	scope, rest, ok := strings.Cut(name, "/")
+	if !ok {
+		if strings.HasPrefix(name, "@") {
+			return "", fmt.Errorf("invalid npm package name %q: missing name after scope", name)
+		}
+		return name, nil
+	}
+	if !strings.HasPrefix(scope, "@") || len(scope) == 1 || rest == "" || strings.Contains(rest, "/") {
+		return "", fmt.Errorf("invalid npm package name %q", name)
+	}
+	return strings.ToLower(scope) + "/" + rest, nil
+}
+
+// Maven returns the name of the Maven package of the given group and
+// artifact IDs.
+func Maven(group, artifact string) string {
+	return group + ":" + artifact
+}
+
+// SplitMaven returns the group and artifact IDs of a Maven package name.
+func SplitMaven(name string) (group, artifact string, err error) {
+	group, artifact, ok := strings.Cut(name, ":")
+	group, artifact = strings.TrimSpace(group), strings.TrimSpace(artifact)
+	if !ok || group == "" || artifact == "" || strings.Contains(artifact, ":") {
+		return "", "", fmt.Errorf("invalid Maven package name %q: want groupID:artifactID", name)
+	}
+	return group, artifact, nil
+}
+
+// GoModule returns the path of a Go module, decoding it if it is
+// case-encoded as in module proxies, where upper case letters are written
+// as "!" followed by the lower case letter.
+// https://go.dev/ref/mod#goproxy-protocol
+func GoModule(path string) (string, error) {
+	if !strings.Contains(path, "!") {
+		return path, nil
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range path {
+		switch {
+		case escaped:
+			if r < 'a' || r > 'z' {
+				return "", fmt.Errorf("invalid escaped module path %q", path)
+			}
+			b.WriteRune(r - 'a' + 'A')
+			escaped = false
+		case r == '!':
+			escaped = true
+		case 'A' <= r && r <= 'Z':
+			return "", fmt.Errorf("invalid escaped module path %q: upper case letter", path)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if escaped {
+		return "", fmt.Errorf("invalid escaped module path %q", path)
+	}
+	return b.String(), nil
+}
+
+// EscapeGoModule returns the case-encoded form of a Go module path, as used
+// by module proxies.
+func EscapeGoModule(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			b.WriteRune(r - 'A' + 'a')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package names
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	for _, test := range []struct {
+		system, name string
+		want         string
+		wantErr      bool
+	}{
+		{"PYPI", "Django", "django", false},
+		{"pypi", "zope.interface", "zope-interface", false},
+		{"PYPI", "Typing__Extensions", "typing-extensions", false},
+		{"NPM", "@Types/node", "@types/node", false},
+		{"NPM", "%40types%2Fnode", "@types/node", false},
+		{"NPM", "JSONStream", "JSONStream", false},
+		{"NPM", "@types", "", true},
+		{"NPM", "@types/node/x", "", true},
+		{"MAVEN", "org.apache.commons:commons-lang3", "org.apache.commons:commons-lang3", false},
+		{"MAVEN", "org.apache.commons%3Acommons-lang3", "org.apache.commons:commons-lang3", false},
+		{"MAVEN", " org.example : lib ", "org.example:lib", false},
+		{"MAVEN", "org.example", "", true},
+		{"MAVEN", "org.example:lib:1.0", "", true},
+		{"GO", "github.com/!burnt!sushi/toml", "github.com/BurntSushi/toml", false},
+		{"GO", "github.com/BurntSushi/toml", "github.com/BurntSushi/toml", false},
+		{"GO", "github.com/!Burnt/toml", "", true},
+		{"GO", "github.com/x!", "", true},
+		{"NUGET", "Newtonsoft.Json", "Newtonsoft.Json", false},
+		{"CARGO", "serde", "serde", false},
+		{"NPM", "", "", true},
+		{"RUBYGEMS", "rails", "", true},
+	} {
+		got, err := Normalize(test.system, test.name)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Normalize(%s, %q): got %q, want error", test.system, test.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Normalize(%s, %q): %v", test.system, test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Normalize(%s, %q): got %q, want %q", test.system, test.name, got, test.want)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	for _, test := range []struct {
+		system, a, b string
+		want         bool
+	}{
+		{"NUGET", "Newtonsoft.Json", "newtonsoft.json", true},
+		{"PYPI", "Flask_SQLAlchemy", "flask-sqlalchemy", true},
+		{"NPM", "@Babel/core", "@babel/core", true},
+		{"NPM", "JSONStream", "jsonstream", false},
+		{"GO", "github.com/!azure/go-autorest", "github.com/Azure/go-autorest", true},
+		{"MAVEN", "org.example:lib", "org.example:Lib", false},
+		{"MAVEN", "invalid", "invalid", false},
+	} {
+		if got := Equal(test.system, test.a, test.b); got != test.want {
+			t.Errorf("Equal(%s, %q, %q): got %t, want %t", test.system, test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	for _, test := range []struct {
+		name, key string
+	}{
+		{"@colors/colors", "%40colors%2Fcolors"},
+		{"org.apache.logging.log4j:log4j-core", "org.apache.logging.log4j%3Alog4j-core"},
+		{"github.com/google/go-cmp", "github.com%2Fgoogle%2Fgo-cmp"},
+		{"left-pad", "left-pad"},
+	} {
+		if got := ToAPIKey(test.name); got != test.key {
+			t.Errorf("ToAPIKey(%q): got %q, want %q", test.name, got, test.key)
+		}
+		if got, err := FromAPIKey(test.key); err != nil || got != test.name {
+			t.Errorf("FromAPIKey(%q): got %q, %v, want %q", test.key, got, err, test.name)
+		}
+	}
+	if _, err := FromAPIKey("%zz"); err == nil {
+		t.Errorf("FromAPIKey(%%zz): got no error")
+	}
+}
+
+func TestGoModule(t *testing.T) {
+	for _, path := range []string{"github.com/BurntSushi/toml", "github.com/google/go-cmp"} {
+		escaped := EscapeGoModule(path)
+		got, err := GoModule(escaped)
+		if err != nil || got != path {
+			t.Errorf("GoModule(EscapeGoModule(%q) = %q): got %q, %v", path, escaped, got, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nuget
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+// The types of the dependencies of a lock file.
+const (
+	Direct            = "Direct"
+	Transitive        = "Transitive"
+	CentralTransitive = "CentralTransitive"
+	ProjectReference  = "Project"
+)
+
+// LockFile holds the contents of a packages.lock.json.
+// https://learn.microsoft.com/en-us/nuget/consume-packages/package-references-in-project-files#locking-dependencies
+type LockFile struct {
+	Version int `json:"version"`
+	// Dependencies maps the target frameworks, optionally followed by a
+	// runtime identifier as in "net8.0/linux-x64", to the locked
+	// dependencies by package name.
+	Dependencies map[string]map[string]LockedDependency `json:"dependencies"`
+}
+
+// LockedDependency is a dependency of a lock file.
+type LockedDependency struct {
+	// Type is Direct, Transitive, CentralTransitive or Project.
+	Type string `json:"type"`
+	// Requested is the requirement of a direct dependency.
+	Requested string `json:"requested"`
+	// Resolved is the version selected.
+	Resolved    string `json:"resolved"`
+	ContentHash string `json:"contentHash"`
+	// Dependencies maps the names of the dependencies of the package to
+	// their requirements.
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// ParseLockFile parses a packages.lock.json.
+func ParseLockFile(data []byte) (*LockFile, error) {
+	var l LockFile
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file: %w", err)
+	}
+	if l.Version != 1 && l.Version != 2 {
+		return nil, fmt.Errorf("unsupported lock file version %d", l.Version)
+	}
+	return &l, nil
+}
+
+// Frameworks returns the target frameworks of the lock file, sorted.
+func (l *LockFile) Frameworks() []string {
+	var frameworks []string
+	for tf := range l.Dependencies {
+		if !strings.Contains(tf, "/") {
+			frameworks = append(frameworks, tf)
+		}
+	}
+	sort.Strings(frameworks)
+	return frameworks
+}
+
+// VersionKeys returns the versions of the packages locked for any target,
+// sorted and without duplicates. Project references are left out.
+func (l *LockFile) VersionKeys() []resolve.VersionKey {
+	seen := make(map[resolve.VersionKey]bool)
+	var vks []resolve.VersionKey
+	for _, deps := range l.Dependencies {
+		for name, d := range deps {
+			if d.Type == ProjectReference || d.Resolved == "" {
+				continue
+			}
+			vk := resolve.VersionKey{
+				PackageKey: resolve.PackageKey{
+					System: resolve.NuGet,
+					Name:   name,
+				},
+				VersionType: resolve.Concrete,
+				Version:     d.Resolved,
+			}
+			if !seen[vk] {
+				seen[vk] = true
+				vks = append(vks, vk)
+			}
+		}
+	}
+	sort.Slice(vks, func(i, j int) bool { return vks[i].Less(vks[j]) })
+	return vks
+}
+
+// Requirements returns the requirements of the direct dependencies, by
+// target framework and then by name. Their types hold the target framework
+// in their Framework attribute.
+func (l *LockFile) Requirements() []resolve.RequirementVersion {
+	var reqs []resolve.RequirementVersion
+	for _, tf := range l.Frameworks() {
+		deps := l.Dependencies[tf]
+		names := make([]string, 0, len(deps))
+		for name, d := range deps {
+			if d.Type == Direct {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			reqs = append(reqs, resolve.RequirementVersion{
+				VersionKey: resolve.VersionKey{
+					PackageKey: resolve.PackageKey{
+						System: resolve.NuGet,
+						Name:   name,
+					},
+					VersionType: resolve.Requirement,
+					Version:     deps[name].Requested,
+				},
+				Type: dep.NewBuilder().Framework(tf).Type(),
+			})
+		}
+	}
+	return reqs
+}
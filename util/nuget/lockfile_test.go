@@ -0,0 +1,108 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nuget
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+const lockFile = `{
+  "version": 1,
+  "dependencies": {
+    "net8.0": {
+      "Serilog": {
+        "type": "Direct",
+        "requested": "[3.1.1, )",
+        "resolved": "3.1.1",
+        "contentHash": "P6G1mNV1Ff5=",
+        "dependencies": {
+          "System.Memory": "4.5.5"
+        }
+      },
+      "System.Memory": {
+        "type": "Transitive",
+        "resolved": "4.5.5",
+        "contentHash": "XIWiDvKPXa4="
+      },
+      "lib": {
+        "type": "Project"
+      }
+    },
+    "net6.0": {
+      "Serilog": {
+        "type": "Direct",
+        "requested": "[3.0.0, )",
+        "resolved": "3.0.0"
+      },
+      "System.Memory": {
+        "type": "CentralTransitive",
+        "requested": "[4.5.5, )",
+        "resolved": "4.5.5"
+      }
+    },
+    "net8.0/linux-x64": {
+      "runtime.linux-x64.Native": {
+        "type": "Transitive",
+        "resolved": "1.0.0"
+      }
+    }
+  }
+}`
+
+func TestLockFile(t *testing.T) {
+	l, err := ParseLockFile([]byte(lockFile))
+	if err != nil {
+		t.Fatalf("ParseLockFile: %v", err)
+	}
+	if diff := cmp.Diff([]string{"net6.0", "net8.0"}, l.Frameworks()); diff != "" {
+		t.Errorf("Frameworks (-want +got):\n%s", diff)
+	}
+
+	vk := func(name, version string) resolve.VersionKey {
+		return resolve.VersionKey{
+			PackageKey:  resolve.PackageKey{System: resolve.NuGet, Name: name},
+			VersionType: resolve.Concrete,
+			Version:     version,
+		}
+	}
+	wantVKs := []resolve.VersionKey{
+		vk("Serilog", "3.0.0"),
+		vk("Serilog", "3.1.1"),
+		vk("System.Memory", "4.5.5"),
+		vk("runtime.linux-x64.Native", "1.0.0"),
+	}
+	if diff := cmp.Diff(wantVKs, l.VersionKeys()); diff != "" {
+		t.Errorf("VersionKeys (-want +got):\n%s", diff)
+	}
+
+	wantReqs := []resolve.RequirementVersion{
+		requirement("Serilog", "[3.0.0, )", dep.NewBuilder().Framework("net6.0")),
+		requirement("Serilog", "[3.1.1, )", dep.NewBuilder().Framework("net8.0")),
+	}
+	if diff := cmp.Diff(wantReqs, l.Requirements()); diff != "" {
+		t.Errorf("Requirements (-want +got):\n%s", diff)
+	}
+
+	for _, bad := range []string{`{"version": 3}`, `{"version": "1"}`} {
+		if _, err := ParseLockFile([]byte(bad)); err == nil {
+			t.Errorf("ParseLockFile(%s): got no error", bad)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package nuget parses the NuGet dependencies of .NET projects: the
+PackageReference items of MSBuild project files such as .csproj, the
+centrally managed versions of Directory.Packages.props, and
+packages.lock.json lock files. Dependencies are returned as resolve
+requirements and version keys.
+*/
+package nuget
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+// Project holds the elements of an MSBuild project file, such as a .csproj
+// or a Directory.Packages.props, relevant to its NuGet dependencies.
+// https://learn.microsoft.com/en-us/nuget/consume-packages/package-references-in-project-files
+type Project struct {
+	SDK            string          `xml:"Sdk,attr"`
+	PropertyGroups []PropertyGroup `xml:"PropertyGroup"`
+	ItemGroups     []ItemGroup     `xml:"ItemGroup"`
+}
+
+// PropertyGroup is a group of MSBuild properties.
+type PropertyGroup struct {
+	Condition  string     `xml:"Condition,attr"`
+	Properties []Property `xml:",any"`
+}
+
+// Property is an MSBuild property.
+type Property struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// ItemGroup is a group of MSBuild items.
+type ItemGroup struct {
+	Condition         string             `xml:"Condition,attr"`
+	PackageReferences []PackageReference `xml:"PackageReference"`
+	// PackageVersions holds the centrally managed versions, in a
+	// Directory.Packages.props.
+	PackageVersions []PackageReference `xml:"PackageVersion"`
+	// GlobalPackageReferences holds the references added to all the
+	// projects, in a Directory.Packages.props.
+	GlobalPackageReferences []PackageReference `xml:"GlobalPackageReference"`
+}
+
+// PackageReference is a PackageReference, PackageVersion or
+// GlobalPackageReference item. Its metadata may be given either as
+// attributes or as child elements.
+type PackageReference struct {
+	// Include is the name of the package. Update is set instead for the
+	// items updating the metadata of previously included references.
+	Include         string
+	Update          string
+	Condition       string
+	Version         string
+	VersionOverride string
+	PrivateAssets   string
+}
+
+func (r *PackageReference) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var x struct {
+		Include             string `xml:"Include,attr"`
+		Update              string `xml:"Update,attr"`
+		Condition           string `xml:"Condition,attr"`
+		Version             string `xml:"Version,attr"`
+		VersionElem         string `xml:"Version"`
+		VersionOverride     string `xml:"VersionOverride,attr"`
+		VersionOverrideElem string `xml:"VersionOverride"`
+		PrivateAssets       string `xml:"PrivateAssets,attr"`
+		PrivateAssetsElem   string `xml:"PrivateAssets"`
+	}
+	if err := d.DecodeElement(&x, &start); err != nil {
+		return err
+	}
+	or := func(a, b string) string {
+		if a != "" {
+			return a
+		}
+		return strings.TrimSpace(b)
+	}
+	*r = PackageReference{
+		Include:         x.Include,
+		Update:          x.Update,
+		Condition:       x.Condition,
+		Version:         or(x.Version, x.VersionElem),
+		VersionOverride: or(x.VersionOverride, x.VersionOverrideElem),
+		PrivateAssets:   or(x.PrivateAssets, x.PrivateAssetsElem),
+	}
+	return nil
+}
+
+// ParseProject parses an MSBuild project file.
+func ParseProject(r io.Reader) (*Project, error) {
+	var p Project
+	if err := xml.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse project: %w", err)
+	}
+	return &p, nil
+}
+
+// Property returns the value of the given property, as last set outside
+// any condition, and whether it is set.
+func (p *Project) Property(name string) (string, bool) {
+	value, found := "", false
+	for _, g := range p.PropertyGroups {
+		if g.Condition != "" {
+			continue
+		}
+		for _, prop := range g.Properties {
+			if strings.EqualFold(prop.XMLName.Local, name) {
+				value, found = strings.TrimSpace(prop.Value), true
+			}
+		}
+	}
+	return value, found
+}
+
+// TargetFrameworks returns the target frameworks of the project, from the
+// TargetFramework or TargetFrameworks properties.
+func (p *Project) TargetFrameworks() []string {
+	if tf, ok := p.Property("TargetFramework"); ok && tf != "" {
+		return []string{tf}
+	}
+	tfs, _ := p.Property("TargetFrameworks")
+	var frameworks []string
+	for _, tf := range strings.Split(tfs, ";") {
+		if tf = strings.TrimSpace(tf); tf != "" {
+			frameworks = append(frameworks, tf)
+		}
+	}
+	return frameworks
+}
+
+// centrallyManaged reports whether the versions of the packages are
+// managed centrally.
+func (p *Project) centrallyManaged() bool {
+	v, _ := p.Property("ManagePackageVersionsCentrally")
+	return strings.EqualFold(v, "true")
+}
+
+var (
+	// frameworkCondition matches the conditions on the target framework.
+	frameworkCondition = regexp.MustCompile(`^\s*'\$\(TargetFramework\)'\s*==\s*'([^']+)'\s*$`)
+	// propertyRef matches the references to properties.
+	propertyRef = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_.-]*)\)`)
+)
+
+// Requirements returns the requirements on the packages referenced by the
+// project, in order. If central is not nil, it is the
+// Directory.Packages.props of the project, providing the versions of the
+// references when central package management is enabled, and the global
+// package references.
+//
+// The types of the requirements hold:
+//   - Framework for the references conditioned on a target framework;
+//   - Environment for the references under other conditions, set to the
+//     conditions;
+//   - Dev for the references whose assets are private, such as analyzers
+//     and global package references.
+//
+// References to properties in versions are expanded from the properties of
+// the project and of central.
+func (p *Project) Requirements(central *Project) ([]resolve.RequirementVersion, error) {
+	props := make(map[string]string)
+	for _, proj := range []*Project{central, p} {
+		if proj == nil {
+			continue
+		}
+		for _, g := range proj.PropertyGroups {
+			if g.Condition != "" {
+				continue
+			}
+			for _, prop := range g.Properties {
+				props[strings.ToLower(prop.XMLName.Local)] = strings.TrimSpace(prop.Value)
+			}
+		}
+	}
+	expand := func(s string) string {
+		return propertyRef.ReplaceAllStringFunc(s, func(ref string) string {
+			return props[strings.ToLower(ref[2:len(ref)-1])]
+		})
+	}
+
+	managed := p.centrallyManaged()
+	versions := make(map[string]string)
+	var refs []PackageReference
+	if central != nil {
+		managed = managed || central.centrallyManaged()
+		for _, g := range central.ItemGroups {
+			for _, v := range g.PackageVersions {
+				versions[strings.ToLower(v.Include)] = v.Version
+			}
+			for _, ref := range g.GlobalPackageReferences {
+				ref.Condition = joinConditions(g.Condition, ref.Condition)
+				ref.PrivateAssets = "all"
+				refs = append(refs, ref)
+			}
+		}
+	}
+	for _, g := range p.ItemGroups {
+		for _, ref := range g.PackageReferences {
+			ref.Condition = joinConditions(g.Condition, ref.Condition)
+			if ref.Include != "" {
+				refs = append(refs, ref)
+				continue
+			}
+			// Update the metadata of the references already included.
+			for i := range refs {
+				if !strings.EqualFold(refs[i].Include, ref.Update) {
+					continue
+				}
+				if ref.Version != "" {
+					refs[i].Version = ref.Version
+				}
+				if ref.VersionOverride != "" {
+					refs[i].VersionOverride = ref.VersionOverride
+				}
+				if ref.PrivateAssets != "" {
+					refs[i].PrivateAssets = ref.PrivateAssets
+				}
+			}
+		}
+	}
+
+	var reqs []resolve.RequirementVersion
+	for _, ref := range refs {
+		version := ref.Version
+		if managed {
+			if v, ok := versions[strings.ToLower(ref.Include)]; ok {
+				version = v
+			}
+		}
+		if ref.VersionOverride != "" {
+			version = ref.VersionOverride
+		}
+		version = expand(version)
+		if version == "" {
+			return nil, fmt.Errorf("no version for package %s", ref.Include)
+		}
+		b := dep.NewBuilder()
+		if m := frameworkCondition.FindStringSubmatch(ref.Condition); m != nil {
+			b.Framework(m[1])
+		} else if ref.Condition != "" {
+			b.Environment(ref.Condition)
+		}
+		if strings.EqualFold(ref.PrivateAssets, "all") {
+			b.Dev()
+		}
+		reqs = append(reqs, resolve.RequirementVersion{
+			VersionKey: resolve.VersionKey{
+				PackageKey: resolve.PackageKey{
+					System: resolve.NuGet,
+					Name:   ref.Include,
+				},
+				VersionType: resolve.Requirement,
+				Version:     version,
+			},
+			Type: b.Type(),
+		})
+	}
+	return reqs, nil
+}
+
+// joinConditions returns the conjunction of the given MSBuild conditions.
+func joinConditions(a, b string) string {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return "(" + a + ") And (" + b + ")"
+}
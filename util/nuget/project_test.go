@@ -0,0 +1,131 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nuget
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+func requirement(name, version string, b *dep.Builder) resolve.RequirementVersion {
+	return resolve.RequirementVersion{
+		VersionKey: resolve.VersionKey{
+			PackageKey:  resolve.PackageKey{System: resolve.NuGet, Name: name},
+			VersionType: resolve.Requirement,
+			Version:     version,
+		},
+		Type: b.Type(),
+	}
+}
+
+func TestProject(t *testing.T) {
+	p, err := ParseProject(strings.NewReader(`
+<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFrameworks>net6.0;net8.0</TargetFrameworks>
+    <SerilogVersion>3.1.1</SerilogVersion>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" Version="13.0.1" />
+    <PackageReference Include="Serilog" Version="$(SerilogVersion)" />
+    <PackageReference Include="StyleCop.Analyzers">
+      <Version>1.1.118</Version>
+      <PrivateAssets>all</PrivateAssets>
+    </PackageReference>
+    <PackageReference Update="Newtonsoft.Json" Version="13.0.3" />
+  </ItemGroup>
+  <ItemGroup Condition="'$(TargetFramework)' == 'net6.0'">
+    <PackageReference Include="System.Text.Json" Version="[6.0.0, 7.0.0)" />
+  </ItemGroup>
+  <ItemGroup>
+    <PackageReference Include="Windows.Only" Version="1.0" Condition="'$(OS)' == 'Windows_NT'" />
+  </ItemGroup>
+</Project>
+`))
+	if err != nil {
+		t.Fatalf("ParseProject: %v", err)
+	}
+	if diff := cmp.Diff([]string{"net6.0", "net8.0"}, p.TargetFrameworks()); diff != "" {
+		t.Errorf("TargetFrameworks (-want +got):\n%s", diff)
+	}
+	got, err := p.Requirements(nil)
+	if err != nil {
+		t.Fatalf("Requirements: %v", err)
+	}
+	want := []resolve.RequirementVersion{
+		requirement("Newtonsoft.Json", "13.0.3", dep.NewBuilder()),
+		requirement("Serilog", "3.1.1", dep.NewBuilder()),
+		requirement("StyleCop.Analyzers", "1.1.118", dep.NewBuilder().Dev()),
+		requirement("System.Text.Json", "[6.0.0, 7.0.0)", dep.NewBuilder().Framework("net6.0")),
+		requirement("Windows.Only", "1.0", dep.NewBuilder().Environment("'$(OS)' == 'Windows_NT'")),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Requirements (-want +got):\n%s", diff)
+	}
+}
+
+func TestProjectCentralPackageManagement(t *testing.T) {
+	central, err := ParseProject(strings.NewReader(`
+<Project>
+  <PropertyGroup>
+    <ManagePackageVersionsCentrally>true</ManagePackageVersionsCentrally>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageVersion Include="Newtonsoft.Json" Version="13.0.3" />
+    <PackageVersion Include="xunit" Version="2.6.0" />
+    <GlobalPackageReference Include="Nerdbank.GitVersioning" Version="3.6.133" />
+  </ItemGroup>
+</Project>
+`))
+	if err != nil {
+		t.Fatalf("ParseProject central: %v", err)
+	}
+	p, err := ParseProject(strings.NewReader(`
+<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageReference Include="newtonsoft.json" />
+    <PackageReference Include="xunit" VersionOverride="2.7.0" />
+  </ItemGroup>
+</Project>
+`))
+	if err != nil {
+		t.Fatalf("ParseProject: %v", err)
+	}
+	got, err := p.Requirements(central)
+	if err != nil {
+		t.Fatalf("Requirements: %v", err)
+	}
+	want := []resolve.RequirementVersion{
+		requirement("Nerdbank.GitVersioning", "3.6.133", dep.NewBuilder().Dev()),
+		requirement("newtonsoft.json", "13.0.3", dep.NewBuilder()),
+		requirement("xunit", "2.7.0", dep.NewBuilder()),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Requirements (-want +got):\n%s", diff)
+	}
+
+	// Without the central versions, the reference has no version.
+	if _, err := p.Requirements(nil); err == nil {
+		t.Errorf("Requirements without central versions: got no error")
+	}
+}
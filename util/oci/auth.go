@@ -0,0 +1,134 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// dockerConfig holds the parts of a docker config.json that concern
+// registry credentials.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth     string `json:"auth"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// DockerCredentials returns the credentials that docker would use for the
+// given registry host, as recorded by docker login in the config.json file
+// of the directory named by $DOCKER_CONFIG, or of ~/.docker by default.
+// Credentials kept by a credential helper are obtained by running it. If
+// there is no config file or it holds no credentials for the host, the
+// credentials are empty.
+func DockerCredentials(host string) (user, password string, err error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	var c dockerConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return "", "", fmt.Errorf("parsing docker config: %w", err)
+	}
+	// Docker Hub credentials are recorded under the index's old URL.
+	keys := []string{host}
+	if host == "registry-1.docker.io" {
+		keys = []string{"https://index.docker.io/v1/", "index.docker.io", "docker.io", host}
+	}
+	for _, k := range keys {
+		if h := c.CredHelpers[k]; h != "" {
+			return credentialHelper(h, k)
+		}
+	}
+	for key, a := range c.Auths {
+		if !matchesAny(key, keys) {
+			continue
+		}
+		if a.Auth == "" {
+			return a.Username, a.Password, nil
+		}
+		dec, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid auth for %s in docker config", key)
+		}
+		user, password, _ = strings.Cut(string(dec), ":")
+		return user, password, nil
+	}
+	if c.CredsStore != "" {
+		return credentialHelper(c.CredsStore, keys[0])
+	}
+	return "", "", nil
+}
+
+// matchesAny reports whether the given key of the auths of a docker config
+// refers to one of the given hosts. Keys may be URLs.
+func matchesAny(key string, hosts []string) bool {
+	h := strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	h, _, _ = strings.Cut(h, "/")
+	for _, host := range hosts {
+		if key == host || h == host {
+			return true
+		}
+	}
+	return false
+}
+
+// credentialHelper runs the named docker credential helper to get the
+// credentials for the given server. A helper knowing no credentials for the
+// server gives empty credentials.
+func credentialHelper(helper, server string) (user, password string, err error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(string(out), "credentials not found") {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("running credential helper %s: %v: %s", helper, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	var c struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(out, &c); err != nil {
+		return "", "", fmt.Errorf("parsing output of credential helper %s: %w", helper, err)
+	}
+	return c.Username, c.Secret, nil
+}
@@ -0,0 +1,239 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// ImageDiff describes the differences between two container images, as
+// returned by DiffImages.
+type ImageDiff struct {
+	// OldBase and NewBase are the base images of the old and new images.
+	OldBase, NewBase Base
+	// RemovedLayers holds the layers of the old image that are not in the
+	// new one, and AddedLayers those of the new image that are not in the
+	// old one, each from the base up.
+	RemovedLayers, AddedLayers []*Layer
+	// RemovedArtifacts holds the vendored artifacts found in the removed
+	// layers whose contents do not appear in the added layers, and
+	// AddedArtifacts the reverse.
+	RemovedArtifacts, AddedArtifacts []Artifact
+}
+
+// BaseChanged reports whether the old and new images have different base
+// images.
+func (d *ImageDiff) BaseChanged() bool {
+	if d.OldBase.ChainID != d.NewBase.ChainID {
+		return true
+	}
+	o, n := d.OldBase.Repositories, d.NewBase.Repositories
+	if len(o) != len(n) {
+		return true
+	}
+	for i := range o {
+		if o[i] != n[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Base is the base image of a container image: the topmost layer that
+// deps.dev knows of in any image repository on Docker Hub.
+type Base struct {
+	// Layers is the number of layers of the image that belong to the base
+	// image. It is zero if no base image was found.
+	Layers int
+	// ChainID is the Chain ID of the topmost layer of the base image.
+	ChainID string
+	// Repositories holds the image repositories with images sharing the
+	// base image's layers.
+	Repositories []string
+}
+
+// Artifact is a vendored package archive, such as a JAR or wheel, found in a
+// layer of a container image.
+type Artifact struct {
+	// Layer is the DiffID of the layer holding the artifact.
+	Layer string
+	// Path is the path of the artifact within the layer.
+	Path string
+	// SHA256 is the SHA-256 hash of the artifact's contents.
+	SHA256 [sha256.Size]byte
+	// Versions holds the package versions that deps.dev knows of with
+	// this content, if any.
+	Versions []*pb.VersionKey
+}
+
+// DiffImages compares the old and new container images, reporting their
+// base images, the layers added and removed, and the vendored artifacts
+// those layers add and remove. Base images are found with BaseImages and
+// artifacts are identified by querying their hashes with the Query method
+// of the deps.dev API. Layers are compared by DiffID, so a layer that is
+// rebuilt with the same contents is not reported.
+func DiffImages(ctx context.Context, c pb.InsightsClient, old, new *Image) (*ImageDiff, error) {
+	var d ImageDiff
+	var err error
+	if d.OldBase, err = baseOf(ctx, c, old); err != nil {
+		return nil, err
+	}
+	if d.NewBase, err = baseOf(ctx, c, new); err != nil {
+		return nil, err
+	}
+	d.RemovedLayers = layersNotIn(old.Layers, new.Layers)
+	d.AddedLayers = layersNotIn(new.Layers, old.Layers)
+
+	removed, err := artifacts(d.RemovedLayers)
+	if err != nil {
+		return nil, err
+	}
+	added, err := artifacts(d.AddedLayers)
+	if err != nil {
+		return nil, err
+	}
+	d.RemovedArtifacts = artifactsNotIn(removed, added)
+	d.AddedArtifacts = artifactsNotIn(added, removed)
+
+	versions := make(map[[sha256.Size]byte][]*pb.VersionKey)
+	for _, as := range [][]Artifact{d.RemovedArtifacts, d.AddedArtifacts} {
+		for i := range as {
+			a := &as[i]
+			vks, ok := versions[a.SHA256]
+			if !ok {
+				if vks, err = queryHash(ctx, c, a.SHA256); err != nil {
+					return nil, fmt.Errorf("querying %s: %w", a.Path, err)
+				}
+				versions[a.SHA256] = vks
+			}
+			a.Versions = vks
+		}
+	}
+	return &d, nil
+}
+
+// baseOf returns the base image of img.
+func baseOf(ctx context.Context, c pb.InsightsClient, img *Image) (Base, error) {
+	repos, err := BaseImages(ctx, c, img)
+	if err != nil {
+		return Base{}, err
+	}
+	for i := len(repos) - 1; i >= 0; i-- {
+		if len(repos[i]) > 0 {
+			return Base{
+				Layers:       i + 1,
+				ChainID:      img.Layers[i].ChainID,
+				Repositories: repos[i],
+			}, nil
+		}
+	}
+	return Base{}, nil
+}
+
+// layersNotIn returns the layers of a whose DiffIDs are not in b, counting
+// repeated layers.
+func layersNotIn(a, b []*Layer) []*Layer {
+	n := make(map[string]int)
+	for _, l := range b {
+		n[l.DiffID]++
+	}
+	var ls []*Layer
+	for _, l := range a {
+		if n[l.DiffID] > 0 {
+			n[l.DiffID]--
+			continue
+		}
+		ls = append(ls, l)
+	}
+	return ls
+}
+
+// artifactsNotIn returns the artifacts of a whose contents are not in b.
+func artifactsNotIn(a, b []Artifact) []Artifact {
+	in := make(map[[sha256.Size]byte]bool)
+	for _, x := range b {
+		in[x.SHA256] = true
+	}
+	var as []Artifact
+	for _, x := range a {
+		if !in[x.SHA256] {
+			as = append(as, x)
+		}
+	}
+	return as
+}
+
+// artifacts returns the vendored artifacts in the given layers.
+func artifacts(layers []*Layer) ([]Artifact, error) {
+	var as []Artifact
+	for _, l := range layers {
+		err := l.Walk(func(name string, r io.Reader) error {
+			if !isArtifact(name) {
+				return nil
+			}
+			h := sha256.New()
+			if _, err := io.Copy(h, r); err != nil {
+				return fmt.Errorf("reading %s: %w", name, err)
+			}
+			a := Artifact{Layer: l.DiffID, Path: name}
+			h.Sum(a.SHA256[:0])
+			as = append(as, a)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return as, nil
+}
+
+// isArtifact reports whether the named file is a kind of package archive
+// that deps.dev indexes by hash.
+func isArtifact(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasSuffix(name, ".tar.gz") {
+		return true
+	}
+	switch path.Ext(name) {
+	case ".jar", ".war", ".ear", ".aar", ".whl", ".egg", ".nupkg", ".tgz", ".crate", ".gem":
+		return true
+	}
+	return false
+}
+
+// queryHash returns the package versions with the given SHA-256 hash.
+func queryHash(ctx context.Context, c pb.InsightsClient, sum [sha256.Size]byte) ([]*pb.VersionKey, error) {
+	resp, err := c.Query(ctx, &pb.QueryRequest{
+		Hash: &pb.Hash{
+			Type:  pb.HashType_SHA256,
+			Value: sum[:],
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var vks []*pb.VersionKey
+	for _, r := range resp.GetResults() {
+		vks = append(vks, r.GetVersion().GetVersionKey())
+	}
+	return vks, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package oci reads container images, either stored on disk as an OCI image
+layout or as the docker-archive written by docker save, or held in a
+registry, and relates their layers to the images and packages known to
+deps.dev.
+
+Each layer of an Image carries its OCI Chain ID, which identifies the
+sequence of layers from the base of the image up to and including that
+layer. BaseImages uses the Chain IDs to find, for each layer, the image
+repositories whose images are built from the same layers. Layer.Walk exposes
+the files of a layer so that their hashes may be queried too.
+
+See https://github.com/opencontainers/image-spec/blob/main/image-layout.md
+and https://github.com/opencontainers/image-spec/blob/main/config.md#layer-chainid.
+*/
+package oci
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Image is a container image.
+type Image struct {
+	// Name is the reference of the image, from the
+	// io.containerd.image.name or org.opencontainers.image.ref.name
+	// annotation of an OCI image layout or the first of the RepoTags of a
+	// docker-archive. It may be empty.
+	Name string
+	// Platform is the platform the image is built for, as os/architecture
+	// with an optional /variant. It may be empty.
+	Platform string
+	// Layers holds the layers of the image, from the base up.
+	Layers []*Layer
+}
+
+// Layer is a layer of a container image.
+type Layer struct {
+	// Digest is the digest of the layer as stored, which may be
+	// compressed. It is empty if the docker-archive does not record it.
+	Digest string
+	// DiffID is the digest of the uncompressed layer.
+	DiffID string
+	// ChainID is the OCI Chain ID of this layer and all those below it.
+	ChainID string
+
+	// open returns the stored, possibly compressed, layer.
+	open func() (io.ReadCloser, error)
+}
+
+// ChainIDs returns the OCI Chain IDs of a sequence of layers with the given
+// DiffIDs, listed from the base up.
+func ChainIDs(diffIDs []string) []string {
+	ids := make([]string, len(diffIDs))
+	for i, d := range diffIDs {
+		if i == 0 {
+			ids[i] = d
+			continue
+		}
+		ids[i] = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(ids[i-1]+" "+d)))
+	}
+	return ids
+}
+
+// Open returns the uncompressed tar stream of the layer. Layers compressed
+// with gzip are decompressed; other compression formats are not supported.
+func (l *Layer) Open() (io.ReadCloser, error) {
+	f, err := l.open()
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		f.Close()
+		return nil, fmt.Errorf("reading layer %s: %w", l.DiffID, err)
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("reading layer %s: %w", l.DiffID, err)
+		}
+		return readCloser{gr, f}, nil
+	case bytes.Equal(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		f.Close()
+		return nil, fmt.Errorf("layer %s: zstd compression is not supported", l.DiffID)
+	}
+	return readCloser{br, f}, nil
+}
+
+// readCloser reads from one reader and closes an underlying file.
+type readCloser struct {
+	io.Reader
+	c io.Closer
+}
+
+func (r readCloser) Close() error {
+	return r.c.Close()
+}
+
+// WalkFunc is called by Layer.Walk for each regular file in a layer, with
+// the file's path within the layer and a reader of its contents that is
+// valid until the function returns.
+type WalkFunc func(name string, r io.Reader) error
+
+// Walk calls fn for each regular file in the layer, in the order they are
+// stored. Whiteout files, which mark the deletion of files from the layers
+// below, are skipped. If fn returns an error Walk stops and returns it.
+func (l *Layer) Walk(fn WalkFunc) error {
+	rc, err := l.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	tr := tar.NewReader(rc)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading layer %s: %w", l.DiffID, err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean("/" + h.Name)[1:]
+		if strings.HasPrefix(path.Base(name), ".wh.") {
+			continue
+		}
+		if err := fn(name, tr); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,441 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// makeTar returns a tar file holding the given files, in order.
+func makeTar(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		if err := tw.WriteHeader(&tar.Header{Name: files[i], Mode: 0o644, Size: int64(len(files[i+1])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func digest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// testLayers are the uncompressed layers of the test images.
+var testLayers = [][]string{
+	{"bin/sh", "shell", "etc/os-release", "ID=test"},
+	{"app/main.jar", "jar", "app/.wh.old.jar", ""},
+}
+
+// writeOCILayout writes an OCI image layout holding an image index with a
+// single image for linux/amd64 and an attestation. The index is given the
+// annotations.
+func writeOCILayout(t *testing.T, dir string, annotations map[string]string) (diffIDs []string) {
+	t.Helper()
+	blobs := make(map[string][]byte)
+	add := func(data []byte) string {
+		d := digest(data)
+		blobs[d] = data
+		return d
+	}
+	var layers []descriptor
+	for _, files := range testLayers {
+		l := makeTar(t, files...)
+		diffIDs = append(diffIDs, digest(l))
+		layers = append(layers, descriptor{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: add(gzipped(t, l))})
+	}
+	cfg := map[string]any{
+		"architecture": "amd64",
+		"os":           "linux",
+		"rootfs":       map[string]any{"type": "layers", "diff_ids": diffIDs},
+	}
+	m := map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     mediaTypeOCIManifest,
+		"config":        descriptor{Digest: add(mustJSON(t, cfg))},
+		"layers":        layers,
+	}
+	att := map[string]any{
+		"config": descriptor{Digest: add([]byte("{}"))},
+	}
+	idx := index{Manifests: []descriptor{
+		{MediaType: mediaTypeOCIManifest, Digest: add(mustJSON(t, m)), Platform: &platform{OS: "linux", Architecture: "amd64"}},
+		{MediaType: mediaTypeOCIManifest, Digest: add(mustJSON(t, att)), Platform: &platform{OS: "unknown", Architecture: "unknown"}},
+	}}
+	top := index{Manifests: []descriptor{
+		{MediaType: mediaTypeOCIIndex, Digest: add(mustJSON(t, idx)), Annotations: annotations},
+	}}
+	files := map[string][]byte{
+		"oci-layout": []byte(`{"imageLayoutVersion":"1.0.0"}`),
+		"index.json": mustJSON(t, top),
+	}
+	for d, data := range blobs {
+		files[filepath.Join("blobs", "sha256", d[len("sha256:"):])] = data
+	}
+	for name, data := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return diffIDs
+}
+
+// writeDockerArchive writes a legacy docker-archive, as written by docker
+// save before version 25, holding a single image.
+func writeDockerArchive(t *testing.T, name string) (diffIDs []string) {
+	t.Helper()
+	var files []string
+	var layerPaths []string
+	for i, lf := range testLayers {
+		l := makeTar(t, lf...)
+		diffIDs = append(diffIDs, digest(l))
+		p := fmt.Sprintf("./layer%d/layer.tar", i)
+		layerPaths = append(layerPaths, p)
+		files = append(files, p, string(l))
+	}
+	cfg := map[string]any{
+		"architecture": "arm64",
+		"variant":      "v8",
+		"os":           "linux",
+		"rootfs":       map[string]any{"type": "layers", "diff_ids": diffIDs},
+	}
+	manifest := []dockerManifest{{Config: "config.json", RepoTags: []string{"test:1.0"}, Layers: layerPaths}}
+	files = append(files,
+		"config.json", string(mustJSON(t, cfg)),
+		"manifest.json", string(mustJSON(t, manifest)),
+	)
+	if err := os.WriteFile(name, makeTar(t, files...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return diffIDs
+}
+
+func TestChainIDs(t *testing.T) {
+	diffIDs := []string{"sha256:a", "sha256:b", "sha256:c"}
+	second := digest([]byte("sha256:a sha256:b"))
+	want := []string{"sha256:a", second, digest([]byte(second + " sha256:c"))}
+	if got := ChainIDs(diffIDs); !reflect.DeepEqual(got, want) {
+		t.Errorf("ChainIDs(%v):\n got %v\nwant %v", diffIDs, got, want)
+	}
+}
+
+// checkImage checks that img is the test image.
+func checkImage(t *testing.T, img *Image, name, platform string, diffIDs []string, digests bool) {
+	t.Helper()
+	if img.Name != name || img.Platform != platform {
+		t.Errorf("got image %q for %q, want %q for %q", img.Name, img.Platform, name, platform)
+	}
+	if len(img.Layers) != len(testLayers) {
+		t.Fatalf("got %d layers, want %d", len(img.Layers), len(testLayers))
+	}
+	chainIDs := ChainIDs(diffIDs)
+	for i, l := range img.Layers {
+		if l.DiffID != diffIDs[i] || l.ChainID != chainIDs[i] {
+			t.Errorf("layer %d: got DiffID %s, ChainID %s, want %s, %s", i, l.DiffID, l.ChainID, diffIDs[i], chainIDs[i])
+		}
+		if (l.Digest != "") != digests {
+			t.Errorf("layer %d: got digest %q", i, l.Digest)
+		}
+		var got []string
+		err := l.Walk(func(name string, r io.Reader) error {
+			data, err := io.ReadAll(r)
+			got = append(got, name, string(data))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("layer %d: Walk: %v", i, err)
+		}
+		var want []string
+		for j := 0; j < len(testLayers[i]); j += 2 {
+			if !strings.HasPrefix(filepath.Base(testLayers[i][j]), ".wh.") {
+				want = append(want, testLayers[i][j:j+2]...)
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("layer %d: Walk:\n got %q\nwant %q", i, got, want)
+		}
+	}
+}
+
+func TestOCILayout(t *testing.T) {
+	dir := t.TempDir()
+	diffIDs := writeOCILayout(t, dir, map[string]string{annotationRefName: "test:latest"})
+	imgs, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if len(imgs) != 1 {
+		t.Fatalf("got %d images, want 1", len(imgs))
+	}
+	checkImage(t, imgs[0], "test:latest", "linux/amd64", diffIDs, true)
+}
+
+func TestOCILayoutTar(t *testing.T) {
+	dir := t.TempDir()
+	// As written by ctr export.
+	diffIDs := writeOCILayout(t, dir, map[string]string{
+		annotationRefName:       "latest",
+		annotationContainerdRef: "docker.io/library/test:latest",
+	})
+	// Archive the layout.
+	var files []string
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		rel, _ := filepath.Rel(dir, p)
+		files = append(files, filepath.ToSlash(rel), string(data))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "image.tar")
+	if err := os.WriteFile(name, makeTar(t, files...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	imgs, err := Open(name)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if len(imgs) != 1 {
+		t.Fatalf("got %d images, want 1", len(imgs))
+	}
+	checkImage(t, imgs[0], "docker.io/library/test:latest", "linux/amd64", diffIDs, true)
+}
+
+func TestDockerArchive(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "image.tar")
+	diffIDs := writeDockerArchive(t, name)
+	imgs, err := Open(name)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if len(imgs) != 1 {
+		t.Fatalf("got %d images, want 1", len(imgs))
+	}
+	checkImage(t, imgs[0], "test:1.0", "linux/arm64/v8", diffIDs, false)
+}
+
+func TestReadError(t *testing.T) {
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Errorf("Open of empty directory succeeded")
+	}
+}
+
+// fakeClient answers QueryContainerImages from a map of Chain IDs to
+// repositories, and Query from a map of file contents to package versions;
+// other chain IDs are not found.
+type fakeClient struct {
+	pb.InsightsClient
+	repos    map[string][]string
+	versions map[string]*pb.VersionKey
+}
+
+func (f fakeClient) QueryContainerImages(ctx context.Context, req *pb.QueryContainerImagesRequest, opts ...grpc.CallOption) (*pb.QueryContainerImagesResult, error) {
+	repos, ok := f.repos[req.GetChainId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	resp := &pb.QueryContainerImagesResult{}
+	for _, r := range repos {
+		resp.Results = append(resp.Results, &pb.QueryContainerImagesResult_Result{Repository: r})
+	}
+	return resp, nil
+}
+
+func (f fakeClient) Query(ctx context.Context, req *pb.QueryRequest, opts ...grpc.CallOption) (*pb.QueryResult, error) {
+	resp := &pb.QueryResult{}
+	for contents, vk := range f.versions {
+		sum := sha256.Sum256([]byte(contents))
+		if bytes.Equal(req.GetHash().GetValue(), sum[:]) {
+			resp.Results = append(resp.Results, &pb.QueryResult_Result{Version: &pb.Version{VersionKey: vk}})
+		}
+	}
+	return resp, nil
+}
+
+func TestBaseImages(t *testing.T) {
+	img := &Image{}
+	for _, id := range ChainIDs([]string{"sha256:a", "sha256:b", "sha256:c"}) {
+		img.Layers = append(img.Layers, &Layer{ChainID: id})
+	}
+	c := fakeClient{repos: map[string][]string{
+		img.Layers[0].ChainID: {"debian", "python"},
+		img.Layers[1].ChainID: {"python"},
+	}}
+	got, err := BaseImages(context.Background(), c, img)
+	if err != nil {
+		t.Fatalf("BaseImages: %v", err)
+	}
+	want := [][]string{{"debian", "python"}, {"python"}, nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BaseImages:\n got %q\nwant %q", got, want)
+	}
+}
+
+// testImage returns an image with layers holding the given tar files.
+func testImage(t *testing.T, layers ...[]byte) *Image {
+	t.Helper()
+	img := &Image{}
+	var diffIDs []string
+	for _, data := range layers {
+		diffIDs = append(diffIDs, digest(data))
+		img.Layers = append(img.Layers, &Layer{
+			DiffID: digest(data),
+			open: func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(data)), nil
+			},
+		})
+	}
+	for i, id := range ChainIDs(diffIDs) {
+		img.Layers[i].ChainID = id
+	}
+	return img
+}
+
+func TestDiffImages(t *testing.T) {
+	base := makeTar(t, "etc/os-release", "debian")
+	oldApp := makeTar(t,
+		"app/lib/guava-31.jar", "guava 31",
+		"app/lib/gson-2.jar", "gson 2",
+		"app/README", "readme",
+	)
+	newApp := makeTar(t,
+		"app/lib/guava-32.jar", "guava 32",
+		"app/lib/gson.jar", "gson 2",
+		"app/lib/local.jar", "local",
+	)
+	old := testImage(t, base, oldApp)
+	new := testImage(t, base, newApp)
+	guava := func(v string) *pb.VersionKey {
+		return &pb.VersionKey{System: pb.System_MAVEN, Name: "com.google.guava:guava", Version: v}
+	}
+	c := fakeClient{
+		repos: map[string][]string{
+			old.Layers[0].ChainID: {"debian"},
+		},
+		versions: map[string]*pb.VersionKey{
+			"guava 31": guava("31.0"),
+			"guava 32": guava("32.0"),
+		},
+	}
+	d, err := DiffImages(context.Background(), c, old, new)
+	if err != nil {
+		t.Fatalf("DiffImages: %v", err)
+	}
+	wantBase := Base{Layers: 1, ChainID: old.Layers[0].ChainID, Repositories: []string{"debian"}}
+	if !reflect.DeepEqual(d.OldBase, wantBase) || !reflect.DeepEqual(d.NewBase, wantBase) {
+		t.Errorf("bases: got %v and %v, want %v", d.OldBase, d.NewBase, wantBase)
+	}
+	if d.BaseChanged() {
+		t.Errorf("BaseChanged: got true, want false")
+	}
+	if len(d.RemovedLayers) != 1 || d.RemovedLayers[0] != old.Layers[1] {
+		t.Errorf("RemovedLayers: got %v, want %v", d.RemovedLayers, old.Layers[1:])
+	}
+	if len(d.AddedLayers) != 1 || d.AddedLayers[0] != new.Layers[1] {
+		t.Errorf("AddedLayers: got %v, want %v", d.AddedLayers, new.Layers[1:])
+	}
+
+	type artifact struct {
+		path     string
+		versions string
+	}
+	summarize := func(as []Artifact) []artifact {
+		var s []artifact
+		for _, a := range as {
+			var vs []string
+			for _, vk := range a.Versions {
+				vs = append(vs, vk.GetName()+"@"+vk.GetVersion())
+			}
+			s = append(s, artifact{a.Path, strings.Join(vs, " ")})
+		}
+		return s
+	}
+	wantRemoved := []artifact{{"app/lib/guava-31.jar", "com.google.guava:guava@31.0"}}
+	if got := summarize(d.RemovedArtifacts); !reflect.DeepEqual(got, wantRemoved) {
+		t.Errorf("RemovedArtifacts:\n got %v\nwant %v", got, wantRemoved)
+	}
+	wantAdded := []artifact{
+		{"app/lib/guava-32.jar", "com.google.guava:guava@32.0"},
+		{"app/lib/local.jar", ""},
+	}
+	if got := summarize(d.AddedArtifacts); !reflect.DeepEqual(got, wantAdded) {
+		t.Errorf("AddedArtifacts:\n got %v\nwant %v", got, wantAdded)
+	}
+
+	c.repos[new.Layers[1].ChainID] = []string{"example/app"}
+	d, err = DiffImages(context.Background(), c, old, new)
+	if err != nil {
+		t.Fatalf("DiffImages: %v", err)
+	}
+	if !d.BaseChanged() {
+		t.Errorf("BaseChanged: got false, want true")
+	}
+}
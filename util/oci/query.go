@@ -0,0 +1,49 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// BaseImages returns, for each layer of img, the image repositories on
+// Docker Hub holding images whose layers up to that one are the same as
+// img's, as reported by the QueryContainerImages method of the deps.dev
+// API. The topmost layer with any matches marks where img departs from its
+// base image.
+func BaseImages(ctx context.Context, c pb.InsightsClient, img *Image) ([][]string, error) {
+	repos := make([][]string, len(img.Layers))
+	for i, l := range img.Layers {
+		resp, err := c.QueryContainerImages(ctx, &pb.QueryContainerImagesRequest{
+			ChainId: l.ChainID,
+		})
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("querying chain ID %s: %w", l.ChainID, err)
+		}
+		for _, r := range resp.GetResults() {
+			repos[i] = append(repos[i], r.GetRepository())
+		}
+	}
+	return repos, nil
+}
@@ -0,0 +1,329 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// Media types of the indexes and manifests that are understood.
+const (
+	mediaTypeOCIIndex    = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerList  = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerImage = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// Annotations holding the reference of an image in an OCI image layout.
+// The containerd annotation, written by ctr export, holds the full name of
+// the image and so is preferred.
+const (
+	annotationRefName       = "org.opencontainers.image.ref.name"
+	annotationContainerdRef = "io.containerd.image.name"
+)
+
+// maxIndexDepth limits the nesting of image indexes.
+const maxIndexDepth = 8
+
+// Open reads the images stored at the given path, which may be a directory
+// or a tar file holding either an OCI image layout or a docker-archive. The
+// returned images read their layers from the path on demand.
+func Open(name string) ([]*Image, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return Read(os.DirFS(name))
+	}
+	return Read(tarFS(name))
+}
+
+// Read reads the images in fsys, which holds either an OCI image layout or
+// the contents of a docker-archive. As the docker-archive written by recent
+// versions of docker is also an OCI image layout, the OCI index is preferred
+// if both are present.
+func Read(fsys fs.FS) ([]*Image, error) {
+	if _, err := fs.Stat(fsys, "index.json"); err == nil {
+		return readOCILayout(fsys)
+	}
+	if _, err := fs.Stat(fsys, "manifest.json"); err == nil {
+		return readDockerArchive(fsys)
+	}
+	return nil, errors.New("neither an OCI image layout nor a docker-archive")
+}
+
+// descriptor is an OCI content descriptor.
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *platform         `json:"platform"`
+}
+
+type platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant"`
+}
+
+func (p *platform) String() string {
+	if p == nil || p.OS == "" {
+		return ""
+	}
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// index is an OCI image index or Docker manifest list.
+type index struct {
+	Manifests []descriptor `json:"manifests"`
+}
+
+// manifest is an OCI image manifest or Docker image manifest.
+type manifest struct {
+	Config descriptor   `json:"config"`
+	Layers []descriptor `json:"layers"`
+}
+
+// config holds the parts of an image configuration that are used here.
+type config struct {
+	platform
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+// readOCILayout reads the images of an OCI image layout, following nested
+// indexes. Images for an unknown platform, which docker uses to attach
+// attestations, are skipped.
+func readOCILayout(fsys fs.FS) ([]*Image, error) {
+	var idx index
+	if err := readJSON(fsys, "index.json", &idx); err != nil {
+		return nil, err
+	}
+	var imgs []*Image
+	var walk func(descs []descriptor, name string, depth int) error
+	walk = func(descs []descriptor, name string, depth int) error {
+		if depth > maxIndexDepth {
+			return errors.New("image indexes nested too deeply")
+		}
+		for _, d := range descs {
+			n := name
+			if r := d.Annotations[annotationContainerdRef]; r != "" {
+				n = r
+			} else if r := d.Annotations[annotationRefName]; r != "" {
+				n = r
+			}
+			switch d.MediaType {
+			case mediaTypeOCIIndex, mediaTypeDockerList:
+				var idx index
+				if err := readBlob(fsys, d.Digest, &idx); err != nil {
+					return err
+				}
+				if err := walk(idx.Manifests, n, depth+1); err != nil {
+					return err
+				}
+			case mediaTypeOCIManifest, mediaTypeDockerImage:
+				if d.Platform != nil && d.Platform.OS == "unknown" {
+					continue
+				}
+				var m manifest
+				if err := readBlob(fsys, d.Digest, &m); err != nil {
+					return err
+				}
+				blobs := make([]string, len(m.Layers))
+				digests := make([]string, len(m.Layers))
+				for i, l := range m.Layers {
+					p, err := blobPath(l.Digest)
+					if err != nil {
+						return err
+					}
+					blobs[i] = p
+					digests[i] = l.Digest
+				}
+				img := newImage(fsys, n, blobs, digests)
+				var c config
+				if err := readBlob(fsys, m.Config.Digest, &c); err != nil {
+					return err
+				}
+				if err := setConfig(img, &c); err != nil {
+					return err
+				}
+				if img.Platform == "" {
+					img.Platform = d.Platform.String()
+				}
+				imgs = append(imgs, img)
+			}
+		}
+		return nil
+	}
+	if err := walk(idx.Manifests, "", 0); err != nil {
+		return nil, err
+	}
+	return imgs, nil
+}
+
+// dockerManifest is an entry of the manifest.json of a docker-archive.
+type dockerManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// readDockerArchive reads the images of a docker-archive, as written by
+// docker save.
+func readDockerArchive(fsys fs.FS) ([]*Image, error) {
+	var ms []dockerManifest
+	if err := readJSON(fsys, "manifest.json", &ms); err != nil {
+		return nil, err
+	}
+	var imgs []*Image
+	for _, m := range ms {
+		name := ""
+		if len(m.RepoTags) > 0 {
+			name = m.RepoTags[0]
+		}
+		blobs := make([]string, len(m.Layers))
+		digests := make([]string, len(m.Layers))
+		for i, l := range m.Layers {
+			blobs[i] = path.Clean(l)
+			// Recent versions of docker store the layers as OCI blobs,
+			// named by their digests.
+			if rest, ok := strings.CutPrefix(blobs[i], "blobs/"); ok {
+				alg, hex, _ := strings.Cut(rest, "/")
+				digests[i] = alg + ":" + hex
+			}
+		}
+		img := newImage(fsys, name, blobs, digests)
+		var c config
+		if err := readJSON(fsys, path.Clean(m.Config), &c); err != nil {
+			return nil, err
+		}
+		if err := setConfig(img, &c); err != nil {
+			return nil, err
+		}
+		imgs = append(imgs, img)
+	}
+	return imgs, nil
+}
+
+// newImage builds an Image whose layers are read from the given blobs.
+func newImage(fsys fs.FS, name string, blobs, digests []string) *Image {
+	img := &Image{Name: name}
+	for i, b := range blobs {
+		img.Layers = append(img.Layers, &Layer{
+			Digest: digests[i],
+			open:   func() (io.ReadCloser, error) { return fsys.Open(b) },
+		})
+	}
+	return img
+}
+
+// setConfig records the platform and layer DiffIDs given by an image
+// configuration, and computes the layers' Chain IDs.
+func setConfig(img *Image, c *config) error {
+	if len(c.RootFS.DiffIDs) != len(img.Layers) {
+		return fmt.Errorf("image %q has %d layers but %d DiffIDs", img.Name, len(img.Layers), len(c.RootFS.DiffIDs))
+	}
+	img.Platform = c.platform.String()
+	for i, id := range ChainIDs(c.RootFS.DiffIDs) {
+		img.Layers[i].DiffID = c.RootFS.DiffIDs[i]
+		img.Layers[i].ChainID = id
+	}
+	return nil
+}
+
+// blobPath returns the path of the blob with the given digest in an OCI
+// image layout.
+func blobPath(digest string) (string, error) {
+	alg, hex, ok := strings.Cut(digest, ":")
+	if !ok || alg == "" || hex == "" || strings.ContainsAny(digest, "/\\") {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	return path.Join("blobs", alg, hex), nil
+}
+
+func readBlob(fsys fs.FS, digest string, v any) error {
+	p, err := blobPath(digest)
+	if err != nil {
+		return err
+	}
+	return readJSON(fsys, p, v)
+}
+
+func readJSON(fsys fs.FS, name string, v any) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return nil
+}
+
+// tarFS is an fs.FS holding the regular files of the named tar file. Each
+// call to Open scans the tar file for the requested entry; as the data of
+// other entries is skipped by seeking, this is cheap for the few large
+// entries of an image archive.
+type tarFS string
+
+func (t tarFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	f, err := os.Open(string(t))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(f)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("reading %s: %w", string(t), err)
+		}
+		if h.Typeflag == tar.TypeReg && path.Clean(strings.TrimPrefix(h.Name, "./")) == name {
+			return &tarFile{Reader: tr, f: f, fi: h.FileInfo()}, nil
+		}
+	}
+	f.Close()
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// tarFile is a file within a tarFS.
+type tarFile struct {
+	io.Reader
+	f  *os.File
+	fi fs.FileInfo
+}
+
+func (t *tarFile) Stat() (fs.FileInfo, error) { return t.fi, nil }
+func (t *tarFile) Close() error               { return t.f.Close() }
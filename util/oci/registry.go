@@ -0,0 +1,378 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxManifestSize limits the size of the manifests, indexes and configs
+// fetched from a registry.
+const maxManifestSize = 4 << 20
+
+// Registry fetches images from container registries using the OCI
+// distribution API. Only the manifests and configs of an image are fetched
+// to build it; its layers are fetched if they are opened. It is safe for
+// concurrent use.
+type Registry struct {
+	// Client makes the requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+	// Credentials returns the user name and password to use with the
+	// given registry host, which are empty for anonymous access. If nil,
+	// DockerCredentials is used.
+	Credentials func(host string) (user, password string, err error)
+
+	// tokensMu controls access to tokens.
+	tokensMu sync.Mutex
+	// tokens holds the bearer tokens obtained for each repository, keyed
+	// by host and repository.
+	tokens map[string]string
+}
+
+// reference is a parsed image reference.
+type reference struct {
+	host string // The registry host, as used by the API.
+	repo string // The repository within the registry.
+	tag  string // The tag or digest.
+}
+
+// parseReference parses an image reference of the form
+// [host/]repository[:tag][@digest], as used by docker. References without a
+// host are to Docker Hub, where single component repositories are in the
+// library namespace. The tag defaults to latest.
+func parseReference(ref string) (reference, error) {
+	var r reference
+	name, digest, hasDigest := strings.Cut(ref, "@")
+	if i := strings.LastIndexByte(name, ':'); i > strings.LastIndexByte(name, '/') {
+		name, r.tag = name[:i], name[i+1:]
+		if r.tag == "" {
+			return reference{}, fmt.Errorf("empty tag in %q", ref)
+		}
+	}
+	if hasDigest {
+		if !strings.Contains(digest, ":") {
+			return reference{}, fmt.Errorf("invalid digest in %q", ref)
+		}
+		r.tag = digest
+	}
+	if r.tag == "" {
+		r.tag = "latest"
+	}
+	if host, repo, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		r.host, r.repo = host, repo
+	} else {
+		r.repo = name
+	}
+	if r.repo == "" || r.repo != strings.ToLower(r.repo) || strings.Contains(r.repo, "//") {
+		return reference{}, fmt.Errorf("invalid repository in %q", ref)
+	}
+	switch r.host {
+	case "", "docker.io", "index.docker.io":
+		r.host = "registry-1.docker.io"
+		if !strings.Contains(r.repo, "/") {
+			r.repo = "library/" + r.repo
+		}
+	}
+	return r, nil
+}
+
+// Fetch fetches the images named by the given reference, such as
+// gcr.io/foo/bar:tag or ubuntu@sha256:... . If the reference names an image
+// index all of the images in the index are returned. The images are named
+// by the reference. Their layers are fetched with ctx when opened, so it
+// should not be canceled before they are used.
+func (r *Registry) Fetch(ctx context.Context, ref string) ([]*Image, error) {
+	rf, err := parseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	data, mediaType, err := r.fetchManifest(ctx, rf, rf.tag)
+	if err != nil {
+		return nil, err
+	}
+	var imgs []*Image
+	switch mediaType {
+	case mediaTypeOCIIndex, mediaTypeDockerList:
+		var idx index
+		if err := json.Unmarshal(data, &idx); err != nil {
+			return nil, fmt.Errorf("parsing index of %s: %w", ref, err)
+		}
+		for _, d := range idx.Manifests {
+			switch d.MediaType {
+			case mediaTypeOCIManifest, mediaTypeDockerImage:
+			default:
+				continue
+			}
+			if d.Platform != nil && d.Platform.OS == "unknown" {
+				continue
+			}
+			data, _, err := r.fetchManifest(ctx, rf, d.Digest)
+			if err != nil {
+				return nil, err
+			}
+			img, err := r.image(ctx, rf, ref, data)
+			if err != nil {
+				return nil, err
+			}
+			if img.Platform == "" {
+				img.Platform = d.Platform.String()
+			}
+			imgs = append(imgs, img)
+		}
+	case mediaTypeOCIManifest, mediaTypeDockerImage:
+		img, err := r.image(ctx, rf, ref, data)
+		if err != nil {
+			return nil, err
+		}
+		imgs = append(imgs, img)
+	default:
+		return nil, fmt.Errorf("%s has unsupported media type %q", ref, mediaType)
+	}
+	return imgs, nil
+}
+
+// image builds the image with the given manifest, fetching its config.
+func (r *Registry) image(ctx context.Context, rf reference, name string, data []byte) (*Image, error) {
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest of %s: %w", name, err)
+	}
+	img := &Image{Name: name}
+	for _, l := range m.Layers {
+		digest := l.Digest
+		img.Layers = append(img.Layers, &Layer{
+			Digest: digest,
+			open: func() (io.ReadCloser, error) {
+				return r.fetchBlob(ctx, rf, digest)
+			},
+		})
+	}
+	rc, err := r.fetchBlob(ctx, rf, m.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var c config
+	if err := json.NewDecoder(io.LimitReader(rc, maxManifestSize)).Decode(&c); err != nil {
+		return nil, fmt.Errorf("parsing config of %s: %w", name, err)
+	}
+	return img, setConfig(img, &c)
+}
+
+// fetchManifest fetches the manifest or index with the given tag or digest,
+// returning it and its media type.
+func (r *Registry) fetchManifest(ctx context.Context, rf reference, tag string) ([]byte, string, error) {
+	resp, err := r.get(ctx, rf, "manifests/"+tag, []string{
+		mediaTypeOCIIndex, mediaTypeOCIManifest, mediaTypeDockerList, mediaTypeDockerImage,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("reading manifest %s: %w", tag, err)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case mediaTypeOCIIndex, mediaTypeOCIManifest, mediaTypeDockerList, mediaTypeDockerImage:
+	default:
+		// Fall back to the media type given in the manifest itself.
+		var d descriptor
+		if err := json.Unmarshal(data, &d); err != nil {
+			return nil, "", fmt.Errorf("parsing manifest %s: %w", tag, err)
+		}
+		mediaType = d.MediaType
+	}
+	return data, mediaType, nil
+}
+
+// fetchBlob returns the blob with the given digest.
+func (r *Registry) fetchBlob(ctx context.Context, rf reference, digest string) (io.ReadCloser, error) {
+	resp, err := r.get(ctx, rf, "blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// get makes a GET request for the given path within the repository,
+// authenticating if the registry asks for it. The caller must close the
+// body of the response.
+func (r *Registry) get(ctx context.Context, rf reference, path string, accept []string) (*http.Response, error) {
+	u := "https://" + rf.host + "/v2/" + rf.repo + "/" + path
+	do := func(auth string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range accept {
+			req.Header.Add("Accept", a)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		return r.client().Do(req)
+	}
+	key := rf.host + "/" + rf.repo
+	r.tokensMu.Lock()
+	auth := r.tokens[key]
+	r.tokensMu.Unlock()
+	resp, err := do(auth)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		auth, err = r.authenticate(ctx, rf, challenge)
+		if err != nil {
+			return nil, fmt.Errorf("authenticating to %s: %w", rf.host, err)
+		}
+		r.tokensMu.Lock()
+		if r.tokens == nil {
+			r.tokens = make(map[string]string)
+		}
+		r.tokens[key] = auth
+		r.tokensMu.Unlock()
+		resp, err = do(auth)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+// authenticate answers the given WWW-Authenticate challenge, returning the
+// value of the Authorization header to send. Bearer tokens are requested
+// with pull access to the repository.
+func (r *Registry) authenticate(ctx context.Context, rf reference, challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	creds := r.Credentials
+	if creds == nil {
+		creds = DockerCredentials
+	}
+	user, password, err := creds(rf.host)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if user == "" {
+			return "", errors.New("no credentials")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password)), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported challenge %q", challenge)
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme != "https" && realm.Scheme != "http" {
+		return "", fmt.Errorf("invalid realm in challenge %q", challenge)
+	}
+	if user != "" && realm.Scheme != "https" {
+		return "", fmt.Errorf("refusing to send credentials to insecure realm %s", realm.Redacted())
+	}
+	q := realm.Query()
+	if s := params["service"]; s != "" {
+		q.Set("service", s)
+	}
+	q.Set("scope", "repository:"+rf.repo+":pull")
+	realm.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching token: %s", resp.Status)
+	}
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&tok); err != nil {
+		return "", fmt.Errorf("parsing token: %w", err)
+	}
+	if tok.Token == "" {
+		tok.Token = tok.AccessToken
+	}
+	if tok.Token == "" {
+		return "", errors.New("empty token")
+	}
+	return "Bearer " + tok.Token, nil
+}
+
+func (r *Registry) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return http.DefaultClient
+}
+
+// parseChallenge parses a WWW-Authenticate header holding a single
+// challenge, such as Bearer realm="https://auth.docker.io/token",
+// service="registry.docker.io".
+func parseChallenge(s string) (scheme string, params map[string]string) {
+	scheme, s, _ = strings.Cut(strings.TrimSpace(s), " ")
+	params = make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " ,")
+		if s == "" {
+			return scheme, params
+		}
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return scheme, params
+		}
+		var val string
+		if strings.HasPrefix(rest, `"`) {
+			rest = rest[1:]
+			var b strings.Builder
+			for rest != "" && rest[0] != '"' {
+				if rest[0] == '\\' && len(rest) > 1 {
+					rest = rest[1:]
+				}
+				b.WriteByte(rest[0])
+				rest = rest[1:]
+			}
+			val, s = b.String(), strings.TrimPrefix(rest, `"`)
+		} else {
+			val, s, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(val)
+	}
+}
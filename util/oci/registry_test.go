@@ -0,0 +1,206 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	for _, c := range []struct {
+		ref  string
+		want reference
+	}{
+		{"ubuntu", reference{"registry-1.docker.io", "library/ubuntu", "latest"}},
+		{"ubuntu:22.04", reference{"registry-1.docker.io", "library/ubuntu", "22.04"}},
+		{"docker.io/grafana/grafana", reference{"registry-1.docker.io", "grafana/grafana", "latest"}},
+		{"gcr.io/foo/bar:tag", reference{"gcr.io", "foo/bar", "tag"}},
+		{"localhost:5000/foo", reference{"localhost:5000", "foo", "latest"}},
+		{"localhost/foo:1", reference{"localhost", "foo", "1"}},
+		{"ghcr.io/a/b:1@sha256:abc", reference{"ghcr.io", "a/b", "sha256:abc"}},
+	} {
+		got, err := parseReference(c.ref)
+		if err != nil {
+			t.Errorf("parseReference(%q): %v", c.ref, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("parseReference(%q): got %+v, want %+v", c.ref, got, c.want)
+		}
+	}
+	for _, ref := range []string{"", "foo:", "Foo", "foo@bar", "gcr.io/"} {
+		if got, err := parseReference(ref); err == nil {
+			t.Errorf("parseReference(%q): got %+v, want error", ref, got)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/ubuntu:pull"`)
+	want := map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/ubuntu:pull",
+	}
+	if scheme != "Bearer" || !reflect.DeepEqual(params, want) {
+		t.Errorf("parseChallenge: got %q %v, want Bearer %v", scheme, params, want)
+	}
+}
+
+// testRegistry serves the test image as the tag test/image:1.0, requiring a
+// bearer token obtained with the user name and password "user:pass".
+func testRegistry(t *testing.T) (*httptest.Server, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	diffIDs := writeOCILayout(t, dir, nil)
+	var idx index
+	if err := readJSON(os.DirFS(dir), "index.json", &idx); err != nil {
+		t.Fatal(err)
+	}
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" || r.URL.Query().Get("scope") != "repository:test/image:pull" {
+				http.Error(w, "bad credentials", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token":"tok"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="test"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		path, ok := strings.CutPrefix(r.URL.Path, "/v2/test/image/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if path == "manifests/1.0" {
+			// The top level index, which is in a blob of the layout.
+			path = "manifests/" + idx.Manifests[0].Digest
+			w.Header().Set("Content-Type", mediaTypeOCIIndex)
+		}
+		_, digest, _ := strings.Cut(path, "/")
+		data, err := os.ReadFile(filepath.Join(dir, "blobs", "sha256", strings.TrimPrefix(digest, "sha256:")))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, diffIDs
+}
+
+func TestRegistryFetch(t *testing.T) {
+	srv, diffIDs := testRegistry(t)
+	r := &Registry{
+		Client: srv.Client(),
+		Credentials: func(host string) (string, string, error) {
+			return "user", "pass", nil
+		},
+	}
+	ref := strings.TrimPrefix(srv.URL, "https://") + "/test/image:1.0"
+	imgs, err := r.Fetch(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(imgs) != 1 {
+		t.Fatalf("got %d images, want 1", len(imgs))
+	}
+	checkImage(t, imgs[0], ref, "linux/amd64", diffIDs, true)
+
+	// Layers are fetched with the context of Fetch.
+	ctx, cancel := context.WithCancel(context.Background())
+	imgs, err = r.Fetch(ctx, ref)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	cancel()
+	if _, err := imgs[0].Layers[0].Open(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Open after canceling the context of Fetch: got error %v, want context.Canceled", err)
+	}
+
+	r.Credentials = func(host string) (string, string, error) {
+		return "", "", nil
+	}
+	r.tokens = nil
+	if _, err := r.Fetch(context.Background(), ref); err == nil {
+		t.Errorf("Fetch without credentials succeeded")
+	}
+}
+
+func TestAuthenticateInsecureRealm(t *testing.T) {
+	r := &Registry{
+		Client: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			t.Errorf("requested %s", req.URL)
+			return nil, errors.New("no requests expected")
+		})},
+		Credentials: func(host string) (string, string, error) {
+			return "user", "pass", nil
+		},
+	}
+	rf := reference{host: "registry.example", repo: "test/image"}
+	if _, err := r.authenticate(context.Background(), rf, `Bearer realm="http://auth.example/token"`); err == nil {
+		t.Errorf("authenticate with an http realm succeeded")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestDockerCredentials(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	if user, pass, err := DockerCredentials("gcr.io"); user != "" || pass != "" || err != nil {
+		t.Errorf("DockerCredentials without config: got %q, %q, %v", user, pass, err)
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte("hub:secret"))
+	config := `{"auths": {
+		"https://index.docker.io/v1/": {"auth": "` + auth + `"},
+		"registry.example.com": {"username": "u", "password": "p"}
+	}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		host, user, pass string
+	}{
+		{"registry-1.docker.io", "hub", "secret"},
+		{"registry.example.com", "u", "p"},
+		{"gcr.io", "", ""},
+	} {
+		user, pass, err := DockerCredentials(c.host)
+		if err != nil {
+			t.Errorf("DockerCredentials(%q): %v", c.host, err)
+			continue
+		}
+		if user != c.user || pass != c.pass {
+			t.Errorf("DockerCredentials(%q): got %q, %q, want %q, %q", c.host, user, pass, c.user, c.pass)
+		}
+	}
+}
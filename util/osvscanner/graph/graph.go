@@ -0,0 +1,52 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package graph converts the dependency graphs resolved by the
+deps.dev/util/resolve package to OSV-Scanner input. It is kept apart from
+package osvscanner so that users of the v3alpha deps.dev API can write
+OSV-Scanner input without linking the v3 API used by resolve.
+*/
+package graph
+
+import (
+	"strings"
+
+	"deps.dev/util/osvscanner"
+	"deps.dev/util/resolve"
+)
+
+// Result returns the versions of the graph, other than its root, as a
+// result of OSV-Scanner input for the lockfile at the given path. Nodes of
+// unknown systems are an error.
+func Result(path string, g *resolve.Graph) (osvscanner.Result, error) {
+	var vers []osvscanner.Version
+	for i, n := range g.Nodes {
+		if i == 0 {
+			continue
+		}
+		vk := n.Version
+		if n.Bundled() {
+			vk.Name = n.BundledFrom.Name
+		} else if strings.Contains(vk.Name, ">") {
+			// Bundled versions have mangled names, ending with the
+			// name of the package in the bundle.
+			if name := vk.Name[strings.LastIndex(vk.Name, ">")+1:]; name != "" {
+				vk.Name = name
+			}
+		}
+		vers = append(vers, osvscanner.Version{System: vk.System.Proto().String(), Name: vk.Name, Version: vk.Version})
+	}
+	return osvscanner.NewResult(path, vers)
+}
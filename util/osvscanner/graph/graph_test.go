@@ -0,0 +1,61 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/osvscanner"
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+func TestResult(t *testing.T) {
+	concrete := func(name, version string) resolve.VersionKey {
+		return resolve.VersionKey{PackageKey: resolve.PackageKey{System: resolve.NPM, Name: name}, VersionType: resolve.Concrete, Version: version}
+	}
+	var g resolve.Graph
+	root := g.AddNode(concrete("app", "1.0.0"))
+	for _, vk := range []resolve.VersionKey{
+		concrete("react", "18.2.0"),
+		concrete("app>1.0.0>loose-envify", "1.4.0"),
+		concrete("loose-envify", "1.4.0"),
+	} {
+		if err := g.AddEdge(root, g.AddNode(vk), "*", dep.NewType()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := Result("package-lock.json", &g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := osvscanner.Result{
+		Source: osvscanner.Source{Path: "package-lock.json", Type: "lockfile"},
+		Packages: []osvscanner.Package{
+			{Package: osvscanner.PackageInfo{Name: "loose-envify", Version: "1.4.0", Ecosystem: "npm"}},
+			{Package: osvscanner.PackageInfo{Name: "react", Version: "18.2.0", Ecosystem: "npm"}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Result (-want +got):\n%s", diff)
+	}
+
+	g.AddNode(resolve.VersionKey{PackageKey: resolve.PackageKey{Name: "unknown"}, VersionType: resolve.Concrete, Version: "1"})
+	if _, err := Result("package-lock.json", &g); err == nil {
+		t.Errorf("Result with an unknown system: got no error")
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package osvscanner writes package versions in the JSON format read by
+OSV-Scanner, so that the inventories and resolved graphs built with deps.dev
+can be scanned for vulnerabilities without parsing the lockfiles again.
+
+The format is that of the output of OSV-Scanner, which it reads back when
+given a file with the osv-scanner: prefix, as in
+
+	osv-scanner --lockfile osv-scanner:inventory.json
+
+See https://google.github.io/osv-scanner/usage/#custom-lockfiles.
+*/
+package osvscanner
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Input is the document read by OSV-Scanner.
+type Input struct {
+	Results []Result `json:"results"`
+}
+
+// Result holds the packages found in a source, such as a lockfile.
+type Result struct {
+	Source   Source    `json:"source"`
+	Packages []Package `json:"packages"`
+}
+
+// Source identifies where packages were found.
+type Source struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// Package wraps the package version of a result.
+type Package struct {
+	Package PackageInfo `json:"package"`
+}
+
+// PackageInfo is a package version in an OSV ecosystem.
+type PackageInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Ecosystem string `json:"ecosystem"`
+}
+
+// ecosystems maps the names of the systems of the deps.dev API to the OSV
+// ecosystems.
+var ecosystems = map[string]string{
+	"GO":    "Go",
+	"NPM":   "npm",
+	"CARGO": "crates.io",
+	"MAVEN": "Maven",
+	"PYPI":  "PyPI",
+	"NUGET": "NuGet",
+}
+
+// Ecosystem returns the OSV ecosystem of the system with the given name in
+// the deps.dev API, such as NPM or MAVEN, or false if there is none.
+func Ecosystem(system string) (string, bool) {
+	e, ok := ecosystems[system]
+	return e, ok
+}
+
+// Version is a package version as identified by the deps.dev API.
+type Version struct {
+	// System is the name of the system in the API, such as NPM.
+	System        string
+	Name, Version string
+}
+
+// NewResult returns a Result for the lockfile at the given path holding
+// the given versions, sorted and with duplicates removed. An error is
+// returned for a system without an OSV ecosystem.
+func NewResult(path string, vers []Version) (Result, error) {
+	r := Result{
+		Source:   Source{Path: path, Type: "lockfile"},
+		Packages: make([]Package, 0, len(vers)),
+	}
+	for _, v := range vers {
+		e, ok := Ecosystem(v.System)
+		if !ok {
+			return Result{}, fmt.Errorf("%s %s: system %q has no OSV ecosystem", v.Name, v.Version, v.System)
+		}
+		r.Packages = append(r.Packages, Package{Package: PackageInfo{Name: v.Name, Version: v.Version, Ecosystem: e}})
+	}
+	slices.SortFunc(r.Packages, func(a, b Package) int {
+		return cmp.Or(
+			cmp.Compare(a.Package.Ecosystem, b.Package.Ecosystem),
+			cmp.Compare(a.Package.Name, b.Package.Name),
+			cmp.Compare(a.Package.Version, b.Package.Version),
+		)
+	})
+	r.Packages = slices.Compact(r.Packages)
+	return r, nil
+}
+
+// Write writes the input as indented JSON.
+func (in *Input) Write(w io.Writer) error {
+	if in.Results == nil {
+		// OSV-Scanner expects a list.
+		in = &Input{Results: []Result{}}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(in)
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package osvscanner
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r, err := NewResult("app/package-lock.json", []Version{
+		{System: "NPM", Name: "react", Version: "18.2.0"},
+		{System: "MAVEN", Name: "junit:junit", Version: "4.13.2"},
+		{System: "NPM", Name: "loose-envify", Version: "1.4.0"},
+		{System: "NPM", Name: "react", Version: "18.2.0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := (&Input{Results: []Result{r}}).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "results": [
+    {
+      "source": {
+        "path": "app/package-lock.json",
+        "type": "lockfile"
+      },
+      "packages": [
+        {
+          "package": {
+            "name": "junit:junit",
+            "version": "4.13.2",
+            "ecosystem": "Maven"
+          }
+        },
+        {
+          "package": {
+            "name": "loose-envify",
+            "version": "1.4.0",
+            "ecosystem": "npm"
+          }
+        },
+        {
+          "package": {
+            "name": "react",
+            "version": "18.2.0",
+            "ecosystem": "npm"
+          }
+        }
+      ]
+    }
+  ]
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Write:\ngot  %s\nwant %s", got, want)
+	}
+
+	buf.Reset()
+	if err := (&Input{}).Write(&buf); err != nil || buf.String() != "{\n  \"results\": []\n}\n" {
+		t.Errorf("Write of an empty input: got %q, %v", buf.String(), err)
+	}
+	if _, err := NewResult("x", []Version{{System: "COBOL", Name: "x", Version: "1"}}); err == nil {
+		t.Errorf("NewResult with an unknown system: got no error")
+	}
+}
+
+func TestEcosystem(t *testing.T) {
+	for sys, want := range map[string]string{"GO": "Go", "CARGO": "crates.io", "PYPI": "PyPI", "NUGET": "NuGet"} {
+		if got, ok := Ecosystem(sys); !ok || got != want {
+			t.Errorf("Ecosystem(%q) = %q, %v, want %q", sys, got, ok, want)
+		}
+	}
+	if got, ok := Ecosystem("SYSTEM_UNSPECIFIED"); ok {
+		t.Errorf("Ecosystem(SYSTEM_UNSPECIFIED) = %q, want none", got)
+	}
+}
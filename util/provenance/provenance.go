@@ -0,0 +1,203 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package provenance summarizes the build provenance of the package versions
+in a resolved dependency graph, from the SLSA provenance statements and
+attestations the deps.dev API records for each version.
+
+Summarize looks up every node of a graph returned by GetDependencies with
+GetVersionBatch, and returns a Report giving the provenance level of each:
+none, present but unverified, or verified by deps.dev. Missing lists the
+dependencies without any build provenance. The report is meant to be
+machine-readable, and encodes as JSON:
+
+	r, err := provenance.Summarize(ctx, client, graph)
+	if err != nil {
+		// ...
+	}
+	for _, res := range r.Missing() {
+		fmt.Println(res.Name, res.Version)
+	}
+	json.NewEncoder(os.Stdout).Encode(r)
+
+A Verifier closes the loop with the artifacts themselves: it downloads the
+attestation of a statement, verifies its signature with a pluggable
+SignatureVerifier, such as one built on Sigstore, and checks that its
+subject matches the digest of a local copy of the artifact:
+
+	d, err := provenance.DigestOf(file)
+	// ...
+	_, err = v.VerifyStatement(ctx, res.Statements[0], d)
+*/
+package provenance
+
+import (
+	"context"
+	"fmt"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/batch"
+)
+
+// Level is how well the build of a package version is attested.
+type Level int
+
+const (
+	// None is the level of a version without provenance or attestations,
+	// or not known to deps.dev.
+	None Level = iota
+	// Unverified is the level of a version whose provenance or
+	// attestations could not be verified by deps.dev.
+	Unverified
+	// Verified is the level of a version with at least one provenance
+	// statement or attestation verified by deps.dev.
+	Verified
+)
+
+var levelNames = [...]string{"NONE", "UNVERIFIED", "VERIFIED"}
+
+func (l Level) String() string {
+	if l < None || l > Verified {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// MarshalText implements encoding.TextMarshaler, so that levels are encoded
+// by name.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// Statement is a SLSA provenance statement or an attestation of a package
+// version.
+type Statement struct {
+	// Type is the type of an attestation, such as
+	// https://slsa.dev/provenance/v1. It is empty for SLSA provenance
+	// statements recorded before attestations, whose type is not known.
+	Type             string `json:"type,omitempty"`
+	URL              string `json:"url,omitempty"`
+	SourceRepository string `json:"sourceRepository,omitempty"`
+	Commit           string `json:"commit,omitempty"`
+	Verified         bool   `json:"verified"`
+}
+
+// Result holds the provenance of a node of the graph.
+type Result struct {
+	System   string `json:"system"`
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Relation string `json:"relation"` // SELF, DIRECT or INDIRECT.
+	// Found reports whether the package version is known to deps.dev. If
+	// not, its provenance is unknown, and its level is None.
+	Found      bool        `json:"found"`
+	Level      Level       `json:"level"`
+	Statements []Statement `json:"statements,omitempty"`
+}
+
+// Report holds the provenance of the nodes of a graph.
+type Report struct {
+	// Results holds a result for each node, in the order of the graph.
+	Results []*Result `json:"results"`
+	// Counts gives the number of results at each level, keyed by name.
+	Counts map[Level]int `json:"counts"`
+}
+
+// Missing returns the results of the dependencies that lack any build
+// provenance, excluding the package version the graph was resolved for.
+func (r *Report) Missing() []*Result {
+	var missing []*Result
+	for _, res := range r.Results {
+		if res.Level == None && res.Relation != pb.DependencyRelation_SELF.String() {
+			missing = append(missing, res)
+		}
+	}
+	return missing
+}
+
+// Summarize fetches the provenance of every node of the given graph, as
+// returned by GetDependencies.
+func Summarize(ctx context.Context, c pb.InsightsClient, g *pb.Dependencies) (*Report, error) {
+	var vks []*pb.VersionKey
+	for _, n := range g.GetNodes() {
+		vks = append(vks, n.GetVersionKey())
+	}
+	versions, err := batch.NewRunner(c, nil).LookupVersions(ctx, vks)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Counts: make(map[Level]int)}
+	for _, n := range g.GetNodes() {
+		vk := n.GetVersionKey()
+		res := &Result{
+			System:   vk.GetSystem().String(),
+			Name:     vk.GetName(),
+			Version:  vk.GetVersion(),
+			Relation: n.GetRelation().String(),
+		}
+		if v, ok := versions[batch.KeyOf(vk)]; ok {
+			res.Found = true
+			res.Statements = statements(v)
+			res.Level = levelOf(res.Statements)
+		}
+		r.Results = append(r.Results, res)
+		r.Counts[res.Level]++
+	}
+	return r, nil
+}
+
+// statements returns the provenance statements and attestations of a
+// version. A SLSA provenance statement is also listed as an attestation
+// when its type is known, in which case only the attestation is kept.
+func statements(v *pb.Version) []Statement {
+	var ss []Statement
+	seen := make(map[string]bool)
+	for _, a := range v.GetAttestations() {
+		ss = append(ss, Statement{
+			Type:             a.GetType(),
+			URL:              a.GetUrl(),
+			SourceRepository: a.GetSourceRepository(),
+			Commit:           a.GetCommit(),
+			Verified:         a.GetVerified(),
+		})
+		if a.GetUrl() != "" {
+			seen[a.GetUrl()] = true
+		}
+	}
+	for _, p := range v.GetSlsaProvenances() {
+		if p.GetUrl() != "" && seen[p.GetUrl()] {
+			continue
+		}
+		ss = append(ss, Statement{
+			URL:              p.GetUrl(),
+			SourceRepository: p.GetSourceRepository(),
+			Commit:           p.GetCommit(),
+			Verified:         p.GetVerified(),
+		})
+	}
+	return ss
+}
+
+// levelOf returns the level of a version with the given statements.
+func levelOf(ss []Statement) Level {
+	l := None
+	for _, s := range ss {
+		if s.Verified {
+			return Verified
+		}
+		l = Unverified
+	}
+	return l
+}
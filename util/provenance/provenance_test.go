@@ -0,0 +1,123 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provenance
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/batch"
+)
+
+// fakeClient serves versions from fixed data. Version batches are returned
+// one response per page, to exercise paging.
+type fakeClient struct {
+	pb.InsightsClient
+	versions map[batch.VersionKey]*pb.Version
+}
+
+func (c *fakeClient) GetVersionBatch(ctx context.Context, req *pb.GetVersionBatchRequest, opts ...grpc.CallOption) (*pb.VersionBatch, error) {
+	i := 0
+	if req.PageToken != "" {
+		i, _ = strconv.Atoi(req.PageToken)
+	}
+	r := req.Requests[i]
+	resp := &pb.VersionBatch{Responses: []*pb.VersionBatch_Response{{
+		Request: r,
+		Version: c.versions[batch.KeyOf(r.GetVersionKey())],
+	}}}
+	if i+1 < len(req.Requests) {
+		resp.NextPageToken = strconv.Itoa(i + 1)
+	}
+	return resp, nil
+}
+
+func vk(name string) *pb.VersionKey {
+	return &pb.VersionKey{System: pb.System_NPM, Name: name, Version: "1.0.0"}
+}
+
+func TestSummarize(t *testing.T) {
+	const slsa = "https://slsa.dev/provenance/v1"
+	c := &fakeClient{versions: map[batch.VersionKey]*pb.Version{
+		batch.KeyOf(vk("app")): {VersionKey: vk("app")},
+		batch.KeyOf(vk("verified")): {
+			VersionKey: vk("verified"),
+			SlsaProvenances: []*pb.SLSAProvenance{
+				{Url: "https://example.com/p", SourceRepository: "https://github.com/a/b", Commit: "abc", Verified: true},
+			},
+			Attestations: []*pb.Attestation{
+				{Type: slsa, Url: "https://example.com/p", SourceRepository: "https://github.com/a/b", Commit: "abc", Verified: true},
+			},
+		},
+		batch.KeyOf(vk("unverified")): {
+			VersionKey:      vk("unverified"),
+			SlsaProvenances: []*pb.SLSAProvenance{{SourceRepository: "https://github.com/c/d"}},
+		},
+		batch.KeyOf(vk("none")): {VersionKey: vk("none")},
+	}}
+	g := &pb.Dependencies{Nodes: []*pb.Dependencies_Node{
+		{VersionKey: vk("app"), Relation: pb.DependencyRelation_SELF},
+		{VersionKey: vk("verified"), Relation: pb.DependencyRelation_DIRECT},
+		{VersionKey: vk("unverified"), Relation: pb.DependencyRelation_DIRECT},
+		{VersionKey: vk("none"), Relation: pb.DependencyRelation_INDIRECT},
+		{VersionKey: vk("unknown"), Relation: pb.DependencyRelation_INDIRECT},
+	}}
+	r, err := Summarize(context.Background(), c, g)
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+
+	result := func(name, relation string, found bool, level Level, ss ...Statement) *Result {
+		return &Result{System: "NPM", Name: name, Version: "1.0.0", Relation: relation, Found: found, Level: level, Statements: ss}
+	}
+	want := &Report{
+		Results: []*Result{
+			result("app", "SELF", true, None),
+			result("verified", "DIRECT", true, Verified,
+				Statement{Type: slsa, URL: "https://example.com/p", SourceRepository: "https://github.com/a/b", Commit: "abc", Verified: true}),
+			result("unverified", "DIRECT", true, Unverified,
+				Statement{SourceRepository: "https://github.com/c/d"}),
+			result("none", "INDIRECT", true, None),
+			result("unknown", "INDIRECT", false, None),
+		},
+		Counts: map[Level]int{None: 3, Unverified: 1, Verified: 1},
+	}
+	if !reflect.DeepEqual(r, want) {
+		got, _ := json.MarshalIndent(r, "", "  ")
+		t.Errorf("Summarize: got\n%s", got)
+	}
+
+	var missing []string
+	for _, res := range r.Missing() {
+		missing = append(missing, res.Name)
+	}
+	if want := []string{"none", "unknown"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("Missing: got %v, want %v", missing, want)
+	}
+
+	data, err := json.Marshal(r.Counts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `{"NONE":3,"UNVERIFIED":1,"VERIFIED":1}`; got != want {
+		t.Errorf("JSON counts: got %s, want %s", got, want)
+	}
+}
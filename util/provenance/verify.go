@@ -0,0 +1,310 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provenance
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Digest holds the hashes of an artifact, keyed by in-toto algorithm name
+// (sha256, sha512) and hex encoded.
+type Digest map[string]string
+
+// DigestOf computes the sha256 and sha512 digests of the contents of r.
+func DigestOf(r io.Reader) (Digest, error) {
+	h256, h512 := sha256.New(), sha512.New()
+	if _, err := io.Copy(io.MultiWriter(h256, h512), r); err != nil {
+		return nil, err
+	}
+	return Digest{
+		"sha256": hex.EncodeToString(h256.Sum(nil)),
+		"sha512": hex.EncodeToString(h512.Sum(nil)),
+	}, nil
+}
+
+// matches reports whether d and o agree on at least one algorithm and
+// disagree on none.
+func (d Digest) matches(o Digest) bool {
+	common := false
+	for alg, h := range d {
+		oh, ok := o[alg]
+		if !ok {
+			continue
+		}
+		if oh != h {
+			return false
+		}
+		common = true
+	}
+	return common
+}
+
+// Envelope is a DSSE envelope, the signed wrapper of an in-toto statement.
+// See https://github.com/secure-systems-lab/dsse.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     []byte      `json:"payload"` // Base64 encoded in JSON.
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a signature of a DSSE envelope.
+type Signature struct {
+	KeyID string `json:"keyid,omitempty"`
+	Sig   []byte `json:"sig"` // Base64 encoded in JSON.
+}
+
+// PAE returns the pre-authentication encoding of the envelope's payload,
+// which is the message its signatures sign.
+func (e *Envelope) PAE() []byte {
+	var b bytes.Buffer
+	b.WriteString("DSSEv1 ")
+	b.WriteString(strconv.Itoa(len(e.PayloadType)))
+	b.WriteByte(' ')
+	b.WriteString(e.PayloadType)
+	b.WriteByte(' ')
+	b.WriteString(strconv.Itoa(len(e.Payload)))
+	b.WriteByte(' ')
+	b.Write(e.Payload)
+	return b.Bytes()
+}
+
+// InTotoStatement is the payload of an attestation envelope. Only the
+// fields needed to match its subjects are decoded; the predicate is kept
+// as is.
+type InTotoStatement struct {
+	Type          string          `json:"_type"`
+	Subject       []Subject       `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate,omitempty"`
+}
+
+// Subject is an artifact an in-toto statement is about.
+type Subject struct {
+	Name   string `json:"name"`
+	Digest Digest `json:"digest"`
+}
+
+// Bundle is a signed attestation as downloaded: its envelope, and the raw
+// JSON of the bundle that held it, from which a SignatureVerifier can take
+// the verification material (certificates, transparency log entries).
+type Bundle struct {
+	Envelope *Envelope
+	Raw      json.RawMessage
+}
+
+// SignatureVerifier verifies the signatures of an attestation.
+//
+// Attestations published to npm, PyPI and Maven Central are signed with
+// Sigstore: a verifier built with sigstore-go, checking the certificate
+// identity against the expected source repository, can be plugged in here
+// without this package depending on it. KeyVerifier covers attestations
+// signed with known public keys.
+type SignatureVerifier interface {
+	VerifySignature(ctx context.Context, b *Bundle) error
+}
+
+// KeyVerifier verifies DSSE signatures against a set of public keys, keyed
+// by key ID. An envelope is verified if any of its signatures verifies with
+// the key of its ID, or with any key if the signature has no ID. ECDSA,
+// Ed25519 and RSA (PKCS #1 v1.5) keys are supported; ECDSA and RSA
+// signatures are over the SHA-256 hash of the message.
+type KeyVerifier map[string]crypto.PublicKey
+
+// VerifySignature implements SignatureVerifier.
+func (kv KeyVerifier) VerifySignature(ctx context.Context, b *Bundle) error {
+	msg := b.Envelope.PAE()
+	for _, s := range b.Envelope.Signatures {
+		for id, key := range kv {
+			if s.KeyID != "" && s.KeyID != id {
+				continue
+			}
+			if verifyWithKey(key, msg, s.Sig) {
+				return nil
+			}
+		}
+	}
+	return ErrSignature
+}
+
+func verifyWithKey(key crypto.PublicKey, msg, sig []byte) bool {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, msg, sig)
+	case *ecdsa.PublicKey:
+		h := sha256.Sum256(msg)
+		return ecdsa.VerifyASN1(k, h[:], sig)
+	case *rsa.PublicKey:
+		h := sha256.Sum256(msg)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, h[:], sig) == nil
+	}
+	return false
+}
+
+var (
+	// ErrSignature is returned when no signature of an attestation could
+	// be verified.
+	ErrSignature = errors.New("attestation signature not verified")
+	// ErrDigest is returned when no subject of an attestation matches the
+	// artifact's digest.
+	ErrDigest = errors.New("artifact digest does not match the attestation subject")
+)
+
+// Verifier downloads attestations and checks them against local
+// artifacts.
+type Verifier struct {
+	// Client is used to download attestations. If nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+	// Signatures verifies the signatures of attestations. It must be set.
+	Signatures SignatureVerifier
+}
+
+// Verification is the result of a successful verification.
+type Verification struct {
+	Statement *InTotoStatement
+	// Subject is the subject of the statement that matched the artifact.
+	Subject Subject
+}
+
+// Verify downloads the attestation at the given URL, as listed by
+// GetVersion, verifies its signature and checks that one of its subjects
+// has the given digest. The URL may serve a DSSE envelope, a Sigstore
+// bundle, or a list of Sigstore bundles such as the npm attestations
+// endpoint returns, in which case verifying any of them is enough.
+func (v *Verifier) Verify(ctx context.Context, url string, artifact Digest) (*Verification, error) {
+	if v.Signatures == nil {
+		return nil, errors.New("no signature verifier")
+	}
+	if len(artifact) == 0 {
+		return nil, errors.New("empty artifact digest")
+	}
+	data, err := v.fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	bundles, err := parseBundles(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing attestation %s: %w", url, err)
+	}
+	var errs []error
+	for _, b := range bundles {
+		res, err := v.verify(ctx, b, artifact)
+		if err == nil {
+			return res, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// VerifyStatement verifies the attestation of a statement returned by
+// Summarize; see Verify.
+func (v *Verifier) VerifyStatement(ctx context.Context, s Statement, artifact Digest) (*Verification, error) {
+	if s.URL == "" {
+		return nil, errors.New("statement has no URL")
+	}
+	return v.Verify(ctx, s.URL, artifact)
+}
+
+func (v *Verifier) verify(ctx context.Context, b *Bundle, artifact Digest) (*Verification, error) {
+	if err := v.Signatures.VerifySignature(ctx, b); err != nil {
+		return nil, err
+	}
+	var st InTotoStatement
+	if err := json.Unmarshal(b.Envelope.Payload, &st); err != nil {
+		return nil, fmt.Errorf("parsing statement: %w", err)
+	}
+	for _, s := range st.Subject {
+		if artifact.matches(s.Digest) {
+			return &Verification{Statement: &st, Subject: s}, nil
+		}
+	}
+	return nil, ErrDigest
+}
+
+// maxAttestationSize bounds the size of a downloaded attestation.
+const maxAttestationSize = 16 << 20
+
+func (v *Verifier) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := v.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching attestation: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching attestation %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxAttestationSize))
+}
+
+// parseBundles decodes a DSSE envelope, a Sigstore bundle, or an npm
+// attestations response.
+func parseBundles(data []byte) ([]*Bundle, error) {
+	var doc struct {
+		// DSSE envelope.
+		Envelope
+		// Sigstore bundle.
+		DSSEEnvelope *Envelope `json:"dsseEnvelope"`
+		// npm attestations.
+		Attestations []struct {
+			Bundle json.RawMessage `json:"bundle"`
+		} `json:"attestations"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	switch {
+	case doc.DSSEEnvelope != nil:
+		return []*Bundle{{Envelope: doc.DSSEEnvelope, Raw: data}}, nil
+	case doc.Attestations != nil:
+		var bs []*Bundle
+		for _, a := range doc.Attestations {
+			b, err := parseBundles(a.Bundle)
+			if err != nil {
+				return nil, err
+			}
+			bs = append(bs, b...)
+		}
+		if len(bs) == 0 {
+			return nil, errors.New("no attestations")
+		}
+		return bs, nil
+	case doc.PayloadType != "":
+		return []*Bundle{{Envelope: &doc.Envelope, Raw: data}}, nil
+	}
+	return nil, errors.New("no DSSE envelope")
+}
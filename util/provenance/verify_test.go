@@ -0,0 +1,114 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provenance
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact, err := DigestOf(strings.NewReader("artifact contents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := DigestOf(strings.NewReader("something else"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope := func(key ed25519.PrivateKey, d Digest) *Envelope {
+		st := InTotoStatement{
+			Type:          "https://in-toto.io/Statement/v1",
+			Subject:       []Subject{{Name: "pkg:npm/a@1.0.0", Digest: Digest{"sha512": d["sha512"]}}},
+			PredicateType: "https://slsa.dev/provenance/v1",
+		}
+		payload, err := json.Marshal(st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := &Envelope{PayloadType: "application/vnd.in-toto+json", Payload: payload}
+		e.Signatures = []Signature{{KeyID: "key", Sig: ed25519.Sign(key, e.PAE())}}
+		return e
+	}
+	encode := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	docs := map[string]string{
+		"/dsse":    encode(envelope(priv, artifact)),
+		"/bundle":  encode(map[string]any{"mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.2", "dsseEnvelope": envelope(priv, artifact)}),
+		"/npm":     encode(map[string]any{"attestations": []any{map[string]any{"bundle": map[string]any{"dsseEnvelope": envelope(otherPriv, artifact)}}, map[string]any{"bundle": map[string]any{"dsseEnvelope": envelope(priv, artifact)}}}}),
+		"/badsig":  encode(envelope(otherPriv, artifact)),
+		"/baddig":  encode(envelope(priv, other)),
+		"/garbage": `{"hello": "world"}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := docs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(doc))
+	}))
+	defer srv.Close()
+
+	v := &Verifier{Client: srv.Client(), Signatures: KeyVerifier{"key": pub}}
+	for _, path := range []string{"/dsse", "/bundle", "/npm"} {
+		res, err := v.Verify(ctx, srv.URL+path, artifact)
+		if err != nil {
+			t.Errorf("Verify(%s): %v", path, err)
+			continue
+		}
+		if got, want := res.Subject.Name, "pkg:npm/a@1.0.0"; got != want {
+			t.Errorf("Verify(%s): subject %q, want %q", path, got, want)
+		}
+	}
+	for path, want := range map[string]error{
+		"/badsig": ErrSignature,
+		"/baddig": ErrDigest,
+	} {
+		if _, err := v.Verify(ctx, srv.URL+path, artifact); !errors.Is(err, want) {
+			t.Errorf("Verify(%s): got error %v, want %v", path, err, want)
+		}
+	}
+	for _, path := range []string{"/garbage", "/missing"} {
+		if _, err := v.Verify(ctx, srv.URL+path, artifact); err == nil {
+			t.Errorf("Verify(%s): got no error", path)
+		}
+	}
+	if _, err := v.VerifyStatement(ctx, Statement{URL: srv.URL + "/dsse"}, artifact); err != nil {
+		t.Errorf("VerifyStatement: %v", err)
+	}
+}
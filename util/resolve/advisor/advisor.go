@@ -0,0 +1,452 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package advisor suggests upgrades of the direct dependencies of a package
+that remove the versions affected by known advisories from its resolved
+dependency graph.
+
+Advise resolves the graph of a version and, for each direct dependency
+through which an affected version is reached, tries the newer versions of
+the dependency in ascending order, resolving the graph with the requirement
+bumped. The first version that removes all the advisories reached through
+the dependency without introducing new ones is suggested, so suggestions
+favor the smallest bumps and, in particular, the fewest major version
+jumps. If no version does, the one removing the most advisories is
+suggested.
+
+Each suggestion comes with the graph resolved with it applied, and the
+report with the graph resolved with all of them applied. Resolutions are
+made with a resolve.Incremental, so the data of the versions common to the
+graphs is fetched once. The changes of the suggestions can be applied to
+the root's manifest with the rewrite package.
+
+Trace and Exposures explain how each advisory in a graph is reached: the
+shortest chains of requirements from the root to the affected version, and
+the direct dependencies through which it is pulled in, which are those to
+upgrade.
+*/
+package advisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/resolve"
+)
+
+// AdvisoryFunc returns the IDs of the advisories affecting a version.
+type AdvisoryFunc func(ctx context.Context, vk resolve.VersionKey) ([]string, error)
+
+// InsightsAdvisories returns an AdvisoryFunc fetching the advisories of
+// versions from deps.dev with GetVersion. Versions unknown to deps.dev
+// have no advisories.
+func InsightsAdvisories(c pb.InsightsClient) AdvisoryFunc {
+	return func(ctx context.Context, vk resolve.VersionKey) ([]string, error) {
+		v, err := c.GetVersion(ctx, &pb.GetVersionRequest{
+			VersionKey: &pb.VersionKey{
+				System:  vk.System.Proto(),
+				Name:    vk.Name,
+				Version: vk.Version,
+			},
+		})
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, ak := range v.GetAdvisoryKeys() {
+			ids = append(ids, ak.GetId())
+		}
+		return ids, nil
+	}
+}
+
+// Options configure Advise.
+type Options struct {
+	// NoMajorBumps restricts the suggestions to versions with the same
+	// major version as the one currently resolved.
+	NoMajorBumps bool
+	// Concurrency is the maximum number of calls to the AdvisoryFunc made
+	// at once. The default is 10.
+	Concurrency int
+}
+
+// Suggestion is a suggested change to a requirement of the root.
+type Suggestion struct {
+	// Change is the change to make, bumping the requirement.
+	Change resolve.RequirementChange
+	// From is the current requirement version.
+	From string
+	// FromVersion and ToVersion are the versions the requirement resolves
+	// to before and after the change.
+	FromVersion string
+	ToVersion   string
+	// MajorBumps is the number of major versions jumped.
+	MajorBumps int
+	// Fixed and Introduced hold the advisories removed from and added to
+	// the graph by the change, sorted.
+	Fixed      []string
+	Introduced []string
+	// Graph is the graph resolved with the change applied.
+	Graph *resolve.Graph
+}
+
+// Report holds the suggestions for a graph.
+type Report struct {
+	// Graph is the graph of the version as currently resolved.
+	Graph *resolve.Graph
+	// Advisories holds the advisories of the affected versions of Graph.
+	Advisories map[resolve.VersionKey][]string
+	// Suggestions holds a suggestion for each direct dependency through
+	// which some advisory is reached and whose upgrade removes some, in
+	// the order of the requirements of the root.
+	Suggestions []Suggestion
+	// Preview is the graph resolved with all the suggestions applied, and
+	// Remaining holds the advisories still affecting it, sorted. Preview
+	// is nil if there are no suggestions.
+	Preview   *resolve.Graph
+	Remaining []string
+}
+
+// Advise resolves the given version with the Resolver returned by
+// newResolver for the client, such as npm.NewResolver, and suggests
+// upgrades of its direct dependencies removing the advisories found in its
+// graph. If opts is nil, the defaults are used.
+func Advise(ctx context.Context, client resolve.Client, newResolver func(resolve.Client) resolve.Resolver, vk resolve.VersionKey, advisories AdvisoryFunc, opts *Options) (*Report, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	a := &advisor{
+		client:      client,
+		inc:         resolve.NewIncremental(client, newResolver),
+		advisories:  advisories,
+		opts:        opts,
+		concurrency: 10,
+		cache:       make(map[resolve.VersionKey]*advisoryCall),
+	}
+	if opts.Concurrency > 0 {
+		a.concurrency = opts.Concurrency
+	}
+	g, err := a.inc.Resolve(ctx, vk)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := a.graphAdvisories(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Graph: g, Advisories: affected}
+	if len(affected) == 0 {
+		return r, nil
+	}
+	current := advisorySet(affected)
+
+	reqs, err := a.inc.Requirements(ctx, vk)
+	if err != nil {
+		return nil, err
+	}
+	var changes []resolve.RequirementChange
+	for _, req := range reqs {
+		n, ok := directNode(g, req)
+		if !ok {
+			continue
+		}
+		reached := reachedAdvisories(g, n, affected)
+		if len(reached) == 0 {
+			continue
+		}
+		s, err := a.suggest(ctx, g, req, g.Nodes[n].Version.Version, reached, current)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			r.Suggestions = append(r.Suggestions, *s)
+			changes = append(changes, s.Change)
+		}
+	}
+	if len(changes) == 0 {
+		r.Remaining = keys(current)
+		return r, nil
+	}
+	r.Preview, err = a.inc.Preview(ctx, g, changes...)
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := a.graphAdvisories(ctx, r.Preview)
+	if err != nil {
+		return nil, err
+	}
+	r.Remaining = keys(advisorySet(remaining))
+	return r, nil
+}
+
+type advisor struct {
+	client      resolve.Client
+	inc         *resolve.Incremental
+	advisories  AdvisoryFunc
+	opts        *Options
+	concurrency int
+
+	mu    sync.Mutex
+	cache map[resolve.VersionKey]*advisoryCall
+}
+
+// advisoryCall is the result of a call to the AdvisoryFunc.
+type advisoryCall struct {
+	once sync.Once
+	ids  []string
+	err  error
+}
+
+// suggest returns the suggested bump of the given requirement of the root
+// of g, which resolves to the given version and reaches the given
+// advisories, or nil if no version removes any of them.
+func (a *advisor) suggest(ctx context.Context, g *resolve.Graph, req resolve.RequirementVersion, from string, reached, current map[string]bool) (*Suggestion, error) {
+	sys := req.System.Semver()
+	fromV, err := sys.Parse(from)
+	if err != nil {
+		return nil, fmt.Errorf("version %s of %s: %w", from, req.Name, err)
+	}
+	fromMajor, _ := fromV.Major()
+	vers, err := a.client.Versions(ctx, req.PackageKey)
+	if err != nil {
+		return nil, fmt.Errorf("versions of %s: %w", req.Name, err)
+	}
+	var candidates []string
+	for _, v := range vers {
+		if v.Blocked() {
+			continue
+		}
+		pv, err := sys.Parse(v.Version)
+		if err != nil || pv.Compare(fromV) <= 0 || (pv.IsPrerelease() && !fromV.IsPrerelease()) {
+			continue
+		}
+		if major, _ := pv.Major(); a.opts.NoMajorBumps && major != fromMajor {
+			continue
+		}
+		candidates = append(candidates, v.Version)
+	}
+	slices.SortFunc(candidates, sys.Compare)
+
+	typ := req.Type.Clone()
+	var best *Suggestion
+	for _, c := range candidates {
+		ch := resolve.RequirementChange{
+			Package: req.PackageKey,
+			Type:    typ,
+			Version: requirement(req.System, req.Version, c),
+		}
+		ng, err := a.inc.Preview(ctx, g, ch)
+		if err != nil {
+			return nil, err
+		}
+		affected, err := a.graphAdvisories(ctx, ng)
+		if err != nil {
+			return nil, err
+		}
+		after := advisorySet(affected)
+		s := &Suggestion{
+			Change:      ch,
+			From:        req.Version,
+			FromVersion: from,
+			Graph:       ng,
+		}
+		for id := range current {
+			if !after[id] {
+				s.Fixed = append(s.Fixed, id)
+			}
+		}
+		for id := range after {
+			if !current[id] {
+				s.Introduced = append(s.Introduced, id)
+			}
+		}
+		slices.Sort(s.Fixed)
+		slices.Sort(s.Introduced)
+		if n, ok := directNode(ng, resolve.RequirementVersion{VersionKey: resolve.VersionKey{PackageKey: req.PackageKey, Version: ch.Version}}); ok {
+			s.ToVersion = ng.Nodes[n].Version.Version
+			if tv, err := sys.Parse(s.ToVersion); err == nil {
+				if major, _ := tv.Major(); major > fromMajor {
+					s.MajorBumps = int(major - fromMajor)
+				}
+			}
+		}
+		fixesAll := true
+		for id := range reached {
+			if after[id] {
+				fixesAll = false
+				break
+			}
+		}
+		if fixesAll && len(s.Introduced) == 0 {
+			return s, nil
+		}
+		if len(s.Fixed) > 0 && (best == nil || len(s.Fixed) > len(best.Fixed)) {
+			best = s
+		}
+	}
+	return best, nil
+}
+
+// requirement returns the requirement on version v to replace the given
+// one, keeping its operator where the system has them.
+func requirement(sys resolve.System, old, v string) string {
+	if sys != resolve.NPM {
+		return v
+	}
+	switch {
+	case strings.HasPrefix(old, "~"):
+		return "~" + v
+	case isVersion(sys, old):
+		return v
+	}
+	return "^" + v
+}
+
+func isVersion(sys resolve.System, s string) bool {
+	_, err := sys.Semver().Parse(s)
+	return err == nil
+}
+
+// directNode returns the node the given requirement of the root resolves
+// to in g.
+func directNode(g *resolve.Graph, req resolve.RequirementVersion) (resolve.NodeID, bool) {
+	for _, e := range g.Edges {
+		if e.From == 0 && e.Requirement == req.Version && g.Nodes[e.To].Version.PackageKey == req.PackageKey {
+			return e.To, true
+		}
+	}
+	return 0, false
+}
+
+// reachedAdvisories returns the advisories of n and the nodes reached
+// from it.
+func reachedAdvisories(g *resolve.Graph, n resolve.NodeID, affected map[resolve.VersionKey][]string) map[string]bool {
+	reached := make(map[string]bool)
+	for i, node := range g.Nodes {
+		ids := affected[node.Version]
+		if len(ids) == 0 {
+			continue
+		}
+		id := resolve.NodeID(i)
+		if id != n && !slices.Contains(g.Ancestors(id), n) {
+			continue
+		}
+		for _, a := range ids {
+			reached[a] = true
+		}
+	}
+	return reached
+}
+
+// graphAdvisories returns the advisories of the versions of g, other than
+// its root, keyed by the affected versions.
+func (a *advisor) graphAdvisories(ctx context.Context, g *resolve.Graph) (map[resolve.VersionKey][]string, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, a.concurrency)
+		affected = make(map[resolve.VersionKey][]string)
+		errs     []error
+		seen     = make(map[resolve.VersionKey]bool)
+	)
+	for i := 1; i < len(g.Nodes); i++ {
+		vk := g.Nodes[i].Version
+		if seen[vk] {
+			continue
+		}
+		seen[vk] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			ids, err := a.advisoriesOf(ctx, vk)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				errs = append(errs, fmt.Errorf("advisories of %s: %w", vk, err))
+			case len(ids) > 0:
+				affected[vk] = ids
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return affected, nil
+}
+
+// advisoriesOf returns the advisories of a version, calling the
+// AdvisoryFunc once per version. Failed calls are made again next time.
+func (a *advisor) advisoriesOf(ctx context.Context, vk resolve.VersionKey) ([]string, error) {
+	a.mu.Lock()
+	c, ok := a.cache[vk]
+	if !ok {
+		c = &advisoryCall{}
+		a.cache[vk] = c
+	}
+	a.mu.Unlock()
+	c.once.Do(func() { c.ids, c.err = a.advisories(ctx, vk) })
+	if c.err != nil {
+		a.mu.Lock()
+		if a.cache[vk] == c {
+			delete(a.cache, vk)
+		}
+		a.mu.Unlock()
+	}
+	return c.ids, c.err
+}
+
+func advisorySet(affected map[resolve.VersionKey][]string) map[string]bool {
+	set := make(map[string]bool)
+	for _, ids := range affected {
+		for _, id := range ids {
+			set[id] = true
+		}
+	}
+	return set
+}
+
+func keys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	slices.Sort(ks)
+	return ks
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/npm"
+	"deps.dev/util/resolve/schema"
+)
+
+// universe has alice depending on an affected version of bob, whose next
+// major version depends on an affected version of eve, and through chuck
+// on an affected version of dave.
+const universe = `
+alice
+	1.0.0
+		bob@^1.0.0
+		chuck@1.0.0
+bob
+	1.0.0
+	2.0.0
+		eve@^1.0.0
+	3.0.0
+	4.0.0-beta
+chuck
+	1.0.0
+		dave@1.0.0
+	1.1.0
+		dave@^1.1.0
+	2.0.0
+dave
+	1.0.0
+	1.1.0
+eve
+	1.0.0
+`
+
+var affected = map[string][]string{
+	"bob@1.0.0":  {"GHSA-bob"},
+	"dave@1.0.0": {"GHSA-dave"},
+	"eve@1.0.0":  {"GHSA-eve"},
+}
+
+func testAdvisories(ctx context.Context, vk resolve.VersionKey) ([]string, error) {
+	return affected[vk.Name+"@"+vk.Version], nil
+}
+
+func newResolver(c resolve.Client) resolve.Resolver { return npm.NewResolver(c) }
+
+func TestAdvise(t *testing.T) {
+	s, err := schema.New(universe, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	}
+	type summary struct {
+		Name, From, To, FromVersion, ToVersion string
+		MajorBumps                             int
+		Fixed, Introduced                      []string
+	}
+	for _, c := range []struct {
+		opts      *Options
+		want      []summary
+		remaining []string
+	}{{
+		opts: nil,
+		want: []summary{
+			{"bob", "^1.0.0", "^3.0.0", "1.0.0", "3.0.0", 2, []string{"GHSA-bob"}, nil},
+			{"chuck", "1.0.0", "1.1.0", "1.0.0", "1.1.0", 0, []string{"GHSA-dave"}, nil},
+		},
+	}, {
+		opts: &Options{NoMajorBumps: true},
+		want: []summary{
+			{"chuck", "1.0.0", "1.1.0", "1.0.0", "1.1.0", 0, []string{"GHSA-dave"}, nil},
+		},
+		remaining: []string{"GHSA-bob"},
+	}} {
+		r, err := Advise(context.Background(), s.NewClient(), newResolver, alice, testAdvisories, c.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Advisories) != 2 {
+			t.Errorf("%+v: got advisories %v, want those of bob and dave", c.opts, r.Advisories)
+		}
+		var got []summary
+		for _, s := range r.Suggestions {
+			got = append(got, summary{s.Change.Package.Name, s.From, s.Change.Version, s.FromVersion, s.ToVersion, s.MajorBumps, s.Fixed, s.Introduced})
+			if s.Graph == nil {
+				t.Errorf("%+v: no graph for %s", c.opts, s.Change.Package.Name)
+			}
+		}
+		if diff := cmp.Diff(c.want, got); diff != "" {
+			t.Errorf("%+v: suggestions (-want +got):\n%s", c.opts, diff)
+		}
+		if r.Preview == nil {
+			t.Fatalf("%+v: no preview", c.opts)
+		}
+		if diff := cmp.Diff(c.remaining, r.Remaining); diff != "" {
+			t.Errorf("%+v: remaining advisories (-want +got):\n%s", c.opts, diff)
+		}
+	}
+}
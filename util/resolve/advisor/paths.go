@@ -0,0 +1,114 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"deps.dev/util/resolve"
+)
+
+// Exposure is how an advisory affecting a version in a graph is reached
+// from the graph's root.
+type Exposure struct {
+	Advisory string
+	// Node is the affected node.
+	Node resolve.NodeID
+	// Paths holds the shortest paths from the root to Node, as returned by
+	// resolve.Graph.PathsTo. Each may be described with
+	// resolve.Graph.Explain.
+	Paths [][]resolve.Edge
+	// Direct holds the edges from the root to the direct dependencies
+	// through which Node is reached, by any path, in the order of the
+	// graph's edges. Upgrading or removing these requirements is needed
+	// to remove Node from the graph. If Node is itself a direct
+	// dependency its edge is included.
+	Direct []resolve.Edge
+}
+
+// Exposures returns how each of the advisories affecting the versions of g,
+// keyed by version as in Report.Advisories, is reached from the root of g.
+// There is an Exposure for every advisory and node it affects, ordered by
+// advisory and then by node. If maxPaths is positive, at most maxPaths
+// shortest paths are given per node.
+func Exposures(g *resolve.Graph, affected map[resolve.VersionKey][]string, maxPaths int) []Exposure {
+	var exps []Exposure
+	for i := 1; i < len(g.Nodes); i++ {
+		n := resolve.NodeID(i)
+		ids := affected[g.Nodes[n].Version]
+		if len(ids) == 0 {
+			continue
+		}
+		paths := shortest(g.PathsTo(n, 0), maxPaths)
+		direct := directEdges(g, n)
+		for _, id := range ids {
+			exps = append(exps, Exposure{
+				Advisory: id,
+				Node:     n,
+				Paths:    paths,
+				Direct:   direct,
+			})
+		}
+	}
+	slices.SortStableFunc(exps, func(a, b Exposure) int {
+		return cmp.Or(cmp.Compare(a.Advisory, b.Advisory), cmp.Compare(a.Node, b.Node))
+	})
+	return exps
+}
+
+// Trace finds the advisories affecting the versions of g with the given
+// AdvisoryFunc and returns how they are reached from its root, as
+// Exposures does. If maxPaths is positive, at most maxPaths shortest paths
+// are given per node. Only the Concurrency of opts is used; if opts is nil
+// the default is used.
+func Trace(ctx context.Context, g *resolve.Graph, advisories AdvisoryFunc, maxPaths int, opts *Options) ([]Exposure, error) {
+	a := &advisor{
+		advisories:  advisories,
+		concurrency: 10,
+		cache:       make(map[resolve.VersionKey]*advisoryCall),
+	}
+	if opts != nil && opts.Concurrency > 0 {
+		a.concurrency = opts.Concurrency
+	}
+	affected, err := a.graphAdvisories(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	return Exposures(g, affected, maxPaths), nil
+}
+
+// shortest returns those of the given paths, ordered shortest first, that
+// are as short as the first, up to limit if it is positive.
+func shortest(paths [][]resolve.Edge, limit int) [][]resolve.Edge {
+	n := 0
+	for n < len(paths) && len(paths[n]) == len(paths[0]) && (limit <= 0 || n < limit) {
+		n++
+	}
+	return paths[:n:n]
+}
+
+// directEdges returns the edges from the root of g to n or its ancestors.
+func directEdges(g *resolve.Graph, n resolve.NodeID) []resolve.Edge {
+	ancestors := g.Ancestors(n)
+	var es []resolve.Edge
+	for _, e := range g.Edges {
+		if e.From == 0 && (e.To == n || slices.Contains(ancestors, e.To)) {
+			es = append(es, e)
+		}
+	}
+	return es
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/schema"
+)
+
+func TestTrace(t *testing.T) {
+	g, err := schema.ParseResolve(`
+app 1.0.0
+	a: alice@^1.0.0 1.0.0
+		d: dave@^1.0.0 1.0.0
+	bob@^2.0.0 2.0.0
+		$a@^1.0.0
+	chuck@^1.0.0 1.0.0
+		$d@~1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	affected := map[string][]string{
+		"alice@1.0.0": {"GHSA-alice"},
+		"dave@1.0.0":  {"GHSA-dave-2", "GHSA-dave-1"},
+	}
+	advisories := func(ctx context.Context, vk resolve.VersionKey) ([]string, error) {
+		return affected[vk.Name+"@"+vk.Version], nil
+	}
+	type summary struct {
+		Advisory, Version string
+		Paths, Direct     []string
+	}
+	summarize := func(exps []Exposure) []summary {
+		var s []summary
+		for _, e := range exps {
+			v := g.Nodes[e.Node].Version
+			sum := summary{Advisory: e.Advisory, Version: v.Name + "@" + v.Version}
+			for _, p := range e.Paths {
+				sum.Paths = append(sum.Paths, g.Explain(p))
+			}
+			for _, d := range e.Direct {
+				sum.Direct = append(sum.Direct, g.Nodes[d.To].Version.Name+"@"+d.Requirement)
+			}
+			s = append(s, sum)
+		}
+		return s
+	}
+
+	alice := summary{
+		Advisory: "GHSA-alice",
+		Version:  "alice@1.0.0",
+		Paths:    []string{"app@1.0.0 -> alice@^1.0.0 (1.0.0)"},
+		Direct:   []string{"alice@^1.0.0", "bob@^2.0.0"},
+	}
+	davePaths := []string{
+		"app@1.0.0 -> alice@^1.0.0 (1.0.0) -> dave@^1.0.0 (1.0.0)",
+		"app@1.0.0 -> chuck@^1.0.0 (1.0.0) -> dave@~1.0.0 (1.0.0)",
+	}
+	daveDirect := []string{"alice@^1.0.0", "bob@^2.0.0", "chuck@^1.0.0"}
+	exps, err := Trace(context.Background(), g, advisories, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []summary{
+		alice,
+		{"GHSA-dave-1", "dave@1.0.0", davePaths, daveDirect},
+		{"GHSA-dave-2", "dave@1.0.0", davePaths, daveDirect},
+	}
+	if diff := cmp.Diff(want, summarize(exps)); diff != "" {
+		t.Errorf("Trace (-want +got):\n%s", diff)
+	}
+
+	exps, err = Trace(context.Background(), g, advisories, 1, &Options{Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []summary{
+		alice,
+		{"GHSA-dave-1", "dave@1.0.0", davePaths[:1], daveDirect},
+		{"GHSA-dave-2", "dave@1.0.0", davePaths[:1], daveDirect},
+	}
+	if diff := cmp.Diff(want, summarize(exps)); diff != "" {
+		t.Errorf("Trace with one path (-want +got):\n%s", diff)
+	}
+}
@@ -18,15 +18,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sort"
 	"strings"
 	"sync"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	pb "deps.dev/api/v3"
 	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/npm/spec"
 	"deps.dev/util/resolve/version"
 )
 
@@ -50,19 +51,13 @@ type APIClient struct {
 	// the bundling package and version as well as the path to the bundled
 	// package version within the bundle. The names should be considered
 	// opaque.
-	bundledVersions map[string]bundledVersion
-}
-
-// bundledVersion is an npm package that was found inside another npm package.
-type bundledVersion struct {
-	Version
-	requirements []RequirementVersion
+	bundledVersions map[string]BundledVersion
 }
 
 // NewAPIClient creates a new APIClient using the provided gRPC client to
 // call the deps.dev Insights service.
 func NewAPIClient(c pb.InsightsClient) *APIClient {
-	return &APIClient{c: c, bundledVersions: make(map[string]bundledVersion)}
+	return &APIClient{c: c, bundledVersions: make(map[string]BundledVersion)}
 }
 
 func (a *APIClient) Version(ctx context.Context, vk VersionKey) (Version, error) {
@@ -75,7 +70,7 @@ func (a *APIClient) Version(ctx context.Context, vk VersionKey) (Version, error)
 	}
 	resp, err := a.c.GetVersion(ctx, &pb.GetVersionRequest{
 		VersionKey: &pb.VersionKey{
-			System:  pb.System(vk.System),
+			System:  vk.System.Proto(),
 			Name:    vk.Name,
 			Version: vk.Version,
 		},
@@ -87,6 +82,10 @@ func (a *APIClient) Version(ctx context.Context, vk VersionKey) (Version, error)
 		return Version{}, err
 	}
 
+	var regs []version.Registry
+	for _, r := range resp.Registries {
+		regs = append(regs, version.Registry{Kind: version.RegistryFetch, ID: r})
+	}
 	if vk.System == Maven {
 		// Fetch repositories and serve as dependency registries.
 		reqResp, err := a.c.GetRequirements(ctx, &pb.GetRequirementsRequest{
@@ -104,14 +103,14 @@ func (a *APIClient) Version(ctx context.Context, vk VersionKey) (Version, error)
 		}
 		if reqResp.Maven != nil {
 			for _, repo := range reqResp.Maven.Repositories {
-				resp.Registries = append(resp.Registries, "dep:"+repo.Url)
+				regs = append(regs, version.Registry{Kind: version.RegistryDependency, ID: repo.Url})
 			}
 		}
 	}
 	// Use the VersionKey provided rather than the possibly canonicalized
 	// name and version returned by the API in case the resolver needs to do
 	// any direct comparisons.
-	return makeVersion(vk, resp, strings.Join(resp.Registries, "|")), nil
+	return makeVersion(vk, resp, regs), nil
 }
 
 func (a *APIClient) Versions(ctx context.Context, pk PackageKey) ([]Version, error) {
@@ -124,7 +123,7 @@ func (a *APIClient) Versions(ctx context.Context, pk PackageKey) ([]Version, err
 	}
 	resp, err := a.c.GetPackage(ctx, &pb.GetPackageRequest{
 		PackageKey: &pb.PackageKey{
-			System: pb.System(pk.System),
+			System: pk.System.Proto(),
 			Name:   pk.Name,
 		},
 	})
@@ -142,7 +141,7 @@ func (a *APIClient) Versions(ctx context.Context, pk PackageKey) ([]Version, err
 			PackageKey:  pk,
 			VersionType: Concrete,
 			Version:     v.VersionKey.Version,
-		}, v, "")
+		}, v, nil)
 	}
 	return vers, nil
 }
@@ -153,11 +152,11 @@ func (a *APIClient) Requirements(ctx context.Context, vk VersionKey) ([]Requirem
 		if !ok {
 			return nil, fmt.Errorf("bundled version %v: %w", vk, ErrNotFound)
 		}
-		return bv.requirements, nil
+		return bv.Requirements, nil
 	}
 	resp, err := a.c.GetRequirements(ctx, &pb.GetRequirementsRequest{
 		VersionKey: &pb.VersionKey{
-			System:  pb.System(vk.System),
+			System:  vk.System.Proto(),
 			Name:    vk.Name,
 			Version: vk.Version,
 		},
@@ -174,6 +173,8 @@ func (a *APIClient) Requirements(ctx context.Context, vk VersionKey) ([]Requirem
 		return a.mavenRequirements(ctx, vk, resp.Maven)
 	case NPM:
 		return a.npmRequirements(vk, resp.Npm)
+	case NuGet:
+		return convertNuGetRequirements(resp.Nuget), nil
 	}
 	return nil, errors.New("unsupported system")
 }
@@ -198,90 +199,24 @@ func (a *APIClient) MatchingVersions(ctx context.Context, vk VersionKey) ([]Vers
 	return MatchRequirement(vk, vers), nil
 }
 
-func (a *APIClient) getBundledVersion(name string) (bundledVersion, bool) {
+func (a *APIClient) npmRequirements(root VersionKey, reqs *pb.Requirements_NPM) ([]RequirementVersion, error) {
+	deps, bundled, err := convertNPMRequirements(root, reqs)
+	if err != nil {
+		return nil, err
+	}
 	a.bundledVersionsMu.Lock()
 	defer a.bundledVersionsMu.Unlock()
-	bv, ok := a.bundledVersions[name]
-	return bv, ok
+	for _, bv := range bundled {
+		a.bundledVersions[bv.Name] = bv
+	}
+	return deps, nil
 }
 
-func (a *APIClient) npmRequirements(root VersionKey, reqs *pb.Requirements_NPM) ([]RequirementVersion, error) {
-	rootDeps := flattenNPMDeps(reqs.Dependencies)
-	// Generate fake packages/versions for anything bundled by this version
-	// and add them to the dependencies with the mangled names expected by
-	// the resolver.
-	type bundle struct {
-		vk           VersionKey
-		originalName string
-		deps         []RequirementVersion
-	}
-	allDeps := map[string]bundle{
-		root.Name: {vk: root, deps: rootDeps},
-	}
-	// Sort by the length of the path, so that we're guaranteed to process
-	// bundles closer to the root before their nested bundles.
-	sort.Slice(reqs.Bundled, func(i, j int) bool {
-		return len(reqs.Bundled[i].Path) < len(reqs.Bundled[j].Path)
-	})
-	for _, b := range reqs.Bundled {
-		bundleDeps := flattenNPMDeps(b.Dependencies)
-		// For a package "b" bundled by package "a" which is itself
-		// bundled by root, the path will be
-		// "node_modules/a/node_modules/b".
-		pkgs := strings.Split(strings.TrimPrefix(b.Path, "node_modules/"), "/node_modules/")
-		mangled := mangledName(root, pkgs)
-		// Add a single Concrete version, and a Requirement version
-		// that matches.
-		bundleVK := VersionKey{
-			PackageKey: PackageKey{
-				System: NPM,
-				Name:   mangled,
-			},
-			VersionType: Concrete,
-			Version:     b.Version,
-		}
-		allDeps[mangled] = bundle{
-			vk:           bundleVK,
-			originalName: b.Name,
-			deps:         bundleDeps,
-		}
-		// Add this to the dependencies of the bundled package
-		// immediately preceding it (which could be the root).
-		parentName := root.Name
-		if i := len(pkgs) - 1; i > 0 {
-			parentName = mangledName(root, pkgs[:i])
-		}
-		parentBundle, ok := allDeps[parentName]
-		if !ok {
-			return nil, fmt.Errorf("internal error: missing bundle parent for %s", mangled)
-		}
-		parentBundle.deps = append(parentBundle.deps, RequirementVersion{
-			VersionKey: VersionKey{
-				PackageKey:  bundleVK.PackageKey,
-				VersionType: Requirement,
-				Version:     b.Version,
-			},
-			Type: dep.NewType(),
-		})
-		allDeps[parentName] = parentBundle
-	}
-	// Add all of the new bundles
+func (a *APIClient) getBundledVersion(name string) (BundledVersion, bool) {
 	a.bundledVersionsMu.Lock()
 	defer a.bundledVersionsMu.Unlock()
-	for name, bundle := range allDeps {
-		if name == root.Name {
-			// This is not a bundled version, we don't need to store
-			// it.
-			continue
-		}
-		v := Version{VersionKey: bundle.vk}
-		v.SetAttr(version.DerivedFrom, bundle.originalName)
-		a.bundledVersions[name] = bundledVersion{
-			Version:      v,
-			requirements: bundle.deps,
-		}
-	}
-	return allDeps[root.Name].deps, nil
+	bv, ok := a.bundledVersions[name]
+	return bv, ok
 }
 
 func flattenNPMDeps(deps *pb.Requirements_NPM_Dependencies) []RequirementVersion {
@@ -290,15 +225,12 @@ func flattenNPMDeps(deps *pb.Requirements_NPM_Dependencies) []RequirementVersion
 		for _, d := range ds {
 			typ := t.Clone()
 			name, req := d.Name, d.Requirement
-			if r, ok := strings.CutPrefix(d.Requirement, "npm:"); ok {
+			if s := spec.Parse(d.Name, d.Requirement); s.Kind == spec.Alias {
 				// This is an aliased dependency, add it as a
 				// dependency on the actual name and keep the
 				// alias in the KnownAs attribute.
 				typ.AddAttr(dep.KnownAs, d.Name)
-				if i := strings.LastIndex(r, "@"); i >= 0 {
-					name = r[:i]
-					req = r[i+1:]
-				}
+				name, req = s.Name, s.Spec
 			}
 			flattened = append(flattened, RequirementVersion{
 				VersionKey: VersionKey{
@@ -317,15 +249,12 @@ func flattenNPMDeps(deps *pb.Requirements_NPM_Dependencies) []RequirementVersion
 	addDeps(deps.GetDevDependencies(), dep.NewType(dep.Dev))
 	addDeps(deps.GetOptionalDependencies(), dep.NewType(dep.Opt))
 
-	peerType := dep.NewType()
-	peerType.AddAttr(dep.Scope, "peer")
-	addDeps(deps.GetPeerDependencies(), peerType)
+	addDeps(deps.GetPeerDependencies(), dep.NewBuilder().Scope(dep.ScopePeer).Type())
 
 	// The resolver expects bundleDependencies to be present as regular
 	// dependencies with a "*" version specifier, even if they were already
 	// in the regular dependencies.
-	bundleType := dep.NewType()
-	bundleType.AddAttr(dep.Scope, "bundle")
+	bundleType := dep.NewBuilder().Scope(dep.ScopeBundle).Type()
 	for _, name := range deps.GetBundleDependencies() {
 		flattened = append(flattened, RequirementVersion{
 			VersionKey: VersionKey{
@@ -347,18 +276,20 @@ func flattenNPMDeps(deps *pb.Requirements_NPM_Dependencies) []RequirementVersion
 // values we need to construct version attributes.
 type defaultGetter interface {
 	GetIsDefault() bool
+	GetPublishedAt() *timestamppb.Timestamp
 }
 
-func makeVersion(vk VersionKey, d defaultGetter, regs string) Version {
+func makeVersion(vk VersionKey, d defaultGetter, regs []version.Registry) Version {
 	var attr version.AttrSet
 	if vk.System == NPM && d.GetIsDefault() {
 		// For NPM, the "default" version is either the highest by
 		// semver or the version with a "latest" dist-tag.
 		attr.SetAttr(version.Tags, "latest")
 	}
-	if regs != "" {
-		attr.SetAttr(version.Registries, regs)
+	if t := d.GetPublishedAt(); t != nil {
+		attr.SetCreated(t.AsTime())
 	}
+	attr.SetRegistries(regs)
 	return Version{VersionKey: vk, AttrSet: attr}
 }
 
@@ -229,9 +229,7 @@ func TestNPMDependencies(t *testing.T) {
 			vk("test>1.0.0>b>duplicate", "0.0.1"): {},
 		},
 	}} {
-		client := APIClient{
-			bundledVersions: make(map[string]bundledVersion),
-		}
+		client := NewAPIClient(nil)
 		// Start with the root, to populate any bundles.
 		got, err := client.npmRequirements(root, c.in)
 		if err != nil && len(c.out[root]) != 0 {
@@ -0,0 +1,119 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"deps.dev/util/resolve/version"
+	"deps.dev/util/semver"
+)
+
+// AsOfClient returns a Client answering as the given client would have at
+// time t, so that a resolution can be reproduced as it would have been
+// then. Versions created after t, according to their Created attribute,
+// are hidden; those whose creation time is unknown are kept. For npm, the
+// "latest" tag is moved to the highest version that is not a prerelease
+// among those kept, which is where npm would usually have put it. Other
+// registry state, such as deprecations, is as it is now.
+func AsOfClient(c Client, t time.Time) Client {
+	return &asOfClient{client: c, t: t}
+}
+
+type asOfClient struct {
+	client Client
+	t      time.Time
+}
+
+// after reports whether the version was created after the client's time.
+func (c *asOfClient) after(v Version) bool {
+	created, ok := v.Created()
+	return ok && created.After(c.t)
+}
+
+func (c *asOfClient) Version(ctx context.Context, vk VersionKey) (Version, error) {
+	v, err := c.client.Version(ctx, vk)
+	if err != nil {
+		return v, err
+	}
+	if c.after(v) {
+		return Version{}, fmt.Errorf("version %v created after %v: %w", vk, c.t.Format(time.RFC3339), ErrNotFound)
+	}
+	return v, nil
+}
+
+func (c *asOfClient) Versions(ctx context.Context, pk PackageKey) ([]Version, error) {
+	vs, err := c.client.Versions(ctx, pk)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]Version, 0, len(vs))
+	for _, v := range vs {
+		if !c.after(v) {
+			kept = append(kept, v)
+		}
+	}
+	if pk.System == NPM && len(kept) < len(vs) {
+		retagLatest(kept)
+	}
+	return kept, nil
+}
+
+func (c *asOfClient) Requirements(ctx context.Context, vk VersionKey) ([]RequirementVersion, error) {
+	return c.client.Requirements(ctx, vk)
+}
+
+func (c *asOfClient) MatchingVersions(ctx context.Context, vk VersionKey) ([]Version, error) {
+	vs, err := c.Versions(ctx, vk.PackageKey)
+	if err != nil {
+		return nil, err
+	}
+	return MatchRequirement(vk, vs), nil
+}
+
+// retagLatest moves the "latest" tag to the highest npm version that is not
+// a prerelease, modifying copies of the attributes of the versions.
+func retagLatest(vs []Version) {
+	latest := -1
+	var latestVer *semver.Version
+	for i, v := range vs {
+		sv, err := semver.NPM.Parse(v.Version)
+		if err != nil || sv.IsPrerelease() {
+			continue
+		}
+		if latestVer == nil || sv.Compare(latestVer) > 0 {
+			latest, latestVer = i, sv
+		}
+	}
+	for i := range vs {
+		tags, _ := vs[i].GetAttr(version.Tags)
+		var kept []string
+		for _, tag := range strings.Split(tags, ",") {
+			if tag != "" && tag != "latest" {
+				kept = append(kept, tag)
+			}
+		}
+		if i == latest {
+			kept = append(kept, "latest")
+		}
+		if joined := strings.Join(kept, ","); joined != tags {
+			vs[i].AttrSet = vs[i].AttrSet.Clone()
+			vs[i].SetAttr(version.Tags, joined)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"deps.dev/util/resolve/version"
+)
+
+func TestAsOfClient(t *testing.T) {
+	ctx := context.Background()
+	pk := PackageKey{System: NPM, Name: "alice"}
+	concrete := func(v, tags string, created time.Time) Version {
+		ver := Version{VersionKey: VersionKey{PackageKey: pk, VersionType: Concrete, Version: v}}
+		if tags != "" {
+			ver.SetAttr(version.Tags, tags)
+		}
+		if !created.IsZero() {
+			ver.SetCreated(created)
+		}
+		return ver
+	}
+	date := func(year int) time.Time {
+		return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	lc := NewLocalClient()
+	for _, v := range []Version{
+		concrete("1.0.0", "", date(2020)),
+		concrete("1.1.0", "latest", date(2023)),
+		concrete("2.0.0-beta", "next", date(2021)),
+		concrete("0.1.0", "", time.Time{}),
+	} {
+		lc.AddVersion(v, nil)
+	}
+	c := AsOfClient(lc, date(2022))
+
+	vs, err := c.Versions(ctx, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, v := range vs {
+		got[v.Version], _ = v.GetAttr(version.Tags)
+	}
+	want := map[string]string{"0.1.0": "", "1.0.0": "latest", "2.0.0-beta": "next"}
+	if len(got) != len(want) {
+		t.Errorf("Versions: got %v, want %v", got, want)
+	}
+	for v, tags := range want {
+		if g, ok := got[v]; !ok || g != tags {
+			t.Errorf("Versions: got %s tagged %q, want %q", v, g, tags)
+		}
+	}
+	// The underlying client is unchanged.
+	if v, err := lc.Version(ctx, concrete("1.0.0", "", time.Time{}).VersionKey); err != nil || v.HasAttr(version.Tags) {
+		t.Errorf("underlying client modified: %v, %v", v, err)
+	}
+
+	if _, err := c.Version(ctx, concrete("1.1.0", "", time.Time{}).VersionKey); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Version of a later version: got %v, want ErrNotFound", err)
+	}
+	for req, want := range map[string]string{"^1.0.0": "1.0.0", "latest": "1.0.0", "next": "2.0.0-beta"} {
+		vs, err := c.MatchingVersions(ctx, VersionKey{PackageKey: pk, VersionType: Requirement, Version: req})
+		if err != nil || len(vs) == 0 || vs[len(vs)-1].Version != want {
+			t.Errorf("MatchingVersions(%s) = %v, %v, want %s last", req, vs, err, want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package dedupe finds the packages present at several versions in a resolved
+dependency graph, typically of npm, and which of those versions could be
+collapsed into one, as "npm dedupe" does.
+
+A version can be collapsed into another version of the same package if
+every requirement resolved to it is also satisfied by the other. For each
+duplicated package, Analyze picks the version of the graph into which the
+most nodes can be collapsed, and estimates the install size saved by doing
+so from the sizes given by a SizeFunc, such as the unpacked sizes recorded
+by the npm registry.
+*/
+package dedupe
+
+import (
+	"slices"
+	"strings"
+
+	"deps.dev/util/resolve"
+)
+
+// SizeFunc returns the install size of a version in bytes, and whether it
+// is known.
+type SizeFunc func(resolve.VersionKey) (int64, bool)
+
+// Use is a version of a duplicated package, as used in the graph.
+type Use struct {
+	Version string
+	// Nodes holds the nodes of the version, as it may be installed
+	// several times.
+	Nodes []resolve.NodeID
+	// Requirements holds the distinct requirements resolved to the
+	// version, sorted.
+	Requirements []string
+}
+
+// Duplicate is a package present at several versions in the graph.
+type Duplicate struct {
+	Package resolve.PackageKey
+	// Uses holds the versions of the package, in ascending order.
+	Uses []Use
+	// Target is the version into which the others can be collapsed, if
+	// any can be. Collapsible lists those, in ascending order.
+	Target      string
+	Collapsible []string
+	// Removed is the number of nodes collapsing would remove, and
+	// SavedBytes their known total size. Unsized is the number of those
+	// nodes whose size is unknown.
+	Removed    int
+	SavedBytes int64
+	Unsized    int
+}
+
+// Full reports whether all the versions of the package can be collapsed
+// into one.
+func (d *Duplicate) Full() bool {
+	return d.Target != "" && len(d.Collapsible) == len(d.Uses)-1
+}
+
+// Report is the result of the analysis of a graph.
+type Report struct {
+	// Duplicates holds the duplicated packages, sorted by package.
+	Duplicates []*Duplicate
+	// Removed, SavedBytes and Unsized are the totals of the duplicates.
+	Removed    int
+	SavedBytes int64
+	Unsized    int
+}
+
+// Analyze finds the duplicated packages of the graph, and how they could be
+// collapsed. The root and the versions of npm bundles, which cannot be
+// collapsed, are ignored. The size function may be nil, in which case
+// every removed node is unsized.
+func Analyze(g *resolve.Graph, size SizeFunc) *Report {
+	reqs := make([]map[string]bool, len(g.Nodes))
+	for _, e := range g.Edges {
+		if reqs[e.To] == nil {
+			reqs[e.To] = make(map[string]bool)
+		}
+		reqs[e.To][e.Requirement] = true
+	}
+	uses := make(map[resolve.PackageKey]map[string]*Use)
+	for i, n := range g.Nodes {
+		vk := n.Version
+		if i == 0 || strings.Contains(vk.Name, ">") {
+			continue
+		}
+		byVersion := uses[vk.PackageKey]
+		if byVersion == nil {
+			byVersion = make(map[string]*Use)
+			uses[vk.PackageKey] = byVersion
+		}
+		u := byVersion[vk.Version]
+		if u == nil {
+			u = &Use{Version: vk.Version}
+			byVersion[vk.Version] = u
+		}
+		u.Nodes = append(u.Nodes, resolve.NodeID(i))
+		for req := range reqs[i] {
+			if !slices.Contains(u.Requirements, req) {
+				u.Requirements = append(u.Requirements, req)
+			}
+		}
+	}
+
+	r := &Report{}
+	for pk, byVersion := range uses {
+		if len(byVersion) < 2 {
+			continue
+		}
+		d := &Duplicate{Package: pk}
+		var versions []resolve.Version
+		for v, u := range byVersion {
+			slices.Sort(u.Requirements)
+			versions = append(versions, resolve.Version{VersionKey: resolve.VersionKey{PackageKey: pk, VersionType: resolve.Concrete, Version: v}})
+		}
+		resolve.SortVersions(versions)
+		for _, v := range versions {
+			d.Uses = append(d.Uses, *byVersion[v.Version])
+		}
+		d.collapse(size)
+		r.Duplicates = append(r.Duplicates, d)
+		r.Removed += d.Removed
+		r.SavedBytes += d.SavedBytes
+		r.Unsized += d.Unsized
+	}
+	slices.SortFunc(r.Duplicates, func(a, b *Duplicate) int {
+		return a.Package.Compare(b.Package)
+	})
+	return r
+}
+
+// collapse sets the target of the duplicate to the version into which the
+// most nodes can be collapsed, preferring the highest version, and the
+// resulting savings.
+func (d *Duplicate) collapse(size SizeFunc) {
+	best, bestNodes := -1, 0
+	var bestCollapsible []int
+	for t := len(d.Uses) - 1; t >= 0; t-- {
+		var collapsible []int
+		nodes := 0
+		for i, u := range d.Uses {
+			if i == t || !satisfies(d.Package.System, u.Requirements, d.Uses[t].Version) {
+				continue
+			}
+			collapsible = append(collapsible, i)
+			nodes += len(u.Nodes)
+		}
+		if nodes > bestNodes {
+			best, bestNodes, bestCollapsible = t, nodes, collapsible
+		}
+	}
+	if best < 0 {
+		return
+	}
+	d.Target = d.Uses[best].Version
+	for _, i := range bestCollapsible {
+		u := d.Uses[i]
+		d.Collapsible = append(d.Collapsible, u.Version)
+		d.Removed += len(u.Nodes)
+		vk := resolve.VersionKey{PackageKey: d.Package, VersionType: resolve.Concrete, Version: u.Version}
+		s, ok := int64(0), false
+		if size != nil {
+			s, ok = size(vk)
+		}
+		if ok {
+			d.SavedBytes += s * int64(len(u.Nodes))
+		} else {
+			d.Unsized += len(u.Nodes)
+		}
+	}
+}
+
+// satisfies reports whether the version satisfies all the requirements.
+func satisfies(sys resolve.System, reqs []string, version string) bool {
+	for _, req := range reqs {
+		if req != version && len(resolve.Match(sys, req, []string{version})) == 0 {
+			return false
+		}
+	}
+	return true
+}
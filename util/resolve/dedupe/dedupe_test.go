@@ -0,0 +1,110 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedupe
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/schema"
+)
+
+func TestAnalyze(t *testing.T) {
+	// lodash is installed at three versions: both copies of 4.17.0 can be
+	// collapsed into 4.17.21, but 3.10.1 cannot. react is installed at
+	// two versions that cannot be collapsed either way. The dave bundled
+	// in eve is ignored.
+	g, err := schema.ParseResolve(`
+app 1.0.0
+	lodash@^4.17.0 4.17.21
+	bob@^1.0.0 1.0.0
+		lodash@~4.17.0 4.17.0
+		react@^17.0.0 17.0.2
+	chuck@^1.0.0 1.0.0
+		lodash@^4.0.0 4.17.0
+		lodash@^3.0.0 3.10.1
+		react@^18.0.0 18.2.0
+	eve@^1.0.0 1.0.0
+		dave@* 1.0.0
+		eve>1.0.0>dave@1.0.0 1.0.0
+	dave@^2.0.0 2.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := func(vk resolve.VersionKey) (int64, bool) {
+		if vk.Name == "lodash" && vk.Version == "4.17.0" {
+			return 1000, true
+		}
+		return 0, false
+	}
+	r := Analyze(g, size)
+	got := make(map[string]Duplicate)
+	for _, d := range r.Duplicates {
+		for i := range d.Uses {
+			d.Uses[i].Nodes = nil
+		}
+		got[d.Package.Name] = *d
+	}
+	lodash := resolve.PackageKey{System: resolve.NPM, Name: "lodash"}
+	react := resolve.PackageKey{System: resolve.NPM, Name: "react"}
+	dave := resolve.PackageKey{System: resolve.NPM, Name: "dave"}
+	want := map[string]Duplicate{
+		"lodash": {
+			Package: lodash,
+			Uses: []Use{
+				{Version: "3.10.1", Requirements: []string{"^3.0.0"}},
+				{Version: "4.17.0", Requirements: []string{"^4.0.0", "~4.17.0"}},
+				{Version: "4.17.21", Requirements: []string{"^4.17.0"}},
+			},
+			Target:      "4.17.21",
+			Collapsible: []string{"4.17.0"},
+			Removed:     2,
+			SavedBytes:  2000,
+		},
+		"react": {
+			Package: react,
+			Uses: []Use{
+				{Version: "17.0.2", Requirements: []string{"^17.0.0"}},
+				{Version: "18.2.0", Requirements: []string{"^18.0.0"}},
+			},
+		},
+		"dave": {
+			Package: dave,
+			Uses: []Use{
+				{Version: "1.0.0", Requirements: []string{"*"}},
+				{Version: "2.0.0", Requirements: []string{"^2.0.0"}},
+			},
+			Target:      "2.0.0",
+			Collapsible: []string{"1.0.0"},
+			Removed:     1,
+			Unsized:     1,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Analyze (-want +got):\n%s", diff)
+	}
+	if r.Removed != 3 || r.SavedBytes != 2000 || r.Unsized != 1 {
+		t.Errorf("got totals %d removed, %d bytes, %d unsized; want 3, 2000, 1", r.Removed, r.SavedBytes, r.Unsized)
+	}
+	if d := got["lodash"]; d.Full() {
+		t.Errorf("lodash: Full() = true")
+	}
+	if d := got["dave"]; !d.Full() {
+		t.Errorf("dave: Full() = false")
+	}
+}
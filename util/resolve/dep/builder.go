@@ -0,0 +1,97 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dep
+
+import "strings"
+
+// Builder builds a Type attribute by attribute, for instance:
+//
+//	dep.NewBuilder().Opt().Scope(dep.ScopeRuntime).MavenClassifier("tests").Type()
+//
+// Each method sets an attribute and returns the Builder.
+type Builder struct {
+	t Type
+}
+
+// NewBuilder returns a Builder starting from a regular Type.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Type returns the Type built so far.
+func (b *Builder) Type() Type {
+	return b.t.Clone()
+}
+
+func (b *Builder) set(key AttrKey, value string) *Builder {
+	b.t.AddAttr(key, value)
+	return b
+}
+
+// Dev sets the Dev attribute.
+func (b *Builder) Dev() *Builder { return b.set(Dev, "") }
+
+// Opt sets the Opt attribute.
+func (b *Builder) Opt() *Builder { return b.set(Opt, "") }
+
+// Test sets the Test attribute.
+func (b *Builder) Test() *Builder { return b.set(Test, "") }
+
+// XTest sets the XTest attribute.
+func (b *Builder) XTest(value string) *Builder { return b.set(XTest, value) }
+
+// Framework sets the NuGet target framework.
+func (b *Builder) Framework(framework string) *Builder { return b.set(Framework, framework) }
+
+// Scope sets the scope, such as ScopePeer or ScopeRuntime.
+func (b *Builder) Scope(scope string) *Builder { return b.set(Scope, scope) }
+
+// MavenClassifier sets the classifier of a Maven dependency.
+func (b *Builder) MavenClassifier(classifier string) *Builder {
+	return b.set(MavenClassifier, classifier)
+}
+
+// MavenArtifactType sets the artifact type of a Maven dependency.
+func (b *Builder) MavenArtifactType(typ string) *Builder { return b.set(MavenArtifactType, typ) }
+
+// MavenDependencyOrigin sets the origin of a Maven dependency, such as
+// OriginManagement.
+func (b *Builder) MavenDependencyOrigin(origin string) *Builder {
+	return b.set(MavenDependencyOrigin, origin)
+}
+
+// MavenExclusions sets the exclusions of a Maven dependency, each of the
+// form groupID:artifactID.
+func (b *Builder) MavenExclusions(exclusions ...string) *Builder {
+	return b.set(MavenExclusions, strings.Join(exclusions, "|"))
+}
+
+// EnabledDependencies sets the optional dependencies enabled in the
+// dependency, such as Cargo features.
+func (b *Builder) EnabledDependencies(names ...string) *Builder {
+	return b.set(EnabledDependencies, strings.Join(names, ","))
+}
+
+// KnownAs sets the name under which the dependency is referenced.
+func (b *Builder) KnownAs(name string) *Builder { return b.set(KnownAs, name) }
+
+// Environment sets the conditions for the dependency to apply.
+func (b *Builder) Environment(env string) *Builder { return b.set(Environment, env) }
+
+// Selector sets the Selector attribute.
+func (b *Builder) Selector() *Builder { return b.set(Selector, "") }
+
+// RelocatedFrom sets the version the dependency was relocated from.
+func (b *Builder) RelocatedFrom(v string) *Builder { return b.set(RelocatedFrom, v) }
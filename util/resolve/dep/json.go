@@ -0,0 +1,47 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dep
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalJSON encodes the Type as a JSON object mapping the names of its
+// attributes, such as "Dev" or "Scope", to their values.
+func (t Type) MarshalJSON() ([]byte, error) {
+	m := make(map[string]string)
+	t.ForEachAttr(func(key AttrKey, value string) {
+		m[key.String()] = value
+	})
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes a Type encoded by MarshalJSON.
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*t = Type{}
+	for name, v := range m {
+		k, ok := ParseAttrKey(name)
+		if !ok {
+			return fmt.Errorf("unknown dependency type attribute %q", name)
+		}
+		t.AddAttr(k, v)
+	}
+	return nil
+}
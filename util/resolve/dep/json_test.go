@@ -0,0 +1,53 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dep
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTypeJSON(t *testing.T) {
+	withAttrs := NewType(Dev, Test)
+	withAttrs.AddAttr(Scope, "peer")
+	withAttrs.AddAttr(MavenExclusions, "a:b|*:c")
+	for _, tc := range []struct {
+		typ  Type
+		want string
+	}{
+		{Type{}, `{}`},
+		{NewType(Opt), `{"Opt":""}`},
+		{withAttrs, `{"Dev":"","MavenExclusions":"a:b|*:c","Scope":"peer","Test":""}`},
+	} {
+		data, err := json.Marshal(tc.typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.want {
+			t.Errorf("Marshal(%v): got %s, want %s", tc.typ, data, tc.want)
+		}
+		var got Type
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(tc.typ) {
+			t.Errorf("round trip of %v: got %v", tc.typ, got)
+		}
+	}
+	var got Type
+	if err := json.Unmarshal([]byte(`{"Unknown":""}`), &got); err == nil {
+		t.Errorf("Unmarshal of unknown attribute: got no error")
+	}
+}
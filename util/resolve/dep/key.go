@@ -104,4 +104,12 @@ const (
 	// For Maven, this is set for all the edges that would appear in the
 	// dependency tree.
 	Selector AttrKey = 11
+
+	// RelocatedFrom is set in the context of resolved graphs on the
+	// dependencies whose requirement was moved to another package or
+	// version, and names the version that was required.
+	//
+	// In Maven, this is the groupId:artifactId:version of a relocated
+	// artifact.
+	RelocatedFrom AttrKey = 12
 )
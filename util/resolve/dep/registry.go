@@ -0,0 +1,122 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dep
+
+import "slices"
+
+// Values of the Scope attribute.
+const (
+	// ScopePeer and ScopeBundle are npm scopes.
+	ScopePeer   = "peer"
+	ScopeBundle = "bundle"
+	// ScopeProvided, ScopeRuntime, ScopeSystem and ScopeImport are Maven
+	// scopes.
+	ScopeProvided = "provided"
+	ScopeRuntime  = "runtime"
+	ScopeSystem   = "system"
+	ScopeImport   = "import"
+	// ScopeBuild is a Cargo scope.
+	ScopeBuild = "build"
+)
+
+// Values of the MavenDependencyOrigin attribute.
+const (
+	OriginImport     = "import"
+	OriginManagement = "management"
+	OriginParent     = "parent"
+)
+
+// AttrInfo describes an attribute key.
+type AttrInfo struct {
+	Key AttrKey
+	// Name is the name of the key, as used in the string and JSON forms of
+	// Types.
+	Name string
+	// Flag reports whether the value of the attribute is ignored, its
+	// presence being the indicator.
+	Flag bool
+	// Values holds the valid values of the attribute, if they are
+	// restricted, for any system.
+	Values []string
+	// Description describes the attribute.
+	Description string
+}
+
+// attrs is the registry of the attribute keys, ordered by key.
+var attrs = []AttrInfo{
+	{Key: Test, Flag: true, Description: "the dependency is required to build the tests of the package"},
+	{Key: Opt, Flag: true, Description: "the dependency is optional"},
+	{Key: Dev, Flag: true, Description: "the dependency is required to develop the package"},
+	{Key: XTest, Description: "the dependency is from a Go XTest"},
+	{Key: Framework, Description: "the NuGet target framework the dependency belongs to"},
+	{
+		Key:         Scope,
+		Values:      []string{ScopeBuild, ScopeBundle, ScopeImport, ScopePeer, ScopeProvided, ScopeRuntime, ScopeSystem},
+		Description: "the scope of the dependency",
+	},
+	{Key: MavenClassifier, Description: "the classifier of a Maven dependency"},
+	{Key: MavenArtifactType, Description: "the artifact type of a Maven dependency"},
+	{
+		Key:         MavenDependencyOrigin,
+		Values:      []string{OriginImport, OriginManagement, OriginParent},
+		Description: "the origin of a Maven dependency",
+	},
+	{Key: EnabledDependencies, Description: "the optional dependencies enabled in the dependency, comma separated"},
+	{Key: KnownAs, Description: "the name under which the dependency is referenced by the package"},
+	{Key: MavenExclusions, Description: "the exclusions of a Maven dependency, as groupID:artifactID separated by pipes"},
+	{Key: Environment, Description: "the conditions on the local context for the dependency to apply"},
+	{Key: Selector, Description: "the edge selects the concrete version in a resolved graph"},
+	{Key: RelocatedFrom, Description: "the version required by the dependency before it was relocated"},
+}
+
+func init() {
+	for i := range attrs {
+		attrs[i].Name = attrs[i].Key.String()
+	}
+}
+
+// Attrs returns the descriptions of all the attribute keys, ordered by
+// key.
+func Attrs() []AttrInfo {
+	return slices.Clone(attrs)
+}
+
+// LookupAttr returns the description of the given attribute key.
+func LookupAttr(key AttrKey) (AttrInfo, bool) {
+	i, ok := slices.BinarySearchFunc(attrs, key, func(a AttrInfo, k AttrKey) int { return int(a.Key) - int(k) })
+	if !ok {
+		return AttrInfo{}, false
+	}
+	return attrs[i], true
+}
+
+// ParseAttrKey returns the attribute key of the given name.
+func ParseAttrKey(name string) (AttrKey, bool) {
+	for _, a := range attrs {
+		if a.Name == name {
+			return a.Key, true
+		}
+	}
+	return 0, false
+}
+
+// ForEachAttr calls fn for each attribute of the Type, in key order.
+func (t Type) ForEachAttr(fn func(key AttrKey, value string)) {
+	for _, a := range attrs {
+		if v, ok := t.GetAttr(a.Key); ok {
+			fn(a.Key, v)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dep
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestAttrs(t *testing.T) {
+	// Every named key is registered, and only those.
+	var named int
+	for k := -(1 << (maskLen - 1)); k <= math.MaxInt8; k++ {
+		key := AttrKey(k)
+		name := key.String()
+		info, ok := LookupAttr(key)
+		if strings.HasPrefix(name, "AttrKey(") {
+			if ok {
+				t.Errorf("LookupAttr(%d): got %+v for an unnamed key", k, info)
+			}
+			continue
+		}
+		named++
+		if !ok || info.Name != name || info.Description == "" {
+			t.Errorf("LookupAttr(%s): got %+v, %v", name, info, ok)
+		}
+		if got, ok := ParseAttrKey(name); !ok || got != key {
+			t.Errorf("ParseAttrKey(%q): got %v, %v", name, got, ok)
+		}
+		if info.Flag != (key < 0) {
+			t.Errorf("%s: got flag %v", name, info.Flag)
+		}
+	}
+	if got := len(Attrs()); got != named {
+		t.Errorf("Attrs: got %d keys, want %d", got, named)
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	got := NewBuilder().Opt().Scope(ScopeRuntime).MavenExclusions("g:a", "*:b").Type()
+	var want Type
+	want.AddAttr(Opt, "")
+	want.AddAttr(Scope, ScopeRuntime)
+	want.AddAttr(MavenExclusions, "g:a|*:b")
+	if !got.Equal(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	var keys []AttrKey
+	got.ForEachAttr(func(key AttrKey, value string) { keys = append(keys, key) })
+	if len(keys) != 3 || keys[0] != Opt || keys[1] != Scope || keys[2] != MavenExclusions {
+		t.Errorf("ForEachAttr: got keys %v", keys)
+	}
+}
@@ -22,17 +22,18 @@ func _() {
 	_ = x[KnownAs-8]
 	_ = x[Environment-10]
 	_ = x[Selector-11]
+	_ = x[RelocatedFrom-12]
 }
 
 const (
 	_AttrKey_name_0 = "Test"
 	_AttrKey_name_1 = "OptDev"
-	_AttrKey_name_2 = "XTestFrameworkScopeMavenClassifierMavenArtifactTypeMavenDependencyOriginEnabledDependenciesKnownAsMavenExclusionsEnvironmentSelector"
+	_AttrKey_name_2 = "XTestFrameworkScopeMavenClassifierMavenArtifactTypeMavenDependencyOriginEnabledDependenciesKnownAsMavenExclusionsEnvironmentSelectorRelocatedFrom"
 )
 
 var (
 	_AttrKey_index_1 = [...]uint8{0, 3, 6}
-	_AttrKey_index_2 = [...]uint8{0, 5, 14, 19, 34, 51, 72, 91, 98, 113, 124, 132}
+	_AttrKey_index_2 = [...]uint8{0, 5, 14, 19, 34, 51, 72, 91, 98, 113, 124, 132, 145}
 )
 
 func (i AttrKey) String() string {
@@ -42,7 +43,7 @@ func (i AttrKey) String() string {
 	case -2 <= i && i <= -1:
 		i -= -2
 		return _AttrKey_name_1[_AttrKey_index_1[i]:_AttrKey_index_1[i+1]]
-	case 1 <= i && i <= 11:
+	case 1 <= i && i <= 12:
 		i -= 1
 		return _AttrKey_name_2[_AttrKey_index_2[i]:_AttrKey_index_2[i+1]]
 	default:
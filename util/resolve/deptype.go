@@ -0,0 +1,85 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"fmt"
+	"slices"
+
+	"deps.dev/util/resolve/dep"
+)
+
+// depAttrs holds, for each system with a resolver, the dependency type
+// attributes it understands, with their valid values if restricted beyond
+// those of dep.AttrInfo.Values.
+var depAttrs = map[System]map[dep.AttrKey][]string{
+	NPM: {
+		dep.Dev:      nil,
+		dep.Opt:      nil,
+		dep.Scope:    {dep.ScopePeer, dep.ScopeBundle},
+		dep.KnownAs:  nil,
+		dep.Selector: nil,
+	},
+	Maven: {
+		dep.Opt:                   nil,
+		dep.Test:                  nil,
+		dep.Scope:                 {dep.ScopeProvided, dep.ScopeRuntime, dep.ScopeSystem, dep.ScopeImport},
+		dep.MavenClassifier:       nil,
+		dep.MavenArtifactType:     nil,
+		dep.MavenDependencyOrigin: nil,
+		dep.MavenExclusions:       nil,
+		dep.Selector:              nil,
+		dep.RelocatedFrom:         nil,
+	},
+}
+
+// DepAttrs returns the dependency type attributes understood by the
+// resolver of the system, in key order, or nil if there is none.
+func (s System) DepAttrs() []dep.AttrKey {
+	var keys []dep.AttrKey
+	for k := range depAttrs[s] {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// ValidateDepType returns an error if the given dependency type has an
+// attribute, or an attribute value, that is not valid in the system.
+func (s System) ValidateDepType(t dep.Type) error {
+	valid, ok := depAttrs[s]
+	if !ok {
+		return fmt.Errorf("no dependency type attributes for system %v", s)
+	}
+	var err error
+	t.ForEachAttr(func(key dep.AttrKey, value string) {
+		if err != nil {
+			return
+		}
+		values, ok := valid[key]
+		if !ok {
+			err = fmt.Errorf("attribute %v is not valid for %v", key, s)
+			return
+		}
+		if values == nil {
+			info, _ := dep.LookupAttr(key)
+			values = info.Values
+		}
+		if values != nil && !slices.Contains(values, value) {
+			err = fmt.Errorf("value %q of attribute %v is not valid for %v", value, key, s)
+		}
+	})
+	return err
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"deps.dev/util/resolve/dep"
+)
+
+func TestValidateDepType(t *testing.T) {
+	for _, c := range []struct {
+		sys   System
+		typ   dep.Type
+		valid bool
+	}{
+		{NPM, dep.Type{}, true},
+		{NPM, dep.NewBuilder().Dev().KnownAs("alias").Type(), true},
+		{NPM, dep.NewBuilder().Scope(dep.ScopePeer).Type(), true},
+		{NPM, dep.NewBuilder().Scope(dep.ScopeRuntime).Type(), false},
+		{NPM, dep.NewBuilder().MavenClassifier("tests").Type(), false},
+		{Maven, dep.NewBuilder().Opt().Scope(dep.ScopeRuntime).MavenClassifier("tests").Type(), true},
+		{Maven, dep.NewBuilder().MavenDependencyOrigin(dep.OriginManagement).Type(), true},
+		{Maven, dep.NewBuilder().MavenDependencyOrigin("elsewhere").Type(), false},
+		{Maven, dep.NewBuilder().Dev().Type(), false},
+		{UnknownSystem, dep.Type{}, false},
+	} {
+		err := c.sys.ValidateDepType(c.typ)
+		if (err == nil) != c.valid {
+			t.Errorf("%v.ValidateDepType(%s): got %v, want valid %v", c.sys, c.typ, err, c.valid)
+		}
+	}
+}
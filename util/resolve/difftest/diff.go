@@ -0,0 +1,126 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package difftest
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"deps.dev/util/resolve"
+)
+
+// Diff holds the differences between a local resolution and that of
+// GetDependencies. Nodes and edges are compared as sets, ignoring their
+// order and the types of the edges, which GetDependencies does not report.
+type Diff struct {
+	// Packages holds the packages resolved to different versions.
+	Packages []PackageDiff `json:"packages,omitempty"`
+	// LocalEdges holds the edges only in the local graph, and
+	// RemoteEdges those only in the GetDependencies graph, formatted as
+	// "from@version -> to@version (requirement)".
+	LocalEdges  []string `json:"localEdges,omitempty"`
+	RemoteEdges []string `json:"remoteEdges,omitempty"`
+	// LocalError and RemoteError are the graph errors, if they differ.
+	LocalError  string `json:"localError,omitempty"`
+	RemoteError string `json:"remoteError,omitempty"`
+}
+
+// PackageDiff is a package resolved to different versions. A package
+// missing from one of the graphs has no versions in it.
+type PackageDiff struct {
+	Name   string   `json:"name"`
+	Local  []string `json:"local"`
+	Remote []string `json:"remote"`
+}
+
+// Empty reports whether the graphs are the same.
+func (d *Diff) Empty() bool {
+	return len(d.Packages) == 0 && len(d.LocalEdges) == 0 && len(d.RemoteEdges) == 0 &&
+		d.LocalError == "" && d.RemoteError == ""
+}
+
+// Compute returns the differences between a local graph and that returned
+// by GetDependencies.
+func Compute(local, remote *resolve.Graph) *Diff {
+	d := &Diff{}
+
+	lv, rv := versions(local), versions(remote)
+	names := make(map[string]bool)
+	for n := range lv {
+		names[n] = true
+	}
+	for n := range rv {
+		names[n] = true
+	}
+	for n := range names {
+		l, r := sortedKeys(lv[n]), sortedKeys(rv[n])
+		if !slices.Equal(l, r) {
+			d.Packages = append(d.Packages, PackageDiff{Name: n, Local: l, Remote: r})
+		}
+	}
+	sort.Slice(d.Packages, func(i, j int) bool { return d.Packages[i].Name < d.Packages[j].Name })
+
+	le, re := edges(local), edges(remote)
+	for e := range le {
+		if !re[e] {
+			d.LocalEdges = append(d.LocalEdges, e)
+		}
+	}
+	for e := range re {
+		if !le[e] {
+			d.RemoteEdges = append(d.RemoteEdges, e)
+		}
+	}
+	sort.Strings(d.LocalEdges)
+	sort.Strings(d.RemoteEdges)
+
+	if local.Error != remote.Error {
+		d.LocalError, d.RemoteError = local.Error, remote.Error
+	}
+	return d
+}
+
+// versions returns the versions of each package in a graph.
+func versions(g *resolve.Graph) map[string]map[string]bool {
+	m := make(map[string]map[string]bool)
+	for _, n := range g.Nodes {
+		name := n.Version.Name
+		if m[name] == nil {
+			m[name] = make(map[string]bool)
+		}
+		m[name][n.Version.Version] = true
+	}
+	return m
+}
+
+// edges returns the formatted edges of a graph.
+func edges(g *resolve.Graph) map[string]bool {
+	m := make(map[string]bool)
+	for _, e := range g.Edges {
+		from, to := g.Nodes[e.From].Version, g.Nodes[e.To].Version
+		m[fmt.Sprintf("%s@%s -> %s@%s (%s)", from.Name, from.Version, to.Name, to.Version, e.Requirement)] = true
+	}
+	return m
+}
+
+func sortedKeys(m map[string]bool) []string {
+	s := make([]string, 0, len(m))
+	for k := range m {
+		s = append(s, k)
+	}
+	sort.Strings(s)
+	return s
+}
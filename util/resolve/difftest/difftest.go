@@ -0,0 +1,238 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package difftest compares the dependency graphs computed by the resolvers in
+deps.dev/util/resolve with those returned by the GetDependencies endpoint of
+the deps.dev API, so that regressions in the local resolvers can be caught,
+for example in continuous integration.
+
+A Harness samples versions of a set of packages, resolves each of them both
+ways, and reports the differences:
+
+	h := difftest.New(insights, nil)
+	vks, err := h.Sample(ctx, pkgs, 10)
+	if err != nil {
+		// ...
+	}
+	r := h.Run(ctx, vks)
+	r.WriteText(os.Stdout)
+	if !r.OK() {
+		os.Exit(1)
+	}
+*/
+package difftest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/maven"
+	"deps.dev/util/resolve/npm"
+)
+
+// NewResolver returns a resolver using the given client.
+type NewResolver func(resolve.Client) resolve.Resolver
+
+// Options configures a Harness.
+type Options struct {
+	// Client is the client used by the local resolvers and for sampling.
+	// The default is a resolve.APIClient using the Harness's Insights
+	// client.
+	Client resolve.Client
+	// Resolvers holds the local resolvers by system. The default holds
+	// the npm and Maven resolvers.
+	Resolvers map[resolve.System]NewResolver
+	// Seed seeds the sampling of versions. The default is 1, so that runs
+	// sample the same versions if the packages are unchanged.
+	Seed int64
+	// Parallelism is the number of versions compared at once. The default
+	// is 4.
+	Parallelism int
+}
+
+// Harness compares local resolutions with those of GetDependencies.
+type Harness struct {
+	insights pb.InsightsClient
+	opts     Options
+}
+
+// New returns a Harness calling GetDependencies with the given client. The
+// options may be nil.
+func New(insights pb.InsightsClient, opts *Options) *Harness {
+	h := &Harness{insights: insights}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Client == nil {
+		h.opts.Client = resolve.NewAPIClient(insights)
+	}
+	if h.opts.Resolvers == nil {
+		h.opts.Resolvers = map[resolve.System]NewResolver{
+			resolve.NPM: func(c resolve.Client) resolve.Resolver {
+				return npm.NewResolver(c)
+			},
+			resolve.Maven: func(c resolve.Client) resolve.Resolver {
+				return maven.NewResolver(c)
+			},
+		}
+	}
+	if h.opts.Seed == 0 {
+		h.opts.Seed = 1
+	}
+	if h.opts.Parallelism <= 0 {
+		h.opts.Parallelism = 4
+	}
+	return h
+}
+
+// Sample returns up to n versions per system, chosen at random among the
+// concrete versions of the given packages. Packages of systems without a
+// local resolver are ignored. The versions are sorted.
+func (h *Harness) Sample(ctx context.Context, pkgs []resolve.PackageKey, n int) ([]resolve.VersionKey, error) {
+	bySystem := make(map[resolve.System][]resolve.VersionKey)
+	for _, pk := range pkgs {
+		if h.opts.Resolvers[pk.System] == nil {
+			continue
+		}
+		vs, err := h.opts.Client.Versions(ctx, pk)
+		if err != nil {
+			return nil, fmt.Errorf("versions of %v: %w", pk, err)
+		}
+		for _, v := range vs {
+			if v.VersionType == resolve.Concrete {
+				bySystem[v.System] = append(bySystem[v.System], v.VersionKey)
+			}
+		}
+	}
+	systems := make([]resolve.System, 0, len(bySystem))
+	for sys := range bySystem {
+		systems = append(systems, sys)
+	}
+	sort.Slice(systems, func(i, j int) bool { return systems[i] < systems[j] })
+
+	rnd := rand.New(rand.NewSource(h.opts.Seed))
+	var sample []resolve.VersionKey
+	for _, sys := range systems {
+		vks := bySystem[sys]
+		// Sort first, as clients need not return versions in order.
+		sort.Slice(vks, func(i, j int) bool { return vks[i].Less(vks[j]) })
+		rnd.Shuffle(len(vks), func(i, j int) { vks[i], vks[j] = vks[j], vks[i] })
+		if len(vks) > n {
+			vks = vks[:n]
+		}
+		sample = append(sample, vks...)
+	}
+	sort.Slice(sample, func(i, j int) bool { return sample[i].Less(sample[j]) })
+	return sample, nil
+}
+
+// Run compares the resolutions of the given versions, returning a report
+// of the results in the same order.
+func (h *Harness) Run(ctx context.Context, vks []resolve.VersionKey) *Report {
+	r := &Report{Results: make([]Result, len(vks))}
+	sem := make(chan struct{}, h.opts.Parallelism)
+	var wg sync.WaitGroup
+	for i, vk := range vks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			r.Results[i] = h.Compare(ctx, vk)
+			<-sem
+		}()
+	}
+	wg.Wait()
+	return r
+}
+
+// Compare resolves a version locally and with GetDependencies, returning
+// the differences between the graphs.
+func (h *Harness) Compare(ctx context.Context, vk resolve.VersionKey) Result {
+	res := Result{Version: vk}
+	newResolver := h.opts.Resolvers[vk.System]
+	if newResolver == nil {
+		res.Error = fmt.Sprintf("no local resolver for %v", vk.System)
+		return res
+	}
+
+	start := time.Now()
+	local, err := newResolver(h.opts.Client).Resolve(ctx, vk)
+	res.LocalDuration = time.Since(start)
+	if err != nil {
+		res.Error = fmt.Sprintf("resolving locally: %v", err)
+		return res
+	}
+
+	start = time.Now()
+	remote, err := h.getDependencies(ctx, vk)
+	res.RemoteDuration = time.Since(start)
+	if err != nil {
+		res.Error = fmt.Sprintf("GetDependencies: %v", err)
+		return res
+	}
+
+	res.LocalNodes = len(local.Nodes)
+	res.RemoteNodes = len(remote.Nodes)
+	res.Diff = Compute(local, remote)
+	return res
+}
+
+// getDependencies returns the graph returned by GetDependencies for a
+// version.
+func (h *Harness) getDependencies(ctx context.Context, vk resolve.VersionKey) (*resolve.Graph, error) {
+	resp, err := h.insights.GetDependencies(ctx, &pb.GetDependenciesRequest{
+		VersionKey: &pb.VersionKey{
+			System:  vk.System.Proto(),
+			Name:    vk.Name,
+			Version: vk.Version,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var g resolve.Graph
+	for _, n := range resp.GetNodes() {
+		k := n.GetVersionKey()
+		sys, err := resolve.SystemFromProto(k.GetSystem())
+		if err != nil {
+			return nil, err
+		}
+		id := g.AddNode(resolve.VersionKey{
+			PackageKey: resolve.PackageKey{
+				System: sys,
+				Name:   k.GetName(),
+			},
+			VersionType: resolve.Concrete,
+			Version:     k.GetVersion(),
+		})
+		for _, e := range n.GetErrors() {
+			g.Nodes[id].Errors = append(g.Nodes[id].Errors, resolve.NodeError{Error: e})
+		}
+	}
+	for _, e := range resp.GetEdges() {
+		if err := g.AddEdge(resolve.NodeID(e.GetFromNode()), resolve.NodeID(e.GetToNode()), e.GetRequirement(), dep.Type{}); err != nil {
+			return nil, err
+		}
+	}
+	g.Error = resp.GetError()
+	return &g, nil
+}
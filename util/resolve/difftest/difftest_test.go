@@ -0,0 +1,178 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package difftest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/resolve"
+)
+
+// fakeInsights serves GetDependencies from prepared responses, keyed by
+// name@version.
+type fakeInsights struct {
+	pb.InsightsClient
+	deps map[string]*pb.Dependencies
+}
+
+func (f *fakeInsights) GetDependencies(ctx context.Context, req *pb.GetDependenciesRequest, opts ...grpc.CallOption) (*pb.Dependencies, error) {
+	d, ok := f.deps[req.VersionKey.Name+"@"+req.VersionKey.Version]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return d, nil
+}
+
+func vk(name, v string, vt resolve.VersionType) resolve.VersionKey {
+	return resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: name},
+		VersionType: vt,
+		Version:     v,
+	}
+}
+
+func localClient() *resolve.LocalClient {
+	c := resolve.NewLocalClient()
+	c.AddVersion(resolve.Version{VersionKey: vk("alice", "1.0.0", resolve.Concrete)}, []resolve.RequirementVersion{
+		{VersionKey: vk("bob", "^1.0.0", resolve.Requirement)},
+	})
+	c.AddVersion(resolve.Version{VersionKey: vk("alice", "2.0.0", resolve.Concrete)}, []resolve.RequirementVersion{
+		{VersionKey: vk("bob", "^1.0.0", resolve.Requirement)},
+	})
+	c.AddVersion(resolve.Version{VersionKey: vk("alice", "3.0.0", resolve.Concrete)}, nil)
+	c.AddVersion(resolve.Version{VersionKey: vk("bob", "1.0.0", resolve.Concrete)}, nil)
+	c.AddVersion(resolve.Version{VersionKey: vk("bob", "1.1.0", resolve.Concrete)}, nil)
+	return c
+}
+
+func node(name, v string) *pb.Dependencies_Node {
+	return &pb.Dependencies_Node{VersionKey: &pb.VersionKey{System: pb.System_NPM, Name: name, Version: v}}
+}
+
+func TestRun(t *testing.T) {
+	insights := &fakeInsights{deps: map[string]*pb.Dependencies{
+		// The same as the local resolution.
+		"alice@1.0.0": {
+			Nodes: []*pb.Dependencies_Node{node("alice", "1.0.0"), node("bob", "1.1.0")},
+			Edges: []*pb.Dependencies_Edge{{FromNode: 0, ToNode: 1, Requirement: "^1.0.0"}},
+		},
+		// Resolved to an older bob.
+		"alice@2.0.0": {
+			Nodes: []*pb.Dependencies_Node{node("alice", "2.0.0"), node("bob", "1.0.0")},
+			Edges: []*pb.Dependencies_Edge{{FromNode: 0, ToNode: 1, Requirement: "^1.0.0"}},
+		},
+	}}
+	h := New(insights, &Options{Client: localClient()})
+	ctx := context.Background()
+	r := h.Run(ctx, []resolve.VersionKey{
+		vk("alice", "1.0.0", resolve.Concrete),
+		vk("alice", "2.0.0", resolve.Concrete),
+		vk("alice", "3.0.0", resolve.Concrete),
+	})
+
+	var got []string
+	for _, res := range r.Results {
+		got = append(got, res.Status())
+	}
+	if want := []string{"match", "diff", "error"}; !slices.Equal(got, want) {
+		t.Fatalf("statuses: got %v, want %v", got, want)
+	}
+	if r.OK() {
+		t.Errorf("OK: got true, want false")
+	}
+	d := r.Results[1].Diff
+	want := []PackageDiff{{Name: "bob", Local: []string{"1.1.0"}, Remote: []string{"1.0.0"}}}
+	if len(d.Packages) != 1 || d.Packages[0].Name != "bob" ||
+		!slices.Equal(d.Packages[0].Local, want[0].Local) || !slices.Equal(d.Packages[0].Remote, want[0].Remote) {
+		t.Errorf("packages: got %+v, want %+v", d.Packages, want)
+	}
+	if !slices.Equal(d.LocalEdges, []string{"alice@2.0.0 -> bob@1.1.0 (^1.0.0)"}) ||
+		!slices.Equal(d.RemoteEdges, []string{"alice@2.0.0 -> bob@1.0.0 (^1.0.0)"}) {
+		t.Errorf("edges: got %v and %v", d.LocalEdges, d.RemoteEdges)
+	}
+
+	sums := r.Summaries()
+	if len(sums) != 1 || sums[0] != (Summary{System: "NPM", Total: 3, Matched: 1, Differ: 1, Errors: 1}) {
+		t.Errorf("summaries: got %+v", sums)
+	}
+
+	var text bytes.Buffer
+	if err := r.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"package bob: local [1.1.0], GetDependencies [1.0.0]", "GetDependencies: rpc error", "NPM: 3 versions, 1 matched, 1 differ, 1 errors"} {
+		if !strings.Contains(text.String(), s) {
+			t.Errorf("text report does not contain %q:\n%s", s, text.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Results []struct {
+			Name, Version, Status string
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("decoding JSON report: %v", err)
+	}
+	if len(decoded.Results) != 3 || decoded.Results[1].Version != "2.0.0" || decoded.Results[1].Status != "diff" {
+		t.Errorf("JSON report: got %+v", decoded.Results)
+	}
+}
+
+func TestSample(t *testing.T) {
+	h := New(&fakeInsights{}, &Options{Client: localClient()})
+	ctx := context.Background()
+	pkgs := []resolve.PackageKey{
+		{System: resolve.NPM, Name: "alice"},
+		{System: resolve.NPM, Name: "bob"},
+		// Ignored, having no local resolver.
+		{System: resolve.Cargo, Name: "chuck"},
+	}
+	first, err := h.Sample(ctx, pkgs, 3)
+	if err != nil {
+		t.Fatalf("Sample: %v", err)
+	}
+	if len(first) != 3 {
+		t.Fatalf("Sample: got %v, want 3 versions", first)
+	}
+	second, err := h.Sample(ctx, pkgs, 3)
+	if err != nil {
+		t.Fatalf("Sample: %v", err)
+	}
+	if !slices.Equal(first, second) {
+		t.Errorf("Sample is not deterministic: got %v and %v", first, second)
+	}
+	all, err := h.Sample(ctx, pkgs, 10)
+	if err != nil {
+		t.Fatalf("Sample: %v", err)
+	}
+	if len(all) != 5 {
+		t.Errorf("Sample: got %v, want all 5 versions", all)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package difftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"deps.dev/util/resolve"
+)
+
+// Result is the comparison of the resolutions of a version.
+type Result struct {
+	Version resolve.VersionKey `json:"-"`
+	// LocalNodes and RemoteNodes are the sizes of the graphs.
+	LocalNodes     int           `json:"localNodes"`
+	RemoteNodes    int           `json:"remoteNodes"`
+	LocalDuration  time.Duration `json:"localDuration"`
+	RemoteDuration time.Duration `json:"remoteDuration"`
+	// Diff holds the differences between the graphs, if both were
+	// computed.
+	Diff *Diff `json:"diff,omitempty"`
+	// Error is set if either graph could not be computed.
+	Error string `json:"error,omitempty"`
+}
+
+// Status returns the outcome of the comparison: "match", "diff" or "error".
+func (r Result) Status() string {
+	switch {
+	case r.Error != "":
+		return "error"
+	case !r.Diff.Empty():
+		return "diff"
+	}
+	return "match"
+}
+
+// MarshalJSON encodes the result with its version and status.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		System  string `json:"system"`
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Status  string `json:"status"`
+		result
+	}{
+		System:  r.Version.System.String(),
+		Name:    r.Version.Name,
+		Version: r.Version.Version,
+		Status:  r.Status(),
+		result:  result(r),
+	})
+}
+
+// Summary counts the results of a system.
+type Summary struct {
+	System  string `json:"system"`
+	Total   int    `json:"total"`
+	Matched int    `json:"matched"`
+	Differ  int    `json:"differ"`
+	Errors  int    `json:"errors"`
+}
+
+// Report holds the results of a run.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// OK reports whether every resolution matched.
+func (r *Report) OK() bool {
+	for _, res := range r.Results {
+		if res.Status() != "match" {
+			return false
+		}
+	}
+	return true
+}
+
+// Summaries returns the counts of results by system, sorted by system.
+func (r *Report) Summaries() []Summary {
+	bySystem := make(map[resolve.System]*Summary)
+	for _, res := range r.Results {
+		s := bySystem[res.Version.System]
+		if s == nil {
+			s = &Summary{System: res.Version.System.String()}
+			bySystem[res.Version.System] = s
+		}
+		s.Total++
+		switch res.Status() {
+		case "match":
+			s.Matched++
+		case "diff":
+			s.Differ++
+		default:
+			s.Errors++
+		}
+	}
+	systems := make([]resolve.System, 0, len(bySystem))
+	for sys := range bySystem {
+		systems = append(systems, sys)
+	}
+	sort.Slice(systems, func(i, j int) bool { return systems[i] < systems[j] })
+	sums := make([]Summary, len(systems))
+	for i, sys := range systems {
+		sums[i] = *bySystem[sys]
+	}
+	return sums
+}
+
+// WriteJSON writes the report, with its summaries, as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Summaries []Summary `json:"summaries"`
+		Results   []Result  `json:"results"`
+	}{r.Summaries(), r.Results})
+}
+
+// WriteText writes the report in a human-readable form, listing the
+// differences of the resolutions that did not match.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	for _, res := range r.Results {
+		if res.Status() == "match" {
+			continue
+		}
+		fmt.Fprintf(&b, "%v:\n", res.Version)
+		if res.Error != "" {
+			fmt.Fprintf(&b, "\terror: %s\n", res.Error)
+			continue
+		}
+		d := res.Diff
+		for _, p := range d.Packages {
+			fmt.Fprintf(&b, "\tpackage %s: local %v, GetDependencies %v\n", p.Name, p.Local, p.Remote)
+		}
+		for _, e := range d.LocalEdges {
+			fmt.Fprintf(&b, "\t+ %s\n", e)
+		}
+		for _, e := range d.RemoteEdges {
+			fmt.Fprintf(&b, "\t- %s\n", e)
+		}
+		if d.LocalError != "" || d.RemoteError != "" {
+			fmt.Fprintf(&b, "\terror: local %q, GetDependencies %q\n", d.LocalError, d.RemoteError)
+		}
+	}
+	for _, s := range r.Summaries() {
+		fmt.Fprintf(&b, "%s: %d versions, %d matched, %d differ, %d errors\n", s.System, s.Total, s.Matched, s.Differ, s.Errors)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
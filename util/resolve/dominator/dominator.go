@@ -0,0 +1,308 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package dominator finds the critical dependencies of a resolved dependency
+graph: those through which every path from the root to some other
+dependencies passes.
+
+A node dominates another if every path from the root to the other node
+passes through it, so removing or replacing the dominating node removes
+the dominated ones from the graph. Analyze reports, for each dependency,
+the nodes it dominates and the advisories and licenses found among them,
+so that the upgrades that would eliminate the most transitive risk can be
+prioritized. FetchExposure fetches the advisories and licenses of the
+versions of a graph from deps.dev.
+
+Dominators are computed with the algorithm described in "A Simple, Fast
+Dominance Algorithm" by Cooper, Harvey and Kennedy.
+*/
+package dominator
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/resolve"
+)
+
+// Immediate returns the immediate dominator of each node of the graph,
+// indexed by NodeID: the closest node, other than the node itself, through
+// which every path from the root to the node passes. The root is its own
+// immediate dominator, and nodes unreachable from the root have -1.
+func Immediate(g *resolve.Graph) []resolve.NodeID {
+	n := len(g.Nodes)
+	idom := make([]resolve.NodeID, n)
+	for i := range idom {
+		idom[i] = -1
+	}
+	if n == 0 {
+		return idom
+	}
+	succ := make([][]resolve.NodeID, n)
+	pred := make([][]resolve.NodeID, n)
+	for _, e := range g.Edges {
+		if e.From < 0 || int(e.From) >= n || e.To < 0 || int(e.To) >= n {
+			continue
+		}
+		succ[e.From] = append(succ[e.From], e.To)
+		pred[e.To] = append(pred[e.To], e.From)
+	}
+
+	// Number the reachable nodes in reverse postorder.
+	order := make([]int, n) // Position in reverse postorder, or -1.
+	for i := range order {
+		order[i] = -1
+	}
+	var post []resolve.NodeID
+	visited := make([]bool, n)
+	type frame struct {
+		node resolve.NodeID
+		next int // Index of the next successor to visit.
+	}
+	stack := []frame{{node: 0}}
+	visited[0] = true
+	for len(stack) > 0 {
+		f := &stack[len(stack)-1]
+		if f.next < len(succ[f.node]) {
+			s := succ[f.node][f.next]
+			f.next++
+			if !visited[s] {
+				visited[s] = true
+				stack = append(stack, frame{node: s})
+			}
+			continue
+		}
+		post = append(post, f.node)
+		stack = stack[:len(stack)-1]
+	}
+	rpo := slices.Clone(post)
+	slices.Reverse(rpo)
+	for i, id := range rpo {
+		order[id] = i
+	}
+
+	intersect := func(a, b resolve.NodeID) resolve.NodeID {
+		for a != b {
+			for order[a] > order[b] {
+				a = idom[a]
+			}
+			for order[b] > order[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	idom[0] = 0
+	for changed := true; changed; {
+		changed = false
+		for _, id := range rpo[1:] {
+			newIdom := resolve.NodeID(-1)
+			for _, p := range pred[id] {
+				if idom[p] < 0 {
+					continue
+				}
+				if newIdom < 0 {
+					newIdom = p
+				} else {
+					newIdom = intersect(p, newIdom)
+				}
+			}
+			if idom[id] != newIdom {
+				idom[id] = newIdom
+				changed = true
+			}
+		}
+	}
+	return idom
+}
+
+// Exposure holds the advisories and licenses of a version.
+type Exposure struct {
+	// Advisories holds the IDs of the advisories affecting the version.
+	Advisories []string
+	// Licenses holds the licenses of the version.
+	Licenses []string
+}
+
+// Dominator is a dependency of the graph, with the dependencies it
+// dominates.
+type Dominator struct {
+	Node    resolve.NodeID
+	Version resolve.VersionKey
+	// Direct reports whether the version is a direct dependency of the
+	// root.
+	Direct bool
+	// Dominated holds the nodes dominated by Node, excluding Node itself,
+	// in node order. They are the nodes that would leave the graph if Node
+	// did.
+	Dominated []resolve.NodeID
+	// Advisories and Licenses hold the distinct advisories and licenses of
+	// Node and the nodes it dominates, sorted.
+	Advisories []string
+	Licenses   []string
+}
+
+// Analyze returns a Dominator for every node of the graph reachable from
+// the root, other than the root itself. They are ordered by decreasing
+// number of advisories, then by decreasing number of dominated nodes, then
+// by version. The exposure of the versions may be nil, in which case only
+// the dominated nodes are reported.
+func Analyze(g *resolve.Graph, exposure map[resolve.VersionKey]Exposure) []Dominator {
+	idom := Immediate(g)
+	children := make([][]resolve.NodeID, len(g.Nodes))
+	for i, d := range idom {
+		if i != 0 && d >= 0 {
+			children[d] = append(children[d], resolve.NodeID(i))
+		}
+	}
+	direct := make(map[resolve.NodeID]bool)
+	for _, e := range g.Edges {
+		if e.From == 0 {
+			direct[e.To] = true
+		}
+	}
+
+	var doms []Dominator
+	for i := 1; i < len(g.Nodes); i++ {
+		id := resolve.NodeID(i)
+		if idom[id] < 0 {
+			continue
+		}
+		d := Dominator{Node: id, Version: g.Nodes[id].Version, Direct: direct[id]}
+		advisories := make(map[string]bool)
+		licenses := make(map[string]bool)
+		// Walk the subtree of the dominator tree rooted at the node.
+		stack := []resolve.NodeID{id}
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if n != id {
+				d.Dominated = append(d.Dominated, n)
+			}
+			x := exposure[g.Nodes[n].Version]
+			for _, a := range x.Advisories {
+				advisories[a] = true
+			}
+			for _, l := range x.Licenses {
+				licenses[l] = true
+			}
+			stack = append(stack, children[n]...)
+		}
+		slices.Sort(d.Dominated)
+		d.Advisories = sortedKeys(advisories)
+		d.Licenses = sortedKeys(licenses)
+		doms = append(doms, d)
+	}
+	slices.SortFunc(doms, func(a, b Dominator) int {
+		return cmp.Or(
+			cmp.Compare(len(b.Advisories), len(a.Advisories)),
+			cmp.Compare(len(b.Dominated), len(a.Dominated)),
+			a.Version.Compare(b.Version),
+			cmp.Compare(a.Node, b.Node),
+		)
+	})
+	return doms
+}
+
+func sortedKeys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Options configure FetchExposure.
+type Options struct {
+	// Concurrency is the maximum number of API calls made at once. The
+	// default is 10.
+	Concurrency int
+}
+
+// FetchExposure fetches the advisories and licenses of the versions of the
+// given graph, other than its root, with GetVersion. Versions unknown to
+// deps.dev are left out. If opts is nil, the defaults are used.
+func FetchExposure(ctx context.Context, c pb.InsightsClient, g *resolve.Graph, opts *Options) (map[resolve.VersionKey]Exposure, error) {
+	concurrency := 10
+	if opts != nil && opts.Concurrency > 0 {
+		concurrency = opts.Concurrency
+	}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, concurrency)
+		exposure = make(map[resolve.VersionKey]Exposure)
+		errs     []error
+		seen     = make(map[resolve.VersionKey]bool)
+	)
+	for i := 1; i < len(g.Nodes); i++ {
+		vk := g.Nodes[i].Version
+		if seen[vk] {
+			continue
+		}
+		seen[vk] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			v, err := c.GetVersion(ctx, &pb.GetVersionRequest{
+				VersionKey: &pb.VersionKey{
+					System:  vk.System.Proto(),
+					Name:    vk.Name,
+					Version: vk.Version,
+				},
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case status.Code(err) == codes.NotFound:
+			case err != nil:
+				errs = append(errs, fmt.Errorf("version %s: %w", vk, err))
+			default:
+				var x Exposure
+				for _, ak := range v.GetAdvisoryKeys() {
+					x.Advisories = append(x.Advisories, ak.GetId())
+				}
+				x.Licenses = v.GetLicenses()
+				exposure[vk] = x
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return exposure, nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dominator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/schema"
+)
+
+// fakeClient serves versions with fixed advisories and licenses, keyed by
+// package name.
+type fakeClient struct {
+	pb.InsightsClient
+	advisories map[string][]string
+	licenses   map[string][]string
+}
+
+func (c fakeClient) GetVersion(ctx context.Context, req *pb.GetVersionRequest, opts ...grpc.CallOption) (*pb.Version, error) {
+	vk := req.GetVersionKey()
+	if vk.GetName() == "unknown" {
+		return nil, status.Error(codes.NotFound, "version not found")
+	}
+	v := &pb.Version{VersionKey: vk, Licenses: c.licenses[vk.GetName()]}
+	for _, id := range c.advisories[vk.GetName()] {
+		v.AdvisoryKeys = append(v.AdvisoryKeys, &pb.AdvisoryKey{Id: id})
+	}
+	return v, nil
+}
+
+// testGraph returns a graph in which dave is reached through both bob and
+// chuck, and frank and grace only through bob.
+func testGraph(t *testing.T) *resolve.Graph {
+	t.Helper()
+	g, err := schema.ParseResolve(`
+app 1.0.0
+	bob@^1.0.0 1.0.0
+		d: dave@^1.0.0 1.0.0
+			eve@^1.0.0 1.0.0
+		frank@^1.0.0 1.0.0
+			grace@^1.0.0 1.0.0
+	chuck@^1.0.0 1.0.0
+		$d@^1.0.0
+	unknown@^1.0.0 1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestImmediate(t *testing.T) {
+	g := testGraph(t)
+	// An unreachable node.
+	heidi := g.AddNode(resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "heidi"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	})
+	idom := Immediate(g)
+	got := make(map[string]string)
+	for i, d := range idom {
+		name := g.Nodes[i].Version.Name
+		if d < 0 {
+			got[name] = "-"
+			continue
+		}
+		got[name] = g.Nodes[d].Version.Name
+	}
+	want := map[string]string{
+		"app":     "app",
+		"bob":     "app",
+		"chuck":   "app",
+		"dave":    "app",
+		"eve":     "dave",
+		"frank":   "bob",
+		"grace":   "frank",
+		"unknown": "app",
+		"heidi":   "-",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Immediate (-want +got):\n%s", diff)
+	}
+	if idom[heidi] != -1 {
+		t.Errorf("unreachable node: got %d, want -1", idom[heidi])
+	}
+
+	// Dominance through a cycle: app -> a -> b -> a.
+	var c resolve.Graph
+	for _, name := range []string{"app", "a", "b"} {
+		c.AddNode(resolve.VersionKey{PackageKey: resolve.PackageKey{System: resolve.NPM, Name: name}, VersionType: resolve.Concrete, Version: "1.0.0"})
+	}
+	for _, e := range [][2]resolve.NodeID{{0, 1}, {1, 2}, {2, 1}} {
+		if err := c.AddEdge(e[0], e[1], "1.0.0", dep.Type{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := Immediate(&c), []resolve.NodeID{0, 0, 1}; !cmp.Equal(got, want) {
+		t.Errorf("Immediate of a cycle: got %v, want %v", got, want)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	g := testGraph(t)
+	c := fakeClient{
+		advisories: map[string][]string{
+			"eve":   {"GHSA-2"},
+			"frank": {"GHSA-1"},
+			"grace": {"GHSA-1", "GHSA-3"},
+		},
+		licenses: map[string][]string{
+			"bob":   {"MIT"},
+			"grace": {"GPL-3.0"},
+			"eve":   {"MIT"},
+		},
+	}
+	exposure, err := FetchExposure(context.Background(), c, g, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range Analyze(g, exposure) {
+		var dominated []string
+		for _, n := range d.Dominated {
+			dominated = append(dominated, g.Nodes[n].Version.Name)
+		}
+		got = append(got, fmt.Sprintf("%s direct=%t dominated=%v advisories=%v licenses=%v",
+			d.Version.Name, d.Direct, dominated, d.Advisories, d.Licenses))
+	}
+	want := []string{
+		"bob direct=true dominated=[frank grace] advisories=[GHSA-1 GHSA-3] licenses=[GPL-3.0 MIT]",
+		"frank direct=false dominated=[grace] advisories=[GHSA-1 GHSA-3] licenses=[GPL-3.0]",
+		"grace direct=false dominated=[] advisories=[GHSA-1 GHSA-3] licenses=[GPL-3.0]",
+		"dave direct=false dominated=[eve] advisories=[GHSA-2] licenses=[MIT]",
+		"eve direct=false dominated=[] advisories=[GHSA-2] licenses=[MIT]",
+		"chuck direct=true dominated=[] advisories=[] licenses=[]",
+		"unknown direct=true dominated=[] advisories=[] licenses=[]",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Analyze (-want +got):\n%s", diff)
+	}
+}
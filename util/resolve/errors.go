@@ -0,0 +1,93 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import "fmt"
+
+// The following types describe resolution errors with machine-readable
+// fields. Resolvers attach them to a Graph and its NodeErrors, alongside the
+// error strings meant for display; use errors.As to inspect them.
+
+// UnsatisfiedRequirement is the error of a requirement that no version of
+// its package satisfies.
+type UnsatisfiedRequirement struct {
+	// Requirement is the requirement that could not be satisfied.
+	Requirement VersionKey
+	// Requirements holds the versions of all the requirements on the
+	// package that had to be satisfied together, if the resolver merges
+	// them, as Maven does. It is nil otherwise.
+	Requirements []string
+}
+
+func (e *UnsatisfiedRequirement) Error() string {
+	if e.Requirements != nil {
+		return fmt.Sprintf("could not find a version that satisfies requirements %s for package %s", e.Requirements, e.Requirement.Name)
+	}
+	return fmt.Sprintf("could not find a version that satisfies requirement %s for package %s", e.Requirement.Version, e.Requirement.Name)
+}
+
+// UnreachableRegistry is the error of a requirement satisfied only by
+// versions held in registries that the resolution is not configured to
+// use. If Requirement is zero, the error concerns the graph as a whole: it
+// differs from the graph resolved using every known registry.
+type UnreachableRegistry struct {
+	// Requirement is the requirement that could not be satisfied.
+	Requirement VersionKey
+	// Version is the version that would satisfy the requirement.
+	Version VersionKey
+	// Registries holds the registries in which Version is available.
+	Registries []string
+}
+
+func (e *UnreachableRegistry) Error() string {
+	if e.Requirement == (VersionKey{}) {
+		return "multi-registry resolution differ: missing repository configuration"
+	}
+	return fmt.Sprintf("could not find a version that satisfies requirement %s for package %s", e.Requirement.Version, e.Requirement.Name)
+}
+
+// ResolutionImpossible is the error of a version that satisfies a
+// requirement but cannot be placed in the graph, such as an npm version
+// that conflicts with another version installed at the same place.
+type ResolutionImpossible struct {
+	// Requirement is the requirement that Version satisfies. It is zero
+	// if Version was not selected for a requirement, such as an unused
+	// version bundled in another.
+	Requirement VersionKey
+	// Version is the version that cannot be placed.
+	Version VersionKey
+	// Conflict is the version in the way of Version, if any.
+	Conflict VersionKey
+	// Message describes the error.
+	Message string
+}
+
+func (e *ResolutionImpossible) Error() string {
+	return e.Message
+}
+
+// UnsupportedRequirement is the error of a requirement that the resolver
+// does not resolve against the registry, such as an npm dependency on a git
+// repository or a local directory.
+type UnsupportedRequirement struct {
+	// Requirement is the unsupported requirement.
+	Requirement VersionKey
+	// Kind is the kind of the requirement, such as "git" or "file".
+	Kind string
+}
+
+func (e *UnsupportedRequirement) Error() string {
+	return fmt.Sprintf("unsupported %s requirement %s for package %s", e.Kind, e.Requirement.Version, e.Requirement.Name)
+}
@@ -13,6 +13,7 @@ require (
 	deps.dev/util/semver v0.0.0-20240109040450-1e316b822bc4
 	github.com/google/go-cmp v0.6.0
 	google.golang.org/grpc v1.69.4
+	google.golang.org/protobuf v1.35.1
 )
 
 require (
@@ -20,5 +21,4 @@ require (
 	golang.org/x/sys v0.26.0 // indirect
 	golang.org/x/text v0.19.0 // indirect
 	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
-	google.golang.org/protobuf v1.35.1 // indirect
 )
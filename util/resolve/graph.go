@@ -15,8 +15,11 @@
 package resolve
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,13 +34,62 @@ type NodeID int
 // Node is a concrete version in a resolved dependency Graph.
 type Node struct {
 	Version VersionKey
-	Errors  []NodeError
+	// Attrs holds the attributes of the artifact of the version the node
+	// stands for, when a version has several artifacts, such as the
+	// MavenClassifier and MavenArtifactType of a Maven artifact. Nodes of
+	// the same version with different attributes are distinct.
+	Attrs dep.Type
+	// BundledFrom is set on the nodes of versions installed from an npm
+	// bundle, whose names are mangled to include the bundling version: it
+	// is the version the bundled one is derived from, which may not exist
+	// in the registry. Alias is the name under which the bundled version
+	// is installed, if it differs from the name of its package. Both are
+	// implied by the version, so they are ignored when comparing and
+	// fingerprinting graphs.
+	BundledFrom VersionKey
+	Alias       string
+	Errors      []NodeError
+	// Status is the state of the version in its registry, as set by
+	// Annotate. It is not part of the resolution, so it is ignored when
+	// comparing and fingerprinting graphs.
+	Status VersionStatus
+}
+
+// Bundled reports whether the node is a version installed from an npm
+// bundle.
+func (n Node) Bundled() bool { return n.BundledFrom != (VersionKey{}) }
+
+// Package returns the package of the version of the node, that of the
+// version it is derived from for a bundled version.
+func (n Node) Package() PackageKey {
+	if n.Bundled() {
+		return n.BundledFrom.PackageKey
+	}
+	return n.Version.PackageKey
+}
+
+// Bundler returns the version whose package holds the bundle the version
+// of the node is installed from, as found in its mangled name.
+func (n Node) Bundler() (VersionKey, bool) {
+	parts := strings.SplitN(n.Version.Name, ">", 3)
+	if !n.Bundled() || len(parts) < 3 {
+		return VersionKey{}, false
+	}
+	return VersionKey{
+		PackageKey:  PackageKey{System: n.Version.System, Name: parts[0]},
+		VersionType: Concrete,
+		Version:     parts[1],
+	}, true
 }
 
 // NodeError holds error information for a Node's Requirement.
 type NodeError struct {
 	Req   VersionKey
 	Error string
+	// Err is the structured form of Error, if the resolver provides one,
+	// such as an *UnsatisfiedRequirement. It is ignored when comparing
+	// NodeErrors.
+	Err error
 }
 
 func (ne NodeError) Compare(other NodeError) int {
@@ -69,6 +121,9 @@ type Graph struct {
 	// is not used for errors that are independent of the data such as a
 	// network connection problem.
 	Error string
+	// Err is the structured form of Error, if the resolver provides one.
+	// Several errors are joined with errors.Join.
+	Err error
 
 	// Duration is the time it took to perform this resolution.
 	Duration time.Duration
@@ -83,6 +138,16 @@ func (g *Graph) AddNode(vk VersionKey) NodeID {
 	return NodeID(len(g.Nodes) - 1)
 }
 
+// AddArtifactNode is like AddNode, but the node stands for the artifact of
+// the version with the given attributes.
+func (g *Graph) AddArtifactNode(vk VersionKey, attrs dep.Type) NodeID {
+	g.Nodes = append(g.Nodes, Node{
+		Version: vk,
+		Attrs:   attrs,
+	})
+	return NodeID(len(g.Nodes) - 1)
+}
+
 // AddEdge inserts an edge in the graph between the two provided nodes.
 func (g *Graph) AddEdge(from, to NodeID, req string, t dep.Type) error {
 	if !g.contains(from) {
@@ -113,6 +178,20 @@ func (g *Graph) AddError(n NodeID, req VersionKey, err string) error {
 	return nil
 }
 
+// AddNodeError is like AddError, but records both the structured error
+// and its message.
+func (g *Graph) AddNodeError(n NodeID, req VersionKey, err error) error {
+	if !g.contains(n) {
+		return fmt.Errorf("node not in graph: %v", n)
+	}
+	g.Nodes[n].Errors = append(g.Nodes[n].Errors, NodeError{
+		Req:   req,
+		Error: err.Error(),
+		Err:   err,
+	})
+	return nil
+}
+
 // contains checks if a provided NodeID is actually in the graph.
 func (g *Graph) contains(n NodeID) bool {
 	return n >= 0 && int(n) < len(g.Nodes)
@@ -310,6 +389,9 @@ func (n Node) Compare(o Node) int {
 	if c := n.Version.Compare(o.Version); c != 0 {
 		return c
 	}
+	if c := n.Attrs.Compare(o.Attrs); c != 0 {
+		return c
+	}
 	// They must have the same version, are the error slices different?
 	if li, lj := len(n.Errors), len(o.Errors); li < lj {
 		return -1
@@ -327,7 +409,8 @@ func (n Node) Compare(o Node) int {
 // String produces a text representation of the graph.
 // The graph is represented by a spanning tree computed using the creator
 // relationship (when available, first edge otherwise).
-// Extraneous (non creating) edges are represented using labels.
+// Extraneous (non creating) edges are represented using labels, and the
+// attributes of artifact nodes follow their version in brackets.
 // The representation is recognized by the resolve graph schema.
 func (g *Graph) String() string {
 	var b strings.Builder
@@ -455,7 +538,11 @@ func (g *Graph) String() string {
 		if n.err != "" {
 			fmt.Fprintf(&b, "ERROR: %s\n", n.err)
 		} else {
-			fmt.Fprintf(&b, "%s\n", n.n.Version.Version)
+			fmt.Fprintf(&b, "%s", n.n.Version.Version)
+			if !n.n.Attrs.IsRegular() {
+				fmt.Fprintf(&b, " [%s]", schemaAttrs(n.n.Attrs))
+			}
+			fmt.Fprintln(&b)
 		}
 		for i, c := range n.children {
 			p1 := "├─ "
@@ -475,3 +562,62 @@ func (g *Graph) String() string {
 	}
 	return b.String()
 }
+
+// schemaAttrs formats the attributes of a dependency type as they are
+// written in the resolve graph schema: lowercase keys, each followed by its
+// value if it has one.
+func schemaAttrs(t dep.Type) string {
+	var ss []string
+	t.ForEachAttr(func(key dep.AttrKey, value string) {
+		ss = append(ss, strings.ToLower(key.String()))
+		switch {
+		case value == "":
+		case strings.ContainsAny(value, " \t\""):
+			ss = append(ss, strconv.Quote(value))
+		default:
+			ss = append(ss, value)
+		}
+	})
+	return strings.Join(ss, " ")
+}
+
+// Fingerprint returns a hash of the contents of the graph, the hex-encoded
+// SHA-256 of its canonical form: two graphs have the same fingerprint if
+// they have the same nodes and node attributes, errors, edges with their requirements and types,
+// and graph-wide error, regardless of the order in which they were added.
+// The duration and structured errors are not included. The graph is left
+// unchanged; an error is returned if it cannot be canonicalized.
+func (g *Graph) Fingerprint() (string, error) {
+	c := &Graph{
+		Nodes: make([]Node, len(g.Nodes)),
+		Edges: make([]Edge, len(g.Edges)),
+		Error: g.Error,
+	}
+	for i, n := range g.Nodes {
+		n.Errors = append([]NodeError(nil), n.Errors...)
+		c.Nodes[i] = n
+	}
+	copy(c.Edges, g.Edges)
+	if err := c.Canon(); err != nil {
+		return "", err
+	}
+
+	// Strings are quoted so that the encoding is unambiguous.
+	h := sha256.New()
+	fmt.Fprintf(h, "error %q\n", c.Error)
+	for i, n := range c.Nodes {
+		v := n.Version
+		fmt.Fprintf(h, "node %d %d %q %d %q\n", i, v.System, v.Name, v.VersionType, v.Version)
+		if !n.Attrs.IsRegular() {
+			fmt.Fprintf(h, "nodeattrs %q\n", n.Attrs.String())
+		}
+		for _, ne := range n.Errors {
+			r := ne.Req
+			fmt.Fprintf(h, "nodeerror %d %q %d %q %q\n", r.System, r.Name, r.VersionType, r.Version, ne.Error)
+		}
+	}
+	for _, e := range c.Edges {
+		fmt.Fprintf(h, "edge %d %d %q %q\n", e.From, e.To, e.Requirement, e.Type.String())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
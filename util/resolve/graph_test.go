@@ -0,0 +1,102 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+	"time"
+
+	"deps.dev/util/resolve/dep"
+)
+
+func TestGraphFingerprint(t *testing.T) {
+	vk := func(name, version string) VersionKey {
+		return VersionKey{PackageKey: PackageKey{System: NPM, Name: name}, VersionType: Concrete, Version: version}
+	}
+	type edge struct {
+		from, to string
+		req      string
+		typ      dep.Type
+	}
+	build := func(names []string, edges []edge) *Graph {
+		g := &Graph{}
+		ids := make(map[string]NodeID)
+		for _, name := range names {
+			ids[name] = g.AddNode(vk(name, "1.0.0"))
+		}
+		for _, e := range edges {
+			if err := g.AddEdge(ids[e.from], ids[e.to], e.req, e.typ); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return g
+	}
+	fingerprint := func(g *Graph) string {
+		t.Helper()
+		f, err := g.Fingerprint()
+		if err != nil {
+			t.Fatalf("Fingerprint: %v", err)
+		}
+		return f
+	}
+
+	edges := []edge{
+		{"alice", "bob", "^1.0.0", dep.NewType()},
+		{"alice", "chuck", "^1.0.0", dep.NewType(dep.Dev)},
+		{"bob", "chuck", "1.0.0", dep.NewType()},
+	}
+	g1 := build([]string{"alice", "bob", "chuck"}, edges)
+	before := g1.String()
+	f1 := fingerprint(g1)
+	if got := g1.String(); got != before {
+		t.Errorf("Fingerprint modified the graph:\n%s\nwant:\n%s", got, before)
+	}
+
+	// The same graph built in another order.
+	g2 := build([]string{"alice", "chuck", "bob"}, []edge{edges[2], edges[1], edges[0]})
+	g2.Duration = time.Second
+	if f2 := fingerprint(g2); f2 != f1 {
+		t.Errorf("fingerprints of the same graph differ: %s and %s", f1, f2)
+	}
+
+	// Graphs differing by a requirement, a type, a node or an error.
+	seen := map[string]string{f1: "original"}
+	changed := func(desc string, g *Graph) {
+		t.Helper()
+		f := fingerprint(g)
+		if other, ok := seen[f]; ok {
+			t.Errorf("%s: same fingerprint as %s", desc, other)
+		}
+		seen[f] = desc
+	}
+	changed("requirement", build([]string{"alice", "bob", "chuck"}, []edge{
+		edges[0], edges[1], {"bob", "chuck", "^1.0.0", dep.NewType()},
+	}))
+	changed("type", build([]string{"alice", "bob", "chuck"}, []edge{
+		edges[0], {"alice", "chuck", "^1.0.0", dep.NewType(dep.Opt)}, edges[2],
+	}))
+	changed("node", build([]string{"alice", "bob", "chuck", "dave"}, edges))
+	g := build([]string{"alice", "bob", "chuck"}, edges)
+	if err := g.AddError(1, VersionKey{PackageKey: PackageKey{System: NPM, Name: "dave"}, VersionType: Requirement, Version: "^2.0.0"}, "not found"); err != nil {
+		t.Fatal(err)
+	}
+	changed("node error", g)
+	g = build([]string{"alice", "bob", "chuck"}, edges)
+	g.Error = "graph error"
+	changed("graph error", g)
+	g = build([]string{"alice", "bob", "chuck"}, edges)
+	g.Nodes[2].Attrs.AddAttr(dep.MavenClassifier, "tests")
+	changed("node attributes", g)
+}
@@ -0,0 +1,297 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package health summarizes the health of the source code projects behind the
+versions of a resolved dependency graph, using the project metadata and
+OpenSSF Scorecard results held by deps.dev.
+
+For each node of the graph, NewReport finds the version's source repository
+with GetVersion, then fetches the project with GetProject, fetching each
+project once however many versions it is related to. The dependencies of
+the report are ordered by Risk, the riskiest first, so that the projects
+most in need of attention head the list.
+
+Each dependency also has a Maintenance risk, which combines the project's
+Scorecard results, popularity and open issues into a single score from 0 to
+1 while keeping the sub-scores that make it up, so that likely abandoned
+dependencies can be found with Report.AtRisk.
+
+See https://github.com/ossf/scorecard for a description of the Scorecard
+checks.
+*/
+package health
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/resolve"
+)
+
+// MaxRisk is the risk of a dependency with no known project or no
+// Scorecard.
+const MaxRisk = 10
+
+// Dependency is the health of the project behind a version in the graph.
+type Dependency struct {
+	Version resolve.VersionKey
+	// Direct reports whether the version is a direct dependency of the
+	// root.
+	Direct bool
+	// Status is the state of the version in its registry, such as
+	// deprecated or yanked, if the graph was annotated with
+	// resolve.Graph.Annotate.
+	Status resolve.VersionStatus
+	// Project is the ID of the version's source repository, such as
+	// github.com/google/go-cmp, or empty if it is not known.
+	Project string
+	// Stars, Forks, OpenIssues and License are taken from the project's
+	// host.
+	Stars, Forks, OpenIssues int
+	License                  string
+	// Scorecard holds the project's Scorecard result, if it has one.
+	Scorecard *Scorecard
+	// Risk is a measure of how much attention the dependency needs, from
+	// 0 to MaxRisk: MaxRisk less the Scorecard's overall score, or MaxRisk
+	// if the project or its Scorecard is unknown.
+	Risk float64
+	// Maintenance is the risk that the project is poorly maintained or
+	// abandoned.
+	Maintenance Maintenance
+}
+
+// Scorecard is an OpenSSF Scorecard result.
+type Scorecard struct {
+	Date  time.Time
+	Score float64 // The overall score, from 0 to 10.
+	// Checks holds the results of the individual checks, in the order
+	// they are given by deps.dev.
+	Checks []Check
+}
+
+// Check is the result of a Scorecard check.
+type Check struct {
+	Name string
+	// Score is from 0 to 10, or -1 if the check could not be run.
+	Score int
+}
+
+// Failing returns the names of the checks scoring below the given
+// threshold, excluding those that could not be run.
+func (s *Scorecard) Failing(threshold int) []string {
+	var names []string
+	for _, c := range s.Checks {
+		if c.Score >= 0 && c.Score < threshold {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// Report holds the health of the dependencies in a graph.
+type Report struct {
+	// Dependencies holds a Dependency for every node of the graph except
+	// its root, ordered by decreasing Risk, then by version.
+	Dependencies []Dependency
+}
+
+// Options configure NewReport.
+type Options struct {
+	// Concurrency is the maximum number of API calls made at once. The
+	// default is 10.
+	Concurrency int
+	// Weights gives the weight of each signal in the Maintenance risk,
+	// keyed by signal name. Signals missing from the map are reported but
+	// do not count. The default is DefaultWeights.
+	Weights map[string]float64
+}
+
+// NewReport returns the health of the dependencies in the given graph,
+// fetching their projects with the given client. Versions and projects
+// unknown to deps.dev are reported with the maximum risk. If opts is nil,
+// the defaults are used.
+func NewReport(ctx context.Context, c pb.InsightsClient, g *resolve.Graph, opts *Options) (*Report, error) {
+	if len(g.Nodes) == 0 {
+		return nil, errors.New("empty graph")
+	}
+	concurrency, weights := 10, DefaultWeights
+	if opts != nil && opts.Concurrency > 0 {
+		concurrency = opts.Concurrency
+	}
+	if opts != nil && opts.Weights != nil {
+		weights = opts.Weights
+	}
+	direct := make(map[resolve.NodeID]bool)
+	for _, e := range g.Edges {
+		if e.From == 0 {
+			direct[e.To] = true
+		}
+	}
+
+	f := &fetcher{
+		c:        c,
+		sem:      make(chan struct{}, concurrency),
+		weights:  weights,
+		projects: make(map[string]*projectResult),
+	}
+	deps := make([]Dependency, len(g.Nodes)-1)
+	errs := make([]error, len(deps))
+	var wg sync.WaitGroup
+	for i := range deps {
+		id := resolve.NodeID(i + 1)
+		deps[i] = Dependency{Version: g.Nodes[id].Version, Direct: direct[id], Status: g.Nodes[id].Status, Risk: MaxRisk, Maintenance: Maintenance{Risk: 1}}
+		wg.Add(1)
+		go func(d *Dependency) {
+			defer wg.Done()
+			errs[i] = f.fill(ctx, d)
+		}(&deps[i])
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(deps, func(a, b Dependency) int {
+		return cmp.Or(cmp.Compare(b.Risk, a.Risk), a.Version.Compare(b.Version))
+	})
+	return &Report{Dependencies: deps}, nil
+}
+
+// fetcher fetches versions and projects, making at most cap(sem) calls at
+// once and fetching each project once.
+type fetcher struct {
+	c       pb.InsightsClient
+	sem     chan struct{}
+	weights map[string]float64
+
+	mu       sync.Mutex
+	projects map[string]*projectResult
+}
+
+// projectResult is the result of fetching a project. The done channel is
+// closed once the fetch is complete.
+type projectResult struct {
+	done    chan struct{}
+	project *pb.Project
+	err     error
+}
+
+// call calls f, waiting for a free slot if too many calls are in progress.
+func (f *fetcher) call(ctx context.Context, fn func() error) error {
+	select {
+	case f.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-f.sem }()
+	return fn()
+}
+
+// fill fills in the project details of d.
+func (f *fetcher) fill(ctx context.Context, d *Dependency) error {
+	vk := d.Version
+	var v *pb.Version
+	err := f.call(ctx, func() (err error) {
+		v, err = f.c.GetVersion(ctx, &pb.GetVersionRequest{
+			VersionKey: &pb.VersionKey{
+				System:  vk.System.Proto(),
+				Name:    vk.Name,
+				Version: vk.Version,
+			},
+		})
+		return err
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("version %v: %w", vk, err)
+	}
+	d.Project = sourceRepo(v)
+	if d.Project == "" {
+		return nil
+	}
+	p, err := f.project(ctx, d.Project)
+	if err != nil || p == nil {
+		return err
+	}
+	d.Stars = int(p.GetStarsCount())
+	d.Forks = int(p.GetForksCount())
+	d.OpenIssues = int(p.GetOpenIssuesCount())
+	d.License = p.GetLicense()
+	d.Maintenance = assess(p, f.weights)
+	if sc := p.GetScorecard(); sc != nil {
+		d.Scorecard = &Scorecard{
+			Date:  sc.GetDate().AsTime(),
+			Score: float64(sc.GetOverallScore()),
+		}
+		for _, c := range sc.GetChecks() {
+			d.Scorecard.Checks = append(d.Scorecard.Checks, Check{Name: c.GetName(), Score: int(c.GetScore())})
+		}
+		d.Risk = MaxRisk - d.Scorecard.Score
+	}
+	return nil
+}
+
+// project returns the project with the given ID, or nil if it is not
+// known to deps.dev.
+func (f *fetcher) project(ctx context.Context, id string) (*pb.Project, error) {
+	f.mu.Lock()
+	r, ok := f.projects[id]
+	if !ok {
+		r = &projectResult{done: make(chan struct{})}
+		f.projects[id] = r
+	}
+	f.mu.Unlock()
+	if ok {
+		select {
+		case <-r.done:
+			return r.project, r.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	defer close(r.done)
+	r.err = f.call(ctx, func() (err error) {
+		r.project, err = f.c.GetProject(ctx, &pb.GetProjectRequest{ProjectKey: &pb.ProjectKey{Id: id}})
+		return err
+	})
+	if status.Code(r.err) == codes.NotFound {
+		r.err = nil
+	} else if r.err != nil {
+		r.err = fmt.Errorf("project %s: %w", id, r.err)
+	}
+	return r.project, r.err
+}
+
+// sourceRepo returns the ID of the source repository of a version, or an
+// empty string if it has none.
+func sourceRepo(v *pb.Version) string {
+	for _, p := range v.GetRelatedProjects() {
+		if p.GetRelationType() == pb.ProjectRelationType_SOURCE_REPO {
+			return p.GetProjectKey().GetId()
+		}
+	}
+	return ""
+}
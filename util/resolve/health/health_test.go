@@ -0,0 +1,240 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/schema"
+)
+
+// fakeClient serves the related projects of versions and the projects
+// themselves from fixed data, counting the calls to GetProject.
+type fakeClient struct {
+	pb.InsightsClient
+	repos    map[string]string // Keyed by package name.
+	projects map[string]*pb.Project
+
+	mu           sync.Mutex
+	projectCalls int
+}
+
+func (c *fakeClient) GetVersion(ctx context.Context, req *pb.GetVersionRequest, opts ...grpc.CallOption) (*pb.Version, error) {
+	vk := req.GetVersionKey()
+	if vk.GetName() == "unknown" {
+		return nil, status.Error(codes.NotFound, "version not found")
+	}
+	v := &pb.Version{VersionKey: vk}
+	if repo, ok := c.repos[vk.GetName()]; ok {
+		v.RelatedProjects = []*pb.Version_Project{{
+			ProjectKey:   &pb.ProjectKey{Id: repo},
+			RelationType: pb.ProjectRelationType_SOURCE_REPO,
+		}}
+	}
+	return v, nil
+}
+
+func (c *fakeClient) GetProject(ctx context.Context, req *pb.GetProjectRequest, opts ...grpc.CallOption) (*pb.Project, error) {
+	c.mu.Lock()
+	c.projectCalls++
+	c.mu.Unlock()
+	p, ok := c.projects[req.GetProjectKey().GetId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "project not found")
+	}
+	return p, nil
+}
+
+func TestNewReport(t *testing.T) {
+	g, err := schema.ParseResolve(`
+app 1.0.0
+	alice@^1.0.0 1.2.0
+		b: bob@^2.0.0 2.0.1
+	$b@^2.0.0
+	carol@^1.0.0 1.0.0
+	unknown@^1.0.0 1.0.0
+	dave@^1.0.0 1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range g.Find(resolve.PackageKey{System: resolve.NPM, Name: "carol"}) {
+		g.Nodes[id].Status = resolve.StatusBlocked
+	}
+	date := timestamppb.New(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	c := &fakeClient{
+		repos: map[string]string{
+			"alice": "github.com/alice/alice",
+			"bob":   "github.com/bob/bob",
+			"carol": "github.com/bob/bob",
+			"dave":  "github.com/dave/gone",
+		},
+		projects: map[string]*pb.Project{
+			"github.com/alice/alice": {
+				ProjectKey: &pb.ProjectKey{Id: "github.com/alice/alice"},
+				StarsCount: 100,
+				License:    "MIT",
+				Scorecard: &pb.Project_Scorecard{
+					Date:         date,
+					OverallScore: 8.5,
+					Checks: []*pb.Project_Scorecard_Check{
+						{Name: "Maintained", Score: 10},
+						{Name: "Fuzzing", Score: 0},
+						{Name: "Signed-Releases", Score: -1},
+					},
+				},
+			},
+			"github.com/bob/bob": {
+				ProjectKey: &pb.ProjectKey{Id: "github.com/bob/bob"},
+				Scorecard:  &pb.Project_Scorecard{Date: date, OverallScore: 3},
+			},
+		},
+	}
+	r, err := NewReport(context.Background(), c, g, &Options{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type summary struct {
+		Name    string
+		Direct  bool
+		Project string
+		Status  resolve.VersionStatus
+		Risk    float64
+	}
+	var got []summary
+	for _, d := range r.Dependencies {
+		got = append(got, summary{d.Version.Name, d.Direct, d.Project, d.Status, d.Risk})
+	}
+	want := []summary{
+		{"dave", true, "github.com/dave/gone", 0, MaxRisk},
+		{"unknown", true, "", 0, MaxRisk},
+		{"bob", true, "github.com/bob/bob", 0, 7},
+		{"carol", true, "github.com/bob/bob", resolve.StatusBlocked, 7},
+		{"alice", true, "github.com/alice/alice", 0, 1.5},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("dependencies (-want +got):\n%s", diff)
+	}
+	alice := r.Dependencies[len(r.Dependencies)-1]
+	if alice.Stars != 100 || alice.License != "MIT" {
+		t.Errorf("alice: got stars %d, license %q", alice.Stars, alice.License)
+	}
+	if diff := cmp.Diff([]string{"Fuzzing"}, alice.Scorecard.Failing(5)); diff != "" {
+		t.Errorf("alice failing checks (-want +got):\n%s", diff)
+	}
+	// Each of the three projects is fetched once.
+	if c.projectCalls != 3 {
+		t.Errorf("GetProject called %d times, want 3", c.projectCalls)
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	g, err := schema.ParseResolve(`
+app 1.0.0
+	alice@^1.0.0 1.2.0
+	bob@^2.0.0 2.0.1
+	dave@^1.0.0 1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &fakeClient{
+		repos: map[string]string{
+			"alice": "github.com/alice/alice",
+			"bob":   "github.com/bob/bob",
+			"dave":  "github.com/dave/gone",
+		},
+		projects: map[string]*pb.Project{
+			"github.com/alice/alice": {
+				StarsCount:      9990,
+				ForksCount:      9,
+				OpenIssuesCount: 1009,
+				Scorecard: &pb.Project_Scorecard{
+					OverallScore: 8,
+					Checks: []*pb.Project_Scorecard_Check{
+						{Name: "Maintained", Score: 10},
+					},
+				},
+			},
+			// bob has no stars or Scorecard Maintained check.
+			"github.com/bob/bob": {
+				OpenIssuesCount: 10,
+				Scorecard:       &pb.Project_Scorecard{OverallScore: 4},
+			},
+		},
+	}
+	r, err := NewReport(context.Background(), c, g, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]Maintenance)
+	for _, d := range r.Dependencies {
+		got[d.Version.Name] = d.Maintenance
+	}
+	want := map[string]Maintenance{
+		"alice": {
+			Risk: (0.4*0 + 0.2*0.2 + 0.2*0 + 0.2*0.5) / 1.0,
+			SubScores: []SubScore{
+				{SignalMaintained, 0, 0.4, "Maintained check 10/10"},
+				{SignalScorecard, 0.2, 0.2, "overall score 8.0/10"},
+				{SignalPopularity, 0, 0.2, "9990 stars, 9 forks"},
+				{SignalIssues, 0.5, 0.2, "1009 open issues"},
+			},
+		},
+		"bob": {
+			Risk: (0.2*0.6 + 0.2*1 + 0.2*0.5) / 0.6,
+			SubScores: []SubScore{
+				{SignalScorecard, 0.6, 0.2, "overall score 4.0/10"},
+				{SignalPopularity, 1, 0.2, "0 stars, 0 forks"},
+				{SignalIssues, 0.5, 0.2, "10 open issues"},
+			},
+		},
+		"dave": {Risk: 1},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("maintenance (-want +got):\n%s", diff)
+	}
+
+	var atRisk []string
+	for _, d := range r.AtRisk(0.5) {
+		atRisk = append(atRisk, d.Version.Name)
+	}
+	if diff := cmp.Diff([]string{"dave", "bob"}, atRisk); diff != "" {
+		t.Errorf("AtRisk (-want +got):\n%s", diff)
+	}
+
+	// Only the weighted signals count.
+	r, err = NewReport(context.Background(), c, g, &Options{Weights: map[string]float64{SignalPopularity: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range r.Dependencies {
+		if d.Version.Name == "bob" && d.Maintenance.Risk != 1 {
+			t.Errorf("bob: got risk %v with only popularity weighted, want 1", d.Maintenance.Risk)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"fmt"
+	"math"
+
+	pb "deps.dev/api/v3"
+)
+
+// The signals combined into a Maintenance risk.
+const (
+	// SignalMaintained is the Scorecard Maintained check, which measures
+	// recent commit and issue activity.
+	SignalMaintained = "maintained"
+	// SignalScorecard is the overall Scorecard score.
+	SignalScorecard = "scorecard"
+	// SignalPopularity is the number of stars and forks of the project.
+	SignalPopularity = "popularity"
+	// SignalIssues is the number of open issues relative to the project's
+	// popularity.
+	SignalIssues = "issues"
+)
+
+// DefaultWeights are the weights given to each signal when Options.Weights
+// is nil. Activity counts most, since a project without it is likely
+// abandoned whatever its other merits.
+var DefaultWeights = map[string]float64{
+	SignalMaintained: 0.4,
+	SignalScorecard:  0.2,
+	SignalPopularity: 0.2,
+	SignalIssues:     0.2,
+}
+
+// Maintenance is a normalized measure of the risk that a dependency's
+// project is poorly maintained or abandoned, with the sub-scores it was
+// computed from.
+type Maintenance struct {
+	// Risk is from 0, a well maintained project, to 1: the mean of the
+	// risks of the sub-scores weighted by their weights, or 1 if the
+	// project is not known.
+	Risk float64
+	// SubScores holds the signals that were available for the project, in
+	// the order maintained, scorecard, popularity, issues.
+	SubScores []SubScore
+}
+
+// SubScore is the risk contributed by a single signal.
+type SubScore struct {
+	Signal string
+	// Risk is from 0 to 1.
+	Risk   float64
+	Weight float64
+	// Detail describes the data the risk was computed from, such as
+	// "120 stars, 8 forks".
+	Detail string
+}
+
+// AtRisk returns the dependencies whose maintenance risk is at least the
+// given threshold, in the order of r.Dependencies.
+func (r *Report) AtRisk(threshold float64) []Dependency {
+	var deps []Dependency
+	for _, d := range r.Dependencies {
+		if d.Maintenance.Risk >= threshold {
+			deps = append(deps, d)
+		}
+	}
+	return deps
+}
+
+// assess returns the maintenance risk of a project, which may be nil if it
+// is not known, giving each signal the weight in weights.
+func assess(p *pb.Project, weights map[string]float64) Maintenance {
+	if p == nil {
+		return Maintenance{Risk: 1}
+	}
+	var m Maintenance
+	add := func(signal string, risk float64, detail string) {
+		m.SubScores = append(m.SubScores, SubScore{
+			Signal: signal,
+			Risk:   min(max(risk, 0), 1),
+			Weight: weights[signal],
+			Detail: detail,
+		})
+	}
+	if sc := p.GetScorecard(); sc != nil {
+		for _, c := range sc.GetChecks() {
+			if c.GetName() == "Maintained" && c.GetScore() >= 0 {
+				add(SignalMaintained, float64(10-c.GetScore())/10, fmt.Sprintf("Maintained check %d/10", c.GetScore()))
+			}
+		}
+		add(SignalScorecard, float64(10-sc.GetOverallScore())/10, fmt.Sprintf("overall score %.1f/10", sc.GetOverallScore()))
+	}
+	// Popularity is measured on a log scale, reaching no risk at 10,000
+	// stars and forks.
+	stars, forks := float64(p.GetStarsCount()), float64(p.GetForksCount())
+	add(SignalPopularity, 1-math.Log10(1+stars+forks)/4, fmt.Sprintf("%d stars, %d forks", p.GetStarsCount(), p.GetForksCount()))
+	// A project is allowed ten open issues, and one more for every ten
+	// stars, before they make up half the risk.
+	open := float64(p.GetOpenIssuesCount())
+	add(SignalIssues, open/(open+10+stars/10), fmt.Sprintf("%d open issues", p.GetOpenIssuesCount()))
+
+	var sum, total float64
+	for _, s := range m.SubScores {
+		sum += s.Risk * s.Weight
+		total += s.Weight
+	}
+	if total == 0 {
+		m.Risk = 1
+	} else {
+		m.Risk = sum / total
+	}
+	return m
+}
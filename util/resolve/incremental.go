@@ -0,0 +1,317 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"deps.dev/util/resolve/dep"
+)
+
+// RequirementChange is a change to one of the requirements of the root of
+// a graph, such as the bump of a direct dependency.
+type RequirementChange struct {
+	// Package is the package required.
+	Package PackageKey
+	// Type identifies the requirement among those on the package, and is
+	// the type of the requirement if it is added.
+	Type dep.Type
+	// Version is the new requirement version. If it is empty, the
+	// requirement is removed; if the root has no such requirement, it is
+	// added.
+	Version string
+}
+
+// Incremental resolves graphs and updates them after changes to the
+// requirements of their roots, for tools that try out upgrades
+// interactively.
+//
+// Where the semantics of the system allow, Update patches the previous
+// graph rather than resolving it again. Currently, this is the case for a
+// single npm requirement changed to one matching exactly the same versions.
+// In other cases the graph is resolved again, but the data fetched for the
+// previous resolutions is reused, so only the data of the versions new to
+// the graph is fetched.
+//
+// An Incremental is safe for concurrent use.
+type Incremental struct {
+	client      *memoClient
+	newResolver func(Client) Resolver
+
+	mu       sync.Mutex
+	override map[VersionKey][]RequirementVersion
+}
+
+// NewIncremental returns an Incremental fetching data from the given client
+// and resolving graphs with the Resolver returned by newResolver, such as
+// npm.NewResolver, for the client it is given.
+func NewIncremental(client Client, newResolver func(Client) Resolver) *Incremental {
+	return &Incremental{
+		client: &memoClient{
+			client: client,
+			calls:  make(map[memoKey]*memoCall),
+		},
+		newResolver: newResolver,
+		override:    make(map[VersionKey][]RequirementVersion),
+	}
+}
+
+// Resolve resolves the given version, taking into account the changes
+// previously made to its requirements.
+func (inc *Incremental) Resolve(ctx context.Context, vk VersionKey) (*Graph, error) {
+	return inc.resolve(ctx, vk, inc.overrides(VersionKey{}, nil))
+}
+
+// Requirements returns the requirements of the given version, taking into
+// account the changes previously made to them.
+func (inc *Incremental) Requirements(ctx context.Context, vk VersionKey) ([]RequirementVersion, error) {
+	return inc.client.overlay(inc.overrides(VersionKey{}, nil)).Requirements(ctx, vk)
+}
+
+// Update returns the graph resulting from applying the changes, in order,
+// to the requirements of the root of g, which must have been returned by
+// Resolve or Update. Changes accumulate: each applies to the requirements
+// as left by the previous ones. The given graph is not modified.
+func (inc *Incremental) Update(ctx context.Context, g *Graph, changes ...RequirementChange) (*Graph, error) {
+	return inc.update(ctx, g, changes, true)
+}
+
+// Preview is like Update but does not keep the changes: subsequent calls
+// see the requirements as they were before.
+func (inc *Incremental) Preview(ctx context.Context, g *Graph, changes ...RequirementChange) (*Graph, error) {
+	return inc.update(ctx, g, changes, false)
+}
+
+func (inc *Incremental) update(ctx context.Context, g *Graph, changes []RequirementChange, keep bool) (*Graph, error) {
+	if len(g.Nodes) == 0 {
+		return nil, errors.New("empty graph")
+	}
+	start := time.Now()
+	root := g.Nodes[0].Version
+	reqs, err := inc.Requirements(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	reqs = slices.Clone(reqs)
+	var (
+		old     RequirementVersion
+		changed bool // Whether a single requirement version was changed.
+	)
+	for _, ch := range changes {
+		i := slices.IndexFunc(reqs, func(r RequirementVersion) bool {
+			return r.PackageKey == ch.Package && r.Type.Equal(ch.Type)
+		})
+		switch {
+		case i >= 0 && ch.Version == "":
+			reqs = slices.Delete(reqs, i, i+1)
+		case i >= 0:
+			old = reqs[i]
+			reqs[i].VersionKey.Version = ch.Version
+			changed = true
+		case ch.Version == "":
+			return nil, fmt.Errorf("no requirement on %v to remove", ch.Package)
+		default:
+			reqs = append(reqs, RequirementVersion{
+				VersionKey: VersionKey{PackageKey: ch.Package, VersionType: Requirement, Version: ch.Version},
+				Type:       ch.Type.Clone(),
+			})
+			SortDependencies(reqs)
+		}
+	}
+	override := inc.overrides(root, reqs)
+	if keep {
+		inc.mu.Lock()
+		inc.override = override
+		inc.mu.Unlock()
+	}
+
+	if len(changes) == 1 && changed {
+		ng, err := inc.patch(ctx, g, old, changes[0].Version)
+		if err != nil {
+			return nil, err
+		}
+		if ng != nil {
+			ng.Duration = time.Since(start)
+			return ng, nil
+		}
+	}
+	return inc.resolve(ctx, root, override)
+}
+
+// overrides returns a copy of the current requirement overrides, with
+// those of the given version set to reqs if reqs is not nil.
+func (inc *Incremental) overrides(vk VersionKey, reqs []RequirementVersion) map[VersionKey][]RequirementVersion {
+	inc.mu.Lock()
+	defer inc.mu.Unlock()
+	override := maps.Clone(inc.override)
+	if reqs != nil {
+		override[vk] = reqs
+	}
+	return override
+}
+
+// resolve resolves the given version with a fresh resolver, seeing the
+// given requirements.
+func (inc *Incremental) resolve(ctx context.Context, vk VersionKey, override map[VersionKey][]RequirementVersion) (*Graph, error) {
+	return inc.newResolver(inc.client.overlay(override)).Resolve(ctx, vk)
+}
+
+// patch returns g with the edge of the old requirement of its root changed
+// to the new requirement version, or nil if the graph must be resolved
+// again.
+func (inc *Incremental) patch(ctx context.Context, g *Graph, old RequirementVersion, newVersion string) (*Graph, error) {
+	if old.System != NPM || old.Version == "*" || newVersion == "*" || old.Type.HasAttr(dep.KnownAs) {
+		// Requirements matching anything installed, or aliased, are not
+		// resolved purely from their matching versions.
+		return nil, nil
+	}
+	edge := -1
+	for i, e := range g.Edges {
+		if e.From == 0 && e.Requirement == old.Version && g.Nodes[e.To].Version.PackageKey == old.PackageKey {
+			if edge >= 0 {
+				return nil, nil
+			}
+			edge = i
+		}
+	}
+	if edge < 0 {
+		return nil, nil
+	}
+	oldVersions, err := inc.client.MatchingVersions(ctx, old.VersionKey)
+	if err != nil {
+		return nil, err
+	}
+	nvk := old.VersionKey
+	nvk.Version = newVersion
+	newVersions, err := inc.client.MatchingVersions(ctx, nvk)
+	if err != nil {
+		return nil, err
+	}
+	// The npm resolver chooses among the matching versions; if they are
+	// the same, so is the choice, and the rest of the resolution.
+	if !slices.EqualFunc(oldVersions, newVersions, func(a, b Version) bool { return a.VersionKey == b.VersionKey }) {
+		return nil, nil
+	}
+	ng := &Graph{
+		Nodes: slices.Clone(g.Nodes),
+		Edges: slices.Clone(g.Edges),
+		Error: g.Error,
+		Err:   g.Err,
+	}
+	for i, n := range ng.Nodes {
+		ng.Nodes[i].Errors = slices.Clone(n.Errors)
+	}
+	ng.Edges[edge].Requirement = newVersion
+	return ng, nil
+}
+
+// memoClient is a Client that remembers the results of the calls to the
+// Client it wraps.
+type memoClient struct {
+	client Client
+
+	mu    sync.Mutex
+	calls map[memoKey]*memoCall
+}
+
+type memoKey struct {
+	method string
+	vk     VersionKey
+}
+
+// memoCall is the result of a call. The once guards the call itself.
+// Only successful calls and those returning ErrNotFound are remembered.
+type memoCall struct {
+	once     sync.Once
+	version  Version
+	versions []Version
+	reqs     []RequirementVersion
+	err      error
+}
+
+func (c *memoClient) call(method string, vk VersionKey, fn func(*memoCall)) *memoCall {
+	k := memoKey{method, vk}
+	c.mu.Lock()
+	mc, ok := c.calls[k]
+	if !ok {
+		mc = &memoCall{}
+		c.calls[k] = mc
+	}
+	c.mu.Unlock()
+	mc.once.Do(func() { fn(mc) })
+	if mc.err != nil && !errors.Is(mc.err, ErrNotFound) {
+		// Forget errors that may be transient, such as the cancellation
+		// of the context, so that the call is made again next time.
+		c.mu.Lock()
+		if c.calls[k] == mc {
+			delete(c.calls, k)
+		}
+		c.mu.Unlock()
+	}
+	return mc
+}
+
+func (c *memoClient) Version(ctx context.Context, vk VersionKey) (Version, error) {
+	mc := c.call("Version", vk, func(mc *memoCall) {
+		mc.version, mc.err = c.client.Version(ctx, vk)
+	})
+	return mc.version, mc.err
+}
+
+func (c *memoClient) Versions(ctx context.Context, pk PackageKey) ([]Version, error) {
+	mc := c.call("Versions", VersionKey{PackageKey: pk}, func(mc *memoCall) {
+		mc.versions, mc.err = c.client.Versions(ctx, pk)
+	})
+	return mc.versions, mc.err
+}
+
+func (c *memoClient) Requirements(ctx context.Context, vk VersionKey) ([]RequirementVersion, error) {
+	mc := c.call("Requirements", vk, func(mc *memoCall) {
+		mc.reqs, mc.err = c.client.Requirements(ctx, vk)
+	})
+	return mc.reqs, mc.err
+}
+
+func (c *memoClient) MatchingVersions(ctx context.Context, vk VersionKey) ([]Version, error) {
+	mc := c.call("MatchingVersions", vk, func(mc *memoCall) {
+		mc.versions, mc.err = c.client.MatchingVersions(ctx, vk)
+	})
+	return mc.versions, mc.err
+}
+
+// overlay returns a Client backed by c that returns the given requirements
+// for the versions they are keyed by.
+func (c *memoClient) overlay(override map[VersionKey][]RequirementVersion) Client {
+	return overlayClient{c, override}
+}
+
+// overlayClient is a memoClient with some requirements overridden.
+type overlayClient struct {
+	*memoClient
+	override map[VersionKey][]RequirementVersion
+}
+
+func (c overlayClient) Requirements(ctx context.Context, vk VersionKey) ([]RequirementVersion, error) {
+	if reqs, ok := c.override[vk]; ok {
+		return reqs, nil
+	}
+	return c.memoClient.Requirements(ctx, vk)
+}
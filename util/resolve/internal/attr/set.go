@@ -65,13 +65,17 @@ func (s Set) GetAttr(key uint8) (value string, ok bool) {
 	return
 }
 
-// Clone returns a clone of the given Set.
+// Clone returns a clone of the given Set. The attributes are only
+// allocated if there are any, as most Sets only hold a Mask.
 func (s Set) Clone() Set {
 	c := Set{
 		Mask:     s.Mask,
-		attrs:    make(map[uint8]string, len(s.attrs)),
 		attrBits: s.attrBits,
 	}
+	if len(s.attrs) == 0 {
+		return c
+	}
+	c.attrs = make(map[uint8]string, len(s.attrs))
 	for k, v := range s.attrs {
 		c.attrs[k] = v
 	}
@@ -42,6 +42,7 @@ var (
 		dep.KnownAs,
 		dep.Environment,
 		dep.Selector,
+		dep.RelocatedFrom,
 	}
 	// flagKeys holds the keys that have no value by design.
 	flagKeys = map[dep.AttrKey]bool{
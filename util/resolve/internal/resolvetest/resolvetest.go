@@ -269,9 +269,9 @@ func Parse(r io.Reader, sys resolve.System) (*Artifact, error) {
 }
 
 // newMultiverse gathers content from all the given universes and returns
-// a graph containing all the gathered versions. The registry attribute
-// of each concrete version is set to a comma-separated list of ids of the
-// given universes in which the version was found.
+// a graph containing all the gathered versions. The registries of each
+// concrete version are the ids of the given universes in which the version
+// was found, followed by the registries it already had.
 func newMultiverse(clients map[string]*resolve.LocalClient) (*resolve.LocalClient, error) {
 	type ver struct {
 		v          resolve.Version
@@ -306,12 +306,12 @@ func newMultiverse(clients map[string]*resolve.LocalClient) (*resolve.LocalClien
 		for _, v := range vs {
 			if len(v.registries) > 1 || v.registries[0] != "" {
 				sort.Strings(v.registries)
-				regs := strings.Join(v.registries, ",")
-				a, ok := v.v.GetAttr(version.Registries)
-				if ok {
-					regs += "," + a
+				var regs []version.Registry
+				for _, id := range v.registries {
+					regs = append(regs, version.Registry{Kind: version.RegistryFetch, ID: id})
 				}
-				v.v.SetAttr(version.Registries, regs)
+				regs = append(regs, v.v.Registries()...)
+				v.v.SetRegistries(regs)
 			}
 			cl.AddVersion(v.v, v.imports)
 		}
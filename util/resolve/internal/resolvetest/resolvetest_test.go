@@ -111,10 +111,14 @@ func TestParseData(t *testing.T) {
 	for _, av := range avs {
 		if av.VersionKey == aliceVK {
 			found = true
-			got, _ := av.GetAttr(version.Registries)
-			want := ",a3,dep:bob"
-			if got != want {
-				t.Fatalf("Unexpected attributes, got %q, want %q", got, want)
+			got := av.Registries()
+			want := []version.Registry{
+				{Kind: version.RegistryFetch, ID: ""},
+				{Kind: version.RegistryFetch, ID: "a3"},
+				{Kind: version.RegistryDependency, ID: "bob"},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("Unexpected registries (-want +got):\n%s", diff)
 			}
 		}
 	}
@@ -0,0 +1,159 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package intoto exports resolved dependency graphs as in-toto attestation
+statements, so that the dependencies a build is believed to have can be
+signed and stored alongside its build provenance.
+
+The subject of a statement is the root artifact of the resolution, and its
+predicate is the inventory of the resolved dependencies. The statement
+format is specified at
+https://github.com/in-toto/attestation/blob/main/spec/v1/statement.md
+*/
+package intoto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/purl"
+)
+
+const (
+	// StatementType is the type of in-toto v1 statements.
+	StatementType = "https://in-toto.io/Statement/v1"
+	// PredicateType is the type of the dependency inventory predicate.
+	PredicateType = "https://deps.dev/attestation/dependencies/v0.1"
+)
+
+// Statement is an in-toto v1 statement holding a dependency inventory.
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     Inventory            `json:"predicate"`
+}
+
+// ResourceDescriptor identifies an artifact, as defined by the in-toto
+// attestation framework.
+type ResourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// Inventory is the predicate of a Statement: the dependencies of the
+// subject, as resolved from its requirements.
+type Inventory struct {
+	Root         Dependency   `json:"root"`
+	Dependencies []Dependency `json:"dependencies"`
+	// Error holds the graph-wide resolution error, if any, in which case
+	// the dependencies may be incomplete.
+	Error string `json:"error,omitempty"`
+}
+
+// Dependency is a concrete version present in the resolved graph.
+type Dependency struct {
+	System  string `json:"system"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// URI is the package URL of the version.
+	URI string `json:"uri,omitempty"`
+	// Direct reports whether the version is a direct dependency of the
+	// root.
+	Direct bool `json:"direct,omitempty"`
+	// Errors holds the errors encountered resolving the requirements of
+	// this version.
+	Errors []string `json:"errors,omitempty"`
+	// Provenance holds the provenance of the version, as known from
+	// sources other than the resolution itself.
+	Provenance []Provenance `json:"provenance,omitempty"`
+}
+
+// Provenance describes where a version was built from.
+type Provenance struct {
+	SourceRepository string `json:"sourceRepository,omitempty"`
+	Commit           string `json:"commit,omitempty"`
+	URL              string `json:"url,omitempty"`
+	// Verified reports whether the provenance was cryptographically
+	// verified.
+	Verified bool `json:"verified,omitempty"`
+}
+
+// Options configures the creation of a Statement.
+type Options struct {
+	// Digest holds the digests of the root artifact, keyed by algorithm
+	// name (for example "sha256"). It is required.
+	Digest map[string]string
+	// Provenance, if not nil, is called for each concrete version of the
+	// graph to enrich the inventory with its provenance.
+	Provenance func(resolve.VersionKey) []Provenance
+}
+
+// NewStatement returns a Statement whose subject is the root of the given
+// resolved graph and whose predicate lists every other node of the graph,
+// in node order.
+func NewStatement(g *resolve.Graph, opts Options) (*Statement, error) {
+	if len(g.Nodes) == 0 {
+		return nil, errors.New("empty graph")
+	}
+	if len(opts.Digest) == 0 {
+		return nil, errors.New("missing subject digest")
+	}
+	direct := make(map[resolve.NodeID]bool)
+	for _, e := range g.Edges {
+		if e.From == 0 {
+			direct[e.To] = true
+		}
+	}
+	deps := make([]Dependency, len(g.Nodes))
+	for i, n := range g.Nodes {
+		vk := n.Version
+		if vk.VersionType != resolve.Concrete {
+			return nil, fmt.Errorf("node %d: not a concrete version: %s", i, vk)
+		}
+		d := Dependency{
+			System:  strings.ToLower(vk.System.String()),
+			Name:    vk.Name,
+			Version: vk.Version,
+			URI:     purl.Format(vk.System.String(), vk.Name, vk.Version),
+			Direct:  direct[resolve.NodeID(i)],
+		}
+		for _, ne := range n.Errors {
+			d.Errors = append(d.Errors, fmt.Sprintf("%s@%s: %s", ne.Req.Name, ne.Req.Version, ne.Error))
+		}
+		if opts.Provenance != nil {
+			d.Provenance = opts.Provenance(vk)
+		}
+		deps[i] = d
+	}
+	root := deps[0]
+	return &Statement{
+		Type: StatementType,
+		Subject: []ResourceDescriptor{{
+			Name:   root.Name + "@" + root.Version,
+			URI:    root.URI,
+			Digest: opts.Digest,
+		}},
+		PredicateType: PredicateType,
+		Predicate: Inventory{
+			Root:         root,
+			Dependencies: deps[1:],
+			Error:        g.Error,
+		},
+	}, nil
+}
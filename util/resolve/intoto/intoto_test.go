@@ -0,0 +1,105 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intoto
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/schema"
+)
+
+func TestNewStatement(t *testing.T) {
+	g, err := schema.ParseResolve(`
+@scope/app 1.0.0
+	a: alice@^1.0.0 1.2.0
+		bob@^2.0.0 2.0.1
+	bob@~2.0.0 ERROR: no matching version
+	$a@1.x
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Error = "partial resolution"
+	digest := map[string]string{"sha256": "abc123"}
+	prov := func(vk resolve.VersionKey) []Provenance {
+		if vk.Name != "alice" {
+			return nil
+		}
+		return []Provenance{{
+			SourceRepository: "https://github.com/alice/alice",
+			Commit:           "0123456789abcdef",
+			Verified:         true,
+		}}
+	}
+	got, err := NewStatement(g, Options{Digest: digest, Provenance: prov})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Statement{
+		Type: StatementType,
+		Subject: []ResourceDescriptor{{
+			Name:   "@scope/app@1.0.0",
+			URI:    "pkg:npm/%40scope/app@1.0.0",
+			Digest: digest,
+		}},
+		PredicateType: PredicateType,
+		Predicate: Inventory{
+			Root: Dependency{
+				System:  "npm",
+				Name:    "@scope/app",
+				Version: "1.0.0",
+				URI:     "pkg:npm/%40scope/app@1.0.0",
+				Errors:  []string{"bob@~2.0.0: no matching version"},
+			},
+			Dependencies: []Dependency{{
+				System:  "npm",
+				Name:    "alice",
+				Version: "1.2.0",
+				URI:     "pkg:npm/alice@1.2.0",
+				Direct:  true,
+				Provenance: []Provenance{{
+					SourceRepository: "https://github.com/alice/alice",
+					Commit:           "0123456789abcdef",
+					Verified:         true,
+				}},
+			}, {
+				System:  "npm",
+				Name:    "bob",
+				Version: "2.0.1",
+				URI:     "pkg:npm/bob@2.0.1",
+			}},
+			Error: "partial resolution",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("NewStatement() (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewStatementError(t *testing.T) {
+	g, err := schema.ParseResolve("root 1.0.0", resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStatement(g, Options{}); err == nil {
+		t.Error("NewStatement() without digest: got no error")
+	}
+	if _, err := NewStatement(&resolve.Graph{}, Options{Digest: map[string]string{"sha256": "abc"}}); err == nil {
+		t.Error("NewStatement() of an empty graph: got no error")
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apipb "deps.dev/api/v3"
+	"deps.dev/util/resolve/dep"
+)
+
+// The JSON form of a Graph is meant to be stable, so that graphs can be
+// cached and exchanged between programs. Systems are named as in the
+// deps.dev API, such as "NPM" or "MAVEN", and version types by their names.
+// Structured errors of the types defined in this package are preserved;
+// other structured errors are dropped, leaving only their messages.
+
+type graphJSON struct {
+	Nodes    []nodeJSON  `json:"nodes"`
+	Edges    []edgeJSON  `json:"edges,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Errs     []errorJSON `json:"errs,omitempty"`
+	Duration string      `json:"duration,omitempty"`
+}
+
+type nodeJSON struct {
+	Version     versionKeyJSON  `json:"version"`
+	Attrs       *dep.Type       `json:"attrs,omitempty"`
+	BundledFrom *versionKeyJSON `json:"bundledFrom,omitempty"`
+	Alias       string          `json:"alias,omitempty"`
+	Errors      []nodeErrorJSON `json:"errors,omitempty"`
+	Status      []string        `json:"status,omitempty"`
+}
+
+type nodeErrorJSON struct {
+	Req   versionKeyJSON `json:"req"`
+	Error string         `json:"error"`
+	Err   *errorJSON     `json:"err,omitempty"`
+}
+
+type edgeJSON struct {
+	From        NodeID   `json:"from"`
+	To          NodeID   `json:"to"`
+	Requirement string   `json:"requirement"`
+	Type        dep.Type `json:"type"`
+}
+
+type versionKeyJSON struct {
+	System      string `json:"system"`
+	Name        string `json:"name"`
+	VersionType string `json:"versionType"`
+	Version     string `json:"version"`
+}
+
+// errorJSON is a structured error. Kind is the name of its type, and only
+// the fields of that type are set.
+type errorJSON struct {
+	Kind         string          `json:"kind"`
+	Requirement  *versionKeyJSON `json:"requirement,omitempty"`
+	Requirements []string        `json:"requirements,omitempty"`
+	Version      *versionKeyJSON `json:"version,omitempty"`
+	Conflict     *versionKeyJSON `json:"conflict,omitempty"`
+	Registries   []string        `json:"registries,omitempty"`
+	Message      string          `json:"message,omitempty"`
+	Specifier    string          `json:"specifier,omitempty"`
+	Limit        string          `json:"limit,omitempty"`
+	Max          int             `json:"max,omitempty"`
+}
+
+// MarshalJSON encodes the graph as JSON.
+func (g Graph) MarshalJSON() ([]byte, error) {
+	gj := graphJSON{
+		Nodes: make([]nodeJSON, len(g.Nodes)),
+		Error: g.Error,
+		Errs:  encodeErrors(g.Err),
+	}
+	if g.Duration != 0 {
+		gj.Duration = g.Duration.String()
+	}
+	for i, n := range g.Nodes {
+		gj.Nodes[i] = encodeNode(n)
+	}
+	for _, e := range g.Edges {
+		gj.Edges = append(gj.Edges, encodeEdge(e))
+	}
+	return json.Marshal(gj)
+}
+
+func encodeNode(n Node) nodeJSON {
+	nj := nodeJSON{Version: encodeVersionKey(n.Version), Status: n.Status.Names()}
+	if !n.Attrs.IsRegular() {
+		nj.Attrs = &n.Attrs
+	}
+	if n.Bundled() {
+		bf := encodeVersionKey(n.BundledFrom)
+		nj.BundledFrom = &bf
+		nj.Alias = n.Alias
+	}
+	for _, ne := range n.Errors {
+		nej := nodeErrorJSON{Req: encodeVersionKey(ne.Req), Error: ne.Error}
+		if errs := encodeErrors(ne.Err); len(errs) == 1 {
+			nej.Err = &errs[0]
+		}
+		nj.Errors = append(nj.Errors, nej)
+	}
+	return nj
+}
+
+func encodeEdge(e Edge) edgeJSON {
+	return edgeJSON{
+		From:        e.From,
+		To:          e.To,
+		Requirement: e.Requirement,
+		Type:        e.Type,
+	}
+}
+
+// UnmarshalJSON decodes a graph encoded by MarshalJSON.
+func (g *Graph) UnmarshalJSON(data []byte) error {
+	var gj graphJSON
+	if err := json.Unmarshal(data, &gj); err != nil {
+		return err
+	}
+	ng := Graph{}
+	if err := ng.decodeHeader(gj.Error, gj.Errs, gj.Duration); err != nil {
+		return err
+	}
+	for i, nj := range gj.Nodes {
+		n, err := decodeNode(nj)
+		if err != nil {
+			return fmt.Errorf("node %d: %w", i, err)
+		}
+		ng.Nodes = append(ng.Nodes, n)
+	}
+	for _, ej := range gj.Edges {
+		if err := ng.AddEdge(ej.From, ej.To, ej.Requirement, ej.Type); err != nil {
+			return err
+		}
+	}
+	*g = ng
+	return nil
+}
+
+// decodeHeader sets the error and duration of the graph.
+func (g *Graph) decodeHeader(msg string, ejs []errorJSON, duration string) error {
+	g.Error = msg
+	if duration != "" {
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return fmt.Errorf("graph duration: %w", err)
+		}
+		g.Duration = d
+	}
+	errs := make([]error, len(ejs))
+	for i, ej := range ejs {
+		err, err2 := decodeError(ej)
+		if err2 != nil {
+			return err2
+		}
+		errs[i] = err
+	}
+	switch len(errs) {
+	case 0:
+	case 1:
+		g.Err = errs[0]
+	default:
+		g.Err = errors.Join(errs...)
+	}
+	return nil
+}
+
+func decodeNode(nj nodeJSON) (Node, error) {
+	vk, err := decodeVersionKey(nj.Version)
+	if err != nil {
+		return Node{}, err
+	}
+	n := Node{Version: vk, Status: parseStatus(nj.Status)}
+	if nj.Attrs != nil {
+		n.Attrs = *nj.Attrs
+	}
+	if nj.BundledFrom != nil {
+		if n.BundledFrom, err = decodeVersionKey(*nj.BundledFrom); err != nil {
+			return Node{}, fmt.Errorf("bundled from: %w", err)
+		}
+		n.Alias = nj.Alias
+	}
+	for _, nej := range nj.Errors {
+		req, err := decodeVersionKey(nej.Req)
+		if err != nil {
+			return Node{}, fmt.Errorf("error requirement: %w", err)
+		}
+		ne := NodeError{Req: req, Error: nej.Error}
+		if nej.Err != nil {
+			if ne.Err, err = decodeError(*nej.Err); err != nil {
+				return Node{}, err
+			}
+		}
+		n.Errors = append(n.Errors, ne)
+	}
+	return n, nil
+}
+
+func encodeVersionKey(vk VersionKey) versionKeyJSON {
+	return versionKeyJSON{
+		System:      vk.System.Proto().String(),
+		Name:        vk.Name,
+		VersionType: vk.VersionType.String(),
+		Version:     vk.Version,
+	}
+}
+
+func decodeVersionKey(vj versionKeyJSON) (VersionKey, error) {
+	sys, ok := apipb.System_value[vj.System]
+	if !ok {
+		return VersionKey{}, fmt.Errorf("unknown system %q", vj.System)
+	}
+	vk := VersionKey{
+		PackageKey: PackageKey{System: System(sys), Name: vj.Name},
+		Version:    vj.Version,
+	}
+	switch vj.VersionType {
+	case Concrete.String():
+		vk.VersionType = Concrete
+	case Requirement.String():
+		vk.VersionType = Requirement
+	case UnknownVersionType.String():
+	default:
+		return VersionKey{}, fmt.Errorf("unknown version type %q", vj.VersionType)
+	}
+	return vk, nil
+}
+
+// optionalVersionKey encodes a version key, or returns nil if it is zero.
+func optionalVersionKey(vk VersionKey) *versionKeyJSON {
+	if vk == (VersionKey{}) {
+		return nil
+	}
+	vj := encodeVersionKey(vk)
+	return &vj
+}
+
+func decodeOptionalVersionKey(vj *versionKeyJSON) (VersionKey, error) {
+	if vj == nil {
+		return VersionKey{}, nil
+	}
+	return decodeVersionKey(*vj)
+}
+
+// encodeErrors encodes the structured errors held by err, unwrapping
+// errors joined with errors.Join.
+func encodeErrors(err error) []errorJSON {
+	if err == nil {
+		return nil
+	}
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		var ejs []errorJSON
+		for _, e := range j.Unwrap() {
+			ejs = append(ejs, encodeErrors(e)...)
+		}
+		return ejs
+	}
+	switch e := err.(type) {
+	case *UnsatisfiedRequirement:
+		return []errorJSON{{
+			Kind:         "UnsatisfiedRequirement",
+			Requirement:  optionalVersionKey(e.Requirement),
+			Requirements: e.Requirements,
+		}}
+	case *UnreachableRegistry:
+		return []errorJSON{{
+			Kind:        "UnreachableRegistry",
+			Requirement: optionalVersionKey(e.Requirement),
+			Version:     optionalVersionKey(e.Version),
+			Registries:  e.Registries,
+		}}
+	case *ResolutionImpossible:
+		return []errorJSON{{
+			Kind:        "ResolutionImpossible",
+			Requirement: optionalVersionKey(e.Requirement),
+			Version:     optionalVersionKey(e.Version),
+			Conflict:    optionalVersionKey(e.Conflict),
+			Message:     e.Message,
+		}}
+	case *UnsupportedRequirement:
+		return []errorJSON{{
+			Kind:        "UnsupportedRequirement",
+			Requirement: optionalVersionKey(e.Requirement),
+			Specifier:   e.Kind,
+		}}
+	case *LimitError:
+		return []errorJSON{{
+			Kind:  "LimitError",
+			Limit: e.Limit.String(),
+			Max:   e.Max,
+		}}
+	}
+	return nil
+}
+
+func decodeError(ej errorJSON) (error, error) {
+	req, err := decodeOptionalVersionKey(ej.Requirement)
+	if err != nil {
+		return nil, err
+	}
+	ver, err := decodeOptionalVersionKey(ej.Version)
+	if err != nil {
+		return nil, err
+	}
+	conflict, err := decodeOptionalVersionKey(ej.Conflict)
+	if err != nil {
+		return nil, err
+	}
+	switch ej.Kind {
+	case "UnsatisfiedRequirement":
+		return &UnsatisfiedRequirement{Requirement: req, Requirements: ej.Requirements}, nil
+	case "UnreachableRegistry":
+		return &UnreachableRegistry{Requirement: req, Version: ver, Registries: ej.Registries}, nil
+	case "ResolutionImpossible":
+		return &ResolutionImpossible{Requirement: req, Version: ver, Conflict: conflict, Message: ej.Message}, nil
+	case "UnsupportedRequirement":
+		return &UnsupportedRequirement{Requirement: req, Kind: ej.Specifier}, nil
+	case "LimitError":
+		for _, l := range []Limit{NodeLimit, EdgeLimit, DepthLimit} {
+			if l.String() == ej.Limit {
+				return &LimitError{Limit: l, Max: ej.Max}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown limit %q", ej.Limit)
+	}
+	return nil, fmt.Errorf("unknown error kind %q", ej.Kind)
+}
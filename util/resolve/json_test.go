@@ -0,0 +1,119 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve/dep"
+)
+
+func TestGraphJSON(t *testing.T) {
+	concrete := func(name, version string) VersionKey {
+		return VersionKey{PackageKey: PackageKey{System: NPM, Name: name}, VersionType: Concrete, Version: version}
+	}
+	requirement := func(name, version string) VersionKey {
+		return VersionKey{PackageKey: PackageKey{System: NPM, Name: name}, VersionType: Requirement, Version: version}
+	}
+	var g Graph
+	alice := g.AddNode(concrete("alice", "1.0.0"))
+	bob := g.AddNode(concrete("bob", "1.0.0"))
+	chuck := g.AddNode(concrete("chuck", "2.0.0"))
+	dev := dep.NewType(dep.Dev)
+	dev.AddAttr(dep.KnownAs, "robert")
+	for _, e := range []struct {
+		from, to NodeID
+		req      string
+		typ      dep.Type
+	}{
+		{alice, bob, "^1.0.0", dev},
+		{alice, chuck, "^2.0.0", dep.NewType(dep.Selector)},
+		{bob, chuck, "2.0.0", dep.Type{}},
+	} {
+		if err := g.AddEdge(e.from, e.to, e.req, e.typ); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.AddNodeError(bob, requirement("dave", "^3.0.0"), &UnsatisfiedRequirement{Requirement: requirement("dave", "^3.0.0")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddError(chuck, requirement("eve", "1.0.0"), "no structured form"); err != nil {
+		t.Fatal(err)
+	}
+	g.Nodes[chuck].Status = StatusBlocked | StatusDeleted
+	g.Nodes[chuck].Attrs.AddAttr(dep.MavenClassifier, "tests")
+	bundled := g.AddNode(concrete("alice>1.0.0>robert", "1.0.0"))
+	g.Nodes[bundled].BundledFrom = concrete("bob", "1.0.0")
+	g.Nodes[bundled].Alias = "robert"
+	if err := g.AddEdge(alice, bundled, "1.0.0", dep.Type{}); err != nil {
+		t.Fatal(err)
+	}
+	g.Error = "graph exceeds the limit of 3 nodes"
+	g.Err = &LimitError{Limit: NodeLimit, Max: 3}
+	g.Duration = 1500 * time.Millisecond
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"system":"NPM"`, `"versionType":"Concrete"`, `"Dev":""`, `"KnownAs":"robert"`, `"kind":"LimitError"`, `"duration":"1.5s"`, `"status":["blocked","deleted"]`, `"attrs":{"MavenClassifier":"tests"}`, `"alias":"robert"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("encoded graph does not contain %s:\n%s", s, data)
+		}
+	}
+	var got Graph
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(g, got); diff != "" {
+		t.Errorf("round trip (-want +got):\n%s", diff)
+	}
+
+	// Joined structured errors, as set by the Maven resolver, are kept.
+	g.Err = errors.Join(&UnreachableRegistry{}, &ResolutionImpossible{Version: concrete("bob", "1.0.0"), Message: "unused"},
+		&UnsupportedRequirement{Requirement: VersionKey{PackageKey: PackageKey{System: NPM, Name: "chuck"}, VersionType: Requirement, Version: "file:../chuck"}, Kind: "file"})
+	data, err = json.Marshal(&g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		ur *UnreachableRegistry
+		ri *ResolutionImpossible
+		us *UnsupportedRequirement
+	)
+	if !errors.As(got.Err, &ur) || !errors.As(got.Err, &ri) || ri.Version != concrete("bob", "1.0.0") ||
+		!errors.As(got.Err, &us) || us.Kind != "file" || us.Requirement.Version != "file:../chuck" {
+		t.Errorf("round trip of joined errors: got %v", got.Err)
+	}
+
+	for _, bad := range []string{
+		`{"nodes": [{"version": {"system": "COBOL", "versionType": "Concrete"}}]}`,
+		`{"nodes": [], "edges": [{"from": 0, "to": 1}]}`,
+		`{"nodes": [], "errs": [{"kind": "Unknown"}]}`,
+	} {
+		if err := json.Unmarshal([]byte(bad), &got); err == nil {
+			t.Errorf("Unmarshal(%s): got no error", bad)
+		}
+	}
+}
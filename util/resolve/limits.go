@@ -0,0 +1,125 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import "fmt"
+
+// Limits bounds the size of a Graph, protecting callers from pathological
+// inputs such as deeply self-referential packages. A zero value for any of
+// the fields means that dimension is not limited.
+type Limits struct {
+	// MaxNodes is the maximum number of nodes in the graph, including the
+	// root.
+	MaxNodes int
+	// MaxEdges is the maximum number of edges in the graph.
+	MaxEdges int
+	// MaxDepth is the maximum distance, in edges, between the root and any
+	// other node, following the shortest path.
+	MaxDepth int
+}
+
+// Limit identifies one of the dimensions bounded by Limits.
+type Limit byte
+
+const (
+	NodeLimit Limit = iota + 1
+	EdgeLimit
+	DepthLimit
+)
+
+func (l Limit) String() string {
+	switch l {
+	case NodeLimit:
+		return "nodes"
+	case EdgeLimit:
+		return "edges"
+	case DepthLimit:
+		return "depth"
+	}
+	return fmt.Sprintf("Limit(%d)", byte(l))
+}
+
+// LimitError reports that a Graph exceeded one of its Limits. Resolvers
+// return it alongside the partial Graph built until the limit was reached.
+type LimitError struct {
+	Limit Limit
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("graph exceeds the limit of %d %s", e.Max, e.Limit)
+}
+
+// CheckNode returns a *LimitError if adding a node at the given depth to the
+// graph would exceed the limits.
+func (l Limits) CheckNode(g *Graph, depth int) error {
+	if l.MaxNodes > 0 && len(g.Nodes) >= l.MaxNodes {
+		return &LimitError{Limit: NodeLimit, Max: l.MaxNodes}
+	}
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return &LimitError{Limit: DepthLimit, Max: l.MaxDepth}
+	}
+	return nil
+}
+
+// CheckEdge returns a *LimitError if adding an edge to the graph would exceed
+// the limits.
+func (l Limits) CheckEdge(g *Graph) error {
+	if l.MaxEdges > 0 && len(g.Edges) >= l.MaxEdges {
+		return &LimitError{Limit: EdgeLimit, Max: l.MaxEdges}
+	}
+	return nil
+}
+
+// Check returns a *LimitError if the graph exceeds the limits. It is meant
+// for graphs that have been built or loaded by other means than a Resolver
+// configured with the same limits.
+func (l Limits) Check(g *Graph) error {
+	if l.MaxNodes > 0 && len(g.Nodes) > l.MaxNodes {
+		return &LimitError{Limit: NodeLimit, Max: l.MaxNodes}
+	}
+	if l.MaxEdges > 0 && len(g.Edges) > l.MaxEdges {
+		return &LimitError{Limit: EdgeLimit, Max: l.MaxEdges}
+	}
+	if l.MaxDepth <= 0 || len(g.Nodes) == 0 {
+		return nil
+	}
+	// Compute the depth of every node with a BFS from the root.
+	edges := make([][]NodeID, len(g.Nodes))
+	for _, e := range g.Edges {
+		edges[e.From] = append(edges[e.From], e.To)
+	}
+	depth := make([]int, len(g.Nodes))
+	for i := range depth {
+		depth[i] = -1
+	}
+	depth[0] = 0
+	queue := []NodeID{0}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, to := range edges[n] {
+			if depth[to] >= 0 {
+				continue
+			}
+			depth[to] = depth[n] + 1
+			if depth[to] > l.MaxDepth {
+				return &LimitError{Limit: DepthLimit, Max: l.MaxDepth}
+			}
+			queue = append(queue, to)
+		}
+	}
+	return nil
+}
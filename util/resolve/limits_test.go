@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"errors"
+	"testing"
+
+	"deps.dev/util/resolve/dep"
+)
+
+func TestLimitsCheck(t *testing.T) {
+	// Build a chain a -> b -> c -> d, with an extra edge a -> d.
+	g := &Graph{}
+	var ids []NodeID
+	for _, name := range []string{"a", "b", "c", "d"} {
+		ids = append(ids, g.AddNode(VersionKey{
+			PackageKey:  PackageKey{System: NPM, Name: name},
+			VersionType: Concrete,
+			Version:     "1.0.0",
+		}))
+	}
+	for i := 1; i < len(ids); i++ {
+		if err := g.AddEdge(ids[i-1], ids[i], "^1.0.0", dep.NewType()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.AddEdge(ids[0], ids[3], "^1.0.0", dep.NewType()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		limits Limits
+		want   Limit // zero means no error
+	}{
+		{Limits{}, 0},
+		{Limits{MaxNodes: 4, MaxEdges: 4, MaxDepth: 2}, 0},
+		{Limits{MaxNodes: 3}, NodeLimit},
+		{Limits{MaxEdges: 3}, EdgeLimit},
+		{Limits{MaxDepth: 1}, DepthLimit},
+	} {
+		err := c.limits.Check(g)
+		if c.want == 0 {
+			if err != nil {
+				t.Errorf("%+v.Check: %v", c.limits, err)
+			}
+			continue
+		}
+		var le *LimitError
+		if !errors.As(err, &le) {
+			t.Errorf("%+v.Check: got %v, want *LimitError", c.limits, err)
+			continue
+		}
+		if le.Limit != c.want {
+			t.Errorf("%+v.Check: got %v limit, want %v", c.limits, le.Limit, c.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"slices"
+)
+
+// ManifestName is the package name of the root returned by ManifestRoot.
+// It is not a valid name in any system, so that it cannot be mistaken for
+// a published package.
+const ManifestName = "<manifest>"
+
+// ManifestRoot returns the version key of the root of a resolution of
+// requirements that are not those of a published version, such as those
+// read from a local package.json or pom.xml.
+func ManifestRoot(sys System) VersionKey {
+	return VersionKey{
+		PackageKey:  PackageKey{System: sys, Name: ManifestName},
+		VersionType: Concrete,
+	}
+}
+
+// RequirementsResolver is implemented by Resolvers that can resolve a set
+// of requirements directly, rather than those of a published version.
+type RequirementsResolver interface {
+	// ResolveRequirements resolves the given requirements of the given
+	// system. They are the requirements of the root of the graph, whose
+	// version key is ManifestRoot(sys).
+	ResolveRequirements(ctx context.Context, sys System, reqs []RequirementVersion) (*Graph, error)
+}
+
+// RootClient returns a Client serving the given root version, with the
+// given requirements, in addition to the versions of c. The root is the
+// only version of its package.
+func RootClient(c Client, root Version, reqs []RequirementVersion) Client {
+	return rootClient{Client: c, root: root, reqs: slices.Clone(reqs)}
+}
+
+type rootClient struct {
+	Client
+	root Version
+	reqs []RequirementVersion
+}
+
+func (c rootClient) Version(ctx context.Context, vk VersionKey) (Version, error) {
+	if vk == c.root.VersionKey {
+		return c.root, nil
+	}
+	return c.Client.Version(ctx, vk)
+}
+
+func (c rootClient) Versions(ctx context.Context, pk PackageKey) ([]Version, error) {
+	if pk == c.root.PackageKey {
+		return []Version{c.root}, nil
+	}
+	return c.Client.Versions(ctx, pk)
+}
+
+func (c rootClient) Requirements(ctx context.Context, vk VersionKey) ([]RequirementVersion, error) {
+	if vk == c.root.VersionKey {
+		return slices.Clone(c.reqs), nil
+	}
+	return c.Client.Requirements(ctx, vk)
+}
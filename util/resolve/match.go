@@ -52,13 +52,15 @@ func SortVersions(vs []Version) {
 }
 
 func sortNPMVersions(vs []Version) {
-	vers := make(map[VersionKey]*semver.Version)
-	for _, v := range vs {
-		ver, err := semver.NPM.Parse(v.Version)
-		if err != nil {
+	// The versions are parsed into a single slice, rather than
+	// allocated one by one.
+	parsed := make([]semver.Version, len(vs))
+	vers := make(map[VersionKey]*semver.Version, len(vs))
+	for i, v := range vs {
+		if err := semver.NPM.ParseInto(&parsed[i], v.Version); err != nil {
 			continue
 		}
-		vers[v.VersionKey] = ver
+		vers[v.VersionKey] = &parsed[i]
 	}
 	sort.Slice(vs, func(i, j int) bool {
 		a, b := vs[i], vs[j]
@@ -158,10 +160,42 @@ func MatchRequirement(req VersionKey, versions []Version) []Version {
 	}
 }
 
+// Match returns the versions from the list that satisfy the requirement in
+// the given system, in ascending order. It applies the same system-specific
+// rules as MatchRequirement, and so the resolvers, including those deciding
+// when prereleases match: an npm range such as ^1.0.0 does not match
+// 1.1.0-beta.1, but a Maven soft requirement such as 1.0 matches every
+// version. A requirement that is not a valid constraint matches only the
+// version spelled the same way. Without attributes, npm tags such as
+// latest match nothing; use MatchRequirement for those.
+func Match(sys System, requirement string, versions []string) []string {
+	pk := PackageKey{System: sys}
+	vs := make([]Version, len(versions))
+	for i, v := range versions {
+		vs[i] = Version{VersionKey: VersionKey{
+			PackageKey:  pk,
+			VersionType: Concrete,
+			Version:     v,
+		}}
+	}
+	req := VersionKey{
+		PackageKey:  pk,
+		VersionType: Requirement,
+		Version:     requirement,
+	}
+	matches := MatchRequirement(req, vs)
+	SortVersions(matches)
+	out := make([]string, len(matches))
+	for i, v := range matches {
+		out[i] = v.Version
+	}
+	return out
+}
+
 // matchNPMRequirement matches npm requirements.
 func matchNPMRequirement(req VersionKey, vers []Version) []Version {
 	sortNPMVersions(vers)
-	constraint, err := req.System.Semver().ParseConstraint(req.Version)
+	constraint, err := req.System.Semver().ParseConstraintCached(req.Version)
 	if err != nil {
 		// Look for an exact string match, either on the version string
 		// or in the tags.
@@ -190,7 +224,7 @@ func matchNPMRequirement(req VersionKey, vers []Version) []Version {
 // matchRequirement is a default implementation of MatchRequirement, appropriate
 // for many systems.
 func matchRequirement(req VersionKey, versions []Version) []Version {
-	constraint, err := req.System.Semver().ParseConstraint(req.Version)
+	constraint, err := req.System.Semver().ParseConstraintCached(req.Version)
 	if err != nil {
 		// Fall back to string matching.
 		constraint = nil
@@ -124,6 +124,47 @@ func TestSortNPMDependencies(t *testing.T) {
 
 }
 
+func TestMatch(t *testing.T) {
+	semverVersions := []string{"2.0.0", "1.0.0", "1.1.0-beta.1", "1.1.0", "0.9.0"}
+	goVersions := []string{"v2.0.0+incompatible", "v1.0.0", "v1.1.0-beta.1", "v1.1.0", "v0.9.0"}
+	for _, c := range []struct {
+		sys         System
+		requirement string
+		versions    []string
+		want        []string
+	}{
+		{NPM, "^1.0.0", semverVersions, []string{"1.0.0", "1.1.0"}},
+		{NPM, "^1.1.0-beta.0", semverVersions, []string{"1.1.0-beta.1", "1.1.0"}},
+		{NPM, "*", semverVersions, []string{"0.9.0", "1.0.0", "1.1.0", "2.0.0"}},
+		{NPM, "", semverVersions, []string{"0.9.0", "1.0.0", "1.1.0", "2.0.0"}},
+		{NPM, ">=3.0.0", semverVersions, []string{}},
+		{NPM, "latest", semverVersions, []string{}},
+		// Cargo's default operator is ^.
+		{Cargo, "1.0.0", semverVersions, []string{"1.0.0", "1.1.0"}},
+		{Cargo, "=1.0.0", semverVersions, []string{"1.0.0"}},
+		// Maven ranges include prereleases, and soft requirements
+		// match everything.
+		{Maven, "[1.0,2.0)", semverVersions, []string{"1.0.0", "1.1.0-beta.1", "1.1.0"}},
+		{Maven, "1.0", semverVersions, []string{"0.9.0", "1.0.0", "1.1.0-beta.1", "1.1.0", "2.0.0"}},
+		// A bare NuGet version is a minimum, including prereleases.
+		{NuGet, "1.0.0", semverVersions, []string{"1.0.0", "1.1.0-beta.1", "1.1.0", "2.0.0"}},
+		{NuGet, "[1.0.0,2.0.0)", semverVersions, []string{"1.0.0", "1.1.0"}},
+		{Go, "v1.0.0", goVersions, []string{"v1.0.0", "v1.1.0"}},
+		// Invalid constraints match by string.
+		{NPM, "file:../a", []string{"1.0.0", "file:../a"}, []string{"file:../a"}},
+		{Maven, "[1.0", []string{"1.0", "[1.0"}, []string{"[1.0"}},
+	} {
+		versions := append([]string(nil), c.versions...)
+		got := Match(c.sys, c.requirement, versions)
+		if diff := cmp.Diff(c.want, got); diff != "" {
+			t.Errorf("Match(%v, %q):\n(-want, +got):\n%s", c.sys, c.requirement, diff)
+		}
+		if diff := cmp.Diff(c.versions, versions); diff != "" {
+			t.Errorf("Match(%v, %q) modified its argument:\n(-want, +got):\n%s", c.sys, c.requirement, diff)
+		}
+	}
+}
+
 func TestMatchNPMRequirement(t *testing.T) {
 	vk := func(v string) VersionKey {
 		return VersionKey{
@@ -91,6 +91,20 @@ func MavenDepTypeToDependency(typ dep.Type) (maven.Dependency, string, error) {
 	return result, "", nil
 }
 
+// MavenRedirect returns the value of the version.Redirect attribute
+// recording the relocation of the given project, if it declares one, as
+// groupId:artifactId:version. The Maven resolver follows these relocations.
+// Clients that build Maven versions from pom.xml files should set the
+// attribute of each version with it; APIClient cannot, as the deps.dev API
+// does not report relocations.
+func MavenRedirect(p maven.Project) (string, bool) {
+	pk, ok := p.RelocatedTo()
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%s", pk.Name(), pk.Version), true
+}
+
 const MaxMavenParent = 100
 
 func (a *APIClient) fetchMavenParents(ctx context.Context, current maven.ProjectKey, project *maven.Project) error {
@@ -107,7 +121,7 @@ func (a *APIClient) fetchMavenParents(ctx context.Context, current maven.Project
 
 		resp, err := a.c.GetRequirements(ctx, &pb.GetRequirementsRequest{
 			VersionKey: &pb.VersionKey{
-				System:  pb.System(Maven),
+				System:  Maven.Proto(),
 				Name:    current.Name(),
 				Version: string(current.Version),
 			},
@@ -131,15 +145,10 @@ func (a *APIClient) fetchMavenParents(ctx context.Context, current maven.Project
 }
 
 func (a *APIClient) mavenRequirements(ctx context.Context, vk VersionKey, reqs *pb.Requirements_Maven) ([]RequirementVersion, error) {
-	projKey, err := maven.MakeProjectKey(vk.Name, vk.Version)
+	project, err := mavenProject(vk, reqs)
 	if err != nil {
 		return nil, err
 	}
-	project := mavenRequirementsToProject(projKey, reqs)
-	// Only merge default profiles by passing empty JDK and OS information.
-	if err := project.MergeProfiles("", maven.ActivationOS{}); err != nil {
-		return nil, err
-	}
 	if err := a.fetchMavenParents(ctx, project.Parent.ProjectKey, &project); err != nil {
 		return nil, err
 	}
@@ -155,7 +164,27 @@ func (a *APIClient) mavenRequirements(ctx context.Context, vk VersionKey, reqs *
 		}
 		return result.DependencyManagement, nil
 	})
+	return mavenProjectRequirements(project), nil
+}
+
+// mavenProject returns the project of the Maven version vk with its
+// default profiles merged.
+func mavenProject(vk VersionKey, reqs *pb.Requirements_Maven) (maven.Project, error) {
+	projKey, err := maven.MakeProjectKey(vk.Name, vk.Version)
+	if err != nil {
+		return maven.Project{}, err
+	}
+	project := mavenRequirementsToProject(projKey, reqs)
+	// Only merge default profiles by passing empty JDK and OS information.
+	if err := project.MergeProfiles("", maven.ActivationOS{}); err != nil {
+		return maven.Project{}, err
+	}
+	return project, nil
+}
 
+// mavenProjectRequirements returns the dependencies of a processed Maven
+// project as requirements.
+func mavenProjectRequirements(project maven.Project) []RequirementVersion {
 	var result []RequirementVersion
 	for _, d := range project.Dependencies {
 		result = append(result, RequirementVersion{
@@ -170,7 +199,7 @@ func (a *APIClient) mavenRequirements(ctx context.Context, vk VersionKey, reqs *
 			Type: MavenDepType(d, ""),
 		})
 	}
-	return result, nil
+	return result
 }
 
 func mavenRequirementsToProject(pk maven.ProjectKey, req *pb.Requirements_Maven) maven.Project {
@@ -287,3 +316,43 @@ func mavenRequirementsToProject(pk maven.ProjectKey, req *pb.Requirements_Maven)
 		Profiles:             profiles,
 	}
 }
+
+// MavenRegistries returns the registry configuration described by the given
+// Maven settings, for the given JDK and OS activating its profiles. The
+// repositories of the active profiles are reachable from every version, and
+// the mirrors of the settings serve the repositories they match, including
+// Maven Central. Registries are identified by their URL.
+func MavenRegistries(s *maven.Settings, jdk string, os maven.ActivationOS) (RegistryConfig, error) {
+	active, err := s.Active(jdk, os)
+	if err != nil {
+		return RegistryConfig{}, err
+	}
+	var c RegistryConfig
+	// ids maps the URLs of the known repositories to their ID, used to
+	// match the mirrors.
+	ids := map[string]maven.String{
+		strings.TrimSuffix(maven.MavenCentral, "/"): maven.CentralID,
+	}
+	for _, prof := range active {
+		for _, repo := range prof.Repositories {
+			u := strings.TrimSuffix(string(repo.URL), "/")
+			if u == "" {
+				continue
+			}
+			if _, ok := ids[u]; !ok {
+				ids[u] = repo.ID
+			}
+			c.Extra = append(c.Extra, string(repo.URL))
+		}
+	}
+	if len(s.Mirrors) > 0 {
+		c.Mirror = func(registry string) string {
+			u := strings.TrimSuffix(registry, "/")
+			if m, ok := s.Mirror(maven.Repository{ID: ids[u], URL: maven.String(registry)}); ok {
+				return strings.TrimSuffix(string(m.URL), "/")
+			}
+			return u
+		}
+	}
+	return c, nil
+}
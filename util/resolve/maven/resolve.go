@@ -22,32 +22,37 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/url"
 	"reflect"
 	"slices"
 	"strings"
 	"time"
 
+	mavenpkg "deps.dev/util/maven"
 	"deps.dev/util/resolve"
 	"deps.dev/util/resolve/dep"
 	versionpkg "deps.dev/util/resolve/version"
 	"deps.dev/util/semver"
 )
 
-const (
-	debug = false
-)
-
 // resolver implements resolve.Resolver for Maven.
 type resolver struct {
 	client resolve.Client
+	opts   resolve.Options
 }
 
 // NewResolver creates a Maven Resolver connected to the given client.
-func NewResolver(client resolve.Client) resolve.Resolver {
+func NewResolver(client resolve.Client, opts ...resolve.Option) resolve.Resolver {
+	o := resolve.NewOptions(opts...)
+	if !o.AsOf.IsZero() {
+		client = resolve.AsOfClient(client, o.AsOf)
+	}
+	if o.Tracer != nil {
+		client = resolve.TraceClient(client, o.Tracer)
+	}
 	return &resolver{
 		client: client,
+		opts:   o,
 	}
 }
 
@@ -66,6 +71,9 @@ type version struct {
 	exclusions map[string]bool
 	// repositories holds the repositories where to look for dependencies.
 	repositories []string
+	// depth holds the distance between the root and the version in the
+	// resolved graph.
+	depth int
 }
 
 // dependency represents a Maven dependency that adds exclusions to a resolve
@@ -87,6 +95,19 @@ type packageKey struct {
 	typ string
 }
 
+// attrs returns the attributes of the artifact the package key stands for,
+// as recorded on its graph nodes.
+func (pk packageKey) attrs() dep.Type {
+	var t dep.Type
+	if pk.classifier != "" {
+		t.AddAttr(dep.MavenClassifier, pk.classifier)
+	}
+	if pk.typ != "" {
+		t.AddAttr(dep.MavenArtifactType, pk.typ)
+	}
+	return t
+}
+
 // versionKey represents a unique key for the resolver. In Maven, only
 // one version of a given packageKey can be installed.
 type versionKey struct {
@@ -96,10 +117,39 @@ type versionKey struct {
 
 var errIncompatible = errors.New("incompatible requirements")
 
-// TODO: a user may set the default registry outside pom.xml, so we should
-// allow injecting the registry configuration.
+// ResolveRequirements resolves the given requirements, such as those of a
+// local pom.xml, as the direct dependencies of resolve.ManifestRoot. It
+// implements resolve.RequirementsResolver.
+func (r *resolver) ResolveRequirements(ctx context.Context, sys resolve.System, reqs []resolve.RequirementVersion) (*resolve.Graph, error) {
+	if sys != resolve.Maven {
+		return nil, fmt.Errorf("expected %s system, got %s", resolve.Maven, sys)
+	}
+	root := resolve.Version{VersionKey: resolve.ManifestRoot(sys)}
+	rr := &resolver{client: resolve.RootClient(r.client, root, reqs), opts: r.opts}
+	return rr.Resolve(ctx, root.VersionKey)
+}
+
+// Resolve resolves the transitive dependencies of the given Maven concrete
+// version. If the resolution reaches one of the configured limits, it stops
+// and returns the partial graph along with a *resolve.LimitError.
+//
+// The registries configured with resolve.WithRegistries, such as those of
+// a settings.xml, are reachable in addition to those declared in pom.xml.
+//
+// Dependencies on relocated versions, whose Redirect attribute names the
+// groupId:artifactId:version they were moved to, resolve to the versions
+// the relocations lead to, and their edges name the relocated version in
+// the RelocatedFrom attribute. The attribute must be supplied by the
+// client, which may set it from the pom.xml of each version with
+// resolve.MavenRedirect: the deps.dev API does not report relocations, so
+// they are not followed with a resolve.APIClient.
 func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve.Graph, error) {
 	start := time.Now()
+	if d := r.opts.MaxDuration; d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
 	const maxRetries = 100
 	// requirements holds all requirements that we encounter during the
 	// resolution.
@@ -117,6 +167,11 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
+		r.opts.Trace(resolve.Event{
+			Kind:    resolve.BacktrackEvent,
+			Version: vk,
+			Message: fmt.Sprintf("restarting the resolution (attempt %d)", i+2),
+		})
 		// The requirements map has been mutated with the additional
 		// requirements, retry the resolution with the new set to see if
 		// this will yield a compatible version for all (or if more
@@ -130,7 +185,8 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 	// Resolve allowing multiple registries.
 	gm, _, err := r.resolve(ctx, vk, requirements, true)
 	if err != nil {
-		return nil, err
+		// The graph is only set when a limit was reached.
+		return gm, err
 	}
 	// Reset duration for comparison.
 	g.Duration, gm.Duration = 0, 0
@@ -138,12 +194,13 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 		return nil, err
 	} else if !equal {
 		// TODO: record a warning instead of an error.
-		e := "multi-registry resolution differ: missing repository configuration"
+		e := &resolve.UnreachableRegistry{}
 		if g.Error == "" {
-			g.Error = e
+			g.Error = e.Error()
 		} else {
-			g.Error += "; " + e
+			g.Error += "; " + e.Error()
 		}
+		g.Err = errors.Join(g.Err, e)
 	}
 	g.Duration = time.Since(start)
 	return g, nil
@@ -180,34 +237,45 @@ func (r *resolver) resolve(ctx context.Context, vk resolve.VersionKey, requireme
 		},
 	}
 
+	regs := r.opts.Registries
 	defaultRegistry, fetchRepos, depRepos := parseRegistries(ver.AttrSet)
-	if len(depRepos) > 0 {
-		v.repositories = append([]string(nil), depRepos...)
-		v.repositories = append(v.repositories, fetchRepos...)
-	} else {
-		v.repositories = fetchRepos
+	if defaultRegistry == "" {
+		defaultRegistry = regs.Default
 	}
+	v.repositories = append([]string(nil), depRepos...)
+	v.repositories = append(v.repositories, fetchRepos...)
+	v.repositories = append(v.repositories, regs.Extra...)
 	todo := []version{v}
 
 	resolvedPackages := map[packageKey]bool{todo[0].packageKey: true}
 	concreteVersions := map[versionKey]resolve.NodeID{todo[0].versionKey: 0}
-	// nodes ensure that there is only one resolve node per version key,
-	// regardless of the dependency type that yields to that resolution.
-	nodes := map[resolve.VersionKey]resolve.NodeID{vk: 0}
+	// nodes ensure that there is only one resolve node per artifact of a
+	// version, regardless of the dependency type that yields to that
+	// resolution.
+	nodes := map[versionKey]resolve.NodeID{v.versionKey: 0}
 	mgt, err := r.dependencyManagement(ctx, ver.VersionKey)
 	if err != nil {
 		return nil, false, fmt.Errorf("cannot get dependency management: %w", err)
 	}
 
+	limits := r.opts.Limits
+	// partial returns the graph built so far when a limit is reached.
+	partial := func(err error) (*resolve.Graph, bool, error) {
+		g.Error = err.Error()
+		g.Err = err
+		g.Duration = time.Since(start)
+		return g, false, err
+	}
+
 	for first := true; len(todo) > 0; first = false {
 		var cur version
 		// This is a BFS, Maven takes the "nearest" definition.
 		// https://maven.apache.org/guides/introduction/introduction-to-dependency-mechanism.html#transitive-dependencies
 		cur, todo = todo[0], todo[1:]
-
-		if debug {
-			log.Printf("cur: %s", cur.VersionKey)
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
 		}
+
 		if cur.includesDependencies {
 			continue
 		}
@@ -229,16 +297,9 @@ func (r *resolver) resolve(ctx context.Context, vk resolve.VersionKey, requireme
 		}
 
 		for _, d := range imps {
-			if debug {
-				log.Printf("dep: %s %s", d.VersionKey, d.Type)
-			}
-
 			if isExcluded, err := r.isExcluded(cur.exclusions, d.VersionKey); err != nil {
 				return nil, false, err
 			} else if isExcluded {
-				if debug {
-					log.Printf("dep excluded: %s %s", d.VersionKey, d.Type)
-				}
 				continue
 			}
 
@@ -260,18 +321,79 @@ func (r *resolver) resolve(ctx context.Context, vk resolve.VersionKey, requireme
 					reqs[i] = req.Version
 				}
 				slices.Sort(reqs)
-				g.AddError(concreteVersions[cur.versionKey], d.VersionKey, fmt.Sprintf("could not find a version that satisfies requirements %s for package %s", reqs, d.Name))
+				e := &resolve.UnsatisfiedRequirement{Requirement: d.VersionKey, Requirements: reqs}
+				msg := e.Error()
+				g.AddNodeError(concreteVersions[cur.versionKey], d.VersionKey, e)
+				r.opts.Trace(resolve.Event{
+					Kind:        resolve.ConflictEvent,
+					From:        cur.VersionKey,
+					Requirement: d.VersionKey,
+					Message:     msg,
+				})
 				continue
 			} else if err != nil {
 				return nil, false, err
 			}
 
+			// A relocated version stands for the one it was moved to,
+			// which the edge records it was relocated from.
+			typ := d.Type
+			if match.HasAttr(versionpkg.Redirect) {
+				moved, err := r.relocate(ctx, match)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil, false, ctx.Err()
+					}
+					g.AddNodeError(concreteVersions[cur.versionKey], d.VersionKey, err)
+					continue
+				}
+				if moved.VersionKey != match.VersionKey {
+					typ = d.Type.Clone()
+					typ.AddAttr(dep.RelocatedFrom, match.Name+":"+match.Version)
+					c.packageKey.PackageKey = moved.PackageKey
+					match = moved
+				}
+			}
+
 			// Look if this is already resolved.
 			c.VersionKey = match.VersionKey
 			if _, ok := concreteVersions[c]; ok {
-				if err := g.AddEdge(concreteVersions[cur.versionKey], concreteVersions[c], d.Version, d.Type); err != nil {
+				if err := limits.CheckEdge(g); err != nil {
+					return partial(err)
+				}
+				if err := g.AddEdge(concreteVersions[cur.versionKey], concreteVersions[c], d.Version, typ); err != nil {
 					return nil, false, err
 				}
+				r.opts.Trace(resolve.Event{
+					Kind:        resolve.PinEvent,
+					From:        cur.VersionKey,
+					Requirement: d.VersionKey,
+					Version:     c.VersionKey,
+				})
+				continue
+			}
+			if ok := resolvedPackages[c.packageKey]; ok && typ.HasAttr(dep.RelocatedFrom) {
+				// The package a dependency was relocated to is
+				// already resolved nearer to the root, and the
+				// nearest version wins.
+				for k, id := range concreteVersions {
+					if k.packageKey != c.packageKey {
+						continue
+					}
+					if err := limits.CheckEdge(g); err != nil {
+						return partial(err)
+					}
+					if err := g.AddEdge(concreteVersions[cur.versionKey], id, d.Version, typ); err != nil {
+						return nil, false, err
+					}
+					r.opts.Trace(resolve.Event{
+						Kind:        resolve.PinEvent,
+						From:        cur.VersionKey,
+						Requirement: d.VersionKey,
+						Version:     k.VersionKey,
+					})
+					break
+				}
 				continue
 			}
 			if ok := resolvedPackages[c.packageKey]; ok {
@@ -283,19 +405,27 @@ func (r *resolver) resolve(ctx context.Context, vk resolve.VersionKey, requireme
 				}
 				// TODO: check requirement duplicates?
 				requirements[c.packageKey] = append(reqs, d.VersionKey)
+				r.opts.Trace(resolve.Event{
+					Kind:        resolve.ConflictEvent,
+					From:        cur.VersionKey,
+					Requirement: d.VersionKey,
+					Version:     match.VersionKey,
+					Message:     "another version of the package is already resolved",
+				})
 				return nil, false, errIncompatible
 			}
 
 			// Check if this is a version that we can't access.
 			reachable := false
+			var available []string
 			if !hasMulti || multi {
 				// Only need to check if we don't already know if multiple
 				// registries are required.
-				_, registries, _ := parseRegistries(match.AttrSet)
+				_, available, _ = parseRegistries(match.AttrSet)
 				// Attributes having no registries means the package only
 				// available in the default registry.
-				keep := len(registries) == 0
-				for _, reg := range registries {
+				keep := len(available) == 0
+				for _, reg := range available {
 					if reg == "" {
 						// This is on the default registry, keep it.
 						keep = true
@@ -311,14 +441,19 @@ func (r *resolver) resolve(ctx context.Context, vk resolve.VersionKey, requireme
 								break
 							}
 						}
-					} else if reg == defaultRegistry {
+						if regs.Same(reg, mavenpkg.MavenCentral) {
+							// This is on a mirror of Maven Central, keep it.
+							keep = true
+							break
+						}
+					} else if regs.Same(reg, defaultRegistry) {
 						keep = true
 						break
 					}
 
 					for _, rep := range cur.repositories {
 						// It can be reached, keep it.
-						if reg == rep {
+						if regs.Same(reg, rep) {
 							keep = true
 							break
 						}
@@ -340,36 +475,62 @@ func (r *resolver) resolve(ctx context.Context, vk resolve.VersionKey, requireme
 				// mechanism to npm bundles with derived packages.
 				// In the meantime, just skip the error as this is most
 				// probably a false positive.
-				if s, _ := d.Type.GetAttr(dep.Scope); s == "provided" {
+				if s, _ := d.Type.GetAttr(dep.Scope); s == dep.ScopeProvided {
 					continue
 				}
-				vk := d.VersionKey
-				g.AddError(concreteVersions[cur.versionKey], vk, fmt.Sprintf("could not find a version that satisfies requirement %s for package %s", vk.Version, vk.Name))
+				g.AddNodeError(concreteVersions[cur.versionKey], d.VersionKey, &resolve.UnreachableRegistry{
+					Requirement: d.VersionKey,
+					Version:     match.VersionKey,
+					Registries:  available,
+				})
 				continue
 			}
 
-			if id, ok := nodes[match.VersionKey]; ok {
+			mk := versionKey{
+				packageKey: c.packageKey,
+				VersionKey: match.VersionKey,
+			}
+			if id, ok := nodes[mk]; ok {
 				// The version key is already in the graph, just add an edge.
-				if err := g.AddEdge(concreteVersions[cur.versionKey], id, d.Version, d.Type); err != nil {
+				if err := limits.CheckEdge(g); err != nil {
+					return partial(err)
+				}
+				if err := g.AddEdge(concreteVersions[cur.versionKey], id, d.Version, typ); err != nil {
 					return nil, false, err
 				}
+				r.opts.Trace(resolve.Event{
+					Kind:        resolve.PinEvent,
+					From:        cur.VersionKey,
+					Requirement: d.VersionKey,
+					Version:     match.VersionKey,
+				})
 				continue
 			}
 
-			matchID := g.AddNode(match.VersionKey)
-			nodes[match.VersionKey] = matchID
-			dt := d.Type.Clone()
+			if err := limits.CheckNode(g, cur.depth+1); err != nil {
+				return partial(err)
+			}
+			if err := limits.CheckEdge(g); err != nil {
+				return partial(err)
+			}
+			matchID := g.AddArtifactNode(match.VersionKey, mk.attrs())
+			nodes[mk] = matchID
+			dt := typ.Clone()
 			dt.AddAttr(dep.Selector, "")
 			if err := g.AddEdge(concreteVersions[cur.versionKey], matchID, d.Version, dt); err != nil {
 				return nil, false, err
 			}
+			r.opts.Trace(resolve.Event{
+				Kind:        resolve.PinEvent,
+				From:        cur.VersionKey,
+				Requirement: d.VersionKey,
+				Version:     match.VersionKey,
+			})
 			n := version{
-				versionKey: versionKey{
-					packageKey: r.packageKeyForDependency(d.RequirementVersion),
-					VersionKey: match.VersionKey,
-				},
+				versionKey:   mk,
 				exclusions:   cur.exclusions,
 				repositories: cur.repositories,
+				depth:        cur.depth + 1,
 			}
 			if t, ok := d.Type.GetAttr(dep.MavenArtifactType); ok {
 				n.includesDependencies = t == "ear" || t == "war" || t == "rar"
@@ -394,6 +555,54 @@ func (r *resolver) resolve(ctx context.Context, vk resolve.VersionKey, requireme
 	return g, hasMulti, nil
 }
 
+// relocate follows the relocations of the given version, and returns the
+// version they lead to, which is v itself if it is not relocated. A
+// relocation is recorded in the Redirect attribute of a version as
+// groupId:artifactId:version, where any part may be empty to keep that of
+// the relocated version, as in the relocation section of a pom.xml.
+func (r *resolver) relocate(ctx context.Context, v resolve.Version) (resolve.Version, error) {
+	from := v.VersionKey
+	visited := map[resolve.VersionKey]bool{from: true}
+	for {
+		to, ok := v.GetAttr(versionpkg.Redirect)
+		if !ok {
+			return v, nil
+		}
+		parts := strings.Split(to, ":")
+		if len(parts) != 3 {
+			return resolve.Version{}, fmt.Errorf("invalid relocation of %s: %q", v.VersionKey, to)
+		}
+		group, artifact, _ := strings.Cut(v.Name, ":")
+		vk := v.VersionKey
+		if parts[0] != "" {
+			group = parts[0]
+		}
+		if parts[1] != "" {
+			artifact = parts[1]
+		}
+		vk.Name = group + ":" + artifact
+		if parts[2] != "" {
+			vk.Version = parts[2]
+		}
+		if vk == v.VersionKey {
+			// A version relocated to itself is not relocated.
+			return v, nil
+		}
+		if visited[vk] {
+			return resolve.Version{}, fmt.Errorf("cycle of relocations from %s", from)
+		}
+		if len(visited) > mavenpkg.MaxRelocations {
+			return resolve.Version{}, fmt.Errorf("too many relocations from %s", from)
+		}
+		visited[vk] = true
+		next, err := r.client.Version(ctx, vk)
+		if err != nil {
+			return resolve.Version{}, fmt.Errorf("relocation of %s: %w", v.VersionKey, err)
+		}
+		v = next
+	}
+}
+
 type importsOpt byte
 
 const (
@@ -438,7 +647,7 @@ func (r *resolver) findMatch(ctx context.Context, requirements []resolve.Version
 
 	// Iterate through to find hard constraints and preference order.
 	for i, req := range requirements {
-		constraint, err := semver.Maven.ParseConstraint(req.Version)
+		constraint, err := semver.Maven.ParseConstraintCached(req.Version)
 		if err != nil {
 			return resolve.Version{}, fmt.Errorf("failed parsing version constraint '%s': %w", req, err)
 		}
@@ -517,7 +726,7 @@ func (r *resolver) imports(ctx context.Context, ver resolve.VersionKey, opt impo
 			continue
 		}
 		if opt&providedImports == 0 {
-			if scope, ok := imp.Type.GetAttr(dep.Scope); ok && scope == "provided" {
+			if scope, ok := imp.Type.GetAttr(dep.Scope); ok && scope == dep.ScopeProvided {
 				continue
 			}
 		}
@@ -555,12 +764,9 @@ func (r *resolver) dependencyManagement(ctx context.Context, vk resolve.VersionK
 		return nil, fmt.Errorf("imports for %s: %w", vk, err)
 	}
 	for _, imp := range imps {
-		if origin, ok := imp.Type.GetAttr(dep.MavenDependencyOrigin); !ok || origin != "management" {
+		if origin, ok := imp.Type.GetAttr(dep.MavenDependencyOrigin); !ok || origin != dep.OriginManagement {
 			continue
 		}
-		if debug {
-			log.Printf("mgt: %s %s", imp.VersionKey, imp.Type)
-		}
 		if mgt == nil {
 			mgt = make(map[packageKey]resolve.VersionKey)
 		}
@@ -613,18 +819,14 @@ func (r *resolver) isExcluded(excl map[string]bool, v resolve.VersionKey) (bool,
 }
 
 func parseRegistries(a versionpkg.AttrSet) (defaultRegistry string, fetch []string, dep []string) {
-	r, ok := a.GetAttr(versionpkg.Registries)
-	if !ok {
-		return "", nil, nil
-	}
-	for _, rr := range strings.Split(r, "|") {
-		rr := strings.TrimSpace(rr)
-		if reg, ok := strings.CutPrefix(rr, "default:"); ok {
-			defaultRegistry = reg
-		} else if reg, ok := strings.CutPrefix(rr, "dep:"); ok {
-			dep = append(dep, reg)
-		} else {
-			fetch = append(fetch, rr)
+	for _, r := range a.Registries() {
+		switch r.Kind {
+		case versionpkg.RegistryDefault:
+			defaultRegistry = r.ID
+		case versionpkg.RegistryDependency:
+			dep = append(dep, r.ID)
+		default:
+			fetch = append(fetch, r.ID)
 		}
 	}
 	return
@@ -16,14 +16,19 @@ package maven
 
 import (
 	"context"
+	"errors"
+	"reflect"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
 	"deps.dev/util/resolve"
 	"deps.dev/util/resolve/dep"
 	"deps.dev/util/resolve/internal/resolvetest"
+	"deps.dev/util/resolve/schema"
 	versionpkg "deps.dev/util/resolve/version"
 )
 
@@ -62,6 +67,7 @@ func TestMavenResolver(t *testing.T) {
 		"testdata/exclusions_test.data", "testdata/exclusions_test.want",
 		"testdata/multiverse_test.data", "testdata/multiverse_test.want",
 		"testdata/version_selection_test.data", "testdata/version_selection_test.want",
+		"testdata/relocation_test.data", "testdata/relocation_test.want",
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -84,12 +90,371 @@ func TestMavenResolver(t *testing.T) {
 	}
 }
 
+func TestMavenResolverTracer(t *testing.T) {
+	s, err := schema.New(`
+group:alice
+	1.0
+		group:bob@1.0
+		group:chuck@1.0
+group:bob
+	1.0
+	2.0
+group:chuck
+	1.0
+		group:bob@[2.0]
+`, resolve.Maven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []resolve.EventKind
+	tracer := resolve.TracerFunc(func(e resolve.Event) {
+		if e.Kind != resolve.ClientCallEvent {
+			kinds = append(kinds, e.Kind)
+		}
+	})
+	r := NewResolver(s.NewClient(), resolve.WithTracer(tracer))
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.Maven, Name: "group:alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0",
+	}
+	if _, err := r.Resolve(context.Background(), vk); err != nil {
+		t.Fatal(err)
+	}
+	// The first attempt picks bob 1.0 and conflicts with the requirement
+	// of chuck, the second one picks bob 2.0 for both.
+	want := []resolve.EventKind{
+		resolve.PinEvent,
+		resolve.PinEvent,
+		resolve.ConflictEvent,
+		resolve.BacktrackEvent,
+		resolve.PinEvent,
+		resolve.PinEvent,
+		resolve.PinEvent,
+	}
+	if diff := cmp.Diff(want, kinds); diff != "" {
+		t.Errorf("events (-want +got):\n%s", diff)
+	}
+}
+
+func TestMavenResolverDeadline(t *testing.T) {
+	s, err := schema.New(`
+group:alice
+	1.0
+		group:bob@1.0
+group:bob
+	1.0
+`, resolve.Maven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := s.NewClient()
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.Maven, Name: "group:alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0",
+	}
+
+	r := NewResolver(client, resolve.WithMaxDuration(time.Nanosecond))
+	if _, err := r.Resolve(context.Background(), vk); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Resolve with MaxDuration: got error %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = NewResolver(client)
+	if _, err := r.Resolve(ctx, vk); !errors.Is(err, context.Canceled) {
+		t.Errorf("Resolve with canceled context: got error %v, want %v", err, context.Canceled)
+	}
+
+	r = NewResolver(client, resolve.WithMaxDuration(time.Minute))
+	if _, err := r.Resolve(context.Background(), vk); err != nil {
+		t.Errorf("Resolve with MaxDuration: %v", err)
+	}
+}
+
+func TestMavenResolverErrors(t *testing.T) {
+	s, err := schema.New(`
+group:alice
+	1.0
+		group:dave@1.0
+		group:eve@1.0
+group:bob
+	2.0
+	3.0-beta1
+	4.0
+group:dave
+	1.0
+		group:bob@[2.0,3.0)
+group:eve
+	1.0
+		group:bob@[3.0,)
+`, resolve.Maven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.Maven, Name: "group:alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0",
+	}
+	g, err := NewResolver(s.NewClient()).Resolve(context.Background(), vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ne resolve.NodeError
+	for _, n := range g.Nodes {
+		if n.Version.Name == "group:eve" && len(n.Errors) == 1 {
+			ne = n.Errors[0]
+		}
+	}
+	var ur *resolve.UnsatisfiedRequirement
+	if !errors.As(ne.Err, &ur) {
+		t.Fatalf("got error %v, want *UnsatisfiedRequirement for group:eve", ne.Err)
+	}
+	if want := []string{"[2.0,3.0)", "[3.0,)"}; ur.Requirement.Name != "group:bob" || !reflect.DeepEqual(ur.Requirements, want) {
+		t.Errorf("got %+v, want requirements %v for group:bob", ur, want)
+	}
+	if ne.Error != ur.Error() {
+		t.Errorf("got message %q, want %q", ne.Error, ur.Error())
+	}
+}
+
+func TestMavenResolverArtifacts(t *testing.T) {
+	s, err := schema.New(`
+group:alice
+	1.0
+		group:bob@1.0
+		mavenclassifier tests|group:bob@1.0
+		mavenartifacttype jar|group:chuck@1.0
+		mavenartifacttype pom|group:dave@1.0
+group:bob
+	1.0
+		group:chuck@1.0
+group:chuck
+	1.0
+group:dave
+	1.0
+`, resolve.Maven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.Maven, Name: "group:alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0",
+	}
+	g, err := NewResolver(s.NewClient()).Resolve(context.Background(), vk)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if g.Error != "" {
+		t.Fatalf("Resolve: graph error %s", g.Error)
+	}
+	attrs := func(kv ...string) dep.Type {
+		var t dep.Type
+		for i := 0; i < len(kv); i += 2 {
+			key := dep.MavenClassifier
+			if kv[i] == "type" {
+				key = dep.MavenArtifactType
+			}
+			t.AddAttr(key, kv[i+1])
+		}
+		return t
+	}
+	type node struct {
+		name  string
+		attrs string
+	}
+	var got []node
+	for _, n := range g.Nodes {
+		got = append(got, node{n.Version.Name, n.Attrs.String()})
+	}
+	// Both artifacts of bob are in the graph, and share the jar of chuck;
+	// the default jar type is not recorded.
+	want := []node{
+		{"group:alice", attrs().String()},
+		{"group:bob", attrs().String()},
+		{"group:bob", attrs("classifier", "tests").String()},
+		{"group:chuck", attrs().String()},
+		{"group:dave", attrs("type", "pom").String()},
+	}
+	slices.SortFunc(got, func(a, b node) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.attrs, b.attrs)
+	})
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(node{})); diff != "" {
+		t.Errorf("nodes (-want +got):\n%s", diff)
+	}
+	if err := g.Canon(); err != nil {
+		t.Errorf("Canon: %v", err)
+	}
+}
+
+func TestMavenResolveRequirements(t *testing.T) {
+	s, err := schema.New(`
+group:alice
+	1.0
+		group:bob@1.0
+		test|group:chuck@2.0
+group:bob
+	1.0
+		group:chuck@1.0
+group:chuck
+	1.0
+	2.0
+`, resolve.Maven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := s.NewClient()
+	ctx := context.Background()
+	alice := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.Maven, Name: "group:alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0",
+	}
+	reqs, err := client.Requirements(ctx, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver(client).(resolve.RequirementsResolver)
+	got, err := r.ResolveRequirements(ctx, resolve.Maven, reqs)
+	if err != nil {
+		t.Fatalf("ResolveRequirements: %v", err)
+	}
+	// The requirements of alice resolve as alice does.
+	want, err := NewResolver(client).Resolve(ctx, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Nodes[0].Version = resolve.ManifestRoot(resolve.Maven)
+	got.Duration, want.Duration = 0, 0
+	if err := got.Canon(); err != nil {
+		t.Fatal(err)
+	}
+	if err := want.Canon(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ResolveRequirements (-want +got):\n%s", diff)
+	}
+	if len(got.Nodes) != 3 {
+		t.Errorf("got %d nodes, want 3", len(got.Nodes))
+	}
+
+	if _, err := r.ResolveRequirements(ctx, resolve.NPM, reqs); err == nil {
+		t.Errorf("ResolveRequirements for npm: got no error")
+	}
+}
+
+func TestMavenResolverRegistries(t *testing.T) {
+	s, err := schema.New(`
+group:alice
+	1.0
+		group:bob@1.0
+group:bob
+	1.0
+		ATTR: Registries https://corp.example.com/maven
+`, resolve.Maven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := s.NewClient()
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.Maven, Name: "group:alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0",
+	}
+	for _, c := range []struct {
+		name        string
+		regs        resolve.RegistryConfig
+		unreachable bool
+	}{
+		{"none", resolve.RegistryConfig{}, true},
+		{"extra", resolve.RegistryConfig{Extra: []string{"https://corp.example.com/maven"}}, false},
+		{"default", resolve.RegistryConfig{Default: "https://corp.example.com/maven"}, false},
+		{"mirror", resolve.RegistryConfig{Mirror: func(string) string { return "https://mirror.example.com" }}, false},
+		{"other", resolve.RegistryConfig{Extra: []string{"https://other.example.com/maven"}}, true},
+	} {
+		g, err := NewResolver(client, resolve.WithRegistries(c.regs)).Resolve(context.Background(), vk)
+		if err != nil {
+			t.Fatalf("%s: Resolve: %v", c.name, err)
+		}
+		var ur *resolve.UnreachableRegistry
+		if got := errors.As(g.Err, &ur); got != c.unreachable {
+			t.Errorf("%s: got unreachable %t, want %t (error %v)", c.name, got, c.unreachable, g.Err)
+		}
+	}
+}
+
+func TestMavenResolverLimits(t *testing.T) {
+	s, err := schema.New(`
+group:alice
+	1.0
+		group:bob@1.0
+		group:chuck@1.0
+group:bob
+	1.0
+		group:dave@1.0
+group:chuck
+	1.0
+		group:dave@1.0
+group:dave
+	1.0
+`, resolve.Maven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := s.NewClient()
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.Maven, Name: "group:alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0",
+	}
+	for _, c := range []struct {
+		limits    resolve.Limits
+		want      resolve.Limit // zero means no error
+		wantNodes int
+	}{
+		{resolve.Limits{}, 0, 4},
+		{resolve.Limits{MaxNodes: 4, MaxEdges: 4, MaxDepth: 2}, 0, 4},
+		{resolve.Limits{MaxNodes: 2}, resolve.NodeLimit, 2},
+		{resolve.Limits{MaxEdges: 3}, resolve.EdgeLimit, 4},
+		{resolve.Limits{MaxDepth: 1}, resolve.DepthLimit, 3},
+	} {
+		r := NewResolver(client, resolve.WithLimits(c.limits))
+		g, err := r.Resolve(context.Background(), vk)
+		if c.want == 0 {
+			if err != nil {
+				t.Errorf("%+v: Resolve: %v", c.limits, err)
+			}
+		} else {
+			var le *resolve.LimitError
+			if !errors.As(err, &le) || le.Limit != c.want {
+				t.Errorf("%+v: Resolve: got error %v, want %v limit error", c.limits, err, c.want)
+				continue
+			}
+			if g == nil || g.Error == "" || !errors.As(g.Err, &le) {
+				t.Errorf("%+v: Resolve: got no partial graph with error", c.limits)
+				continue
+			}
+		}
+		if got := len(g.Nodes); got != c.wantNodes {
+			t.Errorf("%+v: Resolve: got %d nodes, want %d", c.limits, got, c.wantNodes)
+		}
+	}
+}
+
 func BenchmarkMavenResolver(b *testing.B) {
 	a, err := resolvetest.ParseFiles(resolve.Maven,
 		"testdata/resolve_test.data", "testdata/resolve_test.want",
 		"testdata/exclusions_test.data", "testdata/exclusions_test.want",
 		"testdata/multiverse_test.data", "testdata/multiverse_test.want",
 		"testdata/version_selection_test.data", "testdata/version_selection_test.want",
+		"testdata/relocation_test.data", "testdata/relocation_test.want",
 	)
 	if err != nil {
 		b.Fatal(err)
@@ -131,6 +496,15 @@ func cleanGraph(t *testing.T, g *resolve.Graph, flagErrors bool) {
 		}
 	}
 
+	// The test data only holds error messages; structured errors are
+	// checked separately.
+	g.Err = nil
+	for i := range g.Nodes {
+		for j := range g.Nodes[i].Errors {
+			g.Nodes[i].Errors[j].Err = nil
+		}
+	}
+
 	if err := g.Canon(); err != nil {
 		t.Fatalf("Canon: %v", err)
 	}
@@ -323,3 +323,60 @@ func TestDepType(t *testing.T) {
 		}
 	}
 }
+
+func TestMavenRedirect(t *testing.T) {
+	p := maven.Project{
+		ProjectKey: maven.ProjectKey{GroupID: "org.example", ArtifactID: "old", Version: "1.0"},
+	}
+	if got, ok := MavenRedirect(p); ok {
+		t.Errorf("MavenRedirect without relocation: got %q", got)
+	}
+	p.DistributionManagement.Relocation.ArtifactID = "new"
+	if got, ok := MavenRedirect(p); !ok || got != "org.example:new:1.0" {
+		t.Errorf("MavenRedirect: got %q, %t, want org.example:new:1.0", got, ok)
+	}
+}
+
+func TestMavenRegistries(t *testing.T) {
+	s := &maven.Settings{
+		Mirrors: []maven.Mirror{{
+			ID:       "corp",
+			URL:      "https://maven.corp.example.com/all/",
+			MirrorOf: "*,!internal",
+		}},
+		Profiles: []maven.Profile{{
+			ID: "corp",
+			Repositories: []maven.Repository{{
+				ID:  "internal",
+				URL: "https://internal.corp.example.com/maven",
+			}},
+		}, {
+			ID: "inactive",
+			Repositories: []maven.Repository{{
+				ID:  "inactive",
+				URL: "https://inactive.example.com/maven",
+			}},
+		}},
+		ActiveProfiles: []maven.String{"corp"},
+	}
+	c, err := MavenRegistries(s, "", maven.ActivationOS{})
+	if err != nil {
+		t.Fatalf("MavenRegistries: %v", err)
+	}
+	if diff := cmp.Diff([]string{"https://internal.corp.example.com/maven"}, c.Extra); diff != "" {
+		t.Errorf("extra registries (-want +got):\n%s", diff)
+	}
+	for _, test := range []struct {
+		a, b string
+		want bool
+	}{
+		{maven.MavenCentral, "https://maven.corp.example.com/all", true},
+		{maven.MavenCentral + "/", "https://other.example.com", true},
+		{"https://internal.corp.example.com/maven", maven.MavenCentral, false},
+		{"https://internal.corp.example.com/maven", "https://internal.corp.example.com/maven", true},
+	} {
+		if got := c.Same(test.a, test.b); got != test.want {
+			t.Errorf("Same(%s, %s): got %t, want %t", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"deps.dev/util/resolve/dep"
+)
+
+// MergeGraphs combines graphs resolved separately, possibly for different
+// systems, into a single graph whose root is a new node for the given
+// version key, typically naming the application. The root of each graph
+// becomes a direct dependency of the new root, with an empty requirement.
+//
+// The nodes of different graphs are kept distinct even when they are the
+// same version, as they were resolved in different contexts. Graph-wide
+// errors are kept with each line prefixed by the root of its graph, and
+// structured errors are joined. The duration of the result is the sum of
+// those of the graphs. The graphs are left unchanged.
+func MergeGraphs(root VersionKey, graphs ...*Graph) (*Graph, error) {
+	m := &Graph{}
+	m.AddNode(root)
+	var msgs []string
+	var errs []error
+	for i, g := range graphs {
+		if len(g.Nodes) == 0 {
+			return nil, fmt.Errorf("graph %d has no root", i)
+		}
+		offset := NodeID(len(m.Nodes))
+		for _, n := range g.Nodes {
+			n.Attrs = n.Attrs.Clone()
+			n.Errors = append([]NodeError(nil), n.Errors...)
+			m.Nodes = append(m.Nodes, n)
+		}
+		if err := m.AddEdge(0, offset, "", dep.Type{}); err != nil {
+			return nil, err
+		}
+		for _, e := range g.Edges {
+			if err := m.AddEdge(e.From+offset, e.To+offset, e.Requirement, e.Type.Clone()); err != nil {
+				return nil, err
+			}
+		}
+		if g.Error != "" {
+			name := g.Nodes[0].Version.Name
+			for _, l := range strings.Split(g.Error, "\n") {
+				msgs = append(msgs, name+": "+l)
+			}
+		}
+		if g.Err != nil {
+			errs = append(errs, g.Err)
+		}
+		m.Duration += g.Duration
+	}
+	m.Error = strings.Join(msgs, "\n")
+	switch len(errs) {
+	case 0:
+	case 1:
+		m.Err = errs[0]
+	default:
+		m.Err = errors.Join(errs...)
+	}
+	return m, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"deps.dev/util/resolve/dep"
+)
+
+func TestMergeGraphs(t *testing.T) {
+	vk := func(sys System, name, version string) VersionKey {
+		return VersionKey{PackageKey: PackageKey{System: sys, Name: name}, VersionType: Concrete, Version: version}
+	}
+	build := func(sys System, names ...string) *Graph {
+		g := &Graph{Duration: time.Second}
+		for i, name := range names {
+			id := g.AddNode(vk(sys, name, "1.0.0"))
+			if i > 0 {
+				if err := g.AddEdge(0, id, "^1.0.0", dep.NewType(dep.Dev)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		return g
+	}
+	npm := build(NPM, "frontend", "react", "shared")
+	pypi := build(PyPI, "service", "flask", "shared")
+	pypi.Error = "first\nsecond"
+	pypi.Err = &LimitError{Limit: NodeLimit, Max: 3}
+	before := pypi.String()
+
+	m, err := MergeGraphs(vk(UnknownSystem, "app", ""), npm, pypi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pypi.String(); got != before {
+		t.Errorf("MergeGraphs modified its input:\n%s\nwant:\n%s", got, before)
+	}
+	if got, want := len(m.Nodes), 7; got != want {
+		t.Fatalf("got %d nodes, want %d", got, want)
+	}
+	// Both shared nodes are kept, one per system.
+	if m.Nodes[3].Version.System != NPM || m.Nodes[6].Version.System != PyPI {
+		t.Errorf("got nodes %v", m.Nodes)
+	}
+	want := []Edge{
+		{From: 0, To: 1, Type: dep.Type{}},
+		{From: 1, To: 2, Requirement: "^1.0.0", Type: dep.NewType(dep.Dev)},
+		{From: 1, To: 3, Requirement: "^1.0.0", Type: dep.NewType(dep.Dev)},
+		{From: 0, To: 4, Type: dep.Type{}},
+		{From: 4, To: 5, Requirement: "^1.0.0", Type: dep.NewType(dep.Dev)},
+		{From: 4, To: 6, Requirement: "^1.0.0", Type: dep.NewType(dep.Dev)},
+	}
+	if len(m.Edges) != len(want) {
+		t.Fatalf("got edges %v, want %v", m.Edges, want)
+	}
+	for i, e := range m.Edges {
+		w := want[i]
+		if e.From != w.From || e.To != w.To || e.Requirement != w.Requirement || !e.Type.Equal(w.Type) {
+			t.Errorf("edge %d: got %v, want %v", i, e, w)
+		}
+	}
+	if got, want := m.Error, "service: first\nservice: second"; got != want {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+	var le *LimitError
+	if !errors.As(m.Err, &le) {
+		t.Errorf("got structured error %v, want a *LimitError", m.Err)
+	}
+	if got, want := m.Duration, 2*time.Second; got != want {
+		t.Errorf("got duration %v, want %v", got, want)
+	}
+	if err := m.Canon(); err != nil {
+		t.Errorf("Canon: %v", err)
+	}
+
+	if _, err := MergeGraphs(vk(UnknownSystem, "app", ""), npm, &Graph{}); err == nil {
+		t.Errorf("MergeGraphs with an empty graph: got no error")
+	}
+}
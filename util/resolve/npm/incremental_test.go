@@ -0,0 +1,169 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/schema"
+)
+
+// incrementalUniverse holds the packages required by alice, whose
+// requirements are given by each test.
+const incrementalUniverse = `
+bob
+	1.0.0
+		dave@^1.0.0
+	1.1.0
+		dave@^1.0.0
+	2.0.0
+		eve@^1.0.0
+chuck
+	1.0.0
+		dave@^1.0.0
+dave
+	1.0.0
+eve
+	1.0.0
+frank
+	1.0.0
+`
+
+// incrementalSchema returns the universe with alice 1.0.0 having the given
+// requirements, one per line.
+func incrementalSchema(t *testing.T, alice ...string) *schema.Schema {
+	t.Helper()
+	text := "alice\n\t1.0.0\n"
+	for _, r := range alice {
+		text += "\t\t" + r + "\n"
+	}
+	s, err := schema.New(text+incrementalUniverse, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// fullResolution resolves alice 1.0.0 with the given requirements.
+func fullResolution(t *testing.T, alice ...string) *resolve.Graph {
+	t.Helper()
+	g, err := NewResolver(incrementalSchema(t, alice...).NewClient()).Resolve(context.Background(), aliceVK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+var aliceVK = resolve.VersionKey{
+	PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "alice"},
+	VersionType: resolve.Concrete,
+	Version:     "1.0.0",
+}
+
+// countingResolver counts the calls to Resolve.
+type countingResolver struct {
+	resolve.Resolver
+	n *int
+}
+
+func (r countingResolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve.Graph, error) {
+	*r.n++
+	return r.Resolver.Resolve(ctx, vk)
+}
+
+func TestIncremental(t *testing.T) {
+	s := incrementalSchema(t, "bob@^1.0.0", "chuck@^1.0.0")
+	var calls []string
+	tracer := resolve.TracerFunc(func(e resolve.Event) {
+		if e.Kind == resolve.ClientCallEvent && e.Method == "Requirements" {
+			calls = append(calls, e.Requirement.Name)
+		}
+	})
+	var resolutions int
+	inc := resolve.NewIncremental(resolve.TraceClient(s.NewClient(), tracer), func(c resolve.Client) resolve.Resolver {
+		return countingResolver{NewResolver(c), &resolutions}
+	})
+	g, err := inc.Resolve(context.Background(), aliceVK)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare the graphs through their text representation, which does not
+	// depend on the order of the nodes.
+	check := func(name string, got *resolve.Graph, alice ...string) {
+		t.Helper()
+		if err := got.Canon(); err != nil {
+			t.Fatal(err)
+		}
+		want := fullResolution(t, alice...)
+		if err := want.Canon(); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+			t.Errorf("%s (-want +got):\n%s", name, diff)
+		}
+	}
+	bob := resolve.PackageKey{System: resolve.NPM, Name: "bob"}
+
+	// A requirement matching the same versions is patched in.
+	calls, resolutions = nil, 0
+	g, err = inc.Update(context.Background(), g, resolve.RequirementChange{Package: bob, Version: ">=1.0.0 <2.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("same versions", g, "bob@>=1.0.0 <2.0.0", "chuck@^1.0.0")
+	if len(calls) != 0 || resolutions != 0 {
+		t.Errorf("same versions: got %d resolutions and Requirements calls for %v, want none", resolutions, calls)
+	}
+
+	// A bump resolves again, fetching only the new versions.
+	calls, resolutions = nil, 0
+	g, err = inc.Update(context.Background(), g, resolve.RequirementChange{Package: bob, Version: "^2.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("bump", g, "bob@^2.0.0", "chuck@^1.0.0")
+	if diff := cmp.Diff([]string{"bob", "eve"}, calls); diff != "" || resolutions != 1 {
+		t.Errorf("bump: got %d resolutions, want 1; Requirements calls (-want +got):\n%s", resolutions, diff)
+	}
+
+	// Additions and removals accumulate with the previous changes.
+	g, err = inc.Update(context.Background(), g, resolve.RequirementChange{
+		Package: resolve.PackageKey{System: resolve.NPM, Name: "frank"},
+		Type:    dep.NewType(dep.Dev),
+		Version: "^1.0.0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err = inc.Update(context.Background(), g, resolve.RequirementChange{
+		Package: resolve.PackageKey{System: resolve.NPM, Name: "chuck"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("add and remove", g, "bob@^2.0.0", "Dev|frank@^1.0.0")
+
+	if _, err := inc.Update(context.Background(), g, resolve.RequirementChange{
+		Package: resolve.PackageKey{System: resolve.NPM, Name: "chuck"},
+	}); err == nil {
+		t.Errorf("removing a missing requirement: got no error")
+	}
+}
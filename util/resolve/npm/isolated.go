@@ -0,0 +1,327 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/npm/spec"
+	"deps.dev/util/semver"
+)
+
+// PackageManager names a package manager of the npm ecosystem, whose
+// resolution a Resolver reproduces.
+type PackageManager int
+
+const (
+	// NPM resolves as "npm install" does, hoisting the versions in a
+	// node_modules tree. It is the strategy of NewResolver.
+	NPM PackageManager = iota
+	// PNPM resolves as pnpm does. Each version is installed once, in
+	// node_modules/.pnpm, and only sees its own dependencies. Versions
+	// are picked as by npm, except that the versions already selected for
+	// a package are preferred to newer ones when they satisfy a
+	// requirement.
+	PNPM
+	// Yarn resolves as Yarn Berry (version 2 and later) does, with
+	// Plug'n'Play: each version is installed once and only sees its own
+	// dependencies, and each requirement is resolved to its highest
+	// matching version, independently of the others, regardless of tags
+	// and deprecations.
+	Yarn
+)
+
+var packageManagerNames = [...]string{"npm", "pnpm", "yarn"}
+
+func (pm PackageManager) String() string {
+	if pm < NPM || pm > Yarn {
+		return fmt.Sprintf("PackageManager(%d)", int(pm))
+	}
+	return packageManagerNames[pm]
+}
+
+// NewPackageManagerResolver creates a Resolver reproducing the resolutions
+// of the given package manager, connected to the given client. It is safe
+// for concurrent use.
+//
+// For PNPM and Yarn, the graph has a single node per version, as their
+// installations do, and the edges of a version are its dependencies as it
+// sees them. Bundled dependencies are resolved from the bundles, as by
+// NewResolver; peer dependencies are not resolved.
+func NewPackageManagerResolver(pm PackageManager, client resolve.Client, opts ...resolve.Option) resolve.Resolver {
+	r := NewResolver(client, opts...).(*resolver)
+	if pm == NPM {
+		return r
+	}
+	return &isolatedResolver{base: r, pm: pm}
+}
+
+// isolatedResolver implements resolve.Resolver for the package managers
+// installing each version in isolation, with exactly its dependencies.
+// The resolution is a BFS from the root, in which each requirement is
+// resolved from the bundle of its version, if any, and otherwise to a
+// version of the registry, with a single node per version.
+type isolatedResolver struct {
+	base *resolver
+	pm   PackageManager
+}
+
+// bundleScope holds the direct content of a bundle, which is visible from
+// the bundled versions and the version holding the bundle. Bundles are
+// nested in their parent.
+type bundleScope struct {
+	parent   *bundleScope
+	packages map[resolve.PackageKey]*bundledVersion
+	aliases  map[string]*bundledVersion
+}
+
+// lookup returns the bundled version installed for the given package, or
+// under the given alias, in the closest scope that has one.
+func (s *bundleScope) lookup(pk resolve.PackageKey, alias string) *bundledVersion {
+	for ; s != nil; s = s.parent {
+		if alias != "" {
+			if bv := s.aliases[alias]; bv != nil {
+				return bv
+			}
+			continue
+		}
+		if bv := s.packages[pk]; bv != nil {
+			return bv
+		}
+	}
+	return nil
+}
+
+// isolatedNode is a node of the graph waiting to have its requirements
+// resolved.
+type isolatedNode struct {
+	id      resolve.NodeID
+	ver     resolve.Version
+	imports imports
+	scope   *bundleScope
+	depth   int
+}
+
+// ResolveRequirements resolves the given requirements, such as those of a
+// local package.json, as the direct dependencies of resolve.ManifestRoot.
+// It implements resolve.RequirementsResolver.
+func (r *isolatedResolver) ResolveRequirements(ctx context.Context, sys resolve.System, reqs []resolve.RequirementVersion) (*resolve.Graph, error) {
+	if sys != resolve.NPM {
+		return nil, fmt.Errorf("expected %s system, got %s", resolve.NPM, sys)
+	}
+	root := resolve.Version{VersionKey: resolve.ManifestRoot(sys)}
+	base := &resolver{client: resolve.RootClient(r.base.client, root, reqs), opts: r.base.opts}
+	rr := &isolatedResolver{base: base, pm: r.pm}
+	return rr.Resolve(ctx, root.VersionKey)
+}
+
+// Resolve resolves the transitive dependencies of the given NPM concrete
+// version as the package manager of the resolver does. If the resolution
+// reaches one of the configured limits, it stops and returns the partial
+// graph along with a *resolve.LimitError.
+func (r *isolatedResolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve.Graph, error) {
+	if vk.System != resolve.NPM {
+		return nil, fmt.Errorf("expected NPM version, got %q", vk)
+	}
+	if vk.VersionType != resolve.Concrete {
+		return nil, fmt.Errorf("expected Concrete version, got %q", vk)
+	}
+
+	start := time.Now()
+	s := r.base.session()
+	if d := s.opts.MaxDuration; d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	g := &resolve.Graph{}
+	limits := s.opts.Limits
+	partial := func(err error) (*resolve.Graph, error) {
+		g.Error = err.Error()
+		g.Err = err
+		g.Duration = time.Since(start)
+		return g, err
+	}
+
+	v, err := s.client.Version(ctx, vk)
+	if err != nil {
+		return nil, err
+	}
+	// nodes holds the node of each version, bundled versions being
+	// identified by their mangled version keys.
+	nodes := make(map[resolve.VersionKey]resolve.NodeID)
+	// picked holds the versions of the registry selected so far for each
+	// package, in order, and descriptors the version selected for each
+	// requirement.
+	picked := make(map[resolve.PackageKey][]resolve.Version)
+	descriptors := make(map[resolve.VersionKey]resolve.Version)
+
+	// enqueue returns the node for the given version, adding it to the
+	// graph and the queue if it is new.
+	var queue []*isolatedNode
+	enqueue := func(ver resolve.Version, bv *bundledVersion, scope *bundleScope, depth int) (id resolve.NodeID, created bool, err error) {
+		if id, ok := nodes[ver.VersionKey]; ok {
+			return id, false, nil
+		}
+		if len(g.Nodes) > 0 {
+			if err := limits.CheckNode(g, depth); err != nil {
+				return 0, false, err
+			}
+		}
+		imps, err := s.imports(ctx, ver)
+		if err != nil {
+			return 0, false, err
+		}
+		bvs, err := s.directBundleContent(ctx, ver)
+		if err != nil {
+			return 0, false, fmt.Errorf("cannot get bundled content of %s: %w", ver, err)
+		}
+		if len(bvs) > 0 {
+			scope = &bundleScope{parent: scope, packages: make(map[resolve.PackageKey]*bundledVersion), aliases: make(map[string]*bundledVersion)}
+			for _, bv := range bvs {
+				if bv.alias != "" {
+					scope.aliases[bv.alias] = bv
+				} else {
+					scope.packages[bv.derivedFromPackage] = bv
+				}
+			}
+		}
+		id = g.AddNode(ver.VersionKey)
+		if bv != nil {
+			bv.annotate(g, id)
+		}
+		nodes[ver.VersionKey] = id
+		queue = append(queue, &isolatedNode{id: id, ver: ver, imports: imps, scope: scope, depth: depth})
+		return id, true, nil
+	}
+	if _, _, err := enqueue(v, nil, nil, 0); err != nil {
+		return nil, err
+	}
+
+	for len(queue) > 0 {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		cur := queue[0]
+		queue = queue[1:]
+		for i, idep := range cur.imports.ideps {
+			if sp := spec.Parse(idep.Name, idep.Version); !sp.Registry() {
+				g.AddNodeError(cur.id, idep.VersionKey, &resolve.UnsupportedRequirement{Requirement: idep.VersionKey, Kind: sp.Kind.String()})
+				continue
+			}
+			alias, _ := idep.Type.GetAttr(dep.KnownAs)
+			var (
+				ver   resolve.Version
+				bv    = cur.scope.lookup(idep.PackageKey, alias)
+				scope *bundleScope
+			)
+			if bv != nil && (idep.Version == "*" || matches(idep.VersionKey, bv.derivedFromVersion)) {
+				// The bundled version shadows those of the registry.
+				ver, scope = bv.Version, cur.scope
+			} else {
+				bv = nil
+				dvers, err := s.client.MatchingVersions(ctx, idep.VersionKey)
+				if err != nil {
+					return nil, fmt.Errorf("cannot find matching versions for %s: %w", idep.Version, err)
+				}
+				if len(dvers) == 0 {
+					g.AddNodeError(cur.id, idep.VersionKey, &resolve.UnsatisfiedRequirement{Requirement: idep.VersionKey})
+					continue
+				}
+				ver = r.pick(ctx, s, idep.VersionKey, dvers, picked, descriptors)
+			}
+			id, created, err := enqueue(ver, bv, scope, cur.depth+1)
+			if err != nil {
+				if le := (*resolve.LimitError)(nil); errors.As(err, &le) {
+					return partial(err)
+				}
+				return nil, err
+			}
+			if err := limits.CheckEdge(g); err != nil {
+				return partial(err)
+			}
+			dt := idep.Type
+			if created {
+				dt = cur.imports.selected[i]
+			}
+			if err := g.AddEdge(cur.id, id, idep.Version, dt); err != nil {
+				return nil, err
+			}
+			s.opts.Trace(resolve.Event{
+				Kind:        resolve.PinEvent,
+				From:        cur.ver.VersionKey,
+				Requirement: idep.VersionKey,
+				Version:     ver.VersionKey,
+			})
+		}
+	}
+	g.Duration = time.Since(start)
+	return g, nil
+}
+
+// pick returns the version of the registry selected for the given
+// requirement among its non-empty list of matching versions, and records
+// the choice.
+func (r *isolatedResolver) pick(ctx context.Context, s *resolver, req resolve.VersionKey, dvers []resolve.Version, picked map[resolve.PackageKey][]resolve.Version, descriptors map[resolve.VersionKey]resolve.Version) resolve.Version {
+	if v, ok := descriptors[req]; ok {
+		return v
+	}
+	var v resolve.Version
+	if r.pm == PNPM {
+		// Prefer the highest version already selected for the package.
+		for i := len(dvers) - 1; i >= 0 && v.VersionKey == (resolve.VersionKey{}); i-- {
+			for _, p := range picked[req.PackageKey] {
+				if p.VersionKey == dvers[i].VersionKey {
+					v = dvers[i]
+					break
+				}
+			}
+		}
+	}
+	switch {
+	case v.VersionKey != (resolve.VersionKey{}):
+	case r.pm == Yarn:
+		// Yarn ignores the tags and deprecations, and the matching
+		// versions are ordered with latest last.
+		v = highest(dvers)
+	default:
+		v = s.pick(ctx, dvers)
+	}
+	picked[req.PackageKey] = append(picked[req.PackageKey], v)
+	descriptors[req] = v
+	return v
+}
+
+// highest returns the highest of the given non-empty list of versions.
+// Versions that are not valid semver come first.
+func highest(vs []resolve.Version) resolve.Version {
+	h := vs[0]
+	hv, _ := semver.NPM.Parse(h.Version)
+	for _, v := range vs[1:] {
+		sv, err := semver.NPM.Parse(v.Version)
+		if err != nil {
+			continue
+		}
+		if hv == nil || sv.Compare(hv) > 0 {
+			h, hv = v, sv
+		}
+	}
+	return h
+}
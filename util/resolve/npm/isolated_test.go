@@ -0,0 +1,216 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/schema"
+)
+
+// edgeList returns the edges of the graph as "from@version -> to@version",
+// sorted.
+func edgeList(g *resolve.Graph) []string {
+	var edges []string
+	for _, e := range g.Edges {
+		from, to := g.Nodes[e.From].Version, g.Nodes[e.To].Version
+		edges = append(edges, from.Name+"@"+from.Version+" -> "+to.Name+"@"+to.Version)
+	}
+	slices.Sort(edges)
+	return edges
+}
+
+func TestPackageManagerResolver(t *testing.T) {
+	s, err := schema.New(`
+alice
+	1.0.0
+		bob@^1.0.0
+		chuck@1.0.0
+		dave@^1.0.0
+		eve@^2.0.0
+bob
+	1.0.0
+		chuck@^1.0.0
+		eve@^1.0.0
+chuck
+	1.0.0
+	1.1.0
+		ATTR: Tags latest
+	1.2.0
+		ATTR: Blocked
+dave
+	1.0.0
+		eve@^1.0.0
+eve
+	1.0.0
+	2.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	}
+	for _, c := range []struct {
+		pm    PackageManager
+		nodes int
+		edges []string
+	}{{
+		// npm reuses the chuck hoisted at the root, and installs eve 1.0.0
+		// twice, under bob and under dave.
+		pm:    NPM,
+		nodes: 7,
+		edges: []string{
+			"alice@1.0.0 -> bob@1.0.0",
+			"alice@1.0.0 -> chuck@1.0.0",
+			"alice@1.0.0 -> dave@1.0.0",
+			"alice@1.0.0 -> eve@2.0.0",
+			"bob@1.0.0 -> chuck@1.0.0",
+			"bob@1.0.0 -> eve@1.0.0",
+			"dave@1.0.0 -> eve@1.0.0",
+		},
+	}, {
+		// pnpm prefers the chuck already selected, and installs eve 1.0.0
+		// once.
+		pm:    PNPM,
+		nodes: 6,
+		edges: []string{
+			"alice@1.0.0 -> bob@1.0.0",
+			"alice@1.0.0 -> chuck@1.0.0",
+			"alice@1.0.0 -> dave@1.0.0",
+			"alice@1.0.0 -> eve@2.0.0",
+			"bob@1.0.0 -> chuck@1.0.0",
+			"bob@1.0.0 -> eve@1.0.0",
+			"dave@1.0.0 -> eve@1.0.0",
+		},
+	}, {
+		// Yarn resolves the range of bob to the highest chuck, even if
+		// deprecated and not the latest.
+		pm:    Yarn,
+		nodes: 7,
+		edges: []string{
+			"alice@1.0.0 -> bob@1.0.0",
+			"alice@1.0.0 -> chuck@1.0.0",
+			"alice@1.0.0 -> dave@1.0.0",
+			"alice@1.0.0 -> eve@2.0.0",
+			"bob@1.0.0 -> chuck@1.2.0",
+			"bob@1.0.0 -> eve@1.0.0",
+			"dave@1.0.0 -> eve@1.0.0",
+		},
+	}} {
+		g, err := NewPackageManagerResolver(c.pm, s.NewClient()).Resolve(context.Background(), vk)
+		if err != nil {
+			t.Fatalf("%s: Resolve: %v", c.pm, err)
+		}
+		if g.Error != "" {
+			t.Errorf("%s: graph error %s", c.pm, g.Error)
+		}
+		if len(g.Nodes) != c.nodes {
+			t.Errorf("%s: got %d nodes, want %d:\n%s", c.pm, len(g.Nodes), c.nodes, g)
+		}
+		if diff := cmp.Diff(c.edges, edgeList(g)); diff != "" {
+			t.Errorf("%s: edges (-want +got):\n%s", c.pm, diff)
+		}
+		if err := g.Canon(); err != nil {
+			t.Errorf("%s: Canon: %v", c.pm, err)
+		}
+	}
+}
+
+func TestPackageManagerResolverBundles(t *testing.T) {
+	s, err := schema.New(`
+alice
+	1.0.0
+		bob@^1.0.0
+		chuck@^1.0.0
+		Scope bundle|bob@*
+		alice>1.0.0>bob@1.0.0
+alice>1.0.0>bob
+	1.0.0
+		ATTR: DerivedFrom bob
+		chuck@^1.0.0
+bob
+	1.0.0
+	2.0.0
+chuck
+	1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	}
+	for _, pm := range []PackageManager{PNPM, Yarn} {
+		g, err := NewPackageManagerResolver(pm, s.NewClient()).Resolve(context.Background(), vk)
+		if err != nil {
+			t.Fatalf("%s: Resolve: %v", pm, err)
+		}
+		want := []string{
+			"alice>1.0.0>bob@1.0.0 -> chuck@1.0.0",
+			"alice@1.0.0 -> alice>1.0.0>bob@1.0.0",
+			"alice@1.0.0 -> chuck@1.0.0",
+		}
+		if diff := cmp.Diff(want, edgeList(g)); diff != "" {
+			t.Errorf("%s: edges (-want +got):\n%s", pm, diff)
+		}
+		for _, n := range g.Nodes {
+			if got, want := n.Bundled(), n.Version.Name == "alice>1.0.0>bob"; got != want {
+				t.Errorf("%s: %s: Bundled() = %t, want %t", pm, n.Version, got, want)
+			}
+		}
+	}
+}
+
+func TestPackageManagerResolveRequirements(t *testing.T) {
+	s, err := schema.New(`
+bob
+	1.0.0
+		chuck@^1.0.0
+chuck
+	1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs := []resolve.RequirementVersion{{
+		VersionKey: resolve.VersionKey{
+			PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "bob"},
+			VersionType: resolve.Requirement,
+			Version:     "^1.0.0",
+		},
+	}}
+	r := NewPackageManagerResolver(PNPM, s.NewClient()).(resolve.RequirementsResolver)
+	g, err := r.ResolveRequirements(context.Background(), resolve.NPM, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		resolve.ManifestName + "@ -> bob@1.0.0",
+		"bob@1.0.0 -> chuck@1.0.0",
+	}
+	if diff := cmp.Diff(want, edgeList(g)); diff != "" {
+		t.Errorf("edges (-want +got):\n%s", diff)
+	}
+}
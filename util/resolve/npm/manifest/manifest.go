@@ -0,0 +1,316 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package manifest parses npm package.json manifests and converts their
+dependencies to the requirements expected by the npm resolver, so that a
+package can be resolved from its manifest alone.
+https://docs.npmjs.com/cli/configuring-npm/package-json
+*/
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/npm/spec"
+)
+
+// Manifest holds the fields of a package.json relevant to the resolution of
+// its dependencies.
+type Manifest struct {
+	Name    string
+	Version string
+
+	Dependencies         map[string]string
+	DevDependencies      map[string]string
+	PeerDependencies     map[string]string
+	PeerDependenciesMeta map[string]PeerDependencyMeta
+	OptionalDependencies map[string]string
+	// BundleDependencies holds the names of the bundled dependencies, in
+	// order. The value true of the field is expanded to the names of all
+	// dependencies.
+	BundleDependencies []string
+	// Overrides holds the overrides of the manifest, flattened and sorted
+	// by path. They are not applied by Requirements.
+	Overrides []Override
+	// Workspaces holds the glob patterns of the workspaces.
+	Workspaces []string
+	// Exports maps the subpaths exported by the package to their targets,
+	// decoded as by encoding/json. A string, array or object of conditions
+	// given as the whole field is mapped to the "." subpath.
+	Exports map[string]any
+}
+
+// PeerDependencyMeta holds the metadata of a peer dependency.
+type PeerDependencyMeta struct {
+	Optional bool `json:"optional"`
+}
+
+// Override replaces the requirement of the dependencies on the last package
+// of Path, when reached through the other packages of Path.
+// https://docs.npmjs.com/cli/configuring-npm/package-json#overrides
+type Override struct {
+	// Path holds the package selectors leading to the overridden
+	// package, such as "foo" or "foo@1.x".
+	Path []string
+	// Requirement is the requirement replacing that of the dependencies.
+	// References to direct dependencies, such as "$foo", are replaced with
+	// their requirements.
+	Requirement string
+}
+
+// packageJSON is the encoding of a package.json. The fields accepting values
+// of several types are decoded separately.
+type packageJSON struct {
+	Name                 string                        `json:"name"`
+	Version              string                        `json:"version"`
+	Dependencies         map[string]string             `json:"dependencies"`
+	DevDependencies      map[string]string             `json:"devDependencies"`
+	PeerDependencies     map[string]string             `json:"peerDependencies"`
+	PeerDependenciesMeta map[string]PeerDependencyMeta `json:"peerDependenciesMeta"`
+	OptionalDependencies map[string]string             `json:"optionalDependencies"`
+	BundleDependencies   json.RawMessage               `json:"bundleDependencies"`
+	BundledDependencies  json.RawMessage               `json:"bundledDependencies"`
+	Overrides            map[string]json.RawMessage    `json:"overrides"`
+	Workspaces           json.RawMessage               `json:"workspaces"`
+	Exports              json.RawMessage               `json:"exports"`
+}
+
+// Parse parses the given package.json.
+func Parse(data []byte) (*Manifest, error) {
+	var pj packageJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+	m := &Manifest{
+		Name:                 pj.Name,
+		Version:              pj.Version,
+		Dependencies:         pj.Dependencies,
+		DevDependencies:      pj.DevDependencies,
+		PeerDependencies:     pj.PeerDependencies,
+		PeerDependenciesMeta: pj.PeerDependenciesMeta,
+		OptionalDependencies: pj.OptionalDependencies,
+	}
+
+	// Both spellings are accepted by npm, the first one taking
+	// precedence.
+	bundle := pj.BundleDependencies
+	if len(bundle) == 0 {
+		bundle = pj.BundledDependencies
+	}
+	if err := m.parseBundle(bundle); err != nil {
+		return nil, err
+	}
+	for k, v := range pj.Overrides {
+		if err := m.parseOverride([]string{k}, v); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(m.Overrides, func(i, j int) bool {
+		return strings.Join(m.Overrides[i].Path, ">") < strings.Join(m.Overrides[j].Path, ">")
+	})
+	if err := m.parseWorkspaces(pj.Workspaces); err != nil {
+		return nil, err
+	}
+	if err := m.parseExports(pj.Exports); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseBundle parses the bundleDependencies field, either a list of names
+// or a boolean.
+func (m *Manifest) parseBundle(data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var all bool
+	if err := json.Unmarshal(data, &all); err == nil {
+		if all {
+			for name := range m.Dependencies {
+				m.BundleDependencies = append(m.BundleDependencies, name)
+			}
+			sort.Strings(m.BundleDependencies)
+		}
+		return nil
+	}
+	if err := json.Unmarshal(data, &m.BundleDependencies); err != nil {
+		return fmt.Errorf("invalid bundleDependencies: %w", err)
+	}
+	return nil
+}
+
+// parseOverride parses the override of the given path, either a
+// requirement or an object holding the overrides of the dependencies and,
+// with the key ".", the requirement of the package itself.
+func (m *Manifest) parseOverride(path []string, data json.RawMessage) error {
+	var req string
+	if err := json.Unmarshal(data, &req); err == nil {
+		m.addOverride(path, req)
+		return nil
+	}
+	var nested map[string]json.RawMessage
+	if err := json.Unmarshal(data, &nested); err != nil {
+		return fmt.Errorf("invalid override of %s: %w", strings.Join(path, ">"), err)
+	}
+	for k, v := range nested {
+		if k == "." {
+			if err := json.Unmarshal(v, &req); err != nil {
+				return fmt.Errorf("invalid override of %s: %w", strings.Join(path, ">"), err)
+			}
+			m.addOverride(path, req)
+			continue
+		}
+		if err := m.parseOverride(append(path[:len(path):len(path)], k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) addOverride(path []string, req string) {
+	if ref, ok := strings.CutPrefix(req, "$"); ok {
+		// A reference to a direct dependency.
+		req = m.Dependencies[ref]
+	}
+	m.Overrides = append(m.Overrides, Override{Path: path, Requirement: req})
+}
+
+// parseWorkspaces parses the workspaces field, either a list of patterns
+// or an object holding them in its packages field.
+func (m *Manifest) parseWorkspaces(data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, &m.Workspaces); err == nil {
+		return nil
+	}
+	var ws struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return fmt.Errorf("invalid workspaces: %w", err)
+	}
+	m.Workspaces = ws.Packages
+	return nil
+}
+
+// parseExports parses the exports field, mapping its sugared forms to the
+// "." subpath: the keys of an object are either all subpaths, starting with
+// ".", or all conditions.
+// https://nodejs.org/api/packages.html#exports
+func (m *Manifest) parseExports(data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var exports any
+	if err := json.Unmarshal(data, &exports); err != nil {
+		return fmt.Errorf("invalid exports: %w", err)
+	}
+	obj, ok := exports.(map[string]any)
+	if !ok {
+		m.Exports = map[string]any{".": exports}
+		return nil
+	}
+	subpaths := 0
+	for k := range obj {
+		if strings.HasPrefix(k, ".") {
+			subpaths++
+		}
+	}
+	switch subpaths {
+	case 0:
+		m.Exports = map[string]any{".": obj}
+	case len(obj):
+		m.Exports = obj
+	default:
+		return fmt.Errorf("invalid exports: mixed subpaths and conditions")
+	}
+	return nil
+}
+
+// VersionKey returns the version key of the package.
+func (m *Manifest) VersionKey() resolve.VersionKey {
+	return resolve.VersionKey{
+		PackageKey: resolve.PackageKey{
+			System: resolve.NPM,
+			Name:   m.Name,
+		},
+		VersionType: resolve.Concrete,
+		Version:     m.Version,
+	}
+}
+
+// Requirements returns the dependencies of the manifest as expected by the
+// npm resolver, sorted with resolve.SortDependencies. Aliased dependencies
+// are requirements on the actual package, keeping the alias in the KnownAs
+// attribute. As in npm, optional dependencies replace regular dependencies
+// of the same name. Bundled dependencies are added with the "*"
+// requirement, in addition to their regular dependency.
+func (m *Manifest) Requirements() []resolve.RequirementVersion {
+	var reqs []resolve.RequirementVersion
+	add := func(deps map[string]string, t dep.Type) {
+		for name, req := range deps {
+			reqs = append(reqs, requirement(name, req, t.Clone()))
+		}
+	}
+	regular := make(map[string]string, len(m.Dependencies))
+	for name, req := range m.Dependencies {
+		if _, ok := m.OptionalDependencies[name]; !ok {
+			regular[name] = req
+		}
+	}
+	add(regular, dep.NewType())
+	add(m.DevDependencies, dep.NewType(dep.Dev))
+	add(m.OptionalDependencies, dep.NewType(dep.Opt))
+	for name, req := range m.PeerDependencies {
+		b := dep.NewBuilder().Scope(dep.ScopePeer)
+		if m.PeerDependenciesMeta[name].Optional {
+			b = b.Opt()
+		}
+		reqs = append(reqs, requirement(name, req, b.Type()))
+	}
+	// The resolver expects bundleDependencies to be present as regular
+	// dependencies with a "*" version specifier.
+	for _, name := range m.BundleDependencies {
+		reqs = append(reqs, requirement(name, "*", dep.NewBuilder().Scope(dep.ScopeBundle).Type()))
+	}
+	resolve.SortDependencies(reqs)
+	return reqs
+}
+
+// requirement returns the requirement on the given dependency, replacing
+// an alias with the actual package.
+func requirement(name, req string, t dep.Type) resolve.RequirementVersion {
+	if s := spec.Parse(name, req); s.Kind == spec.Alias {
+		t.AddAttr(dep.KnownAs, name)
+		name, req = s.Name, s.Spec
+	}
+	return resolve.RequirementVersion{
+		VersionKey: resolve.VersionKey{
+			PackageKey: resolve.PackageKey{
+				System: resolve.NPM,
+				Name:   name,
+			},
+			VersionType: resolve.Requirement,
+			Version:     req,
+		},
+		Type: t,
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+const alicePackageJSON = `{
+  "name": "alice",
+  "version": "1.0.0",
+  "dependencies": {
+    "bob": "^1.0.0",
+    "chuck": "~2.0.0",
+    "dave": "npm:eve@^3.0.0",
+    "frank": "^4.0.0"
+  },
+  "devDependencies": {
+    "grace": "^5.0.0"
+  },
+  "peerDependencies": {
+    "heidi": ">=6",
+    "ivan": "*"
+  },
+  "peerDependenciesMeta": {
+    "ivan": {"optional": true}
+  },
+  "optionalDependencies": {
+    "frank": "^4.1.0"
+  },
+  "bundleDependencies": ["bob"],
+  "overrides": {
+    "bob": {
+      ".": "1.2.0",
+      "chuck": "$chuck"
+    },
+    "judy@2": "2.1.0"
+  },
+  "workspaces": {"packages": ["packages/*"]},
+  "exports": {
+    "import": "./index.mjs",
+    "require": "./index.cjs"
+  }
+}`
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(alicePackageJSON))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got, want := m.VersionKey(), (resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	}); got != want {
+		t.Errorf("VersionKey: got %v, want %v", got, want)
+	}
+	if diff := cmp.Diff([]string{"bob"}, m.BundleDependencies); diff != "" {
+		t.Errorf("bundle dependencies (-want +got):\n%s", diff)
+	}
+	wantOverrides := []Override{
+		{Path: []string{"bob"}, Requirement: "1.2.0"},
+		{Path: []string{"bob", "chuck"}, Requirement: "~2.0.0"},
+		{Path: []string{"judy@2"}, Requirement: "2.1.0"},
+	}
+	if diff := cmp.Diff(wantOverrides, m.Overrides); diff != "" {
+		t.Errorf("overrides (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"packages/*"}, m.Workspaces); diff != "" {
+		t.Errorf("workspaces (-want +got):\n%s", diff)
+	}
+	wantExports := map[string]any{
+		".": map[string]any{
+			"import":  "./index.mjs",
+			"require": "./index.cjs",
+		},
+	}
+	if diff := cmp.Diff(wantExports, m.Exports); diff != "" {
+		t.Errorf("exports (-want +got):\n%s", diff)
+	}
+
+	req := func(name, version string, t dep.Type) resolve.RequirementVersion {
+		return resolve.RequirementVersion{
+			VersionKey: resolve.VersionKey{
+				PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: name},
+				VersionType: resolve.Requirement,
+				Version:     version,
+			},
+			Type: t,
+		}
+	}
+	want := []resolve.RequirementVersion{
+		req("bob", "^1.0.0", dep.NewType()),
+		req("bob", "*", dep.NewBuilder().Scope(dep.ScopeBundle).Type()),
+		req("chuck", "~2.0.0", dep.NewType()),
+		req("eve", "^3.0.0", dep.NewBuilder().KnownAs("dave").Type()),
+		req("frank", "^4.1.0", dep.NewType(dep.Opt)),
+		req("grace", "^5.0.0", dep.NewType(dep.Dev)),
+		req("heidi", ">=6", dep.NewBuilder().Scope(dep.ScopePeer).Type()),
+		req("ivan", "*", dep.NewBuilder().Opt().Scope(dep.ScopePeer).Type()),
+	}
+	resolve.SortDependencies(want)
+	if diff := cmp.Diff(want, m.Requirements()); diff != "" {
+		t.Errorf("Requirements (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseForms(t *testing.T) {
+	for _, test := range []struct {
+		json       string
+		bundle     []string
+		workspaces []string
+		exports    map[string]any
+	}{{
+		json:   `{"dependencies": {"b": "1", "a": "2"}, "bundledDependencies": true}`,
+		bundle: []string{"a", "b"},
+	}, {
+		json:       `{"workspaces": ["a", "b/*"]}`,
+		workspaces: []string{"a", "b/*"},
+	}, {
+		json:    `{"exports": "./index.js"}`,
+		exports: map[string]any{".": "./index.js"},
+	}, {
+		json:    `{"exports": {".": "./index.js", "./sub": ["./sub.js"]}}`,
+		exports: map[string]any{".": "./index.js", "./sub": []any{"./sub.js"}},
+	}} {
+		m, err := Parse([]byte(test.json))
+		if err != nil {
+			t.Errorf("Parse(%s): %v", test.json, err)
+			continue
+		}
+		if diff := cmp.Diff(test.bundle, m.BundleDependencies); diff != "" {
+			t.Errorf("Parse(%s) bundle (-want +got):\n%s", test.json, diff)
+		}
+		if diff := cmp.Diff(test.workspaces, m.Workspaces); diff != "" {
+			t.Errorf("Parse(%s) workspaces (-want +got):\n%s", test.json, diff)
+		}
+		if diff := cmp.Diff(test.exports, m.Exports); diff != "" {
+			t.Errorf("Parse(%s) exports (-want +got):\n%s", test.json, diff)
+		}
+	}
+
+	for _, bad := range []string{
+		`{"dependencies": []}`,
+		`{"bundleDependencies": "a"}`,
+		`{"overrides": {"a": 1}}`,
+		`{"exports": {".": "./index.js", "import": "./index.mjs"}}`,
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%s): got no error", bad)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	data := []byte(`{
+  "name": "app",
+  "scripts": {"dependencies": "not a dependency"},
+  "dependencies": {
+    "a": "^1.0.0",
+    "b": "npm:c@^2.0.0"
+  },
+  "devDependencies": {"a": "^1.0.0", "d": "2"},
+  "bundleDependencies": ["a"]
+}`)
+	ps, err := Positions("web/package.json", data)
+	if err != nil {
+		t.Fatalf("Positions: %v", err)
+	}
+	m, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	got := make(map[string][]string)
+	for _, r := range m.Requirements() {
+		k := r.Name + "@" + r.Version
+		if len(got[k]) > 0 {
+			continue
+		}
+		for _, p := range ps[r.VersionKey] {
+			got[k] = append(got[k], p.String())
+		}
+	}
+	want := map[string][]string{
+		"a@^1.0.0": {"web/package.json:5:5", "web/package.json:8:23"},
+		"c@^2.0.0": {"web/package.json:6:5"},
+		"d@2":      {"web/package.json:8:38"},
+		"a@*":      {"web/package.json:9:26"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Positions (-want +got):\n%s", diff)
+	}
+
+	if _, err := Positions("package.json", []byte(`{"dependencies": {"a": `)); err == nil {
+		t.Errorf("Positions of truncated package.json: got no error")
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+// dependencyFields holds the fields of a package.json mapping dependencies
+// to their requirements.
+var dependencyFields = map[string]bool{
+	"dependencies":         true,
+	"devDependencies":      true,
+	"peerDependencies":     true,
+	"optionalDependencies": true,
+}
+
+// Positions returns the positions in the given package.json, read from
+// the named file, of the declarations of its dependencies, keyed by the
+// VersionKeys of the requirements returned by Requirements. The position
+// of a dependency is that of its name. A dependency declared in several
+// fields has a position for each, in the order of the file. Bundled
+// dependencies listed by name are found under their "*" requirement.
+func Positions(filename string, data []byte) (resolve.Positions, error) {
+	ps := make(resolve.Positions)
+	d := json.NewDecoder(bytes.NewReader(data))
+	if err := expectDelim(d, '{'); err != nil {
+		return nil, err
+	}
+	for d.More() {
+		field, _, err := key(d, data)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case dependencyFields[field]:
+			err = objectPositions(d, data, func(name, req string, off int) {
+				ps.Add(requirement(name, req, dep.NewType()).VersionKey, position(filename, data, off))
+			})
+		case field == "bundleDependencies" || field == "bundledDependencies":
+			err = bundlePositions(d, data, func(name string, off int) {
+				ps.Add(requirement(name, "*", dep.NewType()).VersionKey, position(filename, data, off))
+			})
+		default:
+			err = skip(d)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", field, err)
+		}
+	}
+	return ps, nil
+}
+
+func position(filename string, data []byte, off int) resolve.Position {
+	line, col := resolve.LineColumn(data, off)
+	return resolve.Position{File: filename, Line: line, Column: col}
+}
+
+// key reads the key of an object member, returning it with the offset of
+// its opening quote.
+func key(d *json.Decoder, data []byte) (string, int, error) {
+	off := int(d.InputOffset())
+	if i := bytes.IndexByte(data[off:], '"'); i >= 0 {
+		off += i
+	}
+	tok, err := d.Token()
+	if err != nil {
+		return "", 0, err
+	}
+	k, ok := tok.(string)
+	if !ok {
+		return "", 0, fmt.Errorf("unexpected %v", tok)
+	}
+	return k, off, nil
+}
+
+// objectPositions reads an object mapping names to requirements, calling
+// f with each of them and the offset of its name.
+func objectPositions(d *json.Decoder, data []byte, f func(name, req string, off int)) error {
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("unexpected %v", tok)
+	}
+	for d.More() {
+		name, off, err := key(d, data)
+		if err != nil {
+			return err
+		}
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		req, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("unexpected %v for %s", tok, name)
+		}
+		f(name, req, off)
+	}
+	_, err = d.Token()
+	return err
+}
+
+// bundlePositions reads the bundleDependencies field, calling f with each
+// of the names it lists and their offsets. The value true, bundling all
+// the dependencies, declares no name.
+func bundlePositions(d *json.Decoder, data []byte, f func(name string, off int)) error {
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return skipRest(d, tok)
+	}
+	for d.More() {
+		off := int(d.InputOffset())
+		if i := bytes.IndexByte(data[off:], '"'); i >= 0 {
+			off += i
+		}
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		if name, ok := tok.(string); ok {
+			f(name, off)
+			continue
+		}
+		if err := skipRest(d, tok); err != nil {
+			return err
+		}
+	}
+	_, err = d.Token()
+	return err
+}
+
+// skip skips the next value.
+func skip(d *json.Decoder) error {
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	return skipRest(d, tok)
+}
+
+// skipRest skips the rest of the value starting with tok.
+func skipRest(d *json.Decoder, tok json.Token) error {
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	tok, err := d.Token()
+	if err != nil {
+		return fmt.Errorf("failed to parse package.json: %w", err)
+	}
+	if tok != delim {
+		return fmt.Errorf("failed to parse package.json: unexpected %v", tok)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"context"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+)
+
+// A resolution asks the client for the same versions many times: a popular
+// package is reached from many places of the tree, and each of its nodes,
+// including those copied in bundles, needs its requirements and their
+// matching versions. The memo below answers the repeated calls of a single
+// resolution, and the arena allocates its tree nodes in blocks.
+
+// memoClient is a resolve.Client remembering the answers of another client
+// for the duration of a resolution. The slices it returns are shared and
+// must not be modified.
+type memoClient struct {
+	resolve.Client
+	versions     map[resolve.VersionKey]versionResult
+	requirements map[resolve.VersionKey]requirementsResult
+	matching     map[resolve.VersionKey]versionsResult
+	// imports holds the regular imports of the versions, as returned by
+	// resolver.regularImports, and the types of the edges that select a
+	// new node for them.
+	imports map[resolve.VersionKey]imports
+}
+
+type versionResult struct {
+	v   resolve.Version
+	err error
+}
+
+type requirementsResult struct {
+	reqs []resolve.RequirementVersion
+	err  error
+}
+
+type versionsResult struct {
+	vs  []resolve.Version
+	err error
+}
+
+// imports holds the regular imports of a version. selected[i] is the type
+// of ideps[i] with the Selector attribute added, shared by all the edges
+// that pick a version for it.
+type imports struct {
+	ideps    []resolve.RequirementVersion
+	selected []dep.Type
+}
+
+func newMemoClient(c resolve.Client) *memoClient {
+	return &memoClient{
+		Client:       c,
+		versions:     make(map[resolve.VersionKey]versionResult),
+		requirements: make(map[resolve.VersionKey]requirementsResult),
+		matching:     make(map[resolve.VersionKey]versionsResult),
+		imports:      make(map[resolve.VersionKey]imports),
+	}
+}
+
+func (c *memoClient) Version(ctx context.Context, vk resolve.VersionKey) (resolve.Version, error) {
+	if r, ok := c.versions[vk]; ok {
+		return r.v, r.err
+	}
+	v, err := c.Client.Version(ctx, vk)
+	if ctx.Err() == nil {
+		c.versions[vk] = versionResult{v, err}
+	}
+	return v, err
+}
+
+func (c *memoClient) Requirements(ctx context.Context, vk resolve.VersionKey) ([]resolve.RequirementVersion, error) {
+	if r, ok := c.requirements[vk]; ok {
+		return r.reqs, r.err
+	}
+	reqs, err := c.Client.Requirements(ctx, vk)
+	if ctx.Err() == nil {
+		c.requirements[vk] = requirementsResult{reqs, err}
+	}
+	return reqs, err
+}
+
+func (c *memoClient) MatchingVersions(ctx context.Context, vk resolve.VersionKey) ([]resolve.Version, error) {
+	if r, ok := c.matching[vk]; ok {
+		return r.vs, r.err
+	}
+	vs, err := c.Client.MatchingVersions(ctx, vk)
+	if ctx.Err() == nil {
+		c.matching[vk] = versionsResult{vs, err}
+	}
+	return vs, err
+}
+
+// nodeArena allocates tree nodes in blocks, as the nodes of a tree are
+// all released together. Blocks double in size, from a few nodes for the
+// many small trees up to maxArenaBlock for the large ones.
+type nodeArena struct {
+	block []treeNode
+	size  int
+}
+
+const (
+	minArenaBlock = 8
+	maxArenaBlock = 512
+)
+
+func (a *nodeArena) new() *treeNode {
+	if len(a.block) == 0 {
+		a.size = min(max(2*a.size, minArenaBlock), maxArenaBlock)
+		a.block = make([]treeNode, a.size)
+	}
+	n := &a.block[0]
+	a.block = a.block[1:]
+	return n
+}
@@ -22,19 +22,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"sort"
 	"strings"
 	"time"
 
 	"deps.dev/util/resolve"
 	"deps.dev/util/resolve/dep"
-	"deps.dev/util/resolve/version"
-	"deps.dev/util/semver"
-)
-
-const (
-	debug = false
+	"deps.dev/util/resolve/npm/spec"
 )
 
 // resolver implements resolve.Resolver for NPM.
@@ -59,12 +53,32 @@ const (
 //     of the root). Put the created node at the end of the processing queue.
 type resolver struct {
 	client resolve.Client
+	opts   resolve.Options
+	// memo and nodes are only set for the duration of a resolution, on a
+	// copy of the resolver returned by session.
+	memo  *memoClient
+	nodes *nodeArena
+}
+
+// session returns a copy of the resolver for a single resolution,
+// remembering the answers of the client and allocating the tree from an
+// arena.
+func (r *resolver) session() *resolver {
+	memo := newMemoClient(r.client)
+	return &resolver{client: memo, opts: r.opts, memo: memo, nodes: &nodeArena{}}
 }
 
 // NewResolver creates a Resolver connected to the given client.
 // It is safe for concurrent use.
-func NewResolver(client resolve.Client) resolve.Resolver {
-	return &resolver{client: client}
+func NewResolver(client resolve.Client, opts ...resolve.Option) resolve.Resolver {
+	o := resolve.NewOptions(opts...)
+	if !o.AsOf.IsZero() {
+		client = resolve.AsOfClient(client, o.AsOf)
+	}
+	if o.Tracer != nil {
+		client = resolve.TraceClient(client, o.Tracer)
+	}
+	return &resolver{client: client, opts: o}
 }
 
 // treeNode is a node in the resolution tree.
@@ -83,18 +97,24 @@ type treeNode struct {
 	pkg resolve.PackageKey
 	// ideps are the imported dependencies of the version.
 	ideps []resolve.RequirementVersion
+	// selected holds the types of the edges selecting a new node for
+	// each of the ideps. The slices are shared between the nodes of a
+	// version.
+	selected []dep.Type
 	// parent is the node's parent in the tree.
 	parent *treeNode
 	// children are the tree nodes children of the node. The version keys are
 	// concrete, such that children[k].vk = k. Note that the nodes are not
 	// the resolution of the direct dependency of the node's version.
 	// In nodejs, that would be the direct content of the node_modules folder.
+	// It is allocated on the first insertion, as most nodes have none.
 	children map[resolve.PackageKey]*treeNode
 	// alias are the tree nodes children of the node. This happens when a node
 	// is installed as an alias, and not using its package name.
 	alias map[string]*treeNode
 	// protected are slots that cannot be used so they don't shadow an
-	// installation that has been placed higher in the tree.
+	// installation that has been placed higher in the tree. It is allocated
+	// on the first insertion.
 	protected map[resolve.PackageKey]bool
 	// aliasProtected is similar to protected, but keyed by alias.
 	aliasProtected map[string]bool
@@ -106,6 +126,9 @@ type treeNode struct {
 	// bundled holds the bundled version when the node is bundled. This is
 	// where we find the mangled version and information on the origin.
 	bundled *bundledVersion
+	// depth is the distance in the resolve.Graph between the root and the
+	// node, following the edge that created it.
+	depth int
 }
 
 // bundledVersion represents a bundled version.
@@ -125,10 +148,24 @@ type bundledVersion struct {
 	derivedFromPackage resolve.PackageKey
 }
 
+// ResolveRequirements resolves the given requirements, such as those of a
+// local package.json, as the direct dependencies of resolve.ManifestRoot. It
+// implements resolve.RequirementsResolver.
+func (r *resolver) ResolveRequirements(ctx context.Context, sys resolve.System, reqs []resolve.RequirementVersion) (*resolve.Graph, error) {
+	if sys != resolve.NPM {
+		return nil, fmt.Errorf("expected %s system, got %s", resolve.NPM, sys)
+	}
+	root := resolve.Version{VersionKey: resolve.ManifestRoot(sys)}
+	rr := &resolver{client: resolve.RootClient(r.client, root, reqs), opts: r.opts}
+	return rr.Resolve(ctx, root.VersionKey)
+}
+
 // Resolve resolves the transitive dependencies of the given NPM concrete version.
 // It returns an error if the version is invalid.
 // It internally creates a resolved tree, similar to the one produced by "npm
 // install" as a hierarchy of node_modules folders.
+// If the resolution reaches one of the configured limits, it stops and returns
+// the partial graph along with a *resolve.LimitError.
 func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve.Graph, error) {
 	if vk.System != resolve.NPM {
 		return nil, fmt.Errorf("expected NPM version, got %q", vk)
@@ -138,7 +175,21 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 	}
 
 	start := time.Now()
+	r = r.session()
+	if d := r.opts.MaxDuration; d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
 	g := &resolve.Graph{}
+	limits := r.opts.Limits
+	// partial returns the graph built so far when a limit is reached.
+	partial := func(err error) (*resolve.Graph, error) {
+		g.Error = err.Error()
+		g.Err = err
+		g.Duration = time.Since(start)
+		return g, err
+	}
 
 	v, err := r.client.Version(ctx, vk)
 	if err != nil {
@@ -168,12 +219,15 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 			continue
 		}
 		cur.processed = true
-		if debug {
-			log.Printf("Current %s", r.treeNodeString(cur))
-		}
 		insQueue = insQueue[:0]
 		// BFS in lexicographic order of the requirements.
-		for _, idep := range cur.ideps {
+		for i, idep := range cur.ideps {
+			// Git, tarball, local and workspace dependencies are not
+			// in the registry.
+			if sp := spec.Parse(idep.Name, idep.Version); !sp.Registry() {
+				g.AddNodeError(cur.id, idep.VersionKey, &resolve.UnsupportedRequirement{Requirement: idep.VersionKey, Kind: sp.Kind.String()})
+				continue
+			}
 			dvers, err := r.client.MatchingVersions(ctx, idep.VersionKey)
 			if err != nil {
 				return nil, fmt.Errorf("cannot find matching versions for %s: %w", idep.Version, err)
@@ -184,13 +238,6 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 			if len(dvers) > 0 {
 				wouldPick = dvers[len(dvers)-1]
 			}
-			if debug {
-				if wouldPick.VersionKey == (resolve.VersionKey{}) {
-					log.Printf("%s -> %s (%s), wouldPick: <nil>", r.treeNodeString(cur), idep, idep.Type)
-				} else {
-					log.Printf("%s -> %s (%s), wouldPick: %s", r.treeNodeString(cur), idep.Version, idep.Type, wouldPick)
-				}
-			}
 			// Walk up the tree looking for one of the resolved concrete
 			// versions; if one exists then we don't need to resolve it here.
 			var resolved *treeNode
@@ -212,10 +259,6 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 						}
 					}
 				} else {
-					c, err := semver.NPM.ParseConstraint(idep.Version)
-					if err != nil {
-						return nil, fmt.Errorf("ParseConstraint %s: %w", idep.Version, err)
-					}
 					var cvk resolve.Version
 					if child.ver.VersionKey != (resolve.VersionKey{}) {
 						cvk = child.ver
@@ -224,7 +267,7 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 					} else {
 						return nil, errors.New("unknown child version")
 					}
-					if c.Match(cvk.Version) {
+					if matches(idep.VersionKey, cvk) {
 						resolved = child
 					}
 					break
@@ -242,12 +285,7 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 				}
 				// A bundled version that doesn't exist outside
 				// the bundle.
-				iver := idep.Version
-				c, err := semver.NPM.ParseConstraint(iver)
-				if err != nil {
-					return nil, fmt.Errorf("ParseConstraint %s: %w", iver, err)
-				}
-				if c.Match(child.bundled.derivedFromVersion.Version) {
+				if matches(idep.VersionKey, child.bundled.derivedFromVersion) {
 					resolved = child
 					break
 				}
@@ -255,9 +293,13 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 					// Discard the child as it doesn't match and is at this
 					// level so that it can be replaced at this level by a
 					// matching version.
-					if debug {
-						log.Printf("delete bundled child, %s does not match %s", r.treeNodeString(child), iver)
-					}
+					r.opts.Trace(resolve.Event{
+						Kind:        resolve.BacktrackEvent,
+						From:        cur.ver.VersionKey,
+						Requirement: idep.VersionKey,
+						Version:     child.bundled.derivedFromVersion.VersionKey,
+						Message:     fmt.Sprintf("bundled %s does not match", r.treeNodeString(child)),
+					})
 					delete(child.parent.children, child.bundled.derivedFromPackage)
 					installHere = true
 				}
@@ -265,13 +307,6 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 				// installed version shadows anything higher up in the tree.
 				break
 			}
-			if debug {
-				if resolved == nil {
-					log.Printf("not resolved")
-				} else {
-					log.Printf("resolved by %v", r.treeNodeString(resolved))
-				}
-			}
 			if resolved != nil {
 				if !resolved.processed {
 					insQueue = append(insQueue, resolved)
@@ -289,27 +324,37 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 						}
 						parent.aliasProtected[alias] = true
 					} else {
-						parent.protected[ipk] = true
+						parent.protect(ipk)
 					}
 					parent = parent.parent
 				}
 				dt := idep.Type
 				if resolved.id == 0 && resolved.parent != nil {
-					resolved.id = g.AddNode(resolved.bundled.Version.VersionKey)
-					if debug {
-						log.Printf("Added node (resolved): %s", g.Nodes[resolved.id].Version)
+					if err := limits.CheckNode(g, cur.depth+1); err != nil {
+						return partial(err)
 					}
-					dt = dt.Clone()
-					dt.AddAttr(dep.Selector, "")
+					resolved.depth = cur.depth + 1
+					resolved.id = g.AddNode(resolved.bundled.Version.VersionKey)
+					resolved.bundled.annotate(g, resolved.id)
+					dt = cur.selected[i]
+				}
+				if err := limits.CheckEdge(g); err != nil {
+					return partial(err)
 				}
 				if err := g.AddEdge(cur.id, resolved.id, idep.Version, dt); err != nil {
 					return nil, err
 				}
+				r.opts.Trace(resolve.Event{
+					Kind:        resolve.PinEvent,
+					From:        cur.ver.VersionKey,
+					Requirement: idep.VersionKey,
+					Version:     g.Nodes[resolved.id].Version,
+				})
 				continue
 			}
 			// No matching concrete version for the requirement.
 			if wouldPick.VersionKey == (resolve.VersionKey{}) {
-				g.AddError(cur.id, idep.VersionKey, fmt.Sprintf("could not find a version that satisfies requirement %s for package %s", idep.Version, idep.Name))
+				g.AddNodeError(cur.id, idep.VersionKey, &resolve.UnsatisfiedRequirement{Requirement: idep.VersionKey})
 				continue
 			}
 
@@ -318,18 +363,7 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 			// for it, and place it as high as possible in the tree (except if
 			// this is the replacement of a mismatched bundled version, in which
 			// case install at this level).
-			latest := r.concreteForLatest(ctx, wouldPick)
-			for i := len(dvers) - 1; i >= 0; i-- {
-				v := dvers[i]
-				if v.Equal(latest) {
-					wouldPick = v
-					break
-				}
-				if !v.HasAttr(version.Blocked) {
-					wouldPick = v
-					break
-				}
-			}
+			wouldPick = r.pick(ctx, dvers)
 			node, err := r.newTreeNode(ctx, wouldPick)
 			if err != nil {
 				return nil, fmt.Errorf("cannot create tree node: %w", err)
@@ -342,11 +376,22 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 			// Find parent for the new node.
 			parent := cur
 			if c, _ := r.candidate(parent, node.pkg, alias); c != nil {
-				err := g.AddError(cur.id, idep.VersionKey,
-					fmt.Sprintf("cannot install two versions of this package at the same level: %v (%s)", node.pkg, alias))
-				if err != nil {
+				msg := fmt.Sprintf("cannot install two versions of this package at the same level: %v (%s)", node.pkg, alias)
+				if err := g.AddNodeError(cur.id, idep.VersionKey, &resolve.ResolutionImpossible{
+					Requirement: idep.VersionKey,
+					Version:     node.ver.VersionKey,
+					Conflict:    c.ver.VersionKey,
+					Message:     msg,
+				}); err != nil {
 					return nil, err
 				}
+				r.opts.Trace(resolve.Event{
+					Kind:        resolve.ConflictEvent,
+					From:        cur.ver.VersionKey,
+					Requirement: idep.VersionKey,
+					Version:     c.ver.VersionKey,
+					Message:     msg,
+				})
 				continue
 			}
 			for !installHere && parent.parent != nil {
@@ -356,7 +401,7 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 				if r.protected(parent.parent, node.pkg, alias) {
 					break
 				}
-				parent.protected[node.pkg] = true
+				parent.protect(node.pkg)
 				parent = parent.parent
 			}
 			// If the parent and the installed version are from the same
@@ -368,15 +413,32 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 			if parent.parent != nil && parent.pkg == node.pkg {
 				cvk := node.ver
 				pvk := parent.ver
-				err := g.AddError(cur.id, idep.VersionKey,
-					fmt.Sprintf("unreachable version %s %s installed under %s %s", cvk.Name, cvk.Version, pvk.Name, pvk.Version))
-				if err != nil {
+				msg := fmt.Sprintf("unreachable version %s %s installed under %s %s", cvk.Name, cvk.Version, pvk.Name, pvk.Version)
+				if err := g.AddNodeError(cur.id, idep.VersionKey, &resolve.ResolutionImpossible{
+					Requirement: idep.VersionKey,
+					Version:     cvk.VersionKey,
+					Conflict:    pvk.VersionKey,
+					Message:     msg,
+				}); err != nil {
 					return nil, err
 				}
+				r.opts.Trace(resolve.Event{
+					Kind:        resolve.ConflictEvent,
+					From:        cur.ver.VersionKey,
+					Requirement: idep.VersionKey,
+					Version:     pvk.VersionKey,
+					Message:     msg,
+				})
 				continue
 			}
+			if err := limits.CheckNode(g, cur.depth+1); err != nil {
+				return partial(err)
+			}
+			if err := limits.CheckEdge(g); err != nil {
+				return partial(err)
+			}
 			if alias == "" {
-				parent.children[node.pkg] = node
+				parent.addChild(node.pkg, node)
 			} else {
 				if parent.alias == nil {
 					parent.alias = make(map[string]*treeNode)
@@ -384,16 +446,18 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 				parent.alias[alias] = node
 			}
 			node.parent = parent
+			node.depth = cur.depth + 1
 			insQueue = append(insQueue, node)
 			node.id = g.AddNode(node.ver.VersionKey)
-			if debug {
-				log.Printf("Added node (regular): %s", g.Nodes[node.id].Version)
-			}
-			dt := idep.Type.Clone()
-			dt.AddAttr(dep.Selector, "")
-			if err := g.AddEdge(cur.id, node.id, idep.Version, dt); err != nil {
+			if err := g.AddEdge(cur.id, node.id, idep.Version, cur.selected[i]); err != nil {
 				return nil, err
 			}
+			r.opts.Trace(resolve.Event{
+				Kind:        resolve.PinEvent,
+				From:        cur.ver.VersionKey,
+				Requirement: idep.VersionKey,
+				Version:     node.ver.VersionKey,
+			})
 		}
 		// Reverse the insertion queue, to have a DFS in the transitive
 		// resolution.
@@ -406,25 +470,31 @@ func (r *resolver) Resolve(ctx context.Context, vk resolve.VersionKey) (*resolve
 	// extraneous version from a bundle and must be reported as an error.
 	queue = queue[:0]
 	queue = append(queue, root)
-	var errs []string
+	var errs []*resolve.ResolutionImpossible
 	for len(queue) > 0 {
 		cur := queue[len(queue)-1]
 		queue = queue[:len(queue)-1]
 		if cur.id == 0 && cur.parent != nil {
 			bvk := cur.bundled.derivedFromVersion
-			errs = append(errs, fmt.Sprintf("unused bundled version %s %s", bvk.Name, bvk.Version))
+			errs = append(errs, &resolve.ResolutionImpossible{
+				Version: bvk.VersionKey,
+				Message: fmt.Sprintf("unused bundled version %s %s", bvk.Name, bvk.Version),
+			})
 			continue
 		}
 		for _, c := range cur.children {
 			queue = append(queue, c)
 		}
 	}
-	sort.Strings(errs)
-	g.Error = strings.Join(errs, ",")
-
-	if debug {
-		log.Print(r.treeString(root, "", ""))
-		log.Print(g.String())
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Message < errs[j].Message })
+		msgs := make([]string, len(errs))
+		joined := make([]error, len(errs))
+		for i, e := range errs {
+			msgs[i], joined[i] = e.Message, e
+		}
+		g.Error = strings.Join(msgs, ",")
+		g.Err = errors.Join(joined...)
 	}
 
 	g.Duration = time.Since(start)
@@ -469,24 +539,56 @@ func (r *resolver) protected(cur *treeNode, ipk resolve.PackageKey, alias string
 
 // newTreeNode creates a new treeNode holding the given version key.
 func (r *resolver) newTreeNode(ctx context.Context, ver resolve.Version) (*treeNode, error) {
-	n := &treeNode{
-		ver:       ver,
-		pkg:       ver.PackageKey,
-		children:  map[resolve.PackageKey]*treeNode{},
-		protected: map[resolve.PackageKey]bool{},
+	imps, err := r.imports(ctx, ver)
+	if err != nil {
+		return nil, err
+	}
+	n := r.nodes.new()
+	n.ver = ver
+	n.pkg = ver.PackageKey
+	n.ideps = imps.ideps
+	n.selected = imps.selected
+	return n, nil
+}
+
+// imports returns the regular imports of the given version, remembered for
+// the duration of the resolution.
+func (r *resolver) imports(ctx context.Context, ver resolve.Version) (imports, error) {
+	if imps, ok := r.memo.imports[ver.VersionKey]; ok {
+		return imps, nil
 	}
 	reqs, err := r.client.Requirements(ctx, ver.VersionKey)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get Requirements for %s: %w", ver, err)
+		return imports{}, fmt.Errorf("cannot get Requirements for %s: %w", ver, err)
 	}
-	n.ideps, err = r.regularImports(ctx, ver.VersionKey, reqs)
+	var imps imports
+	imps.ideps, err = r.regularImports(ctx, ver.VersionKey, reqs)
 	if err != nil {
-		return nil, fmt.Errorf("cannot process regularImports for %s: %w", ver, err)
+		return imports{}, fmt.Errorf("cannot process regularImports for %s: %w", ver, err)
 	}
-	if debug {
-		log.Printf("newTreeNode %p for %s: ideps: %v", n, ver, n.ideps)
+	imps.selected = make([]dep.Type, len(imps.ideps))
+	for i, d := range imps.ideps {
+		imps.selected[i] = d.Type.Clone()
+		imps.selected[i].AddAttr(dep.Selector, "")
 	}
-	return n, nil
+	r.memo.imports[ver.VersionKey] = imps
+	return imps, nil
+}
+
+// addChild installs the given node as the child of n for its package.
+func (n *treeNode) addChild(pk resolve.PackageKey, child *treeNode) {
+	if n.children == nil {
+		n.children = make(map[resolve.PackageKey]*treeNode)
+	}
+	n.children[pk] = child
+}
+
+// protect marks the slot of the given package as protected.
+func (n *treeNode) protect(pk resolve.PackageKey) {
+	if n.protected == nil {
+		n.protected = make(map[resolve.PackageKey]bool)
+	}
+	n.protected[pk] = true
 }
 
 // regularImports returns the regular imports contained in the given imports.
@@ -499,9 +601,6 @@ func (r *resolver) regularImports(ctx context.Context, ver resolve.VersionKey, i
 	)
 
 	for _, d := range imps {
-		if debug {
-			log.Printf("%s regularImports %s %s", ver, d.Version, d.Type)
-		}
 		// Dependencies that are both Dev and Opt behave like Opt.
 		if d.Type.HasAttr(dep.Dev) {
 			continue
@@ -536,11 +635,11 @@ func (r *resolver) regularImports(ctx context.Context, ver resolve.VersionKey, i
 		// as regular only if it is not also present in the regular
 		// dependencies.
 		switch scope, _ := d.Type.GetAttr(dep.Scope); scope {
-		case "bundle":
+		case dep.ScopeBundle:
 			if regPackage[d.Name] {
 				continue
 			}
-		case "peer":
+		case dep.ScopePeer:
 			continue
 		}
 
@@ -550,6 +649,27 @@ func (r *resolver) regularImports(ctx context.Context, ver resolve.VersionKey, i
 	return deps, nil
 }
 
+// matches reports whether the version satisfies the requirement, which may
+// be a range, an exact version or a tag.
+func matches(req resolve.VersionKey, v resolve.Version) bool {
+	return len(resolve.MatchRequirement(req, []resolve.Version{v})) > 0
+}
+
+// pick returns the version to install among the given matching versions,
+// which must not be empty: the highest one that is not blocked, unless the
+// version tagged latest comes first.
+func (r *resolver) pick(ctx context.Context, dvers []resolve.Version) resolve.Version {
+	wouldPick := dvers[len(dvers)-1]
+	latest := r.concreteForLatest(ctx, wouldPick)
+	for i := len(dvers) - 1; i >= 0; i-- {
+		v := dvers[i]
+		if v.Equal(latest) || !v.Blocked() {
+			return v
+		}
+	}
+	return wouldPick
+}
+
 // concreteForLatest returns the concrete version pointed by "latest", if it
 // exists. It returns the zero version otherwise.
 func (r *resolver) concreteForLatest(ctx context.Context, v resolve.Version) resolve.Version {
@@ -566,10 +686,6 @@ func (r *resolver) concreteForLatest(ctx context.Context, v resolve.Version) res
 // injectDerivedFrom injects recursively the bundle content of the given version
 // inside the given tree.
 func (r *resolver) injectDerivedFrom(ctx context.Context, node *treeNode, v resolve.Version) error {
-	if debug {
-		log.Printf("--> injectDerivedFrom for %s", r.treeNodeString(node))
-		defer log.Printf("<-- injectDerivedFrom for %s", r.treeNodeString(node))
-	}
 	bvs, err := r.directBundleContent(ctx, v)
 	if err != nil {
 		return fmt.Errorf("cannot get bundled content: %w", err)
@@ -584,7 +700,7 @@ func (r *resolver) injectDerivedFrom(ctx context.Context, node *treeNode, v reso
 		cn.ver = bv.derivedFromVersion
 		cn.pkg = bv.derivedFromPackage
 		if bv.alias == "" {
-			node.children[cn.pkg] = cn
+			node.addChild(cn.pkg, cn)
 		} else {
 			if node.alias == nil {
 				node.alias = make(map[string]*treeNode)
@@ -620,6 +736,13 @@ func (r *resolver) directBundleContent(ctx context.Context, v resolve.Version) (
 	return bvs, nil
 }
 
+// annotate records on the given node of the graph, which is that of the
+// bundled version, where it comes from.
+func (bv *bundledVersion) annotate(g *resolve.Graph, id resolve.NodeID) {
+	g.Nodes[id].BundledFrom = bv.derivedFromVersion.VersionKey
+	g.Nodes[id].Alias = bv.alias
+}
+
 // getBundledVersion maps the mangled concrete version pointed by the given
 // requirement to its origin concrete version, non mangled.
 // When the given requirement is not mangled, getBundledVersion returns nil.
@@ -635,7 +758,7 @@ func (r *resolver) getBundledVersion(ctx context.Context, d resolve.RequirementV
 		return nil, nil
 	}
 	v := vs[0]
-	name, ok := v.GetAttr(version.DerivedFrom)
+	name, ok := v.DerivedFrom()
 	if !ok {
 		return nil, nil
 	}
@@ -656,7 +779,7 @@ func (r *resolver) getBundledVersion(ctx context.Context, d resolve.RequirementV
 	return bv, nil
 }
 
-// treeNodeString returns a string describing the node, for debug purposes.
+// treeNodeString returns a string describing the node, for tracing purposes.
 func (r *resolver) treeNodeString(n *treeNode, withDeps ...bool) string {
 	var s []string
 	if n.ver.VersionKey != (resolve.VersionKey{}) {
@@ -673,12 +796,3 @@ func (r *resolver) treeNodeString(n *treeNode, withDeps ...bool) string {
 	}
 	return strings.Join(s, " ")
 }
-
-// treeString prints the installation tree, for debug purposes.
-func (r *resolver) treeString(node *treeNode, prefix string, pkg string) string {
-	s := fmt.Sprintf("%s[%s] %s\n", prefix, pkg, r.treeNodeString(node))
-	for p, c := range node.children {
-		s += r.treeString(c, prefix+"    ", p.Name)
-	}
-	return s
-}
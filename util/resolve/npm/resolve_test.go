@@ -16,13 +16,18 @@ package npm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
 	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
 	"deps.dev/util/resolve/internal/resolvetest"
+	"deps.dev/util/resolve/schema"
 	"deps.dev/util/resolve/version"
 )
 
@@ -63,6 +68,372 @@ func TestResolver(t *testing.T) {
 	}
 }
 
+func TestResolverTracer(t *testing.T) {
+	s, err := schema.New(`
+alice
+	1.0.0
+		bob@^1.0.0
+		chuck@^1.0.0
+bob
+	1.0.0
+		dave@^1.0.0
+chuck
+	1.0.0
+		dave@^1.0.0
+dave
+	1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		pins  []string
+		calls int
+	)
+	tracer := resolve.TracerFunc(func(e resolve.Event) {
+		switch e.Kind {
+		case resolve.ClientCallEvent:
+			calls++
+		case resolve.PinEvent:
+			pins = append(pins, fmt.Sprintf("%s -> %s@%s %s", e.From.Name, e.Requirement.Name, e.Requirement.Version, e.Version.Version))
+		}
+	})
+	r := NewResolver(s.NewClient(), resolve.WithTracer(tracer))
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	}
+	if _, err := r.Resolve(context.Background(), vk); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"alice -> bob@^1.0.0 1.0.0",
+		"alice -> chuck@^1.0.0 1.0.0",
+		"bob -> dave@^1.0.0 1.0.0",
+		"chuck -> dave@^1.0.0 1.0.0",
+	}
+	if diff := cmp.Diff(want, pins); diff != "" {
+		t.Errorf("pins (-want +got):\n%s", diff)
+	}
+	if calls == 0 {
+		t.Errorf("got no client call event")
+	}
+}
+
+func TestResolverErrors(t *testing.T) {
+	s, err := schema.New(`
+alice
+	1.0.0
+		bob@^2.0.0
+bob
+	1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	}
+	g, err := NewResolver(s.NewClient()).Resolve(context.Background(), vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Nodes[0].Errors) != 1 {
+		t.Fatalf("got errors %v, want one", g.Nodes[0].Errors)
+	}
+	ne := g.Nodes[0].Errors[0]
+	var ur *resolve.UnsatisfiedRequirement
+	if !errors.As(ne.Err, &ur) {
+		t.Fatalf("got error %v, want *UnsatisfiedRequirement", ne.Err)
+	}
+	if ur.Requirement != ne.Req || ur.Requirement.Version != "^2.0.0" {
+		t.Errorf("got requirement %v, want bob@^2.0.0", ur.Requirement)
+	}
+	if ne.Error != ur.Error() {
+		t.Errorf("got message %q, want %q", ne.Error, ur.Error())
+	}
+}
+
+func TestResolverUnsupported(t *testing.T) {
+	vk := func(name, v string, vt resolve.VersionType) resolve.VersionKey {
+		return resolve.VersionKey{
+			PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: name},
+			VersionType: vt,
+			Version:     v,
+		}
+	}
+	c := resolve.NewLocalClient()
+	c.AddVersion(resolve.Version{VersionKey: vk("alice", "1.0.0", resolve.Concrete)}, []resolve.RequirementVersion{
+		{VersionKey: vk("bob", "^1.0.0", resolve.Requirement), Type: dep.NewType()},
+		{VersionKey: vk("chuck", "file:../chuck", resolve.Requirement), Type: dep.NewType()},
+		{VersionKey: vk("dave", "github:user/dave#v1", resolve.Requirement), Type: dep.NewType()},
+		{VersionKey: vk("eve", "workspace:*", resolve.Requirement), Type: dep.NewType()},
+	})
+	c.AddVersion(resolve.Version{VersionKey: vk("bob", "1.0.0", resolve.Concrete)}, nil)
+
+	g, err := NewResolver(c).Resolve(context.Background(), vk("alice", "1.0.0", resolve.Concrete))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Nodes) != 2 || g.Nodes[1].Version != vk("bob", "1.0.0", resolve.Concrete) {
+		t.Errorf("got nodes %v, want alice and bob", g.Nodes)
+	}
+	var got []string
+	for _, ne := range g.Nodes[0].Errors {
+		var us *resolve.UnsupportedRequirement
+		if !errors.As(ne.Err, &us) {
+			t.Fatalf("got error %v, want *UnsupportedRequirement", ne.Err)
+		}
+		got = append(got, us.Requirement.Name+" "+us.Kind)
+	}
+	if want := []string{"chuck file", "dave git", "eve workspace"}; !cmp.Equal(got, want) {
+		t.Errorf("got unsupported requirements %v, want %v", got, want)
+	}
+}
+
+func TestResolverAsOf(t *testing.T) {
+	vk := func(name, v string, vt resolve.VersionType) resolve.VersionKey {
+		return resolve.VersionKey{
+			PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: name},
+			VersionType: vt,
+			Version:     v,
+		}
+	}
+	c := resolve.NewLocalClient()
+	c.AddVersion(resolve.Version{VersionKey: vk("alice", "1.0.0", resolve.Concrete)}, []resolve.RequirementVersion{
+		{VersionKey: vk("bob", "^1.0.0", resolve.Requirement), Type: dep.NewType()},
+	})
+	for v, year := range map[string]int{"1.0.0": 2020, "1.1.0": 2023} {
+		bob := resolve.Version{VersionKey: vk("bob", v, resolve.Concrete)}
+		bob.SetCreated(time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC))
+		c.AddVersion(bob, nil)
+	}
+	for _, test := range []struct {
+		opts []resolve.Option
+		want string
+	}{
+		{nil, "1.1.0"},
+		{[]resolve.Option{resolve.WithAsOf(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))}, "1.0.0"},
+	} {
+		g, err := NewResolver(c, test.opts...).Resolve(context.Background(), vk("alice", "1.0.0", resolve.Concrete))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(g.Nodes) != 2 || g.Nodes[1].Version.Version != test.want {
+			t.Errorf("got nodes %v, want bob %s", g.Nodes, test.want)
+		}
+	}
+}
+
+func TestResolveRequirements(t *testing.T) {
+	s, err := schema.New(`
+alice
+	1.0.0
+		bob@^1.0.0
+		dev|chuck@^2.0.0
+bob
+	1.0.0
+		chuck@^1.0.0
+	1.1.0
+		chuck@^1.0.0
+chuck
+	1.0.0
+	2.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := s.NewClient()
+	ctx := context.Background()
+	alice := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	}
+	reqs, err := client.Requirements(ctx, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver(client).(resolve.RequirementsResolver)
+	got, err := r.ResolveRequirements(ctx, resolve.NPM, reqs)
+	if err != nil {
+		t.Fatalf("ResolveRequirements: %v", err)
+	}
+	// The requirements of alice resolve as alice does.
+	want, err := NewResolver(client).Resolve(ctx, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Nodes[0].Version = resolve.ManifestRoot(resolve.NPM)
+	got.Duration, want.Duration = 0, 0
+	if err := got.Canon(); err != nil {
+		t.Fatal(err)
+	}
+	if err := want.Canon(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ResolveRequirements (-want +got):\n%s", diff)
+	}
+
+	if _, err := r.ResolveRequirements(ctx, resolve.Maven, reqs); err == nil {
+		t.Errorf("ResolveRequirements for Maven: got no error")
+	}
+}
+
+func TestResolverBundled(t *testing.T) {
+	s, err := schema.New(`
+alice
+	1.0.0
+		bob@^1.0.0
+		Scope bundle|bob@*
+		alice>1.0.0>bob@1.0.0
+alice>1.0.0>bob
+	1.0.0
+		ATTR: DerivedFrom bob
+		chuck@^1.0.0
+		alice>1.0.0>bob>chuck@1.0.0
+alice>1.0.0>bob>chuck
+	1.0.0
+		ATTR: DerivedFrom chuck
+bob
+	1.0.0
+chuck
+	1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk := func(name string) resolve.VersionKey {
+		return resolve.VersionKey{
+			PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: name},
+			VersionType: resolve.Concrete,
+			Version:     "1.0.0",
+		}
+	}
+	g, err := NewResolver(s.NewClient()).Resolve(context.Background(), vk("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, n := range g.Nodes {
+		b, ok := n.Bundler()
+		if !n.Bundled() || !ok {
+			got[n.Version.Name] = n.Package().Name
+			continue
+		}
+		got[n.Version.Name] = n.Package().Name + " bundled by " + b.Name + " " + b.Version
+	}
+	want := map[string]string{
+		"alice":                 "alice",
+		"alice>1.0.0>bob":       "bob bundled by alice 1.0.0",
+		"alice>1.0.0>bob>chuck": "chuck bundled by alice 1.0.0",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("nodes (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolverDeadline(t *testing.T) {
+	s, err := schema.New(`
+alice
+	1.0.0
+		bob@^1.0.0
+bob
+	1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := s.NewClient()
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	}
+
+	r := NewResolver(client, resolve.WithMaxDuration(time.Nanosecond))
+	if _, err := r.Resolve(context.Background(), vk); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Resolve with MaxDuration: got error %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = NewResolver(client)
+	if _, err := r.Resolve(ctx, vk); !errors.Is(err, context.Canceled) {
+		t.Errorf("Resolve with canceled context: got error %v, want %v", err, context.Canceled)
+	}
+
+	r = NewResolver(client, resolve.WithMaxDuration(time.Minute))
+	if _, err := r.Resolve(context.Background(), vk); err != nil {
+		t.Errorf("Resolve with MaxDuration: %v", err)
+	}
+}
+
+func TestResolverLimits(t *testing.T) {
+	s, err := schema.New(`
+alice
+	1.0.0
+		bob@^1.0.0
+		chuck@^1.0.0
+bob
+	1.0.0
+		dave@^1.0.0
+chuck
+	1.0.0
+		dave@^1.0.0
+dave
+	1.0.0
+		eve@^1.0.0
+eve
+	1.0.0
+`, resolve.NPM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := s.NewClient()
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "alice"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	}
+	for _, c := range []struct {
+		limits    resolve.Limits
+		want      resolve.Limit // zero means no error
+		wantNodes int
+	}{
+		{resolve.Limits{}, 0, 5},
+		{resolve.Limits{MaxNodes: 5, MaxEdges: 5, MaxDepth: 3}, 0, 5},
+		{resolve.Limits{MaxNodes: 3}, resolve.NodeLimit, 3},
+		{resolve.Limits{MaxEdges: 3}, resolve.EdgeLimit, 4},
+		{resolve.Limits{MaxDepth: 2}, resolve.DepthLimit, 4},
+	} {
+		r := NewResolver(client, resolve.WithLimits(c.limits))
+		g, err := r.Resolve(context.Background(), vk)
+		if c.want == 0 {
+			if err != nil {
+				t.Errorf("%+v: Resolve: %v", c.limits, err)
+			}
+		} else {
+			var le *resolve.LimitError
+			if !errors.As(err, &le) || le.Limit != c.want {
+				t.Errorf("%+v: Resolve: got error %v, want %v limit error", c.limits, err, c.want)
+				continue
+			}
+			if g == nil || g.Error == "" {
+				t.Errorf("%+v: Resolve: got no partial graph with error", c.limits)
+				continue
+			}
+		}
+		if got := len(g.Nodes); got != c.wantNodes {
+			t.Errorf("%+v: Resolve: got %d nodes, want %d", c.limits, got, c.wantNodes)
+		}
+	}
+}
+
 // cleanGraph cleans up the given graph so that it is suitable for comparison.
 // If flagErrors is set and the given graph contains errors, returns a simple
 // empty "HAS ERROR" graph.
@@ -86,6 +457,30 @@ func cleanGraph(t *testing.T, c resolve.Client, g *resolve.Graph, flagErrors, fl
 		}
 	}
 
+	// The test data only holds error messages; structured errors are
+	// checked separately.
+	g.Err = nil
+	for i := range g.Nodes {
+		for j := range g.Nodes[i].Errors {
+			g.Nodes[i].Errors[j].Err = nil
+		}
+	}
+
+	// The test data holds no bundle annotations; check that those of the
+	// resolution agree with the client before dropping them.
+	for i, n := range g.Nodes {
+		if c != nil && n.Bundled() {
+			v, err := c.Version(context.Background(), n.Version)
+			if err != nil {
+				t.Fatalf("Version(%s): %v", n.Version, err)
+			}
+			if name, _ := v.GetAttr(version.DerivedFrom); name != n.BundledFrom.Name || n.Version.Version != n.BundledFrom.Version {
+				t.Errorf("%s: bundled from %s, want %s", n.Version, n.BundledFrom, name)
+			}
+		}
+		g.Nodes[i].BundledFrom, g.Nodes[i].Alias = resolve.VersionKey{}, ""
+	}
+
 	if flagDemangle {
 		// Take the derived package version's original name.
 		for i, n := range g.Nodes {
@@ -106,3 +501,72 @@ func cleanGraph(t *testing.T, c resolve.Client, g *resolve.Graph, flagErrors, fl
 
 	g.Duration = 0
 }
+
+func BenchmarkNPMResolver(b *testing.B) {
+	a, err := resolvetest.ParseFiles(resolve.NPM,
+		"testdata/resolve_test.data", "testdata/resolve_test.want",
+		"testdata/derivedfrom_test.data", "testdata/derivedfrom_test.want",
+		"testdata/deleted_test.data", "testdata/deleted_test.want",
+		"testdata/alias.data", "testdata/alias.want",
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// Create the resolvers for the tests outside of the benchmark loop.
+	resolvers := make([]resolve.Resolver, len(a.Test))
+	for i, tst := range a.Test {
+		resolvers[i] = NewResolver(tst.Universe)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i, tst := range a.Test {
+			resolvers[i].Resolve(context.Background(), tst.VK)
+		}
+	}
+}
+
+// BenchmarkNPMResolverLarge resolves a generated universe in which many
+// packages are reached from many places, with conflicting requirements,
+// as with the large trees of popular packages.
+func BenchmarkNPMResolverLarge(b *testing.B) {
+	const packages = 300
+	var sb strings.Builder
+	sb.WriteString("root\n\t1.0.0\n")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&sb, "\t\tp%d@^1.0.0\n", i)
+	}
+	for i := 0; i < packages; i++ {
+		fmt.Fprintf(&sb, "p%d\n", i)
+		for v := 0; v < 5; v++ {
+			fmt.Fprintf(&sb, "\t1.%d.0\n", v)
+			for k := 1; k <= 4; k++ {
+				req := "^1.0.0"
+				if (i+k)%3 == 0 {
+					req = fmt.Sprintf("~1.%d.0", (i+k)%5)
+				}
+				fmt.Fprintf(&sb, "\t\tp%d@%s\n", (i*7+k)%packages, req)
+			}
+		}
+	}
+	s, err := schema.New(sb.String(), resolve.NPM)
+	if err != nil {
+		b.Fatal(err)
+	}
+	r := NewResolver(s.NewClient())
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: "root"},
+		VersionType: resolve.Concrete,
+		Version:     "1.0.0",
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := r.Resolve(context.Background(), vk); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
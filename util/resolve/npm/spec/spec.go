@@ -0,0 +1,171 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package spec classifies the specifiers of npm dependencies, the values of
+the dependency maps of a package.json file.
+
+Besides semver ranges and dist-tags, which are resolved against the
+registry, a specifier may name a git repository, a tarball URL, a local
+path or a workspace package, or alias another registry package using the
+npm: prefix. The classification follows that of npm-package-arg, the
+parser used by the npm CLI, closely enough to tell registry specifiers
+from the others.
+*/
+package spec
+
+import (
+	"regexp"
+	"strings"
+
+	"deps.dev/util/semver"
+)
+
+// Kind is the kind of a specifier.
+type Kind int
+
+const (
+	// Range is a semver range, or an exact version, such as ^1.2.3.
+	Range Kind = iota
+	// Tag is a dist-tag, such as latest.
+	Tag
+	// Alias is a dependency on another registry package, such as
+	// npm:lodash@^4.0.0.
+	Alias
+	// Git is a git repository, such as git+https://host/repo.git#v1 or
+	// the GitHub shorthand user/repo.
+	Git
+	// Remote is a tarball URL, such as https://host/pkg.tgz.
+	Remote
+	// File is a local directory or tarball, such as file:../pkg or ./pkg.
+	File
+	// Link is a symbolic link to a local directory, such as link:../pkg.
+	Link
+	// Workspace is a package of the same workspace, such as workspace:*.
+	Workspace
+)
+
+var kindNames = [...]string{
+	Range:     "range",
+	Tag:       "tag",
+	Alias:     "alias",
+	Git:       "git",
+	Remote:    "remote",
+	File:      "file",
+	Link:      "link",
+	Workspace: "workspace",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "unknown"
+	}
+	return kindNames[k]
+}
+
+// Spec is a classified specifier.
+type Spec struct {
+	Kind Kind
+	// Name is the name of the package depended upon: the name of the
+	// dependency or, for an alias, that of the aliased package.
+	Name string
+	// Spec is the specifier to resolve Name with: the range or tag, for an
+	// alias that of the aliased package ("*" if none is given), and the
+	// specifier as given, without surrounding spaces, otherwise.
+	Spec string
+	// Alias is the name of the dependency if it is an alias, the name
+	// under which the aliased package is installed.
+	Alias string
+}
+
+// Registry reports whether the specifier is resolved against the registry,
+// that is whether it is a range, a tag or an alias.
+func (s Spec) Registry() bool {
+	switch s.Kind {
+	case Range, Tag, Alias:
+		return true
+	}
+	return false
+}
+
+var (
+	// gitPrefixes are the prefixes of git specifiers, including the
+	// shortcuts for known hosts.
+	gitPrefixes = []string{"git+", "git://", "github:", "gitlab:", "bitbucket:", "gist:"}
+	// githubShorthand matches user/repo, optionally followed by a
+	// committish.
+	githubShorthand = regexp.MustCompile(`^[^@%/\s.:][^/\s:]*/[^/\s:]+(#.*)?$`)
+	// gitURL matches hosted repository URLs, which npm treats as git
+	// rather than as tarballs.
+	gitURL = regexp.MustCompile(`^https?://[^#]+\.git(#.*)?$`)
+)
+
+// Parse classifies the specifier spec of the dependency with the given
+// name. It does not validate ranges: any specifier of a registry package
+// that is not a valid npm range is classified as a tag.
+func Parse(name, spec string) Spec {
+	spec = strings.TrimSpace(spec)
+	s := Spec{Name: name, Spec: spec}
+	switch {
+	case strings.HasPrefix(spec, "npm:"):
+		s.Kind, s.Alias = Alias, name
+		r := spec[len("npm:"):]
+		// The name of a scoped package begins with @.
+		if i := strings.LastIndex(r, "@"); i > 0 {
+			s.Name, s.Spec = r[:i], r[i+1:]
+		} else {
+			s.Name, s.Spec = r, "*"
+		}
+	case strings.HasPrefix(spec, "workspace:"):
+		s.Kind = Workspace
+	case strings.HasPrefix(spec, "link:"):
+		s.Kind = Link
+	case strings.HasPrefix(spec, "file:"), isPath(spec):
+		s.Kind = File
+	case hasAnyPrefix(spec, gitPrefixes), gitURL.MatchString(spec), githubShorthand.MatchString(spec):
+		s.Kind = Git
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		s.Kind = Remote
+	default:
+		s.Kind = Tag
+		if _, err := semver.NPM.ParseConstraintCached(spec); err == nil {
+			s.Kind = Range
+		}
+	}
+	return s
+}
+
+// isPath reports whether the specifier is a local path.
+func isPath(spec string) bool {
+	switch {
+	case spec == "." || spec == "..":
+		return true
+	case hasAnyPrefix(spec, []string{"./", "../", "/", "~/", `.\`, `..\`}):
+		return true
+	case len(spec) > 2 && spec[1] == ':' && (spec[2] == '\\' || spec[2] == '/'):
+		// A Windows path, such as C:\pkg.
+		c := spec[0] | 0x20
+		return 'a' <= c && c <= 'z'
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
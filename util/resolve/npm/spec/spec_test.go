@@ -0,0 +1,61 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spec
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		spec string
+		want Spec
+	}{
+		{"^1.2.3", Spec{Kind: Range, Name: "a", Spec: "^1.2.3"}},
+		{" 1.x || >=2.5.0 ", Spec{Kind: Range, Name: "a", Spec: "1.x || >=2.5.0"}},
+		{"", Spec{Kind: Range, Name: "a", Spec: ""}},
+		{"*", Spec{Kind: Range, Name: "a", Spec: "*"}},
+		{"latest", Spec{Kind: Tag, Name: "a", Spec: "latest"}},
+		{"next-11", Spec{Kind: Tag, Name: "a", Spec: "next-11"}},
+		{"npm:b@^1.0.0", Spec{Kind: Alias, Name: "b", Spec: "^1.0.0", Alias: "a"}},
+		{"npm:@scope/b@latest", Spec{Kind: Alias, Name: "@scope/b", Spec: "latest", Alias: "a"}},
+		{"npm:@scope/b", Spec{Kind: Alias, Name: "@scope/b", Spec: "*", Alias: "a"}},
+		{"npm:b", Spec{Kind: Alias, Name: "b", Spec: "*", Alias: "a"}},
+		{"git+https://github.com/user/repo.git#v1.0.0", Spec{Kind: Git, Name: "a", Spec: "git+https://github.com/user/repo.git#v1.0.0"}},
+		{"git+ssh://git@github.com:user/repo.git", Spec{Kind: Git, Name: "a", Spec: "git+ssh://git@github.com:user/repo.git"}},
+		{"git://github.com/user/repo", Spec{Kind: Git, Name: "a", Spec: "git://github.com/user/repo"}},
+		{"https://github.com/user/repo.git", Spec{Kind: Git, Name: "a", Spec: "https://github.com/user/repo.git"}},
+		{"github:user/repo#semver:^1.0.0", Spec{Kind: Git, Name: "a", Spec: "github:user/repo#semver:^1.0.0"}},
+		{"gitlab:user/repo", Spec{Kind: Git, Name: "a", Spec: "gitlab:user/repo"}},
+		{"user/repo", Spec{Kind: Git, Name: "a", Spec: "user/repo"}},
+		{"user/repo#main", Spec{Kind: Git, Name: "a", Spec: "user/repo#main"}},
+		{"https://example.com/a-1.0.0.tgz", Spec{Kind: Remote, Name: "a", Spec: "https://example.com/a-1.0.0.tgz"}},
+		{"file:../a", Spec{Kind: File, Name: "a", Spec: "file:../a"}},
+		{"./a", Spec{Kind: File, Name: "a", Spec: "./a"}},
+		{"../a-1.0.0.tgz", Spec{Kind: File, Name: "a", Spec: "../a-1.0.0.tgz"}},
+		{"/tmp/a", Spec{Kind: File, Name: "a", Spec: "/tmp/a"}},
+		{"~/a", Spec{Kind: File, Name: "a", Spec: "~/a"}},
+		{`C:\a`, Spec{Kind: File, Name: "a", Spec: `C:\a`}},
+		{"link:../a", Spec{Kind: Link, Name: "a", Spec: "link:../a"}},
+		{"workspace:*", Spec{Kind: Workspace, Name: "a", Spec: "workspace:*"}},
+		{"workspace:^1.0.0", Spec{Kind: Workspace, Name: "a", Spec: "workspace:^1.0.0"}},
+	} {
+		got := Parse("a", c.spec)
+		if got != c.want {
+			t.Errorf("Parse(%q): got %+v, want %+v", c.spec, got, c.want)
+		}
+		if reg, want := got.Registry(), c.want.Kind <= Alias; reg != want {
+			t.Errorf("Parse(%q).Registry(): got %t, want %t", c.spec, reg, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import "time"
+
+// Options holds the configuration shared by the Resolver implementations.
+// Resolvers accept it as a list of Option values passed to their
+// constructors.
+type Options struct {
+	// Limits bounds the size of the resolved graphs.
+	Limits Limits
+	// Tracer, if not nil, receives the events of the resolutions.
+	Tracer Tracer
+	// MaxDuration, if positive, bounds the duration of each resolution.
+	MaxDuration time.Duration
+	// Registries configures the registries available to the resolutions,
+	// in addition to those declared by the versions.
+	Registries RegistryConfig
+	// AsOf, if not zero, is the time at which the resolutions are
+	// performed, as by AsOfClient.
+	AsOf time.Time
+}
+
+// RegistryConfig describes the registries configured outside the
+// manifests, such as in a Maven settings.xml. Registries are identified as
+// in the Registries version attribute, usually by their URL.
+type RegistryConfig struct {
+	// Default, if set, replaces the default registry of the system for the
+	// versions not setting their own.
+	Default string
+	// Extra holds the registries reachable from every version.
+	Extra []string
+	// Mirror, if not nil, returns the registry serving the artifacts of the
+	// given registry, or the registry itself if it is not mirrored.
+	// Registries served by the same mirror are considered the same.
+	Mirror func(registry string) string
+}
+
+// Same reports whether the two registries are the same, or are served by
+// the same mirror.
+func (c RegistryConfig) Same(a, b string) bool {
+	if a == b {
+		return true
+	}
+	return c.Mirror != nil && c.Mirror(a) == c.Mirror(b)
+}
+
+// Option configures a Resolver.
+type Option func(*Options)
+
+// NewOptions returns the Options resulting from applying the given Option
+// values in order.
+func NewOptions(opts ...Option) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithLimits bounds the size of the resolved graphs. When a limit is reached,
+// the resolution stops and the Resolver returns the partial graph along with
+// a *LimitError.
+func WithLimits(l Limits) Option {
+	return func(o *Options) { o.Limits = l }
+}
+
+// WithTracer reports the steps of the resolutions, including the calls to
+// the Client, to the given Tracer.
+func WithTracer(t Tracer) Option {
+	return func(o *Options) { o.Tracer = t }
+}
+
+// WithMaxDuration bounds the duration of each resolution. When the duration
+// is exceeded, the Resolver returns context.DeadlineExceeded, in the same way
+// as when the deadline of the context given to Resolve is exceeded.
+func WithMaxDuration(d time.Duration) Option {
+	return func(o *Options) { o.MaxDuration = d }
+}
+
+// WithRegistries configures the registries available to the resolutions.
+// It is only used by the Maven resolver.
+func WithRegistries(c RegistryConfig) Option {
+	return func(o *Options) { o.Registries = c }
+}
+
+// WithAsOf resolves as of the given time, hiding the versions created
+// after it, so that a past resolution can be reproduced. See AsOfClient.
+func WithAsOf(t time.Time) Option {
+	return func(o *Options) { o.AsOf = t }
+}
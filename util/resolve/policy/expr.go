@@ -0,0 +1,462 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"deps.dev/util/resolve"
+)
+
+// kind is the type of the value of an expression.
+type kind int
+
+const (
+	boolKind kind = iota
+	numberKind
+	stringKind
+	listKind
+)
+
+func (k kind) String() string {
+	return [...]string{"bool", "number", "string", "list"}[k]
+}
+
+// expr is a compiled expression, evaluated for a node.
+type expr struct {
+	kind kind
+	eval func(n *Node) any // Returns a bool, float64, string or []string.
+	// literal reports whether the expression is a literal, whose value
+	// does not depend on the node.
+	literal bool
+}
+
+// variable is a fact about a node that expressions may refer to by name.
+type variable struct {
+	kind kind
+	get  func(n *Node) any
+}
+
+var variables = map[string]variable{
+	"system":     {stringKind, func(n *Node) any { return n.Version.System.String() }},
+	"name":       {stringKind, func(n *Node) any { return n.Version.Name }},
+	"version":    {stringKind, func(n *Node) any { return n.Version.Version }},
+	"depth":      {numberKind, func(n *Node) any { return float64(n.Depth) }},
+	"direct":     {boolKind, func(n *Node) any { return n.Depth == 1 }},
+	"blocked":    {boolKind, func(n *Node) any { return n.Status&resolve.StatusBlocked != 0 }},
+	"deleted":    {boolKind, func(n *Node) any { return n.Status&resolve.StatusDeleted != 0 }},
+	"license":    {stringKind, func(n *Node) any { return n.License }},
+	"advisories": {listKind, func(n *Node) any { return n.Advisories }},
+	"project":    {stringKind, func(n *Node) any { return n.Project }},
+	"stars":      {numberKind, func(n *Node) any { return float64(n.Stars) }},
+	"scorecard":  {numberKind, func(n *Node) any { return n.Scorecard }},
+	"risk":       {numberKind, func(n *Node) any { return n.Risk }},
+}
+
+// function is a built-in function.
+type function struct {
+	args   []kind
+	result kind
+	// compile returns the implementation of the function for the given
+	// arguments, or an error if they are invalid.
+	compile func(args []expr) (func(n *Node) any, error)
+}
+
+var functions = map[string]function{
+	"len": {[]kind{listKind}, numberKind, func(args []expr) (func(n *Node) any, error) {
+		return func(n *Node) any { return float64(len(args[0].eval(n).([]string))) }, nil
+	}},
+	"contains": {[]kind{stringKind, stringKind}, boolKind, func(args []expr) (func(n *Node) any, error) {
+		return func(n *Node) any {
+			return strings.Contains(args[0].eval(n).(string), args[1].eval(n).(string))
+		}, nil
+	}},
+	"startsWith": {[]kind{stringKind, stringKind}, boolKind, func(args []expr) (func(n *Node) any, error) {
+		return func(n *Node) any {
+			return strings.HasPrefix(args[0].eval(n).(string), args[1].eval(n).(string))
+		}, nil
+	}},
+	"matches": {[]kind{stringKind, stringKind}, boolKind, func(args []expr) (func(n *Node) any, error) {
+		// The pattern must be a literal, so that it is compiled once.
+		if !args[1].literal {
+			return nil, fmt.Errorf("the pattern must be a string literal")
+		}
+		re, err := regexp.Compile(args[1].eval(nil).(string))
+		if err != nil {
+			return nil, err
+		}
+		return func(n *Node) any { return re.MatchString(args[0].eval(n).(string)) }, nil
+	}},
+	"check": {[]kind{stringKind}, numberKind, func(args []expr) (func(n *Node) any, error) {
+		return func(n *Node) any {
+			s, ok := n.Checks[args[0].eval(n).(string)]
+			if !ok {
+				return float64(-1)
+			}
+			return float64(s)
+		}, nil
+	}},
+}
+
+// token kinds.
+const (
+	tokEOF = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind int
+	text string // The identifier or punctuation, or the value of a string.
+	pos  int    // The byte offset in the source.
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(src) && (src[j] == '_' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			toks = append(toks, token{tokIdent, src[i:j], i})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, src[i:j], i})
+			i = j
+		case c == '"' || c == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				sb.WriteByte(src[j])
+			}
+			if j == len(src) {
+				return nil, fmt.Errorf("offset %d: unterminated string", i)
+			}
+			toks = append(toks, token{tokString, sb.String(), i})
+			i = j + 1
+		default:
+			p := ""
+			for _, op := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ","} {
+				if strings.HasPrefix(src[i:], op) {
+					p = op
+					break
+				}
+			}
+			if p == "" {
+				return nil, fmt.Errorf("offset %d: unexpected character %q", i, c)
+			}
+			toks = append(toks, token{tokPunct, p, i})
+			i += len(p)
+		}
+	}
+	return append(toks, token{tokEOF, "", len(src)}), nil
+}
+
+// parser is a recursive descent parser of expressions. The grammar is:
+//
+//	or      = and { "||" and }
+//	and     = not { "&&" not }
+//	not     = "!" not | compare
+//	compare = primary [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "in" ) primary ]
+//	primary = number | string | "true" | "false" | list | ident
+//	        | ident "(" [ or { "," or } ] ")" | "(" or ")"
+//	list    = "[" [ string { "," string } ] "]"
+type parser struct {
+	toks []token
+	i    int
+}
+
+// compile compiles the source of a boolean expression.
+func compile(src string) (expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return expr{}, err
+	}
+	p := &parser{toks: toks}
+	e, err := p.or()
+	if err != nil {
+		return expr{}, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return expr{}, p.errorf(t, "unexpected %q", t.text)
+	}
+	if e.kind != boolKind {
+		return expr{}, fmt.Errorf("expression is a %v, not a bool", e.kind)
+	}
+	return e, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the given punctuation or
+// keyword.
+func (p *parser) accept(text string) bool {
+	if t := p.peek(); (t.kind == tokPunct || t.kind == tokIdent) && t.text == text {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		t := p.peek()
+		return p.errorf(t, "expected %q, found %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf("offset %d: %s", t.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) logical(op string, operand func() (expr, error), combine func(a, b func(*Node) any) func(*Node) any) (expr, error) {
+	e, err := operand()
+	if err != nil {
+		return expr{}, err
+	}
+	for {
+		t := p.peek()
+		if !p.accept(op) {
+			return e, nil
+		}
+		r, err := operand()
+		if err != nil {
+			return expr{}, err
+		}
+		if e.kind != boolKind || r.kind != boolKind {
+			return expr{}, p.errorf(t, "operands of %s must be bools, not %v and %v", op, e.kind, r.kind)
+		}
+		e = expr{kind: boolKind, eval: combine(e.eval, r.eval)}
+	}
+}
+
+func (p *parser) or() (expr, error) {
+	return p.logical("||", p.and, func(a, b func(*Node) any) func(*Node) any {
+		return func(n *Node) any { return a(n).(bool) || b(n).(bool) }
+	})
+}
+
+func (p *parser) and() (expr, error) {
+	return p.logical("&&", p.not, func(a, b func(*Node) any) func(*Node) any {
+		return func(n *Node) any { return a(n).(bool) && b(n).(bool) }
+	})
+}
+
+func (p *parser) not() (expr, error) {
+	t := p.peek()
+	if !p.accept("!") {
+		return p.compare()
+	}
+	e, err := p.not()
+	if err != nil {
+		return expr{}, err
+	}
+	if e.kind != boolKind {
+		return expr{}, p.errorf(t, "operand of ! must be a bool, not %v", e.kind)
+	}
+	return expr{kind: boolKind, eval: func(n *Node) any { return !e.eval(n).(bool) }}, nil
+}
+
+func (p *parser) compare() (expr, error) {
+	l, err := p.primary()
+	if err != nil {
+		return expr{}, err
+	}
+	t := p.peek()
+	op := ""
+	for _, o := range []string{"==", "!=", "<", "<=", ">", ">=", "in"} {
+		if p.accept(o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		return l, nil
+	}
+	r, err := p.primary()
+	if err != nil {
+		return expr{}, err
+	}
+	if op == "in" {
+		if l.kind != stringKind || r.kind != listKind {
+			return expr{}, p.errorf(t, "in requires a string and a list, not %v and %v", l.kind, r.kind)
+		}
+		return expr{kind: boolKind, eval: func(n *Node) any {
+			s := l.eval(n).(string)
+			for _, v := range r.eval(n).([]string) {
+				if v == s {
+					return true
+				}
+			}
+			return false
+		}}, nil
+	}
+	if l.kind != r.kind || l.kind == listKind || l.kind == boolKind && op != "==" && op != "!=" {
+		return expr{}, p.errorf(t, "cannot compare %v and %v with %s", l.kind, r.kind, op)
+	}
+	return expr{kind: boolKind, eval: func(n *Node) any {
+		a, b := l.eval(n), r.eval(n)
+		var c int
+		switch a := a.(type) {
+		case bool:
+			if a == b.(bool) {
+				return op == "=="
+			}
+			return op == "!="
+		case float64:
+			c = cmpOrdered(a, b.(float64))
+		case string:
+			c = strings.Compare(a, b.(string))
+		}
+		switch op {
+		case "==":
+			return c == 0
+		case "!=":
+			return c != 0
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		}
+		return c >= 0
+	}}, nil
+}
+
+func cmpOrdered[T float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (p *parser) primary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return expr{}, p.errorf(t, "invalid number %q", t.text)
+		}
+		return expr{numberKind, func(*Node) any { return f }, true}, nil
+	case tokString:
+		s := t.text
+		return expr{stringKind, func(*Node) any { return s }, true}, nil
+	case tokPunct:
+		switch t.text {
+		case "(":
+			e, err := p.or()
+			if err != nil {
+				return expr{}, err
+			}
+			return e, p.expect(")")
+		case "[":
+			var list []string
+			for !p.accept("]") {
+				if len(list) > 0 {
+					if err := p.expect(","); err != nil {
+						return expr{}, err
+					}
+				}
+				s := p.next()
+				if s.kind != tokString {
+					return expr{}, p.errorf(s, "lists may only hold strings")
+				}
+				list = append(list, s.text)
+			}
+			return expr{listKind, func(*Node) any { return list }, true}, nil
+		}
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			b := t.text == "true"
+			return expr{boolKind, func(*Node) any { return b }, true}, nil
+		}
+		if p.accept("(") {
+			return p.call(t)
+		}
+		v, ok := variables[t.text]
+		if !ok {
+			return expr{}, p.errorf(t, "unknown variable %q", t.text)
+		}
+		return expr{kind: v.kind, eval: v.get}, nil
+	}
+	return expr{}, p.errorf(t, "unexpected %q", t.text)
+}
+
+// call parses the arguments of a call to the function named by t.
+func (p *parser) call(t token) (expr, error) {
+	f, ok := functions[t.text]
+	if !ok {
+		return expr{}, p.errorf(t, "unknown function %q", t.text)
+	}
+	var args []expr
+	for !p.accept(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return expr{}, err
+			}
+		}
+		a, err := p.or()
+		if err != nil {
+			return expr{}, err
+		}
+		args = append(args, a)
+	}
+	if len(args) != len(f.args) {
+		return expr{}, p.errorf(t, "%s takes %d arguments, not %d", t.text, len(f.args), len(args))
+	}
+	for i, a := range args {
+		if a.kind != f.args[i] {
+			return expr{}, p.errorf(t, "argument %d of %s must be a %v, not %v", i+1, t.text, f.args[i], a.kind)
+		}
+	}
+	eval, err := f.compile(args)
+	if err != nil {
+		return expr{}, p.errorf(t, "%s: %v", t.text, err)
+	}
+	return expr{kind: f.result, eval: eval}, nil
+}
@@ -0,0 +1,264 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package policy evaluates rules over the nodes of a resolved dependency
+graph, so that organizations can state what they expect of their
+dependencies, such as their licenses, advisories, Scorecard results or depth
+in the graph, and find the dependencies that fall short.
+
+A Rule is a boolean expression that every dependency must satisfy. For
+example, the rule
+
+	license in ["Apache-2.0", "MIT", "BSD-3-Clause"] || !direct
+
+requires direct dependencies to have one of the listed licenses, and
+
+	len(advisories) == 0 && check("Maintained") != 0
+
+requires dependencies to have no known advisories and not to be
+unmaintained. Expressions are made of literal numbers, strings in double or
+single quotes, true, false and lists of strings such as ["a", "b"], the
+variables and functions below, comparisons with ==, !=, <, <=, >, >= and in,
+and the boolean operators !, && and ||, with parentheses for grouping. The
+operands of a comparison must have the same type; in tests whether a string
+is an element of a list. Expressions are type checked when they are
+compiled.
+
+The variables describe the Node being evaluated:
+
+	system      string  The system of the version, such as "NPM".
+	name        string  The name of the package.
+	version     string  The version.
+	depth       number  The length of the shortest path from the root.
+	direct      bool    Whether the version is a direct dependency.
+	blocked     bool    Whether the version is deprecated or yanked.
+	deleted     bool    Whether the version has been deleted.
+	license     string  The license of the version's source project.
+	advisories  list    The IDs of the advisories affecting the version.
+	project     string  The source project, such as github.com/a/b.
+	stars       number  The stars of the source project.
+	scorecard   number  The Scorecard score of the project, or -1.
+	risk        number  The risk of the version, as computed by health.
+
+The functions are:
+
+	len(list) number             The length of a list.
+	contains(s, sub) bool        Whether s contains sub.
+	startsWith(s, prefix) bool   Whether s starts with prefix.
+	matches(s, pattern) bool     Whether s matches the regular expression
+	                             pattern, which must be a literal.
+	check(name) number           The score of the named Scorecard check,
+	                             or -1 if it is unknown.
+
+The nodes of a graph are built by Nodes, which fills in what the graph
+itself records, and completed by AddHealth and AddAdvisories with data from
+deps.dev.
+*/
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/advisor"
+	"deps.dev/util/resolve/health"
+)
+
+// Rule is a condition the dependencies of a graph must satisfy.
+type Rule struct {
+	// Name identifies the rule in results.
+	Name string
+	// Expr is a boolean expression, described in the package
+	// documentation, that holds for the nodes satisfying the rule.
+	Expr string
+}
+
+// Policy is a compiled set of rules. It is safe for concurrent use.
+type Policy struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	name string
+	expr expr
+}
+
+// Compile compiles the given rules into a Policy, returning an error for
+// each rule that is not a valid boolean expression.
+func Compile(rules []Rule) (*Policy, error) {
+	p := &Policy{}
+	var errs []error
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if r.Name == "" || seen[r.Name] {
+			errs = append(errs, fmt.Errorf("rule %q: name empty or not unique", r.Name))
+			continue
+		}
+		seen[r.Name] = true
+		e, err := compile(r.Expr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %q: %w", r.Name, err))
+			continue
+		}
+		p.rules = append(p.rules, compiledRule{name: r.Name, expr: e})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Node holds the facts about a node of a graph that rules are evaluated
+// against.
+type Node struct {
+	ID      resolve.NodeID
+	Version resolve.VersionKey
+	// Depth is the number of edges on the shortest path from the root,
+	// or -1 if the node is not reachable from it.
+	Depth  int
+	Status resolve.VersionStatus
+	// Project, License, Stars, Scorecard, Checks and Risk are set by
+	// AddHealth.
+	Project   string
+	License   string
+	Stars     int
+	Scorecard float64 // -1 if unknown.
+	Checks    map[string]int
+	Risk      float64
+	// Advisories is set by AddAdvisories.
+	Advisories []string
+}
+
+// Nodes returns a Node for every node of g but its root, in node order,
+// with the facts recorded by the graph itself.
+func Nodes(g *resolve.Graph) []Node {
+	if len(g.Nodes) == 0 {
+		return nil
+	}
+	depth := make([]int, len(g.Nodes))
+	for i := range depth {
+		depth[i] = -1
+	}
+	depth[0] = 0
+	queue := []resolve.NodeID{0}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for e := range g.EdgesFrom(cur) {
+			if depth[e.To] < 0 {
+				depth[e.To] = depth[cur] + 1
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	nodes := make([]Node, len(g.Nodes)-1)
+	for i := range nodes {
+		id := resolve.NodeID(i + 1)
+		nodes[i] = Node{
+			ID:        id,
+			Version:   g.Nodes[id].Version,
+			Depth:     depth[id],
+			Status:    g.Nodes[id].Status,
+			Scorecard: -1,
+			Risk:      health.MaxRisk,
+		}
+	}
+	return nodes
+}
+
+// AddHealth sets the facts about the source projects of the nodes from a
+// health report of the same graph.
+func AddHealth(nodes []Node, r *health.Report) {
+	byVersion := make(map[resolve.VersionKey]*health.Dependency, len(r.Dependencies))
+	for i := range r.Dependencies {
+		byVersion[r.Dependencies[i].Version] = &r.Dependencies[i]
+	}
+	for i := range nodes {
+		n := &nodes[i]
+		d, ok := byVersion[n.Version]
+		if !ok {
+			continue
+		}
+		n.Project, n.License, n.Stars, n.Risk = d.Project, d.License, d.Stars, d.Risk
+		if d.Scorecard != nil {
+			n.Scorecard = d.Scorecard.Score
+			n.Checks = make(map[string]int, len(d.Scorecard.Checks))
+			for _, c := range d.Scorecard.Checks {
+				n.Checks[c.Name] = c.Score
+			}
+		}
+	}
+}
+
+// AddAdvisories sets the advisories of the nodes, as returned by the given
+// function, which is called at most concurrency times at once.
+func AddAdvisories(ctx context.Context, nodes []Node, advisories advisor.AdvisoryFunc, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i := range nodes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(n *Node) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ids, err := advisories(ctx, n.Version)
+			if err != nil {
+				errs[i] = fmt.Errorf("advisories of %v: %w", n.Version, err)
+				return
+			}
+			n.Advisories = ids
+		}(&nodes[i])
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Result is the outcome of evaluating a policy for a node.
+type Result struct {
+	Node resolve.NodeID
+	// Version is the version of the node.
+	Version resolve.VersionKey
+	// Failed holds the names of the rules the node does not satisfy, in
+	// the order of the rules.
+	Failed []string
+}
+
+// Pass reports whether the node satisfies every rule.
+func (r Result) Pass() bool {
+	return len(r.Failed) == 0
+}
+
+// Evaluate evaluates the policy for each of the given nodes, returning a
+// Result for each, in the same order.
+func (p *Policy) Evaluate(nodes []Node) []Result {
+	results := make([]Result, len(nodes))
+	for i := range nodes {
+		n := &nodes[i]
+		results[i] = Result{Node: n.ID, Version: n.Version}
+		for _, r := range p.rules {
+			if !r.expr.eval(n).(bool) {
+				results[i].Failed = append(results[i].Failed, r.name)
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/health"
+)
+
+func concrete(name, version string) resolve.VersionKey {
+	return resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: name},
+		VersionType: resolve.Concrete,
+		Version:     version,
+	}
+}
+
+// testNodes returns the nodes of the graph root -> alice -> bob, with
+// health and advisories.
+func testNodes(t *testing.T) []Node {
+	t.Helper()
+	var g resolve.Graph
+	root := g.AddNode(concrete("root", "1.0.0"))
+	alice := g.AddNode(concrete("alice", "1.0.0"))
+	bob := g.AddNode(concrete("bob", "2.0.0"))
+	g.AddNode(concrete("orphan", "3.0.0"))
+	for _, e := range [][2]resolve.NodeID{{root, alice}, {alice, bob}} {
+		if err := g.AddEdge(e[0], e[1], "*", dep.NewType()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.Nodes[bob].Status = resolve.StatusBlocked
+	nodes := Nodes(&g)
+	AddHealth(nodes, &health.Report{Dependencies: []health.Dependency{{
+		Version: concrete("alice", "1.0.0"),
+		Project: "github.com/example/alice",
+		License: "MIT",
+		Stars:   120,
+		Scorecard: &health.Scorecard{
+			Score:  7.5,
+			Checks: []health.Check{{Name: "Maintained", Score: 10}},
+		},
+		Risk: 2.5,
+	}, {
+		Version: concrete("bob", "2.0.0"),
+		License: "GPL-3.0",
+		Risk:    health.MaxRisk,
+	}}})
+	err := AddAdvisories(context.Background(), nodes, func(_ context.Context, vk resolve.VersionKey) ([]string, error) {
+		if vk.Name == "bob" {
+			return []string{"GHSA-aaaa-bbbb-cccc"}, nil
+		}
+		return nil, nil
+	}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nodes
+}
+
+func TestNodes(t *testing.T) {
+	nodes := testNodes(t)
+	want := []Node{{
+		ID:        1,
+		Version:   concrete("alice", "1.0.0"),
+		Depth:     1,
+		Project:   "github.com/example/alice",
+		License:   "MIT",
+		Stars:     120,
+		Scorecard: 7.5,
+		Checks:    map[string]int{"Maintained": 10},
+		Risk:      2.5,
+	}, {
+		ID:         2,
+		Version:    concrete("bob", "2.0.0"),
+		Depth:      2,
+		Status:     resolve.StatusBlocked,
+		License:    "GPL-3.0",
+		Scorecard:  -1,
+		Risk:       health.MaxRisk,
+		Advisories: []string{"GHSA-aaaa-bbbb-cccc"},
+	}, {
+		ID:        3,
+		Version:   concrete("orphan", "3.0.0"),
+		Depth:     -1,
+		Scorecard: -1,
+		Risk:      health.MaxRisk,
+	}}
+	if diff := cmp.Diff(want, nodes); diff != "" {
+		t.Errorf("nodes (-want +got):\n%s", diff)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	nodes := testNodes(t)
+	p, err := Compile([]Rule{
+		{Name: "licensed", Expr: `license in ["MIT", "Apache-2.0"]`},
+		{Name: "no-advisories", Expr: `len(advisories) == 0`},
+		{Name: "maintained", Expr: `check("Maintained") >= 5 || !direct`},
+		{Name: "shallow", Expr: `depth >= 0 && depth <= 1`},
+		{Name: "healthy", Expr: `scorecard > 5 && stars >= 100 && !blocked && !deleted`},
+		{Name: "named", Expr: `startsWith(name, 'a') || matches(name, "^(bob|orphan)$") && !contains(version, "-")`},
+		{Name: "npm", Expr: `system == "NPM" && (risk < 10 || project == "")`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := p.Evaluate(nodes)
+	want := []Result{{
+		Node:    1,
+		Version: concrete("alice", "1.0.0"),
+	}, {
+		Node:    2,
+		Version: concrete("bob", "2.0.0"),
+		Failed:  []string{"licensed", "no-advisories", "shallow", "healthy"},
+	}, {
+		Node:    3,
+		Version: concrete("orphan", "3.0.0"),
+		Failed:  []string{"licensed", "shallow", "healthy"},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Evaluate (-want +got):\n%s", diff)
+	}
+	if !got[0].Pass() || got[1].Pass() {
+		t.Errorf("Pass: got %t and %t, want true and false", got[0].Pass(), got[1].Pass())
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`license`,
+		`depth`,
+		`unknown == 1`,
+		`depth == "1"`,
+		`advisories == advisories`,
+		`direct < true`,
+		`depth in ["1"]`,
+		`!depth`,
+		`direct && depth`,
+		`len(license) > 0`,
+		`len(advisories, advisories) > 0`,
+		`nope(name)`,
+		`matches(name, license)`,
+		`matches(name, "(")`,
+		`[1, 2] == name`,
+		`name == "unterminated`,
+		`(direct`,
+		`direct direct`,
+		`depth # 1`,
+	} {
+		if _, err := Compile([]Rule{{Name: "r", Expr: expr}}); err == nil {
+			t.Errorf("Compile(%q): got no error", expr)
+		}
+	}
+	if _, err := Compile([]Rule{{Name: "r", Expr: "true"}, {Name: "r", Expr: "false"}}); err == nil {
+		t.Errorf("Compile with duplicate names: got no error")
+	}
+}
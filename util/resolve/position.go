@@ -0,0 +1,83 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import "fmt"
+
+// Position is the position of a declaration in a file, such as that of a
+// requirement in a manifest or of a version in a lockfile. Line and Column
+// are numbered from 1, columns counting bytes; zero means unknown.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// IsValid reports whether the position is known.
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// String returns the position in the form file:line:column, leaving out
+// the parts that are not known.
+func (p Position) String() string {
+	s := p.File
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprint(p.Line)
+		if p.Column > 0 {
+			s += fmt.Sprintf(":%d", p.Column)
+		}
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
+// Positions holds the positions at which versions are declared in the
+// files they were parsed from, as recorded by the parsers of manifests and
+// lockfiles. The positions of a RequirementVersion are found under its
+// VersionKey. A version declared several times, such as a requirement
+// repeated for several dependency types, has several positions, in the
+// order in which they appear.
+type Positions map[VersionKey][]Position
+
+// Add records a position of the given version.
+func (ps Positions) Add(vk VersionKey, p Position) {
+	ps[vk] = append(ps[vk], p)
+}
+
+// Of returns the first position of the given version, and whether it has
+// one.
+func (ps Positions) Of(vk VersionKey) (Position, bool) {
+	if len(ps[vk]) == 0 {
+		return Position{}, false
+	}
+	return ps[vk][0], true
+}
+
+// LineColumn returns the line and column, numbered from 1, of the given
+// byte offset in data.
+func LineColumn(data []byte, offset int) (line, column int) {
+	line, start := 1, 0
+	for i := 0; i < offset && i < len(data); i++ {
+		if data[i] == '\n' {
+			line++
+			start = i + 1
+		}
+	}
+	return line, offset - start + 1
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import "testing"
+
+func TestPosition(t *testing.T) {
+	for _, tc := range []struct {
+		p    Position
+		want string
+	}{
+		{Position{File: "go.mod", Line: 3, Column: 2}, "go.mod:3:2"},
+		{Position{File: "go.sum", Line: 7}, "go.sum:7"},
+		{Position{Line: 1, Column: 5}, "1:5"},
+		{Position{File: "package.json"}, "package.json"},
+		{Position{}, "-"},
+	} {
+		if got := tc.p.String(); got != tc.want {
+			t.Errorf("%#v: got %q, want %q", tc.p, got, tc.want)
+		}
+	}
+
+	vk := VersionKey{PackageKey: PackageKey{System: NPM, Name: "a"}, VersionType: Requirement, Version: "^1.0.0"}
+	ps := make(Positions)
+	if _, ok := ps.Of(vk); ok {
+		t.Errorf("Of on empty Positions reports a position")
+	}
+	ps.Add(vk, Position{File: "package.json", Line: 4, Column: 5})
+	ps.Add(vk, Position{File: "package.json", Line: 9, Column: 5})
+	if p, ok := ps.Of(vk); !ok || p.Line != 4 {
+		t.Errorf("Of: got %v, %t, want line 4", p, ok)
+	}
+
+	data := []byte("ab\ncd\n\nef")
+	for _, tc := range []struct{ offset, line, column int }{
+		{0, 1, 1}, {1, 1, 2}, {3, 2, 1}, {4, 2, 2}, {6, 3, 1}, {7, 4, 1}, {8, 4, 2},
+	} {
+		if l, c := LineColumn(data, tc.offset); l != tc.line || c != tc.column {
+			t.Errorf("LineColumn(%d) = %d:%d, want %d:%d", tc.offset, l, c, tc.line, tc.column)
+		}
+	}
+}
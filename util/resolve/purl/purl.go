@@ -0,0 +1,80 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package purl formats the package URLs of the packages and versions of the
+deps.dev packaging systems, as specified by
+https://github.com/package-url/purl-spec.
+
+It imports neither version of the deps.dev API, so that it may be used
+with either: systems are named as in the API, such as NPM or MAVEN.
+*/
+package purl
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Format returns the package URL of the given package version, or of the
+// package if version is empty. The system is named as in the deps.dev API,
+// without regard to case. The result is empty if the system has no package
+// URL type or the name is not valid for it.
+func Format(system, name, version string) string {
+	var typ, namespace string
+	switch strings.ToUpper(system) {
+	case "NPM":
+		typ = "npm"
+		if scope, ok := strings.CutPrefix(name, "@"); ok {
+			// The @ of a scope is percent-encoded in package URLs.
+			namespace, name, _ = strings.Cut(scope, "/")
+			namespace = "%40" + url.PathEscape(namespace)
+		}
+	case "MAVEN":
+		typ = "maven"
+		group, artifact, ok := strings.Cut(name, ":")
+		if !ok {
+			return ""
+		}
+		namespace, name = url.PathEscape(group), artifact
+	case "GO":
+		typ = "golang"
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			segs := strings.Split(name[:i], "/")
+			for j, s := range segs {
+				segs[j] = url.PathEscape(s)
+			}
+			namespace, name = strings.Join(segs, "/"), name[i+1:]
+		}
+	case "PYPI":
+		// Python package names are normalized in package URLs.
+		typ = "pypi"
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	case "CARGO":
+		typ = "cargo"
+	case "NUGET":
+		typ = "nuget"
+	default:
+		return ""
+	}
+	purl := "pkg:" + typ + "/"
+	if namespace != "" {
+		purl += namespace + "/"
+	}
+	purl += url.PathEscape(name)
+	if version != "" {
+		purl += "@" + url.PathEscape(version)
+	}
+	return purl
+}
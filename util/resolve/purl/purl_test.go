@@ -0,0 +1,41 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package purl
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	for _, test := range []struct {
+		system, name, version string
+		want                  string
+	}{
+		{"NPM", "left-pad", "1.3.0", "pkg:npm/left-pad@1.3.0"},
+		{"NPM", "@types/node", "20.1.0", "pkg:npm/%40types/node@20.1.0"},
+		{"NPM", "left-pad", "", "pkg:npm/left-pad"},
+		{"MAVEN", "org.example:lib", "1.0", "pkg:maven/org.example/lib@1.0"},
+		{"Maven", "org.example:lib", "1.0", "pkg:maven/org.example/lib@1.0"},
+		{"MAVEN", "invalid", "1.0", ""},
+		{"CARGO", "semver", "1.0.0+build", "pkg:cargo/semver@1.0.0+build"},
+		{"NUGET", "Newtonsoft.Json", "13.0.1", "pkg:nuget/Newtonsoft.Json@13.0.1"},
+		{"GO", "github.com/google/go-cmp", "v0.6.0", "pkg:golang/github.com/google/go-cmp@v0.6.0"},
+		{"GO", "example.com/a b/c", "v1.0.0", "pkg:golang/example.com/a%20b/c@v1.0.0"},
+		{"PYPI", "Django_Rest", "3.0", "pkg:pypi/django-rest@3.0"},
+		{"SYSTEM_UNSPECIFIED", "x", "1.0", ""},
+	} {
+		if got := Format(test.system, test.name, test.version); got != test.want {
+			t.Errorf("Format(%q, %q, %q) = %q, want %q", test.system, test.name, test.version, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Find returns the nodes of the given package, in node order.
+func (g *Graph) Find(pk PackageKey) []NodeID {
+	var ids []NodeID
+	for i, n := range g.Nodes {
+		if n.Version.PackageKey == pk {
+			ids = append(ids, NodeID(i))
+		}
+	}
+	return ids
+}
+
+// Dependencies returns the edges from the given node, in edge order.
+func (g *Graph) Dependencies(n NodeID) []Edge {
+	var es []Edge
+	for _, e := range g.Edges {
+		if e.From == n {
+			es = append(es, e)
+		}
+	}
+	return es
+}
+
+// Dependents returns the edges to the given node, in edge order.
+func (g *Graph) Dependents(n NodeID) []Edge {
+	var es []Edge
+	for _, e := range g.Edges {
+		if e.To == n {
+			es = append(es, e)
+		}
+	}
+	return es
+}
+
+// Ancestors returns the nodes from which the given node can be reached,
+// excluding the node itself unless it is part of a cycle, in node order.
+func (g *Graph) Ancestors(n NodeID) []NodeID {
+	in := g.inEdges()
+	seen := make([]bool, len(g.Nodes))
+	queue := []NodeID{n}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range in[cur] {
+			if !seen[e.From] {
+				seen[e.From] = true
+				queue = append(queue, e.From)
+			}
+		}
+	}
+	var ids []NodeID
+	for i, ok := range seen {
+		if ok {
+			ids = append(ids, NodeID(i))
+		}
+	}
+	return ids
+}
+
+// Why returns the edges of a shortest path from the root to the given
+// node: the chain of requirements that brought it into the graph. It
+// returns nil if the node is the root or is not reachable from it.
+func (g *Graph) Why(n NodeID) []Edge {
+	paths := g.PathsTo(n, 1)
+	if len(paths) == 0 {
+		return nil
+	}
+	return paths[0]
+}
+
+// PathsTo returns the paths from the root to the given node, as the edges
+// of each path from the root down, shortest first. Paths do not visit a
+// node twice. If limit is positive, at most limit paths are returned; as the
+// number of paths can grow exponentially with the size of the graph, a
+// limit should be given for large graphs. It returns nil if the node is the
+// root or is not reachable from it.
+func (g *Graph) PathsTo(n NodeID, limit int) [][]Edge {
+	if !g.contains(n) || n == 0 {
+		return nil
+	}
+	in := g.inEdges()
+	// Paths are built up from the node, so are held in reverse.
+	type partial struct {
+		edges []Edge
+		nodes []NodeID // Visited nodes, to keep paths simple.
+	}
+	var paths [][]Edge
+	queue := []partial{{nodes: []NodeID{n}}}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		head := p.nodes[len(p.nodes)-1]
+		for _, e := range in[head] {
+			if slices.Contains(p.nodes, e.From) {
+				continue
+			}
+			edges := append(slices.Clip(p.edges), e)
+			if e.From == 0 {
+				path := slices.Clone(edges)
+				slices.Reverse(path)
+				paths = append(paths, path)
+				if limit > 0 && len(paths) == limit {
+					return paths
+				}
+				continue
+			}
+			queue = append(queue, partial{
+				edges: edges,
+				nodes: append(slices.Clip(p.nodes), e.From),
+			})
+		}
+	}
+	return paths
+}
+
+// Explain describes a path, as returned by Why or PathsTo, as the chain of
+// requirements it follows, such as
+//
+//	alice@1.0.0 -> bob@^1.0.0 (1.0.0) -> chuck@~2.0.0 (2.0.1)
+//
+// where each requirement is followed by the version it resolved to.
+func (g *Graph) Explain(path []Edge) string {
+	if len(path) == 0 {
+		return ""
+	}
+	root := g.Nodes[path[0].From].Version
+	parts := []string{fmt.Sprintf("%s@%s", root.Name, root.Version)}
+	for _, e := range path {
+		to := g.Nodes[e.To].Version
+		parts = append(parts, fmt.Sprintf("%s@%s (%s)", to.Name, e.Requirement, to.Version))
+	}
+	return strings.Join(parts, " -> ")
+}
+
+// inEdges returns the edges to each node, indexed by node.
+func (g *Graph) inEdges() [][]Edge {
+	in := make([][]Edge, len(g.Nodes))
+	for _, e := range g.Edges {
+		if g.contains(e.To) {
+			in[e.To] = append(in[e.To], e)
+		}
+	}
+	return in
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve/dep"
+)
+
+// queryGraph returns the graph
+//
+//	alice -> bob -> dave -> eve
+//	alice -> chuck -> dave
+//	chuck -> eve
+//	eve -> chuck (a cycle)
+//	frank (unreachable)
+func queryGraph(t *testing.T) *Graph {
+	t.Helper()
+	g := &Graph{}
+	for _, name := range []string{"alice", "bob", "chuck", "dave", "eve", "frank"} {
+		g.AddNode(VersionKey{
+			PackageKey:  PackageKey{System: NPM, Name: name},
+			VersionType: Concrete,
+			Version:     "1.0.0",
+		})
+	}
+	for _, e := range [][2]NodeID{{0, 1}, {0, 2}, {1, 3}, {2, 3}, {3, 4}, {2, 4}, {4, 2}} {
+		if err := g.AddEdge(e[0], e[1], "^1.0.0", dep.Type{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func TestGraphQueries(t *testing.T) {
+	g := queryGraph(t)
+	if got := g.Find(PackageKey{System: NPM, Name: "dave"}); !cmp.Equal(got, []NodeID{3}) {
+		t.Errorf("Find(dave): got %v", got)
+	}
+	if got := g.Find(PackageKey{System: Maven, Name: "dave"}); got != nil {
+		t.Errorf("Find(Maven dave): got %v", got)
+	}
+	if got := len(g.Dependencies(2)); got != 2 {
+		t.Errorf("Dependencies(chuck): got %d edges, want 2", got)
+	}
+	if got := len(g.Dependents(3)); got != 2 {
+		t.Errorf("Dependents(dave): got %d edges, want 2", got)
+	}
+	if got, want := g.Ancestors(3), []NodeID{0, 1, 2, 3, 4}; !cmp.Equal(got, want) {
+		// dave is its own ancestor through the cycle dave -> eve -> chuck.
+		t.Errorf("Ancestors(dave): got %v, want %v", got, want)
+	}
+	if got, want := g.Ancestors(1), []NodeID{0}; !cmp.Equal(got, want) {
+		t.Errorf("Ancestors(bob): got %v, want %v", got, want)
+	}
+
+	explain := func(paths [][]Edge) []string {
+		var s []string
+		for _, p := range paths {
+			s = append(s, g.Explain(p))
+		}
+		return s
+	}
+	want := []string{
+		"alice@1.0.0 -> chuck@^1.0.0 (1.0.0) -> eve@^1.0.0 (1.0.0)",
+		"alice@1.0.0 -> bob@^1.0.0 (1.0.0) -> dave@^1.0.0 (1.0.0) -> eve@^1.0.0 (1.0.0)",
+		"alice@1.0.0 -> chuck@^1.0.0 (1.0.0) -> dave@^1.0.0 (1.0.0) -> eve@^1.0.0 (1.0.0)",
+	}
+	if diff := cmp.Diff(want, explain(g.PathsTo(4, 0))); diff != "" {
+		t.Errorf("PathsTo(eve) (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want[:2], explain(g.PathsTo(4, 2))); diff != "" {
+		t.Errorf("PathsTo(eve, 2) (-want +got):\n%s", diff)
+	}
+	if got := g.Explain(g.Why(4)); got != want[0] {
+		t.Errorf("Why(eve): got %q, want %q", got, want[0])
+	}
+	for _, n := range []NodeID{0, 5, 42} {
+		if got := g.Why(n); got != nil {
+			t.Errorf("Why(%d): got %v, want nil", n, got)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package replay records the calls a resolver makes to a resolve.Client, and
+replays them, so that a resolution can be reproduced without the original
+data source, such as when reporting a bug in a resolver.
+
+A Recorder wraps a Client, keeping the result of each distinct call. Its
+Recording is written as JSON, and read back to serve the same results with
+NewClient:
+
+	rec := replay.NewRecorder(resolve.NewAPIClient(insights))
+	g, err := npm.NewResolver(rec).Resolve(ctx, vk)
+	// ...
+	err = rec.Recording().Write(f)
+
+	r, err := replay.Read(f)
+	// ...
+	c, err := replay.NewClient(r)
+	// ...
+	g, err = npm.NewResolver(c).Resolve(ctx, vk)
+
+A replayed resolution that makes a call absent from the recording gets an
+error wrapping ErrNotRecorded, showing that it diverged from the recorded
+one.
+*/
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	apipb "deps.dev/api/v3"
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/version"
+)
+
+// ErrNotRecorded is returned, wrapped, by a replaying Client for calls
+// that are not in its recording.
+var ErrNotRecorded = errors.New("call not recorded")
+
+// The names of the methods of resolve.Client, as recorded.
+const (
+	methodVersion          = "Version"
+	methodVersions         = "Versions"
+	methodRequirements     = "Requirements"
+	methodMatchingVersions = "MatchingVersions"
+)
+
+// Recording holds the calls made to a Client, and their results.
+type Recording struct {
+	Calls []Call `json:"calls"`
+}
+
+// Call is a call made to a Client.
+type Call struct {
+	// Method is the name of the Client method called, such as
+	// "Requirements".
+	Method string `json:"method"`
+	// Key is the argument of the call; only its package key is set for
+	// calls to Versions.
+	Key VersionKey `json:"key"`
+	// Versions holds the result of calls to Version, Versions and
+	// MatchingVersions.
+	Versions []Version `json:"versions,omitempty"`
+	// Requirements holds the result of calls to Requirements.
+	Requirements []Requirement `json:"requirements,omitempty"`
+	// NotFound reports that the call failed with resolve.ErrNotFound.
+	NotFound bool `json:"notFound,omitempty"`
+	// Error is the message of any other error of the call.
+	Error string `json:"error,omitempty"`
+}
+
+// VersionKey is the JSON form of a resolve.VersionKey. Systems are named as
+// in the deps.dev API, such as "NPM", and version types by their names.
+type VersionKey struct {
+	System      string `json:"system"`
+	Name        string `json:"name"`
+	VersionType string `json:"versionType,omitempty"`
+	Version     string `json:"version,omitempty"`
+}
+
+// Version is a version returned by a call.
+type Version struct {
+	Key        VersionKey      `json:"key"`
+	Attributes version.AttrSet `json:"attributes,omitempty"`
+}
+
+// Requirement is a requirement returned by a call.
+type Requirement struct {
+	Key  VersionKey `json:"key"`
+	Type dep.Type   `json:"type,omitempty"`
+}
+
+func encodeKey(vk resolve.VersionKey) VersionKey {
+	k := VersionKey{
+		System:  vk.System.Proto().String(),
+		Name:    vk.Name,
+		Version: vk.Version,
+	}
+	if vk.VersionType != resolve.UnknownVersionType {
+		k.VersionType = vk.VersionType.String()
+	}
+	return k
+}
+
+func decodeKey(k VersionKey) (resolve.VersionKey, error) {
+	sys, ok := apipb.System_value[k.System]
+	if !ok {
+		return resolve.VersionKey{}, fmt.Errorf("unknown system %q", k.System)
+	}
+	vk := resolve.VersionKey{
+		PackageKey: resolve.PackageKey{System: resolve.System(sys), Name: k.Name},
+		Version:    k.Version,
+	}
+	switch k.VersionType {
+	case "":
+	case resolve.Concrete.String():
+		vk.VersionType = resolve.Concrete
+	case resolve.Requirement.String():
+		vk.VersionType = resolve.Requirement
+	default:
+		return resolve.VersionKey{}, fmt.Errorf("unknown version type %q", k.VersionType)
+	}
+	return vk, nil
+}
+
+// Write writes the recording as indented JSON.
+func (r *Recording) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Read reads a recording written by Write.
+func Read(r io.Reader) (*Recording, error) {
+	var rec Recording
+	if err := json.NewDecoder(r).Decode(&rec); err != nil {
+		return nil, fmt.Errorf("reading recording: %w", err)
+	}
+	return &rec, nil
+}
+
+// callKey identifies a call.
+type callKey struct {
+	method string
+	key    resolve.VersionKey
+}
+
+// Recorder is a resolve.Client that records the calls made to another. It
+// is safe for concurrent use if the wrapped client is.
+type Recorder struct {
+	client resolve.Client
+
+	mu    sync.Mutex
+	calls map[callKey]Call
+}
+
+// NewRecorder returns a Recorder of the calls made to c.
+func NewRecorder(c resolve.Client) *Recorder {
+	return &Recorder{client: c, calls: make(map[callKey]Call)}
+}
+
+// Recording returns the calls recorded so far, sorted by method and key so
+// that recordings of the same resolution are identical. A call made several
+// times is recorded once, with its first result.
+func (r *Recorder) Recording() *Recording {
+	r.mu.Lock()
+	keys := make([]callKey, 0, len(r.calls))
+	for k := range r.calls {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b callKey) int {
+		if a.method != b.method {
+			if a.method < b.method {
+				return -1
+			}
+			return 1
+		}
+		return a.key.Compare(b.key)
+	})
+	rec := &Recording{Calls: make([]Call, len(keys))}
+	for i, k := range keys {
+		rec.Calls[i] = r.calls[k]
+	}
+	r.mu.Unlock()
+	return rec
+}
+
+// record records the result of a call, unless already recorded.
+func (r *Recorder) record(method string, vk resolve.VersionKey, err error, fill func(*Call)) {
+	c := Call{Method: method, Key: encodeKey(vk)}
+	switch {
+	case errors.Is(err, resolve.ErrNotFound):
+		c.NotFound = true
+	case err != nil:
+		c.Error = err.Error()
+	default:
+		fill(&c)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := callKey{method, vk}
+	if _, ok := r.calls[k]; !ok {
+		r.calls[k] = c
+	}
+}
+
+func encodeVersions(vs []resolve.Version) []Version {
+	out := make([]Version, len(vs))
+	for i, v := range vs {
+		out[i] = Version{Key: encodeKey(v.VersionKey), Attributes: v.AttrSet.Clone()}
+	}
+	return out
+}
+
+func (r *Recorder) Version(ctx context.Context, vk resolve.VersionKey) (resolve.Version, error) {
+	v, err := r.client.Version(ctx, vk)
+	r.record(methodVersion, vk, err, func(c *Call) { c.Versions = encodeVersions([]resolve.Version{v}) })
+	return v, err
+}
+
+func (r *Recorder) Versions(ctx context.Context, pk resolve.PackageKey) ([]resolve.Version, error) {
+	vs, err := r.client.Versions(ctx, pk)
+	r.record(methodVersions, resolve.VersionKey{PackageKey: pk}, err, func(c *Call) { c.Versions = encodeVersions(vs) })
+	return vs, err
+}
+
+func (r *Recorder) Requirements(ctx context.Context, vk resolve.VersionKey) ([]resolve.RequirementVersion, error) {
+	reqs, err := r.client.Requirements(ctx, vk)
+	r.record(methodRequirements, vk, err, func(c *Call) {
+		c.Requirements = make([]Requirement, len(reqs))
+		for i, req := range reqs {
+			c.Requirements[i] = Requirement{Key: encodeKey(req.VersionKey), Type: req.Type.Clone()}
+		}
+	})
+	return reqs, err
+}
+
+func (r *Recorder) MatchingVersions(ctx context.Context, vk resolve.VersionKey) ([]resolve.Version, error) {
+	vs, err := r.client.MatchingVersions(ctx, vk)
+	r.record(methodMatchingVersions, vk, err, func(c *Call) { c.Versions = encodeVersions(vs) })
+	return vs, err
+}
+
+// replayClient is a resolve.Client serving the calls of a recording.
+type replayClient struct {
+	calls map[callKey]Call
+}
+
+// NewClient returns a resolve.Client serving the calls of the given
+// recording. It is safe for concurrent use.
+func NewClient(rec *Recording) (resolve.Client, error) {
+	c := &replayClient{calls: make(map[callKey]Call)}
+	for _, call := range rec.Calls {
+		switch call.Method {
+		case methodVersion, methodVersions, methodRequirements, methodMatchingVersions:
+		default:
+			return nil, fmt.Errorf("recorded call to unknown method %q", call.Method)
+		}
+		vk, err := decodeKey(call.Key)
+		if err != nil {
+			return nil, fmt.Errorf("recorded call to %s: %w", call.Method, err)
+		}
+		// Check the results now, so that replaying does not fail.
+		for _, v := range call.Versions {
+			if _, err := decodeKey(v.Key); err != nil {
+				return nil, fmt.Errorf("recorded call to %s %v: %w", call.Method, vk, err)
+			}
+		}
+		for _, r := range call.Requirements {
+			if _, err := decodeKey(r.Key); err != nil {
+				return nil, fmt.Errorf("recorded call to %s %v: %w", call.Method, vk, err)
+			}
+		}
+		c.calls[callKey{call.Method, vk}] = call
+	}
+	return c, nil
+}
+
+// call returns the recorded call, or its error.
+func (c *replayClient) call(method string, vk resolve.VersionKey) (Call, error) {
+	call, ok := c.calls[callKey{method, vk}]
+	switch {
+	case !ok:
+		return Call{}, fmt.Errorf("%s %v: %w", method, vk, ErrNotRecorded)
+	case call.NotFound:
+		return Call{}, fmt.Errorf("%s %v: %w", method, vk, resolve.ErrNotFound)
+	case call.Error != "":
+		return Call{}, fmt.Errorf("%s %v: %s", method, vk, call.Error)
+	}
+	return call, nil
+}
+
+// versions returns the versions of a call, which have been checked by
+// NewClient.
+func (c *replayClient) versions(method string, vk resolve.VersionKey) ([]resolve.Version, error) {
+	call, err := c.call(method, vk)
+	if err != nil {
+		return nil, err
+	}
+	vs := make([]resolve.Version, len(call.Versions))
+	for i, v := range call.Versions {
+		vs[i].VersionKey, _ = decodeKey(v.Key)
+		vs[i].AttrSet = v.Attributes.Clone()
+	}
+	return vs, nil
+}
+
+func (c *replayClient) Version(ctx context.Context, vk resolve.VersionKey) (resolve.Version, error) {
+	vs, err := c.versions(methodVersion, vk)
+	if err != nil {
+		return resolve.Version{}, err
+	}
+	if len(vs) != 1 {
+		return resolve.Version{}, fmt.Errorf("%s %v: recorded %d versions", methodVersion, vk, len(vs))
+	}
+	return vs[0], nil
+}
+
+func (c *replayClient) Versions(ctx context.Context, pk resolve.PackageKey) ([]resolve.Version, error) {
+	return c.versions(methodVersions, resolve.VersionKey{PackageKey: pk})
+}
+
+func (c *replayClient) Requirements(ctx context.Context, vk resolve.VersionKey) ([]resolve.RequirementVersion, error) {
+	call, err := c.call(methodRequirements, vk)
+	if err != nil {
+		return nil, err
+	}
+	reqs := make([]resolve.RequirementVersion, len(call.Requirements))
+	for i, r := range call.Requirements {
+		reqs[i].VersionKey, _ = decodeKey(r.Key)
+		reqs[i].Type = r.Type.Clone()
+	}
+	return reqs, nil
+}
+
+func (c *replayClient) MatchingVersions(ctx context.Context, vk resolve.VersionKey) ([]resolve.Version, error) {
+	return c.versions(methodMatchingVersions, vk)
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/npm"
+	"deps.dev/util/resolve/version"
+)
+
+func vk(name, v string, vt resolve.VersionType) resolve.VersionKey {
+	return resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: name},
+		VersionType: vt,
+		Version:     v,
+	}
+}
+
+func localClient() *resolve.LocalClient {
+	c := resolve.NewLocalClient()
+	var dev dep.Type
+	dev.AddAttr(dep.Dev, "")
+	c.AddVersion(resolve.Version{VersionKey: vk("alice", "1.0.0", resolve.Concrete)}, []resolve.RequirementVersion{
+		{VersionKey: vk("bob", "^1.0.0", resolve.Requirement)},
+		{VersionKey: vk("chuck", "^1.0.0", resolve.Requirement), Type: dev},
+	})
+	c.AddVersion(resolve.Version{VersionKey: vk("bob", "1.0.0", resolve.Concrete)}, nil)
+	var tags version.AttrSet
+	tags.SetAttr(version.Tags, "latest")
+	c.AddVersion(resolve.Version{VersionKey: vk("bob", "1.1.0", resolve.Concrete), AttrSet: tags}, []resolve.RequirementVersion{
+		{VersionKey: vk("chuck", "1.0.0", resolve.Requirement)},
+	})
+	c.AddVersion(resolve.Version{VersionKey: vk("chuck", "1.0.0", resolve.Concrete)}, nil)
+	return c
+}
+
+func TestRecordReplay(t *testing.T) {
+	ctx := context.Background()
+	root := vk("alice", "1.0.0", resolve.Concrete)
+	rec := NewRecorder(localClient())
+	want, err := npm.NewResolver(rec).Resolve(ctx, root)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if _, err := rec.Version(ctx, vk("eve", "1.0.0", resolve.Concrete)); !errors.Is(err, resolve.ErrNotFound) {
+		t.Fatalf("Version(eve): got %v, want not found", err)
+	}
+
+	var buf bytes.Buffer
+	if err := rec.Recording().Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// Recordings of the same resolution are identical.
+	var again bytes.Buffer
+	if err := rec.Recording().Write(&again); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if buf.String() != again.String() {
+		t.Errorf("recordings differ:\n%s\n%s", buf.String(), again.String())
+	}
+
+	r, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	c, err := NewClient(r)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	got, err := npm.NewResolver(c).Resolve(ctx, root)
+	if err != nil {
+		t.Fatalf("replayed Resolve: %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("replayed graph:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := c.Version(ctx, vk("eve", "1.0.0", resolve.Concrete)); !errors.Is(err, resolve.ErrNotFound) {
+		t.Errorf("replayed Version(eve): got %v, want not found", err)
+	}
+	if _, err := c.Requirements(ctx, vk("dave", "1.0.0", resolve.Concrete)); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("Requirements(dave): got %v, want not recorded", err)
+	}
+}
+
+func TestNewClientErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"calls": [{"method": "Resolve", "key": {"system": "NPM", "name": "alice"}}]}`,
+		`{"calls": [{"method": "Versions", "key": {"system": "X", "name": "alice"}}]}`,
+		`{"calls": [{"method": "Version", "key": {"system": "NPM", "name": "alice", "versionType": "X"}}]}`,
+		`{"calls": [{"method": "Versions", "key": {"system": "NPM", "name": "alice"}, "versions": [{"key": {"system": "X"}}]}]}`,
+	} {
+		r, err := Read(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("Read(%s): %v", data, err)
+		}
+		if _, err := NewClient(r); err == nil {
+			t.Errorf("NewClient(%s): got no error", data)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/maven"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/version"
+)
+
+// APIRequirements holds the requirements of a package version converted from
+// the response to the GetRequirements method of the deps.dev API.
+type APIRequirements struct {
+	// Requirements are the direct requirements of the version, in the
+	// form returned by Client.Requirements.
+	Requirements []RequirementVersion
+	// Bundled holds the versions bundled inside an npm package version,
+	// named with the mangled names the npm resolver expects, which
+	// should be considered opaque.
+	Bundled []BundledVersion
+}
+
+// BundledVersion is an npm package version found inside another, with its
+// own requirements.
+type BundledVersion struct {
+	Version
+	Requirements []RequirementVersion
+}
+
+// ConvertRequirements converts the requirements of the version vk, as
+// returned by GetRequirements, to the form used by the resolvers, so that
+// they may be added to a LocalClient. The dependency types carry the same
+// attributes as those produced by the manifest parsers: npm dependencies
+// are marked Dev, Opt or with the peer or bundle Scope, Maven dependencies
+// are described by MavenDepType, and NuGet dependencies carry the target
+// framework of their group in the Framework attribute.
+//
+// Maven requirements are converted with the default profiles merged and
+// the properties of the version itself interpolated, but without its
+// parents or imported dependency management, which would have to be
+// fetched; APIClient fetches them.
+func ConvertRequirements(vk VersionKey, reqs *pb.Requirements) (*APIRequirements, error) {
+	switch vk.System {
+	case NPM:
+		rs, bundled, err := convertNPMRequirements(vk, reqs.GetNpm())
+		if err != nil {
+			return nil, err
+		}
+		return &APIRequirements{Requirements: rs, Bundled: bundled}, nil
+	case Maven:
+		project, err := mavenProject(vk, reqs.GetMaven())
+		if err != nil {
+			return nil, err
+		}
+		if err := project.Interpolate(); err != nil {
+			return nil, err
+		}
+		project.ProcessDependencies(func(maven.String, maven.String, maven.String) (maven.DependencyManagement, error) {
+			return maven.DependencyManagement{}, nil
+		})
+		return &APIRequirements{Requirements: mavenProjectRequirements(project)}, nil
+	case NuGet:
+		return &APIRequirements{Requirements: convertNuGetRequirements(reqs.GetNuget())}, nil
+	}
+	return nil, fmt.Errorf("unsupported system %v", vk.System)
+}
+
+// AddTo adds v to lc with the requirements, along with any bundled versions.
+func (r *APIRequirements) AddTo(lc *LocalClient, v Version) {
+	lc.AddVersion(v, r.Requirements)
+	for _, bv := range r.Bundled {
+		lc.AddVersion(bv.Version, bv.Requirements)
+	}
+}
+
+// convertNPMRequirements returns the requirements of the npm package
+// version root, and the versions it bundles. Each bundled version is added
+// to the requirements of the version bundling it, under its mangled name.
+func convertNPMRequirements(root VersionKey, reqs *pb.Requirements_NPM) ([]RequirementVersion, []BundledVersion, error) {
+	rootDeps := flattenNPMDeps(reqs.GetDependencies())
+	type bundle struct {
+		vk           VersionKey
+		originalName string
+		deps         []RequirementVersion
+	}
+	allDeps := map[string]bundle{
+		root.Name: {vk: root, deps: rootDeps},
+	}
+	// Sort by the length of the path, so that we're guaranteed to process
+	// bundles closer to the root before their nested bundles.
+	bundled := append([]*pb.Requirements_NPM_Bundle(nil), reqs.GetBundled()...)
+	sort.SliceStable(bundled, func(i, j int) bool {
+		return len(bundled[i].Path) < len(bundled[j].Path)
+	})
+	for _, b := range bundled {
+		bundleDeps := flattenNPMDeps(b.Dependencies)
+		// For a package "b" bundled by package "a" which is itself
+		// bundled by root, the path will be
+		// "node_modules/a/node_modules/b".
+		pkgs := strings.Split(strings.TrimPrefix(b.Path, "node_modules/"), "/node_modules/")
+		mangled := mangledName(root, pkgs)
+		// Add a single Concrete version, and a Requirement version
+		// that matches.
+		bundleVK := VersionKey{
+			PackageKey: PackageKey{
+				System: NPM,
+				Name:   mangled,
+			},
+			VersionType: Concrete,
+			Version:     b.Version,
+		}
+		allDeps[mangled] = bundle{
+			vk:           bundleVK,
+			originalName: b.Name,
+			deps:         bundleDeps,
+		}
+		// Add this to the dependencies of the bundled package
+		// immediately preceding it (which could be the root).
+		parentName := root.Name
+		if i := len(pkgs) - 1; i > 0 {
+			parentName = mangledName(root, pkgs[:i])
+		}
+		parentBundle, ok := allDeps[parentName]
+		if !ok {
+			return nil, nil, fmt.Errorf("internal error: missing bundle parent for %s", mangled)
+		}
+		parentBundle.deps = append(parentBundle.deps, RequirementVersion{
+			VersionKey: VersionKey{
+				PackageKey:  bundleVK.PackageKey,
+				VersionType: Requirement,
+				Version:     b.Version,
+			},
+			Type: dep.NewType(),
+		})
+		allDeps[parentName] = parentBundle
+	}
+	var bvs []BundledVersion
+	for name, bundle := range allDeps {
+		if name == root.Name {
+			// This is not a bundled version.
+			continue
+		}
+		v := Version{VersionKey: bundle.vk}
+		v.SetAttr(version.DerivedFrom, bundle.originalName)
+		bvs = append(bvs, BundledVersion{Version: v, Requirements: bundle.deps})
+	}
+	sort.Slice(bvs, func(i, j int) bool {
+		return bvs[i].Name < bvs[j].Name
+	})
+	return allDeps[root.Name].deps, bvs, nil
+}
+
+// convertNuGetRequirements returns the requirements of a NuGet package
+// version, one for each dependency of each dependency group.
+func convertNuGetRequirements(reqs *pb.Requirements_NuGet) []RequirementVersion {
+	var rs []RequirementVersion
+	for _, g := range reqs.GetDependencyGroups() {
+		typ := dep.NewType()
+		if g.TargetFramework != "" {
+			typ = dep.NewBuilder().Framework(g.TargetFramework).Type()
+		}
+		for _, d := range g.Dependencies {
+			rs = append(rs, RequirementVersion{
+				VersionKey: VersionKey{
+					PackageKey: PackageKey{
+						System: NuGet,
+						Name:   d.Name,
+					},
+					VersionType: Requirement,
+					Version:     d.Requirement,
+				},
+				Type: typ.Clone(),
+			})
+		}
+	}
+	SortDependencies(rs)
+	return rs
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	pb "deps.dev/api/v3"
+	"deps.dev/util/resolve/internal/deptest"
+)
+
+func TestConvertRequirements(t *testing.T) {
+	key := func(sys System, vt VersionType, name, version string) VersionKey {
+		return VersionKey{
+			PackageKey:  PackageKey{System: sys, Name: name},
+			VersionType: vt,
+			Version:     version,
+		}
+	}
+	req := func(sys System, name, version, typ string) RequirementVersion {
+		dt, err := deptest.ParseString(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return RequirementVersion{VersionKey: key(sys, Requirement, name, version), Type: dt}
+	}
+
+	for _, c := range []struct {
+		vk   VersionKey
+		in   *pb.Requirements
+		want []RequirementVersion
+	}{{
+		vk: key(NuGet, Concrete, "Test", "1.0.0"),
+		in: &pb.Requirements{Nuget: &pb.Requirements_NuGet{
+			DependencyGroups: []*pb.Requirements_NuGet_DependencyGroup{{
+				Dependencies: []*pb.Requirements_NuGet_DependencyGroup_Dependency{
+					{Name: "Newtonsoft.Json", Requirement: "[13.0.1, )"},
+				},
+			}, {
+				TargetFramework: "net6.0",
+				Dependencies: []*pb.Requirements_NuGet_DependencyGroup_Dependency{
+					{Name: "System.Memory", Requirement: "[4.5.5, )"},
+				},
+			}},
+		}},
+		want: []RequirementVersion{
+			req(NuGet, "Newtonsoft.Json", "[13.0.1, )", ""),
+			req(NuGet, "System.Memory", "[4.5.5, )", "Framework net6.0"),
+		},
+	}, {
+		// Properties and dependency management of the version itself
+		// are applied.
+		vk: key(Maven, Concrete, "org.test:test", "1.0.0"),
+		in: &pb.Requirements{Maven: &pb.Requirements_Maven{
+			Properties: []*pb.Requirements_Maven_Property{
+				{Name: "aaa.version", Value: "1.2.3"},
+			},
+			DependencyManagement: []*pb.Requirements_Maven_Dependency{
+				{Name: "org.test:bbb", Version: "4.5.6"},
+			},
+			Dependencies: []*pb.Requirements_Maven_Dependency{
+				{Name: "org.test:aaa", Version: "${aaa.version}"},
+				{Name: "org.test:bbb", Scope: "test"},
+			},
+		}},
+		want: []RequirementVersion{
+			req(Maven, "org.test:aaa", "1.2.3", ""),
+			req(Maven, "org.test:bbb", "4.5.6", "test"),
+		},
+	}} {
+		got, err := ConvertRequirements(c.vk, c.in)
+		if err != nil {
+			t.Errorf("ConvertRequirements(%v): %v", c.vk, err)
+			continue
+		}
+		if diff := cmp.Diff(c.want, got.Requirements); diff != "" {
+			t.Errorf("ConvertRequirements(%v):\n(- want, + got):\n%s", c.vk, diff)
+		}
+	}
+
+	if _, err := ConvertRequirements(key(Cargo, Concrete, "test", "1.0.0"), &pb.Requirements{}); err == nil {
+		t.Errorf("ConvertRequirements for Cargo: got no error")
+	}
+}
+
+func TestAPIRequirementsAddTo(t *testing.T) {
+	ctx := context.Background()
+	root := Version{VersionKey: VersionKey{
+		PackageKey:  PackageKey{System: NPM, Name: "test"},
+		VersionType: Concrete,
+		Version:     "1.0.0",
+	}}
+	reqs, err := ConvertRequirements(root.VersionKey, &pb.Requirements{Npm: &pb.Requirements_NPM{
+		Dependencies: &pb.Requirements_NPM_Dependencies{
+			Dependencies: []*pb.Requirements_NPM_Dependencies_Dependency{
+				{Name: "a", Requirement: "^1.0.0"},
+			},
+			BundleDependencies: []string{"a"},
+		},
+		Bundled: []*pb.Requirements_NPM_Bundle{{
+			Path:    "node_modules/a",
+			Name:    "a",
+			Version: "1.0.1",
+			Dependencies: &pb.Requirements_NPM_Dependencies{
+				Dependencies: []*pb.Requirements_NPM_Dependencies_Dependency{
+					{Name: "b", Requirement: "^2.0.0"},
+				},
+			},
+		}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs.Bundled) != 1 || reqs.Bundled[0].Name != "test>1.0.0>a" {
+		t.Fatalf("got bundled versions %v, want test>1.0.0>a", reqs.Bundled)
+	}
+	lc := NewLocalClient()
+	reqs.AddTo(lc, root)
+	got, err := lc.Requirements(ctx, root.VersionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Errorf("root requirements: got %v, want a, a (bundled) and test>1.0.0>a", got)
+	}
+	vers, err := lc.MatchingVersions(ctx, VersionKey{
+		PackageKey:  PackageKey{System: NPM, Name: "test>1.0.0>a"},
+		VersionType: Requirement,
+		Version:     "1.0.1",
+	})
+	if err != nil || len(vers) != 1 {
+		t.Fatalf("bundled version: got %v, %v", vers, err)
+	}
+	bundledReqs, err := lc.Requirements(ctx, vers[0].VersionKey)
+	if err != nil || len(bundledReqs) != 1 || bundledReqs[0].Name != "b" {
+		t.Errorf("bundled requirements: got %v, %v, want b", bundledReqs, err)
+	}
+}
@@ -41,15 +41,28 @@ const (
 	UnknownSystem = System(apipb.System_SYSTEM_UNSPECIFIED)
 	NPM           = System(apipb.System_NPM)
 	Maven         = System(apipb.System_MAVEN)
+	Cargo         = System(apipb.System_CARGO)
+	NuGet         = System(apipb.System_NUGET)
+	Go            = System(apipb.System_GO)
+	PyPI          = System(apipb.System_PYPI)
 )
 
-// Semver returns the corresponding semver.System.
+// Semver returns the corresponding semver.System, or semver.DefaultSystem
+// if there is none. SemverSystem reports the lack of one as an error.
 func (s System) Semver() semver.System {
 	switch s {
 	case NPM:
 		return semver.NPM
 	case Maven:
 		return semver.Maven
+	case Cargo:
+		return semver.Cargo
+	case NuGet:
+		return semver.NuGet
+	case Go:
+		return semver.Go
+	case PyPI:
+		return semver.PyPI
 	}
 	return semver.DefaultSystem
 }
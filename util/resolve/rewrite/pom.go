@@ -0,0 +1,174 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rewrite
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"deps.dev/util/resolve"
+)
+
+// pomDependency is a dependency declared in a POM, with the bounds of the
+// text of its version.
+type pomDependency struct {
+	groupID, artifactID string
+	version             string
+	start, end          int
+}
+
+// pomText is the text of an element, with its bounds.
+type pomText struct {
+	text       string
+	start, end int
+}
+
+// POM applies the changes to a Maven pom.xml file, updating the versions of
+// the matching dependencies declared in its dependencies and
+// dependencyManagement sections, including those of its profiles. A version
+// given by a property of the project, such as ${guava.version}, is changed
+// by changing the property, which also changes any other dependency using
+// it. Packages are named groupId:artifactId.
+func POM(data []byte, changes []resolve.RequirementChange) ([]byte, error) {
+	changes, err := dedupe(changes)
+	if err != nil {
+		return nil, err
+	}
+	deps, props, err := scanPOM(data)
+	if err != nil {
+		return nil, err
+	}
+	var edits []edit
+	// The versions set for each property, to detect conflicting changes.
+	propVersions := make(map[string]string)
+	for _, c := range changes {
+		if err := check(c, resolve.Maven); err != nil {
+			return nil, err
+		}
+		found := false
+		for _, d := range deps {
+			if d.groupID+":"+d.artifactID != c.Package.Name || d.version == "" {
+				continue
+			}
+			found = true
+			name, ok := strings.CutPrefix(d.version, "${")
+			if !ok {
+				edits = append(edits, edit{d.start, d.end, c.Version})
+				continue
+			}
+			name = strings.TrimSuffix(name, "}")
+			p, ok := props[name]
+			if !ok {
+				return nil, fmt.Errorf("%s: version property %s is not defined in the project", c.Package.Name, name)
+			}
+			if v, ok := propVersions[name]; ok {
+				if v != c.Version {
+					return nil, fmt.Errorf("%s: property %s is already changed to %s", c.Package.Name, name, v)
+				}
+				continue
+			}
+			propVersions[name] = c.Version
+			edits = append(edits, edit{p.start, p.end, c.Version})
+		}
+		if !found {
+			return nil, notFound(c)
+		}
+	}
+	return apply(data, edits)
+}
+
+// scanPOM returns the dependencies declared in a POM and the properties of
+// the project.
+func scanPOM(data []byte) ([]pomDependency, map[string]pomText, error) {
+	var (
+		deps  []pomDependency
+		props = make(map[string]pomText)
+		stack []string
+		// The dependency being read, and the text of the current element.
+		cur  *pomDependency
+		text pomText
+	)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	for {
+		start := int(d.InputOffset())
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing pom.xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			text = pomText{start: int(d.InputOffset())}
+			text.end = text.start
+			if isDependency(stack) {
+				cur = &pomDependency{}
+			}
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+			raw := string(data[start:d.InputOffset()])
+			lead := len(raw) - len(strings.TrimLeft(raw, " \t\r\n"))
+			text.text = strings.TrimSpace(string(t))
+			text.start = start + lead
+			text.end = text.start + len(strings.TrimSpace(raw))
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, nil, errors.New("parsing pom.xml: unbalanced elements")
+			}
+			switch {
+			case isDependency(stack):
+				deps = append(deps, *cur)
+				cur = nil
+			case cur != nil && len(stack) >= 2 && isDependency(stack[:len(stack)-1]):
+				switch t.Name.Local {
+				case "groupId":
+					cur.groupID = text.text
+				case "artifactId":
+					cur.artifactID = text.text
+				case "version":
+					cur.version, cur.start, cur.end = text.text, text.start, text.end
+				}
+			case len(stack) == 3 && stack[0] == "project" && stack[1] == "properties":
+				props[t.Name.Local] = text
+			}
+			stack = stack[:len(stack)-1]
+			text = pomText{}
+		}
+	}
+	return deps, props, nil
+}
+
+// isDependency reports whether the elements of the stack lead to a
+// dependency of the project or of one of its profiles.
+func isDependency(stack []string) bool {
+	n := len(stack)
+	if n < 3 || stack[0] != "project" || stack[n-1] != "dependency" || stack[n-2] != "dependencies" {
+		return false
+	}
+	rest := stack[1 : n-2]
+	if len(rest) >= 2 && rest[0] == "profiles" && rest[1] == "profile" {
+		rest = rest[2:]
+	}
+	return len(rest) == 0 || len(rest) == 1 && rest[0] == "dependencyManagement"
+}
@@ -0,0 +1,352 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package rewrite applies changes to the requirements of a package, such as
+the upgrades suggested by the advisor package, to the manifest declaring
+them, so that they can be proposed as a change to the source.
+
+The manifests supported are package.json, requirements.txt, pom.xml and
+go.mod. Each is edited in place, replacing only the text of the requirement
+versions changed, so that the formatting, ordering and comments of the file
+are kept. Only existing requirements can be changed: a change adding or
+removing a requirement, or naming a package the manifest does not depend
+on, is an error, as are two changes setting different versions of the same
+package.
+*/
+package rewrite
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/npm/manifest"
+	"deps.dev/util/resolve/npm/spec"
+)
+
+// ErrNotFound is returned, wrapped, when a change names a package the
+// manifest has no requirement on.
+var ErrNotFound = errors.New("no such requirement")
+
+// File applies the changes to the manifest with the given name, choosing
+// the format from the base name of the file.
+func File(name string, data []byte, changes []resolve.RequirementChange) ([]byte, error) {
+	base := path.Base(strings.ReplaceAll(name, `\`, "/"))
+	switch {
+	case base == "package.json":
+		return PackageJSON(data, changes)
+	case base == "go.mod":
+		return GoMod(data, changes)
+	case base == "pom.xml" || strings.HasSuffix(base, ".pom"):
+		return POM(data, changes)
+	case strings.HasSuffix(base, ".txt") && strings.Contains(base, "requirements"):
+		return Requirements(data, changes)
+	}
+	return nil, fmt.Errorf("%s: unsupported manifest", name)
+}
+
+// edit replaces data[start:end] with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// apply returns a copy of data with the given edits applied. Repeated
+// edits are applied once; other overlapping edits are an error.
+func apply(data []byte, edits []edit) ([]byte, error) {
+	slices.SortFunc(edits, func(a, b edit) int {
+		return cmp.Or(cmp.Compare(a.start, b.start), cmp.Compare(a.end, b.end))
+	})
+	edits = slices.Compact(edits)
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			return nil, fmt.Errorf("conflicting edits at offset %d", e.start)
+		}
+		buf.Write(data[last:e.start])
+		buf.WriteString(e.text)
+		last = e.end
+	}
+	buf.Write(data[last:])
+	return buf.Bytes(), nil
+}
+
+// dedupe returns the changes without repeated ones, and an error if two
+// changes set different versions of the same package.
+func dedupe(changes []resolve.RequirementChange) ([]resolve.RequirementChange, error) {
+	versions := make(map[resolve.PackageKey]string)
+	var out []resolve.RequirementChange
+	for _, c := range changes {
+		if v, ok := versions[c.Package]; ok {
+			if v != c.Version {
+				return nil, fmt.Errorf("%s: conflicting changes to %q and %q", c.Package.Name, v, c.Version)
+			}
+			continue
+		}
+		versions[c.Package] = c.Version
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// check returns an error if a change is not a change of version to a
+// requirement of the given system.
+func check(c resolve.RequirementChange, sys resolve.System) error {
+	if c.Package.System != sys {
+		return fmt.Errorf("%s: not a %v package", c.Package.Name, sys)
+	}
+	if c.Version == "" {
+		return fmt.Errorf("%s: removing requirements is not supported", c.Package.Name)
+	}
+	return nil
+}
+
+func notFound(c resolve.RequirementChange) error {
+	return fmt.Errorf("%s: %w", c.Package.Name, ErrNotFound)
+}
+
+// PackageJSON applies the changes to a package.json file. Every declaration
+// of a changed package, in any of the dependency fields, is updated;
+// aliased dependencies keep their alias.
+func PackageJSON(data []byte, changes []resolve.RequirementChange) ([]byte, error) {
+	changes, err := dedupe(changes)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := manifest.Positions("package.json", data)
+	if err != nil {
+		return nil, err
+	}
+	lines := lineOffsets(data)
+	var edits []edit
+	for _, c := range changes {
+		if err := check(c, resolve.NPM); err != nil {
+			return nil, err
+		}
+		found := false
+		for vk, positions := range ps {
+			if vk.Name != c.Package.Name {
+				continue
+			}
+			for _, p := range positions {
+				start, end, ok := jsonValue(data, lines[p.Line-1]+p.Column-1)
+				if !ok {
+					// A name listed in bundleDependencies.
+					continue
+				}
+				text := c.Version
+				if sp := spec.Parse(c.Package.Name, string(data[start:end])); sp.Kind == spec.Alias {
+					text = "npm:" + sp.Name + "@" + c.Version
+				}
+				edits = append(edits, edit{start, end, text})
+				found = true
+			}
+		}
+		if !found {
+			return nil, notFound(c)
+		}
+	}
+	return apply(data, edits)
+}
+
+// lineOffsets returns the offsets of the starts of the lines of data.
+func lineOffsets(data []byte) []int {
+	offs := []int{0}
+	for i, b := range data {
+		if b == '\n' {
+			offs = append(offs, i+1)
+		}
+	}
+	return offs
+}
+
+// jsonValue returns the bounds of the contents of the string value of the
+// object member whose key starts at off, and whether there is one.
+func jsonValue(data []byte, off int) (start, end int, ok bool) {
+	i := stringEnd(data, off)
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	if i == len(data) || data[i] != ':' {
+		return 0, 0, false
+	}
+	i++
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	if i == len(data) || data[i] != '"' {
+		return 0, 0, false
+	}
+	end = stringEnd(data, i)
+	return i + 1, end - 1, true
+}
+
+// stringEnd returns the offset just past the JSON string starting at off.
+func stringEnd(data []byte, off int) int {
+	for i := off + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// GoMod applies the changes to a go.mod file, updating the versions of its
+// require directives. Replace directives are left alone.
+func GoMod(data []byte, changes []resolve.RequirementChange) ([]byte, error) {
+	changes, err := dedupe(changes)
+	if err != nil {
+		return nil, err
+	}
+	// Find the offset of the version of each requirement.
+	type span struct{ start, end int }
+	versions := make(map[string][]span)
+	lines := lineOffsets(data)
+	inBlock := false
+	for i, start := range lines {
+		end := len(data)
+		if i+1 < len(lines) {
+			end = lines[i+1]
+		}
+		line := string(data[start:end])
+		if j := strings.Index(line, "//"); j >= 0 {
+			line = line[:j]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case inBlock && len(fields) == 1 && fields[0] == ")":
+			inBlock = false
+			continue
+		case !inBlock && len(fields) == 2 && fields[0] == "require" && fields[1] == "(":
+			inBlock = true
+			continue
+		case inBlock && len(fields) == 2:
+		case !inBlock && len(fields) == 3 && fields[0] == "require":
+			fields = fields[1:]
+		default:
+			continue
+		}
+		path, version := strings.Trim(fields[0], `"`), fields[1]
+		off := strings.Index(line, fields[0]) + len(fields[0])
+		off += strings.Index(line[off:], version)
+		versions[path] = append(versions[path], span{start + off, start + off + len(version)})
+	}
+	var edits []edit
+	for _, c := range changes {
+		if err := check(c, resolve.Go); err != nil {
+			return nil, err
+		}
+		spans := versions[c.Package.Name]
+		if len(spans) == 0 {
+			return nil, notFound(c)
+		}
+		for _, s := range spans {
+			edits = append(edits, edit{s.start, s.end, c.Version})
+		}
+	}
+	return apply(data, edits)
+}
+
+// requirementLine matches a requirement of a requirements file, capturing
+// the name with its extras and the version specifier.
+var requirementLine = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)(\s*\[[^\]]*\])?([^;#]*)`)
+
+// Requirements applies the changes to a pip requirements file. A change to
+// a bare version replaces the version of a specifier with a single clause,
+// keeping its operator, and otherwise pins the version with ==; a change
+// starting with an operator replaces the whole specifier. Options, such as
+// -r, and URLs are left alone.
+func Requirements(data []byte, changes []resolve.RequirementChange) ([]byte, error) {
+	changes, err := dedupe(changes)
+	if err != nil {
+		return nil, err
+	}
+	type req struct {
+		spec       string
+		start, end int
+	}
+	reqs := make(map[string][]req)
+	lines := lineOffsets(data)
+	for i, start := range lines {
+		end := len(data)
+		if i+1 < len(lines) {
+			end = lines[i+1]
+		}
+		m := requirementLine.FindSubmatchIndex(data[start:end])
+		if m == nil {
+			continue
+		}
+		spec := string(data[start+m[6] : start+m[7]])
+		if strings.Contains(spec, "@") {
+			// A direct reference, name @ url.
+			continue
+		}
+		// Keep the whitespace around the specifier.
+		lead := len(spec) - len(strings.TrimLeft(spec, " \t"))
+		trimmed := strings.TrimSpace(spec)
+		s := start + m[6] + lead
+		name := normalize(string(data[start+m[2] : start+m[3]]))
+		reqs[name] = append(reqs[name], req{trimmed, s, s + len(trimmed)})
+	}
+	var edits []edit
+	for _, c := range changes {
+		if err := check(c, resolve.PyPI); err != nil {
+			return nil, err
+		}
+		rs := reqs[normalize(c.Package.Name)]
+		if len(rs) == 0 {
+			return nil, notFound(c)
+		}
+		for _, r := range rs {
+			edits = append(edits, edit{r.start, r.end, pipSpec(r.spec, c.Version)})
+		}
+	}
+	return apply(data, edits)
+}
+
+// pipSpec returns the specifier replacing old for the requirement version
+// v.
+func pipSpec(old, v string) string {
+	if strings.ContainsAny(v[:1], "=<>!~") {
+		return v
+	}
+	if old == "" || strings.Contains(old, ",") {
+		return "==" + v
+	}
+	rest := strings.TrimLeft(old, "=<>!~ \t")
+	return old[:len(old)-len(rest)] + v
+}
+
+// normalize returns the normalized form of a Python package name, as set
+// out by PEP 503.
+func normalize(name string) string {
+	return strings.ToLower(separators.ReplaceAllString(name, "-"))
+}
+
+var separators = regexp.MustCompile(`[-_.]+`)
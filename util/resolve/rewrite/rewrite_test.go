@@ -0,0 +1,297 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rewrite
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve"
+)
+
+func change(sys resolve.System, name, version string) resolve.RequirementChange {
+	return resolve.RequirementChange{
+		Package: resolve.PackageKey{System: sys, Name: name},
+		Version: version,
+	}
+}
+
+func TestFile(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		in      string
+		changes []resolve.RequirementChange
+		want    string
+	}{{
+		name: "package.json",
+		in: `{
+  "name": "app",
+  "dependencies": {
+    "bob":   "^1.0.0",
+    "alias": "npm:bob@^1.0.0",
+    "chuck": "~2.0.0"
+  },
+  "devDependencies": {"bob": "1.0.0"},
+  "bundleDependencies": ["bob"]
+}
+`,
+		changes: []resolve.RequirementChange{change(resolve.NPM, "bob", "^3.0.0")},
+		want: `{
+  "name": "app",
+  "dependencies": {
+    "bob":   "^3.0.0",
+    "alias": "npm:bob@^3.0.0",
+    "chuck": "~2.0.0"
+  },
+  "devDependencies": {"bob": "^3.0.0"},
+  "bundleDependencies": ["bob"]
+}
+`,
+	}, {
+		name: "package.json",
+		in: `{
+  "dependencies": {
+    "foo": "npm:bar",
+    "baz": "npm:@scope/bar",
+    "qux": "npm:@scope/bar@^1.0.0"
+  }
+}
+`,
+		changes: []resolve.RequirementChange{
+			change(resolve.NPM, "bar", "2.0.0"),
+			change(resolve.NPM, "@scope/bar", "^2.0.0"),
+		},
+		want: `{
+  "dependencies": {
+    "foo": "npm:bar@2.0.0",
+    "baz": "npm:@scope/bar@^2.0.0",
+    "qux": "npm:@scope/bar@^2.0.0"
+  }
+}
+`,
+	}, {
+		name: "go.mod",
+		in: `module example.com/app
+
+go 1.22
+
+require golang.org/x/text v0.3.0 // pinned for reasons
+
+require (
+	// The tests need it.
+	github.com/google/go-cmp v0.5.0
+	golang.org/x/net v0.1.0 // indirect
+)
+
+replace golang.org/x/net v0.1.0 => ../net
+`,
+		changes: []resolve.RequirementChange{
+			change(resolve.Go, "golang.org/x/text", "v0.14.0"),
+			change(resolve.Go, "golang.org/x/net", "v0.20.0"),
+		},
+		want: `module example.com/app
+
+go 1.22
+
+require golang.org/x/text v0.14.0 // pinned for reasons
+
+require (
+	// The tests need it.
+	github.com/google/go-cmp v0.5.0
+	golang.org/x/net v0.20.0 // indirect
+)
+
+replace golang.org/x/net v0.1.0 => ../net
+`,
+	}, {
+		name: "requirements.txt",
+		in: `# Runtime.
+-r base.txt
+Django >= 3.2  # LTS
+requests[socks]==2.25.0 ; python_version >= "3.8"
+urllib3
+six>=1.0,<2
+pip @ https://example.com/pip.whl
+`,
+		changes: []resolve.RequirementChange{
+			change(resolve.PyPI, "django", "4.2"),
+			change(resolve.PyPI, "requests", "2.31.0"),
+			change(resolve.PyPI, "urllib3", "2.0.7"),
+			change(resolve.PyPI, "six", "~=1.16"),
+		},
+		want: `# Runtime.
+-r base.txt
+Django >= 4.2  # LTS
+requests[socks]==2.31.0 ; python_version >= "3.8"
+urllib3==2.0.7
+six~=1.16
+pip @ https://example.com/pip.whl
+`,
+	}, {
+		name: "pom.xml",
+		in: `<?xml version="1.0" encoding="UTF-8"?>
+<project>
+  <properties>
+    <guava.version>31.0-jre</guava.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.google.guava</groupId>
+        <artifactId>guava</artifactId>
+        <version>${guava.version}</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <!-- Keep in sync with the IDE. -->
+      <version> 4.12 </version>
+      <scope>test</scope>
+    </dependency>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+    </dependency>
+  </dependencies>
+</project>
+`,
+		changes: []resolve.RequirementChange{
+			change(resolve.Maven, "com.google.guava:guava", "33.0.0-jre"),
+			change(resolve.Maven, "junit:junit", "4.13.2"),
+		},
+		want: `<?xml version="1.0" encoding="UTF-8"?>
+<project>
+  <properties>
+    <guava.version>33.0.0-jre</guava.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.google.guava</groupId>
+        <artifactId>guava</artifactId>
+        <version>${guava.version}</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <!-- Keep in sync with the IDE. -->
+      <version> 4.13.2 </version>
+      <scope>test</scope>
+    </dependency>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+    </dependency>
+  </dependencies>
+</project>
+`,
+	}} {
+		got, err := File("dir/"+c.name, []byte(c.in), c.changes)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if diff := cmp.Diff(c.want, string(got)); diff != "" {
+			t.Errorf("%s (-want +got):\n%s", c.name, diff)
+		}
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	pkg := []byte(`{"dependencies": {"bob": "^1.0.0"}}`)
+	if _, err := PackageJSON(pkg, []resolve.RequirementChange{change(resolve.NPM, "alice", "1.0.0")}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing package: got %v, want ErrNotFound", err)
+	}
+	if _, err := PackageJSON(pkg, []resolve.RequirementChange{change(resolve.NPM, "bob", "")}); err == nil {
+		t.Errorf("removal succeeded")
+	}
+	if _, err := PackageJSON(pkg, []resolve.RequirementChange{change(resolve.Maven, "bob", "1.0.0")}); err == nil {
+		t.Errorf("Maven change to package.json succeeded")
+	}
+	if _, err := File("Cargo.toml", nil, nil); err == nil {
+		t.Errorf("Cargo.toml succeeded")
+	}
+	pom := []byte(`<project>
+  <properties><v>1</v></properties>
+  <dependencies>
+    <dependency><groupId>g</groupId><artifactId>a</artifactId><version>${v}</version></dependency>
+    <dependency><groupId>g</groupId><artifactId>b</artifactId><version>${v}</version></dependency>
+  </dependencies>
+</project>`)
+	changes := []resolve.RequirementChange{
+		change(resolve.Maven, "g:a", "2"),
+		change(resolve.Maven, "g:b", "3"),
+	}
+	if _, err := POM(pom, changes); err == nil {
+		t.Errorf("conflicting property changes succeeded")
+	}
+
+	// Repeated changes are applied once, conflicting ones are an error.
+	gomod := []byte("module m\n\nrequire example.com/a v1.0.0\n")
+	for _, test := range []struct {
+		name string
+		fn   func([]byte, []resolve.RequirementChange) ([]byte, error)
+		data []byte
+		sys  resolve.System
+		pkg  string
+		want string
+	}{
+		{"package.json", PackageJSON, pkg, resolve.NPM, "bob", `{"dependencies": {"bob": "^2.0.0"}}`},
+		{"go.mod", GoMod, gomod, resolve.Go, "example.com/a", "module m\n\nrequire example.com/a v1.2.0\n"},
+	} {
+		version := "^2.0.0"
+		if test.sys == resolve.Go {
+			version = "v1.2.0"
+		}
+		got, err := test.fn(test.data, []resolve.RequirementChange{
+			change(test.sys, test.pkg, version),
+			change(test.sys, test.pkg, version),
+		})
+		if err != nil {
+			t.Errorf("%s: repeated change: %v", test.name, err)
+		} else if string(got) != test.want {
+			t.Errorf("%s: repeated change: got %q, want %q", test.name, got, test.want)
+		}
+		_, err = test.fn(test.data, []resolve.RequirementChange{
+			change(test.sys, test.pkg, version),
+			change(test.sys, test.pkg, "v3.0.0"),
+		})
+		if err == nil {
+			t.Errorf("%s: conflicting changes succeeded", test.name)
+		}
+	}
+}
+
+func TestApplyOverlap(t *testing.T) {
+	data := []byte("0123456789")
+	got, err := apply(data, []edit{{2, 4, "ab"}, {6, 8, "cd"}, {2, 4, "ab"}})
+	if err != nil || string(got) != "01ab45cd89" {
+		t.Errorf("apply with repeated edits: got %q, %v, want %q", got, err, "01ab45cd89")
+	}
+	if got, err := apply(data, []edit{{2, 6, "ab"}, {4, 8, "cd"}}); err == nil {
+		t.Errorf("apply with overlapping edits: got %q, want error", got)
+	}
+	if got, err := apply(data, []edit{{2, 4, "ab"}, {2, 4, "cd"}}); err == nil {
+		t.Errorf("apply with conflicting edits: got %q, want error", got)
+	}
+}
@@ -49,6 +49,8 @@ type resolveRow struct {
 	label, name, requirement, concrete string
 	// dt holds the dependency type of the edge.
 	dt dep.Type
+	// attrs holds the attributes of the node.
+	attrs dep.Type
 	// err holds the node error parsed by [ERROR: ].
 	err string
 }
@@ -90,6 +92,14 @@ edge)
 		name4@v4 v4
 			[DepType|]$label@v2-5
 
+A node defining line may end with the attributes of the node, using the same
+syntax as dependency types, for example to hold two nodes of the same version
+that are different artifacts:
+
+	name1 v1
+		name2@v2 v2
+		mavenclassifier tests|name2@v2 v2 [mavenclassifier tests]
+
 The first line defines the graph root. It may or may not have a label:
 
 	[label: ]name concrete
@@ -97,7 +107,7 @@ The first line defines the graph root. It may or may not have a label:
 Pattern of other lines, defining nodes, contains tabulation to implicitly
 create an edge:
 
-	tabs [label: ]name@requirement concrete
+	tabs [label: ]name@requirement concrete[ [Attrs]]
 
 Pattern for rows referring a node by label:
 
@@ -146,7 +156,7 @@ func ParseResolve(text string, sys resolve.System) (*resolve.Graph, error) {
 			VersionType: resolve.Concrete,
 			Version:     r.concrete,
 		}
-		nodes[i] = g.AddNode(vk)
+		nodes[i] = g.AddArtifactNode(vk, r.attrs)
 	}
 
 	// Create edges.
@@ -233,6 +243,16 @@ func parseResolve(text string) (*resolveSchema, error) {
 			tl = tl[:i]
 		}
 
+		// The optional node attributes come last.
+		if i := strings.LastIndex(tl, " ["); i != -1 && strings.HasSuffix(tl, "]") {
+			var err error
+			r.attrs, err = deptest.ParseString(tl[i+2 : len(tl)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", r.line, err)
+			}
+			tl = strings.TrimSpace(tl[:i])
+		}
+
 		// The optional defining label comes first.
 		if i := strings.Index(tl, ": "); i != -1 {
 			r.label = tl[:i]
@@ -325,6 +325,20 @@ alice 1
 			},
 			err: errors.New("not found"),
 		},
+		{
+			title: "artifacts",
+			schema: `
+alice 1
+	bob@r1 2
+	bob@r1 2 [mavenclassifier tests]
+	chuck@r2 3 [mavenartifacttype pom knownas "c k"]
+`,
+			want: `alice 1
+├─ bob@r1 2
+├─ bob@r1 2 [mavenclassifier tests]
+└─ chuck@r2 3 [mavenartifacttype pom knownas "c k"]
+`,
+		},
 		{
 			title: "simple with scopes",
 			schema: `
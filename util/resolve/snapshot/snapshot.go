@@ -0,0 +1,248 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package snapshot provides a resolve.Client serving package versions and
+their requirements from a snapshot of deps.dev data held in files, so that
+dependencies can be resolved and analyzed without network access, such as
+in air-gapped environments.
+
+A snapshot is a set of newline-delimited JSON files, optionally compressed
+with gzip, holding one Row per package version. This is the form of a
+BigQuery export of the deps.dev public dataset, once a query has shaped its
+rows as below, and the files may be copied from Cloud Storage or produced
+by any other means:
+
+	{"system": "NPM", "name": "alice", "version": "1.0.0",
+	 "attributes": {"Tags": "latest"},
+	 "requirements": [{"name": "bob", "requirement": "^1.0.0"},
+	                  {"name": "chuck", "requirement": "^2.0.0", "type": {"Dev": ""}}]}
+
+Open reads the files matching glob patterns, such as the shards of an
+export:
+
+	c, err := snapshot.Open("/data/deps.dev/npm-*.json.gz")
+	if err != nil {
+		// ...
+	}
+	g, err := npm.NewResolver(c).Resolve(ctx, vk)
+*/
+package snapshot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	apipb "deps.dev/api/v3"
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/version"
+)
+
+// Row is a package version in a snapshot.
+type Row struct {
+	// System is the name of the packaging system, as in the deps.dev API,
+	// such as "NPM" or "MAVEN".
+	System  string `json:"system"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Attributes are the attributes of the version, keyed by name, as in
+	// the JSON form of version.AttrSet.
+	Attributes version.AttrSet `json:"attributes,omitempty"`
+	// Requirements are the requirements of the version. A row without
+	// requirements, as opposed to an empty list, is a version whose
+	// requirements are not in the snapshot.
+	Requirements []Requirement `json:"requirements"`
+}
+
+// Requirement is a requirement of a package version in a snapshot.
+type Requirement struct {
+	// System is the packaging system of the required package. The
+	// default is that of the requiring version.
+	System      string `json:"system,omitempty"`
+	Name        string `json:"name"`
+	Requirement string `json:"requirement"`
+	// Type is the type of the dependency, as in the JSON form of
+	// dep.Type, such as {"Dev": ""}.
+	Type dep.Type `json:"type,omitempty"`
+}
+
+// Client is a resolve.Client serving the package versions of a snapshot.
+// It must not be modified by Read while in use; once loaded, it is safe
+// for concurrent use.
+type Client struct {
+	local *resolve.LocalClient
+	// noRequirements holds the versions whose requirements are not in
+	// the snapshot.
+	noRequirements map[resolve.VersionKey]bool
+}
+
+// New returns an empty Client.
+func New() *Client {
+	return &Client{
+		local:          resolve.NewLocalClient(),
+		noRequirements: make(map[resolve.VersionKey]bool),
+	}
+}
+
+// Open returns a Client serving the rows of the files matching the given
+// glob patterns. An error is returned if a pattern matches no file.
+func Open(patterns ...string) (*Client, error) {
+	c := New()
+	for _, p := range patterns {
+		names, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%s: no such snapshot files", p)
+		}
+		for _, name := range names {
+			if err := c.readFile(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c *Client) readFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := c.Read(f); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Read adds the rows read from r, which may be compressed with gzip. A row
+// for a version already in the client replaces it.
+func (c *Client) Read(r io.Reader) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		br = bufio.NewReader(zr)
+	}
+	d := json.NewDecoder(br)
+	for line := 1; ; line++ {
+		var row Row
+		err := d.Decode(&row)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("row %d: %w", line, err)
+		}
+		if err := c.Add(row); err != nil {
+			return fmt.Errorf("row %d: %w", line, err)
+		}
+	}
+}
+
+// Add adds a row to the client, replacing any row of the same version.
+func (c *Client) Add(row Row) error {
+	sys, err := system(row.System)
+	if err != nil {
+		return err
+	}
+	if row.Name == "" || row.Version == "" {
+		return fmt.Errorf("missing name or version")
+	}
+	vk := resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: sys, Name: row.Name},
+		VersionType: resolve.Concrete,
+		Version:     row.Version,
+	}
+	var reqs []resolve.RequirementVersion
+	for _, r := range row.Requirements {
+		rsys := sys
+		if r.System != "" {
+			if rsys, err = system(r.System); err != nil {
+				return err
+			}
+		}
+		reqs = append(reqs, resolve.RequirementVersion{
+			VersionKey: resolve.VersionKey{
+				PackageKey:  resolve.PackageKey{System: rsys, Name: r.Name},
+				VersionType: resolve.Requirement,
+				Version:     r.Requirement,
+			},
+			Type: r.Type.Clone(),
+		})
+	}
+	// LocalClient keeps the attributes of a version it already holds, so
+	// remove it first.
+	vs := c.local.PackageVersions[vk.PackageKey]
+	for i, v := range vs {
+		if v.VersionKey == vk {
+			c.local.PackageVersions[vk.PackageKey] = append(vs[:i:i], vs[i+1:]...)
+			break
+		}
+	}
+	c.local.AddVersion(resolve.Version{VersionKey: vk, AttrSet: row.Attributes}, reqs)
+	if row.Requirements == nil {
+		c.noRequirements[vk] = true
+	} else {
+		delete(c.noRequirements, vk)
+	}
+	return nil
+}
+
+// system returns the system of the given API name.
+func system(name string) (resolve.System, error) {
+	v, ok := apipb.System_value[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown system %q", name)
+	}
+	return resolve.SystemFromProto(apipb.System(v))
+}
+
+// Version implements resolve.Client.
+func (c *Client) Version(ctx context.Context, vk resolve.VersionKey) (resolve.Version, error) {
+	return c.local.Version(ctx, vk)
+}
+
+// Versions implements resolve.Client, returning the versions of a package
+// in the snapshot. A package that is only required by versions of the
+// snapshot has no versions, rather than not being found.
+func (c *Client) Versions(ctx context.Context, pk resolve.PackageKey) ([]resolve.Version, error) {
+	return c.local.Versions(ctx, pk)
+}
+
+// Requirements implements resolve.Client. It returns resolve.ErrNotFound
+// for a version whose requirements are not in the snapshot.
+func (c *Client) Requirements(ctx context.Context, vk resolve.VersionKey) ([]resolve.RequirementVersion, error) {
+	if c.noRequirements[vk] {
+		return nil, fmt.Errorf("requirements of %v: %w", vk, resolve.ErrNotFound)
+	}
+	return c.local.Requirements(ctx, vk)
+}
+
+// MatchingVersions implements resolve.Client.
+func (c *Client) MatchingVersions(ctx context.Context, vk resolve.VersionKey) ([]resolve.Version, error) {
+	return c.local.MatchingVersions(ctx, vk)
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"deps.dev/util/resolve"
+	"deps.dev/util/resolve/dep"
+	"deps.dev/util/resolve/npm"
+	"deps.dev/util/resolve/version"
+)
+
+const rows = `{"system": "NPM", "name": "alice", "version": "1.0.0", "requirements": [{"name": "bob", "requirement": "^1.0.0"}, {"name": "chuck", "requirement": "^1.0.0", "type": {"Dev": ""}}]}
+{"system": "NPM", "name": "bob", "version": "1.0.0", "requirements": []}
+{"system": "NPM", "name": "bob", "version": "1.1.0", "attributes": {"Tags": "latest"}, "requirements": [{"name": "chuck", "requirement": "1.0.0"}]}
+`
+
+const moreRows = `{"system": "NPM", "name": "chuck", "version": "1.0.0", "requirements": []}
+{"system": "NPM", "name": "dave", "version": "1.0.0"}
+`
+
+func vk(name, v string) resolve.VersionKey {
+	return resolve.VersionKey{
+		PackageKey:  resolve.PackageKey{System: resolve.NPM, Name: name},
+		VersionType: resolve.Concrete,
+		Version:     v,
+	}
+}
+
+func TestOpenAndResolve(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "npm-000.json"), []byte(rows), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "npm-001.json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	zw.Write([]byte(moreRows))
+	zw.Close()
+	f.Close()
+
+	c, err := Open(filepath.Join(dir, "npm-*"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	ctx := context.Background()
+
+	v, err := c.Version(ctx, vk("bob", "1.1.0"))
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if tags, _ := v.GetAttr(version.Tags); tags != "latest" {
+		t.Errorf("Version(bob 1.1.0): got tags %q, want latest", tags)
+	}
+	reqs, err := c.Requirements(ctx, vk("alice", "1.0.0"))
+	if err != nil {
+		t.Fatalf("Requirements: %v", err)
+	}
+	if len(reqs) != 2 || !reqs[1].Type.HasAttr(dep.Dev) {
+		t.Errorf("Requirements(alice 1.0.0): got %v", reqs)
+	}
+	if _, err := c.Requirements(ctx, vk("dave", "1.0.0")); !errors.Is(err, resolve.ErrNotFound) {
+		t.Errorf("Requirements(dave 1.0.0): got %v, want not found", err)
+	}
+	if _, err := c.Version(ctx, vk("eve", "1.0.0")); !errors.Is(err, resolve.ErrNotFound) {
+		t.Errorf("Version(eve 1.0.0): got %v, want not found", err)
+	}
+
+	g, err := npm.NewResolver(c).Resolve(ctx, vk("alice", "1.0.0"))
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	var got []string
+	for _, n := range g.Nodes {
+		got = append(got, n.Version.Name+"@"+n.Version.Version)
+	}
+	want := "alice@1.0.0 bob@1.1.0 chuck@1.0.0"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Resolve: got nodes %v, want %s", got, want)
+	}
+}
+
+func TestAddReplaces(t *testing.T) {
+	c := New()
+	if err := c.Read(strings.NewReader(rows)); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	err := c.Add(Row{System: "NPM", Name: "bob", Version: "1.1.0", Requirements: []Requirement{}})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	ctx := context.Background()
+	v, err := c.Version(ctx, vk("bob", "1.1.0"))
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if v.HasAttr(version.Tags) {
+		t.Errorf("replaced version kept its tags")
+	}
+	if vs, _ := c.Versions(ctx, vk("bob", "").PackageKey); len(vs) != 2 {
+		t.Errorf("Versions(bob): got %v, want two versions", vs)
+	}
+	if reqs, err := c.Requirements(ctx, vk("bob", "1.1.0")); err != nil || len(reqs) != 0 {
+		t.Errorf("Requirements(bob 1.1.0): got %v, %v, want none", reqs, err)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"system": "NPM", "name": "alice"`,
+		`{"system": "RUBYGEMS", "name": "alice", "version": "1.0.0"}`,
+		`{"system": "NPM", "name": "alice", "version": ""}`,
+		`{"system": "NPM", "name": "alice", "version": "1.0.0", "requirements": [{"system": "X", "name": "bob"}]}`,
+		`{"system": "NPM", "name": "alice", "version": "1.0.0", "attributes": {"Unknown": ""}}`,
+	} {
+		if err := New().Read(strings.NewReader(data)); err == nil {
+			t.Errorf("Read(%s): got no error", data)
+		}
+	}
+	if _, err := Open(filepath.Join(t.TempDir(), "*.json")); err == nil {
+		t.Errorf("Open with no matching files: got no error")
+	}
+}
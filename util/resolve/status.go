@@ -0,0 +1,119 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"deps.dev/util/resolve/version"
+)
+
+// VersionStatus records the state of a version in its registry that makes
+// it a poor choice of dependency, as set on the nodes of a graph by
+// Annotate. The zero value is a version in good standing.
+type VersionStatus uint8
+
+const (
+	// StatusBlocked marks a version that is blocked for resolution, as
+	// a deprecated version in npm or a yanked version in Cargo.
+	StatusBlocked VersionStatus = 1 << iota
+	// StatusDeleted marks a version that has been deleted upstream.
+	StatusDeleted
+)
+
+var statusNames = []struct {
+	s    VersionStatus
+	name string
+}{
+	{StatusBlocked, "blocked"},
+	{StatusDeleted, "deleted"},
+}
+
+// Names returns the names of the flags set in s, such as "blocked".
+func (s VersionStatus) Names() []string {
+	var names []string
+	for _, sn := range statusNames {
+		if s&sn.s != 0 {
+			names = append(names, sn.name)
+		}
+	}
+	return names
+}
+
+// String returns the names of the flags set in s, separated by |, or the
+// empty string if none is.
+func (s VersionStatus) String() string {
+	return strings.Join(s.Names(), "|")
+}
+
+// parseStatus returns the status with the given flag names set, ignoring
+// unknown names.
+func parseStatus(names []string) VersionStatus {
+	var s VersionStatus
+	for _, n := range names {
+		for _, sn := range statusNames {
+			if n == sn.name {
+				s |= sn.s
+			}
+		}
+	}
+	return s
+}
+
+// statusOf returns the status of a version from its attributes.
+func statusOf(attrs version.AttrSet) VersionStatus {
+	var s VersionStatus
+	if attrs.Blocked() {
+		s |= StatusBlocked
+	}
+	if attrs.HasAttr(version.Deleted) {
+		s |= StatusDeleted
+	}
+	return s
+}
+
+// Annotate sets the Status of every node of the graph from the attributes
+// of its version, fetched with the given client. Resolvers may pick
+// blocked versions, such as when no other version satisfies a requirement
+// or when a lockfile pins them, so a resolved graph can hold them. Nodes
+// whose versions are unknown to the client are left unchanged.
+func (g *Graph) Annotate(ctx context.Context, c Client) error {
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		v, err := c.Version(ctx, n.Version)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		n.Status = statusOf(v.AttrSet)
+	}
+	return nil
+}
+
+// Flagged returns the nodes whose Status is set, excluding the root, in
+// node order.
+func (g *Graph) Flagged() []NodeID {
+	var ids []NodeID
+	for i, n := range g.Nodes {
+		if i > 0 && n.Status != 0 {
+			ids = append(ids, NodeID(i))
+		}
+	}
+	return ids
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"deps.dev/util/resolve/version"
+)
+
+// versionClient serves versions from a map. Unlike a LocalClient, it keeps
+// deleted versions.
+type versionClient struct {
+	Client
+	versions map[VersionKey]Version
+}
+
+func (c versionClient) Version(ctx context.Context, vk VersionKey) (Version, error) {
+	v, ok := c.versions[vk]
+	if !ok {
+		return Version{}, ErrNotFound
+	}
+	return v, nil
+}
+
+func TestAnnotate(t *testing.T) {
+	concrete := func(name, v string) VersionKey {
+		return VersionKey{PackageKey: PackageKey{System: NPM, Name: name}, VersionType: Concrete, Version: v}
+	}
+	c := versionClient{versions: make(map[VersionKey]Version)}
+	var blocked, deleted, both version.AttrSet
+	blocked.SetAttr(version.Blocked, "")
+	deleted.SetAttr(version.Deleted, "")
+	both.SetAttr(version.Blocked, "")
+	both.SetAttr(version.Deleted, "")
+	for _, v := range []Version{
+		{VersionKey: concrete("root", "1.0.0"), AttrSet: blocked},
+		{VersionKey: concrete("fine", "1.0.0")},
+		{VersionKey: concrete("deprecated", "1.0.0"), AttrSet: blocked},
+		{VersionKey: concrete("gone", "1.0.0"), AttrSet: deleted},
+		{VersionKey: concrete("both", "1.0.0"), AttrSet: both},
+	} {
+		c.versions[v.VersionKey] = v
+	}
+
+	var g Graph
+	for _, name := range []string{"root", "fine", "deprecated", "gone", "both", "unknown"} {
+		g.AddNode(concrete(name, "1.0.0"))
+	}
+	if err := g.Annotate(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range g.Nodes {
+		got = append(got, n.Status.String())
+	}
+	if want := []string{"blocked", "", "blocked", "deleted", "blocked|deleted", ""}; !slices.Equal(got, want) {
+		t.Errorf("statuses: got %q, want %q", got, want)
+	}
+	// The root is not reported.
+	if got, want := g.Flagged(), []NodeID{2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("Flagged: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// The NDJSON form of a Graph holds one JSON object per line, so that large
+// graphs can be written and read a row at a time. The first row describes
+// the graph as a whole, and is followed by a row for each node, in node
+// order, then a row for each edge:
+//
+//	{"graph":{"nodes":2,"edges":1}}
+//	{"node":{"id":0,"version":{...}}}
+//	{"node":{"id":1,"version":{...}}}
+//	{"edge":{"from":0,"to":1,"requirement":"^1.0.0","type":{}}}
+//
+// Nodes and edges are encoded as in the JSON form of a Graph.
+
+type rowJSON struct {
+	Graph *graphHeaderJSON `json:"graph,omitempty"`
+	Node  *nodeRowJSON     `json:"node,omitempty"`
+	Edge  *edgeJSON        `json:"edge,omitempty"`
+}
+
+type graphHeaderJSON struct {
+	Nodes    int         `json:"nodes"`
+	Edges    int         `json:"edges"`
+	Error    string      `json:"error,omitempty"`
+	Errs     []errorJSON `json:"errs,omitempty"`
+	Duration string      `json:"duration,omitempty"`
+}
+
+type nodeRowJSON struct {
+	ID NodeID `json:"id"`
+	nodeJSON
+}
+
+// WriteNDJSON writes the graph to w in its NDJSON form, a row at a time,
+// without holding the whole encoding in memory.
+func (g *Graph) WriteNDJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	h := &graphHeaderJSON{
+		Nodes: len(g.Nodes),
+		Edges: len(g.Edges),
+		Error: g.Error,
+		Errs:  encodeErrors(g.Err),
+	}
+	if g.Duration != 0 {
+		h.Duration = g.Duration.String()
+	}
+	if err := enc.Encode(rowJSON{Graph: h}); err != nil {
+		return err
+	}
+	for id, n := range g.AllNodes() {
+		if err := enc.Encode(rowJSON{Node: &nodeRowJSON{ID: id, nodeJSON: encodeNode(n)}}); err != nil {
+			return err
+		}
+	}
+	for e := range g.AllEdges() {
+		ej := encodeEdge(e)
+		if err := enc.Encode(rowJSON{Edge: &ej}); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadNDJSON reads a graph written by WriteNDJSON.
+func ReadNDJSON(r io.Reader) (*Graph, error) {
+	var g *Graph
+	for row, err := range NDJSONRows(r) {
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case row.Header != nil:
+			if g != nil {
+				return nil, errors.New("repeated graph row")
+			}
+			g = row.Header
+			g.Nodes = make([]Node, 0, row.NodeCount)
+			g.Edges = make([]Edge, 0, row.EdgeCount)
+		case g == nil:
+			return nil, errors.New("missing graph row")
+		case row.Node != nil:
+			if int(row.ID) != len(g.Nodes) {
+				return nil, fmt.Errorf("node %d out of order", row.ID)
+			}
+			g.Nodes = append(g.Nodes, *row.Node)
+		case row.Edge != nil:
+			e := row.Edge
+			if err := g.AddEdge(e.From, e.To, e.Requirement, e.Type); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if g == nil {
+		return nil, errors.New("missing graph row")
+	}
+	return g, nil
+}
+
+// Row is a row of the NDJSON form of a Graph. Exactly one of Header, Node
+// and Edge is set.
+type Row struct {
+	// Header holds the error and duration of the graph, without nodes or
+	// edges; NodeCount and EdgeCount hold the numbers of nodes and edges
+	// that follow.
+	Header               *Graph
+	NodeCount, EdgeCount int
+	// Node is the node of the given ID.
+	Node *Node
+	ID   NodeID
+	Edge *Edge
+}
+
+// NDJSONRows returns an iterator over the rows of a graph written by
+// WriteNDJSON, decoding them one at a time, so that a graph can be
+// processed without being held in memory. The iteration stops after the
+// first error.
+func NDJSONRows(r io.Reader) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		dec := json.NewDecoder(r)
+		for line := 1; ; line++ {
+			var rj rowJSON
+			if err := dec.Decode(&rj); err == io.EOF {
+				return
+			} else if err != nil {
+				yield(Row{}, fmt.Errorf("row %d: %w", line, err))
+				return
+			}
+			row, err := decodeRow(rj)
+			if err != nil {
+				err = fmt.Errorf("row %d: %w", line, err)
+			}
+			if !yield(row, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func decodeRow(rj rowJSON) (Row, error) {
+	switch {
+	case rj.Graph != nil:
+		g := &Graph{}
+		if err := g.decodeHeader(rj.Graph.Error, rj.Graph.Errs, rj.Graph.Duration); err != nil {
+			return Row{}, err
+		}
+		return Row{Header: g, NodeCount: rj.Graph.Nodes, EdgeCount: rj.Graph.Edges}, nil
+	case rj.Node != nil:
+		n, err := decodeNode(rj.Node.nodeJSON)
+		if err != nil {
+			return Row{}, fmt.Errorf("node %d: %w", rj.Node.ID, err)
+		}
+		return Row{Node: &n, ID: rj.Node.ID}, nil
+	case rj.Edge != nil:
+		return Row{Edge: &Edge{
+			From:        rj.Edge.From,
+			To:          rj.Edge.To,
+			Requirement: rj.Edge.Requirement,
+			Type:        rj.Edge.Type,
+		}}, nil
+	}
+	return Row{}, errors.New("empty row")
+}
+
+// AllNodes returns an iterator over the nodes of the graph and their IDs,
+// in node order.
+func (g *Graph) AllNodes() iter.Seq2[NodeID, Node] {
+	return func(yield func(NodeID, Node) bool) {
+		for i, n := range g.Nodes {
+			if !yield(NodeID(i), n) {
+				return
+			}
+		}
+	}
+}
+
+// AllEdges returns an iterator over the edges of the graph, in edge order.
+func (g *Graph) AllEdges() iter.Seq[Edge] {
+	return func(yield func(Edge) bool) {
+		for _, e := range g.Edges {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// EdgesFrom returns an iterator over the edges from the given node, in
+// edge order. Unlike Dependencies, it does not allocate.
+func (g *Graph) EdgesFrom(n NodeID) iter.Seq[Edge] {
+	return func(yield func(Edge) bool) {
+		for _, e := range g.Edges {
+			if e.From == n && !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// Reachable returns an iterator over the nodes reachable from the root,
+// starting with the root, in breadth-first order. It visits the edges of
+// the graph once, holding a bit per node and an index of the edges by
+// node.
+func (g *Graph) Reachable() iter.Seq[NodeID] {
+	return func(yield func(NodeID) bool) {
+		if len(g.Nodes) == 0 {
+			return
+		}
+		// start[n]:start[n+1] indexes the edges from n in out.
+		start := make([]int32, len(g.Nodes)+1)
+		for _, e := range g.Edges {
+			if g.contains(e.From) && g.contains(e.To) {
+				start[e.From+1]++
+			}
+		}
+		for i := 1; i < len(start); i++ {
+			start[i] += start[i-1]
+		}
+		out := make([]NodeID, start[len(g.Nodes)])
+		next := append([]int32(nil), start[:len(g.Nodes)]...)
+		for _, e := range g.Edges {
+			if g.contains(e.From) && g.contains(e.To) {
+				out[next[e.From]] = e.To
+				next[e.From]++
+			}
+		}
+		seen := make([]bool, len(g.Nodes))
+		seen[0] = true
+		queue := []NodeID{0}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			if !yield(n) {
+				return
+			}
+			for _, m := range out[start[n]:start[n+1]] {
+				if !seen[m] {
+					seen[m] = true
+					queue = append(queue, m)
+				}
+			}
+		}
+	}
+}
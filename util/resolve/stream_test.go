@@ -0,0 +1,145 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"deps.dev/util/resolve/dep"
+)
+
+func TestNDJSON(t *testing.T) {
+	concrete := func(name, version string) VersionKey {
+		return VersionKey{PackageKey: PackageKey{System: NPM, Name: name}, VersionType: Concrete, Version: version}
+	}
+	var g Graph
+	alice := g.AddNode(concrete("alice", "1.0.0"))
+	bob := g.AddNode(concrete("bob", "1.0.0"))
+	chuck := g.AddNode(concrete("chuck", "2.0.0"))
+	for _, e := range []struct {
+		from, to NodeID
+		req      string
+	}{
+		{alice, bob, "^1.0.0"},
+		{alice, chuck, "^2.0.0"},
+		{bob, chuck, "2.0.0"},
+	} {
+		if err := g.AddEdge(e.from, e.to, e.req, dep.NewType(dep.Dev)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := VersionKey{PackageKey: PackageKey{System: NPM, Name: "dave"}, VersionType: Requirement, Version: "^3.0.0"}
+	if err := g.AddNodeError(bob, req, &UnsatisfiedRequirement{Requirement: req}); err != nil {
+		t.Fatal(err)
+	}
+	g.Nodes[chuck].Status = StatusBlocked
+	g.Error = "graph exceeds the limit of 3 nodes"
+	g.Err = &LimitError{Limit: NodeLimit, Max: 3}
+	g.Duration = 1500 * time.Millisecond
+
+	var buf bytes.Buffer
+	if err := g.WriteNDJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1+len(g.Nodes)+len(g.Edges) {
+		t.Fatalf("got %d rows, want %d:\n%s", len(lines), 1+len(g.Nodes)+len(g.Edges), buf.String())
+	}
+	for i, prefix := range []string{`{"graph":{"nodes":3,"edges":3,`, `{"node":{"id":0,`, `{"node":{"id":1,`, `{"node":{"id":2,`, `{"edge":{"from":0,"to":1,`} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("row %d: got %s, want prefix %s", i, lines[i], prefix)
+		}
+	}
+
+	got, err := ReadNDJSON(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(g, *got); diff != "" {
+		t.Errorf("round trip (-want +got):\n%s", diff)
+	}
+
+	// The rows can be consumed without building the graph.
+	edges := 0
+	for row, err := range NDJSONRows(bytes.NewReader(buf.Bytes())) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if row.Edge != nil {
+			edges++
+		}
+	}
+	if edges != 3 {
+		t.Errorf("NDJSONRows: got %d edges, want 3", edges)
+	}
+
+	for _, bad := range []string{
+		``,
+		`{"node":{"id":0,"version":{"system":"NPM","versionType":"Concrete"}}}`,
+		`{"graph":{"nodes":1,"edges":0}}` + "\n" + `{"node":{"id":1,"version":{"system":"NPM","versionType":"Concrete"}}}`,
+		`{"graph":{"nodes":0,"edges":1}}` + "\n" + `{"edge":{"from":0,"to":1}}`,
+		`{"graph":{"nodes":0,"edges":0}}` + "\n" + `{}`,
+		`{"graph":`,
+	} {
+		if _, err := ReadNDJSON(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadNDJSON(%s): got no error", bad)
+		}
+	}
+}
+
+func TestIterators(t *testing.T) {
+	var g Graph
+	for _, name := range []string{"root", "a", "b", "c", "unreachable"} {
+		g.AddNode(VersionKey{PackageKey: PackageKey{System: NPM, Name: name}, VersionType: Concrete, Version: "1.0.0"})
+	}
+	for _, e := range [][2]NodeID{{0, 2}, {0, 1}, {1, 3}, {3, 1}, {4, 0}} {
+		if err := g.AddEdge(e[0], e[1], "*", dep.Type{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var names []string
+	for id, n := range g.AllNodes() {
+		if id == 3 {
+			break
+		}
+		names = append(names, n.Version.Name)
+	}
+	if want := []string{"root", "a", "b"}; !slices.Equal(names, want) {
+		t.Errorf("AllNodes: got %v, want %v", names, want)
+	}
+	if n := len(slices.Collect(g.AllEdges())); n != 5 {
+		t.Errorf("AllEdges: got %d edges, want 5", n)
+	}
+	var tos []NodeID
+	for e := range g.EdgesFrom(0) {
+		tos = append(tos, e.To)
+	}
+	if want := []NodeID{2, 1}; !slices.Equal(tos, want) {
+		t.Errorf("EdgesFrom(0): got %v, want %v", tos, want)
+	}
+	if got, want := slices.Collect(g.Reachable()), []NodeID{0, 2, 1, 3}; !slices.Equal(got, want) {
+		t.Errorf("Reachable: got %v, want %v", got, want)
+	}
+	if got := slices.Collect((&Graph{}).Reachable()); len(got) != 0 {
+		t.Errorf("Reachable of an empty graph: got %v", got)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"fmt"
+	"strings"
+
+	apipb "deps.dev/api/v3"
+	"deps.dev/util/semver"
+)
+
+// Systems holds every known System, in the order of their values.
+var Systems = []System{Go, NPM, Cargo, Maven, PyPI, NuGet}
+
+// IsValid reports whether s is one of the known systems.
+func (s System) IsValid() bool {
+	switch s {
+	case Go, NPM, Cargo, Maven, PyPI, NuGet:
+		return true
+	}
+	return false
+}
+
+// Proto returns the API enum value of s.
+func (s System) Proto() apipb.System {
+	return apipb.System(s)
+}
+
+// SemverSystem returns the semver.System used to parse the versions and
+// requirements of s, or an error if s is not a known system.
+func (s System) SemverSystem() (semver.System, error) {
+	if !s.IsValid() {
+		return semver.DefaultSystem, fmt.Errorf("unknown system %v", s)
+	}
+	return s.Semver(), nil
+}
+
+// SystemFromProto returns the System of the API enum value s, or an error
+// if s is unspecified or unknown.
+func SystemFromProto(s apipb.System) (System, error) {
+	if s < 0 || s > 0xff || !System(s).IsValid() {
+		return UnknownSystem, fmt.Errorf("unknown system %v", s)
+	}
+	return System(s), nil
+}
+
+// SystemFromSemver returns the System whose versions are parsed by s, or an
+// error if there is none. semver.DefaultSystem has no System of its own.
+func SystemFromSemver(s semver.System) (System, error) {
+	switch s {
+	case semver.Go:
+		return Go, nil
+	case semver.NPM:
+		return NPM, nil
+	case semver.Cargo:
+		return Cargo, nil
+	case semver.Maven:
+		return Maven, nil
+	case semver.PyPI:
+		return PyPI, nil
+	case semver.NuGet:
+		return NuGet, nil
+	}
+	return UnknownSystem, fmt.Errorf("no system corresponds to %v", s)
+}
+
+// ParseSystem returns the System with the given name, matched without
+// regard to case, as either the name of the System or that of the API enum
+// value, such as "NuGet" or "NUGET".
+func ParseSystem(name string) (System, error) {
+	for _, s := range Systems {
+		if strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return UnknownSystem, fmt.Errorf("unknown system %q", name)
+}
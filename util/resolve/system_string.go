@@ -11,22 +11,34 @@ func _() {
 	_ = x[UnknownSystem-0]
 	_ = x[NPM-3]
 	_ = x[Maven-6]
+	_ = x[Cargo-4]
+	_ = x[NuGet-8]
+	_ = x[Go-1]
+	_ = x[PyPI-7]
 }
 
 const (
-	_System_name_0 = "UnknownSystem"
-	_System_name_1 = "NPM"
-	_System_name_2 = "Maven"
+	_System_name_0 = "UnknownSystemGo"
+	_System_name_1 = "NPMCargo"
+	_System_name_2 = "MavenPyPINuGet"
+)
+
+var (
+	_System_index_0 = [...]uint8{0, 13, 15}
+	_System_index_1 = [...]uint8{0, 3, 8}
+	_System_index_2 = [...]uint8{0, 5, 9, 14}
 )
 
 func (i System) String() string {
 	switch {
-	case i == 0:
-		return _System_name_0
-	case i == 3:
-		return _System_name_1
-	case i == 6:
-		return _System_name_2
+	case i <= 1:
+		return _System_name_0[_System_index_0[i]:_System_index_0[i+1]]
+	case 3 <= i && i <= 4:
+		i -= 3
+		return _System_name_1[_System_index_1[i]:_System_index_1[i+1]]
+	case 6 <= i && i <= 8:
+		i -= 6
+		return _System_name_2[_System_index_2[i]:_System_index_2[i+1]]
 	default:
 		return "System(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
@@ -0,0 +1,82 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	apipb "deps.dev/api/v3"
+	"deps.dev/util/semver"
+)
+
+// TestSystemsExhaustive checks that every system of the API has a System,
+// and that the conversions between the three enums agree.
+func TestSystemsExhaustive(t *testing.T) {
+	for v, name := range apipb.System_name {
+		ps := apipb.System(v)
+		sys, err := SystemFromProto(ps)
+		if ps == apipb.System_SYSTEM_UNSPECIFIED {
+			if err == nil {
+				t.Errorf("SystemFromProto(%v): got %v, want error", ps, sys)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SystemFromProto(%v): %v", ps, err)
+			continue
+		}
+		if got := sys.Proto(); got != ps {
+			t.Errorf("%v.Proto() = %v, want %v", sys, got, ps)
+		}
+		if got, err := ParseSystem(name); err != nil || got != sys {
+			t.Errorf("ParseSystem(%q) = %v, %v, want %v", name, got, err, sys)
+		}
+		if got, err := ParseSystem(sys.String()); err != nil || got != sys {
+			t.Errorf("ParseSystem(%q) = %v, %v, want %v", sys.String(), got, err, sys)
+		}
+		ss, err := sys.SemverSystem()
+		if err != nil {
+			t.Errorf("%v.SemverSystem(): %v", sys, err)
+			continue
+		}
+		if got, err := SystemFromSemver(ss); err != nil || got != sys {
+			t.Errorf("SystemFromSemver(%v) = %v, %v, want %v", ss, got, err, sys)
+		}
+	}
+	if got, want := len(Systems), len(apipb.System_name)-1; got != want {
+		t.Errorf("got %d Systems, want %d", got, want)
+	}
+}
+
+func TestSystemErrors(t *testing.T) {
+	for _, ps := range []apipb.System{-1, 2, 5, 256 + 3} {
+		if sys, err := SystemFromProto(ps); err == nil {
+			t.Errorf("SystemFromProto(%d): got %v, want error", ps, sys)
+		}
+	}
+	if ss, err := UnknownSystem.SemverSystem(); err == nil {
+		t.Errorf("UnknownSystem.SemverSystem(): got %v, want error", ss)
+	}
+	for _, ss := range []semver.System{semver.DefaultSystem, semver.RubyGems, semver.Debian} {
+		if sys, err := SystemFromSemver(ss); err == nil {
+			t.Errorf("SystemFromSemver(%v): got %v, want error", ss, sys)
+		}
+	}
+	for _, name := range []string{"", "SYSTEM_UNSPECIFIED", "UnknownSystem", "cobol"} {
+		if sys, err := ParseSystem(name); err == nil {
+			t.Errorf("ParseSystem(%q): got %v, want error", name, sys)
+		}
+	}
+}
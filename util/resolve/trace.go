@@ -0,0 +1,149 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// EventKind identifies the kind of an Event.
+type EventKind byte
+
+const (
+	// ClientCallEvent reports a call to the Client.
+	ClientCallEvent EventKind = iota + 1
+	// PinEvent reports that a requirement has been resolved to a concrete
+	// version.
+	PinEvent
+	// BacktrackEvent reports that a previous decision has been undone.
+	BacktrackEvent
+	// ConflictEvent reports that a requirement could not be resolved
+	// because of the versions already chosen.
+	ConflictEvent
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case ClientCallEvent:
+		return "call"
+	case PinEvent:
+		return "pin"
+	case BacktrackEvent:
+		return "backtrack"
+	case ConflictEvent:
+		return "conflict"
+	}
+	return fmt.Sprintf("EventKind(%d)", byte(k))
+}
+
+// Event describes a step of a resolution. Only the fields relevant to its
+// Kind are set.
+type Event struct {
+	Kind EventKind
+	// From is the concrete version declaring the requirement.
+	From VersionKey
+	// Requirement is the requirement being resolved. For client calls, it
+	// is the argument of the call; only its PackageKey is set for calls to
+	// Versions.
+	Requirement VersionKey
+	// Version is the concrete version that is picked, dropped, or
+	// conflicting with the requirement.
+	Version VersionKey
+	// Method is the name of the Client method, for client calls.
+	Method string
+	// Err is the error returned by a client call.
+	Err error
+	// Message gives details about the event.
+	Message string
+}
+
+func (e Event) String() string {
+	var b strings.Builder
+	b.WriteString(e.Kind.String())
+	if e.Method != "" {
+		fmt.Fprintf(&b, " %s", e.Method)
+	}
+	if e.From != (VersionKey{}) {
+		fmt.Fprintf(&b, " from %s", e.From)
+	}
+	if e.Requirement != (VersionKey{}) {
+		fmt.Fprintf(&b, " %s", e.Requirement)
+	}
+	if e.Version != (VersionKey{}) {
+		fmt.Fprintf(&b, " -> %s", e.Version)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	return b.String()
+}
+
+// Tracer receives the events of resolutions. It must be safe for concurrent
+// use if the Resolver is used concurrently.
+type Tracer interface {
+	Trace(Event)
+}
+
+// TracerFunc adapts a function to a Tracer.
+type TracerFunc func(Event)
+
+func (f TracerFunc) Trace(e Event) { f(e) }
+
+// Trace reports the event to the configured Tracer, if any.
+func (o Options) Trace(e Event) {
+	if o.Tracer != nil {
+		o.Tracer.Trace(e)
+	}
+}
+
+// TraceClient returns a Client that reports every call to the given client
+// as a ClientCallEvent.
+func TraceClient(c Client, t Tracer) Client {
+	return &tracingClient{client: c, tracer: t}
+}
+
+type tracingClient struct {
+	client Client
+	tracer Tracer
+}
+
+func (c *tracingClient) Version(ctx context.Context, vk VersionKey) (Version, error) {
+	v, err := c.client.Version(ctx, vk)
+	c.tracer.Trace(Event{Kind: ClientCallEvent, Method: "Version", Requirement: vk, Err: err})
+	return v, err
+}
+
+func (c *tracingClient) Versions(ctx context.Context, pk PackageKey) ([]Version, error) {
+	vs, err := c.client.Versions(ctx, pk)
+	c.tracer.Trace(Event{Kind: ClientCallEvent, Method: "Versions", Requirement: VersionKey{PackageKey: pk}, Err: err})
+	return vs, err
+}
+
+func (c *tracingClient) Requirements(ctx context.Context, vk VersionKey) ([]RequirementVersion, error) {
+	reqs, err := c.client.Requirements(ctx, vk)
+	c.tracer.Trace(Event{Kind: ClientCallEvent, Method: "Requirements", Requirement: vk, Err: err})
+	return reqs, err
+}
+
+func (c *tracingClient) MatchingVersions(ctx context.Context, vk VersionKey) ([]Version, error) {
+	vs, err := c.client.MatchingVersions(ctx, vk)
+	c.tracer.Trace(Event{Kind: ClientCallEvent, Method: "MatchingVersions", Requirement: vk, Err: err})
+	return vs, err
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestTraceClient(t *testing.T) {
+	pk := PackageKey{System: NPM, Name: "alice"}
+	vk := VersionKey{PackageKey: pk, VersionType: Concrete, Version: "1.0.0"}
+	lc := NewLocalClient()
+	lc.AddVersion(Version{VersionKey: vk}, nil)
+
+	var got []Event
+	c := TraceClient(lc, TracerFunc(func(e Event) { got = append(got, e) }))
+	ctx := context.Background()
+	if _, err := c.Version(ctx, vk); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Versions(ctx, pk); err != nil {
+		t.Fatal(err)
+	}
+	missing := vk
+	missing.Version = "2.0.0"
+	if _, err := c.Requirements(ctx, missing); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Requirements: got %v, want %v", err, ErrNotFound)
+	}
+	want := []Event{
+		{Kind: ClientCallEvent, Method: "Version", Requirement: vk},
+		{Kind: ClientCallEvent, Method: "Versions", Requirement: VersionKey{PackageKey: pk}},
+		{Kind: ClientCallEvent, Method: "Requirements", Requirement: missing, Err: ErrNotFound},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("events (-want +got):\n%s", diff)
+	}
+}
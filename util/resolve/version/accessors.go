@@ -0,0 +1,121 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+// RegistryKind is the role of a registry in the Registries attribute.
+type RegistryKind byte
+
+const (
+	// RegistryFetch is a registry in which the version can be found. The
+	// empty ID denotes the default registry of the system.
+	RegistryFetch RegistryKind = iota
+	// RegistryDependency is a registry in which the dependencies of the
+	// version can be fetched. It is encoded with the "dep:" prefix.
+	RegistryDependency
+	// RegistryDefault is the registry replacing the default registry of
+	// the system. It is encoded with the "default:" prefix.
+	RegistryDefault
+)
+
+// Registry is an entry of the Registries attribute.
+type Registry struct {
+	Kind RegistryKind
+	// ID identifies the registry, such as a repository ID or URL.
+	ID string
+}
+
+var registryPrefixes = map[RegistryKind]string{
+	RegistryDependency: "dep:",
+	RegistryDefault:    "default:",
+}
+
+// String returns the encoded form of the registry.
+func (r Registry) String() string {
+	return registryPrefixes[r.Kind] + r.ID
+}
+
+// ParseRegistry decodes a registry from its encoded form.
+func ParseRegistry(s string) Registry {
+	s = strings.TrimSpace(s)
+	for _, k := range []RegistryKind{RegistryDependency, RegistryDefault} {
+		if id, ok := strings.CutPrefix(s, registryPrefixes[k]); ok {
+			return Registry{Kind: k, ID: id}
+		}
+	}
+	return Registry{Kind: RegistryFetch, ID: s}
+}
+
+// Registries returns the registries of the Registries attribute, in order,
+// or nil if the attribute is not set.
+func (s AttrSet) Registries() []Registry {
+	v, ok := s.GetAttr(Registries)
+	if !ok {
+		return nil
+	}
+	var regs []Registry
+	for _, r := range strings.Split(v, "|") {
+		regs = append(regs, ParseRegistry(r))
+	}
+	return regs
+}
+
+// SetRegistries sets the Registries attribute to the given registries.
+// If there are none, the set is left unchanged.
+func (s *AttrSet) SetRegistries(regs []Registry) {
+	if len(regs) == 0 {
+		return
+	}
+	encoded := make([]string, len(regs))
+	for i, r := range regs {
+		encoded[i] = r.String()
+	}
+	s.SetAttr(Registries, strings.Join(encoded, "|"))
+}
+
+// Blocked reports whether the version is blocked for resolution.
+func (s AttrSet) Blocked() bool {
+	return s.HasAttr(Blocked)
+}
+
+// DerivedFrom returns the name of the package from which the version is
+// derived, if any.
+func (s AttrSet) DerivedFrom() (string, bool) {
+	return s.GetAttr(DerivedFrom)
+}
+
+// Created returns the time the version was created, if known.
+func (s AttrSet) Created() (time.Time, bool) {
+	v, ok := s.GetAttr(Created)
+	if !ok {
+		return time.Time{}, false
+	}
+	secs, n := binary.Varint([]byte(v))
+	if n <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(secs, 0).UTC(), true
+}
+
+// SetCreated sets the Created attribute to the given time, truncated to
+// the second.
+func (s *AttrSet) SetCreated(t time.Time) {
+	s.SetAttr(Created, string(binary.AppendVarint(nil, t.Unix())))
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegistries(t *testing.T) {
+	tests := []struct {
+		attr string
+		want []Registry
+	}{
+		{"", []Registry{{RegistryFetch, ""}}},
+		{"central", []Registry{{RegistryFetch, "central"}}},
+		{"a|dep:https://repo.example.com|default:b", []Registry{
+			{RegistryFetch, "a"},
+			{RegistryDependency, "https://repo.example.com"},
+			{RegistryDefault, "b"},
+		}},
+	}
+	for _, test := range tests {
+		s := newAttrSet(Registries, test.attr)
+		got := s.Registries()
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Registries of %q (-want +got):\n%s", test.attr, diff)
+		}
+		var set AttrSet
+		set.SetRegistries(got)
+		if v, _ := set.GetAttr(Registries); v != test.attr {
+			t.Errorf("SetRegistries(%v): got %q, want %q", got, v, test.attr)
+		}
+	}
+	var empty AttrSet
+	if got := empty.Registries(); got != nil {
+		t.Errorf("Registries of empty set: got %v, want nil", got)
+	}
+	empty.SetRegistries(nil)
+	if !empty.Empty() {
+		t.Errorf("SetRegistries(nil): got %v, want empty set", empty)
+	}
+}
+
+func TestFlagAccessors(t *testing.T) {
+	s := newAttrSet(Blocked, "", DerivedFrom, "bob")
+	if !s.Blocked() {
+		t.Errorf("Blocked: got false, want true")
+	}
+	if name, ok := s.DerivedFrom(); !ok || name != "bob" {
+		t.Errorf("DerivedFrom: got %q, %v, want bob", name, ok)
+	}
+	var empty AttrSet
+	if _, ok := empty.DerivedFrom(); empty.Blocked() || ok {
+		t.Errorf("empty set: got Blocked or DerivedFrom")
+	}
+}
+
+func TestCreated(t *testing.T) {
+	var s AttrSet
+	if _, ok := s.Created(); ok {
+		t.Errorf("empty set: got Created")
+	}
+	for _, want := range []time.Time{
+		time.Date(2021, 12, 9, 17, 4, 5, 0, time.UTC),
+		time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC),
+	} {
+		s.SetCreated(want.Add(300 * time.Millisecond))
+		if got, ok := s.Created(); !ok || !got.Equal(want) {
+			t.Errorf("Created() = %v, %v, want %v", got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// attrKeys maps the names of the AttrKeys to their values.
+var attrKeys = func() map[string]AttrKey {
+	m := make(map[string]AttrKey)
+	for k := -(1 << (maskLen - 1)); k <= math.MaxInt8; k++ {
+		if name := AttrKey(k).String(); !strings.HasPrefix(name, "AttrKey(") {
+			m[name] = AttrKey(k)
+		}
+	}
+	return m
+}()
+
+// isBinary reports whether the values of the given key are binary rather
+// than text.
+func isBinary(k AttrKey) bool {
+	return k == Ident || k == Created
+}
+
+// MarshalJSON encodes the AttrSet as a JSON object mapping the names of its
+// attributes, such as "Blocked" or "Registries", to their values. The
+// values of Ident and Created, which are binary, are encoded in base64.
+func (s AttrSet) MarshalJSON() ([]byte, error) {
+	m := make(map[string]string)
+	s.ForEachAttr(func(k AttrKey, v string) {
+		if isBinary(k) {
+			v = base64.StdEncoding.EncodeToString([]byte(v))
+		}
+		m[k.String()] = v
+	})
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes an AttrSet encoded by MarshalJSON.
+func (s *AttrSet) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*s = AttrSet{}
+	for name, v := range m {
+		k, ok := attrKeys[name]
+		if !ok {
+			return fmt.Errorf("unknown version attribute %q", name)
+		}
+		if isBinary(k) {
+			b, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return fmt.Errorf("version attribute %s: %w", name, err)
+			}
+			v = string(b)
+		}
+		s.SetAttr(k, v)
+	}
+	return nil
+}
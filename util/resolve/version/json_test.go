@@ -0,0 +1,51 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAttrSetJSON(t *testing.T) {
+	for _, tc := range []struct {
+		set  AttrSet
+		want string
+	}{
+		{AttrSet{}, `{}`},
+		{newAttrSet(Blocked, "", Tags, "latest,next"), `{"Blocked":"","Tags":"latest,next"}`},
+		// Binary values are base64-encoded.
+		{newAttrSet(Created, "\x80\xe1\xeb\x17", Ident, "\x00\xff"), `{"Created":"gOHrFw==","Ident":"AP8="}`},
+	} {
+		data, err := json.Marshal(tc.set)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.want {
+			t.Errorf("Marshal(%v): got %s, want %s", tc.set, data, tc.want)
+		}
+		var got AttrSet
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(tc.set) {
+			t.Errorf("round trip of %v: got %v", tc.set, got)
+		}
+	}
+	var got AttrSet
+	if err := json.Unmarshal([]byte(`{"Ident":"not base64!"}`), &got); err == nil {
+		t.Errorf("Unmarshal of malformed binary value: got no error")
+	}
+}
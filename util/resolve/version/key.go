@@ -81,8 +81,10 @@ const (
 
 	// Registries specifies the registries where the version can be found and
 	// the registries in which the dependencies can be fetched.
-	// In Maven, this is a comma separated list of registry IDs, and dependency
-	// registries are prefixed with "dep:".
+	// In Maven, this is a pipe separated list of registry IDs, dependency
+	// registries are prefixed with "dep:" and a registry replacing the
+	// default one with "default:". Use AttrSet.Registries and
+	// AttrSet.SetRegistries rather than this encoding.
 	Registries AttrKey = 5
 
 	// SupportedFrameworks specifies what dotnet target frameworks this
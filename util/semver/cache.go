@@ -0,0 +1,107 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import "sync"
+
+// DefaultConstraintCacheSize is the number of constraints held by the
+// cache used by ParseConstraintCached.
+const DefaultConstraintCacheSize = 1 << 14
+
+// sharedConstraints is the cache used by ParseConstraintCached, shared by
+// all its callers, such as the resolvers.
+var sharedConstraints = NewConstraintCache(DefaultConstraintCacheSize)
+
+// ParseConstraintCached is like ParseConstraint, but keeps the results,
+// including errors, in a cache shared by the whole program, so that the
+// constraints found again and again in the requirements of packages are
+// parsed once. The returned constraint is shared, and must not be modified
+// with UnmarshalText.
+func (sys System) ParseConstraintCached(str string) (*Constraint, error) {
+	return sharedConstraints.ParseConstraint(sys, str)
+}
+
+// ConstraintCache is a cache of parsed constraints, keyed by system and
+// string, holding a bounded number of them: when it is full, the oldest
+// constraint is evicted to add another. It is safe for concurrent use.
+type ConstraintCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[constraintKey]constraintEntry
+	keys    []constraintKey // The keys of the entries, in a ring.
+	oldest  int             // The index in keys of the oldest entry.
+}
+
+type constraintKey struct {
+	sys System
+	str string
+}
+
+type constraintEntry struct {
+	c   *Constraint
+	err error
+}
+
+// NewConstraintCache returns a cache holding at most size constraints. A
+// size of zero or less selects DefaultConstraintCacheSize.
+func NewConstraintCache(size int) *ConstraintCache {
+	if size <= 0 {
+		size = DefaultConstraintCacheSize
+	}
+	return &ConstraintCache{
+		size:    size,
+		entries: make(map[constraintKey]constraintEntry),
+		keys:    make([]constraintKey, 0, size),
+	}
+}
+
+// ParseConstraint returns the result of sys.ParseConstraint(str), parsing
+// the constraint only if it is not in the cache. The returned constraint
+// is shared, and must not be modified with UnmarshalText.
+func (cc *ConstraintCache) ParseConstraint(sys System, str string) (*Constraint, error) {
+	k := constraintKey{sys, str}
+	cc.mu.Lock()
+	e, ok := cc.entries[k]
+	cc.mu.Unlock()
+	if ok {
+		return e.c, e.err
+	}
+
+	// Parse outside of the lock; concurrent misses on the same key parse
+	// it more than once, keeping the first result.
+	c, err := sys.ParseConstraint(str)
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if e, ok := cc.entries[k]; ok {
+		return e.c, e.err
+	}
+	if len(cc.keys) < cc.size {
+		cc.keys = append(cc.keys, k)
+	} else {
+		delete(cc.entries, cc.keys[cc.oldest])
+		cc.keys[cc.oldest] = k
+		cc.oldest = (cc.oldest + 1) % cc.size
+	}
+	cc.entries[k] = constraintEntry{c, err}
+	return c, err
+}
+
+// Len returns the number of constraints in the cache.
+func (cc *ConstraintCache) Len() int {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return len(cc.entries)
+}
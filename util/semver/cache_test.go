@@ -0,0 +1,118 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestConstraintCache(t *testing.T) {
+	cc := NewConstraintCache(2)
+	c1, err := cc.ParseConstraint(NPM, "^1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c1.Match("1.3.0") || c1.Match("2.0.0") {
+		t.Errorf("cached constraint %v matches wrongly", c1)
+	}
+	if c2, _ := cc.ParseConstraint(NPM, "^1.2.0"); c2 != c1 {
+		t.Errorf("second parse: got a new constraint")
+	}
+	// The same string in another system is another constraint.
+	cargo, _ := cc.ParseConstraint(Cargo, "^1.2.0")
+	if cargo == c1 {
+		t.Errorf("Cargo constraint: got the NPM one")
+	}
+	// Errors are cached too.
+	if _, err := cc.ParseConstraint(NPM, "latest"); err == nil {
+		t.Errorf("ParseConstraint(latest): got no error")
+	}
+	// The cache was full, and only the oldest constraint was evicted to
+	// add the error.
+	if n := cc.Len(); n != 2 {
+		t.Errorf("Len: got %d, want 2", n)
+	}
+	if c, _ := cc.ParseConstraint(Cargo, "^1.2.0"); c != cargo {
+		t.Errorf("Cargo constraint after eviction: got a new one")
+	}
+	if c, _ := cc.ParseConstraint(NPM, "^1.2.0"); c == c1 {
+		t.Errorf("evicted constraint: got the old one")
+	}
+	if n := testing.AllocsPerRun(100, func() { cc.ParseConstraint(NPM, "^1.2.0") }); n != 0 {
+		t.Errorf("cache hit: got %v allocs, want 0", n)
+	}
+}
+
+func TestConstraintCacheConcurrent(t *testing.T) {
+	cc := NewConstraintCache(16)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				str := fmt.Sprintf(">=%d.0.0", j%32)
+				c, err := cc.ParseConstraint(NPM, str)
+				if err != nil || c.String() != str {
+					t.Errorf("ParseConstraint(%q) = %v, %v", str, c, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := cc.Len(); n < 1 || n > 16 {
+		t.Errorf("Len: got %d, want between 1 and 16", n)
+	}
+}
+
+func TestParseConstraintCached(t *testing.T) {
+	c1, err := Maven.ParseConstraintCached("[1.0,2.0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, _ := Maven.ParseConstraintCached("[1.0,2.0)")
+	if c1 != c2 {
+		t.Errorf("got distinct constraints")
+	}
+}
+
+func TestParseConstraintCachedSet(t *testing.T) {
+	const str = "1.x || >=3.0.0 || 2.1.0"
+	c, err := NPM.ParseConstraintCached(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := c.Set().String()
+	// Modifying the set of a shared constraint must not modify the
+	// constraint, even if the spans of the set have room to grow.
+	s := c.Set()
+	other, err := NPM.ParseConstraint("<0.5.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Union(other.Set()); err != nil {
+		t.Fatal(err)
+	}
+	c, _ = NPM.ParseConstraintCached(str)
+	if got := c.Set().String(); got != want {
+		t.Errorf("cached constraint modified through its set: got %s, want %s", got, want)
+	}
+	if c.Match("0.1.0") || !c.Match("1.5.0") {
+		t.Errorf("cached constraint modified through its set: %s matches wrongly", c.Set())
+	}
+}
@@ -0,0 +1,761 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Composer-specific support. Versions and constraints follow Composer's
+// VersionParser, https://github.com/composer/semver, and versions are
+// ordered as PHP's version_compare orders their normalized forms.
+
+// composerExtension implements the Composer-specific parts of a Version.
+// It uses the numbers from the version but stores the stability modifier
+// that may follow them, as in 1.0.0-beta2, as separate elements. A branch
+// version such as dev-main has no numbers; branches sort before all
+// numbered versions, and by name among themselves.
+type composerExtension struct {
+	version *Version
+	branch  bool           // Version names a branch, as in dev-main.
+	name    string         // Name of the branch, without the "dev-".
+	elems   []composerElem // The stability modifier.
+}
+
+// A composerElem is an element of a stability modifier: a word or a number.
+type composerElem struct {
+	rank int   // Position in the ordering of elements.
+	num  int64 // Value if the element is a number.
+}
+
+// Ranks of the elements of a stability modifier, in increasing order, as
+// in version_compare. Numbers sort between the prerelease words and patch.
+const (
+	composerDev = iota
+	composerAlpha
+	composerBeta
+	composerRC
+	composerNumber
+	composerPatch
+)
+
+// composerWords holds the canonical spellings of the words, by rank.
+var composerWords = [...]string{
+	composerDev:    "dev",
+	composerAlpha:  "alpha",
+	composerBeta:   "beta",
+	composerRC:     "RC",
+	composerNumber: "stable",
+	composerPatch:  "patch",
+}
+
+// composerModifiers lists the words that may begin a stability modifier,
+// lower-cased, longest first so that prefixes match last.
+var composerModifiers = []struct {
+	word string
+	rank int
+}{
+	{"stable", composerNumber},
+	{"alpha", composerAlpha},
+	{"patch", composerPatch},
+	{"beta", composerBeta},
+	{"rc", composerRC},
+	{"pl", composerPatch},
+	{"a", composerAlpha},
+	{"b", composerBeta},
+	{"p", composerPatch},
+}
+
+// composerFlags maps the stability flags of constraints, as in 1.0@beta,
+// lower-cased, to the ranks of the words they name.
+var composerFlags = map[string]int{
+	"dev":    composerDev,
+	"alpha":  composerAlpha,
+	"beta":   composerBeta,
+	"rc":     composerRC,
+	"stable": composerNumber,
+}
+
+// composerAlias is the number that stands for x in a branch alias such as
+// 1.0.x-dev, which Composer normalizes to 1.0.9999999.9999999-dev.
+const composerAlias = 9999999
+
+// newComposerExtension builds the extension object for an extant Composer Version.
+func newComposerExtension(v *Version, str string) (*composerExtension, error) {
+	e := &composerExtension{
+		version: v,
+	}
+	return e, e.init(str)
+}
+
+// composerVersion parses a Composer Version. The str field is set by the
+// caller; the numbers are stored in the Version and the rest in the
+// extension, which is constructed here and attached to the returned Version.
+func (p *versionParser) composerVersion() (*Version, error) {
+	var err error
+	p.Version.ext, err = p.Version.newExtension(p.Version.str)
+	return p.Version, err
+}
+
+func (c *composerExtension) copy(v *Version) extension {
+	n := new(composerExtension)
+	*n = *c
+	n.version = v
+	return n
+}
+
+func (c *composerExtension) clearPre() {
+	c.elems = nil
+}
+
+func (c *composerExtension) empty() bool {
+	return c == nil || !c.branch && len(c.elems) == 0
+}
+
+// canon returns a canonicalized string representation of the version/extension,
+// which is Composer's normalized form: four or more numbers followed by the
+// stability modifier, as in 1.0.0.0-beta2-dev, or the branch, as in dev-main.
+func (c *composerExtension) canon(showBuild bool) string {
+	if c.branch {
+		return "dev-" + c.name
+	}
+	var b strings.Builder
+	n := len(c.version.num)
+	if n < 4 {
+		n = 4
+	}
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		fmt.Fprint(&b, c.version.getNum(i))
+	}
+	for i, e := range c.elems {
+		switch {
+		case e.rank != composerNumber:
+			b.WriteByte('-')
+			b.WriteString(composerWords[e.rank])
+		case i > 0 && c.elems[i-1].rank == composerNumber:
+			fmt.Fprintf(&b, ".%d", e.num)
+		default:
+			fmt.Fprint(&b, e.num)
+		}
+	}
+	if showBuild {
+		b.WriteString(c.version.build)
+	}
+	return b.String()
+}
+
+// composerMinVersion is the branch dev-, whose empty name sorts before
+// those of all other branches and so before all versions.
+var composerMinVersion *Version
+
+// composerFloor is 0.0.0.0-dev, the least numbered version, which bounds
+// ranges such as <1.0 that match no branches.
+var composerFloor *Version
+
+func init() {
+	composerMinVersion = &Version{
+		sys: Composer,
+		str: "dev-",
+	}
+	composerMinVersion.ext = &composerExtension{
+		version: composerMinVersion,
+		branch:  true,
+	}
+	composerFloor = composerBump(composerMinVersion, 0, 0)
+}
+
+// init parses a Composer version string and stores the numbers in the
+// Version and the stability modifier or branch in the extension.
+func (c *composerExtension) init(input string) error {
+	v := c.version
+	if len(input) >= 4 && strings.EqualFold(input[:4], "dev-") {
+		for _, r := range input[4:] {
+			if r <= ' ' || r >= 0x7F || strings.ContainsRune(",|@#{}", r) {
+				return fmt.Errorf("invalid branch name in %#q", input)
+			}
+		}
+		c.branch = true
+		c.name = input[4:]
+		v.isPrerelease = true
+		return nil
+	}
+	s := input
+	if s != "" && (s[0] == 'v' || s[0] == 'V') {
+		s = s[1:]
+	}
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		v.build = s[i:]
+		s = s[:i]
+		if len(v.build) == 1 || strings.Trim(v.build[1:], "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.-_") != "" {
+			return fmt.Errorf("invalid build metadata in %#q", input)
+		}
+	}
+	for {
+		n := 0
+		for n < len(s) && isDigit(rune(s[n])) {
+			n++
+		}
+		if n == 0 {
+			return fmt.Errorf("invalid version %#q", input)
+		}
+		val, err := parseNum(s[:n])
+		if err != nil {
+			return err
+		}
+		v.addNum(val)
+		s = s[n:]
+		if len(s) < 2 || s[0] != '.' || !isDigit(rune(s[1])) {
+			break
+		}
+		s = s[1:]
+	}
+	v.userNumCount = int16(len(v.num))
+	s = strings.ToLower(s)
+	// A branch alias such as 1.0.x-dev names the development version
+	// above all the releases it covers.
+	if strings.HasPrefix(s, ".x") || strings.HasPrefix(s, ".*") {
+		for len(s) >= 2 && s[0] == '.' && (s[1] == 'x' || s[1] == '*') {
+			s = s[2:]
+			v.addNum(composerAlias)
+		}
+		if s != "dev" && s != "-dev" && s != ".dev" || len(v.num) > 4 {
+			return fmt.Errorf("invalid branch alias %#q", input)
+		}
+		for len(v.num) < 4 {
+			v.addNum(composerAlias)
+		}
+		c.elems = []composerElem{{rank: composerDev}}
+		v.isPrerelease = true
+		return nil
+	}
+	elems, ok := parseComposerModifier(s)
+	if !ok {
+		return fmt.Errorf("invalid version %#q", input)
+	}
+	c.elems = elems
+	for _, e := range elems {
+		if e.rank < composerNumber {
+			v.isPrerelease = true
+		}
+	}
+	return nil
+}
+
+// parseComposerModifier parses the lower-cased stability modifier that
+// follows the numbers of a version, as in -beta.2-dev, and returns its
+// elements. Like Composer, it drops a stable modifier altogether.
+func parseComposerModifier(s string) ([]composerElem, bool) {
+	if s != "" && strings.IndexByte("._-", s[0]) >= 0 {
+		s = s[1:]
+	}
+	var elems []composerElem
+	for _, m := range composerModifiers {
+		if !strings.HasPrefix(s, m.word) {
+			continue
+		}
+		s = s[len(m.word):]
+		elems = append(elems, composerElem{rank: m.rank})
+		for {
+			t := s
+			if t != "" && (t[0] == '.' || t[0] == '-') {
+				t = t[1:]
+			}
+			n := 0
+			for n < len(t) && isDigit(rune(t[n])) {
+				n++
+			}
+			if n == 0 {
+				break
+			}
+			val, err := parseNum(t[:n])
+			if err != nil {
+				return nil, false
+			}
+			elems = append(elems, composerElem{rank: composerNumber, num: int64(val)})
+			s = t[n:]
+		}
+		break
+	}
+	if s != "" {
+		if s[0] == '.' || s[0] == '-' {
+			s = s[1:]
+		}
+		if s != "dev" {
+			return nil, false
+		}
+		elems = append(elems, composerElem{rank: composerDev})
+	}
+	if len(elems) > 0 && elems[0].rank == composerNumber {
+		return nil, true // Stable.
+	}
+	return elems, true
+}
+
+// compare uses version_compare's rules to decide the ordering of the
+// receiver and argument.
+func (c *composerExtension) compare(e extension) int {
+	d := e.(*composerExtension)
+	switch {
+	case c.branch && d.branch:
+		return strings.Compare(c.name, d.name)
+	case c.branch:
+		return -1
+	case d.branch:
+		return 1
+	}
+
+	n := len(c.version.num)
+	if len(d.version.num) > n {
+		n = len(d.version.num)
+	}
+	for i := 0; i < n; i++ {
+		if s := sgnv(c.version.getNum(i), d.version.getNum(i)); s != 0 {
+			return s
+		}
+	}
+
+	for i := 0; i < len(c.elems) || i < len(d.elems); i++ {
+		// When one runs out, the next element of the other decides:
+		// a number or patch makes it later, a prerelease word earlier.
+		switch {
+		case i >= len(c.elems):
+			if d.elems[i].rank >= composerNumber {
+				return -1
+			}
+			return 1
+		case i >= len(d.elems):
+			if c.elems[i].rank >= composerNumber {
+				return 1
+			}
+			return -1
+		}
+		a, b := c.elems[i], d.elems[i]
+		if s := sgn(a.rank, b.rank); s != 0 {
+			return s
+		}
+		if s := sgn64(a.num, b.num); s != 0 {
+			return s
+		}
+	}
+	return 0
+}
+
+// composerBump returns the version of the first n numbers of v, the last
+// of them increased by inc, padded with zeros to four numbers and followed
+// by -dev, as Composer builds the bounds of ranges such as ^1.2. It is not
+// marked as a prerelease, as the user did not write one.
+func composerBump(v *Version, n int, inc value) *Version {
+	b := &Version{
+		sys:          Composer,
+		userNumCount: 4,
+	}
+	for i := 0; i < 4; i++ {
+		val := value(0)
+		if i < n {
+			val = v.getNum(i)
+		}
+		if i == n-1 {
+			val += inc
+		}
+		b.addNum(val)
+	}
+	b.ext = &composerExtension{
+		version: b,
+		elems:   []composerElem{{rank: composerDev}},
+	}
+	b.str = b.ext.canon(false)
+	return b
+}
+
+// composerWithStability returns a copy of v with the stability word of the
+// given rank appended, as Composer appends -dev to the bounds of ranges
+// such as >=1.0. Like composerBump, it does not mark the copy a prerelease.
+func composerWithStability(v *Version, rank int) *Version {
+	n := v.copy()
+	e := n.ext.(*composerExtension)
+	e.elems = append(e.elems[:len(e.elems):len(e.elems)], composerElem{rank: rank})
+	n.build = ""
+	n.str = e.canon(false)
+	return n
+}
+
+// composerModified reports whether the version text has a stability
+// modifier, stable included.
+func composerModified(text string) bool {
+	text, _, _ = strings.Cut(text, "+")
+	return strings.TrimLeft(strings.TrimLeft(text, "vV"), "0123456789.") != ""
+}
+
+// composerDashModifier reports whether the version text ends with a
+// hyphen and a possibly empty stability modifier, as in 1.0-beta or
+// 1.0.x-dev. Composer appends -dev to the operands of < and >= without one.
+func composerDashModifier(text string) bool {
+	text = strings.ToLower(text)
+	for i := 0; i < len(text); i++ {
+		if text[i] != '-' {
+			continue
+		}
+		if _, ok := parseComposerModifier(text[i+1:]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Stability returns the stability flag of a Composer constraint, such as
+// "dev" for 1.0.*@dev or "RC" for ^2.0@rc, the least stable if there are
+// several, or the empty string if there are none. Composer uses the flag
+// to relax the minimum stability of the constrained package.
+func (c *Constraint) Stability() string {
+	return c.stability
+}
+
+/*
+composerConstraint parses a Composer constraint as Composer's
+VersionParser does.
+
+	constraint = andList
+		| constraint '||' andList
+		| constraint '|' andList
+	andList = item
+		| andList ',' item
+		| andList ' ' item
+	item = range ['@' STABILITY] [' as ' VERSION]
+	range = '*'
+		| VERSION ' - ' VERSION
+		| op VERSION
+		| WILDCARD
+	op = '' | '=' | '==' | '!=' | '<>' | '<' | '<=' | '>' | '>=' | '~' | '^'
+
+Every item is a single word apart from hyphenated ranges and aliases, but
+a comparison operator may be separated from its version by spaces.
+*/
+func (p *constraintParser) composerConstraint() (*Constraint, error) {
+	ors := strings.Split(strings.ReplaceAll(p.lex.str, "||", "|"), "|")
+	var spans []span
+	for _, or := range ors {
+		items := p.composerAndList(or)
+		if p.lex.err != nil {
+			return p.Constraint, p.lex.err
+		}
+		if len(items) == 0 {
+			p.lex.setErr("missing item in or list")
+			return p.Constraint, p.lex.err
+		}
+		var set Set
+		for i, item := range items {
+			s := p.composerItem(item)
+			if p.lex.err != nil {
+				return p.Constraint, p.lex.err
+			}
+			if i == 0 {
+				set.span = s
+				continue
+			}
+			if err := set.Intersect(Set{span: s}); err != nil {
+				p.lex.setError(err)
+				return p.Constraint, p.lex.err
+			}
+		}
+		spans = append(spans, set.span...)
+	}
+	spans, err := canon(spans)
+	if err != nil {
+		p.lex.setError(err)
+		return p.Constraint, p.lex.err
+	}
+	p.set = Set{
+		sys:  Composer,
+		span: spans,
+	}
+	p.Constraint.simple = len(ors) == 1 && p.weight == 1
+	return p.Constraint, nil
+}
+
+// composerAndList splits an and-list into its items, joining the words of
+// hyphenated ranges and aliases and the operators separated from their
+// versions by spaces.
+func (p *constraintParser) composerAndList(list string) []string {
+	var (
+		items []string
+		op    string // Operator awaiting its version, as in ">= 1.0".
+		join  bool   // The next word continues the last item, as in "1.0 - 2.0".
+		comma bool   // The last word was a comma.
+	)
+	for _, field := range strings.Fields(list) {
+		for field != "" {
+			word := ","
+			if i := strings.IndexByte(field, ','); i < 0 {
+				word = field
+			} else if i > 0 {
+				word = field[:i]
+			}
+			field = field[len(word):]
+			switch {
+			case word == ",":
+				if len(items) == 0 || comma || join || op != "" {
+					p.lex.setErr("unexpected comma")
+					return nil
+				}
+				comma = true
+			case (word == "-" || word == "as") && len(items) > 0 && !comma && !join && op == "":
+				items[len(items)-1] += " " + word + " "
+				join = true
+			default:
+				word = op + word
+				op = ""
+				if strings.Trim(word, "<>=!") == "" {
+					op = word
+					continue
+				}
+				if join {
+					items[len(items)-1] += word
+				} else {
+					items = append(items, word)
+				}
+				join = false
+				comma = false
+			}
+		}
+	}
+	switch {
+	case op != "":
+		p.lex.setErr("expected version after operator")
+	case join:
+		p.lex.setErr("expected version after hyphen")
+	case comma:
+		p.lex.setErr("missing item after comma")
+	}
+	return items
+}
+
+// composerItem returns the spans of a single item of an and-list.
+func (p *constraintParser) composerItem(item string) []span {
+	// An alias, as in "1.0.x-dev as 1.0.0", does not affect matching.
+	item, _, _ = strings.Cut(item, " as ")
+	// A stability flag also relaxes the bounds of comparisons.
+	modifier := -1
+	if i := strings.LastIndexByte(item, '@'); i >= 0 {
+		flag := strings.ToLower(item[i+1:])
+		rank, ok := composerFlags[flag]
+		if !ok {
+			p.lex.setErr(fmt.Sprintf("invalid stability flag %#q", item[i+1:]))
+			return nil
+		}
+		if p.stability == "" || rank < composerFlags[strings.ToLower(p.stability)] {
+			p.stability = composerWords[rank]
+		}
+		if rank != composerNumber {
+			modifier = rank
+		}
+		item = item[:i]
+		if item == "" {
+			item = "*"
+		}
+	}
+	// A reference, as in dev-main#abc123, is for installation only.
+	if ref, _, ok := strings.Cut(item, "#"); ok {
+		lower := strings.ToLower(ref)
+		if strings.HasPrefix(lower, "dev-") || strings.HasSuffix(lower, ".x-dev") {
+			item = ref
+		}
+	}
+	if item == "" {
+		p.lex.setErr("empty constraint")
+		return nil
+	}
+	p.weight++
+	inf, err := Composer.parse("∞.∞.∞", true)
+	if err != nil {
+		p.lex.setError(err)
+		return nil
+	}
+
+	// Match all.
+	if t := strings.TrimLeft(item, "vV"); len(item)-len(t) <= 1 && composerWildcards(t) > 0 {
+		p.weight++
+		// Only a lone wildcard matches branches.
+		if t == item && composerWildcards(t) == 1 {
+			return p.composerSpans(composerMinVersion.copy(), closed, inf, closed)
+		}
+		return p.composerSpans(composerFloor.copy(), closed, inf, closed)
+	}
+
+	// Tilde and caret ranges.
+	if strings.HasPrefix(item, "~>") {
+		p.lex.setErr(`invalid operator "~>"`)
+		return nil
+	}
+	if item[0] == '~' || item[0] == '^' {
+		p.weight++
+		v := p.composerOperand(item[1:])
+		if v == nil {
+			return nil
+		}
+		lo := v
+		if !composerModified(item[1:]) {
+			lo = composerWithStability(v, composerDev)
+		}
+		pos := int(v.userNumCount)
+		if item[0] == '~' {
+			// ~1.2.3 means >=1.2.3 <1.3, and ~1.2 means >=1.2 <2.
+			pos--
+			if pos < 1 {
+				pos = 1
+			}
+		} else {
+			// ^1.2.3 means >=1.2.3 <2, ^0.2.3 means >=0.2.3 <0.3, and
+			// ^0.0.3 means >=0.0.3 <0.0.4.
+			switch {
+			case v.getNum(0) != 0 || pos < 2:
+				pos = 1
+			case v.getNum(1) != 0 || pos < 3:
+				pos = 2
+			default:
+				pos = 3
+			}
+		}
+		return p.composerSpans(lo, closed, composerBump(v, pos, 1), open)
+	}
+
+	// Wildcards, as in 1.2.*, which match the releases with those numbers.
+	if t := strings.TrimRight(item, "xX*."); t != "" && strings.IndexByte("0123456789vV", t[0]) >= 0 &&
+		strings.HasPrefix(item[len(t):], ".") && composerWildcards(item[len(t)+1:]) > 0 {
+		p.weight++
+		v := p.composerOperand(t)
+		if v == nil {
+			return nil
+		}
+		if composerModified(t) || v.userNumCount > 3 {
+			p.lex.setErr(fmt.Sprintf("invalid wildcard %#q", item))
+			return nil
+		}
+		pos := int(v.userNumCount)
+		return p.composerSpans(composerBump(v, pos, 0), closed, composerBump(v, pos, 1), open)
+	}
+
+	// Hyphenated ranges. A partial upper bound, as in 1.0 - 2, is a
+	// wildcard, meaning <3.
+	if from, to, ok := strings.Cut(item, " - "); ok {
+		p.weight++
+		lo := p.composerOperand(from)
+		hi := p.composerOperand(to)
+		if lo == nil || hi == nil {
+			return nil
+		}
+		if !composerModified(from) {
+			lo = composerWithStability(lo, composerDev)
+		}
+		if hi.userNumCount >= 3 || composerModified(to) {
+			return p.composerSpans(lo, closed, hi, closed)
+		}
+		return p.composerSpans(lo, closed, composerBump(hi, int(hi.userNumCount), 1), open)
+	}
+
+	// Comparisons.
+	op := ""
+	for _, o := range []string{"<>", "!=", ">=", "<=", "==", ">", "<", "="} {
+		if strings.HasPrefix(item, o) {
+			op = o
+			break
+		}
+	}
+	text := strings.TrimLeft(item[len(op):], " ")
+	v, err := Composer.Parse(text)
+	if err != nil && strings.HasSuffix(text, "-dev") && strings.Trim(text, "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-./") == "" {
+		// Composer reads foo-dev as dev-foo.
+		v, err = Composer.Parse("dev-" + strings.TrimSuffix(text, "-dev"))
+	}
+	if err != nil {
+		p.lex.setError(err)
+		return nil
+	}
+	e := v.ext.(*composerExtension)
+	switch op {
+	case "", "=", "==":
+		return p.composerSpans(v, closed, v, closed)
+	case "!=", "<>":
+		p.weight++
+		left := p.composerSpans(composerMinVersion.copy(), closed, v, open)
+		return append(left, p.composerSpans(v, open, inf, closed)...)
+	}
+	p.weight++
+	if e.branch {
+		// Branches are not ordered.
+		return []span{{rank: empty}}
+	}
+	switch {
+	case modifier >= 0 && len(e.elems) == 0:
+		v = composerWithStability(v, modifier)
+	case (op == "<" || op == ">=") && !composerDashModifier(text) && (len(e.elems) == 0 || e.elems[len(e.elems)-1].rank != composerDev):
+		v = composerWithStability(v, composerDev)
+	}
+	switch op {
+	case "<":
+		return p.composerSpans(composerFloor.copy(), closed, v, open)
+	case "<=":
+		return p.composerSpans(composerFloor.copy(), closed, v, closed)
+	case ">":
+		return p.composerSpans(v, open, inf, closed)
+	default: // ">="
+		return p.composerSpans(v, closed, inf, closed)
+	}
+}
+
+// composerWildcards returns the number of dot-separated wildcards in s,
+// or zero if s is not a list of them.
+func composerWildcards(s string) int {
+	n := 0
+	for _, w := range strings.Split(s, ".") {
+		if w != "x" && w != "X" && w != "*" {
+			return 0
+		}
+		n++
+	}
+	return n
+}
+
+// composerOperand parses the version of a range, which must be a numbered
+// version of at most four numbers.
+func (p *constraintParser) composerOperand(text string) *Version {
+	v, err := Composer.Parse(text)
+	if err != nil {
+		p.lex.setError(err)
+		return nil
+	}
+	if v.ext.(*composerExtension).branch || len(v.num) != int(v.userNumCount) || v.userNumCount > 4 {
+		p.lex.setErr(fmt.Sprintf("invalid version %#q in range", text))
+		return nil
+	}
+	return v
+}
+
+// composerSpans returns the span between the versions, which is empty if
+// they are out of order, as a slice.
+func (p *constraintParser) composerSpans(min *Version, minOpen bool, max *Version, maxOpen bool) []span {
+	if c := min.Compare(max); c > 0 || c == 0 && (minOpen || maxOpen) {
+		return []span{{rank: empty}}
+	}
+	s, err := newSpan(min, minOpen, max, maxOpen)
+	if err != nil {
+		p.lex.setError(err)
+		return nil
+	}
+	return []span{s}
+}
@@ -14,10 +14,13 @@
 
 package semver
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
-// Composer-specific tests.
-// TODO: Investigate Composer's version semantics further.
+// Composer-specific tests. Many cases are taken from composer/semver's
+// VersionParserTest.php and ComparatorTest.php.
 
 var composerVersionParseTests = []versionParseTest{
 	// The following tests are taken from advisory ranges observed in the wild.
@@ -32,8 +35,296 @@ var composerVersionParseTests = []versionParseTest{
 
 	// The following is an artificial test case
 	v("vv1.1", "invalid version `vv1.1`", ""), // too many 'v's.
+
+	v("1.0.0-beta.2", "", "1.0.0"),
+	v("1.0.x-dev", "", "1.0"),
+	v("dev-master", "", ""),
+	v("1.0.0+foo", "", "1.0.0", "+foo"),
+	v("1.0.0-foo", "invalid version `1.0.0-foo`", ""),
+	v("1.0.x.x.x-dev", "invalid branch alias `1.0.x.x.x-dev`", ""),
+	v("dev-a b", "invalid branch name in `dev-a b`", ""),
 }
 
 func TestComposerVersionParse(t *testing.T) {
 	testVersionParse(t, Composer, composerVersionParseTests)
 }
+
+var composerCanonTests = []canonTest{
+	{"1.0.0", "1.0.0.0", ""},
+	{"1.2.3.4", "1.2.3.4", ""},
+	{"v1.0.0", "1.0.0.0", ""},
+	{"1.0.0-RC1", "1.0.0.0-RC1", ""},
+	{"1.0.0-rC15-dev", "1.0.0.0-RC15-dev", ""},
+	{"1.0.0-rc1-dev", "1.0.0.0-RC1-dev", ""},
+	{"1.0.0.RC.15-dev", "1.0.0.0-RC15-dev", ""},
+	{"1.0.0-beta.5+foo", "1.0.0.0-beta5+foo", "1.0.0.0-beta5"},
+	{"1.0.0-b.5.1", "1.0.0.0-beta5.1", ""},
+	{"1.0.0alpha3.1", "1.0.0.0-alpha3.1", ""},
+	{"1.0.0-a", "1.0.0.0-alpha", ""},
+	{"1.0.0-pl3", "1.0.0.0-patch3", ""},
+	{"1.0.0-p", "1.0.0.0-patch", ""},
+	{"1.0.0-stable", "1.0.0.0", ""},
+	{"1.0-dev", "1.0.0.0-dev", ""},
+	{"1.0.0.dev", "1.0.0.0-dev", ""},
+	{"1.0.0-BETA-dev", "1.0.0.0-beta-dev", ""},
+	{"2010.01.02", "2010.1.2.0", ""},
+	{"1.x-dev", "1.9999999.9999999.9999999-dev", ""},
+	{"1.0.*-dev", "1.0.9999999.9999999-dev", ""},
+	{"dev-master", "dev-master", ""},
+	{"DEV-feature/foo", "dev-feature/foo", ""},
+}
+
+func TestComposerCanon(t *testing.T) {
+	testVersionCanon(t, Composer, composerCanonTests)
+}
+
+var composerCompareTests = []compareTest{
+	{"1.0", "1.0.0.0", 0},
+	{"1.25.0", "1.24.0", 1},
+	{"1.0.0-beta2", "1.0.0-b2", 0},
+	{"1.0.0-beta2", "1.0.0-beta.2", 0},
+	{"1.0.0-pl1", "1.0.0-patch1", 0},
+	{"1.0.0-beta2.1", "1.0.0-beta2", 1},
+	{"1.0.0-beta2-dev", "1.0.0-beta2", -1},
+	{"1.0.0-beta10", "1.0.0-beta9", 1},
+	{"1.0.0-patch1", "1.0.0.1", -1},
+	{"1.0.0-patch1", "1.0.0", 1},
+	{"1.0.0.1-dev", "1.0.0-patch1", 1},
+	{"dev-foo", "1.0.0", -1},
+	{"dev-foo", "0.0.0-dev", -1},
+	{"dev-bar", "dev-foo", -1},
+	{"1.0.x-dev", "1.0.99", 1},
+	{"1.0.x-dev", "1.1.0-dev", -1},
+}
+
+func TestComposerCompare(t *testing.T) {
+	testCompare(t, Composer, composerCompareTests)
+}
+
+func TestComposerCompareSequential(t *testing.T) {
+	tests := []string{
+		"dev-",
+		"dev-feature",
+		"dev-main",
+		"0.0.0-dev",
+		"1.0.0-dev",
+		"1.0.0-alpha-dev",
+		"1.0.0-alpha",
+		"1.0.0-alpha1",
+		"1.0.0-beta1-dev",
+		"1.0.0-beta1",
+		"1.0.0-beta1.1",
+		"1.0.0-beta2",
+		"1.0.0-RC1",
+		"1.0.0",
+		"1.0.0-patch1-dev",
+		"1.0.0-patch1",
+		"1.0.0-patch2",
+		"1.0.0.1-dev",
+		"1.0.0.1",
+		"1.0.x-dev",
+		"1.1.0",
+	}
+	testCompareSequential(t, Composer, tests)
+}
+
+func TestComposerConstraintSpans(t *testing.T) {
+	tests := []struct {
+		cs    string
+		debug string
+	}{
+		{"*", "{[dev-:∞.∞.∞]}"},
+		{"*.*", "{[0.0.0.0-dev:∞.∞.∞]}"},
+		{"v*", "{[0.0.0.0-dev:∞.∞.∞]}"},
+		{"1.0.0", "{1.0.0.0}"},
+		{"=1.0.0", "{1.0.0.0}"},
+		{"== 1.0.0", "{1.0.0.0}"},
+		{"dev-master", "{dev-master}"},
+		{"master-dev", "{dev-master}"},
+		{"1.0.x-dev", "{1.0.9999999.9999999-dev}"},
+		{"dev-master#abc123", "{dev-master}"},
+		{"1.0.x-dev#abc123", "{1.0.9999999.9999999-dev}"},
+		{"dev-master as 1.0.0", "{dev-master}"},
+		{">1.0.0", "{(1.0.0.0:∞.∞.∞]}"},
+		{">=1.0.0", "{[1.0.0.0-dev:∞.∞.∞]}"},
+		{"<1.2.3.4", "{[0.0.0.0-dev:1.2.3.4-dev)}"},
+		{"<=1.2.3", "{[0.0.0.0-dev:1.2.3.0]}"},
+		{"<1.2.3-beta", "{[0.0.0.0-dev:1.2.3.0-beta)}"},
+		{">=1.0.0-RC2", "{[1.0.0.0-RC2:∞.∞.∞]}"},
+		{"<1.0.0-dev", "{[0.0.0.0-dev:1.0.0.0-dev)}"},
+		{"<1.0.0beta", "{[0.0.0.0-dev:1.0.0.0-beta-dev)}"},
+		{"!=1.0.0", "{[dev-:1.0.0.0),(1.0.0.0:∞.∞.∞]}"},
+		{"<>1.0.0", "{[dev-:1.0.0.0),(1.0.0.0:∞.∞.∞]}"},
+		{"!=dev-master", "{[dev-:dev-master),(dev-master:∞.∞.∞]}"},
+		{"<dev-master", "{<empty>}"},
+		{">=dev-master", "{<empty>}"},
+		{"~1", "{[1.0.0.0-dev:2.0.0.0-dev)}"},
+		{"~1.2", "{[1.2.0.0-dev:2.0.0.0-dev)}"},
+		{"~1.2.3", "{[1.2.3.0-dev:1.3.0.0-dev)}"},
+		{"~1.2.3.4", "{[1.2.3.4-dev:1.2.4.0-dev)}"},
+		{"~1.2-beta", "{[1.2.0.0-beta:2.0.0.0-dev)}"},
+		{"~1.2-b2", "{[1.2.0.0-beta2:2.0.0.0-dev)}"},
+		{"~1.2-stable", "{[1.2.0.0:2.0.0.0-dev)}"},
+		{"~1.2.3+build", "{[1.2.3.0-dev:1.3.0.0-dev)}"},
+		{"^1", "{[1.0.0.0-dev:2.0.0.0-dev)}"},
+		{"^1.2.3", "{[1.2.3.0-dev:2.0.0.0-dev)}"},
+		{"^0", "{[0.0.0.0-dev:1.0.0.0-dev)}"},
+		{"^0.0", "{[0.0.0.0-dev:0.1.0.0-dev)}"},
+		{"^0.3", "{[0.3.0.0-dev:0.4.0.0-dev)}"},
+		{"^0.3.2", "{[0.3.2.0-dev:0.4.0.0-dev)}"},
+		{"^0.0.3", "{[0.0.3.0-dev:0.0.4.0-dev)}"},
+		{"^0.0.3-alpha", "{[0.0.3.0-alpha:0.0.4.0-dev)}"},
+		{"^1.2.3-beta.2", "{[1.2.3.0-beta2:2.0.0.0-dev)}"},
+		{"1.*", "{[1.0.0.0-dev:2.0.0.0-dev)}"},
+		{"v1.*", "{[1.0.0.0-dev:2.0.0.0-dev)}"},
+		{"1.x.x", "{[1.0.0.0-dev:2.0.0.0-dev)}"},
+		{"1.2.x", "{[1.2.0.0-dev:1.3.0.0-dev)}"},
+		{"1.2.3.*", "{[1.2.3.0-dev:1.2.4.0-dev)}"},
+		{"0.*", "{[0.0.0.0-dev:1.0.0.0-dev)}"},
+		{"1 - 2", "{[1.0.0.0-dev:3.0.0.0-dev)}"},
+		{"1.2 - 2.3", "{[1.2.0.0-dev:2.4.0.0-dev)}"},
+		{"1.2.3 - 2.3.4.5", "{[1.2.3.0-dev:2.3.4.5]}"},
+		{"1.2 - 2.0.0", "{[1.2.0.0-dev:2.0.0.0]}"},
+		{"1.2-beta - 2.3-dev", "{[1.2.0.0-beta:2.3.0.0-dev]}"},
+		{"1.2-RC - 2.3.1", "{[1.2.0.0-RC:2.3.1.0]}"},
+		{"2 - 1", "{<empty>}"},
+		{">2.0,<=3.0", "{(2.0.0.0:3.0.0.0]}"},
+		{">2.0 <=3.0", "{(2.0.0.0:3.0.0.0]}"},
+		{">2.0, <=3.0", "{(2.0.0.0:3.0.0.0]}"},
+		{">2.0 ,  <=3.0", "{(2.0.0.0:3.0.0.0]}"},
+		{">= 2.0 < 3.0", "{[2.0.0.0-dev:3.0.0.0-dev)}"},
+		{"1.0 - 2.0, !=1.5", "{[1.0.0.0-dev:1.5.0.0),(1.5.0.0:2.1.0.0-dev)}"},
+		{"^1.0 || ^2.0", "{[1.0.0.0-dev:3.0.0.0-dev)}"},
+		{"~1.0 | ~3.0", "{[1.0.0.0-dev:2.0.0.0-dev),[3.0.0.0-dev:4.0.0.0-dev)}"},
+		{"^1.0||^3.0", "{[1.0.0.0-dev:2.0.0.0-dev),[3.0.0.0-dev:4.0.0.0-dev)}"},
+		{">2.0,<=3.0 || 1.0 - 1.2", "{[1.0.0.0-dev:1.3.0.0-dev),(2.0.0.0:3.0.0.0]}"},
+		{"dev-master || ^1.0", "{dev-master,[1.0.0.0-dev:2.0.0.0-dev)}"},
+		{"1.0.0@dev", "{1.0.0.0}"},
+		{">=1.0@beta", "{[1.0.0.0-beta:∞.∞.∞]}"},
+		{">=1.0@stable", "{[1.0.0.0-dev:∞.∞.∞]}"},
+		{"<1.0@RC", "{[0.0.0.0-dev:1.0.0.0-RC)}"},
+		{">1.0-beta@alpha", "{(1.0.0.0-beta:∞.∞.∞]}"},
+		{"^1.0@dev", "{[1.0.0.0-dev:2.0.0.0-dev)}"},
+		{"@dev", "{[dev-:∞.∞.∞]}"},
+		{"1.0.x-dev as 1.0.0", "{1.0.9999999.9999999-dev}"},
+		{"", "{[0.0.0.0-dev:∞.∞.∞]}"},
+	}
+	for _, test := range tests {
+		c := parseConstraint(t, Composer, test.cs)
+		if got := c.Debug(); got != test.debug {
+			t.Errorf("Debug(%q) is %q; expect %q", test.cs, got, test.debug)
+		}
+		// The set must read back.
+		d, err := Composer.ParseSetConstraint(c.Debug())
+		if err != nil {
+			t.Errorf("ParseSetConstraint(%q): %v", c.Debug(), err)
+		} else if got := d.Debug(); got != test.debug {
+			t.Errorf("ParseSetConstraint(%q) is %q", test.debug, got)
+		}
+	}
+}
+
+var composerTestVersions = strings.Fields(`
+dev-main
+dev-feature
+0.9.0
+1.0.0-dev
+1.0.0-alpha1
+1.0.0-beta2
+1.0.0-RC1
+1.0.0
+1.0.0-patch1
+1.0.1
+1.0.x-dev
+1.2.0
+2.0.0-beta1
+2.0.0
+2.1.0`)
+
+func TestComposerMatch(t *testing.T) {
+	tests := []struct {
+		con     string
+		matches string
+	}{
+		{"*", "dev-main dev-feature 0.9.0 1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0 2.0.0-beta1 2.0.0 2.1.0"},
+		{"*.*", "0.9.0 1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0 2.0.0-beta1 2.0.0 2.1.0"},
+		{"1.0.0", "1.0.0"},
+		{"dev-main", "dev-main"},
+		{"main-dev", "dev-main"},
+		{"1.0.x-dev", "1.0.x-dev"},
+		{"^1.0", "1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0"},
+		{"^1.0.0-RC1", "1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0"},
+		{"~1.0.0", "1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev"},
+		{"1.0.*", "1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev"},
+		{">1.0.0", "1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0 2.0.0-beta1 2.0.0 2.1.0"},
+		{"<2.0", "0.9.0 1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0"},
+		{"<=2.0", "0.9.0 1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0 2.0.0-beta1 2.0.0"},
+		{"<2.0@beta", "0.9.0 1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0"},
+		{"<2.0@RC", "0.9.0 1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0 2.0.0-beta1"},
+		{"!=1.0.0", "dev-main dev-feature 0.9.0 1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0 2.0.0-beta1 2.0.0 2.1.0"},
+		{"!=dev-main", "dev-feature 0.9.0 1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0 2.0.0-beta1 2.0.0 2.1.0"},
+		{">=dev-main", ""},
+		{"1.0.0 - 1.2", "1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0"},
+		{"1.0.0 - 1.2.0", "1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 1.2.0"},
+		{"1.0.0 - 1.0.1", "1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1"},
+		{">=1.0 <1.2 || ^2.1", "1.0.0-dev 1.0.0-alpha1 1.0.0-beta2 1.0.0-RC1 1.0.0 1.0.0-patch1 1.0.1 1.0.x-dev 2.1.0"},
+		{"dev-feature || 0.9.*", "dev-feature 0.9.0"},
+	}
+	for _, test := range tests {
+		c := parseConstraint(t, Composer, test.con)
+		want := m(test.matches)
+		for _, vs := range composerTestVersions {
+			if got := c.Match(vs); got != want[vs] {
+				t.Errorf("Composer %q.Match(%q) = %t; want %t", test.con, vs, got, want[vs])
+			}
+		}
+	}
+}
+
+func TestComposerConstraintStability(t *testing.T) {
+	tests := []struct {
+		con       string
+		stability string
+		simple    bool
+	}{
+		{"1.0.0", "", true},
+		{"dev-main", "", true},
+		{"^1.0", "", false},
+		{"1.0.0 || 2.0.0", "", false},
+		{"1.0.*@dev", "dev", false},
+		{"^2.0@rc", "RC", false},
+		{"1.0.0@stable", "stable", true},
+		{"^1.0@beta || ^2.0@alpha", "alpha", false},
+		{"^1.0@dev || ^2.0@beta", "dev", false},
+		{">=1.0@stable <2.0@beta", "beta", false},
+	}
+	for _, test := range tests {
+		c := parseConstraint(t, Composer, test.con)
+		if got := c.Stability(); got != test.stability {
+			t.Errorf("Composer %q.Stability() = %q; want %q", test.con, got, test.stability)
+		}
+		if got := c.IsSimple(); got != test.simple {
+			t.Errorf("Composer %q.IsSimple() = %t; want %t", test.con, got, test.simple)
+		}
+	}
+}
+
+var composerConstraintErrorTests = []constraintErrorTest{
+	{"~>1.2", "invalid operator \"~>\" in `~>1.2`"},
+	{"1.0 ||", "missing item in or list in `1.0 ||`"},
+	{"|| 1.0", "missing item in or list in `|| 1.0`"},
+	{">=1.0,", "missing item after comma in `>=1.0,`"},
+	{">=1.0,,<2.0", "unexpected comma in `>=1.0,,<2.0`"},
+	{">=", "expected version after operator in `>=`"},
+	{"1.0 -", "expected version after hyphen in `1.0 -`"},
+	{"1.0@foo", "invalid stability flag `foo` in `1.0@foo`"},
+	{"1.0.0-foo", "invalid version `1.0.0-foo`"},
+	{"^dev-master", "invalid version `dev-master` in range in `^dev-master`"},
+	{"1.2.3.4.*", "invalid wildcard `1.2.3.4.*` in `1.2.3.4.*`"},
+	{">=1.*", "invalid branch alias `1.*`"},
+	{"foo", "invalid version `foo`"},
+}
+
+func TestComposerConstraintError(t *testing.T) {
+	testConstraintError(t, Composer, composerConstraintErrorTests)
+}
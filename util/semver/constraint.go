@@ -25,6 +25,16 @@ type Constraint struct {
 	sys    System // Packaging system in which constraint was expressed.
 	simple bool   // Constraint was created by a simple version, not a wildcard, no operators except ==.
 	set    Set
+	// arbitrary holds the operands of PyPI's arbitrary equality operator
+	// ===, which compares strings rather than versions. The set holds
+	// each operand that is a version, if any.
+	arbitrary []string
+	// arbitraryOnly reports that the constraint consists only of ===
+	// comparisons, so it may match a string that is not a version.
+	arbitraryOnly bool
+	// stability holds the least stable of the stability flags of a
+	// Composer constraint, as in 1.0@beta.
+	stability string
 }
 
 // String returns the string used to create the Constraint, trimmed of
@@ -43,9 +53,16 @@ func (c *Constraint) IsSimple() bool {
 	return c.simple
 }
 
-// Set returns the set representation of the constraint.
+// Set returns the set representation of the constraint. The set does
+// not capture PyPI's arbitrary equality operator ===, which compares
+// strings: it holds just the version named by the operator, if any.
+// The set is a copy, which may be modified without affecting the
+// constraint.
 func (c *Constraint) Set() Set {
-	return c.set
+	return Set{
+		sys:  c.set.sys,
+		span: append([]span(nil), c.set.span...),
+	}
 }
 
 // HasPrerelease reports whether the constraint contains a prerelease tag.
@@ -58,6 +75,62 @@ func (c *Constraint) HasPrerelease() bool {
 	return false
 }
 
+// Intersect returns a constraint matching the versions matched by both the
+// receiver and the argument, which must belong to the same System.
+func (c *Constraint) Intersect(d *Constraint) (*Constraint, error) {
+	return c.combine(d, (*Set).Intersect)
+}
+
+// Union returns a constraint matching the versions matched by either the
+// receiver or the argument, which must belong to the same System.
+func (c *Constraint) Union(d *Constraint) (*Constraint, error) {
+	return c.combine(d, (*Set).Union)
+}
+
+// Subtract returns a constraint matching the versions matched by the receiver
+// but not by the argument, which must belong to the same System.
+func (c *Constraint) Subtract(d *Constraint) (*Constraint, error) {
+	return c.combine(d, (*Set).Difference)
+}
+
+// Intersects reports whether some version is matched by both the receiver
+// and the argument, that is whether both constraints can be satisfied at
+// once.
+func (c *Constraint) Intersects(d *Constraint) (bool, error) {
+	i, err := c.Intersect(d)
+	if err != nil {
+		return false, err
+	}
+	return !i.set.Empty(), nil
+}
+
+// combine returns the constraint resulting from applying op to copies of the
+// sets of the receiver and the argument. The string of the result is the
+// representation of its set, acceptable by ParseSetConstraint.
+func (c *Constraint) combine(d *Constraint, op func(*Set, Set) error) (*Constraint, error) {
+	if c.sys != d.sys {
+		return nil, fmt.Errorf("cannot combine %s and %s constraints", c.sys, d.sys)
+	}
+	// The Set methods may reuse the span slices, which are shared by the
+	// constraints.
+	set := Set{
+		sys:  c.sys,
+		span: append([]span(nil), c.set.span...),
+	}
+	other := Set{
+		sys:  d.sys,
+		span: append([]span(nil), d.set.span...),
+	}
+	if err := op(&set, other); err != nil {
+		return nil, err
+	}
+	return &Constraint{
+		str: set.String(),
+		sys: c.sys,
+		set: set,
+	}, nil
+}
+
 type constraintParser struct {
 	*Constraint     // Accumulator for result.
 	weight      int // Incremented for each version or operator. Used to set Constraint.simple.
@@ -152,6 +225,9 @@ func (p *constraintParser) constraint() (*Constraint, error) {
 		}
 		return p.Constraint, err
 	}
+	if sys == Composer {
+		return p.composerConstraint()
+	}
 
 	// Some systems require an operator be present, that is, that the first
 	// element of the constraint is not a version.
@@ -178,6 +254,9 @@ func (p *constraintParser) constraint() (*Constraint, error) {
 		p.lex.unexpected(typ, tok)
 	}
 	p.Constraint.simple = p.weight == 1
+	// Each arbitrary equality adds one to the weight, and any other
+	// value at least one.
+	p.Constraint.arbitraryOnly = len(p.arbitrary) > 0 && p.weight == len(p.arbitrary)
 	return p.Constraint, p.lex.err
 }
 
@@ -198,7 +277,11 @@ func (p *constraintParser) orList() Set {
 	if sys == Maven || sys == NuGet {
 		orToken = tokComma
 	}
+	// The end of the last range, for Maven.
+	var lastMax *Version
 	for {
+		typ, _, _ := sys.token(p.lex.str[p.lex.pos:])
+		bracketed := typ == tokLbracket
 		set, ok := p.andList()
 		if !ok {
 			if lastWasOr {
@@ -208,6 +291,16 @@ func (p *constraintParser) orList() Set {
 			break
 		}
 		lastWasOr = false
+		// Maven 3 requires the ranges to be in order and disjoint,
+		// although they may share a bound. Soft requirements, which
+		// match anything, are not ranges.
+		if sys == Maven && bracketed && len(set.span) > 0 {
+			if lastMax != nil && set.span[0].min.lessThan(lastMax) {
+				p.lex.setErr("ranges overlap")
+				return Set{}
+			}
+			lastMax = set.span[len(set.span)-1].max
+		}
 		spans = append(spans, set.span...)
 		if sys == NuGet && len(spans) > 1 {
 			p.lex.setErr("cannot have more than one range")
@@ -327,6 +420,37 @@ func (p *constraintParser) value() (spans []span, hyphenated, valid bool) {
 	case tokInvalid:
 		p.lex.unexpected(typ, tok)
 		return
+	case tokArbitrary:
+		// PyPI's === compares strings, so its operand need not be a
+		// version: it is any text up to a space or comma.
+		rest := p.lex.str[p.lex.pos+i:]
+		start := 0
+		for start < len(rest) && rest[start] < 0x7F && byteType[rest[start]] == tWS {
+			start++
+		}
+		end := start
+		for end < len(rest) && rest[end] != ',' && !(rest[end] < 0x7F && byteType[rest[end]] == tWS) {
+			end++
+		}
+		if end == start {
+			p.lex.setErr("expected version after operator")
+			return
+		}
+		operand := rest[start:end]
+		p.lex.pos += i + end
+		p.arbitrary = append(p.arbitrary, operand)
+		p.weight++
+		// Only the version spelled as the operand can match, and
+		// nothing does if it is not a version.
+		spans = []span{{rank: empty}}
+		if v, err := sys.Parse(operand); err == nil && !v.IsWildcard() {
+			s, err := newSpan(v, closed, v, closed)
+			if err != nil {
+				p.lex.setError(err)
+				return
+			}
+			spans = []span{s}
+		}
 	case tokEqual, tokGreater, tokGreaterEqual, tokLess, tokLessEqual, tokNotEqual, tokCaret, tokTilde, tokBacon:
 		unop := tok
 		typ2, tok2, j := sys.token(p.lex.str[p.lex.pos+i:])
@@ -548,6 +672,14 @@ func (p *constraintParser) setRange(sys System) (set Set, ok bool) {
 			p.lex.setErr("expected closing bracket")
 			return
 		}
+		// As in Maven 3, the bounds must be ordered, and equal bounds
+		// must both be closed (but then it's a hard requirement).
+		if sys == Maven {
+			if c := max.Compare(min); c < 0 || c == 0 && (minOpen || maxOpen) {
+				p.lex.setErr("range defies version ordering")
+				return
+			}
+		}
 		sp, err = newSpan(min, minOpen, max, maxOpen)
 		if err != nil {
 			p.lex.setError(err)
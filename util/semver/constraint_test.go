@@ -168,3 +168,77 @@ func TestIsSimple(t *testing.T) {
 		}
 	}
 }
+
+func TestConstraintAlgebra(t *testing.T) {
+	tests := []struct {
+		sys                        System
+		c1, c2                     string
+		intersect, union, subtract string
+	}{
+		{NPM, "^1.2.0", "~1.4.0", "{[1.4.0:1.4.∞]}", "{[1.2.0:1.∞.∞]}", "{[1.2.0:1.4.0),(1.4.∞:1.∞.∞]}"},
+		{NPM, "^1.0.0", "^2.0.0", "{<empty>}", "{[1.0.0:2.∞.∞]}", "{[1.0.0:1.∞.∞]}"},
+		// As in TestUnion, spans starting with a prerelease are not merged.
+		{NPM, ">=1.0.0", "<1.0.0 || >=2.0.0", "{[2.0.0:∞.∞.∞]}", "{[0.0.0-0:1.0.0),[1.0.0:∞.∞.∞]}", "{[1.0.0:2.0.0)}"},
+		{Cargo, "1.2", "=1.3.1", "{1.3.1}", "{[1.2.0:1.∞.∞]}", "{[1.2.0:1.3.1),(1.3.1:1.∞.∞]}"},
+		{PyPI, ">=1.0,<2.0", "~=1.4", "{[1.4.0:1.∞.∞]}", "{[1.0.0:2.0.0)}", "{[1.0.0:1.4.0),(1.∞.∞:2.0.0)}"},
+		// Maven sets are not canonicalized.
+		{Maven, "[1.0,2.0)", "[1.5,3.0]", "{[1.5:2)}", "{[1:2),[1.5:3]}", "{[1:1.5)}"},
+	}
+	for _, test := range tests {
+		c1, err := test.sys.ParseConstraint(test.c1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c2, err := test.sys.ParseConstraint(test.c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s1, s2 := c1.Set().String(), c2.Set().String()
+		for _, op := range []struct {
+			name string
+			f    func(*Constraint) (*Constraint, error)
+			want string
+		}{
+			{"Intersect", c1.Intersect, test.intersect},
+			{"Union", c1.Union, test.union},
+			{"Subtract", c1.Subtract, test.subtract},
+		} {
+			got, err := op.f(c2)
+			if err != nil {
+				t.Errorf("%s: %q.%s(%q): %v", test.sys, test.c1, op.name, test.c2, err)
+				continue
+			}
+			if got.String() != op.want {
+				t.Errorf("%s: %q.%s(%q) = %s; want %s", test.sys, test.c1, op.name, test.c2, got, op.want)
+			}
+		}
+		// The operands must not be modified.
+		if got := c1.Set().String(); got != s1 {
+			t.Errorf("%s: %q modified to %s; want %s", test.sys, test.c1, got, s1)
+		}
+		if got := c2.Set().String(); got != s2 {
+			t.Errorf("%s: %q modified to %s; want %s", test.sys, test.c2, got, s2)
+		}
+		ok, err := c1.Intersects(c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := test.intersect != "{<empty>}"; ok != want {
+			t.Errorf("%s: %q.Intersects(%q) = %t; want %t", test.sys, test.c1, test.c2, ok, want)
+		}
+	}
+}
+
+func TestConstraintAlgebraSystems(t *testing.T) {
+	c1, err := NPM.ParseConstraint("^1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := Cargo.ParseConstraint("^1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c1.Intersect(c2); err == nil {
+		t.Errorf("Intersect of NPM and Cargo constraints: got no error")
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Debian-specific support.
+
+// debianExtension implements the Debian-specific parts of a Version,
+// [epoch:]upstream_version[-debian_revision], ordered as dpkg does. See
+// https://www.debian.org/doc/debian-policy/ch-controlfields.html#version.
+// None of the numbers of the Version are set; everything is stored here.
+type debianExtension struct {
+	version  *Version
+	epoch    int // -1 if absent.
+	upstream string
+	revision string // Without the leading hyphen; empty if absent.
+}
+
+// newDebianExtension builds the extension object for an extant Debian Version.
+func newDebianExtension(v *Version, str string) (*debianExtension, error) {
+	e := &debianExtension{
+		version: v,
+	}
+	return e, e.init(str)
+}
+
+// debianVersion parses a Debian Version. The str field is set by the caller;
+// all else is stored in the extension, which is constructed here and attached
+// to the returned Version.
+func (p *versionParser) debianVersion() (*Version, error) {
+	var err error
+	p.Version.ext, err = p.Version.newExtension(p.Version.str)
+	return p.Version, err
+}
+
+func (d *debianExtension) copy(v *Version) extension {
+	n := new(debianExtension)
+	*n = *d
+	n.version = v
+	return n
+}
+
+func (d *debianExtension) clearPre() {
+	// Debian has no prereleases; a tilde just sorts early.
+}
+
+func (d *debianExtension) empty() bool {
+	return d == nil || d.upstream == ""
+}
+
+// canon returns a canonicalized string representation of the version/extension.
+// A zero or missing epoch is omitted. The showBuild argument is ignored;
+// Debian doesn't have that concept.
+func (d *debianExtension) canon(showBuild bool) string {
+	var b strings.Builder
+	if d.epoch > 0 {
+		fmt.Fprintf(&b, "%d:", d.epoch)
+	}
+	b.WriteString(d.upstream)
+	if d.revision != "" {
+		b.WriteByte('-')
+		b.WriteString(d.revision)
+	}
+	return b.String()
+}
+
+var debianMinVersion *Version
+
+func init() {
+	// No version sorts before all others, as any number of tildes can be
+	// prepended, but no real version starts with a tilde.
+	debianMinVersion = &Version{
+		sys: Debian,
+		str: "~",
+	}
+	debianMinVersion.ext = &debianExtension{
+		version:  debianMinVersion,
+		epoch:    -1,
+		upstream: "~",
+	}
+}
+
+// splitEVR splits a version string of the form [epoch:]version[-release],
+// as used by Debian and RPM, into its parts. The epoch is -1 if absent.
+func splitEVR(input string) (epoch int, version, release string, err error) {
+	epoch = -1
+	version = input
+	if e, v, ok := strings.Cut(version, ":"); ok {
+		if e == "" {
+			return 0, "", "", fmt.Errorf("empty epoch in %#q", input)
+		}
+		for _, r := range e {
+			if !isDigit(r) {
+				return 0, "", "", fmt.Errorf("invalid epoch in %#q", input)
+			}
+		}
+		epoch, err = strconv.Atoi(e)
+		if err != nil {
+			return 0, "", "", fmt.Errorf("invalid epoch in %#q", input)
+		}
+		version = v
+	}
+	if i := strings.LastIndexByte(version, '-'); i >= 0 {
+		version, release = version[:i], version[i+1:]
+		if release == "" {
+			return 0, "", "", fmt.Errorf("empty revision in %#q", input)
+		}
+	}
+	if version == "" {
+		return 0, "", "", fmt.Errorf("empty version in %#q", input)
+	}
+	return epoch, version, release, nil
+}
+
+// init parses a Debian version string and stores its parts in the extension.
+// The rules are those of dpkg, which is more permissive than the policy.
+func (d *debianExtension) init(input string) error {
+	epoch, upstream, revision, err := splitEVR(input)
+	if err != nil {
+		return err
+	}
+	if !isDigit(rune(upstream[0])) {
+		return fmt.Errorf("version %#q does not start with a digit", input)
+	}
+	for _, r := range upstream {
+		if !isAlphanumeric(r) && !strings.ContainsRune(".+~-:", r) {
+			return fmt.Errorf("invalid character %q in `%s`", r, input)
+		}
+	}
+	for _, r := range revision {
+		if !isAlphanumeric(r) && !strings.ContainsRune(".+~", r) {
+			return fmt.Errorf("invalid character %q in `%s`", r, input)
+		}
+	}
+	d.epoch = epoch
+	d.upstream = upstream
+	d.revision = revision
+	return nil
+}
+
+// compare uses dpkg's rules to decide the ordering of d and e. A missing
+// epoch is equivalent to an epoch of 0 and a missing revision to a revision
+// of 0.
+func (d *debianExtension) compare(e extension) int {
+	f := e.(*debianExtension)
+	if c := sgn(max(d.epoch, 0), max(f.epoch, 0)); c != 0 {
+		return c
+	}
+	if c := debianCompare(d.upstream, f.upstream); c != 0 {
+		return c
+	}
+	return debianCompare(d.revision, f.revision)
+}
+
+// debianCompare compares two upstream versions or revisions as dpkg's
+// verrevcmp does: alternating non-digit and digit parts are compared in
+// turn, the former with debianOrder and the latter numerically.
+func debianCompare(a, b string) int {
+	for a != "" || b != "" {
+		for a != "" && !isDigit(rune(a[0])) || b != "" && !isDigit(rune(b[0])) {
+			ac, bc := debianOrder(a), debianOrder(b)
+			if ac != bc {
+				return sgn(ac, bc)
+			}
+			// The bytes are equal and neither string is exhausted.
+			a, b = a[1:], b[1:]
+		}
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+		firstDiff := 0
+		for a != "" && isDigit(rune(a[0])) && b != "" && isDigit(rune(b[0])) {
+			if firstDiff == 0 {
+				firstDiff = sgn(int(a[0]), int(b[0]))
+			}
+			a, b = a[1:], b[1:]
+		}
+		// The longer number is the larger.
+		if a != "" && isDigit(rune(a[0])) {
+			return 1
+		}
+		if b != "" && isDigit(rune(b[0])) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// debianOrder returns the weight of the first byte of s when comparing the
+// non-digit parts of a version. The tilde sorts before anything, even the
+// end of the string, and letters sort before other characters.
+func debianOrder(s string) int {
+	if s == "" {
+		return 0
+	}
+	c := s[0]
+	switch {
+	case isDigit(rune(c)):
+		return 0
+	case isAlpha(rune(c)):
+		return int(c)
+	case c == '~':
+		return -1
+	}
+	return int(c) + 256
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+// Debian-specific tests.
+
+import (
+	"strings"
+	"testing"
+)
+
+var debianCanonTests = []canonTest{
+	{"1.0", "1.0", ""},
+	{"1.0-1", "1.0-1", ""},
+	{"0:1.0-1", "1.0-1", ""},
+	{"2:1.0-1", "2:1.0-1", ""},
+	{"1.0~rc1-1", "1.0~rc1-1", ""},
+	{"1.0+dfsg-1+deb12u1", "1.0+dfsg-1+deb12u1", ""},
+	// Only the last hyphen starts the revision.
+	{"1.0-beta-2", "1.0-beta-2", ""},
+	// Colons are allowed in the upstream version if there is an epoch.
+	{"1:2:3-4", "1:2:3-4", ""},
+}
+
+func TestDebianCanon(t *testing.T) {
+	testVersionCanon(t, Debian, debianCanonTests)
+}
+
+func TestDebianVersionError(t *testing.T) {
+	tests := []struct {
+		str string
+		err string
+	}{
+		{"", "invalid version ``"},
+		{"a1.0", "invalid version `a1.0`"},
+		{":1.0", "invalid version `:1.0`"},
+		{"1a:1.0", "invalid epoch in `1a:1.0`"},
+		{"1:", "empty version in `1:`"},
+		{"1:a1.0", "version `1:a1.0` does not start with a digit"},
+		{"1.0-", "empty revision in `1.0-`"},
+		{"1.0 ", "invalid character ' ' in `1.0 `"},
+		{"1.0_1", "invalid character '_' in `1.0_1`"},
+		{"1.0-1:2", "invalid epoch in `1.0-1:2`"},
+		{"1.0-1-a_b", "invalid character '_' in `1.0-1-a_b`"},
+	}
+	for _, test := range tests {
+		_, err := Debian.Parse(test.str)
+		if err == nil {
+			t.Errorf("Debian.Parse(%q): no error", test.str)
+			continue
+		}
+		if err.Error() != test.err {
+			t.Errorf("Debian.Parse(%q) got error %#q; should have %q", test.str, err, test.err)
+		}
+	}
+}
+
+// Borrowed example tests from dpkg's t/t-version.c.
+var debianCompareTests = []compareTest{
+	{"0", "0", 0},
+	{"0", "00", 0},
+	{"1.0", "1.0-0", 0},
+	{"0:1.0", "1.0", 0},
+	{"1:0", "0:0", 1},
+	{"1:0", "2:0", -1},
+	{"1:1.0", "1.1", 1},
+	{"0:0-0", "0:0-1", -1},
+	{"0:0-a", "0:0-b", -1},
+	{"0:0-a", "0:0-0", 1},
+	{"0:0-0", "0:0-00", 0},
+	{"0:0-a1", "0:0-a1", 0},
+	{"0:0-a01", "0:0-a1", 0},
+	{"0:0-a", "0:0-a~", 1},
+	{"0:0-a~", "0:0-a~~", 1},
+	{"0:0-a~1", "0:0-a~2", -1},
+	{"0:0-1.2", "0:0-1.2", 0},
+	{"0:0-1+", "0:0-1.", -1},
+	{"0:0.0-1", "0:0.00-1", 0},
+	{"0:09", "0:10", -1},
+	{"1.0", "1.0.0", -1},
+	{"1.0a", "1.0+", -1},
+	{"1.0", "1.0a", -1},
+	{"2.30", "2.4", 1},
+}
+
+func TestDebianCompare(t *testing.T) {
+	testCompare(t, Debian, debianCompareTests)
+}
+
+func TestDebianCompareSequential(t *testing.T) {
+	tests := []string{
+		"1.0~~",
+		"1.0~~a",
+		"1.0~",
+		"1.0~alpha1",
+		"1.0~rc1",
+		"1.0",
+		"1.0-1~bpo1",
+		"1.0-1",
+		"1.0-1+deb12u1",
+		"1.0-1.1",
+		"1.0a",
+		"1.0+dfsg",
+		"1.0.1",
+		"1.1",
+		"1:0.9",
+		"2:0.1",
+	}
+	testCompareSequential(t, Debian, tests)
+}
+
+var debianTestVersions = strings.Fields(`
+0.9
+1.0~rc1
+1.0
+1.0-1
+1.0-1+deb12u1
+1.0-2
+1.0+dfsg-1
+1.1
+2.0
+1:0.5`)
+
+func TestDebianMatch(t *testing.T) {
+	tests := []struct {
+		con     string
+		matches string
+	}{
+		{"1.0", "1.0"},
+		{"= 1.0-1", "1.0-1"},
+		{">> 1.0-1", "1.0-1+deb12u1 1.0-2 1.0+dfsg-1 1.1 2.0 1:0.5"},
+		{">= 1.0-1", "1.0-1 1.0-1+deb12u1 1.0-2 1.0+dfsg-1 1.1 2.0 1:0.5"},
+		{"<< 1.0", "0.9 1.0~rc1"},
+		{"<= 1.0", "0.9 1.0~rc1 1.0"},
+		// The obsolete < and > are not strict.
+		{"< 1.0", "0.9 1.0~rc1 1.0"},
+		{"> 1.1", "1.1 2.0 1:0.5"},
+		{">= 1.0, << 1.1", "1.0 1.0-1 1.0-1+deb12u1 1.0-2 1.0+dfsg-1"},
+		{">= 1.0 << 1.0-2", "1.0 1.0-1 1.0-1+deb12u1"},
+		{"<< 1.0 | >= 2.0", "0.9 1.0~rc1 2.0 1:0.5"},
+		{"= 0.9 || = 1.1", "0.9 1.1"},
+		{">= 1:0", "1:0.5"},
+	}
+	for _, test := range tests {
+		c := parseConstraint(t, Debian, test.con)
+		want := m(test.matches)
+		for _, vs := range debianTestVersions {
+			if got := c.Match(vs); got != want[vs] {
+				t.Errorf("Debian %q.Match(%q) = %t; want %t", test.con, vs, got, want[vs])
+			}
+		}
+	}
+}
+
+var debianConstraintErrorTests = []constraintErrorTest{
+	{"1.0 - 2.0", "invalid text `-` in `1.0 - 2.0`"},
+	{"~1.0", "invalid version `~1.0`"},
+	{"^1.0", "invalid `^` in `^1.0`"},
+	{"!= 1.0", "invalid `!` in `!= 1.0`"},
+	{">= a1.0", "invalid version `a1.0`"},
+	{">= 1.0,", "missing item after comma in `>= 1.0,`"},
+}
+
+func TestDebianConstraintError(t *testing.T) {
+	testConstraintError(t, Debian, debianConstraintErrorTests)
+}
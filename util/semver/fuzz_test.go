@@ -0,0 +1,213 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+// Fuzz tests. Version strings and constraints come from registries and
+// manifests, so parsing and matching must not panic on any input. Run one
+// with, for example:
+//
+//	go test -fuzz=FuzzParse
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// fuzzSystems holds every system, including those not in allSystems.
+var fuzzSystems = []System{DefaultSystem, Cargo, Go, Maven, NPM, NuGet, PyPI, RubyGems, Composer, Debian, RPM}
+
+var fuzzVersions = []string{
+	"", "0", "1", "1.2", "1.2.3", "v1.2.3", "vv1.2.3", "1.2.3-alpha.1+build.01",
+	"1.2.3--+-", "1.0.0.0.0.1", "1.2.3.rc1", "1.0a1.post2.dev3", "1!2.0",
+	"2:1.2-3", "1.0~rc1", "1.0^git1", "1.0-SNAPSHOT", "1.0.RELEASE", "1.*",
+	"x", "99999999999999999999999.0.0", "1.2.3-" + string(rune(0xfffd)),
+}
+
+var fuzzConstraints = []string{
+	"", "*", "1.2.3", "=1.2.3", "^1.2.3", "~1.2.3", "~>1.2", ">=1.0 <2.0",
+	"1.0 - 2.0", "<1 || >2", ">=1.0, <2.0", "~=1.4.2", "!=1.0", "== 1.*",
+	"[1.0,2.0)", "(,1.0],[1.2,)", "[1.0]", "(,)", ">> 1.0 | << 0.5", "^0.0.0-0",
+	">=1.0.0-alpha <1.0.0", "1.x.x", "v1.2.3", "===foobar", ">=1!1.0",
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range fuzzVersions {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, str string) {
+		for _, sys := range fuzzSystems {
+			v, err := sys.Parse(str)
+			if err != nil {
+				continue
+			}
+			if c := v.Compare(v); c != 0 {
+				t.Errorf("%v: %q compares %d with itself", sys, str, c)
+			}
+			if c := sys.Compare(str, str); c != 0 {
+				t.Errorf("%v: Compare(%q, %q) = %d", sys, str, str, c)
+			}
+			// Exercise the accessors.
+			_ = v.String()
+			_ = v.Canon(true)
+			_ = v.IsWildcard()
+			_ = v.IsPrerelease()
+			_ = v.PrereleaseIdentifiers()
+			_ = v.BuildIdentifiers()
+		}
+	})
+}
+
+func FuzzCompare(f *testing.F) {
+	for i, s := range fuzzVersions {
+		f.Add(s, fuzzVersions[(i+1)%len(fuzzVersions)])
+	}
+	f.Fuzz(func(t *testing.T, a, b string) {
+		for _, sys := range fuzzSystems {
+			va, err := sys.Parse(a)
+			if err != nil {
+				continue
+			}
+			vb, err := sys.Parse(b)
+			if err != nil {
+				continue
+			}
+			if ab, ba := va.Compare(vb), vb.Compare(va); ab != -ba {
+				t.Errorf("%v: Compare(%q, %q) = %d but Compare(%q, %q) = %d", sys, a, b, ab, b, a, ba)
+			}
+		}
+	})
+}
+
+func FuzzParseConstraint(f *testing.F) {
+	for i, s := range fuzzConstraints {
+		f.Add(s, fuzzVersions[i%len(fuzzVersions)])
+	}
+	f.Fuzz(func(t *testing.T, constraint, version string) {
+		for _, sys := range fuzzSystems {
+			c, err := sys.ParseConstraint(constraint)
+			if err != nil {
+				continue
+			}
+			_ = c.String()
+			_ = c.IsSimple()
+			_ = c.HasPrerelease()
+			match := c.Match(version)
+			if v, err := sys.Parse(version); err == nil {
+				if mv := c.MatchVersion(v); mv != match {
+					t.Errorf("%v: %q: Match(%q) = %t but MatchVersion = %t", sys, constraint, version, match, mv)
+				}
+				_ = c.MatchVersionPrerelease(v)
+			} else if match && !strings.Contains(constraint, "===") {
+				t.Errorf("%v: %q matches unparseable version %q", sys, constraint, version)
+			}
+		}
+	})
+}
+
+// strictSemver matches the versions of semver.org, without build metadata,
+// which all of the systems in overlapSystems parse and order alike.
+var strictSemver = regexp.MustCompile(`^(0|[1-9][0-9]{0,8})\.(0|[1-9][0-9]{0,8})\.(0|[1-9][0-9]{0,8})(-(0|[1-9][0-9]{0,8}|[0-9]*[a-zA-Z-][0-9a-zA-Z-]*)(\.(0|[1-9][0-9]{0,8}|[0-9]*[a-zA-Z-][0-9a-zA-Z-]*))*)?$`)
+
+// overlapSystems are the systems following semver.org for versions such
+// as those matched by strictSemver. Go requires a leading "v".
+var overlapSystems = []System{DefaultSystem, Cargo, NPM, Go}
+
+func goVersion(sys System, s string) string {
+	if sys == Go {
+		return "v" + s
+	}
+	return s
+}
+
+// FuzzCompareDifferential checks that systems whose grammars overlap order
+// versions in the overlap alike.
+func FuzzCompareDifferential(f *testing.F) {
+	f.Add("1.2.3", "1.2.3-alpha")
+	f.Add("1.0.0-alpha.1", "1.0.0-alpha.beta")
+	f.Add("1.0.0-2", "1.0.0-10")
+	f.Add("0.9.9", "1.0.0-rc.1")
+	f.Fuzz(func(t *testing.T, a, b string) {
+		if !strictSemver.MatchString(a) || !strictSemver.MatchString(b) {
+			return
+		}
+		want := DefaultSystem.Compare(a, b)
+		for _, sys := range overlapSystems {
+			va, err := sys.Parse(goVersion(sys, a))
+			if err != nil {
+				t.Fatalf("%v: Parse(%q): %v", sys, a, err)
+			}
+			vb, err := sys.Parse(goVersion(sys, b))
+			if err != nil {
+				t.Fatalf("%v: Parse(%q): %v", sys, b, err)
+			}
+			if got := va.Compare(vb); got != want {
+				t.Errorf("%v: Compare(%q, %q) = %d, %v gives %d", sys, a, b, got, DefaultSystem, want)
+			}
+		}
+	})
+}
+
+// FuzzMatchDifferential checks that systems whose constraint grammars
+// overlap match simple comparisons alike, for versions without
+// prereleases, whose matching rules vary.
+func FuzzMatchDifferential(f *testing.F) {
+	f.Add(">=", "1.2.3", "1.3.0")
+	f.Add("<", "2.0.0", "1.9.9")
+	f.Add("=", "1.0.0", "1.0.0")
+	f.Fuzz(func(t *testing.T, op, bound, version string) {
+		switch op {
+		case "=", ">", ">=", "<", "<=":
+		default:
+			return
+		}
+		if !strictSemver.MatchString(bound) || !strictSemver.MatchString(version) {
+			return
+		}
+		v, err := DefaultSystem.Parse(version)
+		if err != nil || v.IsPrerelease() {
+			return
+		}
+		var want bool
+		for i, sys := range []System{DefaultSystem, Cargo, NPM} {
+			c, err := sys.ParseConstraint(op + bound)
+			if err != nil {
+				t.Fatalf("%v: ParseConstraint(%q): %v", sys, op+bound, err)
+			}
+			got := c.Match(version)
+			if i == 0 {
+				want = got
+			} else if got != want {
+				t.Errorf("%v: %q matching %q: got %t, %v gives %t", sys, op+bound, version, got, DefaultSystem, want)
+			}
+		}
+	})
+}
+
+func FuzzCompareStrings(f *testing.F) {
+	for i, s := range fuzzVersions {
+		f.Add(s, fuzzVersions[(i+1)%len(fuzzVersions)])
+	}
+	f.Add("1.2", "1.2.0")
+	f.Add("01.2.3", "1.2.3")
+	f.Add("123456789012345678.0", "123456789012345679")
+	f.Fuzz(func(t *testing.T, a, b string) {
+		for _, sys := range fuzzSystems {
+			if got, want := sys.CompareStrings(a, b), sys.Compare(a, b); got != want {
+				t.Errorf("%v: CompareStrings(%q, %q) = %d, Compare = %d", sys, a, b, got, want)
+			}
+		}
+	})
+}
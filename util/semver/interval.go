@@ -111,6 +111,10 @@ func (v *Version) inc() error {
 // opVersionToSpan takes a possibly empty operator and a version and returns
 // the span represented by applying the operator to the version.
 func opVersionToSpan(typ tokType, op string, lo *Version) (span, error) {
+	// Debian and RPM versions are opaque; only plain comparisons apply.
+	if lo.sys == Debian || lo.sys == RPM {
+		return evrOpVersionToSpan(typ, op, lo)
+	}
 	// If the version has a wildcard, any prerelease info is irrelevant, so
 	// drop it. NuGet wildcard constraints exclude pre-releases unless
 	// explicitly specified.
@@ -130,7 +134,6 @@ func opVersionToSpan(typ tokType, op string, lo *Version) (span, error) {
 	}
 	hi := lo.copy()
 	minOpen, maxOpen := closed, closed
-	// TODO: PyPI has the special operator ===, which we do not handle.
 	switch typ {
 	case tokEmpty, tokEqual:
 		switch len(lo.num) {
@@ -178,6 +181,13 @@ func opVersionToSpan(typ tokType, op string, lo *Version) (span, error) {
 			hi.num[i] = infinity
 		}
 		hi.clearPre()
+		// PyPI compares epochs first, so a bound in the epoch of lo
+		// would exclude every later epoch. Without its extension the
+		// bound compares by its numbers alone, like the infinity of
+		// ParseSetConstraint, and so follows every epoch.
+		if hi.sys == PyPI {
+			hi.ext = nil
+		}
 		// lo.IsWildcard doesn't report prerelease wildcards.
 		if lo.sys == NuGet && (lo.IsWildcard() || wildcard) {
 			return newSpan(lo, closed, hi, open)
@@ -185,8 +195,10 @@ func opVersionToSpan(typ tokType, op string, lo *Version) (span, error) {
 		hi.build = ""
 
 	case tokLess:
-		// Special horrible cases.
-		if lo.all(wildcard) || lo.all(0) {
+		// Special horrible cases. A PyPI version with an epoch follows
+		// every version of the earlier epochs, even if its numbers
+		// are all zero.
+		if epoch, _ := lo.Epoch(); lo.all(wildcard) || (lo.all(0) && epoch == 0) {
 			return span{rank: empty}, nil
 		}
 		for i, val := range hi.num {
@@ -259,6 +271,11 @@ func opVersionToSpan(typ tokType, op string, lo *Version) (span, error) {
 		}
 
 	case tokBacon:
+		if lo.sys == RubyGems {
+			// RubyGems bumps the release, dropping any prerelease:
+			// ~>1.0.0.beta matches versions whose release is below 1.1.
+			hi.clearPre()
+		}
 		n := len(lo.num)
 		if lo.sys == RubyGems || lo.sys == PyPI {
 			// RubyGems and PyPI fill trailing zeros, but this operator needs to
@@ -286,7 +303,7 @@ func opVersionToSpan(typ tokType, op string, lo *Version) (span, error) {
 		case 3:
 			hi.setPatch(infinity)
 		default:
-			hi.setNum(len(hi.num)-1, infinity)
+			hi.setNum(n-1, infinity)
 		}
 
 	default:
@@ -307,8 +324,30 @@ func opVersionToSpan(typ tokType, op string, lo *Version) (span, error) {
 	return newSpan(lo, minOpen, hi, maxOpen)
 }
 
+// evrOpVersionToSpan is opVersionToSpan for the systems whose versions
+// have the form [epoch:]version[-release], which have no wildcards and
+// no operators beyond comparisons.
+func evrOpVersionToSpan(typ tokType, op string, v *Version) (span, error) {
+	switch typ {
+	case tokEmpty, tokEqual:
+		return newSpan(v, closed, v, closed)
+	case tokLess, tokLessEqual:
+		return newSpan(v.sys.MinVersion(&Version{sys: v.sys}), closed, v, typ == tokLess)
+	case tokGreater, tokGreaterEqual:
+		inf, err := v.sys.parse("∞.∞.∞", true)
+		if err != nil {
+			return span{}, err
+		}
+		return newSpan(v, typ == tokGreater, inf, closed)
+	}
+	return span{}, fmt.Errorf("unrecognized operator %q", op)
+}
+
 func (v *Version) rebuildExtension() error {
-	if v.ext == nil || v.ext.empty() {
+	// A RubyGems extension reads the numbers from the version and holds
+	// only the prerelease, which reparsing the canonical form would
+	// alter: 1.0.0.beta would become 1.0.0.pre.beta.
+	if v.ext == nil || v.ext.empty() || v.sys == RubyGems {
 		return nil
 	}
 	var err error
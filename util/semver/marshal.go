@@ -0,0 +1,96 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The text form of Versions and Constraints is the name of their System,
+// a colon, and the string they were created from, as in "NPM:^1.2.3". The
+// System name is matched without regard to case when decoding. As the
+// encoding/json package uses the text form, Versions and Constraints are
+// encoded in JSON as strings.
+
+// MarshalText implements encoding.TextMarshaler.
+func (v *Version) MarshalText() ([]byte, error) {
+	return marshalText(v.sys, v.str), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. The text must be
+// of the form produced by MarshalText.
+func (v *Version) UnmarshalText(text []byte) error {
+	sys, str, err := unmarshalText(text)
+	if err != nil {
+		return err
+	}
+	u, err := sys.Parse(str)
+	if err != nil {
+		return err
+	}
+	*v = *u
+	// Don't share the storage of u.
+	v.num = append(v.buf[:0], u.num...)
+	if u.ext != nil {
+		v.ext = u.ext.copy(v)
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c *Constraint) MarshalText() ([]byte, error) {
+	return marshalText(c.sys, c.str), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. The text must be
+// of the form produced by MarshalText. Constraints built by combining
+// others, whose string is in the set syntax, are parsed with
+// ParseSetConstraint.
+func (c *Constraint) UnmarshalText(text []byte) error {
+	sys, str, err := unmarshalText(text)
+	if err != nil {
+		return err
+	}
+	parse := sys.ParseConstraint
+	if strings.HasPrefix(str, "{") {
+		parse = sys.ParseSetConstraint
+	}
+	d, err := parse(str)
+	if err != nil {
+		return err
+	}
+	*c = *d
+	return nil
+}
+
+func marshalText(sys System, str string) []byte {
+	return []byte(sys.String() + ":" + str)
+}
+
+// unmarshalText splits the text form of a Version or Constraint into its
+// System and string.
+func unmarshalText(text []byte) (System, string, error) {
+	name, str, ok := strings.Cut(string(text), ":")
+	if !ok {
+		return 0, "", fmt.Errorf("missing system in %#q", text)
+	}
+	for sys := DefaultSystem; !strings.HasPrefix(sys.String(), "System("); sys++ {
+		if strings.EqualFold(sys.String(), name) {
+			return sys, str, nil
+		}
+	}
+	return 0, "", fmt.Errorf("unknown system %#q", name)
+}
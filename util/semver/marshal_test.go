@@ -0,0 +1,152 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestVersionText(t *testing.T) {
+	tests := []struct {
+		sys  System
+		v    string
+		text string
+	}{
+		{NPM, "1.2.3-beta+build", "NPM:1.2.3-beta+build"},
+		{Go, "v1.2.3", "Go:v1.2.3"},
+		{Maven, "1.0.0-SNAPSHOT", "Maven:1.0.0-SNAPSHOT"},
+		{PyPI, "1!2.0rc1", "PyPI:1!2.0rc1"},
+		{RubyGems, "1.2.3.4.a", "RubyGems:1.2.3.4.a"},
+		{Debian, "1:2.0-1", "Debian:1:2.0-1"},
+		{DefaultSystem, "1.2", "DefaultSystem:1.2"},
+	}
+	for _, test := range tests {
+		v := parseVersion(t, test.sys, test.v)
+		text, err := v.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(text) != test.text {
+			t.Errorf("%s: %q.MarshalText() = %q; want %q", test.sys, test.v, text, test.text)
+		}
+		var u Version
+		if err := u.UnmarshalText(text); err != nil {
+			t.Errorf("UnmarshalText(%q): %v", text, err)
+			continue
+		}
+		if u.sys != test.sys || u.String() != test.v || u.Compare(v) != 0 {
+			t.Errorf("UnmarshalText(%q) = %s %s; want %s %s", text, u.sys, &u, test.sys, test.v)
+		}
+	}
+}
+
+func TestConstraintText(t *testing.T) {
+	tests := []struct {
+		sys  System
+		c    string
+		text string
+	}{
+		{NPM, "^1.2.3 || 2.x", "NPM:^1.2.3 || 2.x"},
+		{Maven, "[1.0,2.0)", "Maven:[1.0,2.0)"},
+		{PyPI, ">=1.0,!=1.5", "PyPI:>=1.0,!=1.5"},
+	}
+	for _, test := range tests {
+		c := parseConstraint(t, test.sys, test.c)
+		text, err := c.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(text) != test.text {
+			t.Errorf("%s: %q.MarshalText() = %q; want %q", test.sys, test.c, text, test.text)
+		}
+		var d Constraint
+		if err := d.UnmarshalText(text); err != nil {
+			t.Errorf("UnmarshalText(%q): %v", text, err)
+			continue
+		}
+		if d.sys != test.sys || d.String() != test.c || !d.set.equal(c.set) {
+			t.Errorf("UnmarshalText(%q) = %s %s; want %s %s", text, d.sys, &d, test.sys, test.c)
+		}
+	}
+}
+
+func TestConstraintTextCombined(t *testing.T) {
+	c := parseConstraint(t, NPM, ">=1.0.0")
+	d := parseConstraint(t, NPM, "<2.0.0")
+	e, err := c.Intersect(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := e.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f Constraint
+	if err := f.UnmarshalText(text); err != nil {
+		t.Fatalf("UnmarshalText(%q): %v", text, err)
+	}
+	if !f.set.equal(e.set) {
+		t.Errorf("UnmarshalText(%q) = %s; want %s", text, f.set, e.set)
+	}
+}
+
+func TestTextJSON(t *testing.T) {
+	type config struct {
+		Version    *Version
+		Constraint *Constraint
+	}
+	in := config{
+		Version:    parseVersion(t, NPM, "1.2.3"),
+		Constraint: parseConstraint(t, NPM, "^1.0.0"),
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"Version":"NPM:1.2.3","Constraint":"NPM:^1.0.0"}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s; want %s", data, want)
+	}
+	var out config
+	if err := json.Unmarshal([]byte(`{"Version":"npm:1.2.3","Constraint":"npm:^1.0.0"}`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Constraint.MatchVersion(out.Version) {
+		t.Errorf("%s does not match %s after decoding", out.Constraint, out.Version)
+	}
+}
+
+func TestUnmarshalTextError(t *testing.T) {
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{"1.2.3", "missing system in `1.2.3`"},
+		{"Foo:1.2.3", "unknown system `Foo`"},
+		{"Go:1.2.3", "invalid version `1.2.3`"},
+	}
+	for _, test := range tests {
+		var v Version
+		err := v.UnmarshalText([]byte(test.text))
+		if err == nil {
+			t.Errorf("UnmarshalText(%q): no error", test.text)
+			continue
+		}
+		if err.Error() != test.err {
+			t.Errorf("UnmarshalText(%q) got error %#q; should have %q", test.text, err, test.err)
+		}
+	}
+}
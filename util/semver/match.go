@@ -70,11 +70,23 @@ version is in any of the ranges.
 func (c *Constraint) Match(version string) bool {
 	v, err := c.sys.Parse(version)
 	if err != nil {
-		return false
+		// PyPI's === may match a string that is not a version.
+		return c.arbitraryOnly && c.matchArbitrary(version)
 	}
 	return c.match(v)
 }
 
+// matchArbitrary reports whether the version string equals, ignoring case
+// as pip does, the operand of every arbitrary equality in the constraint.
+func (c *Constraint) matchArbitrary(version string) bool {
+	for _, a := range c.arbitrary {
+		if !strings.EqualFold(version, a) {
+			return false
+		}
+	}
+	return true
+}
+
 // MatchVersion is like Match but it takes a *Version.
 func (c *Constraint) MatchVersion(v *Version) bool {
 	if v.IsWildcard() {
@@ -83,6 +95,25 @@ func (c *Constraint) MatchVersion(v *Version) bool {
 	return c.match(v)
 }
 
+// MinSatisfying returns the lowest of the versions matched by the constraint,
+// and whether there is one. Versions that cannot be parsed are ignored.
+func (c *Constraint) MinSatisfying(versions []string) (string, bool) {
+	var (
+		min    *Version
+		minStr string
+	)
+	for _, str := range versions {
+		v, err := c.sys.Parse(str)
+		if err != nil || !c.MatchVersion(v) {
+			continue
+		}
+		if min == nil || v.lessThan(min) {
+			min, minStr = v, str
+		}
+	}
+	return minStr, min != nil
+}
+
 // match is a helper that also tweaks prerelease in some cases.
 func (c *Constraint) match(v *Version) bool {
 	prerelease := false
@@ -92,6 +123,9 @@ func (c *Constraint) match(v *Version) bool {
 	if c.sys == NuGet && !strings.ContainsAny(c.str, "[(,]*") {
 		prerelease = true
 	}
+	if !c.matchArbitrary(v.str) {
+		return false
+	}
 	// The empty constraint in PyPI does not match dev versions.
 	if c.sys == PyPI && c.str == "" && v.ext.(*pep440Extension).isDev() {
 		return false
@@ -105,7 +139,7 @@ func (c *Constraint) match(v *Version) bool {
 // It is used when matching vulnerabilities, not during constraint satisfaction
 // for builds.
 func (c *Constraint) MatchVersionPrerelease(v *Version) bool {
-	if v.IsWildcard() {
+	if v.IsWildcard() || !c.matchArbitrary(v.str) {
 		return false
 	}
 	return c.set.matchVersion(v, true)
@@ -434,3 +434,31 @@ var testVersions = strings.Fields(`
 4.17.0
 4.17.1
 4.17.2`)
+
+func TestMinSatisfying(t *testing.T) {
+	versions := []string{"2.0.0", "1.2.0", "1.10.0", "1.3.0-beta", "not-a-version", "1.1.0", "0.9.0"}
+	tests := []struct {
+		sys        System
+		constraint string
+		want       string
+		ok         bool
+	}{
+		{NPM, "^1.1.5", "1.2.0", true},
+		{NPM, ">=1.0.0", "1.1.0", true},
+		{NPM, "*", "0.9.0", true},
+		{NPM, "^3.0.0", "", false},
+		{NPM, "~1.3.0-alpha", "1.3.0-beta", true},
+		{Maven, "[1.1.1,2.0.0)", "1.2.0", true},
+		{Cargo, "1.5", "1.10.0", true},
+	}
+	for _, test := range tests {
+		c, err := test.sys.ParseConstraint(test.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := c.MinSatisfying(versions)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%s: %q.MinSatisfying() = %q, %t; want %q, %t", test.sys, test.constraint, got, ok, test.want, test.ok)
+		}
+	}
+}
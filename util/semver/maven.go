@@ -20,9 +20,9 @@ import (
 	"unicode/utf8"
 )
 
-// Maven-specific support - roughly equivalent to v3.6.0 / v3.8.6
-// Note that v3.8.7/v3.9.0 changed some of these rules and it's not
-// clear if those will be reverted yet.
+// Maven-specific support, equivalent to Maven 3.9. Earlier versions of
+// Maven 3 ordered a qualifier after a period before the same qualifier after
+// a hyphen; since v3.9.0 the separator before a qualifier is irrelevant.
 
 // mavenExtension implements the Maven-specific parts of a Version,
 // including its peculiar ordering requirements as defined in
@@ -57,8 +57,8 @@ func (m *mavenExtension) copy(v *Version) extension {
 }
 
 func (m *mavenExtension) clearPre() {
-	// TODO: Implement this when Maven 3 happens.
-	// For Maven version 2, it's irrelevant.
+	// Irrelevant: Maven ranges are bounded by exact versions, and
+	// qualifiers are not stripped from them.
 }
 
 func (m *mavenExtension) empty() bool {
@@ -230,6 +230,10 @@ func (m *mavenExtension) init(input string) error {
 				elements[i].int = int64(val)
 			}
 		} else {
+			if e.str == "cr" {
+				// An alias, as in Maven.
+				elements[i].str = "rc"
+			}
 			m.version.isPrerelease = true // TODO is this right?
 		}
 	}
@@ -315,19 +319,16 @@ func (m *mavenExtension) compare(e extension) int {
 			}
 			return sgn64(a.int, b.int)
 		}
-		if a.sep != b.sep {
-			return int(b.sep) - int(a.sep) // Note: reversed compared to numeric. Nice.
-		}
+		// The separator before a qualifier doesn't matter: 1.foo = 1-foo.
 		c := compareMavenQualifier(a.str, b.str)
 		if c == 0 {
 			continue
 		}
 		return c
 	}
-	if len(bs) > len(as) {
-		return -1
-	}
-	return 0
+	// The elements only differ by padding, which happens when a leading
+	// element matches it, as in "." against "": the longer is greater.
+	return sgn(len(as), len(bs))
 }
 
 // mavenUnknownQualifierCompare handles the case where a is an unknown
@@ -338,9 +339,6 @@ func mavenUnknownQualifierCompare(a, b mavenElement, aOrder, bCategory int) int
 		bOrder := mavenVersionQualifierOrder[b.str]
 		if aOrder == bOrder {
 			// b is also undefined or SP; use normal ordering rules.
-			if a.sep != b.sep {
-				return int(b.sep) - int(a.sep)
-			}
 			return sgnStr(a.str, b.str)
 		}
 		return sgn(aOrder, bOrder)
@@ -365,7 +363,7 @@ var mavenVersionQualifierOrder = map[string]int{
 	"beta":      mavenEmptyQualifier - 4,
 	"milestone": mavenEmptyQualifier - 3,
 	"rc":        mavenEmptyQualifier - 2,
-	"cr":        mavenEmptyQualifier - 2, // sic.
+	"cr":        mavenEmptyQualifier - 2, // sic; canonicalized to rc.
 	"snapshot":  mavenEmptyQualifier - 1,
 	"":          mavenEmptyQualifier,
 	"release":   mavenEmptyQualifier, // Undocumented but prevalent.
@@ -19,8 +19,10 @@ import (
 )
 
 // Maven-specific tests.
-// Maven 3.9.0 (or perhaps 3.8.7) changed the version parsing behaviour
-// We're not targeting that yet.
+// Maven 3.9.0 (or perhaps 3.8.7) changed the version parsing behaviour.
+// The ordering of qualifiers follows it, but the canonical forms in the
+// section of mavenCanonTests marked "Maven 3.9.0" still follow the earlier
+// rules.
 
 var mavenCanonTests = []canonTest{
 	{"1", "1", ""}, // Trailing zeros, null strings are trimmed.
@@ -46,7 +48,8 @@ var mavenCanonTests = []canonTest{
 	{"1.0.0-0.0.0", "1", ""},
 	{"1-1.foo-bar1baz-.1", "1-1.foo-bar-1-baz-0.1", ""},
 
-	// These change in Maven 3.9.0
+	// Maven 3.9.0: these canonical forms change in Maven 3.9.0, and
+	// follow the earlier rules here.
 	{"1.2.alpha.alpha", "1.2.alpha.alpha", ""},
 	{"1.2.alpha.alpha.alpha", "1.2.alpha.alpha.alpha", ""},
 	{"1.2.g.g", "1.2.g.g", ""},
@@ -74,6 +77,10 @@ var mavenCanonTests = []canonTest{
 	{"a0", "alpha", ""},
 	{"a1", "alpha-1", ""},
 	{"a-1", "a-1", ""},
+
+	// The cr qualifier is an alias.
+	{"1.0-cr1", "1-rc-1", ""},
+	{"1.0.CR", "1.0.rc", ""},
 }
 
 func TestMavenCanon(t *testing.T) {
@@ -87,6 +94,15 @@ var mavenConstraintErrorTests = []constraintErrorTest{
 	{"(1.0)", "hard requirement must be closed on both ends in `(1.0)`"},
 	{"[1.0]]2.0]", "unexpected rbracket in `[1.0]]2.0]`"},
 	{"[1.0][2.0]", "unexpected lbracket in `[1.0][2.0]`"},
+
+	// Maven 3 range validation.
+	{"[2.0,1.0]", "range defies version ordering in `[2.0,1.0]`"},
+	{"[1.0,1.0)", "range defies version ordering in `[1.0,1.0)`"},
+	{"(1.0,1.0]", "range defies version ordering in `(1.0,1.0]`"},
+	{"[1.0,2.0],[1.5,3.0]", "ranges overlap in `[1.0,2.0],[1.5,3.0]`"},
+	{"[3.0,4.0],[1.0,2.0]", "ranges overlap in `[3.0,4.0],[1.0,2.0]`"},
+	{"[1.0,),[2.0]", "ranges overlap in `[1.0,),[2.0]`"},
+	{"[1.0],(,2.0]", "ranges overlap in `[1.0],(,2.0]`"},
 }
 
 func TestMavenConstraintError(t *testing.T) {
@@ -113,6 +129,9 @@ func TestMavenSets(t *testing.T) {
 		// Other examples.
 		{"[1.2],[1.4]", "{1.2,1.4}"},
 		{"[1.2.a_4],[1.4]", "{1.2.a_-4,1.4}"},
+		{"[1.0,1.0]", "{1}"},
+		{"[1.0,1.1),[1.1,2.0]", "{[1:1.1),[1.1:2]}"},
+		{"1,[2.0]", "{[0:∞.∞.∞],2}"},
 	}
 	for _, test := range tests {
 		if !sameSet(Maven, test.con, test.ref) {
@@ -132,7 +151,7 @@ var mavenCompareTests = []compareTest{
 	{"1-foo2", "1-foo10", -1},
 	{"1-FOO2", "1-foo2", 0}, // Case insensitive.
 	{"1-foo2", "1-FOO2", 0}, // Case insensitive.
-	{"1.foo", "1-foo", -1},  // Changes in Maven 3.9.0
+	{"1.foo", "1-foo", 0},   // Since Maven 3.9.0; was -1.
 	{"1-foo", "1-1", -1},
 	{"1-1", "1.1", -1},
 	{"1-1", "1.1", -1},
@@ -142,6 +161,12 @@ var mavenCompareTests = []compareTest{
 	{"1-release", "1-0", 0},
 	{"1.final", "1-ga", 0},
 	{"1-final", "1-0", 0},
+	{"1-cr1", "1-rc1", 0},
+	{"1.rc", "1-cr", 0},
+	{"1-rc", "1-snapshot", -1},
+	{"1-snapshot", "1-ga", -1},
+	{"1.sp", "1-sp", 0},
+	{"1-sp", "1.foo", -1},
 	{"1-0", "1.0", 0},
 	{"1.0", "1", 0},
 	{"1-sp", "1-ga", 1},
@@ -172,6 +197,10 @@ var mavenCompareTests = []compareTest{
 	{"a-1", "alpha-1", 1},
 	{"a.1", "alpha-1", 1},
 	{"aaaaaa", "alpha", 1},
+
+	// A leading separator is an element, even if it matches the padding.
+	{"", ".", -1},
+	{"", ".0", -1},
 }
 
 func TestMavenCompare(t *testing.T) {
@@ -287,3 +287,83 @@ func TestPyPIPostReleaseMatch(t *testing.T) {
 		"1.1.post1",
 	})
 }
+
+// Cases from the specifier tests of pypa/packaging.
+func TestPyPIArbitraryEquality(t *testing.T) {
+	tests := []struct {
+		con     string
+		version string
+		want    bool
+	}{
+		{"===foobar", "foobar", true},
+		{"===foobar", "FooBar", true},
+		{"=== foobar", "foobar", true},
+		{"===foobar", "foo", false},
+		{"===1.0", "1.0", true},
+		{"===1.0", "1.0.0", false},
+		{"===1.0", "1.0+downstream1", false},
+		{"===1.0+downstream1", "1.0+downstream1", true},
+		{"===1.0, >=0.5", "1.0", true},
+		{"===1.0, >=2.0", "1.0", false},
+		// Other clauses do not match strings that are not versions.
+		{"===foobar, >=1.0", "foobar", false},
+	}
+	for _, test := range tests {
+		c, err := PyPI.ParseConstraint(test.con)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q): %v", test.con, err)
+			continue
+		}
+		if got := c.Match(test.version); got != test.want {
+			t.Errorf("%q matching %q: got %t, want %t", test.con, test.version, got, test.want)
+		}
+	}
+	for _, con := range []string{"===", "=== ,==1.0"} {
+		if _, err := PyPI.ParseConstraint(con); err == nil {
+			t.Errorf("ParseConstraint(%q): got no error", con)
+		}
+	}
+}
+
+func TestPyPIEpochMatch(t *testing.T) {
+	testMatch(t, false, []matchTest{
+		{P, "", m("1.0 2.0 1!0.5 1!1.0 1!2.0 2!0.1")},
+		{P, ">=1.0", m("1.0 2.0 1!0.5 1!1.0 1!2.0 2!0.1")},
+		{P, ">1.0", m("2.0 1!0.5 1!1.0 1!2.0 2!0.1")},
+		{P, "<2.0", m("1.0")},
+		{P, "<=1!1.0", m("1.0 2.0 1!0.5 1!1.0")},
+		{P, ">=1!1.0", m("1!1.0 1!2.0 2!0.1")},
+		{P, ">1!0,<2!0", m("1!0.5 1!1.0 1!2.0")},
+		{P, "==1!1.0", m("1!1.0")},
+		{P, "==1!1.*", m("1!1.0")},
+		{P, "~=1!1.0", m("1!1.0")},
+		{P, "!=1!1.0", m("1.0 2.0 1!0.5 1!2.0 2!0.1")},
+		{P, "!=1.*", m("2.0 1!0.5 1!1.0 1!2.0 2!0.1")},
+	}, []string{
+		"1.0",
+		"2.0",
+		"1!0.5",
+		"1!1.0",
+		"1!2.0",
+		"2!0.1",
+	})
+}
+
+func TestPyPIWildcardExclusion(t *testing.T) {
+	testMatch(t, false, []matchTest{
+		{P, "!=1.0.*", m("0.9 1.1 1.1.post1 2.0")},
+		{P, "!=1.*", m("0.9 2.0")},
+		{P, "!=1.0.*,!=1.1.*", m("0.9 2.0")},
+		{P, "==1.*,!=1.0.*", m("1.1 1.1.post1")},
+		{P, ">=0.9,!=1.0.*,<2", m("0.9 1.1 1.1.post1")},
+		{P, "!=1!1.0.*", m("0.9 1.0 1.0.post1 1.0.1 1.1 1.1.post1 2.0")},
+	}, []string{
+		"0.9",
+		"1.0",
+		"1.0.post1",
+		"1.0.1",
+		"1.1",
+		"1.1.post1",
+		"2.0",
+	})
+}
@@ -0,0 +1,217 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RPM-specific support.
+
+// rpmExtension implements the RPM-specific parts of a Version,
+// [epoch:]version[-release], ordered as rpm's rpmvercmp does. See
+// https://rpm-software-management.github.io/rpm/manual/dependencies.html.
+// None of the numbers of the Version are set; everything is stored here.
+type rpmExtension struct {
+	version *Version
+	epoch   int    // -1 if absent.
+	ver     string // The version proper.
+	release string // Without the leading hyphen; empty if absent.
+}
+
+// newRPMExtension builds the extension object for an extant RPM Version.
+func newRPMExtension(v *Version, str string) (*rpmExtension, error) {
+	e := &rpmExtension{
+		version: v,
+	}
+	return e, e.init(str)
+}
+
+// rpmVersion parses an RPM Version. The str field is set by the caller;
+// all else is stored in the extension, which is constructed here and attached
+// to the returned Version.
+func (p *versionParser) rpmVersion() (*Version, error) {
+	var err error
+	p.Version.ext, err = p.Version.newExtension(p.Version.str)
+	return p.Version, err
+}
+
+func (r *rpmExtension) copy(v *Version) extension {
+	n := new(rpmExtension)
+	*n = *r
+	n.version = v
+	return n
+}
+
+func (r *rpmExtension) clearPre() {
+	// RPM has no prereleases; a tilde just sorts early.
+}
+
+func (r *rpmExtension) empty() bool {
+	return r == nil || r.ver == ""
+}
+
+// canon returns a canonicalized string representation of the version/extension.
+// A zero or missing epoch is omitted. The showBuild argument is ignored; RPM
+// doesn't have that concept.
+func (r *rpmExtension) canon(showBuild bool) string {
+	var b strings.Builder
+	if r.epoch > 0 {
+		fmt.Fprintf(&b, "%d:", r.epoch)
+	}
+	b.WriteString(r.ver)
+	if r.release != "" {
+		b.WriteByte('-')
+		b.WriteString(r.release)
+	}
+	return b.String()
+}
+
+var rpmMinVersion *Version
+
+func init() {
+	// As with Debian, a tilde sorts before anything, and no real version
+	// starts with one.
+	rpmMinVersion = &Version{
+		sys: RPM,
+		str: "~",
+	}
+	rpmMinVersion.ext = &rpmExtension{
+		version: rpmMinVersion,
+		epoch:   -1,
+		ver:     "~",
+	}
+}
+
+// init parses an RPM version string and stores its parts in the extension.
+func (r *rpmExtension) init(input string) error {
+	epoch, ver, release, err := splitEVR(input)
+	if err != nil {
+		return err
+	}
+	for _, c := range ver + release {
+		if !isAlphanumeric(c) && !strings.ContainsRune("._+~^", c) {
+			return fmt.Errorf("invalid character %q in `%s`", c, input)
+		}
+	}
+	r.epoch = epoch
+	r.ver = ver
+	r.release = release
+	return nil
+}
+
+// compare uses rpm's rules to decide the ordering of r and e. A missing
+// epoch is equivalent to an epoch of 0, but a missing release sorts before
+// any release, so 1.0 < 1.0-1. Note that this differs from rpm's matching of
+// dependencies, which ignores the release if the dependency has none.
+func (r *rpmExtension) compare(e extension) int {
+	s := e.(*rpmExtension)
+	if c := sgn(max(r.epoch, 0), max(s.epoch, 0)); c != 0 {
+		return c
+	}
+	if c := rpmCompare(r.ver, s.ver); c != 0 {
+		return c
+	}
+	return rpmCompare(r.release, s.release)
+}
+
+// rpmCompare compares two versions or releases as rpm's rpmvercmp does.
+// Each string is broken into maximal runs of digits or of letters, all
+// other characters serving as separators, and the runs are compared in
+// turn: numerically for digits, lexically for letters, with numbers sorting
+// after letters. A tilde sorts before anything, even the end of the string;
+// a caret sorts after the end of the string but before anything else.
+func rpmCompare(a, b string) int {
+	if a == b {
+		return 0
+	}
+	for a != "" || b != "" {
+		a = strings.TrimLeftFunc(a, rpmSeparator)
+		b = strings.TrimLeftFunc(b, rpmSeparator)
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			switch {
+			case a == "":
+				return -1
+			case b == "":
+				return 1
+			case a[0] != '^':
+				return 1
+			case b[0] != '^':
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if a == "" || b == "" {
+			break
+		}
+		class := isAlpha
+		if isDigit(rune(a[0])) {
+			class = isDigit
+		}
+		var x, y string
+		x, a = rpmSegment(a, class)
+		y, b = rpmSegment(b, class)
+		if y == "" {
+			// The segments are of different types.
+			if isDigit(rune(x[0])) {
+				return 1
+			}
+			return -1
+		}
+		if isDigit(rune(x[0])) {
+			x = strings.TrimLeft(x, "0")
+			y = strings.TrimLeft(y, "0")
+			if c := sgn(len(x), len(y)); c != 0 {
+				return c
+			}
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+// rpmSeparator reports whether r separates the segments of an RPM version.
+func rpmSeparator(r rune) bool {
+	return !isAlphanumeric(r) && r != '~' && r != '^'
+}
+
+// rpmSegment splits s after its longest prefix of runes of the given class.
+func rpmSegment(s string, class func(rune) bool) (string, string) {
+	i := strings.IndexFunc(s, func(r rune) bool { return !class(r) })
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
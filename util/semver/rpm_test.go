@@ -0,0 +1,150 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+// RPM-specific tests.
+
+import (
+	"strings"
+	"testing"
+)
+
+var rpmCanonTests = []canonTest{
+	{"1.0", "1.0", ""},
+	{"1.0-1.el8", "1.0-1.el8", ""},
+	{"0:1.0-1", "1.0-1", ""},
+	{"2:1.0-1", "2:1.0-1", ""},
+	{"1.0~rc1^git1-1", "1.0~rc1^git1-1", ""},
+	{"1.0_1-2.fc39", "1.0_1-2.fc39", ""},
+}
+
+func TestRPMCanon(t *testing.T) {
+	testVersionCanon(t, RPM, rpmCanonTests)
+}
+
+func TestRPMVersionError(t *testing.T) {
+	tests := []struct {
+		str string
+		err string
+	}{
+		{"", "empty version in ``"},
+		{"1:", "empty version in `1:`"},
+		{"x:1.0", "invalid epoch in `x:1.0`"},
+		{"1.0-", "empty revision in `1.0-`"},
+		{"1.0 ", "invalid character ' ' in `1.0 `"},
+		{"1.0/2", "invalid character '/' in `1.0/2`"},
+	}
+	for _, test := range tests {
+		_, err := RPM.Parse(test.str)
+		if err == nil {
+			t.Errorf("RPM.Parse(%q): no error", test.str)
+			continue
+		}
+		if err.Error() != test.err {
+			t.Errorf("RPM.Parse(%q) got error %#q; should have %q", test.str, err, test.err)
+		}
+	}
+}
+
+// Borrowed example tests from rpm's tests/rpmvercmp.at.
+var rpmCompareTests = []compareTest{
+	{"1.0", "1.0", 0},
+	{"1.0", "2.0", -1},
+	{"2.0.1", "2.0.1", 0},
+	{"2.0", "2.0.1", -1},
+	{"2.0.1a", "2.0.1a", 0},
+	{"2.0.1a", "2.0.1", 1},
+	{"5.5p1", "5.5p2", -1},
+	{"5.5p10", "5.5p1", 1},
+	{"10xyz", "10.1xyz", -1},
+	{"xyz10", "xyz10.1", -1},
+	{"xyz.4", "8", -1},
+	{"xyz.4", "2", -1},
+	{"5.5p2", "5.6p1", -1},
+	{"5.6p1", "6.5p1", -1},
+	{"6.0.rc1", "6.0", 1},
+	{"10b2", "10a1", 1},
+	{"1.0aa", "1.0a", 1},
+	{"10.0001", "10.1", 0},
+	{"10.0001", "10.0039", -1},
+	{"4.999.9", "5.0", -1},
+	{"20101121", "20101122", -1},
+	{"2_0", "2_0", 0},
+	{"2.0", "2_0", 0},
+	{"a", "a", 0},
+	{"a+", "a_", 0},
+	{"+", "_", 0},
+	{"1.0~rc1", "1.0~rc1", 0},
+	{"1.0~rc1", "1.0", -1},
+	{"1.0~rc1", "1.0~rc2", -1},
+	{"1.0~rc1~git123", "1.0~rc1", -1},
+	{"1.0^", "1.0^", 0},
+	{"1.0^", "1.0", 1},
+	{"1.0^git1", "1.0^git2", -1},
+	{"1.0^git1", "1.01", -1},
+	{"1.0^20160101", "1.0.1", -1},
+	{"1.0~rc1^git1", "1.0~rc1", 1},
+	{"1.0^git1~pre", "1.0^git1", -1},
+
+	// Epochs and releases.
+	{"0:1.0", "1.0", 0},
+	{"1:1.0", "2.0", 1},
+	{"1.0-1", "1.0-2", -1},
+	{"1.0-1.el8", "1.0-1.el8_3", -1},
+	{"1.0", "1.0-1", -1},
+}
+
+func TestRPMCompare(t *testing.T) {
+	testCompare(t, RPM, rpmCompareTests)
+}
+
+var rpmTestVersions = strings.Fields(`
+0.9-1
+1.0~rc1-1
+1.0-1
+1.0-2.el8
+1.0-2.el8_3
+1.0^git1-1
+1.0.1-1
+2.0-1
+1:0.5-1`)
+
+func TestRPMMatch(t *testing.T) {
+	tests := []struct {
+		con     string
+		matches string
+	}{
+		{"1.0-1", "1.0-1"},
+		{"= 1.0-2.el8", "1.0-2.el8"},
+		// Without a release, the version sorts before all its releases.
+		{"= 1.0", ""},
+		{"< 1.0", "0.9-1 1.0~rc1-1"},
+		{"<= 1.0-1", "0.9-1 1.0~rc1-1 1.0-1"},
+		{"> 1.0-2.el8", "1.0-2.el8_3 1.0^git1-1 1.0.1-1 2.0-1 1:0.5-1"},
+		{">= 1.0.1", "1.0.1-1 2.0-1 1:0.5-1"},
+		{">= 1.0, < 1.0.1", "1.0-1 1.0-2.el8 1.0-2.el8_3 1.0^git1-1"},
+		{"< 1.0 || >= 2.0", "0.9-1 1.0~rc1-1 2.0-1 1:0.5-1"},
+		{">= 1:0", "1:0.5-1"},
+	}
+	for _, test := range tests {
+		c := parseConstraint(t, RPM, test.con)
+		want := m(test.matches)
+		for _, vs := range rpmTestVersions {
+			if got := c.Match(vs); got != want[vs] {
+				t.Errorf("RPM %q.Match(%q) = %t; want %t", test.con, vs, got, want[vs])
+			}
+		}
+	}
+}
@@ -102,6 +102,8 @@ func (g *gemExtension) canon(showBuild bool) string {
 	return b.String()
 }
 
+// rubyGemsMinVersion is 0.0.0-a, that is 0.0.0.pre.a, so that it reads
+// back from its canonical form.
 var rubyGemsMinVersion *Version
 
 func init() {
@@ -109,12 +111,16 @@ func init() {
 		sys:          RubyGems,
 		userNumCount: 3,
 		isPrerelease: false,
-		str:          "0.0.0.a0",
+		str:          "0.0.0-a",
 	}
 	rubyGemsMinVersion.num = rubyGemsMinVersion.buf[:3]
 	rubyGemsMinVersion.ext = &gemExtension{
 		version: rubyGemsMinVersion,
 		elems: []gemElement{
+			{
+				str: "pre",
+				int: 0,
+			},
 			{
 				str: "a",
 				int: 0,
@@ -164,11 +170,9 @@ func (g *gemExtension) init(input string) error {
 		}
 		elements = append(elements, gemElement{str: str})
 	}
-	// Trim trailing zeros.
-	for i := len(elements) - 1; i >= 0; i-- {
-		if elements[i].str == "0" {
-			elements = elements[:i]
-		}
+	// Trim trailing zeros, however they are spelled.
+	for len(elements) > 0 && strings.Trim(elements[len(elements)-1].str, "0") == "" {
+		elements = elements[:len(elements)-1]
 	}
 	// Integers for numbers.
 	for i, e := range elements {
@@ -253,7 +257,11 @@ func (g *gemExtension) compare(e extension) int {
 			return -1
 		}
 		if ac == versionNumeric {
-			return sgn64(a.int, b.int)
+			// Equal values may be spelled differently, as in 01 and 1.
+			if c := sgn64(a.int, b.int); c != 0 {
+				return c
+			}
+			continue
 		}
 		c := strings.Compare(a.str, b.str)
 		if c == 0 {
@@ -261,8 +269,5 @@ func (g *gemExtension) compare(e extension) int {
 		}
 		return c
 	}
-	if len(bs) > len(as) {
-		return -1
-	}
-	return 0
+	return sgn(len(as), len(bs))
 }
@@ -156,6 +156,17 @@ var rubyGemsCompareTests = []compareTest{
 	{"0.0.beta", "0.beta.1", -1},
 	{"5.a", "5.0.0.rc2", -1},
 	{"5.x", "5.0.0.rc2", 1},
+	{"1.9.3", "1.9.2.99", 1},
+	{"1.9.3", "1.9.3.1", -1},
+	{"1.2.b1", "1.2.b.1", 0},
+
+	// test_semver.
+	{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+	{"1.0.0-alpha.1", "1.0.0-beta.2", -1},
+	{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+	{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+	{"1.0.0-rc1", "1.0.0", -1},
+	{"1.0.0-1", "1", -1},
 
 	// Tests added by this package.
 	{"1.2.3", "1.2.3.a", 1},
@@ -179,6 +190,19 @@ var rubyGemsCompareTests = []compareTest{
 	// A minus becomes an element ".pre."; very subtle consequences.
 	{"1.0.0-alpha.5", "1.0.0.alpha.10", 1},
 
+	// Strings compare lexically, so b sorts before beta; only the
+	// boundaries between letters and digits matter.
+	{"1.0.0.beta.2", "1.0.0.b2", 1},
+	{"1.0.0.beta.2", "1.0.0.beta2", 0},
+	{"1.0.0.b.2", "1.0.0.b2", 0},
+
+	// Only trailing zeros are dropped; a missing element is a zero,
+	// which dominates a string.
+	{"1.0.a.0.b", "1.0.a", -1},
+	{"1.0.a.0", "1.a", 0},
+	{"1.0.a.01.b", "1.0.a.1.c", -1},
+	{"1.0.a.00", "1.0.a", 0},
+
 	// Many numbers.
 	{"6.5.4.3.2", "6.5.4.3", 1},
 	{"6.5.4.3.2", "6.5.4.3.1", 1},
@@ -290,3 +314,139 @@ var rubyGemsTestVersions = strings.Fields(`
 2.3.4.5.6
 2.3.4.5.6-pre
 2.3.4.5.7`)
+
+// rubyGemsRequirementTests checks conformance with Gem::Requirement. The
+// first cases are borrowed from
+// https://github.com/rubygems/rubygems/blob/3.4/test/rubygems/test_gem_requirement.rb,
+// leaving out those with blank or padded versions, which this package does
+// not parse.
+var rubyGemsRequirementTests = []struct {
+	constraint string
+	version    string
+	want       bool
+}{
+	// test_satisfied_by_eh_bang_equal and friends.
+	{"!= 1.2", "1.1", true},
+	{"!= 1.2", "1.2", false},
+	{"!= 1.2", "1.3", true},
+	{"1.2", "1.1", false},
+	{"1.2", "1.2", true},
+	{"1.2", "1.3", false},
+	{"= 1.2", "1.1", false},
+	{"= 1.2", "1.2", true},
+	{"= 1.2", "1.3", false},
+	{"> 1.2", "1.1", false},
+	{"> 1.2", "1.2", false},
+	{"> 1.2", "1.3", true},
+	{">= 1.2", "1.1", false},
+	{">= 1.2", "1.2", true},
+	{">= 1.2", "1.3", true},
+	{"> 1.1, < 1.3", "1.1", false},
+	{"> 1.1, < 1.3", "1.2", true},
+	{"> 1.1, < 1.3", "1.3", false},
+	{"< 1.2", "1.1", true},
+	{"< 1.2", "1.2", false},
+	{"< 1.2", "1.3", false},
+	{"<= 1.2", "1.1", true},
+	{"<= 1.2", "1.2", true},
+	{"<= 1.2", "1.3", false},
+	{"~> 1.2", "1.1", false},
+	{"~> 1.2", "1.2", true},
+	{"~> 1.2", "1.3", true},
+	{"~> 0.0.1", "0.1.1", false},
+	{"~> 0.0.1", "0.0.2", true},
+	{"~> 0.0.1", "0.0.1", true},
+
+	// test_satisfied_by_eh_good.
+	{"= 0.2.33", "0.2.33", true},
+	{"> 0.2.33", "0.2.34", true},
+	{"= 1.0", "1.0", true},
+	{"= 1.0", "1.0.0", true},
+	{"= 1.0.0", "1.0", true},
+	{"1.0", "1.0", true},
+	{"> 1.8.0", "1.8.2", true},
+	{"> 1.111", "1.112", true},
+	{"> 0.0.0", "0.2", true},
+	{"> 0.0.0", "0.0.0.0.0.2", true},
+	{"> 0.0.0.1", "0.0.1.0", true},
+	{"> 9.3.2", "10.3.2", true},
+	{"= 1.0", "1.0.0.0", true},
+	{"!= 9.3.4", "10.3.2", true},
+	{"= 0", "0", true},
+	{"~> 1.3", "1.4", true},
+	{"~> 1.4.4", "1.4.4", true},
+	{"~> 1.4.4", "1.4.5", true},
+
+	// test_satisfied_by_eh_bad.
+	{"> 0.2.33", "0.2.32", false},
+	{"> 1.0", "1.0", false},
+	{"!= 1.2.3", "1.2.3", false},
+	{"!= 1.02.3", "1.2.003.0.0", false},
+	{"< 1.2.3", "4.5.6", false},
+	{"> 1.1", "1.0", false},
+	{"> 1.1.1", "1.1.1", false},
+	{"= 1.1", "1.2", false},
+	{"= 1.1", "1.40", false},
+	{"= 1.40", "1.3", false},
+	{"<= 9.3.2", "9.3.3", false},
+	{">= 9.3.2", "9.3.1", false},
+	{"<= 9.3.2", "9.3.03", false},
+	{"= 1.0", "1.0.0.1", false},
+	{"~> 1.4.4", "1.4", false},
+	{"~> 1.4.4", "1.5", false},
+
+	// test_satisfied_by_explicitly_bounded: an upper bound admits the
+	// prereleases of its release unless it is itself a prerelease.
+	{">= 1.4.4, < 1.5", "1.4.5", true},
+	{">= 1.4.4, < 1.5", "1.5.0.rc1", true},
+	{">= 1.4.4, < 1.5", "1.5.0", false},
+	{">= 1.4.4, < 1.5.a", "1.4.5", true},
+	{">= 1.4.4, < 1.5.a", "1.5.0.rc1", false},
+	{">= 1.4.4, < 1.5.a", "1.5.0", false},
+
+	// Tests added by this package, following Gem::Version#bump and
+	// #release: ~> v matches versions from v whose release, without any
+	// prerelease, is below v.bump.
+	{"~> 1.4.4", "1.5.0.rc1", false},
+	{"~> 1.4.4", "1.4.5.a", true},
+	{"~> 1", "1.9", true},
+	{"~> 1", "2.0.a", false},
+	{"~> 1.0", "1.0.0.0.1", true},
+	{"~> 1.2.3.4", "1.2.3.3", false},
+	{"~> 1.2.3.4", "1.2.3.9", true},
+	{"~> 1.2.3.4", "1.2.3.99.1", true},
+	{"~> 1.2.3.4", "1.2.4.a", false},
+	{"~> 1.2.3.4", "1.2.4", false},
+	{"~> 1.2.3.0", "1.2.3.5", true},
+	{"~> 1.2.3.0", "1.2.4", false},
+	{"~> 1.2.3.4.5", "1.2.3.4.4", false},
+	{"~> 1.2.3.4.5", "1.2.3.4.9", true},
+	{"~> 1.2.3.4.5", "1.2.3.5", false},
+	{"~> 1.0.0.beta.2", "1.0.0.b2", false},
+	{"~> 1.0.0.beta.2", "1.0.0.beta.2", true},
+	{"~> 1.0.0.beta.2", "1.0.0.beta.3", true},
+	{"~> 1.0.0.beta.2", "1.0.0", true},
+	{"~> 1.0.0.beta.2", "1.0.5", true},
+	{"~> 1.0.0.beta.2", "1.1.0.a", false},
+	{"~> 1.2.a", "1.2.3.a.4", true},
+	{"~> 1.2.a", "1.9", true},
+	{"~> 1.2.a", "2.0.a", false},
+	{"~> 1.9.a", "1.9.b", true},
+	{"~> 1.9.a", "2.0.0.a", false},
+	{">= 1.0.0.beta.2", "1.0.0.beta.10", true},
+	{">= 1.0.0.beta.2", "1.0.0.b3", false},
+	{"> 1.0.a.0.b", "1.0.a", true},
+}
+
+func TestRubyGemsRequirement(t *testing.T) {
+	for _, test := range rubyGemsRequirementTests {
+		c, err := RubyGems.ParseConstraint(test.constraint)
+		if err != nil {
+			t.Errorf("%q: %v", test.constraint, err)
+			continue
+		}
+		if got := c.Match(test.version); got != test.want {
+			t.Errorf("%q matching %q: got %t, want %t", test.constraint, test.version, got, test.want)
+		}
+	}
+}
@@ -123,7 +123,13 @@ func canon(s []span) ([]span, error) {
 		for j := i + 1; j < len(s); j++ {
 			next := s[j]
 			if !this.max.equal(next.min) { // If equal, we can merge unless both are open (handled below)
-				if len(this.max.pre) == 0 {
+				if sys := this.max.sys; sys == Debian || sys == RPM || sys == Composer {
+					// There is no next version to step to; any two
+					// distinct versions have others between them.
+					if this.max.lessThan(next.min) {
+						break
+					}
+				} else if len(this.max.pre) == 0 {
 					maxPlusOne := this.max.copy()
 					err := maxPlusOne.inc()
 					if err != nil {
@@ -191,53 +197,123 @@ func (s *Set) Union(t Set) error {
 
 // Intersect replaces the receiver with the set intersection of the receiver and the argument.
 func (s *Set) Intersect(t Set) error {
-	out := []span{} // TODO: Avoid allocation?
-	for _, selem := range s.span {
-		if selem.rank == empty { // Shouldn't happen.
-			continue
+	// Walk both lists in min order, as in a merge, so the cost is linear
+	// in the number of spans rather than quadratic.
+	ss, ts := sortedSpans(s.span), sortedSpans(t.span)
+	out := []span{}
+	for i, j := 0, 0; i < len(ss) && j < len(ts); {
+		selem, telem := ss[i], ts[j]
+		// Choose the larger min and the lesser max.
+		min, max := selem.min, selem.max
+		minOpen, maxOpen := selem.minOpen, selem.maxOpen
+		if telem.min.greaterThan(selem.min) || (telem.min.equal(selem.min) && telem.minOpen) {
+			min = telem.min
+			minOpen = telem.minOpen
 		}
-		// Intersect selem with each member of t. Since they're
-		// canonical, so sorted in Min order, we can stop once there's
-		// no chance of overlap.
-		for _, telem := range t.span {
-			if telem.rank == empty { // Shouldn't happen.
-				continue
-			}
-			// TODO: Avoid the n^2 here.
-			if telem.max.lessThan(selem.min) || (telem.max.equal(selem.min) && telem.maxOpen) {
-				continue // Not there yet.
-			}
-			if telem.min.greaterThan(selem.max) {
-				// No need to check further.
-				break
-			}
-			// We know they overlap. Choose the larger min and the lesser max.
-			min, max := selem.min, selem.max
-			minOpen, maxOpen := selem.minOpen, selem.maxOpen
-			if telem.min.greaterThan(selem.min) || (telem.min.equal(selem.min) && telem.minOpen) {
-				min = telem.min
-				minOpen = telem.minOpen
-			}
-			if telem.max.lessThan(selem.max) || (telem.max.equal(selem.max) && telem.maxOpen) {
-				max = telem.max
-				maxOpen = telem.maxOpen
-			}
-			span, err := newSpan(min, minOpen, max, maxOpen)
+		if telem.max.lessThan(selem.max) || (telem.max.equal(selem.max) && telem.maxOpen) {
+			max = telem.max
+			maxOpen = telem.maxOpen
+		}
+		// Whichever element ends first can't overlap anything further
+		// along the other list.
+		if max == selem.max && maxOpen == selem.maxOpen {
+			i++
+		} else {
+			j++
+		}
+		if max.lessThan(min) || (max.equal(min) && (minOpen || maxOpen)) {
+			continue // No overlap.
+		}
+		span, err := newSpan(min.copy(), minOpen, max.copy(), maxOpen)
+		if err != nil {
+			return err
+		}
+		out = append(out, span)
+	}
+	if len(out) == 0 {
+		// An empty list means everything, so we need an explicitly empty span.
+		out = []span{{rank: empty}}
+	}
+	var err error
+	s.span, err = canon(out)
+	return err
+}
+
+// Complement replaces the receiver with the set of versions it does not
+// contain, from the lowest version of the system to infinity.
+func (s *Set) Complement() error {
+	if len(s.span) == 0 {
+		// An empty list means everything.
+		s.span = []span{{rank: empty}}
+		return nil
+	}
+	// lo is the lower bound of the next gap; loOpen reports whether lo
+	// itself is covered by the spans already visited.
+	lo, loOpen := s.sys.MinVersion(&Version{sys: s.sys}), closed
+	out := []span{}
+	for _, elem := range sortedSpans(s.span) {
+		// The gap before elem ends at its min, which is in the gap only
+		// if elem excludes it.
+		if lo.lessThan(elem.min) || (lo.equal(elem.min) && !loOpen && elem.minOpen) {
+			gap, err := newSpan(lo.copy(), loOpen, elem.min.copy(), !elem.minOpen)
 			if err != nil {
 				return err
 			}
-			out = append(out, span)
+			out = append(out, gap)
+		}
+		if elem.max.greaterThan(lo) || (elem.max.equal(lo) && !elem.maxOpen) {
+			lo, loOpen = elem.max, !elem.maxOpen
+		}
+	}
+	inf, err := s.sys.parse("∞.∞.∞", true)
+	if err != nil {
+		return err
+	}
+	if lo.lessThan(inf) || (lo.equal(inf) && !loOpen) {
+		gap, err := newSpan(lo.copy(), loOpen, inf, closed)
+		if err != nil {
+			return err
 		}
+		out = append(out, gap)
 	}
 	if len(out) == 0 {
-		// An empty list means everything, so we need an explicitly empty span.
 		out = []span{{rank: empty}}
 	}
-	var err error
 	s.span, err = canon(out)
 	return err
 }
 
+// Difference replaces the receiver with the set of versions it contains that
+// are not in the argument.
+func (s *Set) Difference(t Set) error {
+	if err := t.Complement(); err != nil {
+		return err
+	}
+	return s.Intersect(t)
+}
+
+// sortedSpans returns the non-empty spans of the list in increasing min
+// order. Canonical lists are already in that order, but Maven ones are not
+// canonicalized.
+func sortedSpans(list []span) []span {
+	out := make([]span, 0, len(list))
+	for _, elem := range list {
+		if elem.rank != empty {
+			out = append(out, elem)
+		}
+	}
+	less := func(i, j int) bool {
+		if c := out[i].min.Compare(out[j].min); c != 0 {
+			return c < 0
+		}
+		return !out[i].minOpen && out[j].minOpen
+	}
+	if !sort.SliceIsSorted(out, less) {
+		sort.SliceStable(out, less)
+	}
+	return out
+}
+
 // Match reports whether the version defined by the argument string is contained
 // in the set.
 func (s Set) Match(version string) (bool, error) {
@@ -254,8 +330,9 @@ func (s Set) matchVersion(v *Version, includePrerelease bool) bool {
 		return len(v.pre) == 0
 	}
 
-	// For RubyGems, prereleases are just another element.
-	if v.sys == RubyGems {
+	// For RubyGems, prereleases are just another element. Composer
+	// leaves them to the minimum stability of the project.
+	if v.sys == RubyGems || v.sys == Composer {
 		includePrerelease = true
 	}
 
@@ -189,6 +189,12 @@ func TestIntersect(t *testing.T) {
 		// Overlapping.
 		{strs(">=1.0"), strs("<3.2"), "{[1.0.0:3.2.0)}"},
 
+		// Multiple spans.
+		{strs("[1.0.0:2.0.0]", "[3.0.0:4.0.0]"), strs("[1.5.0:3.5.0]"), "{[1.5.0:2.0.0],[3.0.0:3.5.0]}"},
+		{strs("[1.0.0:2.0.0)", "[3.0.0:4.0.0)"), strs("[2.0.0:3.0.0]"), "{3.0.0}"},
+		{strs("1", "3", "5"), strs("[1.5.0:5.5.0]", "7"), "{[1.5.0:1.∞.∞],[3.0.0:3.∞.∞],[5.0.0:5.5.0]}"},
+		{strs("[3.0.0:4.0.0]", "[1.0.0:2.0.0]"), strs("[1.5.0:3.5.0]"), "{[1.5.0:2.0.0],[3.0.0:3.5.0]}"},
+
 		// Symmetry, open/closed.
 		{strs("(1.0.0:2.0.0]"), strs("[1.0.0:2.0.0]"), "{(1.0.0:2.0.0]}"},
 		{strs("[1.0.0:2.0.0]"), strs("(1.0.0:2.0.0]"), "{(1.0.0:2.0.0]}"},
@@ -211,3 +217,80 @@ func TestIntersect(t *testing.T) {
 		}
 	}
 }
+
+func TestComplement(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"{<empty>}", "{[0.0.0-0:∞.∞.∞]}"},
+		{"{[0.0.0-0:∞.∞.∞]}", "{<empty>}"},
+		{"{1.2.3}", "{[0.0.0-0:1.2.3),(1.2.3:∞.∞.∞]}"},
+		{"{[1.0.0:1.∞.∞]}", "{[0.0.0-0:1.0.0),(1.∞.∞:∞.∞.∞]}"},
+		{"{[0.0.0-0:1.2.3)}", "{[1.2.3:∞.∞.∞]}"},
+		{"{[0.0.0-0:1.2.3]}", "{(1.2.3:∞.∞.∞]}"},
+		{"{[1.2.3:∞.∞.∞]}", "{[0.0.0-0:1.2.3)}"},
+		{"{(1.2.3:∞.∞.∞]}", "{[0.0.0-0:1.2.3]}"},
+		{"{(0.0.0-0:∞.∞.∞)}", "{0.0.0-0,∞.∞.∞}"},
+
+		// Open/closed touching spans leave a single version.
+		{"{[1.0.0:2.0.0),(2.0.0:3.0.0]}", "{[0.0.0-0:1.0.0),2.0.0,(3.0.0:∞.∞.∞]}"},
+		{"{[1.0.0:2.0.0),[2.0.0:3.0.0]}", "{[0.0.0-0:1.0.0),(3.0.0:∞.∞.∞]}"},
+
+		// Overlapping and contained spans.
+		{"{[1.0.0:2.0.0],[1.5.0:3.0.0)}", "{[0.0.0-0:1.0.0),[3.0.0:∞.∞.∞]}"},
+		{"{[1.0.0:4.0.0],[2.0.0:3.0.0]}", "{[0.0.0-0:1.0.0),(4.0.0:∞.∞.∞]}"},
+	}
+	for _, test := range tests {
+		c, err := DefaultSystem.ParseSetConstraint(test.in)
+		if err != nil {
+			t.Fatalf("ParseSetConstraint(%q): %v", test.in, err)
+		}
+		set := c.set
+		if err := set.Complement(); err != nil {
+			t.Errorf("Complement(%s): %v", test.in, err)
+			continue
+		}
+		if out := set.String(); out != test.out {
+			t.Errorf("Complement(%s) = %s; want %s", test.in, out, test.out)
+		}
+		// The complement of the complement is the original set, in
+		// canonical form.
+		if err := set.Complement(); err != nil {
+			t.Errorf("Complement(Complement(%s)): %v", test.in, err)
+			continue
+		}
+		want := Set{DefaultSystem, nil}
+		if want.span, err = canon(c.set.span); err != nil {
+			t.Fatal(err)
+		}
+		if !set.equal(want) {
+			t.Errorf("Complement(Complement(%s)) = %s; want %s", test.in, set, want)
+		}
+	}
+}
+
+func TestSetDifference(t *testing.T) {
+	tests := []struct {
+		s1, s2 []string
+		out    string
+	}{
+		{strs("[1.0.0:2.0.0]"), strs("[1.5.0:3.0.0]"), "{[1.0.0:1.5.0)}"},
+		{strs("[1.0.0:2.0.0]"), strs("[0.5.0:3.0.0]"), "{<empty>}"},
+		{strs("[1.0.0:2.0.0]"), strs("[3.0.0:4.0.0]"), "{[1.0.0:2.0.0]}"},
+		{strs("[1.0.0:3.0.0]"), strs("2.0.0"), "{[1.0.0:2.0.0),(2.0.0:3.0.0]}"},
+		{strs("[1.0.0:3.0.0]"), strs("(1.0.0:3.0.0)"), "{1.0.0,3.0.0}"},
+		{strs(">=1.0.0"), strs(">=2.0.0"), "{[1.0.0:2.0.0)}"},
+		{strs("1", "2"), strs("1.5"), "{[1.0.0:1.5.0),(1.5.∞:2.∞.∞]}"},
+	}
+	for _, test := range tests {
+		set1 := Set{DefaultSystem, spans(t, test.s1...)}
+		set2 := Set{DefaultSystem, spans(t, test.s2...)}
+		if err := set1.Difference(set2); err != nil {
+			t.Errorf("Difference(%q, %q): %v", test.s1, test.s2, err)
+			continue
+		}
+		if out := set1.String(); out != test.out {
+			t.Errorf("Difference(%q, %q) = %s; want %s", test.s1, test.s2, out, test.out)
+		}
+	}
+}
@@ -17,11 +17,13 @@ func _() {
 	_ = x[PyPI-6]
 	_ = x[RubyGems-7]
 	_ = x[Composer-8]
+	_ = x[Debian-9]
+	_ = x[RPM-10]
 }
 
-const _System_name = "DefaultSystemCargoGoMavenNPMNuGetPyPIRubyGemsComposer"
+const _System_name = "DefaultSystemCargoGoMavenNPMNuGetPyPIRubyGemsComposerDebianRPM"
 
-var _System_index = [...]uint8{0, 13, 18, 20, 25, 28, 33, 37, 45, 53}
+var _System_index = [...]uint8{0, 13, 18, 20, 25, 28, 33, 37, 45, 53, 59, 62}
 
 func (i System) String() string {
 	if i >= System(len(_System_index)-1) {
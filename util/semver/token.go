@@ -48,6 +48,7 @@ const (
 	tokCaret
 	tokTilde
 	tokBacon
+	tokArbitrary // PyPI only.
 	tokComma
 	tokOr
 	tokHyphen
@@ -135,25 +136,51 @@ var operators = []map[string]tokType{
 	},
 
 	PyPI: {
-		"==": tokEqual,
+		"==":  tokEqual,
+		"===": tokArbitrary,
+		">":   tokGreater,
+		">=":  tokGreaterEqual,
+		"<":   tokLess,
+		"<=":  tokLessEqual,
+		"!=":  tokNotEqual,
+		"~=":  tokBacon,
+		",":   tokComma,
+	},
+
+	RubyGems: {
+		"=":  tokEqual,
 		">":  tokGreater,
 		">=": tokGreaterEqual,
 		"<":  tokLess,
 		"<=": tokLessEqual,
 		"!=": tokNotEqual,
-		"~=": tokBacon,
+		"~>": tokBacon,
 		",":  tokComma,
 	},
 
-	RubyGems: {
+	// Debian's << and >> are strict; the obsolete < and > are not, as
+	// in dpkg.
+	Debian: {
+		"=":  tokEqual,
+		">>": tokGreater,
+		">=": tokGreaterEqual,
+		">":  tokGreaterEqual,
+		"<<": tokLess,
+		"<=": tokLessEqual,
+		"<":  tokLessEqual,
+		",":  tokComma,
+		"|":  tokOr,
+		"||": tokOr,
+	},
+
+	RPM: {
 		"=":  tokEqual,
 		">":  tokGreater,
 		">=": tokGreaterEqual,
 		"<":  tokLess,
 		"<=": tokLessEqual,
-		"!=": tokNotEqual,
-		"~>": tokBacon,
 		",":  tokComma,
+		"||": tokOr,
 	},
 }
 
@@ -166,6 +193,14 @@ func (sys System) typeOf(r rune) uint8 {
 	if r == '+' && sys == RubyGems {
 		return tXX
 	}
+	// Epochs and tildes are part of Debian and RPM versions, and RPM
+	// versions may also contain underscores and carets.
+	if (r == ':' || r == '~') && (sys == Debian || sys == RPM) {
+		return tVS
+	}
+	if (r == '_' || r == '^') && sys == RPM {
+		return tVS
+	}
 	if r >= 0x7F {
 		return tXX
 	}
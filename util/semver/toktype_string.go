@@ -20,19 +20,20 @@ func _() {
 	_ = x[tokCaret-9]
 	_ = x[tokTilde-10]
 	_ = x[tokBacon-11]
-	_ = x[tokComma-12]
-	_ = x[tokOr-13]
-	_ = x[tokHyphen-14]
-	_ = x[tokLbracket-15]
-	_ = x[tokRbracket-16]
-	_ = x[tokVersion-17]
-	_ = x[tokWildcard-18]
-	_ = x[tokEOF-19]
+	_ = x[tokArbitrary-12]
+	_ = x[tokComma-13]
+	_ = x[tokOr-14]
+	_ = x[tokHyphen-15]
+	_ = x[tokLbracket-16]
+	_ = x[tokRbracket-17]
+	_ = x[tokVersion-18]
+	_ = x[tokWildcard-19]
+	_ = x[tokEOF-20]
 }
 
-const _tokType_name = "InvalidInternalErrorEmptyEqualGreaterGreaterEqualLessLessEqualNotEqualCaretTildeBaconCommaOrHyphenLbracketRbracketVersionWildcardEOF"
+const _tokType_name = "InvalidInternalErrorEmptyEqualGreaterGreaterEqualLessLessEqualNotEqualCaretTildeBaconArbitraryCommaOrHyphenLbracketRbracketVersionWildcardEOF"
 
-var _tokType_index = [...]uint8{0, 7, 20, 25, 30, 37, 49, 53, 62, 70, 75, 80, 85, 90, 92, 98, 106, 114, 121, 129, 132}
+var _tokType_index = [...]uint8{0, 7, 20, 25, 30, 37, 49, 53, 62, 70, 75, 80, 85, 94, 99, 101, 107, 115, 123, 130, 138, 141}
 
 func (i tokType) String() string {
 	if i < 0 || i >= tokType(len(_tokType_index)-1) {
@@ -68,6 +68,25 @@ Variants:
 		There may be more than 3 numbers.
 		A prerelease tag may be separated by a period rather than a
 		than a hyphen. Such a prerelease tag must not be numeric.
+	Debian
+		Versions have the form [epoch:]upstream_version[-debian_revision]
+		and are ordered as dpkg does, a tilde sorting before anything,
+		even the end of the version. There are no prereleases or
+		wildcards.
+	RPM
+		Versions have the form [epoch:]version[-release] and are ordered
+		as rpm does, a tilde sorting before anything and a caret after
+		the end of the version but before anything else. A version
+		without a release sorts before the same version with any release.
+	Composer
+		A version string may begin with one 'v' or 'V' character, and
+		there may be up to four numbers, which may have leading zeros.
+		The numbers may be followed by a stability modifier such as
+		-beta2, -RC1, -patch1 or -dev, and versions are ordered as
+		Composer orders them: dev < alpha < beta < RC < release < patch.
+		A branch alias such as 1.0.x-dev sorts after the releases it
+		covers. A branch version such as dev-main has no numbers and
+		sorts before all numbered versions.
 
 The constraint grammar is derived from documentation, examples, and
 examination of public usage. (There is no standard uniform constraint
@@ -93,7 +112,7 @@ which non-default system supports each binary operator.
 	constraint = orList
 
 	orList = andList
-		| orList '||' andList // NPM only.
+		| orList '||' andList // NPM, Debian and RPM only.
 
 	andList = value
 		| andList value
@@ -106,6 +125,7 @@ which non-default system supports each binary operator.
 
 	unop = '='
 		| '==' // PyPI only; other systems use '='.
+		| '===' // PyPI only; arbitrary equality, comparing strings.
 		| '>'
 		| '>='
 		| '<'
@@ -129,10 +149,23 @@ Operators supported:
 	Go
 		None.
 	Python
-		== > >= < <= ~= ~=
-		In Python, ~= is the same as RubyGems ~>.
+		== === != > >= < <= ~=
+		In Python, ~= is the same as RubyGems ~>. The operand of ===
+		need not be a version; it matches a version string equal to it,
+		ignoring case.
 	RubyGems
 		= != > >= < <= ~>
+		As in Gem::Requirement, ~>1.2.3.4 means >=1.2.3.4 and a release
+		below 1.2.4, which excludes the prereleases of 1.2.4, and
+		~>1.0.0.beta means >=1.0.0.beta and a release below 1.1.
+	Debian
+		= >> >= << <=
+		The obsolete > and < are accepted and mean >= and <=, as in dpkg.
+		Alternatives may be or-ed with | as well as ||.
+	RPM
+		= > >= < <=
+	Composer
+		= == != <> > >= < <= ^ ~
 
 Other variants:
 
@@ -145,17 +178,36 @@ Other variants:
 		its API print lists of constraints separated by commas, so we
 		accept them here.
 	Maven
-		TODO: The implementation supports Maven version 2 only.
+		The implementation follows Maven 3.9.
 		Maven uses a grammar with open and closed ranges and
 		comma-separated or-ed together range lists:
 			[2.0,2.1),[3.0.0,3.4.0]
 		means (2.0 <= v && v < 2.1) || (3.0.0 <= v && v <= 3.4.0).
 		Versions can be omitted, so (,2.0) means v <= 2.0 and
-		(,) means everything. Version syntax is standard, without
-		wildcards.
+		(,) means everything. As in Maven, the ranges of a list must
+		be in increasing order and must not overlap, and the bounds
+		of a range must be ordered. Version syntax is standard, without
+		wildcards, and versions are ordered as specified at
+		https://maven.apache.org/pom.html#Version_Order_Specification.
 	NuGet
 		NuGet uses a set grammar with the same syntax as Maven.
 		Version syntax permits * as a wildcard.
+	Composer
+		The implementation follows Composer's VersionParser. Items are
+		and-ed by commas or spaces and or-ed by | or ||, and a
+		hyphenated range may be and-ed with other items. Ranges include
+		the prereleases of their bounds, as Composer appends -dev to
+		them: ^1.2 means >=1.2.0.0-dev <2.0.0.0-dev, 1.2.* means
+		>=1.2.0.0-dev <1.3.0.0-dev, and >=1.2 means >=1.2.0.0-dev.
+		A partial upper bound of a hyphenated range is a wildcard, so
+		1.0 - 2 means >=1.0.0.0-dev <3.0.0.0-dev. An item may carry a
+		stability flag, as in ^1.2@beta, which relaxes the bounds of
+		comparisons and is reported by Constraint.Stability, an alias,
+		as in "1.0.x-dev as 1.0.0", and, for branches, a reference, as
+		in dev-main#abc123; aliases and references do not affect
+		matching. Branches match only themselves, * and !=. Prereleases
+		are not filtered when matching; that is the job of Composer's
+		minimum stability.
 */
 package semver
 
@@ -187,13 +239,15 @@ const (
 	PyPI
 	RubyGems
 	Composer
+	Debian
+	RPM
 )
 
 // supportsAnd reports whether the system supports space or comma as an
 // AND operator in its constraint grammar.
 func (sys System) supportsAnd() bool {
 	switch sys {
-	case DefaultSystem, NPM, PyPI, RubyGems:
+	case DefaultSystem, NPM, PyPI, RubyGems, Debian, RPM:
 		return true
 	default:
 		return false
@@ -210,7 +264,7 @@ type Version struct {
 	num          []value   // Dot-separated numerical components.
 	pre          []string  // Must be kept as individual elements for comparison, stripped of leading '-'.
 	build        string    // Build tags; concatenated for efficiency (unlike with pre); the '+' is present.
-	ext          extension // Only set for some Systems (Maven, RubyGems).
+	ext          extension // Only set for some Systems (Maven, RubyGems, Debian, RPM).
 }
 
 type extension interface {
@@ -264,13 +318,30 @@ func (sys System) Parse(str string) (*Version, error) {
 	return sys.parse(str, false)
 }
 
+// ParseInto is like Parse, but stores the version in v, replacing its
+// contents, rather than allocating a new one. Versions made only of numbers
+// in the systems without extensions, such as NPM, are parsed without
+// allocating, so that a slice of Versions can hold many versions parsed in
+// bulk, as when sorting. The contents of v are unspecified after an error.
+func (sys System) ParseInto(v *Version, str string) error {
+	if !sys.possibleVersionString(str) {
+		return fmt.Errorf("invalid version %#q", str)
+	}
+	_, err := sys.parseInto(v, str, false)
+	return err
+}
+
 func (sys System) parse(str string, allowInfinity bool) (*Version, error) {
-	version := &Version{
+	return sys.parseInto(&Version{}, str, allowInfinity)
+}
+
+func (sys System) parseInto(v *Version, str string, allowInfinity bool) (*Version, error) {
+	*v = Version{
 		sys: sys,
 		str: str,
 	}
 	parser := versionParser{
-		Version: version,
+		Version: v,
 		lex: lexer{
 			str:           str,
 			allowInfinity: allowInfinity,
@@ -288,13 +359,21 @@ func (sys System) parse(str string, allowInfinity bool) (*Version, error) {
 // filter out most invalid versions.
 func (sys System) possibleVersionString(str string) bool {
 	switch sys {
-	// Any string can be a Maven version.
-	case Maven:
+	// Any string can be a Maven version. RPM is nearly as lax, and
+	// its parser does the checking.
+	case Maven, RPM:
 		return true
+	// Debian versions start with a digit, which may begin the epoch.
+	case Debian:
+		return len(str) > 0 && isDigit(rune(str[0]))
 	// For NPM, PyPI, Go and Composer, peel off leading v's. For NPM, there can be many.
 	case NPM:
 		str = strings.TrimLeft(str, "v")
 	case PyPI, Composer:
+		// Composer branches, as in dev-main, have no numbers.
+		if sys == Composer && len(str) >= 4 && strings.EqualFold(str[:4], "dev-") {
+			return true
+		}
 		if len(str) > 0 && (str[0] == 'v' || str[0] == 'V') {
 			str = str[1:]
 		}
@@ -436,6 +515,29 @@ func (v *Version) Prerelease() string {
 	return "-" + strings.Join(v.pre, ".")
 }
 
+// PrereleaseIdentifiers returns the dot-separated identifiers of the
+// prerelease tags, if any, without the leading hyphen.
+func (v *Version) PrereleaseIdentifiers() []string {
+	if len(v.pre) == 0 {
+		return nil
+	}
+	return append([]string(nil), v.pre...)
+}
+
+// Build returns the build metadata, if any, including the leading plus sign.
+func (v *Version) Build() string {
+	return v.build
+}
+
+// BuildIdentifiers returns the dot-separated identifiers of the build
+// metadata, if any, without the leading plus sign.
+func (v *Version) BuildIdentifiers() []string {
+	if v.build == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(v.build, "+"), ".")
+}
+
 // version
 //
 //	1
@@ -468,6 +570,12 @@ func (p *versionParser) version() (*Version, error) {
 		return p.mavenVersion()
 	case PyPI:
 		return p.pep440Version()
+	case Debian:
+		return p.debianVersion()
+	case RPM:
+		return p.rpmVersion()
+	case Composer:
+		return p.composerVersion()
 	}
 	// Get rid of leading v's.
 	switch sys {
@@ -479,11 +587,6 @@ func (p *versionParser) version() (*Version, error) {
 		if p.lex.next() != 'v' {
 			p.lex.setErr("Go versions require leading 'v'")
 		}
-	case Composer:
-		if p.lex.peek() == 'v' || p.lex.peek() == 'V' {
-			p.lex.next()
-		}
-
 	}
 	// Semver requires 3 numbers, but we allow 1, 2, or 3 before canonicalization.
 	// In RubyGems, the maximum number of numbers is unbounded.
@@ -529,8 +632,7 @@ func (p *versionParser) version() (*Version, error) {
 		if r != eof {
 			p.isPrerelease = true
 			r = p.metadata(&p.pre, false, "pre-release")
-			l := p.pre[len(p.pre)-1]
-			if l[len(l)-1] != '*' {
+			if n := len(p.pre); n == 0 || !strings.HasSuffix(p.pre[n-1], "*") {
 				p.lex.setErr("missing asterisk at end of prerelease")
 				return nil, p.lex.err
 			}
@@ -625,7 +727,7 @@ func (p *versionParser) number() bool {
 	// NPM also allows them, if by accident.
 	if p.lex.pos > start+1 && p.lex.str[start] == '0' {
 		switch p.Version.sys {
-		case NPM, NuGet, RubyGems, Composer:
+		case NPM, NuGet, RubyGems:
 		default:
 			p.lex.setErr("number has leading zero")
 			return false
@@ -646,7 +748,7 @@ func (p *versionParser) number() bool {
 func (p *versionParser) addNum(v value) bool {
 	if len(p.Version.num) == 3 {
 		switch p.Version.sys {
-		case NuGet, PyPI, RubyGems, Composer:
+		case NuGet, PyPI, RubyGems:
 			// OK
 		default:
 			p.lex.setErr("more than 3 numbers present")
@@ -780,6 +882,59 @@ func (sys System) Compare(str1, str2 string) int {
 	return compare(v1, v2)
 }
 
+// CompareStrings is like Compare, but compares versions made only of one to
+// three numbers, such as "1.2.3", without parsing them, which is the common
+// case when sorting the versions of a package. Other versions are compared
+// by Compare.
+func (sys System) CompareStrings(str1, str2 string) int {
+	if n1, ok := sys.numericVersion(str1); ok {
+		if n2, ok := sys.numericVersion(str2); ok {
+			for i := range n1 {
+				if c := sgnu64(n1[i], n2[i]); c != 0 {
+					return c
+				}
+			}
+			return 0
+		}
+	}
+	return sys.Compare(str1, str2)
+}
+
+// numericVersion returns the numbers of a version made only of one to three
+// dot-separated numbers without leading zeros, missing numbers being zero,
+// and whether the version has that form. Such versions compare as their
+// numbers in every system but those whose versions require a prefix, or
+// whose trailing zeros are significant.
+func (sys System) numericVersion(str string) (nums [3]uint64, ok bool) {
+	switch sys {
+	case Go, Debian, RPM:
+		return nums, false
+	}
+	n, digits := 0, 0
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		switch {
+		case c == '.':
+			if digits == 0 || n == 2 {
+				return nums, false
+			}
+			n++
+			digits = 0
+		case '0' <= c && c <= '9':
+			// Leading zeros are invalid in some systems, and more
+			// than 18 digits may overflow.
+			if (digits == 1 && nums[n] == 0) || digits == 18 {
+				return nums, false
+			}
+			nums[n] = nums[n]*10 + uint64(c-'0')
+			digits++
+		default:
+			return nums, false
+		}
+	}
+	return nums, digits > 0
+}
+
 // Compare compares two versions. See the Compare func for the semantics.
 func (v *Version) Compare(o *Version) int { return compare(v, o) }
 
@@ -1046,6 +1201,12 @@ func (v *Version) newExtension(str string) (extension, error) {
 		return newPEP440Extension(v, str)
 	case RubyGems:
 		return newGemExtension(v, str)
+	case Debian:
+		return newDebianExtension(v, str)
+	case RPM:
+		return newRPMExtension(v, str)
+	case Composer:
+		return newComposerExtension(v, str)
 	}
 	return nil, nil
 }
@@ -1072,19 +1233,138 @@ func (v *Version) copy() *Version {
 // version did not specify an epoch, or the system doesn't support it,
 // 0 and false are returned.
 func (v *Version) Epoch() (int, bool) {
-	if p, ok := v.ext.(*pep440Extension); ok && p.ext != nil {
-		return p.ext.epoch, true
+	switch e := v.ext.(type) {
+	case *pep440Extension:
+		if e.ext != nil {
+			return e.ext.epoch, true
+		}
+	case *debianExtension:
+		if e.epoch >= 0 {
+			return e.epoch, true
+		}
+	case *rpmExtension:
+		if e.epoch >= 0 {
+			return e.epoch, true
+		}
 	}
 	return 0, false
 }
 
 // Major extracts the major from the parsed semantic version. If the
-// version did not specify a major version 0 and false are returned.
+// version did not specify a major version, or it is a wildcard, 0 and
+// false are returned.
 func (v *Version) Major() (int64, bool) {
-	if len(v.num) == 0 {
+	return v.component(nMajor)
+}
+
+// Minor extracts the minor from the parsed semantic version. If the
+// version did not specify a minor version, or it is a wildcard, 0 and
+// false are returned.
+func (v *Version) Minor() (int64, bool) {
+	return v.component(nMinor)
+}
+
+// Patch extracts the patch from the parsed semantic version. If the
+// version did not specify a patch version, or it is a wildcard, 0 and
+// false are returned.
+func (v *Version) Patch() (int64, bool) {
+	return v.component(nPatch)
+}
+
+// component returns the n-th number of the version, if it specifies one.
+func (v *Version) component(n int) (int64, bool) {
+	if m, ok := v.ext.(*mavenExtension); ok {
+		// Maven keeps its numbers in the extension.
+		if x := m.num(n); x >= 0 {
+			return x, true
+		}
+		return 0, false
+	}
+	if len(v.num) <= n || v.num[n] == wildcard {
 		return 0, false
 	}
-	return int64(v.num[0]), true
+	return int64(v.num[n]), true
+}
+
+// Components returns the numbers of the parsed semantic version in order,
+// which may be more than three for some systems. Missing numbers that the
+// system takes to be zero may be included. It returns nil if the version
+// is a wildcard or has no numbers, as is the case for the systems with
+// their own version syntax such as Maven.
+func (v *Version) Components() []int64 {
+	if len(v.num) == 0 || v.IsWildcard() {
+		return nil
+	}
+	nums := make([]int64, len(v.num))
+	for i, n := range v.num {
+		nums[i] = int64(n)
+	}
+	return nums
+}
+
+// NextMajor returns the first release of the next major version, M+1.0.0
+// for a version M.m.p. Prerelease and build information are dropped.
+func (v *Version) NextMajor() (*Version, error) {
+	return v.next(nMajor)
+}
+
+// NextMinor returns the first release of the next minor version, M.m+1.0
+// for a version M.m.p. Prerelease and build information are dropped.
+func (v *Version) NextMinor() (*Version, error) {
+	return v.next(nMinor)
+}
+
+// NextPatch returns the next patch release, M.m.p+1 for a version M.m.p.
+// Prerelease and build information are dropped.
+func (v *Version) NextPatch() (*Version, error) {
+	return v.next(nPatch)
+}
+
+// next returns the version whose n-th number is incremented and the
+// following ones are zero. The result is parsed from its string so that it
+// is represented as any other version of the system.
+func (v *Version) next(n int) (*Version, error) {
+	if v.IsWildcard() {
+		return nil, fmt.Errorf("cannot increment wildcard version %s", v)
+	}
+	switch v.ext.(type) {
+	case *debianExtension, *rpmExtension:
+		// The version has no structure beyond its ordering.
+		return nil, fmt.Errorf("cannot increment %s version %s", v.sys, v)
+	}
+	get := v.getNum
+	if m, ok := v.ext.(*mavenExtension); ok {
+		// Maven keeps its numbers in the extension.
+		if m.num(nMajor) < 0 {
+			return nil, fmt.Errorf("cannot increment version %s: no major number", v)
+		}
+		get = func(i int) value {
+			if n := m.num(i); n >= 0 {
+				return value(n)
+			}
+			return 0
+		}
+	}
+	var nums [3]value
+	for i := 0; i < n; i++ {
+		nums[i] = get(i)
+	}
+	nums[n] = get(n).inc()
+	for _, val := range nums {
+		if val == infinity {
+			return nil, fmt.Errorf("cannot increment version %s: overflow", v)
+		}
+	}
+	str := fmt.Sprintf("%d.%d.%d", nums[nMajor], nums[nMinor], nums[nPatch])
+	switch v.sys {
+	case Go:
+		str = "v" + str
+	case PyPI:
+		if epoch, ok := v.Epoch(); ok && epoch != 0 {
+			str = fmt.Sprintf("%d!%s", epoch, str)
+		}
+	}
+	return v.sys.Parse(str)
 }
 
 // Semver 2.0 states that all prereleases sort before releases,
@@ -1121,5 +1401,11 @@ func (sys System) MinVersion(v *Version) *Version {
 		return pypiMinVersion.copy()
 	case RubyGems:
 		return rubyGemsMinVersion.copy()
+	case Debian:
+		return debianMinVersion.copy()
+	case RPM:
+		return rpmMinVersion.copy()
+	case Composer:
+		return composerMinVersion.copy()
 	}
 }
@@ -518,3 +518,221 @@ func TestParseNumErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestAccessors(t *testing.T) {
+	tests := []struct {
+		sys                 System
+		v                   string
+		major, minor, patch string // Empty if absent.
+		components          string
+		prerelease, build   string // Identifiers joined by spaces.
+		buildMetadata       string
+	}{
+		{NPM, "1.2.3", "1", "2", "3", "1 2 3", "", "", ""},
+		{NPM, "1.2.3-beta.1+build.5", "1", "2", "3", "1 2 3", "beta 1", "build 5", "+build.5"},
+		{NPM, "1", "1", "", "", "1", "", "", ""},
+		{Go, "v1.2.3-pre", "1", "2", "3", "1 2 3", "pre", "", ""},
+		{Cargo, "1.2", "1", "2", "", "1 2", "", "", ""},
+		{PyPI, "1.2rc1", "1", "2", "0", "1 2 0", "rc 1", "", ""},
+		{RubyGems, "1.2.3.4.a", "1", "2", "3", "1 2 3 4", "a", "", ""},
+		{NuGet, "1.2.3.4-Beta", "1", "2", "3", "1 2 3 4", "Beta", "", ""},
+		{NPM, "1.x", "1", "", "", "", "", "", ""},
+		{NPM, "1.2.*", "1", "2", "", "", "", "", ""},
+		{Maven, "1.2.3-alpha", "1", "2", "3", "", "", "", ""},
+		{Maven, "1", "1", "", "", "", "", "", ""},
+		{Maven, "RELEASE", "", "", "", "", "", "", ""},
+	}
+	opt := func(n int64, ok bool) string {
+		if !ok {
+			return ""
+		}
+		return fmt.Sprint(n)
+	}
+	join := func(s []string) string { return strings.Join(s, " ") }
+	for _, test := range tests {
+		v := parseVersion(t, test.sys, test.v)
+		if got := opt(v.Major()); got != test.major {
+			t.Errorf("%s: %q.Major() = %q; want %q", test.sys, test.v, got, test.major)
+		}
+		if got := opt(v.Minor()); got != test.minor {
+			t.Errorf("%s: %q.Minor() = %q; want %q", test.sys, test.v, got, test.minor)
+		}
+		if got := opt(v.Patch()); got != test.patch {
+			t.Errorf("%s: %q.Patch() = %q; want %q", test.sys, test.v, got, test.patch)
+		}
+		if got := strings.Trim(fmt.Sprint(v.Components()), "[]"); got != test.components {
+			t.Errorf("%s: %q.Components() = %q; want %q", test.sys, test.v, got, test.components)
+		}
+		if got := join(v.PrereleaseIdentifiers()); got != test.prerelease {
+			t.Errorf("%s: %q.PrereleaseIdentifiers() = %q; want %q", test.sys, test.v, got, test.prerelease)
+		}
+		if got := join(v.BuildIdentifiers()); got != test.build {
+			t.Errorf("%s: %q.BuildIdentifiers() = %q; want %q", test.sys, test.v, got, test.build)
+		}
+		if got := v.Build(); got != test.buildMetadata {
+			t.Errorf("%s: %q.Build() = %q; want %q", test.sys, test.v, got, test.buildMetadata)
+		}
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		sys                 System
+		v                   string
+		major, minor, patch string
+	}{
+		{NPM, "1.2.3", "2.0.0", "1.3.0", "1.2.4"},
+		{NPM, "1.2.3-beta.1+build", "2.0.0", "1.3.0", "1.2.4"},
+		{NPM, "0.0.9", "1.0.0", "0.1.0", "0.0.10"},
+		{Go, "v1.2.3", "v2.0.0", "v1.3.0", "v1.2.4"},
+		{Cargo, "1.2.3", "2.0.0", "1.3.0", "1.2.4"},
+		{Maven, "1.2", "2.0.0", "1.3.0", "1.2.1"},
+		{Maven, "1.2.3-SNAPSHOT", "2.0.0", "1.3.0", "1.2.4"},
+		{PyPI, "1.2.3.post1", "2.0.0", "1.3.0", "1.2.4"},
+		{PyPI, "1!1.2", "1!2.0.0", "1!1.3.0", "1!1.2.1"},
+		{RubyGems, "1.2.3.4", "2.0.0", "1.3.0", "1.2.4"},
+		{NuGet, "1.2.3.4", "2.0.0", "1.3.0", "1.2.4"},
+	}
+	for _, test := range tests {
+		v, err := test.sys.Parse(test.v)
+		if err != nil {
+			t.Fatalf("%s.Parse(%q): %v", test.sys, test.v, err)
+		}
+		for _, next := range []struct {
+			name string
+			f    func() (*Version, error)
+			want string
+		}{
+			{"NextMajor", v.NextMajor, test.major},
+			{"NextMinor", v.NextMinor, test.minor},
+			{"NextPatch", v.NextPatch, test.patch},
+		} {
+			got, err := next.f()
+			if err != nil {
+				t.Errorf("%s: %q.%s(): %v", test.sys, test.v, next.name, err)
+				continue
+			}
+			want, err := test.sys.Parse(next.want)
+			if err != nil {
+				t.Fatalf("%s.Parse(%q): %v", test.sys, next.want, err)
+			}
+			if got.String() != next.want || got.Compare(want) != 0 {
+				t.Errorf("%s: %q.%s() = %s; want %s", test.sys, test.v, next.name, got, next.want)
+			}
+			if got.Compare(v) <= 0 {
+				t.Errorf("%s: %q.%s() = %s; not greater", test.sys, test.v, next.name, got)
+			}
+		}
+	}
+}
+
+func TestNextVersionError(t *testing.T) {
+	tests := []struct {
+		sys System
+		v   string
+	}{
+		{NPM, "1.x"},
+		{Maven, "alpha"},
+	}
+	for _, test := range tests {
+		v, err := test.sys.Parse(test.v)
+		if err != nil {
+			t.Fatalf("%s.Parse(%q): %v", test.sys, test.v, err)
+		}
+		if got, err := v.NextMajor(); err == nil {
+			t.Errorf("%s: %q.NextMajor() = %s; want error", test.sys, test.v, got)
+		}
+	}
+}
+
+func TestCompareStrings(t *testing.T) {
+	strs := []string{
+		"", "0", "1", "1.0", "1.0.0", "1.2", "1.2.0", "1.2.3", "1.10.0", "1.9.9",
+		"01.2.3", "1.02.3", "1..2", ".1", "1.", "1.2.3.4", "v1.2.3", "1.2.3-rc.1",
+		"999999999999999999", "1000000000000000000", "99999999999999999999.1",
+	}
+	for _, sys := range fuzzSystems {
+		for _, a := range strs {
+			for _, b := range strs {
+				if got, want := sys.CompareStrings(a, b), sys.Compare(a, b); got != want {
+					t.Errorf("%v: CompareStrings(%q, %q) = %d, Compare = %d", sys, a, b, got, want)
+				}
+			}
+		}
+	}
+	if n := testing.AllocsPerRun(1000, func() { NPM.CompareStrings("1.2.3", "1.10.0") }); n != 0 {
+		t.Errorf("CompareStrings of numeric versions: got %v allocs, want 0", n)
+	}
+}
+
+func TestParseInto(t *testing.T) {
+	var v Version
+	for _, sys := range fuzzSystems {
+		for _, str := range fuzzVersions {
+			want, wantErr := sys.Parse(str)
+			err := sys.ParseInto(&v, str)
+			if (err != nil) != (wantErr != nil) {
+				t.Errorf("%v: ParseInto(%q): got error %v, want %v", sys, str, err, wantErr)
+				continue
+			}
+			if err != nil {
+				continue
+			}
+			if v.String() != want.String() || v.Canon(true) != want.Canon(true) || v.Compare(want) != 0 {
+				t.Errorf("%v: ParseInto(%q) = %v, want %v", sys, str, v.Canon(true), want.Canon(true))
+			}
+		}
+	}
+	// The contents of a reused Version are replaced.
+	if err := NPM.ParseInto(&v, "1.2.3-beta.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := NPM.ParseInto(&v, "2.0"); err != nil {
+		t.Fatal(err)
+	}
+	if v.IsPrerelease() || v.Canon(false) != "2.0.0" {
+		t.Errorf("reused Version: got %v", v.Canon(true))
+	}
+	if n := testing.AllocsPerRun(1000, func() { NPM.ParseInto(&v, "1.2.3") }); n != 0 {
+		t.Errorf("ParseInto of a numeric version: got %v allocs, want 0", n)
+	}
+}
+
+var benchVersions = []string{"1.2.3", "1.10.0", "0.9.12", "2.0.0", "1.2.4", "10.0.1", "1.2.3-beta.1", "3.1"}
+
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		for _, s := range benchVersions {
+			NPM.Parse(s)
+		}
+	}
+}
+
+func BenchmarkParseInto(b *testing.B) {
+	b.ReportAllocs()
+	var v Version
+	for n := 0; n < b.N; n++ {
+		for _, s := range benchVersions {
+			NPM.ParseInto(&v, s)
+		}
+	}
+}
+
+func BenchmarkCompare(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		for i, s := range benchVersions {
+			NPM.Compare(s, benchVersions[(i+1)%len(benchVersions)])
+		}
+	}
+}
+
+func BenchmarkCompareStrings(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		for i, s := range benchVersions {
+			NPM.CompareStrings(s, benchVersions[(i+1)%len(benchVersions)])
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package typosquat flags dependencies that may be typosquats: packages whose
+names are close to those of much more popular packages, and so may have
+been installed by mistake, or published to be.
+
+For each dependency, Check asks the deps.dev API for packages with similar
+names, using its GetSimilarlyNamedPackages method, and compares their
+popularity with that of the dependency. A dependency is flagged if a
+similarly named package is far more popular. Popularity is measured by the
+number of packages depending on the default version of a package, as
+returned by GetDependents; the API does not report download counts.
+
+A flagged dependency is not necessarily malicious, nor is an unflagged one
+safe: the findings are a prompt for review.
+*/
+package typosquat
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// Options configure Check. The zero value gives the defaults.
+type Options struct {
+	// Ratio is how many times more dependents a similarly named package
+	// must have than a dependency for the dependency to be flagged. The
+	// default is 100.
+	Ratio float64
+	// MinDependents is the number of dependents a similarly named package
+	// must have for the dependency to be flagged, so that a dependency is
+	// not flagged for resembling a package that is itself little used.
+	// The default is 1000.
+	MinDependents int
+	// Concurrency is the maximum number of dependencies checked at once.
+	// The default is 10.
+	Concurrency int
+}
+
+// Finding is a dependency flagged as a possible typosquat.
+type Finding struct {
+	Package *pb.PackageKey
+	// Dependents is the number of dependents of the dependency.
+	Dependents int
+	// Similar holds the similarly named packages that are much more
+	// popular than the dependency, most popular first.
+	Similar []Similar
+}
+
+// Similar is a package with a name similar to that of a dependency.
+type Similar struct {
+	Package    *pb.PackageKey
+	Dependents int
+}
+
+// Check checks the given dependencies and returns those that may be
+// typosquats, ordered by name. Dependencies unknown to deps.dev are not
+// flagged. If opts is nil, the defaults are used.
+func Check(ctx context.Context, c pb.InsightsClient, deps []*pb.PackageKey, opts *Options) ([]*Finding, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	ch := &checker{
+		client:        c,
+		ratio:         cmp.Or(opts.Ratio, 100),
+		minDependents: cmp.Or(opts.MinDependents, 1000),
+		popularity:    make(map[packageKey]*popularity),
+	}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, cmp.Or(opts.Concurrency, 10))
+		findings []*Finding
+		firstErr error
+	)
+	for _, pk := range deps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			f, err := ch.check(ctx, pk)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				if firstErr == nil {
+					firstErr = err
+				}
+			case f != nil:
+				findings = append(findings, f)
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(findings, func(a, b *Finding) int {
+		return cmp.Or(
+			cmp.Compare(a.Package.GetSystem(), b.Package.GetSystem()),
+			cmp.Compare(a.Package.GetName(), b.Package.GetName()),
+		)
+	})
+	return findings, nil
+}
+
+// packageKey is a comparable package key.
+type packageKey struct {
+	system pb.System
+	name   string
+}
+
+// popularity is the number of dependents of a package, fetched once.
+type popularity struct {
+	once       sync.Once
+	dependents int
+	err        error
+}
+
+type checker struct {
+	client        pb.InsightsClient
+	ratio         float64
+	minDependents int
+
+	mu         sync.Mutex
+	popularity map[packageKey]*popularity
+}
+
+// check returns a finding for the given dependency, or nil if it is not
+// flagged.
+func (ch *checker) check(ctx context.Context, pk *pb.PackageKey) (*Finding, error) {
+	sim, err := ch.client.GetSimilarlyNamedPackages(ctx, &pb.GetSimilarlyNamedPackagesRequest{PackageKey: pk})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("packages similar to %v %s: %w", pk.GetSystem(), pk.GetName(), err)
+	}
+	if len(sim.GetPackages()) == 0 {
+		return nil, nil
+	}
+	n, err := ch.dependents(ctx, pk)
+	if err != nil {
+		return nil, err
+	}
+	f := &Finding{Package: pk, Dependents: n}
+	for _, p := range sim.GetPackages() {
+		spk := p.GetPackageKey()
+		m, err := ch.dependents(ctx, spk)
+		if err != nil {
+			return nil, err
+		}
+		if m >= ch.minDependents && float64(m) >= ch.ratio*float64(n) {
+			f.Similar = append(f.Similar, Similar{Package: spk, Dependents: m})
+		}
+	}
+	if len(f.Similar) == 0 {
+		return nil, nil
+	}
+	slices.SortFunc(f.Similar, func(a, b Similar) int {
+		return cmp.Or(
+			cmp.Compare(b.Dependents, a.Dependents),
+			cmp.Compare(a.Package.GetName(), b.Package.GetName()),
+		)
+	})
+	return f, nil
+}
+
+// dependents returns the number of dependents of the default version of
+// the given package, or zero if the package or its default version is
+// unknown. Each package is looked up once.
+func (ch *checker) dependents(ctx context.Context, pk *pb.PackageKey) (int, error) {
+	k := packageKey{pk.GetSystem(), pk.GetName()}
+	ch.mu.Lock()
+	p, ok := ch.popularity[k]
+	if !ok {
+		p = &popularity{}
+		ch.popularity[k] = p
+	}
+	ch.mu.Unlock()
+	p.once.Do(func() {
+		p.dependents, p.err = ch.fetchDependents(ctx, pk)
+	})
+	return p.dependents, p.err
+}
+
+func (ch *checker) fetchDependents(ctx context.Context, pk *pb.PackageKey) (int, error) {
+	pkg, err := ch.client.GetPackage(ctx, &pb.GetPackageRequest{PackageKey: pk})
+	if status.Code(err) == codes.NotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("package %v %s: %w", pk.GetSystem(), pk.GetName(), err)
+	}
+	var vk *pb.VersionKey
+	for _, v := range pkg.GetVersions() {
+		if v.GetIsDefault() {
+			vk = v.GetVersionKey()
+		}
+	}
+	if vk == nil {
+		return 0, nil
+	}
+	d, err := ch.client.GetDependents(ctx, &pb.GetDependentsRequest{VersionKey: vk})
+	if status.Code(err) == codes.NotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("dependents of %v %s@%s: %w", vk.GetSystem(), vk.GetName(), vk.GetVersion(), err)
+	}
+	return int(d.GetDependentCount()), nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typosquat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// fakeClient serves npm packages whose default version is 1.0.0, with the
+// given dependent counts and similarly named packages.
+type fakeClient struct {
+	pb.InsightsClient
+	dependents map[string]uint32
+	similar    map[string][]string
+	fail       string // A package for which GetPackage fails.
+
+	mu       sync.Mutex
+	packages map[string]int // GetPackage calls by name.
+}
+
+func (c *fakeClient) GetSimilarlyNamedPackages(ctx context.Context, req *pb.GetSimilarlyNamedPackagesRequest, opts ...grpc.CallOption) (*pb.SimilarlyNamedPackages, error) {
+	name := req.GetPackageKey().GetName()
+	if _, ok := c.dependents[name]; !ok {
+		return nil, status.Error(codes.NotFound, "no such package")
+	}
+	r := &pb.SimilarlyNamedPackages{PackageKey: req.GetPackageKey()}
+	for _, s := range c.similar[name] {
+		r.Packages = append(r.Packages, &pb.SimilarlyNamedPackages_Package{
+			PackageKey: &pb.PackageKey{System: pb.System_NPM, Name: s},
+		})
+	}
+	return r, nil
+}
+
+func (c *fakeClient) GetPackage(ctx context.Context, req *pb.GetPackageRequest, opts ...grpc.CallOption) (*pb.Package, error) {
+	name := req.GetPackageKey().GetName()
+	c.mu.Lock()
+	c.packages[name]++
+	c.mu.Unlock()
+	if name == c.fail {
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	if _, ok := c.dependents[name]; !ok {
+		return nil, status.Error(codes.NotFound, "no such package")
+	}
+	return &pb.Package{
+		PackageKey: req.GetPackageKey(),
+		Versions: []*pb.Package_Version{{
+			VersionKey: &pb.VersionKey{System: pb.System_NPM, Name: name, Version: "1.0.0"},
+			IsDefault:  true,
+		}},
+	}, nil
+}
+
+func (c *fakeClient) GetDependents(ctx context.Context, req *pb.GetDependentsRequest, opts ...grpc.CallOption) (*pb.Dependents, error) {
+	return &pb.Dependents{DependentCount: c.dependents[req.GetVersionKey().GetName()]}, nil
+}
+
+func npm(names ...string) []*pb.PackageKey {
+	var pks []*pb.PackageKey
+	for _, n := range names {
+		pks = append(pks, &pb.PackageKey{System: pb.System_NPM, Name: n})
+	}
+	return pks
+}
+
+func newFake() *fakeClient {
+	return &fakeClient{
+		dependents: map[string]uint32{
+			"lodash":   500000,
+			"lodahs":   3,
+			"lodash-x": 2000,
+			"lodas":    900,
+			"react":    300000,
+			"raect":    0,
+			"express":  200000,
+			"expres":   5000,
+			"tiny":     10,
+			"tiny2":    50,
+		},
+		similar: map[string][]string{
+			"lodahs":  {"lodas", "lodash", "lodash-x"},
+			"raect":   {"react", "unknown"},
+			"expres":  {"express"},
+			"tiny":    {"tiny2"},
+			"express": {"expres"},
+		},
+		packages: make(map[string]int),
+	}
+}
+
+func TestCheck(t *testing.T) {
+	c := newFake()
+	got, err := Check(context.Background(), c, npm("raect", "lodahs", "expres", "tiny", "express", "not-found"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// expres has a fortieth of the dependents of express, above the
+	// default ratio; tiny2 has too few dependents to matter; lodas is not
+	// popular enough to flag lodahs.
+	want := []string{
+		"lodahs: lodash(500000) lodash-x(2000)",
+		"raect: react(300000)",
+	}
+	var gotS []string
+	for _, f := range got {
+		s := fmt.Sprintf("%s:", f.Package.GetName())
+		for _, sim := range f.Similar {
+			s += fmt.Sprintf(" %s(%d)", sim.Package.GetName(), sim.Dependents)
+		}
+		gotS = append(gotS, s)
+	}
+	if fmt.Sprint(gotS) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", gotS, want)
+	}
+	for name, n := range c.packages {
+		if n != 1 {
+			t.Errorf("GetPackage called %d times for %s, want once", n, name)
+		}
+	}
+
+	got, err = Check(context.Background(), newFake(), npm("expres", "tiny"), &Options{Ratio: 5, MinDependents: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Package.GetName() != "expres" || got[1].Package.GetName() != "tiny" {
+		t.Errorf("with options: got %v, want expres and tiny", got)
+	}
+
+	c = newFake()
+	c.fail = "react"
+	if _, err := Check(context.Background(), c, npm("raect"), nil); status.Code(errors.Unwrap(err)) != codes.Unavailable {
+		t.Errorf("with failing client: got %v, want Unavailable", err)
+	}
+}
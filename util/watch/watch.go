@@ -0,0 +1,388 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package watch detects changes to the deps.dev data of an inventory of
+package versions between runs, so that a scheduled job can report what is
+new since it last ran.
+
+Take records a Snapshot of the advisories and licenses of each version, and
+the default and published versions of each package, and Compare lists the
+changes between two snapshots. Watch does both, keeping the previous
+snapshot in a file:
+
+	changes, err := watch.Watch(ctx, client, inv.VersionKeys(), "snapshot.json", nil)
+	if err != nil {
+		// ...
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+
+Versions are fetched with the batch package and packages with GetPackage.
+*/
+package watch
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+	"deps.dev/util/batch"
+)
+
+// Snapshot is the state of an inventory's package versions and their
+// packages at a point in time.
+type Snapshot struct {
+	// Taken is the time the snapshot was taken.
+	Taken time.Time `json:"taken"`
+	// Versions holds the versions of the inventory, sorted by system, name
+	// and version.
+	Versions []Version `json:"versions"`
+	// Packages holds the packages of the versions known to deps.dev,
+	// sorted by system and name.
+	Packages []Package `json:"packages"`
+}
+
+// Version is the state of a package version.
+type Version struct {
+	System  string `json:"system"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Found reports whether the version is known to deps.dev.
+	Found bool `json:"found"`
+	// Advisories holds the IDs of the advisories affecting the version,
+	// sorted.
+	Advisories []string `json:"advisories,omitempty"`
+	// Licenses holds the licenses of the version, sorted.
+	Licenses []string `json:"licenses,omitempty"`
+}
+
+// Package is the state of a package.
+type Package struct {
+	System string `json:"system"`
+	Name   string `json:"name"`
+	// Default is the default version of the package, if it has one.
+	Default string `json:"default,omitempty"`
+	// Versions holds the published versions of the package, sorted as
+	// strings.
+	Versions []string `json:"versions"`
+}
+
+// Kind is a kind of change.
+type Kind int
+
+const (
+	// NewAdvisory is an advisory newly affecting a version.
+	NewAdvisory Kind = iota
+	// LicenseChange is a change to the licenses of a version.
+	LicenseChange
+	// NewDefault is a change to the default version of a package.
+	NewDefault
+	// NewVersion is a newly published version of a package.
+	NewVersion
+)
+
+var kindNames = [...]string{"NEW_ADVISORY", "LICENSE_CHANGE", "NEW_DEFAULT", "NEW_VERSION"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// MarshalText implements encoding.TextMarshaler, so that kinds are encoded
+// by name.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Change is a change between two snapshots.
+type Change struct {
+	Kind   Kind   `json:"kind"`
+	System string `json:"system"`
+	Name   string `json:"name"`
+	// Version is the version changed, for NewAdvisory and LicenseChange.
+	Version string `json:"version,omitempty"`
+	// Old and New describe the change: the advisory ID for NewAdvisory,
+	// the licenses joined by " AND " for LicenseChange, the default
+	// versions for NewDefault and the version published for NewVersion.
+	// Old is empty for NewAdvisory and NewVersion.
+	Old string `json:"old,omitempty"`
+	New string `json:"new"`
+}
+
+func (c Change) String() string {
+	pkg := fmt.Sprintf("%s %s", c.System, c.Name)
+	if c.Version != "" {
+		pkg += "@" + c.Version
+	}
+	switch c.Kind {
+	case NewAdvisory:
+		return fmt.Sprintf("%s: new advisory %s", pkg, c.New)
+	case LicenseChange:
+		return fmt.Sprintf("%s: license changed from %q to %q", pkg, c.Old, c.New)
+	case NewDefault:
+		return fmt.Sprintf("%s: default version changed from %s to %s", pkg, c.Old, c.New)
+	case NewVersion:
+		return fmt.Sprintf("%s: version %s published", pkg, c.New)
+	}
+	return fmt.Sprintf("%s: %v %s", pkg, c.Kind, c.New)
+}
+
+// Options configure Take and Watch.
+type Options struct {
+	// Batch configures the fetching of the versions.
+	Batch *batch.Options
+	// Concurrency is the maximum number of packages fetched at once. The
+	// default is 10.
+	Concurrency int
+}
+
+// Take returns a snapshot of the given versions and their packages. If opts
+// is nil, the defaults are used.
+func Take(ctx context.Context, c pb.InsightsClient, vks []*pb.VersionKey, opts *Options) (*Snapshot, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 10
+	}
+	s := &Snapshot{Taken: time.Now().UTC()}
+	type pkgKey struct {
+		system pb.System
+		name   string
+	}
+	var pkgs []pkgKey
+	seen := make(map[pkgKey]bool)
+	err := batch.NewRunner(c, o.Batch).Run(ctx, vks, func(resp *pb.VersionBatch_Response) error {
+		vk := resp.GetRequest().GetVersionKey()
+		v := Version{System: vk.GetSystem().String(), Name: vk.GetName(), Version: vk.GetVersion()}
+		if pv := resp.GetVersion(); pv != nil {
+			v.Found = true
+			for _, ak := range pv.GetAdvisoryKeys() {
+				v.Advisories = append(v.Advisories, ak.GetId())
+			}
+			v.Licenses = slices.Clone(pv.GetLicenses())
+			slices.Sort(v.Advisories)
+			slices.Sort(v.Licenses)
+			if k := (pkgKey{vk.GetSystem(), vk.GetName()}); !seen[k] {
+				seen[k] = true
+				pkgs = append(pkgs, k)
+			}
+		}
+		s.Versions = append(s.Versions, v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, o.Concurrency)
+		errs []error
+	)
+	for _, k := range pkgs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			p, err := c.GetPackage(ctx, &pb.GetPackageRequest{
+				PackageKey: &pb.PackageKey{System: k.system, Name: k.name},
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case status.Code(err) == codes.NotFound:
+			case err != nil:
+				errs = append(errs, fmt.Errorf("package %v %s: %w", k.system, k.name, err))
+			default:
+				pkg := Package{System: k.system.String(), Name: k.name}
+				for _, pv := range p.GetVersions() {
+					v := pv.GetVersionKey().GetVersion()
+					pkg.Versions = append(pkg.Versions, v)
+					if pv.GetIsDefault() {
+						pkg.Default = v
+					}
+				}
+				slices.Sort(pkg.Versions)
+				s.Packages = append(s.Packages, pkg)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(s.Versions, func(a, b Version) int {
+		return cmp.Or(cmp.Compare(a.System, b.System), cmp.Compare(a.Name, b.Name), cmp.Compare(a.Version, b.Version))
+	})
+	s.Versions = slices.CompactFunc(s.Versions, func(a, b Version) bool {
+		return a.System == b.System && a.Name == b.Name && a.Version == b.Version
+	})
+	slices.SortFunc(s.Packages, func(a, b Package) int {
+		return cmp.Or(cmp.Compare(a.System, b.System), cmp.Compare(a.Name, b.Name))
+	})
+	return s, nil
+}
+
+// Compare returns the changes from the old snapshot to the new one, sorted
+// by system, name, version and kind. Only the versions and packages present
+// in both snapshots are compared, so that adding a version to the inventory
+// does not report its existing advisories as new.
+func Compare(old, new *Snapshot) []Change {
+	var changes []Change
+	oldVersions := make(map[[3]string]Version)
+	for _, v := range old.Versions {
+		oldVersions[[3]string{v.System, v.Name, v.Version}] = v
+	}
+	for _, v := range new.Versions {
+		o, ok := oldVersions[[3]string{v.System, v.Name, v.Version}]
+		if !ok || !o.Found || !v.Found {
+			continue
+		}
+		for _, id := range v.Advisories {
+			if !slices.Contains(o.Advisories, id) {
+				changes = append(changes, Change{Kind: NewAdvisory, System: v.System, Name: v.Name, Version: v.Version, New: id})
+			}
+		}
+		if !slices.Equal(o.Licenses, v.Licenses) {
+			changes = append(changes, Change{
+				Kind:    LicenseChange,
+				System:  v.System,
+				Name:    v.Name,
+				Version: v.Version,
+				Old:     strings.Join(o.Licenses, " AND "),
+				New:     strings.Join(v.Licenses, " AND "),
+			})
+		}
+	}
+	oldPackages := make(map[[2]string]Package)
+	for _, p := range old.Packages {
+		oldPackages[[2]string{p.System, p.Name}] = p
+	}
+	for _, p := range new.Packages {
+		o, ok := oldPackages[[2]string{p.System, p.Name}]
+		if !ok {
+			continue
+		}
+		if p.Default != o.Default {
+			changes = append(changes, Change{Kind: NewDefault, System: p.System, Name: p.Name, Old: o.Default, New: p.Default})
+		}
+		for _, v := range p.Versions {
+			if _, found := slices.BinarySearch(o.Versions, v); !found {
+				changes = append(changes, Change{Kind: NewVersion, System: p.System, Name: p.Name, New: v})
+			}
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b Change) int {
+		return cmp.Or(
+			cmp.Compare(a.System, b.System),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Version, b.Version),
+			cmp.Compare(a.Kind, b.Kind),
+		)
+	})
+	return changes
+}
+
+// Read reads a snapshot written by Write.
+func Read(name string) (*Snapshot, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing snapshot %s: %w", name, err)
+	}
+	return &s, nil
+}
+
+// Write writes the snapshot to the named file as JSON. The file is
+// replaced atomically, so that an interruption leaves either the old or the
+// new snapshot.
+func (s *Snapshot) Write(name string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("saving snapshot: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("saving snapshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("saving snapshot: %w", err)
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("saving snapshot: %w", err)
+	}
+	return nil
+}
+
+// Watch takes a snapshot of the given versions, compares it with the one
+// held in the named file, if it exists, and replaces the file with the new
+// snapshot. It returns the changes since the previous snapshot, or none on
+// the first run. If opts is nil, the defaults are used.
+func Watch(ctx context.Context, c pb.InsightsClient, vks []*pb.VersionKey, name string, opts *Options) ([]Change, error) {
+	old, err := Read(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	s, err := Take(ctx, c, vks, opts)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	if old != nil {
+		changes = Compare(old, s)
+	}
+	if err := s.Write(name); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
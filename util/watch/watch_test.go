@@ -0,0 +1,144 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "deps.dev/api/v3alpha"
+)
+
+// fakeClient serves versions and packages from fixed data, keyed by name
+// and by name@version.
+type fakeClient struct {
+	pb.InsightsClient
+	versions map[string]*pb.Version
+	packages map[string]*pb.Package
+}
+
+func (c *fakeClient) GetVersionBatch(ctx context.Context, req *pb.GetVersionBatchRequest, opts ...grpc.CallOption) (*pb.VersionBatch, error) {
+	b := &pb.VersionBatch{}
+	for _, r := range req.GetRequests() {
+		vk := r.GetVersionKey()
+		b.Responses = append(b.Responses, &pb.VersionBatch_Response{
+			Request: r,
+			Version: c.versions[vk.GetName()+"@"+vk.GetVersion()],
+		})
+	}
+	return b, nil
+}
+
+func (c *fakeClient) GetPackage(ctx context.Context, req *pb.GetPackageRequest, opts ...grpc.CallOption) (*pb.Package, error) {
+	p, ok := c.packages[req.GetPackageKey().GetName()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such package")
+	}
+	return p, nil
+}
+
+func vk(name, version string) *pb.VersionKey {
+	return &pb.VersionKey{System: pb.System_NPM, Name: name, Version: version}
+}
+
+func version(name, v string, licenses []string, advisories ...string) *pb.Version {
+	pv := &pb.Version{VersionKey: vk(name, v), Licenses: licenses}
+	for _, id := range advisories {
+		pv.AdvisoryKeys = append(pv.AdvisoryKeys, &pb.AdvisoryKey{Id: id})
+	}
+	return pv
+}
+
+func pkg(name, def string, versions ...string) *pb.Package {
+	p := &pb.Package{PackageKey: &pb.PackageKey{System: pb.System_NPM, Name: name}}
+	for _, v := range versions {
+		p.Versions = append(p.Versions, &pb.Package_Version{VersionKey: vk(name, v), IsDefault: v == def})
+	}
+	return p
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+	name := filepath.Join(t.TempDir(), "snapshot.json")
+	mit := []string{"MIT"}
+	c := &fakeClient{
+		versions: map[string]*pb.Version{
+			"alice@1.0.0": version("alice", "1.0.0", mit),
+			"bob@2.0.0":   version("bob", "2.0.0", mit, "GHSA-old"),
+		},
+		packages: map[string]*pb.Package{
+			"alice": pkg("alice", "1.0.0", "1.0.0"),
+			"bob":   pkg("bob", "2.0.0", "2.0.0"),
+		},
+	}
+	vks := []*pb.VersionKey{vk("alice", "1.0.0"), vk("bob", "2.0.0"), vk("missing", "1.0.0")}
+	changes, err := Watch(ctx, c, vks, name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("first run: got changes %v, want none", changes)
+	}
+
+	// alice gains an advisory and a new default version; bob is
+	// relicensed and gets a prerelease; carol is new to the inventory and
+	// its advisory is not reported.
+	c.versions["alice@1.0.0"] = version("alice", "1.0.0", mit, "GHSA-alice")
+	c.packages["alice"] = pkg("alice", "1.1.0", "1.0.0", "1.1.0")
+	c.versions["bob@2.0.0"] = version("bob", "2.0.0", []string{"Apache-2.0", "MIT"}, "GHSA-old")
+	c.packages["bob"] = pkg("bob", "2.0.0", "2.0.0", "3.0.0-rc.1")
+	c.versions["carol@1.0.0"] = version("carol", "1.0.0", mit, "GHSA-carol")
+	c.packages["carol"] = pkg("carol", "1.0.0", "1.0.0")
+	vks = append(vks, vk("carol", "1.0.0"))
+	changes, err = Watch(ctx, c, vks, name, &Options{Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Kind: NewDefault, System: "NPM", Name: "alice", Old: "1.0.0", New: "1.1.0"},
+		{Kind: NewVersion, System: "NPM", Name: "alice", New: "1.1.0"},
+		{Kind: NewAdvisory, System: "NPM", Name: "alice", Version: "1.0.0", New: "GHSA-alice"},
+		{Kind: NewVersion, System: "NPM", Name: "bob", New: "3.0.0-rc.1"},
+		{Kind: LicenseChange, System: "NPM", Name: "bob", Version: "2.0.0", Old: "MIT", New: "Apache-2.0 AND MIT"},
+	}
+	if diff := cmp.Diff(want, changes); diff != "" {
+		t.Errorf("second run (-want +got):\n%s", diff)
+	}
+	if got, want := changes[2].String(), "NPM alice@1.0.0: new advisory GHSA-alice"; got != want {
+		t.Errorf("String: got %q, want %q", got, want)
+	}
+
+	// Nothing has changed since the last run.
+	changes, err = Watch(ctx, c, vks, name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("third run: got changes %v, want none", changes)
+	}
+	s, err := Read(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Versions) != 4 || len(s.Packages) != 3 || s.Versions[3].Found {
+		t.Errorf("snapshot: got %d versions and %d packages, want 4 and 3 with missing not found", len(s.Versions), len(s.Packages))
+	}
+}